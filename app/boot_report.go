@@ -0,0 +1,200 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+)
+
+// DefaultSlowProviderThreshold 服务提供者单个阶段超过该耗时时，启动报告给出警告
+const DefaultSlowProviderThreshold = time.Second
+
+// 启动阶段
+const (
+	BootStageConfig   = "config"   // 验证与加载服务提供者的配置段
+	BootStageRegister = "register" // 服务提供者 Register
+	BootStageBoot     = "boot"     // 服务提供者 Boot
+	BootStageStartup  = "startup"  // 引擎的启动任务，见 flow.Engine.AddStartupTask
+)
+
+// 阶段状态
+const (
+	BootStatusOK      = "ok"
+	BootStatusFailed  = "failed"
+	BootStatusSkipped = "skipped"
+)
+
+// BootPhase 启动过程中的一个阶段
+type BootPhase struct {
+	Stage    string        `json:"stage"`
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// BootReport 应用启动报告，启动完成时输出一次，也可通过 Application.BootReport 获取
+type BootReport struct {
+	App         string         `json:"app"`
+	Version     string         `json:"version"`
+	Build       flow.BuildInfo `json:"build"`
+	Environment string         `json:"environment"`
+	Hostname    string         `json:"hostname"`
+	StartedAt   time.Time      `json:"started_at"`
+	Duration    time.Duration  `json:"duration"`
+	Phases      []BootPhase    `json:"phases"`
+	Routes      int            `json:"routes"`
+	Listeners   []string       `json:"listeners,omitempty"`
+	Features    []string       `json:"features,omitempty"`
+	Warnings    []string       `json:"warnings,omitempty"`
+}
+
+// SetSlowProviderThreshold 设置慢服务提供者的警告阈值，默认为 DefaultSlowProviderThreshold，小于等于0时不警告
+func (a *Application) SetSlowProviderThreshold(d time.Duration) {
+	a.bootMu.Lock()
+	defer a.bootMu.Unlock()
+	a.slowProviderThreshold = d
+}
+
+// ReportFeature 记录已启用的功能，例如 "docs"、"admin"，显示在启动报告中
+//
+// 仅限开发的功能使用 ReportDevFeature，生产环境中会给出警告
+func (a *Application) ReportFeature(name string) {
+	a.bootMu.Lock()
+	defer a.bootMu.Unlock()
+	for _, f := range a.features {
+		if f == name {
+			return
+		}
+	}
+	a.features = append(a.features, name)
+}
+
+// recordPhase 记录一个启动阶段
+func (a *Application) recordPhase(stage, name string, duration time.Duration, err error) {
+	phase := BootPhase{Stage: stage, Name: name, Duration: duration, Status: BootStatusOK}
+	if err != nil {
+		phase.Status = BootStatusFailed
+		phase.Error = err.Error()
+	}
+	a.bootMu.Lock()
+	defer a.bootMu.Unlock()
+	a.bootPhases = append(a.bootPhases, phase)
+}
+
+// recordSkipped 记录因运行环境跳过的服务提供者
+func (a *Application) recordSkipped(name string) {
+	a.bootMu.Lock()
+	defer a.bootMu.Unlock()
+	a.bootPhases = append(a.bootPhases, BootPhase{Stage: BootStageRegister, Name: name, Status: BootStatusSkipped})
+}
+
+// finishBoot 记录启动完成的时间
+func (a *Application) finishBoot() {
+	a.bootMu.Lock()
+	defer a.bootMu.Unlock()
+	a.bootFinished = time.Now()
+}
+
+// BootReport 返回启动报告：各阶段耗时与状态、路由数、监听地址、环境、已启用的功能，
+// 以及其他启动检查给出的警告（生产环境中的开发功能、慢服务提供者、重复的依赖与重叠的路由）
+//
+// 启动完成前调用时返回截至调用时的内容
+func (a *Application) BootReport() BootReport {
+	report := BootReport{
+		App:         a.environment.AppName,
+		Version:     a.environment.AppVersion,
+		Build:       flow.ReadBuildInfo(),
+		Environment: a.environment.AppEnv,
+		Hostname:    a.environment.Hostname,
+		StartedAt:   a.bootStartTime,
+	}
+
+	a.bootMu.Lock()
+	report.Phases = append([]BootPhase(nil), a.bootPhases...)
+	features := append([]string(nil), a.features...)
+	threshold := a.slowProviderThreshold
+	finished := a.bootFinished
+	a.bootMu.Unlock()
+
+	if finished.IsZero() {
+		finished = time.Now()
+	}
+	report.Duration = finished.Sub(a.bootStartTime)
+	report.Features = append(features, a.DevFeatures()...)
+
+	for _, phase := range report.Phases {
+		if threshold > 0 && phase.Duration > threshold && (phase.Stage == BootStageRegister || phase.Stage == BootStageBoot) {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("服务提供者 %s 的 %s 耗时 %s，超过 %s",
+				phase.Name, phase.Stage, phase.Duration.Round(time.Millisecond), threshold))
+		}
+	}
+	if a.environment.IsProduction() {
+		for _, feature := range a.DevFeatures() {
+			report.Warnings = append(report.Warnings, "生产环境启用了仅限开发的功能: "+feature)
+		}
+	}
+
+	if a.engine != nil {
+		for _, task := range a.engine.StartupTasks() {
+			phase := BootPhase{Stage: BootStageStartup, Name: task.Name, Duration: task.Duration, Status: BootStatusOK}
+			if task.Err != nil {
+				phase.Status, phase.Error = BootStatusFailed, task.Err.Error()
+			}
+			report.Phases = append(report.Phases, phase)
+		}
+		report.Routes = len(a.engine.RouteList())
+		report.Listeners = a.engine.ListenAddrs()
+		for _, dup := range a.engine.DI().Duplicates() {
+			report.Warnings = append(report.Warnings, dup.Error())
+		}
+		for _, conflict := range a.engine.RouteConflicts() {
+			report.Warnings = append(report.Warnings, conflict.Error())
+		}
+	}
+	return report
+}
+
+// Table 以表格形式输出启动报告，用于开发环境的终端
+func (r BootReport) Table() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s · flow %s · 环境 %s · 耗时 %s\n",
+		r.App, r.Version, r.Build, r.Environment, r.Duration.Round(time.Millisecond))
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  阶段\t名称\t耗时\t状态")
+	for _, phase := range r.Phases {
+		status := phase.Status
+		if phase.Error != "" {
+			status += ": " + phase.Error
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", phase.Stage, phase.Name, phase.Duration.Round(time.Microsecond), status)
+	}
+	_ = w.Flush()
+
+	fmt.Fprintf(&b, "  路由: %d", r.Routes)
+	if len(r.Listeners) > 0 {
+		fmt.Fprintf(&b, "  监听: %s", strings.Join(r.Listeners, ", "))
+	}
+	b.WriteString("\n")
+	if len(r.Features) > 0 {
+		fmt.Fprintf(&b, "  功能: %s\n", strings.Join(r.Features, ", "))
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(&b, "  ⚠ %s\n", warning)
+	}
+	return b.String()
+}
+
+// logBootReport 输出启动报告：日志格式为 json 的环境（预发布、生产）输出一条结构化日志，其他环境输出表格
+func (a *Application) logBootReport() {
+	report := a.BootReport()
+	if a.environment.Defaults.LogFormat == "json" {
+		a.logger.WithField("boot_report", report).Info("应用启动完成")
+		return
+	}
+	a.logger.Info("应用启动完成\n" + report.Table())
+}
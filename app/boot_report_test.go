@@ -0,0 +1,142 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+)
+
+// sleepyProvider Boot 耗时固定的测试提供者
+type sleepyProvider struct {
+	*BaseProvider
+	delay time.Duration
+}
+
+func (p *sleepyProvider) Boot(app *Application) error {
+	time.Sleep(p.delay)
+	return nil
+}
+
+// phaseKeys 返回各阶段的 "stage name status"
+func phaseKeys(report BootReport) []string {
+	keys := make([]string, len(report.Phases))
+	for i, phase := range report.Phases {
+		keys[i] = phase.Stage + " " + phase.Name + " " + phase.Status
+	}
+	return keys
+}
+
+func TestBootReport_Completeness(t *testing.T) {
+	application, _ := newEnvTestApp(t, "test")
+	application.Engine().GET("/users", func(c *flow.Context) {})
+	application.Engine().POST("/users", func(c *flow.Context) {})
+	application.ReportFeature("docs")
+	application.ReportFeature("docs")
+	require.NoError(t, application.RegisterProviders([]ServiceProvider{
+		&sleepyProvider{BaseProvider: NewBaseProvider("cache", 10)},
+		NewBaseProvider("mailer", 20),
+		NewBaseProvider("dev_tools", 30).OnlyInEnvironments("dev"),
+	}))
+	require.NoError(t, application.Boot())
+
+	report := application.BootReport()
+	assert.Equal(t, "testing", report.Environment)
+	assert.Equal(t, flow.Version, report.Build.Version)
+	assert.Equal(t, []string{
+		"register dev_tools skipped",
+		"config 服务提供者配置 ok",
+		"register cache ok",
+		"boot cache ok",
+		"register mailer ok",
+		"boot mailer ok",
+	}, phaseKeys(report))
+	assert.Equal(t, 2, report.Routes)
+	assert.Equal(t, "docs", report.Features[0])
+	assert.Contains(t, report.Features, "调试运行模式 (mode=debug)", "包含仅限开发的功能")
+	assert.Empty(t, report.Warnings)
+	assert.Positive(t, report.Duration)
+
+	// 启动完成后报告不再变化
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, report.Duration, application.BootReport().Duration)
+}
+
+func TestBootReport_Warnings(t *testing.T) {
+	application, _ := newEnvTestApp(t, "production")
+	application.SetSlowProviderThreshold(10 * time.Millisecond)
+	application.ReportDevFeature("调试端点 (/_debug)")
+	require.NoError(t, application.RegisterProviders([]ServiceProvider{
+		&sleepyProvider{BaseProvider: NewBaseProvider("search", 10), delay: 30 * time.Millisecond},
+		NewBaseProvider("fast", 20),
+	}))
+	require.NoError(t, application.Boot())
+
+	warnings := application.BootReport().Warnings
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "服务提供者 search 的 boot 耗时")
+	assert.Equal(t, "生产环境启用了仅限开发的功能: 调试端点 (/_debug)", warnings[1])
+
+	// 阈值小于等于0时不警告
+	application.SetSlowProviderThreshold(0)
+	assert.Len(t, application.BootReport().Warnings, 1)
+}
+
+func TestBootReport_FormatByEnvironment(t *testing.T) {
+	// 生产环境输出一条结构化日志
+	application, logs := newEnvTestApp(t, "production")
+	require.NoError(t, application.RegisterProvider(NewBaseProvider("cache", 10)))
+	require.NoError(t, application.Boot())
+
+	var entry struct {
+		Msg    string     `json:"msg"`
+		Report BootReport `json:"boot_report"`
+	}
+	var found bool
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if strings.Contains(line, "boot_report") {
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			found = true
+		}
+	}
+	require.True(t, found, logs.String())
+	assert.Equal(t, "应用启动完成", entry.Msg)
+	assert.Equal(t, "production", entry.Report.Environment)
+	assert.Contains(t, phaseKeys(entry.Report), "boot cache ok")
+
+	// 开发环境输出表格
+	application, logs = newEnvTestApp(t, "development")
+	require.NoError(t, application.RegisterProvider(NewBaseProvider("cache", 10)))
+	require.NoError(t, application.Boot())
+	assert.Contains(t, logs.String(), "阶段")
+	assert.Regexp(t, `boot\s+cache\s+\S+\s+ok`, logs.String())
+	assert.NotContains(t, logs.String(), "boot_report")
+}
+
+func TestBootReport_Run(t *testing.T) {
+	t.Setenv("FLOW_ENV", "test")
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	e := flow.New(flow.WithMode("test"))
+	e.GET("/ping", func(c *flow.Context) { c.String(http.StatusOK, "pong") })
+	application := New(e)
+	require.NoError(t, application.RegisterProvider(NewBaseProvider("cache", 10)))
+
+	addr := freeAddr(t)
+	done := make(chan error, 1)
+	go func() { done <- application.Run(addr) }()
+	require.Eventually(t, e.Ready, 2*time.Second, 10*time.Millisecond)
+
+	// 就绪后包含监听地址与启动任务
+	report := application.BootReport()
+	assert.Equal(t, []string{addr}, report.Listeners)
+	assert.Contains(t, phaseKeys(report), "startup 服务提供者 ok")
+	assert.Contains(t, phaseKeys(report), "boot cache ok")
+
+	require.NoError(t, application.Shutdown(time.Second))
+	assert.NoError(t, <-done)
+}
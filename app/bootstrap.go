@@ -1,6 +1,10 @@
 package app
 
 import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"reflect"
@@ -8,6 +12,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/config"
 )
 
 // Application 是Flow应用容器
@@ -19,6 +24,17 @@ type Application struct {
 	providerManager *ProviderManager  // 服务提供者管理器
 	logger          *logrus.Logger    // 日志记录器
 	bootStartTime   time.Time         // 启动开始时间
+	maintenance     MaintenanceStore  // 维护模式状态存储
+	devFeatures     []string          // 已启用的仅限开发的功能
+	devFeaturesMu   sync.Mutex
+
+	// 启动报告
+	bootMu                sync.Mutex
+	bootPhases            []BootPhase
+	bootFinished          time.Time
+	features              []string
+	slowProviderThreshold time.Duration
+	running               atomic.Bool // 通过 Run 启动时在就绪后输出启动报告
 }
 
 // 控制器接口，用于自动注册路由
@@ -37,8 +53,25 @@ func New(engine *flow.Engine) *Application {
 		providerManager: NewProviderManager(),
 		logger:          logrus.New(),
 		bootStartTime:   time.Now(),
+
+		slowProviderThreshold: DefaultSlowProviderThreshold,
+	}
+
+	// 未设置 FLOW_ENV 时使用配置中的 app.env，并同步到 FLOW_ENV 供种子数据等组件读取
+	if os.Getenv("FLOW_ENV") == "" {
+		_ = engine.Invoke(func(cm *config.ConfigManager) {
+			if env := cm.GetString("app.env"); env != "" {
+				app.environment.SetAppEnv(env)
+				os.Setenv("FLOW_ENV", app.environment.AppEnv)
+			}
+		})
 	}
 
+	// 注入环境信息，服务可通过容器获取 *app.Environment
+	_ = engine.Provide(func() *Environment {
+		return app.environment
+	})
+
 	// 初始化应用
 	app.initialize()
 
@@ -50,11 +83,25 @@ func New(engine *flow.Engine) *Application {
 
 // initialize 初始化应用
 func (a *Application) initialize() {
+	a.applyEnvironment()
+
+	// 注册默认钩子
+	a.registerDefaultHooks()
+}
+
+// applyEnvironment 按当前环境应用日志与运行模式等默认设置
+func (a *Application) applyEnvironment() {
 	// 设置日志格式
-	a.logger.SetFormatter(&logrus.TextFormatter{
-		TimestampFormat: "2006-01-02 15:04:05",
-		FullTimestamp:   true,
-	})
+	if a.environment.Defaults.LogFormat == "json" {
+		a.logger.SetFormatter(&logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339,
+		})
+	} else {
+		a.logger.SetFormatter(&logrus.TextFormatter{
+			TimestampFormat: "2006-01-02 15:04:05",
+			FullTimestamp:   true,
+		})
+	}
 
 	// 根据环境设置日志级别
 	if a.environment.Debug {
@@ -63,21 +110,69 @@ func (a *Application) initialize() {
 		a.logger.SetLevel(logrus.InfoLevel)
 	}
 
-	// 注册默认钩子
-	a.registerDefaultHooks()
+	// 生产环境下未显式指定运行模式时切换到release
+	if a.environment.IsProduction() && a.engine != nil && a.engine.IsDebug() &&
+		os.Getenv("FLOW_MODE") == "" && os.Getenv("GIN_MODE") == "" {
+		flow.WithMode("release")(a.engine)
+	}
+}
+
+// SetEnvironment 切换应用环境，重新应用默认设置，并同步到 FLOW_ENV 供其他组件读取
+func (a *Application) SetEnvironment(env string) {
+	a.environment.SetAppEnv(env)
+	os.Setenv("FLOW_ENV", a.environment.AppEnv)
+	a.applyEnvironment()
+}
+
+// ReportDevFeature 记录已启用的仅限开发的功能，生产环境启动时会输出警告
+func (a *Application) ReportDevFeature(name string) {
+	a.devFeaturesMu.Lock()
+	defer a.devFeaturesMu.Unlock()
+	for _, f := range a.devFeatures {
+		if f == name {
+			return
+		}
+	}
+	a.devFeatures = append(a.devFeatures, name)
+}
+
+// DevFeatures 获取已启用的仅限开发的功能
+func (a *Application) DevFeatures() []string {
+	a.devFeaturesMu.Lock()
+	defer a.devFeaturesMu.Unlock()
+
+	features := make([]string, 0, len(a.devFeatures)+2)
+	if a.engine != nil && a.engine.IsDebug() {
+		features = append(features, "调试运行模式 (mode=debug)")
+	}
+	if a.environment.Debug && !a.environment.IsDevelopment() {
+		features = append(features, "调试日志 (FLOW_DEBUG)")
+	}
+	return append(features, a.devFeatures...)
+}
+
+// warnDevFeatures 生产环境中对仍启用的开发功能输出警告
+func (a *Application) warnDevFeatures() {
+	if !a.environment.IsProduction() {
+		return
+	}
+	for _, feature := range a.DevFeatures() {
+		a.logger.Warnf("生产环境启用了仅限开发的功能: %s", feature)
+	}
 }
 
 // registerDefaultHooks 注册默认钩子
 func (a *Application) registerDefaultHooks() {
-	// 启动前钩子 - 打印环境信息
-	a.hooks.RegisterBeforeStart("print_environment", func() {
-		a.logger.Info("应用环境信息:\n", a.environment.Summary())
-	}, 10)
+	// 启动后钩子 - 检查生产环境中遗留的开发功能
+	a.hooks.RegisterAfterStart("warn_dev_features", a.warnDevFeatures, 5)
 
-	// 启动后钩子 - 打印启动时间
-	a.hooks.RegisterAfterStart("print_boot_time", func() {
-		bootTime := time.Since(a.bootStartTime)
-		a.logger.Infof("应用启动完成，耗时: %s", bootTime)
+	// 启动后钩子 - 输出启动报告，通过 Run 启动时改为在所有启动任务完成后输出
+	a.hooks.RegisterAfterStart("boot_report", func() {
+		if a.running.Load() {
+			return
+		}
+		a.finishBoot()
+		a.logBootReport()
 	}, 10)
 
 	// 关闭前钩子 - 打印关闭提示
@@ -107,14 +202,14 @@ func (a *Application) Logger() *logrus.Logger {
 	return a.logger
 }
 
-// RegisterProvider 注册服务提供者
-func (a *Application) RegisterProvider(provider ServiceProvider) {
-	a.providerManager.Register(provider)
+// RegisterProvider 注册服务提供者，同名的提供者已注册时返回 *DuplicateProviderError，Boot 时同样返回该错误
+func (a *Application) RegisterProvider(provider ServiceProvider) error {
+	return a.providerManager.Register(provider)
 }
 
 // RegisterProviders 注册多个服务提供者
-func (a *Application) RegisterProviders(providers []ServiceProvider) {
-	a.providerManager.RegisterAll(providers)
+func (a *Application) RegisterProviders(providers []ServiceProvider) error {
+	return a.providerManager.RegisterAll(providers)
 }
 
 // RegisterHook 注册应用钩子
@@ -137,18 +232,44 @@ func (a *Application) Boot() error {
 		return err
 	}
 
+	// 调试模式下在监听之前检查处理函数的依赖
+	if a.engine != nil && a.engine.IsDebug() {
+		if err := a.VerifyDependencies(); err != nil {
+			return err
+		}
+	}
+
 	// 执行启动后钩子
 	a.hooks.Execute(HookAfterStart)
 
 	return nil
 }
 
+// VerifyDependencies 检查通过 flow.H2 注册的处理函数以及 functions 的依赖是否都已注册，
+// 一次返回所有缺少的类型。调试模式下 Boot 会自动执行，其他模式可在启动前显式调用
+func (a *Application) VerifyDependencies(functions ...interface{}) error {
+	if a.engine == nil {
+		return nil
+	}
+	return a.engine.VerifyDependencies(functions...)
+}
+
 // Run 运行应用
+//
+// 先监听端口，服务提供者、迁移检查与启动任务完成之前请求响应503，/readyz 同步反映就绪状态；
+// 启动失败或超过 flow.WithStartupTimeout 设置的时间时返回错误
 func (a *Application) Run(addr string) error {
-	// 先启动应用
-	if err := a.Boot(); err != nil {
-		return err
-	}
+	// 全部启动任务完成后输出启动报告
+	a.running.Store(true)
+	a.engine.OnReady(func() {
+		a.finishBoot()
+		a.logBootReport()
+	})
+
+	// 服务提供者在监听端口之后、其他启动任务之前启动
+	a.engine.AddStartupTask("服务提供者", func(ctx context.Context) error {
+		return a.Boot()
+	}, StartupPriorityProviders)
 
 	// 启动HTTP服务器
 	return a.lifecycle.Start(addr)
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DiagnoseError 启动诊断发现的全部问题
+type DiagnoseError struct {
+	Errors []error
+}
+
+// Error 实现error接口，每个问题一行
+func (e *DiagnoseError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "启动诊断发现 %d 个问题:", len(e.Errors))
+	for _, err := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap 返回全部问题，支持 errors.Is 与 errors.As
+func (e *DiagnoseError) Unwrap() []error {
+	return e.Errors
+}
+
+// Diagnose 执行全部启动诊断而不启动服务，一次返回所有问题，错误为 *DiagnoseError：
+//
+//   - 被拒绝的重复服务提供者（*DuplicateProviderError）
+//   - 同一类型的重复依赖注册（*di.DuplicateProviderError）
+//   - 主机与方法相同、模式重叠的路由（flow.RouteConflict）
+//   - 处理函数缺少的依赖（*di.NotProvidedError），见 VerifyDependencies
+//
+// 只检查调用时已注册的服务与路由，应在完成与启动时相同的注册之后调用，flow doctor 命令使用该方法
+func (a *Application) Diagnose() error {
+	errs := a.providerManager.Rejected()
+	if a.engine != nil {
+		for _, dup := range a.engine.DI().Duplicates() {
+			errs = append(errs, dup)
+		}
+		for _, conflict := range a.engine.RouteConflicts() {
+			errs = append(errs, conflict)
+		}
+		if err := a.VerifyDependencies(); err != nil {
+			var verify interface{ Unwrap() []error }
+			if errors.As(err, &verify) {
+				errs = append(errs, verify.Unwrap()...)
+			} else {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &DiagnoseError{Errors: errs}
+}
@@ -7,6 +7,8 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/zzliekkas/flow/v2/config"
 )
 
 // Environment 环境信息结构体
@@ -27,6 +29,49 @@ type Environment struct {
 	AppVersion string // 应用版本
 	AppName    string // 应用名称
 	Debug      bool   // 是否为调试模式
+
+	// Defaults 框架随环境切换的默认设置
+	Defaults EnvironmentDefaults
+}
+
+// EnvironmentDefaults 框架随环境切换的默认设置
+type EnvironmentDefaults struct {
+	DetailedErrors bool   // 错误响应中包含panic信息等细节
+	TemplateReload bool   // 模板热重载
+	DebugEndpoints bool   // 调试与文档端点默认启用
+	LogFormat      string // 日志格式：text 或 json
+	SecureCookies  bool   // Cookie默认设置Secure
+}
+
+// DefaultsFor 返回指定环境的默认设置，只有开发与测试环境启用调试相关功能
+func DefaultsFor(env string) EnvironmentDefaults {
+	switch config.NormalizeEnvironment(env) {
+	case config.EnvDevelopment:
+		return EnvironmentDefaults{
+			DetailedErrors: true,
+			TemplateReload: true,
+			DebugEndpoints: true,
+			LogFormat:      "text",
+		}
+	case config.EnvTesting:
+		return EnvironmentDefaults{
+			DetailedErrors: true,
+			LogFormat:      "text",
+		}
+	default:
+		return EnvironmentDefaults{
+			LogFormat:     "json",
+			SecureCookies: true,
+		}
+	}
+}
+
+// CurrentDefaults 返回当前应用环境的默认设置，尚未创建应用时按 FLOW_ENV 解析
+func CurrentDefaults() EnvironmentDefaults {
+	if app := GetApplication(); app != nil {
+		return app.environment.Defaults
+	}
+	return DefaultsFor(os.Getenv("FLOW_ENV"))
 }
 
 // NewEnvironment 创建一个新的环境信息实例
@@ -45,10 +90,7 @@ func NewEnvironment() *Environment {
 	}
 
 	// 确定应用环境
-	appEnv := os.Getenv("FLOW_ENV")
-	if appEnv == "" {
-		appEnv = "development"
-	}
+	appEnv := config.NormalizeEnvironment(os.Getenv("FLOW_ENV"))
 
 	// 确定应用版本
 	appVersion := os.Getenv("FLOW_VERSION")
@@ -63,7 +105,7 @@ func NewEnvironment() *Environment {
 	}
 
 	// 调试模式
-	debug := appEnv == "development" || strings.ToLower(os.Getenv("FLOW_DEBUG")) == "true"
+	debug := appEnv == config.EnvDevelopment || strings.ToLower(os.Getenv("FLOW_DEBUG")) == "true"
 
 	return &Environment{
 		GoVersion:   runtime.Version(),
@@ -79,22 +121,40 @@ func NewEnvironment() *Environment {
 		AppVersion:  appVersion,
 		AppName:     appName,
 		Debug:       debug,
+		Defaults:    DefaultsFor(appEnv),
 	}
 }
 
 // IsDevelopment 检查是否为开发环境
 func (e *Environment) IsDevelopment() bool {
-	return e.AppEnv == "development"
+	return e.AppEnv == config.EnvDevelopment
 }
 
 // IsProduction 检查是否为生产环境
 func (e *Environment) IsProduction() bool {
-	return e.AppEnv == "production"
+	return e.AppEnv == config.EnvProduction
 }
 
 // IsTesting 检查是否为测试环境
 func (e *Environment) IsTesting() bool {
-	return e.AppEnv == "testing"
+	return e.AppEnv == config.EnvTesting
+}
+
+// Is 检查当前环境是否为给定环境之一，支持别名，例如 Is("dev", "test")
+func (e *Environment) Is(envs ...string) bool {
+	for _, env := range envs {
+		if config.NormalizeEnvironment(env) == e.AppEnv {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAppEnv 切换应用环境并重新计算默认设置
+func (e *Environment) SetAppEnv(env string) {
+	e.AppEnv = config.NormalizeEnvironment(env)
+	e.Debug = e.AppEnv == config.EnvDevelopment || strings.ToLower(e.GetEnv("FLOW_DEBUG", "")) == "true"
+	e.Defaults = DefaultsFor(e.AppEnv)
 }
 
 // GetEnv 获取环境变量，如果不存在则返回默认值
@@ -115,6 +175,8 @@ func (e *Environment) Summary() string {
 	summary := strings.Builder{}
 	summary.WriteString(fmt.Sprintf("应用: %s (版本: %s)\n", e.AppName, e.AppVersion))
 	summary.WriteString(fmt.Sprintf("环境: %s (调试模式: %v)\n", e.AppEnv, e.Debug))
+	summary.WriteString(fmt.Sprintf("默认设置: 日志格式=%s 详细错误=%v 调试端点=%v 安全Cookie=%v\n",
+		e.Defaults.LogFormat, e.Defaults.DetailedErrors, e.Defaults.DebugEndpoints, e.Defaults.SecureCookies))
 	summary.WriteString(fmt.Sprintf("系统: %s/%s (Go %s)\n", e.GOOS, e.GOARCH, e.GoVersion))
 	summary.WriteString(fmt.Sprintf("CPU核心: %d\n", e.NumCPU))
 	summary.WriteString(fmt.Sprintf("主机名: %s\n", e.Hostname))
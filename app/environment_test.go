@@ -0,0 +1,100 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+)
+
+// newEnvTestApp 创建指定环境的应用，并捕获日志输出
+func newEnvTestApp(t *testing.T, env string) (*Application, *bytes.Buffer) {
+	t.Setenv("FLOW_ENV", env)
+	t.Setenv("FLOW_MODE", "")
+	t.Setenv("GIN_MODE", "")
+
+	application := New(flow.New())
+	var buf bytes.Buffer
+	application.Logger().SetOutput(&buf)
+	return application, &buf
+}
+
+func TestDefaultsFor(t *testing.T) {
+	tests := []struct {
+		env  string
+		want EnvironmentDefaults
+	}{
+		{"dev", EnvironmentDefaults{DetailedErrors: true, TemplateReload: true, DebugEndpoints: true, LogFormat: "text"}},
+		{"", EnvironmentDefaults{DetailedErrors: true, TemplateReload: true, DebugEndpoints: true, LogFormat: "text"}},
+		{"test", EnvironmentDefaults{DetailedErrors: true, LogFormat: "text"}},
+		{"Production", EnvironmentDefaults{LogFormat: "json", SecureCookies: true}},
+		{"staging", EnvironmentDefaults{LogFormat: "json", SecureCookies: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			assert.Equal(t, tt.want, DefaultsFor(tt.env))
+		})
+	}
+}
+
+func TestEnvironment_ResolvedAndInjected(t *testing.T) {
+	application, _ := newEnvTestApp(t, "prod")
+
+	env := application.Environment()
+	assert.Equal(t, "production", env.AppEnv)
+	assert.True(t, env.IsProduction())
+	assert.True(t, env.Is("dev", "prod"))
+	assert.False(t, env.Is("dev", "test"))
+
+	// 生产环境未显式指定模式时切换到release
+	assert.False(t, application.Engine().IsDebug())
+
+	var injected *Environment
+	require.NoError(t, application.Engine().Invoke(func(e *Environment) {
+		injected = e
+	}))
+	assert.Same(t, env, injected)
+}
+
+func TestProviders_OnlyInEnvironments(t *testing.T) {
+	application, logs := newEnvTestApp(t, "production")
+
+	devOnly := NewBaseProvider("dev_tools", 10).OnlyInEnvironments("dev", "test")
+	always := NewBaseProvider("always", 20)
+	application.RegisterProviders([]ServiceProvider{devOnly, always})
+	require.NoError(t, application.Boot())
+
+	assert.False(t, application.providerManager.IsBooted("dev_tools"))
+	assert.True(t, application.providerManager.IsBooted("always"))
+	assert.Contains(t, logs.String(), "跳过服务提供者 dev_tools")
+
+	// 切换到开发环境后正常启动
+	application.SetEnvironment("dev")
+	require.NoError(t, application.Boot())
+	assert.True(t, application.providerManager.IsBooted("dev_tools"))
+}
+
+func TestBoot_WarnsDevFeaturesInProduction(t *testing.T) {
+	application, logs := newEnvTestApp(t, "production")
+	application.ReportDevFeature("调试端点 (/_debug)")
+	require.NoError(t, application.Boot())
+	assert.Contains(t, logs.String(), "生产环境启用了仅限开发的功能: 调试端点 (/_debug)")
+	assert.NotContains(t, logs.String(), "调试运行模式")
+
+	// 显式指定的debug模式不会被覆盖，但会被提示
+	application = New(flow.New(flow.WithMode("debug")))
+	logs.Reset()
+	application.Logger().SetOutput(logs)
+	assert.True(t, application.Engine().IsDebug())
+	require.NoError(t, application.Boot())
+	assert.Contains(t, logs.String(), "调试运行模式")
+
+	// 开发环境不输出警告
+	application, logs = newEnvTestApp(t, "development")
+	application.ReportDevFeature("调试端点 (/_debug)")
+	require.NoError(t, application.Boot())
+	assert.NotContains(t, logs.String(), "仅限开发的功能")
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -106,15 +107,32 @@ func (lm *LifecycleManager) Start(addr string) error {
 	lm.logger.Info("应用已启动，监听地址: ", addr)
 
 	// 启动HTTP服务器（非阻塞）
+	served := make(chan error, 1)
 	go func() {
-		if err := lm.engine.Run(addr); err != nil {
-			lm.logger.Errorf("HTTP服务器错误: %v", err)
-		}
+		served <- lm.engine.Run(addr)
 	}()
 
-	// 等待关闭信号
-	<-lm.shutdownCh
-	return nil
+	// 等待关闭信号，启动失败时直接返回错误；平滑重启交接完成后同样返回
+	select {
+	case <-lm.shutdownCh:
+		return nil
+	case <-lm.engine.Restarted():
+		lm.setStatus(StatusStopped)
+		lm.logger.Info("应用已平滑重启，当前进程退出")
+		return nil
+	case err := <-served:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			lm.logger.Errorf("HTTP服务器错误: %v", err)
+			lm.setStatus(StatusStopped)
+			return err
+		}
+		select {
+		case <-lm.shutdownCh:
+		case <-lm.engine.Restarted():
+			lm.setStatus(StatusStopped)
+		}
+		return nil
+	}
 }
 
 // Shutdown 优雅关闭应用
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/event"
+)
+
+// 维护模式事件名称
+const (
+	EventMaintenanceDown = "app.maintenance.down"
+	EventMaintenanceUp   = "app.maintenance.up"
+)
+
+// DefaultMaintenanceFile 默认的维护模式标记文件
+const DefaultMaintenanceFile = "storage/framework/down"
+
+// MaintenanceState 维护模式状态
+type MaintenanceState struct {
+	Since      time.Time `json:"since"`                 // 进入维护模式的时间
+	RetryAfter int       `json:"retry_after,omitempty"` // 建议客户端重试的秒数
+	Secret     string    `json:"secret,omitempty"`      // 绕过维护模式的密钥
+	Message    string    `json:"message,omitempty"`     // 展示给用户的提示
+	AllowCIDRs []string  `json:"allow_cidrs,omitempty"` // 允许访问的网段
+}
+
+// DownOptions 进入维护模式的选项
+type DownOptions struct {
+	RetryAfter int
+	Secret     string
+	Message    string
+	AllowCIDRs []string
+}
+
+// MaintenanceStore 维护模式状态存储，多实例部署时应使用共享存储
+type MaintenanceStore interface {
+	// Load 读取状态，未处于维护模式时返回nil
+	Load(ctx context.Context) (*MaintenanceState, error)
+
+	// Save 保存状态
+	Save(ctx context.Context, state *MaintenanceState) error
+
+	// Clear 清除状态，退出维护模式
+	Clear(ctx context.Context) error
+}
+
+// FileMaintenanceStore 基于本地文件的维护模式存储
+type FileMaintenanceStore struct {
+	path string
+}
+
+// NewFileMaintenanceStore 创建文件存储，path为空时使用DefaultMaintenanceFile
+func NewFileMaintenanceStore(path string) *FileMaintenanceStore {
+	if path == "" {
+		path = DefaultMaintenanceFile
+	}
+	return &FileMaintenanceStore{path: path}
+}
+
+// Load 读取状态
+func (s *FileMaintenanceStore) Load(ctx context.Context) (*MaintenanceState, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state MaintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析维护模式文件失败: %w", err)
+	}
+	return &state, nil
+}
+
+// Save 保存状态，先写临时文件再重命名，避免读到不完整的内容
+func (s *FileMaintenanceStore) Save(ctx context.Context, state *MaintenanceState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Clear 删除维护模式文件
+func (s *FileMaintenanceStore) Clear(ctx context.Context) error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SetMaintenanceStore 设置维护模式存储
+func (a *Application) SetMaintenanceStore(store MaintenanceStore) {
+	a.maintenance = store
+}
+
+// MaintenanceStore 获取维护模式存储，未设置时使用默认文件存储
+func (a *Application) MaintenanceStore() MaintenanceStore {
+	if a.maintenance == nil {
+		a.maintenance = NewFileMaintenanceStore("")
+	}
+	return a.maintenance
+}
+
+// Down 进入维护模式
+func (a *Application) Down(opts DownOptions) error {
+	state := &MaintenanceState{
+		Since:      time.Now(),
+		RetryAfter: opts.RetryAfter,
+		Secret:     opts.Secret,
+		Message:    opts.Message,
+		AllowCIDRs: opts.AllowCIDRs,
+	}
+	if err := a.MaintenanceStore().Save(context.Background(), state); err != nil {
+		return fmt.Errorf("进入维护模式失败: %w", err)
+	}
+
+	a.logger.Warnf("应用已进入维护模式 (retry-after: %ds)", opts.RetryAfter)
+	a.dispatchMaintenanceEvent(EventMaintenanceDown, map[string]interface{}{
+		"retry_after": opts.RetryAfter,
+		"since":       state.Since,
+	})
+	return nil
+}
+
+// Up 退出维护模式
+func (a *Application) Up() error {
+	if err := a.MaintenanceStore().Clear(context.Background()); err != nil {
+		return fmt.Errorf("退出维护模式失败: %w", err)
+	}
+
+	a.logger.Info("应用已退出维护模式")
+	a.dispatchMaintenanceEvent(EventMaintenanceUp, map[string]interface{}{})
+	return nil
+}
+
+// IsDown 检查是否处于维护模式
+func (a *Application) IsDown() bool {
+	state, err := a.MaintenanceStore().Load(context.Background())
+	return err == nil && state != nil
+}
+
+// dispatchMaintenanceEvent 在容器中存在事件分发器时分发维护模式事件
+func (a *Application) dispatchMaintenanceEvent(name string, payload map[string]interface{}) {
+	_ = a.engine.Invoke(func(dispatcher event.Dispatcher) {
+		evt := event.NewBaseEvent(name)
+		evt.SetPayload(payload)
+		if err := dispatcher.Dispatch(evt); err != nil {
+			a.logger.Warnf("分发维护模式事件失败: %v", err)
+		}
+	})
+}
@@ -1,9 +1,16 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"runtime"
 	"sort"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/config"
 )
 
 // ServiceProvider 服务提供者接口
@@ -21,9 +28,36 @@ type ServiceProvider interface {
 	Priority() int
 }
 
+// EnvironmentScoped 限定运行环境的服务提供者，不在列表中的环境会跳过注册与启动
+type EnvironmentScoped interface {
+	Environments() []string
+}
+
+// ErrDuplicateProvider 同名的服务提供者已注册
+var ErrDuplicateProvider = errors.New("服务提供者重复注册")
+
+// DuplicateProviderError 重复注册同名服务提供者的错误，可通过 errors.Is(err, ErrDuplicateProvider) 判断
+type DuplicateProviderError struct {
+	Name     string // 提供者名称
+	Location string // 被拒绝的注册位置
+	Previous string // 先注册的位置
+}
+
+// Error 实现error接口
+func (e *DuplicateProviderError) Error() string {
+	return fmt.Sprintf("服务提供者 %s 重复注册: %s 与先注册的 %s 冲突", e.Name, e.Location, e.Previous)
+}
+
+// Is 判断是否为 ErrDuplicateProvider
+func (e *DuplicateProviderError) Is(target error) bool {
+	return target == ErrDuplicateProvider
+}
+
 // ProviderManager 提供者管理器
 type ProviderManager struct {
 	providers       []ServiceProvider // 注册的服务提供者
+	locations       map[string]string // 提供者名称到注册位置
+	rejected        []error           // 被拒绝的重复注册，启动时返回
 	bootedProviders map[string]bool   // 已启动的提供者
 	mutex           sync.RWMutex      // 互斥锁
 }
@@ -32,43 +66,91 @@ type ProviderManager struct {
 func NewProviderManager() *ProviderManager {
 	return &ProviderManager{
 		providers:       make([]ServiceProvider, 0),
+		locations:       make(map[string]string),
 		bootedProviders: make(map[string]bool),
 	}
 }
 
 // Register 注册服务提供者
-func (pm *ProviderManager) Register(provider ServiceProvider) {
+//
+// 同名的提供者已注册时拒绝注册并返回 *DuplicateProviderError，错误同时记录下来，BootAll 时返回；
+// 重复注册同一个实例不视为冲突
+func (pm *ProviderManager) Register(provider ServiceProvider) error {
+	location := providerCallSite()
+
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
 	// 检查提供者是否已注册
 	for _, p := range pm.providers {
-		if p.Name() == provider.Name() {
-			// 提供者已注册，跳过
-			return
+		if p.Name() != provider.Name() {
+			continue
+		}
+		if sameProvider(p, provider) {
+			return nil
 		}
+		err := &DuplicateProviderError{Name: provider.Name(), Location: location, Previous: pm.locations[p.Name()]}
+		pm.rejected = append(pm.rejected, err)
+		return err
 	}
 
 	// 添加提供者
 	pm.providers = append(pm.providers, provider)
+	pm.locations[provider.Name()] = location
 
 	// 按优先级排序
 	pm.sortProviders()
+	return nil
 }
 
-// RegisterAll 注册多个服务提供者
-func (pm *ProviderManager) RegisterAll(providers []ServiceProvider) {
+// RegisterAll 注册多个服务提供者，返回所有被拒绝的重复注册
+func (pm *ProviderManager) RegisterAll(providers []ServiceProvider) error {
+	var errs []error
 	for _, provider := range providers {
-		pm.Register(provider)
+		if err := pm.Register(provider); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
 }
 
 // RegisterAndBoot 注册并启动服务提供者
 func (pm *ProviderManager) RegisterAndBoot(provider ServiceProvider, app *Application) error {
-	pm.Register(provider)
+	if err := pm.Register(provider); err != nil {
+		return err
+	}
 	return pm.BootProvider(provider, app)
 }
 
+// Rejected 返回被拒绝的重复注册
+func (pm *ProviderManager) Rejected() []error {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return append([]error(nil), pm.rejected...)
+}
+
+// sameProvider 判断两个提供者是否为同一实例
+func sameProvider(a, b ServiceProvider) bool {
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
+
+// providerCallSite 返回注册提供者的调用位置，跳过 app 包内部的调用
+func providerCallSite() string {
+	pkg := reflect.TypeOf(ProviderManager{}).PkgPath() + "."
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkg) || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
 // BootProvider 启动单个服务提供者
 func (pm *ProviderManager) BootProvider(provider ServiceProvider, app *Application) error {
 	pm.mutex.Lock()
@@ -80,12 +162,18 @@ func (pm *ProviderManager) BootProvider(provider ServiceProvider, app *Applicati
 	}
 
 	// 先注册服务
-	if err := provider.Register(app); err != nil {
+	start := time.Now()
+	err := provider.Register(app)
+	app.recordPhase(BootStageRegister, provider.Name(), time.Since(start), err)
+	if err != nil {
 		return fmt.Errorf("注册服务提供者 %s 失败: %w", provider.Name(), err)
 	}
 
 	// 再启动服务
-	if err := provider.Boot(app); err != nil {
+	start = time.Now()
+	err = provider.Boot(app)
+	app.recordPhase(BootStageBoot, provider.Name(), time.Since(start), err)
+	if err != nil {
 		return fmt.Errorf("启动服务提供者 %s 失败: %w", provider.Name(), err)
 	}
 
@@ -95,15 +183,46 @@ func (pm *ProviderManager) BootProvider(provider ServiceProvider, app *Applicati
 	return nil
 }
 
-// BootAll 启动所有注册的服务提供者
+// BootAll 启动所有注册的服务提供者，存在被拒绝的重复注册时不启动任何提供者
 func (pm *ProviderManager) BootAll(app *Application) error {
+	if rejected := pm.Rejected(); len(rejected) > 0 {
+		return errors.Join(rejected...)
+	}
+
 	pm.mutex.RLock()
 	providers := make([]ServiceProvider, len(pm.providers))
 	copy(providers, pm.providers)
 	pm.mutex.RUnlock()
 
-	// 按优先级顺序启动所有提供者
+	// 筛选当前环境启用且尚未启动的提供者
+	enabled := make([]ServiceProvider, 0, len(providers))
 	for _, provider := range providers {
+		if scoped, ok := provider.(EnvironmentScoped); ok {
+			if envs := scoped.Environments(); len(envs) > 0 && !app.environment.Is(envs...) {
+				app.logger.Infof("跳过服务提供者 %s: 仅在 %v 环境启用，当前环境为 %s", provider.Name(), envs, app.environment.AppEnv)
+				app.recordSkipped(provider.Name())
+				continue
+			}
+		}
+		if !pm.IsBooted(provider.Name()) {
+			enabled = append(enabled, provider)
+		}
+	}
+
+	// 注册之前统一验证所有提供者的配置段，任何错误都会阻止启动
+	var configManager *config.ConfigManager
+	_ = app.engine.Invoke(func(cm *config.ConfigManager) {
+		configManager = cm
+	})
+	start := time.Now()
+	err := configureProviders(enabled, configManager)
+	app.recordPhase(BootStageConfig, "服务提供者配置", time.Since(start), err)
+	if err != nil {
+		return err
+	}
+
+	// 按优先级顺序启动所有提供者，各阶段的耗时记录在启动报告中
+	for _, provider := range enabled {
 		if err := pm.BootProvider(provider, app); err != nil {
 			return err
 		}
@@ -138,8 +257,9 @@ func (pm *ProviderManager) IsBooted(name string) bool {
 
 // BaseProvider 基础服务提供者结构体，可作为自定义提供者的基类
 type BaseProvider struct {
-	name     string
-	priority int
+	name         string
+	priority     int
+	environments []string
 }
 
 // NewBaseProvider 创建基础服务提供者
@@ -160,6 +280,17 @@ func (bp *BaseProvider) Priority() int {
 	return bp.priority
 }
 
+// OnlyInEnvironments 限定提供者只在指定环境启用，支持别名，例如 OnlyInEnvironments("dev", "test")
+func (bp *BaseProvider) OnlyInEnvironments(envs ...string) *BaseProvider {
+	bp.environments = envs
+	return bp
+}
+
+// Environments 获取提供者限定的环境，为空表示所有环境
+func (bp *BaseProvider) Environments() []string {
+	return bp.environments
+}
+
 // Register 注册服务（需要子类重写）
 func (bp *BaseProvider) Register(app *Application) error {
 	return nil
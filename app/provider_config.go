@@ -0,0 +1,224 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/validation"
+)
+
+// ConfigProvider 声明配置段的服务提供者
+//
+// 应用启动时在 Register 之前读取 ConfigSection 指定的配置段，解析到 ConfigSchema 返回的结构体并执行验证，
+// 通过后调用 Configure 传入该结构体。所有提供者的配置错误汇总为一个 ConfigErrors 返回
+type ConfigProvider interface {
+	// ConfigSection 配置段名称，例如 "cache"
+	ConfigSection() string
+
+	// ConfigSchema 返回配置结构体的指针，字段可预先填写默认值
+	// 字段使用 mapstructure 标签映射配置键，json 标签用于错误路径，validate 标签声明验证规则
+	ConfigSchema() interface{}
+
+	// Configure 接收验证通过的配置，参数为 ConfigSchema 返回的同一类型
+	Configure(cfg interface{}) error
+}
+
+// ConfigError 单个配置项的错误
+type ConfigError struct {
+	Provider string // 服务提供者名称
+	Path     string // 配置路径，例如 cache.stores.redis.driver
+	Message  string // 错误说明
+}
+
+// Error 实现error接口
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ConfigErrors 启动时汇总的配置错误
+type ConfigErrors []*ConfigError
+
+// Error 实现error接口，列出所有错误的配置项
+func (e ConfigErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = fmt.Sprintf("  - [%s] %s", err.Provider, err.Error())
+	}
+	return fmt.Sprintf("配置验证失败（%d 项）:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// configureProviders 为声明了配置段的提供者解析、验证并传入配置，汇总所有错误
+func configureProviders(providers []ServiceProvider, configManager *config.ConfigManager) error {
+	var errs ConfigErrors
+	for _, provider := range providers {
+		cp, ok := provider.(ConfigProvider)
+		if !ok {
+			continue
+		}
+		errs = append(errs, configureProvider(provider.Name(), cp, configManager)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ConfigureProvider 对单个提供者执行配置段的解析、验证与 Configure
+// 用于不通过 Application 注册的提供者，例如 db.DatabaseProvider
+func ConfigureProvider(name string, cp ConfigProvider, configManager *config.ConfigManager) error {
+	if errs := configureProvider(name, cp, configManager); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// configureProvider 处理单个提供者的配置段
+func configureProvider(name string, cp ConfigProvider, configManager *config.ConfigManager) ConfigErrors {
+	section := cp.ConfigSection()
+	schema := cp.ConfigSchema()
+
+	if configManager != nil && configManager.Has(section) {
+		if err := configManager.Unmarshal(section, schema); err != nil {
+			return ConfigErrors{{Provider: name, Path: section, Message: fmt.Sprintf("解析配置失败: %v", err)}}
+		}
+	}
+
+	if err := validation.Validate(schema); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return ConfigErrors{{Provider: name, Path: section, Message: err.Error()}}
+		}
+		errs := make(ConfigErrors, len(fieldErrs))
+		for i, fe := range fieldErrs {
+			errs[i] = &ConfigError{
+				Provider: name,
+				Path:     configPath(section, fe.Namespace()),
+				Message:  strings.Join(validation.TranslateError(validator.ValidationErrors{fe}), "; "),
+			}
+		}
+		return errs
+	}
+
+	if err := cp.Configure(schema); err != nil {
+		var cfgErr *ConfigError
+		if errors.As(err, &cfgErr) {
+			if cfgErr.Provider == "" {
+				cfgErr.Provider = name
+			}
+			return ConfigErrors{cfgErr}
+		}
+		return ConfigErrors{{Provider: name, Path: section, Message: err.Error()}}
+	}
+	return nil
+}
+
+// mapKeyPattern 验证错误路径中的映射键，例如 stores[redis]
+var mapKeyPattern = regexp.MustCompile(`\[([^\]]+)\]`)
+
+// configPath 将验证错误的命名空间转换为配置路径
+// 例如 ProviderConfig.stores[redis].driver 转换为 cache.stores.redis.driver
+func configPath(section, namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		namespace = namespace[i+1:]
+	} else {
+		namespace = ""
+	}
+	namespace = mapKeyPattern.ReplaceAllString(namespace, ".$1")
+	if namespace == "" {
+		return section
+	}
+	return section + "." + namespace
+}
+
+// ConfigSection 提供者配置段的描述，用于生成配置文档
+type ConfigSection struct {
+	Provider string        // 服务提供者名称
+	Section  string        // 配置段名称
+	Fields   []ConfigField // 配置项
+}
+
+// ConfigField 配置项描述
+type ConfigField struct {
+	Key      string // 相对于配置段的键，映射的键以 <name> 表示
+	Type     string // Go类型
+	Rules    string // 验证规则
+	Default  string // 默认值
+	Required bool   // 是否必填
+}
+
+// ConfigSections 返回所有声明了配置段的服务提供者及其配置项
+func (a *Application) ConfigSections() []ConfigSection {
+	var sections []ConfigSection
+	for _, provider := range a.providerManager.GetProviders() {
+		cp, ok := provider.(ConfigProvider)
+		if !ok {
+			continue
+		}
+		schema := reflect.ValueOf(cp.ConfigSchema())
+		sections = append(sections, ConfigSection{
+			Provider: provider.Name(),
+			Section:  cp.ConfigSection(),
+			Fields:   describeFields(schema, ""),
+		})
+	}
+	return sections
+}
+
+// describeFields 通过反射列出结构体的配置项
+func describeFields(v reflect.Value, prefix string) []ConfigField {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []ConfigField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		key := strings.SplitN(f.Tag.Get("mapstructure"), ",", 2)[0]
+		if key == "-" || strings.Contains(f.Tag.Get("mapstructure"), "remain") {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(f.Name)
+		}
+		key = prefix + key
+
+		fv := v.Field(i)
+		ft := f.Type
+		switch {
+		case ft.Kind() == reflect.Struct && ft.PkgPath() != "time":
+			fields = append(fields, describeFields(fv, key+".")...)
+			continue
+		case ft.Kind() == reflect.Map && ft.Elem().Kind() == reflect.Struct:
+			fields = append(fields, describeFields(reflect.New(ft.Elem()), key+".<name>.")...)
+			continue
+		}
+
+		rules := f.Tag.Get("validate")
+		field := ConfigField{
+			Key:      key,
+			Type:     ft.String(),
+			Rules:    rules,
+			Required: strings.Contains(","+rules+",", ",required,"),
+		}
+		if !fv.IsZero() {
+			field.Default = fmt.Sprint(fv.Interface())
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
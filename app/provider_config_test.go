@@ -0,0 +1,97 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/config"
+)
+
+type mailConfig struct {
+	Host    string        `mapstructure:"host" json:"host" validate:"required"`
+	Port    int           `mapstructure:"port" json:"port" validate:"gte=1,lte=65535"`
+	Timeout time.Duration `mapstructure:"timeout" json:"timeout"`
+	Queues  map[string]struct {
+		Workers int `mapstructure:"workers" json:"workers" validate:"gte=1"`
+	} `mapstructure:"queues" json:"queues" validate:"dive"`
+}
+
+// mailProvider 声明 mail 配置段的测试提供者
+type mailProvider struct {
+	*BaseProvider
+	received *mailConfig
+}
+
+func (p *mailProvider) ConfigSection() string { return "mail" }
+
+func (p *mailProvider) ConfigSchema() interface{} { return &mailConfig{Port: 25} }
+
+func (p *mailProvider) Configure(cfg interface{}) error {
+	p.received = cfg.(*mailConfig)
+	return nil
+}
+
+func newConfigTestApp(t *testing.T, settings map[string]interface{}) *Application {
+	t.Setenv("FLOW_ENV", "test")
+	cm := config.NewConfigManager()
+	for k, v := range settings {
+		cm.Set(k, v)
+	}
+	e := flow.New()
+	require.NoError(t, e.Provide(func() *config.ConfigManager { return cm }))
+	return New(e)
+}
+
+func TestConfigProvider_ValidConfigDelivered(t *testing.T) {
+	application := newConfigTestApp(t, map[string]interface{}{
+		"mail.host":    "smtp.example.com",
+		"mail.timeout": "5s",
+	})
+	provider := &mailProvider{BaseProvider: NewBaseProvider("mail", 10)}
+	plain := NewBaseProvider("plain", 20)
+	application.RegisterProviders([]ServiceProvider{provider, plain})
+
+	require.NoError(t, application.Boot())
+	require.NotNil(t, provider.received)
+	assert.Equal(t, "smtp.example.com", provider.received.Host)
+	assert.Equal(t, 25, provider.received.Port, "未配置的项保留默认值")
+	assert.Equal(t, 5*time.Second, provider.received.Timeout)
+	assert.True(t, application.providerManager.IsBooted("plain"))
+}
+
+func TestConfigProvider_InvalidSectionFailsBoot(t *testing.T) {
+	application := newConfigTestApp(t, map[string]interface{}{
+		"mail.port":                   70000,
+		"mail.queues.default.workers": 0,
+	})
+	provider := &mailProvider{BaseProvider: NewBaseProvider("mail", 10)}
+	application.RegisterProvider(provider)
+
+	err := application.Boot()
+	var errs ConfigErrors
+	require.ErrorAs(t, err, &errs)
+
+	paths := make([]string, len(errs))
+	for i, e := range errs {
+		paths[i] = e.Path
+	}
+	assert.ElementsMatch(t, []string{"mail.host", "mail.port", "mail.queues.default.workers"}, paths)
+	assert.Contains(t, err.Error(), "[mail] mail.port")
+	assert.Nil(t, provider.received)
+	assert.False(t, application.providerManager.IsBooted("mail"), "配置错误时不注册提供者")
+}
+
+func TestConfigSections(t *testing.T) {
+	application := newConfigTestApp(t, nil)
+	application.RegisterProvider(&mailProvider{BaseProvider: NewBaseProvider("mail", 10)})
+
+	sections := application.ConfigSections()
+	require.Len(t, sections, 1)
+	assert.Equal(t, "mail", sections[0].Section)
+	assert.Equal(t, ConfigField{Key: "host", Type: "string", Rules: "required", Required: true}, sections[0].Fields[0])
+	assert.Equal(t, "25", sections[0].Fields[1].Default)
+	assert.Equal(t, "queues.<name>.workers", sections[0].Fields[3].Key)
+}
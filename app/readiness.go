@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/db"
+)
+
+// 启动任务优先级，Run 依次启动服务提供者、检查迁移，再执行其他启动任务（默认优先级100）
+const (
+	// StartupPriorityProviders 服务提供者的启动优先级
+	StartupPriorityProviders = 0
+	// StartupPriorityMigrations 迁移检查的启动优先级
+	StartupPriorityMigrations = 10
+)
+
+// migrationCheckInterval 等待迁移完成时的检查间隔
+var migrationCheckInterval = time.Second
+
+// RequireMigrations 在开始处理请求之前等待所有迁移执行完成
+//
+// 适用于迁移由单独的任务执行的部署方式：Run 期间每秒检查一次待执行的迁移，
+// 超过 flow.WithStartupTimeout 设置的时间仍未完成时启动失败，错误中列出待执行的迁移
+func (a *Application) RequireMigrations(migrator *db.Migrator) {
+	a.engine.AddStartupTask("迁移检查", func(ctx context.Context) error {
+		ticker := time.NewTicker(migrationCheckInterval)
+		defer ticker.Stop()
+		for {
+			pending, err := migrator.GetPending()
+			if err != nil {
+				return fmt.Errorf("检查待执行的迁移失败: %w", err)
+			}
+			if len(pending) == 0 {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				ids := make([]string, len(pending))
+				for i, m := range pending {
+					ids[i] = m.ID()
+				}
+				return fmt.Errorf("仍有 %d 个待执行的迁移: %s", len(pending), strings.Join(ids, ", "))
+			case <-ticker.C:
+			}
+		}
+	}, StartupPriorityMigrations)
+}
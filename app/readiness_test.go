@@ -0,0 +1,96 @@
+package app
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowProvider 启动时等待信号的测试提供者
+type slowProvider struct {
+	*BaseProvider
+	release chan struct{}
+}
+
+func (p *slowProvider) Boot(app *Application) error {
+	<-p.release
+	return nil
+}
+
+// freeAddr 返回一个空闲的本地地址
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+// statusOf 请求并返回状态码，连接失败时返回0
+func statusOf(url string) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode
+}
+
+func TestRun_WaitsForProviders(t *testing.T) {
+	t.Setenv("FLOW_ENV", "test")
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	e := flow.New(flow.WithMode("test"))
+	e.GET("/ping", func(c *flow.Context) { c.String(http.StatusOK, "pong") })
+	application := New(e)
+	provider := &slowProvider{BaseProvider: NewBaseProvider("slow", 10), release: make(chan struct{})}
+	application.RegisterProvider(provider)
+
+	addr := freeAddr(t)
+	done := make(chan error, 1)
+	go func() { done <- application.Run(addr) }()
+
+	// 服务提供者启动期间请求与就绪端点都响应503
+	require.Eventually(t, func() bool { return statusOf("http://"+addr+"/ping") != 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, statusOf("http://"+addr+"/ping"))
+	assert.Equal(t, http.StatusServiceUnavailable, statusOf("http://"+addr+flow.ReadinessPath))
+
+	close(provider.release)
+	require.Eventually(t, e.Ready, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, statusOf("http://"+addr+"/ping"))
+	assert.Equal(t, http.StatusOK, statusOf("http://"+addr+flow.ReadinessPath))
+
+	require.NoError(t, application.Shutdown(time.Second))
+	assert.NoError(t, <-done)
+}
+
+func TestRun_PendingMigrationsTimeout(t *testing.T) {
+	t.Setenv("FLOW_ENV", "test")
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	interval := migrationCheckInterval
+	migrationCheckInterval = 10 * time.Millisecond
+	defer func() { migrationCheckInterval = interval }()
+
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	migrator := db.NewMigrator(conn, t.TempDir())
+	require.NoError(t, migrator.Register(db.NewMigration("20240101000000_create_users", "create users", nil, nil)))
+
+	application := New(flow.New(flow.WithMode("test"), flow.WithStartupTimeout(100*time.Millisecond)))
+	application.RequireMigrations(migrator)
+
+	err = application.Run(freeAddr(t))
+	require.ErrorIs(t, err, flow.ErrStartupTimeout)
+	assert.Contains(t, err.Error(), "20240101000000_create_users")
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/di"
+)
+
+type reportService struct{}
+
+type auditService struct{}
+
+func TestBoot_VerifiesDependenciesInDebug(t *testing.T) {
+	t.Setenv("FLOW_ENV", "test")
+	e := flow.New(flow.WithMode("debug"))
+	e.GET("/reports", flow.H2(func(c *flow.Context, svc *reportService) {}))
+	application := New(e)
+
+	err := application.Boot()
+	require.Error(t, err, "缺少依赖时在监听之前失败")
+	assert.ErrorIs(t, err, di.ErrNotProvided)
+	assert.Contains(t, err.Error(), "*app.reportService")
+}
+
+func TestBoot_SkipsVerificationOutsideDebug(t *testing.T) {
+	t.Setenv("FLOW_ENV", "test")
+	e := flow.New(flow.WithMode("release"))
+	e.GET("/reports", flow.H2(func(c *flow.Context, svc *reportService) {}))
+	application := New(e)
+
+	require.NoError(t, application.Boot())
+	// 非调试模式可显式检查
+	assert.ErrorIs(t, application.VerifyDependencies(), di.ErrNotProvided)
+}
+
+func TestRegisterProvider_RejectsDuplicateName(t *testing.T) {
+	t.Setenv("FLOW_ENV", "test")
+	application := New(flow.New(flow.WithMode("release")))
+	mail := NewBaseProvider("mail", 10)
+
+	_, file, line, _ := runtime.Caller(0)
+	require.NoError(t, application.RegisterProvider(mail))
+	require.NoError(t, application.RegisterProvider(mail), "同一实例重复注册不视为冲突")
+	err := application.RegisterProvider(NewBaseProvider("mail", 20))
+	assert.ErrorIs(t, err, ErrDuplicateProvider)
+
+	var dup *DuplicateProviderError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, fmt.Sprintf("%s:%d", file, line+1), dup.Previous)
+	assert.Equal(t, fmt.Sprintf("%s:%d", file, line+3), dup.Location)
+
+	// 忽略返回值时启动同样失败
+	assert.ErrorIs(t, application.Boot(), ErrDuplicateProvider)
+	assert.False(t, application.providerManager.IsBooted("mail"))
+}
+
+func TestDiagnose_ReportsAllFindings(t *testing.T) {
+	t.Setenv("FLOW_ENV", "test")
+	e := flow.New(flow.WithMode("release"))
+	application := New(e)
+	require.NoError(t, application.Diagnose())
+
+	application.RegisterProvider(NewBaseProvider("mail", 10))
+	application.RegisterProvider(NewBaseProvider("mail", 10))
+	require.NoError(t, e.Provide(func() *reportService { return &reportService{} }))
+	require.NoError(t, e.Provide(func() *reportService { return &reportService{} }))
+	e.GET("/reports/:id", flow.H2(func(c *flow.Context, svc *reportService, audit *auditService) {}))
+	e.GET("/reports/latest", func(c *flow.Context) {})
+
+	err := application.Diagnose()
+	var diagnose *DiagnoseError
+	require.ErrorAs(t, err, &diagnose)
+	require.Len(t, diagnose.Errors, 4)
+	assert.ErrorIs(t, err, ErrDuplicateProvider)
+	assert.ErrorIs(t, err, di.ErrDuplicateProvider)
+	assert.ErrorIs(t, err, di.ErrNotProvided)
+	var conflict flow.RouteConflict
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "/reports/latest", conflict.Winner)
+}
+
+func TestStrictDI_RejectsDuplicate(t *testing.T) {
+	e := flow.New(flow.WithStrictDI())
+	require.NoError(t, e.Provide(func() *reportService { return &reportService{} }))
+	assert.ErrorIs(t, e.Provide(func() *reportService { return &reportService{} }), di.ErrDuplicateProvider)
+}
@@ -0,0 +1,144 @@
+package flow
+
+import "reflect"
+
+// routeAttrsKey 当前请求路由属性在上下文中的键
+const routeAttrsKey = "_flow/route_attrs"
+
+// routeAttr 路由属性，以值的类型作为键
+type routeAttr struct {
+	typ   reflect.Type
+	value interface{}
+}
+
+// WithAttr 为路由或路由组附加类型化属性，与处理函数一起传入：
+//
+//	e.POST("/upload", h, flow.WithAttr(middleware.MaxBody(50<<20)))
+//	api := e.Group("/api", flow.WithAttr(middleware.MaxBody(1<<20)))
+//
+// 属性以类型区分，同一类型后者覆盖前者，路由级属性覆盖路由组属性。
+// 属性在注册时确定，之后不可修改；中间件通过 AttrFrom 读取
+func WithAttr[T any](value T) HandlerFunc {
+	return attrHandler(routeAttr{typ: reflect.TypeOf((*T)(nil)).Elem(), value: value})
+}
+
+// attrHandler 返回携带属性的标记处理函数，注册路由时被识别并移出处理链
+func attrHandler(attr routeAttr) HandlerFunc {
+	return func(c *Context) {
+		if c.attrSink != nil {
+			*c.attrSink = append(*c.attrSink, attr)
+			return
+		}
+		// 误用在 Use 等位置时不影响请求
+		c.Next()
+	}
+}
+
+// attrHandlerPC attrHandler 返回的处理函数的代码地址，用于识别属性标记
+var attrHandlerPC = reflect.ValueOf(attrHandler(routeAttr{})).Pointer()
+
+// splitAttrs 从处理函数中分离出属性标记
+func splitAttrs(handlers []HandlerFunc) ([]HandlerFunc, []routeAttr) {
+	var rest []HandlerFunc
+	var attrs []routeAttr
+	for i, h := range handlers {
+		if h == nil || reflect.ValueOf(h).Pointer() != attrHandlerPC {
+			if attrs != nil {
+				rest = append(rest, h)
+			}
+			continue
+		}
+		if attrs == nil {
+			rest = append([]HandlerFunc(nil), handlers[:i]...)
+			attrs = []routeAttr{}
+		}
+		h(&Context{attrSink: &attrs})
+	}
+	if attrs == nil {
+		return handlers, nil
+	}
+	return rest, attrs
+}
+
+// mergeAttrs 合并属性，同一类型只保留最后的值，结果为新切片
+func mergeAttrs(base, overrides []routeAttr) []routeAttr {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := append([]routeAttr(nil), base...)
+	for _, attr := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].typ == attr.typ {
+				merged[i] = attr
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, attr)
+		}
+	}
+	return merged
+}
+
+// AttrFrom 读取当前请求所匹配路由上类型为 T 的属性，不存在时返回零值和 false
+func AttrFrom[T any](c *Context) (T, bool) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	for _, attr := range c.routeAttrs() {
+		if attr.typ == typ {
+			return attr.value.(T), true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// AttrOr 读取当前路由类型为 T 的属性，不存在时返回 def
+func AttrOr[T any](c *Context, def T) T {
+	if v, ok := AttrFrom[T](c); ok {
+		return v
+	}
+	return def
+}
+
+// routeAttrs 返回当前请求路由的属性，每个请求只查找一次路由
+func (c *Context) routeAttrs() []routeAttr {
+	if v, ok := c.Get(routeAttrsKey); ok {
+		attrs, _ := v.([]routeAttr)
+		return attrs
+	}
+	var attrs []routeAttr
+	if r := currentRoute(c.Context); r != nil {
+		attrs = r.attrs
+	} else if c.engine != nil && c.Request != nil {
+		attrs = c.engine.routeTable.attrs(requestHostKey(c.Context), c.Request.Method, c.FullPath())
+	}
+	c.Set(routeAttrsKey, attrs)
+	return attrs
+}
+
+// attrs 查找路由属性，没有任何路由设置属性时直接返回
+func (t *routeTable) attrs(host, method, fullPath string) []routeAttr {
+	if !t.hasAttrs.Load() {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if r, ok := t.index[routeKey(host, method, fullPath)]; ok {
+		return r.attrs
+	}
+	return nil
+}
+
+// attrsInfo 生成属性的展示形式，键为属性类型名称
+func attrsInfo(attrs []routeAttr) map[string]interface{} {
+	if len(attrs) == 0 {
+		return nil
+	}
+	info := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		info[attr.typ.String()] = attr.value
+	}
+	return info
+}
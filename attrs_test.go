@@ -0,0 +1,81 @@
+package flow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTimeout time.Duration
+
+type testLimit int
+
+func TestRouteAttrs(t *testing.T) {
+	e := newRouteTestEngine()
+	e.Use(func(c *Context) {
+		// 全局中间件读取匹配路由的属性
+		c.Header("X-Timeout", time.Duration(AttrOr(c, testTimeout(time.Second))).String())
+		c.Next()
+	})
+	handler := func(c *Context) {
+		limit, ok := AttrFrom[testLimit](c)
+		if !ok {
+			c.String(http.StatusOK, "none")
+			return
+		}
+		c.String(http.StatusOK, "%d", limit)
+	}
+
+	api := e.Group("/api", WithAttr(testTimeout(5*time.Second)), WithAttr(testLimit(10)))
+	admin := api.Group("/admin", WithAttr(testLimit(20)))
+	api.GET("/users", handler)
+	api.POST("/upload", handler, WithAttr(testTimeout(2*time.Minute)))
+	admin.GET("/stats", handler)
+	e.GET("/health", handler)
+
+	get := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := get(http.MethodGet, "/api/users")
+	assert.Equal(t, "10", w.Body.String())
+	assert.Equal(t, "5s", w.Header().Get("X-Timeout"))
+
+	// 路由级属性覆盖路由组属性，属性不作为处理函数执行
+	w = get(http.MethodPost, "/api/upload")
+	assert.Equal(t, "10", w.Body.String())
+	assert.Equal(t, "2m0s", w.Header().Get("X-Timeout"))
+
+	// 子路由组继承并覆盖父路由组属性
+	w = get(http.MethodGet, "/api/admin/stats")
+	assert.Equal(t, "20", w.Body.String())
+	assert.Equal(t, "5s", w.Header().Get("X-Timeout"))
+
+	// 未设置属性时使用默认值
+	w = get(http.MethodGet, "/health")
+	assert.Equal(t, "none", w.Body.String())
+	assert.Equal(t, "1s", w.Header().Get("X-Timeout"))
+}
+
+func TestRouteAttrs_Introspection(t *testing.T) {
+	e := newRouteTestEngine()
+	api := e.Group("/api", WithAttr(testLimit(10)))
+	api.POST("/upload", func(c *Context) {}, WithAttr(testTimeout(time.Minute)))
+	e.GET("/health", func(c *Context) {})
+
+	routes := e.RouteList()
+	assert.Len(t, routes, 2)
+	// 属性不计入中间件和处理函数
+	assert.Equal(t, []string{"recovery"}, routes[0].Middleware)
+	assert.Contains(t, routes[0].Handler, "TestRouteAttrs_Introspection")
+	assert.Equal(t, map[string]interface{}{
+		"flow.testLimit":   testLimit(10),
+		"flow.testTimeout": testTimeout(time.Minute),
+	}, routes[0].Attrs)
+	assert.Nil(t, routes[1].Attrs)
+}
@@ -0,0 +1,245 @@
+// Package tokens 提供供脚本与集成使用的个人访问令牌
+//
+// 令牌形如 flow_pat_xxxxxxxx...，明文只在签发时返回一次，数据库只保存 SHA-256 摘要与用于定位的前缀
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/gorm"
+)
+
+// TokenPrefix 令牌明文的固定前缀
+const TokenPrefix = "flow_pat_"
+
+// 令牌随机部分的长度：前 lookupLength 个字符与 TokenPrefix 一起作为可见前缀保存，用于定位
+const (
+	lookupLength = 8
+	randomLength = 40
+)
+
+// DefaultLastUsedInterval 最近使用时间的最小更新间隔，避免每个请求都写数据库
+const DefaultLastUsedInterval = time.Minute
+
+// 令牌错误
+var (
+	ErrTokenInvalid  = errors.New("访问令牌无效")
+	ErrTokenExpired  = errors.New("访问令牌已过期")
+	ErrTokenRevoked  = errors.New("访问令牌已撤销")
+	ErrTokenNotFound = errors.New("访问令牌不存在")
+)
+
+// encoding 小写 base32，不含容易混淆的符号
+var encoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// Token 个人访问令牌记录
+type Token struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	UserID     string     `gorm:"size:64;index;not null" json:"user_id"`
+	Name       string     `gorm:"size:255;not null" json:"name"`
+	Prefix     string     `gorm:"size:32;index;not null" json:"prefix"`
+	Hash       string     `gorm:"size:64;not null" json:"-"`
+	Scopes     []string   `gorm:"serializer:json" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName 表名
+func (Token) TableName() string {
+	return "personal_access_tokens"
+}
+
+// HasScope 判断令牌是否拥有权限范围，* 表示全部
+func (t *Token) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Option 令牌管理器选项
+type Option func(*Manager)
+
+// WithClock 设置时钟，用于测试
+func WithClock(now func() time.Time) Option {
+	return func(m *Manager) {
+		m.now = now
+	}
+}
+
+// WithRandom 设置随机数来源，用于测试
+func WithRandom(r io.Reader) Option {
+	return func(m *Manager) {
+		m.random = r
+	}
+}
+
+// WithLastUsedInterval 设置最近使用时间的最小更新间隔
+func WithLastUsedInterval(interval time.Duration) Option {
+	return func(m *Manager) {
+		m.lastUsedInterval = interval
+	}
+}
+
+// Manager 令牌管理器
+type Manager struct {
+	db               *gorm.DB
+	now              func() time.Time
+	random           io.Reader
+	lastUsedInterval time.Duration
+}
+
+// NewManager 创建令牌管理器
+func NewManager(conn *gorm.DB, opts ...Option) *Manager {
+	m := &Manager{
+		db:               conn,
+		now:              time.Now,
+		random:           rand.Reader,
+		lastUsedInterval: DefaultLastUsedInterval,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Migration 返回创建令牌表的迁移，可注册到 db.Migrator
+func Migration() db.Migration {
+	return db.NewMigration("20261016000000", "create_personal_access_tokens_table",
+		func(conn *gorm.DB) error {
+			return conn.AutoMigrate(&Token{})
+		},
+		func(conn *gorm.DB) error {
+			return conn.Migrator().DropTable(&Token{})
+		})
+}
+
+// Migrate 直接创建或更新令牌表
+func (m *Manager) Migrate(ctx context.Context) error {
+	return m.db.WithContext(ctx).AutoMigrate(&Token{})
+}
+
+// IssueToken 签发令牌，返回只出现这一次的明文；expiresAt 为零值时永不过期
+func (m *Manager) IssueToken(ctx context.Context, userID, name string, scopes []string, expiresAt time.Time) (string, *Token, error) {
+	if userID == "" {
+		return "", nil, errors.New("签发令牌需要用户ID")
+	}
+
+	buf := make([]byte, randomLength*5/8)
+	if _, err := io.ReadFull(m.random, buf); err != nil {
+		return "", nil, fmt.Errorf("生成令牌失败: %w", err)
+	}
+	plaintext := TokenPrefix + encoding.EncodeToString(buf)
+
+	token := &Token{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plaintext[:len(TokenPrefix)+lookupLength],
+		Hash:      hash(plaintext),
+		Scopes:    scopes,
+		CreatedAt: m.now(),
+	}
+	if token.Scopes == nil {
+		token.Scopes = []string{}
+	}
+	if !expiresAt.IsZero() {
+		token.ExpiresAt = &expiresAt
+	}
+	if err := m.db.WithContext(ctx).Create(token).Error; err != nil {
+		return "", nil, err
+	}
+	return plaintext, token, nil
+}
+
+// Authenticate 验证令牌明文并返回令牌记录，同时按间隔更新最近使用时间
+func (m *Manager) Authenticate(ctx context.Context, plaintext string) (*Token, error) {
+	if !strings.HasPrefix(plaintext, TokenPrefix) || len(plaintext) <= len(TokenPrefix)+lookupLength {
+		return nil, ErrTokenInvalid
+	}
+
+	// 前缀可能重复，逐个比较摘要
+	var candidates []Token
+	if err := m.db.WithContext(ctx).Where("prefix = ?", plaintext[:len(TokenPrefix)+lookupLength]).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	digest := hash(plaintext)
+	var token *Token
+	for i := range candidates {
+		if subtle.ConstantTimeCompare([]byte(candidates[i].Hash), []byte(digest)) == 1 {
+			token = &candidates[i]
+		}
+	}
+	if token == nil {
+		return nil, ErrTokenInvalid
+	}
+
+	now := m.now()
+	if token.RevokedAt != nil {
+		return nil, ErrTokenRevoked
+	}
+	if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= m.lastUsedInterval {
+		// 条件更新，并发请求中只有一个会写入
+		result := m.db.WithContext(ctx).Model(&Token{}).
+			Where("id = ? AND (last_used_at IS NULL OR last_used_at <= ?)", token.ID, now.Add(-m.lastUsedInterval)).
+			Update("last_used_at", now)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected > 0 {
+			token.LastUsedAt = &now
+		}
+	}
+	return token, nil
+}
+
+// RevokeToken 撤销令牌
+func (m *Manager) RevokeToken(ctx context.Context, id uint) error {
+	result := m.db.WithContext(ctx).Model(&Token{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", m.now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := m.db.WithContext(ctx).Model(&Token{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrTokenNotFound
+		}
+	}
+	return nil
+}
+
+// ListTokens 列出用户的令牌（包括已撤销与过期的），按签发顺序排列，不读取摘要
+func (m *Manager) ListTokens(ctx context.Context, userID string) ([]Token, error) {
+	var tokens []Token
+	err := m.db.WithContext(ctx).Omit("hash").Where("user_id = ?", userID).Order("id").Find(&tokens).Error
+	return tokens, err
+}
+
+// hash 计算令牌明文的 SHA-256 摘要
+func hash(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,164 @@
+package tokens
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestManager(t *testing.T, opts ...Option) (*Manager, *gorm.DB) {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := conn.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	m := NewManager(conn, opts...)
+	require.NoError(t, m.Migrate(context.Background()))
+	return m, conn
+}
+
+func TestIssueTokenStoresOnlyHash(t *testing.T) {
+	m, conn := newTestManager(t)
+	ctx := context.Background()
+
+	plaintext, token, err := m.IssueToken(ctx, "42", "deploy", []string{"read:users"}, time.Time{})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plaintext, TokenPrefix))
+	assert.Len(t, plaintext, len(TokenPrefix)+randomLength)
+	assert.Equal(t, plaintext[:len(TokenPrefix)+lookupLength], token.Prefix)
+
+	var stored Token
+	require.NoError(t, conn.First(&stored, token.ID).Error)
+	assert.NotEqual(t, plaintext, stored.Hash)
+	assert.Equal(t, hash(plaintext), stored.Hash)
+	assert.Equal(t, []string{"read:users"}, stored.Scopes)
+
+	// 数据库中任何列都不包含明文
+	var rows []map[string]interface{}
+	require.NoError(t, conn.Table(Token{}.TableName()).Find(&rows).Error)
+	for _, row := range rows {
+		for _, v := range row {
+			if s, ok := v.(string); ok {
+				assert.NotContains(t, s, plaintext[len(token.Prefix):])
+			}
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(t, WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+
+	plaintext, issued, err := m.IssueToken(ctx, "42", "cli", []string{"read:users", "write:users"}, time.Time{})
+	require.NoError(t, err)
+
+	token, err := m.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, issued.ID, token.ID)
+	assert.Equal(t, "42", token.UserID)
+	assert.True(t, token.HasScope("write:users"))
+	assert.False(t, token.HasScope("admin"))
+
+	_, err = m.Authenticate(ctx, plaintext[:len(plaintext)-1]+"x")
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+	_, err = m.Authenticate(ctx, "flow_pat_")
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+	_, err = m.Authenticate(ctx, "ghp_"+plaintext)
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+}
+
+func TestAuthenticateRejectsRevokedAndExpired(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(t, WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+
+	revoked, token, err := m.IssueToken(ctx, "42", "old", nil, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, m.RevokeToken(ctx, token.ID))
+	_, err = m.Authenticate(ctx, revoked)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// 重复撤销不报错，不存在的令牌返回 ErrTokenNotFound
+	assert.NoError(t, m.RevokeToken(ctx, token.ID))
+	assert.ErrorIs(t, m.RevokeToken(ctx, 999), ErrTokenNotFound)
+
+	expired, _, err := m.IssueToken(ctx, "42", "short", nil, now.Add(time.Hour))
+	require.NoError(t, err)
+	_, err = m.Authenticate(ctx, expired)
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+	_, err = m.Authenticate(ctx, expired)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	list, err := m.ListTokens(ctx, "42")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "old", list[0].Name)
+	assert.NotNil(t, list[0].RevokedAt)
+	assert.Empty(t, list[0].Hash)
+}
+
+func TestLastUsedThrottled(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	m, conn := newTestManager(t, WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+
+	plaintext, token, err := m.IssueToken(ctx, "42", "cli", nil, time.Time{})
+	require.NoError(t, err)
+
+	lastUsed := func() time.Time {
+		var stored Token
+		require.NoError(t, conn.First(&stored, token.ID).Error)
+		require.NotNil(t, stored.LastUsedAt)
+		return stored.LastUsedAt.UTC()
+	}
+
+	_, err = m.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	first := now
+	assert.Equal(t, first, lastUsed())
+
+	// 间隔内的使用不写数据库
+	now = now.Add(30 * time.Second)
+	_, err = m.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, first, lastUsed())
+
+	now = now.Add(30 * time.Second)
+	_, err = m.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, now, lastUsed())
+}
+
+func TestPrefixCollision(t *testing.T) {
+	// 两个令牌的随机部分前 lookupLength 个字符相同，只有末尾不同
+	first := bytes.Repeat([]byte{0}, randomLength*5/8)
+	second := append(bytes.Repeat([]byte{0}, randomLength*5/8-1), 1)
+	m, _ := newTestManager(t, WithRandom(bytes.NewReader(append(first, second...))))
+	ctx := context.Background()
+
+	a, tokenA, err := m.IssueToken(ctx, "1", "a", []string{"read"}, time.Time{})
+	require.NoError(t, err)
+	b, tokenB, err := m.IssueToken(ctx, "2", "b", []string{"write"}, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, tokenA.Prefix, tokenB.Prefix)
+	require.NotEqual(t, a, b)
+
+	got, err := m.Authenticate(ctx, a)
+	require.NoError(t, err)
+	assert.Equal(t, "1", got.UserID)
+	got, err = m.Authenticate(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, "2", got.UserID)
+}
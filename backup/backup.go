@@ -0,0 +1,450 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/db"
+)
+
+// 备份相关错误
+var (
+	// ErrBackupNotFound 备份不存在
+	ErrBackupNotFound = errors.New("备份不存在")
+	// ErrChecksumMismatch 备份文件校验失败
+	ErrChecksumMismatch = errors.New("备份文件校验和不匹配")
+	// ErrTargetNotEmpty 恢复目标非空且未指定强制覆盖
+	ErrTargetNotEmpty = errors.New("恢复目标非空，使用 --force 强制覆盖")
+	// ErrKeyRequired 加密备份缺少密钥
+	ErrKeyRequired = errors.New("备份已加密，需要提供密钥")
+)
+
+// manifestFile 清单文件名
+const manifestFile = "manifest.json"
+
+// Manifest 备份清单
+type Manifest struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	FlowVersion string    `json:"flow_version"`
+	GoVersion   string    `json:"go_version"`
+	Encrypted   bool      `json:"encrypted"`
+	Entries     []Entry   `json:"entries"`
+}
+
+// Entry 备份中的单个文件
+type Entry struct {
+	Type       string `json:"type"` // 目前仅支持 database
+	Connection string `json:"connection"`
+	Driver     string `json:"driver"`
+	File       string `json:"file"`
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+}
+
+// Manager 备份管理器，备份以目录形式存放在目标目录下
+type Manager struct {
+	dir         string
+	databases   *db.Manager
+	connections []string
+	key         []byte
+	retention   int
+	dumpers     map[string]Dumper
+	now         func() time.Time
+}
+
+// Option 备份管理器选项
+type Option func(*Manager)
+
+// WithConnections 只备份指定的数据库连接，默认备份全部连接
+func WithConnections(names ...string) Option {
+	return func(m *Manager) {
+		m.connections = names
+	}
+}
+
+// WithEncryptionKey 使用AES-GCM加密备份文件
+func WithEncryptionKey(key []byte) Option {
+	return func(m *Manager) {
+		m.key = key
+	}
+}
+
+// WithRetention 设置保留的备份数量，为0时不清理
+func WithRetention(count int) Option {
+	return func(m *Manager) {
+		m.retention = count
+	}
+}
+
+// WithDumper 为指定驱动注册导出器
+func WithDumper(driver string, dumper Dumper) Option {
+	return func(m *Manager) {
+		m.dumpers[driver] = dumper
+	}
+}
+
+// NewManager 创建备份管理器
+func NewManager(dir string, databases *db.Manager, options ...Option) *Manager {
+	m := &Manager{
+		dir:       dir,
+		databases: databases,
+		dumpers: map[string]Dumper{
+			db.SQLite:     SQLiteDumper{},
+			db.PostgreSQL: PostgresDumper(),
+			db.MySQL:      MySQLDumper(),
+		},
+		now: time.Now,
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// Create 创建一次备份并按保留策略清理旧备份
+func (m *Manager) Create(ctx context.Context) (*Manifest, error) {
+	if len(m.key) > 0 {
+		if _, err := newGCM(m.key); err != nil {
+			return nil, err
+		}
+	}
+
+	createdAt := m.now().UTC()
+	manifest := &Manifest{
+		ID:          createdAt.Format("20060102T150405.000Z"),
+		CreatedAt:   createdAt,
+		FlowVersion: flow.Version,
+		GoVersion:   runtime.Version(),
+		Encrypted:   len(m.key) > 0,
+	}
+
+	backupDir := filepath.Join(m.dir, manifest.ID)
+	if err := os.MkdirAll(backupDir, 0750); err != nil {
+		return nil, err
+	}
+
+	configs, err := m.selectedConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedKeys(configs) {
+		config := configs[name]
+		dumper, ok := m.dumpers[config.Driver]
+		if !ok {
+			os.RemoveAll(backupDir)
+			return nil, fmt.Errorf("连接 %s 的驱动 %s 不支持备份", name, config.Driver)
+		}
+
+		entry, err := m.dumpDatabase(ctx, backupDir, name, config, dumper)
+		if err != nil {
+			os.RemoveAll(backupDir)
+			return nil, fmt.Errorf("备份连接 %s 失败: %w", name, err)
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	if err := writeManifest(backupDir, manifest); err != nil {
+		os.RemoveAll(backupDir)
+		return nil, err
+	}
+
+	if m.retention > 0 {
+		if _, err := m.Prune(); err != nil {
+			return manifest, fmt.Errorf("清理旧备份失败: %w", err)
+		}
+	}
+
+	return manifest, nil
+}
+
+// dumpDatabase 导出单个数据库到备份目录，边写边计算校验和
+func (m *Manager) dumpDatabase(ctx context.Context, backupDir, name string, config db.Config, dumper Dumper) (Entry, error) {
+	fileName := "db-" + name + ".dump"
+	if len(m.key) > 0 {
+		fileName += ".enc"
+	}
+
+	file, err := os.OpenFile(filepath.Join(backupDir, fileName), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	counter := &countWriter{}
+	var w io.Writer = io.MultiWriter(file, hash, counter)
+
+	var enc *encryptWriter
+	if len(m.key) > 0 {
+		if enc, err = newEncryptWriter(w, m.key); err != nil {
+			return Entry{}, err
+		}
+		w = enc
+	}
+
+	if err := dumper.Dump(ctx, config, w); err != nil {
+		return Entry{}, err
+	}
+	if enc != nil {
+		if err := enc.Close(); err != nil {
+			return Entry{}, err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return Entry{}, err
+	}
+
+	return Entry{
+		Type:       "database",
+		Connection: name,
+		Driver:     config.Driver,
+		File:       fileName,
+		Size:       counter.n,
+		SHA256:     hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// RestoreOptions 恢复选项
+type RestoreOptions struct {
+	// Force 目标非空时仍然覆盖
+	Force bool
+	// Connections 只恢复指定连接，为空时恢复全部
+	Connections []string
+}
+
+// Restore 校验并恢复指定备份
+func (m *Manager) Restore(ctx context.Context, id string, options RestoreOptions) error {
+	manifest, err := m.Verify(id)
+	if err != nil {
+		return err
+	}
+	if manifest.Encrypted && len(m.key) == 0 {
+		return ErrKeyRequired
+	}
+
+	configs := m.databases.Configs()
+	entries := make([]Entry, 0, len(manifest.Entries))
+	for _, entry := range manifest.Entries {
+		if len(options.Connections) > 0 && !contains(options.Connections, entry.Connection) {
+			continue
+		}
+		config, ok := configs[entry.Connection]
+		if !ok {
+			return fmt.Errorf("恢复目标连接未配置: %s", entry.Connection)
+		}
+		dumper, ok := m.dumpers[config.Driver]
+		if !ok {
+			return fmt.Errorf("连接 %s 的驱动 %s 不支持恢复", entry.Connection, config.Driver)
+		}
+
+		// 全部目标检查通过后才开始恢复
+		if !options.Force {
+			empty, err := dumper.IsEmpty(ctx, config)
+			if err != nil {
+				return err
+			}
+			if !empty {
+				return fmt.Errorf("%w: %s", ErrTargetNotEmpty, entry.Connection)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	backupDir := filepath.Join(m.dir, manifest.ID)
+	for _, entry := range entries {
+		config := configs[entry.Connection]
+		if err := m.restoreEntry(ctx, backupDir, entry, config, m.dumpers[config.Driver]); err != nil {
+			return fmt.Errorf("恢复连接 %s 失败: %w", entry.Connection, err)
+		}
+	}
+	return nil
+}
+
+// restoreEntry 恢复单个备份文件
+func (m *Manager) restoreEntry(ctx context.Context, backupDir string, entry Entry, config db.Config, dumper Dumper) error {
+	file, err := os.Open(filepath.Join(backupDir, entry.File))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if len(m.key) > 0 && filepath.Ext(entry.File) == ".enc" {
+		if r, err = newDecryptReader(file, m.key); err != nil {
+			return err
+		}
+	}
+	return dumper.Restore(ctx, config, r)
+}
+
+// Verify 读取清单并校验所有备份文件
+func (m *Manager) Verify(id string) (*Manifest, error) {
+	backupDir := filepath.Join(m.dir, filepath.Base(id))
+	manifest, err := readManifest(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range manifest.Entries {
+		sum, err := fileChecksum(filepath.Join(backupDir, entry.File))
+		if err != nil {
+			return nil, err
+		}
+		if sum != entry.SHA256 {
+			return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, entry.File)
+		}
+	}
+	return manifest, nil
+}
+
+// List 列出目标目录下的所有备份，按创建时间从新到旧排列
+func (m *Manager) List() ([]*Manifest, error) {
+	dirs, err := os.ReadDir(m.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make([]*Manifest, 0, len(dirs))
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		manifest, err := readManifest(filepath.Join(m.dir, d.Name()))
+		if err != nil {
+			continue // 跳过不完整的备份目录
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].CreatedAt.After(manifests[j].CreatedAt)
+	})
+	return manifests, nil
+}
+
+// Prune 按保留数量删除最旧的备份，返回被删除的备份ID
+func (m *Manager) Prune() ([]string, error) {
+	if m.retention <= 0 {
+		return nil, nil
+	}
+
+	manifests, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i := m.retention; i < len(manifests); i++ {
+		if err := os.RemoveAll(filepath.Join(m.dir, manifests[i].ID)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, manifests[i].ID)
+	}
+	return removed, nil
+}
+
+// selectedConfigs 返回需要备份的连接配置
+func (m *Manager) selectedConfigs() (map[string]db.Config, error) {
+	configs := m.databases.Configs()
+	if len(m.connections) == 0 {
+		return configs, nil
+	}
+
+	selected := make(map[string]db.Config, len(m.connections))
+	for _, name := range m.connections {
+		config, ok := configs[name]
+		if !ok {
+			return nil, fmt.Errorf("数据库连接未配置: %s", name)
+		}
+		selected[name] = config
+	}
+	return selected, nil
+}
+
+// writeManifest 写入清单文件
+func writeManifest(dir string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, manifestFile), data, 0640)
+}
+
+// readManifest 读取清单文件
+func readManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, filepath.Base(dir))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("备份清单格式错误: %w", err)
+	}
+	return &manifest, nil
+}
+
+// fileChecksum 计算文件SHA256
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// countWriter 统计写入字节数
+type countWriter struct {
+	n int64
+}
+
+// Write 计数
+func (c *countWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// sortedKeys 返回排序后的连接名称
+func sortedKeys(configs map[string]db.Config) []string {
+	keys := make([]string, 0, len(configs))
+	for name := range configs {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// contains 判断切片是否包含指定值
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/db"
+)
+
+type note struct {
+	ID   uint
+	Body string
+}
+
+// setupSQLite 创建包含测试数据的SQLite数据库
+func setupSQLite(t *testing.T) (*db.Manager, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.db")
+
+	manager := db.NewManager()
+	require.NoError(t, manager.Register("default", db.Config{Driver: db.SQLite, Database: path}))
+	conn, err := manager.Connection("default")
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&note{}))
+	require.NoError(t, conn.Create(&note{Body: "hello"}).Error)
+	return manager, path
+}
+
+func countNotes(t *testing.T, path string) int64 {
+	t.Helper()
+	manager := db.NewManager()
+	defer manager.Close()
+	require.NoError(t, manager.Register("check", db.Config{Driver: db.SQLite, Database: path}))
+	conn, err := manager.Connection("check")
+	require.NoError(t, err)
+
+	var count int64
+	require.NoError(t, conn.Model(&note{}).Count(&count).Error)
+	return count
+}
+
+func TestBackup_SQLiteRoundTrip(t *testing.T) {
+	manager, path := setupSQLite(t)
+	defer manager.Close()
+	ctx := context.Background()
+
+	backups := NewManager(t.TempDir(), manager)
+	manifest, err := backups.Create(ctx)
+	require.NoError(t, err)
+	require.Len(t, manifest.Entries, 1)
+	assert.Equal(t, "default", manifest.Entries[0].Connection)
+	assert.NotEmpty(t, manifest.Entries[0].SHA256)
+
+	// 目标非空时拒绝覆盖
+	err = backups.Restore(ctx, manifest.ID, RestoreOptions{})
+	assert.True(t, errors.Is(err, ErrTargetNotEmpty))
+
+	// 删除数据库后恢复
+	require.NoError(t, manager.Close())
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, backups.Restore(ctx, manifest.ID, RestoreOptions{}))
+	assert.Equal(t, int64(1), countNotes(t, path))
+
+	// 强制覆盖
+	require.NoError(t, backups.Restore(ctx, manifest.ID, RestoreOptions{Force: true}))
+	assert.Equal(t, int64(1), countNotes(t, path))
+}
+
+func TestBackup_ManifestVerification(t *testing.T) {
+	manager, _ := setupSQLite(t)
+	defer manager.Close()
+
+	dir := t.TempDir()
+	backups := NewManager(dir, manager)
+	manifest, err := backups.Create(context.Background())
+	require.NoError(t, err)
+
+	_, err = backups.Verify(manifest.ID)
+	require.NoError(t, err)
+
+	// 篡改备份文件
+	file := filepath.Join(dir, manifest.ID, manifest.Entries[0].File)
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("tampered")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = backups.Verify(manifest.ID)
+	assert.True(t, errors.Is(err, ErrChecksumMismatch))
+	err = backups.Restore(context.Background(), manifest.ID, RestoreOptions{Force: true})
+	assert.True(t, errors.Is(err, ErrChecksumMismatch), "校验失败时不应恢复")
+
+	_, err = backups.Verify("missing")
+	assert.True(t, errors.Is(err, ErrBackupNotFound))
+}
+
+func TestBackup_EncryptionRoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	// 跨越多个加密帧的数据
+	plain := make([]byte, encryptChunkSize*2+123)
+	_, err = rand.Read(plain)
+	require.NoError(t, err)
+
+	var sealed bytes.Buffer
+	enc, err := newEncryptWriter(&sealed, key)
+	require.NoError(t, err)
+	_, err = enc.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, enc.Close())
+	assert.NotContains(t, sealed.String(), string(plain[:64]))
+
+	dec, err := newDecryptReader(bytes.NewReader(sealed.Bytes()), key)
+	require.NoError(t, err)
+	decrypted, err := io.ReadAll(dec)
+	require.NoError(t, err)
+	assert.Equal(t, plain, decrypted)
+
+	// 错误的密钥无法解密
+	wrong := make([]byte, 32)
+	dec, err = newDecryptReader(bytes.NewReader(sealed.Bytes()), wrong)
+	require.NoError(t, err)
+	_, err = io.ReadAll(dec)
+	assert.Error(t, err)
+
+	// 恰好整帧的数据同样以最后一帧结束
+	var exact bytes.Buffer
+	enc, err = newEncryptWriter(&exact, key)
+	require.NoError(t, err)
+	_, err = enc.Write(plain[:encryptChunkSize])
+	require.NoError(t, err)
+	require.NoError(t, enc.Close())
+	dec, err = newDecryptReader(bytes.NewReader(exact.Bytes()), key)
+	require.NoError(t, err)
+	decrypted, err = io.ReadAll(dec)
+	require.NoError(t, err)
+	assert.Equal(t, plain[:encryptChunkSize], decrypted)
+
+	// 加密备份的完整往返
+	manager, path := setupSQLite(t)
+	backups := NewManager(t.TempDir(), manager, WithEncryptionKey(key))
+	manifest, err := backups.Create(context.Background())
+	require.NoError(t, err)
+	assert.True(t, manifest.Encrypted)
+	require.NoError(t, manager.Close())
+	require.NoError(t, os.Remove(path))
+
+	err = NewManager(backups.dir, manager).Restore(context.Background(), manifest.ID, RestoreOptions{})
+	assert.True(t, errors.Is(err, ErrKeyRequired))
+	require.NoError(t, backups.Restore(context.Background(), manifest.ID, RestoreOptions{}))
+	assert.Equal(t, int64(1), countNotes(t, path))
+}
+
+func TestBackup_EncryptionDetectsTampering(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	plain := make([]byte, encryptChunkSize*2+123)
+	_, err = rand.Read(plain)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	enc, err := newEncryptWriter(&buf, key)
+	require.NoError(t, err)
+	_, err = enc.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, enc.Close())
+	sealed := buf.Bytes()
+
+	decrypt := func(data []byte) error {
+		dec, err := newDecryptReader(bytes.NewReader(data), key)
+		require.NoError(t, err)
+		_, err = io.ReadAll(dec)
+		return err
+	}
+	require.NoError(t, decrypt(sealed))
+
+	header := len(encryptMagic)
+	frame := 5 + 12 + encryptChunkSize + 16
+	first := sealed[header : header+frame]
+	second := sealed[header+frame : header+2*frame]
+
+	// 在帧边界截断：缺少最后一帧
+	assert.ErrorIs(t, decrypt(sealed[:header+2*frame]), ErrTruncated)
+	assert.ErrorIs(t, decrypt(sealed[:len(sealed)-3]), ErrTruncated)
+
+	// 交换帧的顺序
+	swapped := append(append(append([]byte(encryptMagic), second...), first...), sealed[header+2*frame:]...)
+	assert.Error(t, decrypt(swapped))
+
+	// 把中间帧标记为最后一帧
+	forged := append([]byte(nil), sealed[:header+frame]...)
+	forged[header] = frameFinal
+	assert.Error(t, decrypt(forged))
+
+	// 最后一帧之后附加数据
+	assert.Error(t, decrypt(append(append([]byte(nil), sealed...), first...)))
+}
+
+func TestBackup_RetentionPruning(t *testing.T) {
+	manager, _ := setupSQLite(t)
+	defer manager.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	backups := NewManager(t.TempDir(), manager, WithRetention(2))
+	backups.now = func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		manifest, err := backups.Create(context.Background())
+		require.NoError(t, err)
+		ids = append(ids, manifest.ID)
+	}
+
+	manifests, err := backups.List()
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+	assert.Equal(t, ids[3], manifests[0].ID)
+	assert.Equal(t, ids[2], manifests[1].ID)
+}
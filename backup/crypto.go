@@ -0,0 +1,202 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 加密文件格式：魔数 + 若干帧，每帧为 1字节标志 + 4字节密文长度 + 12字节nonce + 密文
+// 分帧加密使备份可以流式处理，内存占用与文件大小无关。每帧的附加数据包含魔数、帧序号与标志，
+// 帧被重排、替换或在最后一帧之前截断时解密失败
+const (
+	encryptMagic     = "FLOWENC2"
+	encryptChunkSize = 64 * 1024
+
+	frameMore  byte = 0 // 后面还有帧
+	frameFinal byte = 1 // 最后一帧
+)
+
+// ErrTruncated 加密备份在最后一帧之前结束
+var ErrTruncated = errors.New("加密备份不完整，缺少最后一帧")
+
+// ErrInvalidKey 加密密钥长度无效
+var ErrInvalidKey = errors.New("备份加密密钥必须为16、24或32字节")
+
+// newGCM 根据密钥创建AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// frameAAD 返回帧的附加数据
+func frameAAD(index uint64, flag byte) []byte {
+	aad := make([]byte, 0, len(encryptMagic)+9)
+	aad = append(aad, encryptMagic...)
+	aad = binary.BigEndian.AppendUint64(aad, index)
+	return append(aad, flag)
+}
+
+// encryptWriter 分帧加密写入器
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	buf    []byte
+	index  uint64
+	header bool
+	closed bool
+}
+
+// newEncryptWriter 创建加密写入器，调用方必须调用Close写出最后一帧
+func newEncryptWriter(w io.Writer, key []byte) (*encryptWriter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, buf: make([]byte, 0, encryptChunkSize)}, nil
+}
+
+// Write 缓冲明文，缓冲已满且还有数据时写出一帧，最后一帧留到Close写出
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("加密写入器已关闭")
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(e.buf) == cap(e.buf) {
+			if err := e.flush(frameMore); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close 写出剩余数据作为最后一帧，没有数据时写出空的最后一帧
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.flush(frameFinal)
+}
+
+// flush 加密并写出当前帧
+func (e *encryptWriter) flush(flag byte) error {
+	if !e.header {
+		if _, err := io.WriteString(e.w, encryptMagic); err != nil {
+			return err
+		}
+		e.header = true
+	}
+
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := e.aead.Seal(nil, nonce, e.buf, frameAAD(e.index, flag))
+
+	var head [5]byte
+	head[0] = flag
+	binary.BigEndian.PutUint32(head[1:], uint32(len(sealed)))
+	for _, part := range [][]byte{head[:], nonce, sealed} {
+		if _, err := e.w.Write(part); err != nil {
+			return err
+		}
+	}
+	e.index++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// decryptReader 分帧解密读取器
+type decryptReader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	plain  []byte
+	index  uint64
+	header bool
+	final  bool
+}
+
+// newDecryptReader 创建解密读取器
+func newDecryptReader(r io.Reader, key []byte) (*decryptReader, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{r: r, aead: aead}, nil
+}
+
+// Read 读取并解密，在最后一帧之前结束时返回 ErrTruncated
+func (d *decryptReader) Read(p []byte) (int, error) {
+	if !d.header {
+		magic := make([]byte, len(encryptMagic))
+		if _, err := io.ReadFull(d.r, magic); err != nil || string(magic) != encryptMagic {
+			return 0, errors.New("备份文件不是有效的加密格式")
+		}
+		d.header = true
+	}
+
+	for len(d.plain) == 0 {
+		if d.final {
+			// 最后一帧之后不允许有多余的数据
+			var extra [1]byte
+			if n, _ := io.ReadFull(d.r, extra[:]); n > 0 {
+				return 0, errors.New("加密备份在最后一帧之后还有数据")
+			}
+			return 0, io.EOF
+		}
+
+		var head [5]byte
+		if _, err := io.ReadFull(d.r, head[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return 0, ErrTruncated
+			}
+			return 0, err
+		}
+		flag := head[0]
+		if flag != frameMore && flag != frameFinal {
+			return 0, fmt.Errorf("加密帧标志无效: %d", flag)
+		}
+		length := binary.BigEndian.Uint32(head[1:])
+		if length > encryptChunkSize+uint32(d.aead.Overhead()) {
+			return 0, fmt.Errorf("加密帧长度无效: %d", length)
+		}
+
+		frame := make([]byte, d.aead.NonceSize()+int(length))
+		if _, err := io.ReadFull(d.r, frame); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return 0, ErrTruncated
+			}
+			return 0, err
+		}
+		plain, err := d.aead.Open(nil, frame[:d.aead.NonceSize()], frame[d.aead.NonceSize():], frameAAD(d.index, flag))
+		if err != nil {
+			return 0, fmt.Errorf("解密备份失败: %w", err)
+		}
+		d.index++
+		d.final = flag == frameFinal
+		d.plain = plain
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
@@ -0,0 +1,239 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/zzliekkas/flow/v2/db"
+)
+
+// Dumper 数据库导出导入器
+type Dumper interface {
+	// Dump 将数据库内容写入w
+	Dump(ctx context.Context, config db.Config, w io.Writer) error
+
+	// Restore 从r恢复数据库内容
+	Restore(ctx context.Context, config db.Config, r io.Reader) error
+
+	// IsEmpty 判断目标数据库是否为空，用于防止误覆盖
+	IsEmpty(ctx context.Context, config db.Config) (bool, error)
+}
+
+// SQLiteDumper SQLite导出器，在写锁事务内直接复制数据库文件，无需外部工具
+type SQLiteDumper struct{}
+
+// Dump 导出SQLite数据库
+func (SQLiteDumper) Dump(ctx context.Context, config db.Config, w io.Writer) error {
+	conn, err := sql.Open("sqlite3", config.Database)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// 将WAL内容合并到主文件，再持有写锁复制，保证快照一致
+	if _, err := c.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return err
+	}
+	if _, err := c.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = c.ExecContext(context.Background(), "ROLLBACK")
+	}()
+
+	file, err := os.Open(config.Database)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// Restore 恢复SQLite数据库，先写入临时文件再原子替换
+func (SQLiteDumper) Restore(ctx context.Context, config db.Config, r io.Reader) error {
+	dir := filepath.Dir(config.Database)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// 移除旧的WAL文件，避免与新数据库内容不一致
+	_ = os.Remove(config.Database + "-wal")
+	_ = os.Remove(config.Database + "-shm")
+	return os.Rename(tmp.Name(), config.Database)
+}
+
+// IsEmpty 数据库文件不存在或为空时视为空
+func (SQLiteDumper) IsEmpty(ctx context.Context, config db.Config) (bool, error) {
+	info, err := os.Stat(config.Database)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return info.Size() == 0, nil
+}
+
+// CommandDumper 通过外部命令（pg_dump、mysqldump等）导出导入
+type CommandDumper struct {
+	// DumpCommand 构建导出命令
+	DumpCommand func(ctx context.Context, config db.Config) *exec.Cmd
+
+	// RestoreCommand 构建导入命令
+	RestoreCommand func(ctx context.Context, config db.Config) *exec.Cmd
+
+	// Tables 返回目标库中的表，用于判断是否为空
+	Tables func(ctx context.Context, config db.Config) ([]string, error)
+}
+
+// Dump 执行导出命令，输出写入w
+func (d CommandDumper) Dump(ctx context.Context, config db.Config, w io.Writer) error {
+	cmd := d.DumpCommand(ctx, config)
+	cmd.Stdout = w
+	return runCommand(cmd)
+}
+
+// Restore 执行导入命令，从r读取输入
+func (d CommandDumper) Restore(ctx context.Context, config db.Config, r io.Reader) error {
+	cmd := d.RestoreCommand(ctx, config)
+	cmd.Stdin = r
+	return runCommand(cmd)
+}
+
+// IsEmpty 判断目标库是否没有任何表
+func (d CommandDumper) IsEmpty(ctx context.Context, config db.Config) (bool, error) {
+	if d.Tables == nil {
+		return false, nil
+	}
+	tables, err := d.Tables(ctx, config)
+	if err != nil {
+		return false, err
+	}
+	return len(tables) == 0, nil
+}
+
+// runCommand 执行命令并在失败时附带标准错误输出
+func runCommand(cmd *exec.Cmd) error {
+	stderr := &limitedBuffer{limit: 4096}
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s 执行失败: %w: %s", filepath.Base(cmd.Path), err, stderr.String())
+	}
+	return nil
+}
+
+// PostgresDumper 使用pg_dump/pg_restore的PostgreSQL导出器，密码通过PGPASSWORD传递
+func PostgresDumper() Dumper {
+	env := func(config db.Config) []string {
+		return append(os.Environ(), "PGPASSWORD="+config.Password)
+	}
+	args := func(config db.Config) []string {
+		return []string{"-h", config.Host, "-p", strconv.Itoa(config.Port), "-U", config.Username}
+	}
+
+	return CommandDumper{
+		DumpCommand: func(ctx context.Context, config db.Config) *exec.Cmd {
+			cmd := exec.CommandContext(ctx, "pg_dump", append(args(config), "-Fc", config.Database)...)
+			cmd.Env = env(config)
+			return cmd
+		},
+		RestoreCommand: func(ctx context.Context, config db.Config) *exec.Cmd {
+			cmd := exec.CommandContext(ctx, "pg_restore",
+				append(args(config), "--clean", "--if-exists", "--no-owner", "-d", config.Database)...)
+			cmd.Env = env(config)
+			return cmd
+		},
+		Tables: connectionTables,
+	}
+}
+
+// MySQLDumper 使用mysqldump/mysql的MySQL导出器，密码通过MYSQL_PWD传递
+func MySQLDumper() Dumper {
+	env := func(config db.Config) []string {
+		return append(os.Environ(), "MYSQL_PWD="+config.Password)
+	}
+	args := func(config db.Config) []string {
+		return []string{"-h", config.Host, "-P", strconv.Itoa(config.Port), "-u", config.Username}
+	}
+
+	return CommandDumper{
+		DumpCommand: func(ctx context.Context, config db.Config) *exec.Cmd {
+			cmd := exec.CommandContext(ctx, "mysqldump",
+				append(args(config), "--single-transaction", "--routines", "--triggers", config.Database)...)
+			cmd.Env = env(config)
+			return cmd
+		},
+		RestoreCommand: func(ctx context.Context, config db.Config) *exec.Cmd {
+			cmd := exec.CommandContext(ctx, "mysql", append(args(config), config.Database)...)
+			cmd.Env = env(config)
+			return cmd
+		},
+		Tables: connectionTables,
+	}
+}
+
+// connectionTables 通过GORM连接列出目标库中的表
+func connectionTables(ctx context.Context, config db.Config) ([]string, error) {
+	manager := db.NewManager()
+	defer manager.Close()
+
+	if err := manager.Register("backup", config); err != nil {
+		return nil, err
+	}
+	conn, err := manager.Connection("backup")
+	if err != nil {
+		return nil, err
+	}
+	return conn.WithContext(ctx).Migrator().GetTables()
+}
+
+// limitedBuffer 只保留前limit字节的缓冲区
+type limitedBuffer struct {
+	data  []byte
+	limit int
+}
+
+// Write 写入数据，超出部分丢弃
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - len(b.data); remain > 0 {
+		if len(p) > remain {
+			b.data = append(b.data, p[:remain]...)
+		} else {
+			b.data = append(b.data, p...)
+		}
+	}
+	return len(p), nil
+}
+
+// String 返回缓冲内容
+func (b *limitedBuffer) String() string {
+	return string(b.data)
+}
@@ -0,0 +1,483 @@
+package flow
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/zzliekkas/flow/v2/validation"
+)
+
+// 绑定来源
+const (
+	BindSourceURI    = "uri"
+	BindSourceQuery  = "query"
+	BindSourceHeader = "header"
+	BindSourceForm   = "form"
+)
+
+// BindError 参数绑定错误，指明出错的来源与参数名
+type BindError struct {
+	Source string // 来源：uri、query、header、form
+	Param  string // 参数名
+	Value  string // 原始值
+	Err    error
+}
+
+// Error 实现error接口
+func (e *BindError) Error() string {
+	return fmt.Sprintf("%s 参数 %s 的值 %q 无效: %v", e.Source, e.Param, e.Value, e.Err)
+}
+
+// Unwrap 返回底层错误
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// BindUri 按 `uri:"id"` 标签将路由参数绑定到结构体，失败时响应400
+//
+// 支持字符串、整数、浮点数、布尔、time.Duration、time.Time（`layout:"2006-01-02"`，默认RFC3339）
+// 以及实现了 encoding.TextUnmarshaler 的类型（如UUID）。绑定后按 `binding` 标签验证，与gin保持一致；
+// 同时按 `validate` 标签验证，失败时返回 validation.ValidationError，字段名为参数名
+func (c *Context) BindUri(dst interface{}) error {
+	return c.abortOnBindError(validateBinding(dst, BindSourceURI, bindValues(dst, BindSourceURI, c.uriValues(), true)))
+}
+
+// BindQuery 按 `query:"name"` 标签（兼容 `form` 标签）绑定查询参数，失败时响应400
+//
+// 切片可通过重复参数传入（?tag=a&tag=b），嵌套结构体支持 a.b 与 a[b] 两种写法，
+// 缺失的参数使用 `default:"..."` 标签的值，指针字段只在参数存在时赋值。
+// 绑定后按 `validate` 标签验证（如 `validate:"required,min=1"`），失败时返回 validation.ValidationError
+func (c *Context) BindQuery(dst interface{}) error {
+	return c.abortOnBindError(validateBinding(dst, BindSourceQuery, bindValues(dst, BindSourceQuery, c.Request.URL.Query(), true)))
+}
+
+// BindHeader 按 `header:"X-Api-Key"` 标签绑定请求头，失败时响应400，验证规则与 BindQuery 相同
+func (c *Context) BindHeader(dst interface{}) error {
+	return c.abortOnBindError(validateBinding(dst, BindSourceHeader, bindValues(dst, BindSourceHeader, headerValues(c.Request.Header), true)))
+}
+
+// validateBinding 绑定成功后依次按gin的 `binding` 标签与 `validate` 标签验证
+func validateBinding(dst interface{}, source string, err error) error {
+	if err != nil {
+		return err
+	}
+	if err := binding.Validator.ValidateStruct(dst); err != nil {
+		return err
+	}
+	return paramValidationError(dst, source, validation.Validate(dst))
+}
+
+// paramValidationError 将验证器错误转换为 validation.ValidationError，字段名使用来源中的参数名
+func paramValidationError(dst interface{}, source string, err error) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	trans := validation.GetTranslator()
+	rt := reflect.TypeOf(dst).Elem()
+	fieldErrors := make([]validation.FieldError, 0, len(errs))
+	for _, e := range errs {
+		fieldErrors = append(fieldErrors, validation.FieldError{
+			Field:   paramName(rt, e.StructNamespace(), source),
+			Message: e.Translate(trans),
+			Tag:     e.Tag(),
+			Value:   e.Value(),
+		})
+	}
+	return validation.ValidationError{Errors: fieldErrors}
+}
+
+// paramName 按结构体命名空间（如 Query.Filter.Status）查找参数名，找不到标签时使用字段名
+func paramName(rt reflect.Type, namespace, source string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		// 切片与映射元素的命名空间形如 Tags[0]
+		part = strings.SplitN(part, "[", 2)[0]
+		for rt != nil && (rt.Kind() == reflect.Pointer || rt.Kind() == reflect.Slice) {
+			rt = rt.Elem()
+		}
+		if rt == nil || rt.Kind() != reflect.Struct {
+			names = append(names, part)
+			rt = nil
+			continue
+		}
+		field, ok := rt.FieldByName(part)
+		if !ok {
+			names = append(names, part)
+			rt = nil
+			continue
+		}
+		rt = field.Type
+		if name := tagName(field, source); name != "" && name != "-" {
+			names = append(names, name)
+		} else if !field.Anonymous {
+			names = append(names, field.Name)
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+// BindAndValidate 依次从请求体、查询参数、请求头与路由参数绑定到同一个结构体并执行验证，失败时响应400
+//
+// 同一字段出现在多个来源时，后绑定的来源优先：路由参数 > 请求头 > 查询参数 > 请求体 > default标签。
+// 请求体支持JSON与表单，读取后仍可通过 RawBody 获取；验证使用 `validate` 标签
+func (c *Context) BindAndValidate(dst interface{}) error {
+	return c.abortOnBindError(c.ShouldBindAndValidate(dst))
+}
+
+// ShouldBindAndValidate 与 BindAndValidate 相同，但不写入响应
+func (c *Context) ShouldBindAndValidate(dst interface{}) error {
+	if err := applyDefaults(reflect.ValueOf(dst)); err != nil {
+		return err
+	}
+	if err := c.bindBody(dst); err != nil {
+		return err
+	}
+	if err := bindValues(dst, BindSourceQuery, c.Request.URL.Query(), false); err != nil {
+		return err
+	}
+	if err := bindValues(dst, BindSourceHeader, headerValues(c.Request.Header), false); err != nil {
+		return err
+	}
+	if err := bindValues(dst, BindSourceURI, c.uriValues(), false); err != nil {
+		return err
+	}
+	return validation.Validate(dst)
+}
+
+// bindBody 按内容类型解码请求体，没有请求体时跳过
+func (c *Context) bindBody(dst interface{}) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		if err := c.Request.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return &BindError{Source: BindSourceForm, Param: "body", Err: err}
+		}
+		return bindValues(dst, BindSourceForm, c.Request.PostForm, false)
+	case "application/json", "":
+		body, err := c.RawBody()
+		if err != nil {
+			return err
+		}
+		if len(strings.TrimSpace(string(body))) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(body, dst); err != nil {
+			return fmt.Errorf("解析JSON请求体失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// uriValues 将路由参数转换为 url.Values
+func (c *Context) uriValues() url.Values {
+	values := make(url.Values, len(c.Params))
+	for _, p := range c.Params {
+		values[p.Key] = []string{p.Value}
+	}
+	return values
+}
+
+// abortOnBindError 绑定失败时响应400（请求体超限为413），错误信息包含参数名
+func (c *Context) abortOnBindError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var bindErr *BindError
+	var validationErrs validator.ValidationErrors
+	var paramErrs validation.ValidationError
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, H{"error": err.Error()})
+	case errors.As(err, &bindErr):
+		c.AbortWithStatusJSON(http.StatusBadRequest, H{
+			"error":  err.Error(),
+			"field":  bindErr.Param,
+			"source": bindErr.Source,
+		})
+	case errors.As(err, &paramErrs):
+		details := make(H, len(paramErrs.Errors))
+		for _, fe := range paramErrs.Errors {
+			details[fe.Field] = fe.Message
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, H{
+			"error":   "参数验证失败",
+			"details": details,
+		})
+	case errors.As(err, &validationErrs):
+		c.AbortWithStatusJSON(http.StatusBadRequest, H{
+			"error":   "参数验证失败",
+			"details": validation.TranslateError(validationErrs),
+		})
+	default:
+		c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": err.Error()})
+	}
+	return err
+}
+
+// headerValues 将请求头的键转为小写，实现大小写不敏感的查找
+func headerValues(header http.Header) url.Values {
+	values := make(url.Values, len(header))
+	for key, v := range header {
+		values[strings.ToLower(key)] = v
+	}
+	return values
+}
+
+// normalizeKeys 将 a[b] 形式的键转换为 a.b
+func normalizeKeys(values url.Values) url.Values {
+	normalized := make(url.Values, len(values))
+	for key, v := range values {
+		if strings.Contains(key, "[") {
+			key = strings.NewReplacer("][", ".", "[", ".", "]", "").Replace(key)
+		}
+		normalized[key] = append(normalized[key], v...)
+	}
+	return normalized
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// bindValues 按来源标签将值绑定到结构体，withDefaults 为true时缺失的参数使用default标签
+func bindValues(dst interface{}, source string, values url.Values, withDefaults bool) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("绑定目标必须是非nil的结构体指针")
+	}
+	if source == BindSourceQuery || source == BindSourceForm {
+		values = normalizeKeys(values)
+	}
+	return bindStruct(rv.Elem(), source, values, "", withDefaults)
+}
+
+// bindStruct 绑定结构体的各字段，prefix 为嵌套结构体的键前缀
+func bindStruct(rv reflect.Value, source string, values url.Values, prefix string, withDefaults bool) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+
+		name := tagName(field, source)
+		if name == "-" {
+			continue
+		}
+
+		// 嵌套结构体（time.Time 与 TextUnmarshaler 视为单个值）
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType && !reflect.PointerTo(ft).Implements(textUnmarshalerType) {
+			if name == "" && !field.Anonymous {
+				continue
+			}
+			nested := prefix
+			if name != "" {
+				nested = prefix + name + "."
+			}
+			if !hasPrefix(values, nested) && !withDefaults {
+				continue
+			}
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					fv.Set(reflect.New(ft))
+				}
+				fv = fv.Elem()
+			}
+			if err := bindStruct(fv, source, values, nested, withDefaults); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name == "" {
+			continue
+		}
+		key := prefix + name
+		if source == BindSourceHeader {
+			key = strings.ToLower(key)
+		}
+
+		raw, ok := values[key]
+		if !ok || len(raw) == 0 {
+			def, hasDefault := field.Tag.Lookup("default")
+			if !withDefaults || !hasDefault {
+				continue
+			}
+			raw = []string{def}
+		}
+		if err := setField(fv, field, raw); err != nil {
+			return &BindError{Source: source, Param: key, Value: strings.Join(raw, ","), Err: err}
+		}
+	}
+	return nil
+}
+
+// tagName 返回字段在来源中的名称，查询参数兼容 form 标签
+func tagName(field reflect.StructField, source string) string {
+	tag, ok := field.Tag.Lookup(source)
+	if !ok && source == BindSourceQuery {
+		tag, ok = field.Tag.Lookup("form")
+	}
+	if !ok {
+		return ""
+	}
+	return strings.SplitN(tag, ",", 2)[0]
+}
+
+// hasPrefix 判断是否存在以 prefix 开头的键
+func hasPrefix(values url.Values, prefix string) bool {
+	for key := range values {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDefaults 为所有带default标签的零值字段设置默认值
+func applyDefaults(rv reflect.Value) error {
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if fv.IsZero() {
+				if err := setField(fv, field, []string{def}); err != nil {
+					return &BindError{Source: "default", Param: field.Name, Value: def, Err: err}
+				}
+			}
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			if err := applyDefaults(fv.Addr()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setField 将字符串值转换后写入字段，切片字段接收全部值
+func setField(fv reflect.Value, field reflect.StructField, raw []string) error {
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(fv.Type(), len(raw), len(raw))
+		for i, s := range raw {
+			if err := setValue(slice.Index(i), field, s); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setValue(fv, field, raw[0])
+}
+
+// setValue 转换单个值
+func setValue(fv reflect.Value, field reflect.StructField, s string) error {
+	if fv.Kind() == reflect.Pointer {
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setValue(ptr.Elem(), field, s); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+
+	switch {
+	case fv.Type() == timeType:
+		layout := field.Tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return fmt.Errorf("时间格式应为 %s", layout)
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case fv.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.New("不是有效的时长")
+		}
+		fv.SetInt(int64(d))
+		return nil
+	case fv.Addr().Type().Implements(textUnmarshalerType):
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("不是有效的布尔值")
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("不是有效的整数")
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("不是有效的非负整数")
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return errors.New("不是有效的数字")
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("不支持的字段类型 %s", fv.Type())
+	}
+	return nil
+}
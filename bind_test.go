@@ -0,0 +1,205 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/validation"
+)
+
+type uriParams struct {
+	ID     uint64    `uri:"id"`
+	Ref    uuid.UUID `uri:"ref"`
+	Active bool      `uri:"active"`
+}
+
+func TestBindUri(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := New()
+	e.GET("/items/:id/:ref/:active", func(c *Context) {
+		var params uriParams
+		if err := c.BindUri(&params); err != nil {
+			return
+		}
+		c.JSON(http.StatusOK, params)
+	})
+
+	ref := uuid.New()
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/42/"+ref.String()+"/true", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var got uriParams
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, uriParams{ID: 42, Ref: ref, Active: true}, got)
+
+	// 转换失败时响应400并指明参数
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/abc/"+ref.String()+"/true", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"id"`)
+	assert.Contains(t, w.Body.String(), `"source":"uri"`)
+}
+
+type listQuery struct {
+	Tags    []string      `query:"tag"`
+	Page    int           `query:"page" default:"1"`
+	Size    int           `query:"size" default:"20"`
+	Since   time.Time     `query:"since" layout:"2006-01-02"`
+	Timeout time.Duration `query:"timeout"`
+	Filter  struct {
+		Status string `query:"status"`
+		MinAge int    `query:"min_age"`
+	} `query:"filter"`
+}
+
+func TestBindQuery(t *testing.T) {
+	c := newBodyTestContext(httptest.NewRequest(http.MethodGet,
+		"/?tag=a&tag=b&size=50&since=2024-03-01&timeout=1.5s&filter.status=open&filter[min_age]=18", nil))
+
+	var q listQuery
+	require.NoError(t, c.BindQuery(&q))
+	assert.Equal(t, []string{"a", "b"}, q.Tags)
+	assert.Equal(t, 1, q.Page)
+	assert.Equal(t, 50, q.Size)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), q.Since)
+	assert.Equal(t, 1500*time.Millisecond, q.Timeout)
+	assert.Equal(t, "open", q.Filter.Status)
+	assert.Equal(t, 18, q.Filter.MinAge)
+
+	c = newBodyTestContext(httptest.NewRequest(http.MethodGet, "/?since=03/01/2024", nil))
+	err := c.BindQuery(&listQuery{})
+	var bindErr *BindError
+	require.ErrorAs(t, err, &bindErr)
+	assert.Equal(t, "since", bindErr.Param)
+	assert.Contains(t, err.Error(), "2006-01-02")
+	assert.Equal(t, http.StatusBadRequest, c.Writer.Status())
+}
+
+type pageQuery struct {
+	Page     int           `form:"page" default:"1" validate:"min=1"`
+	Size     uint          `form:"size" default:"20" validate:"required,max=100"`
+	Ratio    float64       `form:"ratio"`
+	Desc     bool          `form:"desc"`
+	Timeout  time.Duration `form:"timeout"`
+	Keywords []string      `form:"q"`
+	Cursor   *string       `form:"cursor" validate:"omitempty,min=3"`
+	Limit    *int          `form:"limit"`
+	Filter   struct {
+		Status string `form:"status" validate:"omitempty,oneof=open closed"`
+	} `form:"filter"`
+}
+
+func TestBindQueryValidate(t *testing.T) {
+	c := newBodyTestContext(httptest.NewRequest(http.MethodGet,
+		"/?ratio=0.5&desc=true&timeout=2s&q=a&q=b&cursor=abc&filter[status]=open", nil))
+
+	var q pageQuery
+	require.NoError(t, c.BindQuery(&q))
+	assert.Equal(t, 1, q.Page)
+	assert.Equal(t, uint(20), q.Size)
+	assert.Equal(t, 0.5, q.Ratio)
+	assert.True(t, q.Desc)
+	assert.Equal(t, 2*time.Second, q.Timeout)
+	assert.Equal(t, []string{"a", "b"}, q.Keywords)
+	require.NotNil(t, q.Cursor)
+	assert.Equal(t, "abc", *q.Cursor)
+	// 缺失的指针参数保持nil
+	assert.Nil(t, q.Limit)
+	assert.Equal(t, "open", q.Filter.Status)
+
+	// validate 标签验证失败时返回 ValidationError，字段名为参数名
+	c = newBodyTestContext(httptest.NewRequest(http.MethodGet, "/?page=0&size=500&filter.status=draft", nil))
+	err := c.BindQuery(&pageQuery{})
+	var validationErr validation.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	fields := make([]string, 0, len(validationErr.Errors))
+	for _, fe := range validationErr.Errors {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{"page", "size", "filter.status"}, fields)
+	assert.Equal(t, http.StatusBadRequest, c.Writer.Status())
+}
+
+func TestBindHeader(t *testing.T) {
+	type headers struct {
+		APIKey  string `header:"X-Api-Key" binding:"required"`
+		Retries int    `header:"X-Retries" default:"3"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("x-api-key", "secret")
+	c := newBodyTestContext(req)
+
+	var h headers
+	require.NoError(t, c.BindHeader(&h))
+	assert.Equal(t, headers{APIKey: "secret", Retries: 3}, h)
+
+	// binding 标签仍然生效
+	c = newBodyTestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Error(t, c.BindHeader(&headers{}))
+	assert.Equal(t, http.StatusBadRequest, c.Writer.Status())
+
+	// validate 标签同样生效
+	type versioned struct {
+		Version int `header:"X-Api-Version" validate:"min=2"`
+	}
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Version", "1")
+	c = newBodyTestContext(req)
+	var validationErr validation.ValidationError
+	require.ErrorAs(t, c.BindHeader(&versioned{}), &validationErr)
+	assert.Equal(t, "X-Api-Version", validationErr.Errors[0].Field)
+}
+
+type updateRequest struct {
+	ID    uint   `uri:"id" json:"-"`
+	Name  string `json:"name" query:"name" validate:"required"`
+	Notes string `json:"notes"`
+	Limit int    `json:"limit" query:"limit" default:"10"`
+}
+
+func TestBindAndValidate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := New()
+	e.PUT("/items/:id", func(c *Context) {
+		var req updateRequest
+		if err := c.BindAndValidate(&req); err != nil {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": req.ID, "name": req.Name, "notes": req.Notes, "limit": req.Limit})
+	})
+
+	put := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	// 查询参数优先于请求体，未提供的字段使用默认值
+	w := put("/items/7?name=from-query", `{"name":"from-body","notes":"n"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":7,"name":"from-query","notes":"n","limit":10}`, w.Body.String())
+
+	w = put("/items/7", `{"name":"from-body","limit":5}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":7,"name":"from-body","notes":"","limit":5}`, w.Body.String())
+
+	// 合并后的结构体执行验证
+	w = put("/items/7", `{"notes":"n"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "参数验证失败")
+
+	w = put("/items/x", `{"name":"a"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"id"`)
+}
@@ -0,0 +1,153 @@
+package flow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultRawBodyLimit RawBody 默认的最大请求体大小
+const DefaultRawBodyLimit int64 = 4 << 20
+
+// 请求体相关的上下文键
+const (
+	rawBodyLimitKey = "flow.raw_body_limit"
+	rawBodyFileKey  = "flow.raw_body_file"
+)
+
+// 请求体相关错误
+var (
+	// ErrBodyTooLarge 请求体超过限制，应响应413
+	ErrBodyTooLarge = errors.New("请求体超过大小限制")
+
+	// ErrBodyConsumed 请求体已被绑定等操作读取，无法再获取原始内容
+	ErrBodyConsumed = errors.New("请求体已被读取: 请在 Bind 之前调用 RawBody，或在路由上使用 middleware.CaptureBody")
+
+	// ErrMultipartBody multipart 请求不会被缓存到内存
+	ErrMultipartBody = errors.New("multipart 请求体不会被缓存: 请使用 MultipartForm 或 RawBodyStream")
+)
+
+// WithRawBodyLimit 设置 RawBody 的默认大小限制
+func WithRawBodyLimit(limit int64) Option {
+	return func(e *Engine) {
+		e.config.RawBodyLimit = limit
+	}
+}
+
+// SetRawBodyLimit 设置当前请求 RawBody 的大小限制，需在首次读取前调用
+func (c *Context) SetRawBodyLimit(limit int64) {
+	c.Set(rawBodyLimitKey, limit)
+}
+
+// rawBodyLimit 当前请求的大小限制
+func (c *Context) rawBodyLimit() int64 {
+	if v, ok := c.Get(rawBodyLimitKey); ok {
+		if limit, ok := v.(int64); ok && limit > 0 {
+			return limit
+		}
+	}
+	if c.engine != nil && c.engine.config.RawBodyLimit > 0 {
+		return c.engine.config.RawBodyLimit
+	}
+	return DefaultRawBodyLimit
+}
+
+// RawBody 读取并缓存原始请求体，之后 BindJSON、BindXML 等仍可正常读取
+//
+// 超过限制时返回 ErrBodyTooLarge（不会把超出部分读入内存），multipart 请求返回 ErrMultipartBody，
+// 请求体已被绑定读取时返回 ErrBodyConsumed。缓存与 gin 的 ShouldBindBodyWith 共用
+func (c *Context) RawBody() ([]byte, error) {
+	if v, ok := c.Get(gin.BodyBytesKey); ok {
+		if body, ok := v.([]byte); ok {
+			return body, nil
+		}
+	}
+	if isMultipart(c.Request) {
+		return nil, ErrMultipartBody
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		c.Set(gin.BodyBytesKey, []byte{})
+		return []byte{}, nil
+	}
+
+	limit := c.rawBodyLimit()
+	if c.Request.ContentLength > limit {
+		return nil, fmt.Errorf("%w: %d 字节，限制 %d 字节", ErrBodyTooLarge, c.Request.ContentLength, limit)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+	_ = c.Request.Body.Close()
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, fmt.Errorf("%w: 限制 %d 字节", ErrBodyTooLarge, limit)
+		}
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	if len(body) == 0 && c.Request.ContentLength > 0 {
+		return nil, ErrBodyConsumed
+	}
+
+	c.Set(gin.BodyBytesKey, body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// RawBodyStream 将请求体写入临时文件并返回可重复读取的副本，用于超过 RawBody 限制的大请求体
+// 临时文件在请求结束时删除；之后请求体从临时文件重新读取，绑定仍然可用
+func (c *Context) RawBodyStream() (io.ReadSeeker, error) {
+	if v, ok := c.Get(gin.BodyBytesKey); ok {
+		if body, ok := v.([]byte); ok {
+			return bytes.NewReader(body), nil
+		}
+	}
+	if v, ok := c.Get(rawBodyFileKey); ok {
+		file := v.(*os.File)
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return io.NewSectionReader(file, 0, info.Size()), nil
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return bytes.NewReader(nil), nil
+	}
+
+	file, err := os.CreateTemp("", "flow-body-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建请求体临时文件失败: %w", err)
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	size, err := io.Copy(file, c.Request.Body)
+	_ = c.Request.Body.Close()
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	if size == 0 && c.Request.ContentLength > 0 {
+		cleanup()
+		return nil, ErrBodyConsumed
+	}
+
+	context.AfterFunc(c.Request.Context(), cleanup)
+	c.Set(rawBodyFileKey, file)
+	c.Request.Body = io.NopCloser(io.NewSectionReader(file, 0, size))
+	return io.NewSectionReader(file, 0, size), nil
+}
+
+// isMultipart 判断是否为multipart请求
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
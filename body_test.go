@@ -0,0 +1,128 @@
+package flow
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bodyPayload = `{"name":"flow","version":2}`
+
+func newBodyTestContext(req *http.Request) *Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	return &Context{Context: c}
+}
+
+type bodyPayloadStruct struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+func TestRawBody_BindAfterRawBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(bodyPayload))
+	req.Header.Set("Content-Type", "application/json")
+	c := newBodyTestContext(req)
+
+	raw, err := c.RawBody()
+	require.NoError(t, err)
+	assert.Equal(t, bodyPayload, string(raw))
+
+	var payload bodyPayloadStruct
+	require.NoError(t, c.ShouldBindJSON(&payload))
+	assert.Equal(t, bodyPayloadStruct{Name: "flow", Version: 2}, payload)
+
+	// 绑定之后仍可获取缓存的请求体
+	raw, err = c.RawBody()
+	require.NoError(t, err)
+	assert.Equal(t, bodyPayload, string(raw))
+}
+
+func TestRawBody_AfterBindExplainsError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(bodyPayload))
+	req.Header.Set("Content-Type", "application/json")
+	c := newBodyTestContext(req)
+
+	var payload bodyPayloadStruct
+	require.NoError(t, c.ShouldBindJSON(&payload))
+
+	_, err := c.RawBody()
+	assert.ErrorIs(t, err, ErrBodyConsumed)
+	assert.Contains(t, err.Error(), "CaptureBody")
+}
+
+func TestRawBody_Limit(t *testing.T) {
+	// Content-Length 超过限制时不读取请求体
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(bodyPayload))
+	c := newBodyTestContext(req)
+	c.SetRawBodyLimit(8)
+	_, err := c.RawBody()
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+
+	// 分块传输没有 Content-Length，读取到限制时停止
+	req = httptest.NewRequest(http.MethodPost, "/", io.MultiReader(strings.NewReader(bodyPayload)))
+	req.ContentLength = -1
+	c = newBodyTestContext(req)
+	c.engine = New(WithRawBodyLimit(8))
+	_, err = c.RawBody()
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+}
+
+func TestRawBody_Multipart(t *testing.T) {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", "a.txt")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("file content"))
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/", &buf)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	c := newBodyTestContext(req)
+
+	_, err = c.RawBody()
+	assert.ErrorIs(t, err, ErrMultipartBody)
+
+	// 未读取请求体，文件上传仍可解析
+	file, err := c.FormFile("file")
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", file.Filename)
+}
+
+func TestRawBodyStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(bodyPayload)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	c := newBodyTestContext(req)
+
+	stream, err := c.RawBodyStream()
+	require.NoError(t, err)
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, bodyPayload, string(data))
+
+	var payload bodyPayloadStruct
+	require.NoError(t, c.ShouldBindJSON(&payload))
+	assert.Equal(t, "flow", payload.Name)
+
+	// 请求结束后删除临时文件
+	v, _ := c.Get(rawBodyFileKey)
+	name := v.(*os.File).Name()
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(name)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,63 @@
+package flow
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// BuildInfo 当前可执行文件的构建信息，启动报告与命令行 Banner 共用
+type BuildInfo struct {
+	Version    string `json:"version"`               // 框架版本
+	Commit     string `json:"commit,omitempty"`      // VCS 提交，构建时不在仓库中则为空
+	CommitTime string `json:"commit_time,omitempty"` // 提交时间
+	Modified   bool   `json:"modified,omitempty"`    // 构建时工作区有未提交的修改
+	GoVersion  string `json:"go_version"`
+}
+
+var (
+	buildInfoOnce sync.Once
+	buildInfo     BuildInfo
+)
+
+// ReadBuildInfo 读取构建信息，结果在进程内缓存
+func ReadBuildInfo() BuildInfo {
+	buildInfoOnce.Do(func() {
+		buildInfo = BuildInfo{Version: Version, GoVersion: runtime.Version()}
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				buildInfo.Commit = s.Value
+			case "vcs.time":
+				buildInfo.CommitTime = s.Value
+			case "vcs.modified":
+				buildInfo.Modified = s.Value == "true"
+			}
+		}
+	})
+	return buildInfo
+}
+
+// ShortCommit 返回7位的提交哈希，工作区有修改时追加 "-dirty"，没有提交信息时为空
+func (b BuildInfo) ShortCommit() string {
+	commit := b.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	if commit != "" && b.Modified {
+		commit += "-dirty"
+	}
+	return commit
+}
+
+// String 返回版本与提交，例如 "2.0.0 (a1b2c3d)"
+func (b BuildInfo) String() string {
+	if commit := b.ShortCommit(); commit != "" {
+		return b.Version + " (" + commit + ")"
+	}
+	return b.Version
+}
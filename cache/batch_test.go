@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_SetManyMemory(t *testing.T) {
+	store := NewMemoryStore()
+	manager := NewManager()
+	manager.AddStore("memory", store)
+	ctx := context.Background()
+
+	require.NoError(t, manager.SetMany(ctx, []Entry{
+		{Key: "user:1", Value: "alice", TTL: time.Minute, Tags: []string{"users"}},
+		{Key: "post:1", Value: "hello", Tags: []string{"posts"}},
+	}, WithExpiration(time.Hour)))
+
+	assert.Equal(t, time.Minute, store.items["user:1"].Expiration)
+	assert.Equal(t, time.Hour, store.items["post:1"].Expiration, "未设置TTL的项使用选项中的过期时间")
+
+	users, err := manager.TaggedGet(ctx, "users")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"user:1": "alice"}, users)
+
+	require.NoError(t, manager.DeleteMultiple(ctx, []string{"user:1", "post:1"}))
+	assert.False(t, manager.Has(ctx, "post:1"))
+}
+
+func TestManager_SetManyRedis(t *testing.T) {
+	store, server, hook := newTestRedisStore(t)
+	manager := NewManager()
+	manager.AddStore("memory", store)
+	ctx := context.Background()
+
+	require.NoError(t, manager.SetMany(ctx, []Entry{
+		{Key: "user:1", Value: "alice", TTL: time.Minute, Tags: []string{"users"}},
+		{Key: "post:1", Value: "hello", TTL: 2 * time.Hour, Tags: []string{"posts"}},
+	}))
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&hook.count), "原生批量写入只需一次往返")
+	assert.Equal(t, time.Minute, server.ttl("flow:user:1"))
+	assert.Equal(t, 2*time.Hour, server.ttl("flow:post:1"))
+	assert.Equal(t, []string{"flow:user:1"}, server.setMembers("flow:tag:users"))
+	assert.Equal(t, []string{"flow:post:1"}, server.setMembers("flow:tag:posts"))
+}
+
+func TestJitterTTL_Bounds(t *testing.T) {
+	assert.Equal(t, 90*time.Second, jitterTTL(100*time.Second, 0.1, 0))
+	assert.Equal(t, 100*time.Second, jitterTTL(100*time.Second, 0.1, 0.5))
+	assert.Equal(t, time.Duration(0), jitterTTL(0, 0.1, 0), "不过期的项不浮动")
+	assert.Equal(t, -time.Second, jitterTTL(-time.Second, 0.1, 0))
+	assert.Equal(t, time.Nanosecond, jitterTTL(time.Nanosecond, 0.5, 0), "结果不会小于等于0")
+	assert.Greater(t, jitterTTL(time.Second, 5, 0), time.Duration(0), "比例大于等于1时被限制")
+}
+
+func TestManager_TTLJitterDeterministic(t *testing.T) {
+	ttls := func() []time.Duration {
+		store := NewMemoryStore()
+		manager := NewManager()
+		manager.AddStore("memory", store)
+		manager.SetJitterSeed(42)
+		ctx := context.Background()
+
+		require.NoError(t, manager.Set(ctx, "a", 1, WithExpiration(time.Hour), WithTTLJitter(0.2)))
+		require.NoError(t, manager.SetMany(ctx, []Entry{{Key: "b", Value: 2, TTL: time.Hour}}, WithTTLJitter(0.2)))
+		return []time.Duration{store.items["a"].Expiration, store.items["b"].Expiration}
+	}
+
+	first := ttls()
+	assert.Equal(t, first, ttls(), "相同种子得到相同的过期时间")
+	assert.NotEqual(t, time.Hour, first[0])
+}
+
+func TestManager_TTLJitterSpreadsExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	manager := NewManager()
+	manager.AddStore("memory", store)
+	manager.SetJitterSeed(1)
+
+	// 模拟缓存预热：一次写入大量相同TTL的键
+	items := make(map[string]interface{}, 1000)
+	for i := 0; i < 1000; i++ {
+		items[fmt.Sprintf("product:%d", i)] = i
+	}
+	require.NoError(t, manager.SetMultiple(context.Background(), items, WithExpiration(time.Hour), WithTTLJitter(0.1)))
+
+	perSecond := make(map[int64]int)
+	for _, item := range store.items {
+		ttl := item.Expiration
+		assert.GreaterOrEqual(t, ttl, 54*time.Minute)
+		assert.LessOrEqual(t, ttl, 66*time.Minute)
+		perSecond[int64(ttl/time.Second)]++
+	}
+	busiest := 0
+	for _, n := range perSecond {
+		busiest = max(busiest, n)
+	}
+	assert.Greater(t, len(perSecond), 500, "过期时间分散到不同的秒")
+	assert.Less(t, busiest, 10, "没有大量键在同一秒过期")
+}
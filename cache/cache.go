@@ -3,6 +3,9 @@ package cache
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -11,15 +14,106 @@ import (
 var (
 	ErrCacheMiss  = errors.New("缓存不存在")
 	ErrInvalidKey = errors.New("无效的缓存键")
+	// ErrStale 缓存项已过期但仍在陈旧期内，Get 同时返回旧值，见 WithStaleTTL
+	ErrStale = errors.New("缓存已过期，返回的是陈旧值")
 )
 
+// IsStale 判断 Get 返回的错误是否表示陈旧值，此时返回的值仍可使用
+func IsStale(err error) bool {
+	return errors.Is(err, ErrStale)
+}
+
+// MultiError 批量操作中部分键失败时返回的错误，Errors 记录每个失败键的原因
+// 返回 MultiError 时结果中仍包含成功读取的键
+type MultiError struct {
+	Errors map[string]error
+}
+
+// add 记录一个失败键，接收者为 nil 时创建新的 MultiError
+func (e *MultiError) add(key string, err error) *MultiError {
+	if e == nil {
+		e = &MultiError{Errors: make(map[string]error)}
+	}
+	e.Errors[key] = err
+	return e
+}
+
+// Keys 返回按字典序排列的失败键
+func (e *MultiError) Keys() []string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (e *MultiError) Error() string {
+	keys := e.Keys()
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %v", key, e.Errors[key])
+	}
+	return fmt.Sprintf("%d 个缓存键读取失败: %s", len(keys), strings.Join(parts, "; "))
+}
+
+// Unwrap 支持 errors.Is/As 检查各个键的错误
+func (e *MultiError) Unwrap() []error {
+	keys := e.Keys()
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		errs[i] = e.Errors[key]
+	}
+	return errs
+}
+
+// NoExpiration TTL 对没有过期时间的键返回的剩余时间
+const NoExpiration time.Duration = -1
+
 // Item 缓存项结构
 type Item struct {
 	Key        string        // 缓存键
 	Value      interface{}   // 缓存值
 	Tags       []string      // 关联标签
-	Expiration time.Duration // 过期时间
+	Expiration time.Duration // 写入时设置的过期时长，仅作记录，是否过期以 ExpiresAt 为准
+	ExpiresAt  time.Time     // 绝对过期时间，零值表示不过期；Increment 与 Touch 后保持真实的剩余时间
 	CreatedAt  time.Time     // 创建时间
+	StaleUntil time.Time     // 陈旧期结束时间，零值表示没有陈旧期；过期后到该时间之前 Get 返回旧值与 ErrStale
+}
+
+// newItem 创建缓存项，ttl 大于0时设置绝对过期时间
+func newItem(key string, value interface{}, tags []string, ttl time.Duration, now time.Time) Item {
+	item := Item{Key: key, Value: value, Tags: tags, Expiration: ttl, CreatedAt: now}
+	if ttl > 0 {
+		item.ExpiresAt = now.Add(ttl)
+	}
+	return item
+}
+
+// withStale 按陈旧期设置 StaleUntil，只作用于有过期时间的缓存项
+func (i Item) withStale(staleTTL time.Duration) Item {
+	if staleTTL > 0 && !i.ExpiresAt.IsZero() {
+		i.StaleUntil = i.ExpiresAt.Add(staleTTL)
+	}
+	return i
+}
+
+// stale 判断缓存项在 now 时是否已过期但仍在陈旧期内
+func (i Item) stale(now time.Time) bool {
+	return i.expired(now) && now.Before(i.StaleUntil)
+}
+
+// expired 判断缓存项在 now 时是否已过期
+func (i Item) expired(now time.Time) bool {
+	return !i.ExpiresAt.IsZero() && !now.Before(i.ExpiresAt)
+}
+
+// ttl 返回缓存项的剩余时间，没有过期时间时返回 NoExpiration
+func (i Item) ttl(now time.Time) time.Duration {
+	if i.ExpiresAt.IsZero() {
+		return NoExpiration
+	}
+	return i.ExpiresAt.Sub(now)
 }
 
 // Store 缓存存储接口
@@ -36,10 +130,17 @@ type Store interface {
 	SetMultiple(ctx context.Context, items map[string]interface{}, options ...Option) error
 	DeleteMultiple(ctx context.Context, keys []string) error
 
-	// 计数器操作
+	// 计数器操作，保留键的剩余过期时间
 	Increment(ctx context.Context, key string, value int64) (int64, error)
 	Decrement(ctx context.Context, key string, value int64) (int64, error)
 
+	// 过期时间操作，键不存在时返回 ErrCacheMiss，没有过期时间的键 TTL 为 NoExpiration
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Touch 重新设置键的过期时间而不改写值，ttl 小于等于0时移除过期时间
+	Touch(ctx context.Context, key string, ttl time.Duration) error
+	// GetWithTTL 同时返回值与剩余时间，便于调用方实现自己的过期策略
+	GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error)
+
 	// 标签操作
 	TaggedGet(ctx context.Context, tag string) (map[string]interface{}, error)
 	TaggedDelete(ctx context.Context, tag string) error
@@ -51,10 +152,25 @@ type Store interface {
 	Flush(ctx context.Context) error
 }
 
+// Entry 批量写入的缓存项，每项有自己的过期时间与标签
+type Entry struct {
+	Key   string
+	Value interface{}
+	TTL   time.Duration // 为0时使用批量写入选项或存储的默认过期时间
+	Tags  []string
+}
+
+// BatchSetter 支持原生批量写入的存储，未实现时 Manager.SetMany 逐项调用 Set
+type BatchSetter interface {
+	SetMany(ctx context.Context, entries []Entry) error
+}
+
 // Options 缓存选项
 type Options struct {
 	Expiration time.Duration // 过期时间
 	Tags       []string      // 标签
+	Jitter     float64       // 过期时间随机浮动比例，由 Manager 应用
+	StaleTTL   time.Duration // 过期后继续提供旧值的时长
 }
 
 // Option 缓存配置函数
@@ -74,6 +190,39 @@ func WithTags(tags ...string) Option {
 	}
 }
 
+// WithTTLJitter 让过期时间在 ±fraction 范围内随机浮动，避免大量键在同一时刻过期
+// 只作用于大于0的过期时间，fraction 取值 [0, 1)；由 Manager 的 Set、SetMultiple、SetMany 应用
+func WithTTLJitter(fraction float64) Option {
+	return func(o *Options) {
+		o.Jitter = fraction
+	}
+}
+
+// WithStaleTTL 设置陈旧期：过期时间到达后的 d 时长内，Get 仍返回旧值并附带 ErrStale（用 IsStale 判断），
+// Manager.Remember 在此期间返回旧值并在后台刷新；只作用于大于0的过期时间。
+// 内存存储与Redis存储支持陈旧期，其他存储忽略该选项。陈旧期内的键对 GetMultiple、TaggedGet 视为不存在；
+// Redis 中的键在陈旧期结束时才被删除，因此 Has 与 TTL 按键的实际存活时间计算
+func WithStaleTTL(d time.Duration) Option {
+	return func(o *Options) {
+		o.StaleTTL = d
+	}
+}
+
+// jitterTTL 按比例随机调整过期时间，r 为 [0, 1) 的随机数，结果始终大于0
+func jitterTTL(ttl time.Duration, fraction, r float64) time.Duration {
+	if ttl <= 0 || fraction <= 0 {
+		return ttl
+	}
+	if fraction >= 1 {
+		fraction = 0.99
+	}
+	jittered := ttl + time.Duration(float64(ttl)*fraction*(2*r-1))
+	if jittered <= 0 {
+		return ttl
+	}
+	return jittered
+}
+
 // 应用配置选项
 func applyOptions(options ...Option) Options {
 	opts := Options{}
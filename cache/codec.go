@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec 缓存项的序列化方式，RedisStore 通过 WithRedisCodec 设置
+type Codec interface {
+	Marshal(item Item) ([]byte, error)
+	Unmarshal(data []byte, item *Item) error
+}
+
+// 内置编码写入时的格式前缀；JSON 不加前缀，以兼容切换编码之前写入的值
+const (
+	formatGob     byte = 0x01
+	formatMsgpack byte = 0x02
+)
+
+// JSONCodec JSON编码，默认编码；读取时值中的数字为 float64，结构体为 map[string]interface{}
+type JSONCodec struct{}
+
+// Marshal 实现 Codec
+func (JSONCodec) Marshal(item Item) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+// Unmarshal 实现 Codec
+func (JSONCodec) Unmarshal(data []byte, item *Item) error {
+	return json.Unmarshal(data, item)
+}
+
+// GobCodec gob编码，读取时保留值的具体类型（如结构体中的 time.Time）
+//
+// 基本类型之外的值需要事先通过 gob.Register 注册，否则写入时返回错误
+type GobCodec struct{}
+
+// Marshal 实现 Codec
+func (GobCodec) Marshal(item Item) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(formatGob)
+	if err := gob.NewEncoder(&buf).Encode(item); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal 实现 Codec
+func (GobCodec) Unmarshal(data []byte, item *Item) error {
+	if len(data) == 0 || data[0] != formatGob {
+		return fmt.Errorf("%w: 不是gob格式", ErrInvalidValue)
+	}
+	return gob.NewDecoder(bytes.NewReader(data[1:])).Decode(item)
+}
+
+// MsgpackCodec MessagePack编码，体积小于JSON；读取时整数为 int64，time.Time 保持原类型，结构体为 map[string]interface{}
+type MsgpackCodec struct{}
+
+// msgpackHandle MessagePack 编解码设置，只读，可并发使用
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.RawToString = true
+	h.SignedInteger = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}()
+
+// Marshal 实现 Codec
+func (MsgpackCodec) Marshal(item Item) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(formatMsgpack)
+	if err := codec.NewEncoder(&buf, msgpackHandle).Encode(item); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal 实现 Codec
+func (MsgpackCodec) Unmarshal(data []byte, item *Item) error {
+	if len(data) == 0 || data[0] != formatMsgpack {
+		return fmt.Errorf("%w: 不是MessagePack格式", ErrInvalidValue)
+	}
+	return codec.NewDecoderBytes(data[1:], msgpackHandle).Decode(item)
+}
+
+// CodecByName 按名称返回内置编码：json、gob、msgpack
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", "json":
+		return JSONCodec{}, nil
+	case "gob":
+		return GobCodec{}, nil
+	case "msgpack":
+		return MsgpackCodec{}, nil
+	}
+	return nil, fmt.Errorf("不支持的缓存编码: %s", name)
+}
+
+// decodeWith 按格式前缀选择内置编码解码，没有可识别的前缀时使用 fallback，
+// 因此切换编码后仍能读取之前写入的值
+func decodeWith(fallback Codec, data []byte, item *Item) error {
+	if len(data) > 0 {
+		switch data[0] {
+		case '{':
+			return JSONCodec{}.Unmarshal(data, item)
+		case formatGob:
+			return GobCodec{}.Unmarshal(data, item)
+		case formatMsgpack:
+			return MsgpackCodec{}.Unmarshal(data, item)
+		}
+	}
+	return fallback.Unmarshal(data, item)
+}
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type codecOrder struct {
+	ID        int64
+	Customer  string
+	Items     []string
+	CreatedAt time.Time
+}
+
+func init() {
+	gob.Register(codecOrder{})
+}
+
+// newCodecRedisStores 创建共享同一个Redis、使用不同编码的存储
+func newCodecRedisStores(t *testing.T, codecs ...Codec) []*RedisStore {
+	_, addr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { client.Close() })
+
+	stores := make([]*RedisStore, len(codecs))
+	for i, codec := range codecs {
+		stores[i] = NewRedisStore(client, WithRedisHealthCheck(false, 0), WithRedisCodec(codec))
+	}
+	return stores
+}
+
+func TestRedisStore_Codecs(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	order := codecOrder{ID: 42, Customer: "alice", Items: []string{"a", "b"}, CreatedAt: created}
+	ctx := context.Background()
+
+	// gob 保留具体类型
+	store := newCodecRedisStores(t, GobCodec{})[0]
+	require.NoError(t, store.Set(ctx, "order", order, WithTags("orders")))
+	v, err := store.Get(ctx, "order")
+	require.NoError(t, err)
+	assert.Equal(t, order, v)
+	tagged, err := store.TaggedGet(ctx, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, order, tagged["order"])
+
+	// msgpack 中的 time.Time 保持原类型，整数为 int64
+	store = newCodecRedisStores(t, MsgpackCodec{})[0]
+	require.NoError(t, store.Set(ctx, "order", order))
+	v, err = store.Get(ctx, "order")
+	require.NoError(t, err)
+	fields := v.(map[string]interface{})
+	assert.Equal(t, int64(42), fields["ID"])
+	assert.True(t, created.Equal(fields["CreatedAt"].(time.Time)))
+	n, err := store.Increment(ctx, "hits", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = store.Increment(ctx, "hits", 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	// 默认的 JSON 编码将结构体解码为 map
+	store = newCodecRedisStores(t, JSONCodec{})[0]
+	require.NoError(t, store.Set(ctx, "order", order))
+	v, err = store.Get(ctx, "order")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-01T12:00:00Z", v.(map[string]interface{})["CreatedAt"])
+}
+
+func TestRedisStore_CodecSwitch(t *testing.T) {
+	stores := newCodecRedisStores(t, JSONCodec{}, GobCodec{}, MsgpackCodec{})
+	ctx := context.Background()
+
+	for i, writer := range stores {
+		key := "k" + string(rune('0'+i))
+		require.NoError(t, writer.Set(ctx, key, "v"))
+	}
+	// 任一编码都能读取其他编码写入的值
+	for _, reader := range stores {
+		items, err := reader.GetMultiple(ctx, []string{"k0", "k1", "k2"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"k0": "v", "k1": "v", "k2": "v"}, items)
+	}
+}
+
+func TestCodecByName(t *testing.T) {
+	for name, want := range map[string]Codec{"": JSONCodec{}, "json": JSONCodec{}, "gob": GobCodec{}, "msgpack": MsgpackCodec{}} {
+		codec, err := CodecByName(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, codec)
+	}
+	_, err := CodecByName("xml")
+	assert.Error(t, err)
+
+	_, err = (&RedisDriver{}).New(map[string]interface{}{"codec": "xml"})
+	assert.ErrorContains(t, err, "xml")
+
+	var item Item
+	assert.ErrorIs(t, GobCodec{}.Unmarshal([]byte("{}"), &item), ErrInvalidValue)
+}
+
+// BenchmarkCodecs 比较各编码处理约5KB结构体的耗时与体积
+func BenchmarkCodecs(b *testing.B) {
+	items := make([]string, 100)
+	for i := range items {
+		items[i] = strings.Repeat("x", 40)
+	}
+	item := newItem("order", codecOrder{ID: 42, Customer: "alice", Items: items, CreatedAt: time.Now()}, []string{"orders"}, time.Hour, time.Now())
+
+	for name, codec := range map[string]Codec{"json": JSONCodec{}, "gob": GobCodec{}, "msgpack": MsgpackCodec{}} {
+		b.Run(name, func(b *testing.B) {
+			data, err := codec.Marshal(item)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				data, _ := codec.Marshal(item)
+				var decoded Item
+				if err := codec.Unmarshal(data, &decoded); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(data)), "bytes")
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadConfig 从配置映射加载缓存存储配置
+// 配置结构对应 cache 配置节：
+//
+//	cache:
+//	  default: redis
+//	  stores:
+//	    redis:
+//	      driver: redis
+//	      host: 127.0.0.1
+//	    local:
+//	      driver: memory
+//	      ttl: 60
+//
+// 存储只注册配置，首次通过 Store(name) 访问时才会创建
+func (m *Manager) LoadConfig(cacheConfig map[string]interface{}) error {
+	stores := make(map[string]interface{})
+	if storesConfig, ok := cacheConfig["stores"].(map[string]interface{}); ok {
+		stores = storesConfig
+	}
+
+	// 注册所有缓存配置
+	for storeName, storeConfig := range stores {
+		cfg, ok := storeConfig.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("缓存存储配置格式错误: %s", storeName)
+		}
+		m.Register(storeName, parseStoreConfig(cfg))
+	}
+
+	// 获取默认存储，未配置时回退到 memory
+	defaultStore := "memory"
+	if def, ok := cacheConfig["default"].(string); ok && def != "" {
+		defaultStore = def
+	}
+
+	m.mutex.RLock()
+	_, exists := m.configs[defaultStore]
+	configured := m.configuredNamesLocked()
+	m.mutex.RUnlock()
+	if !exists {
+		return &StoreNotFoundError{Name: defaultStore, Configured: configured}
+	}
+
+	m.SetDefault(defaultStore)
+	return nil
+}
+
+// parseStoreConfig 解析单个存储的配置，完整的配置映射会原样传递给驱动
+func parseStoreConfig(cfg map[string]interface{}) Config {
+	// 获取驱动类型
+	driver := "memory"
+	if d, ok := cfg["driver"].(string); ok && d != "" {
+		driver = d
+	}
+
+	// 获取键前缀
+	prefix := ""
+	if p, ok := cfg["prefix"].(string); ok {
+		prefix = p
+	}
+
+	// 获取过期时间，数字按秒处理，字符串按时长格式解析
+	var ttl time.Duration
+	switch t := cfg["ttl"].(type) {
+	case int:
+		ttl = time.Duration(t) * time.Second
+	case int64:
+		ttl = time.Duration(t) * time.Second
+	case float64:
+		ttl = time.Duration(t * float64(time.Second))
+	case string:
+		if parsedTTL, err := time.ParseDuration(t); err == nil {
+			ttl = parsedTTL
+		}
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	return Config{
+		Driver: driver,
+		Prefix: prefix,
+		TTL:    ttl,
+		Config: cfg,
+	}
+}
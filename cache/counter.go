@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CounterKeyPrefix 计数器键的专用前缀
+//
+// 计数器以纯整数保存在该前缀下（Redis 中为 存储前缀 + "counter:" + 键），不使用 Item 的 JSON 封装，
+// 因此可以直接使用 INCRBY。计数器与普通缓存项互不可见：Get、Has、Delete 读写不到计数器，
+// Increment/Decrement 仍然操作 Item 封装的普通缓存项；Clear 与 Flush 会同时清空计数器
+const CounterKeyPrefix = "counter:"
+
+// ErrCountersUnsupported 默认存储不支持原生计数器
+var ErrCountersUnsupported = errors.New("缓存存储不支持计数器")
+
+// CounterStore 支持原生计数器的存储
+type CounterStore interface {
+	// IncrBatch 在一次往返中为每个键增加 delta 并返回新值，ttl 只在键首次创建时设置
+	IncrBatch(ctx context.Context, deltas map[string]int64, ttl time.Duration) (map[string]int64, error)
+	// GetCounters 读取计数器，不存在的键为0
+	GetCounters(ctx context.Context, keys []string) (map[string]int64, error)
+}
+
+// counterStore 返回支持计数器的默认存储
+func (m *Manager) counterStore() (CounterStore, error) {
+	store, err := m.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+	counters, ok := store.(CounterStore)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrCountersUnsupported, store)
+	}
+	return counters, nil
+}
+
+// IncrBatch 批量增加默认存储中的计数器并返回新值，ttl 只在计数器首次创建时设置，为0时不过期
+//
+// Redis 存储在一个事务中执行 INCRBY 与 EXPIRE NX（需要 Redis 7.0 及以上）
+func (m *Manager) IncrBatch(ctx context.Context, deltas map[string]int64, ttl time.Duration) (map[string]int64, error) {
+	counters, err := m.counterStore()
+	if err != nil {
+		return nil, err
+	}
+	return counters.IncrBatch(ctx, deltas, ttl)
+}
+
+// GetCounters 批量读取默认存储中的计数器，不存在的键为0
+func (m *Manager) GetCounters(ctx context.Context, keys []string) (map[string]int64, error) {
+	counters, err := m.counterStore()
+	if err != nil {
+		return nil, err
+	}
+	return counters.GetCounters(ctx, keys)
+}
+
+// WindowCounter 基于计数器的时间窗口计数，适用于限流与用量统计
+//
+// 每个窗口使用一个计数器，键为 名称:键:窗口序号，保留两个窗口的时长。
+// 滑动窗口计数按上一窗口在当前时刻仍覆盖的比例加权估算：
+//
+//	sliding = previous * (1 - elapsed/window) + current
+type WindowCounter struct {
+	manager *Manager
+	name    string
+	window  time.Duration
+	now     func() time.Time
+}
+
+// WindowOption 窗口计数器选项
+type WindowOption func(*WindowCounter)
+
+// WithWindowClock 设置时钟，用于测试
+func WithWindowClock(now func() time.Time) WindowOption {
+	return func(w *WindowCounter) {
+		w.now = now
+	}
+}
+
+// NewWindowCounter 创建窗口计数器
+func NewWindowCounter(m *Manager, name string, window time.Duration, opts ...WindowOption) *WindowCounter {
+	if window <= 0 {
+		window = time.Minute
+	}
+	w := &WindowCounter{
+		manager: m,
+		name:    name,
+		window:  window,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// WindowCount 窗口计数结果
+type WindowCount struct {
+	Fixed   int64     // 当前固定窗口内的计数
+	Sliding float64   // 最近一个窗口时长内的估算计数
+	ResetAt time.Time // 当前固定窗口结束的时间
+}
+
+// Add 为键增加计数并返回增加后的结果，在一次往返中完成
+func (w *WindowCounter) Add(ctx context.Context, key string, n int64) (WindowCount, error) {
+	now := w.now()
+	current, previous := w.buckets(key, now)
+	// 上一窗口增加0只为在同一次往返中读取它的值
+	values, err := w.manager.IncrBatch(ctx, map[string]int64{current: n, previous: 0}, 2*w.window)
+	if err != nil {
+		return WindowCount{}, err
+	}
+	return w.count(now, values[current], values[previous]), nil
+}
+
+// Count 返回键当前的计数
+func (w *WindowCounter) Count(ctx context.Context, key string) (WindowCount, error) {
+	now := w.now()
+	current, previous := w.buckets(key, now)
+	values, err := w.manager.GetCounters(ctx, []string{current, previous})
+	if err != nil {
+		return WindowCount{}, err
+	}
+	return w.count(now, values[current], values[previous]), nil
+}
+
+// buckets 返回当前与上一窗口的计数器键
+func (w *WindowCounter) buckets(key string, now time.Time) (string, string) {
+	index := now.UnixNano() / int64(w.window)
+	return fmt.Sprintf("%s:%s:%d", w.name, key, index), fmt.Sprintf("%s:%s:%d", w.name, key, index-1)
+}
+
+// count 计算窗口计数结果
+func (w *WindowCounter) count(now time.Time, current, previous int64) WindowCount {
+	start := time.Unix(0, now.UnixNano()/int64(w.window)*int64(w.window))
+	elapsed := float64(now.Sub(start)) / float64(w.window)
+	return WindowCount{
+		Fixed:   current,
+		Sliding: float64(previous)*(1-elapsed) + float64(current),
+		ResetAt: start.Add(w.window),
+	}
+}
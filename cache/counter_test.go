@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStore_IncrBatchSetsTTLOnCreate(t *testing.T) {
+	store, server, hook := newTestRedisStore(t)
+	ctx := context.Background()
+
+	values, err := store.IncrBatch(ctx, map[string]int64{"api:k1": 3, "api:k2": 1}, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"api:k1": 3, "api:k2": 1}, values)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&hook.count), "一次批量增加只有一次往返")
+
+	// 再次增加不会重置首次创建时设置的过期时间
+	values, err = store.IncrBatch(ctx, map[string]int64{"api:k1": 2}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), values["api:k1"])
+	assert.Equal(t, time.Minute, server.ttl("flow:counter:api:k1"))
+	assert.Equal(t, "5", server.strings["flow:counter:api:k1"], "计数器保存为纯整数")
+
+	// 计数器与普通缓存项互不可见
+	assert.False(t, store.Has(ctx, "api:k1"))
+}
+
+func TestGetCounters(t *testing.T) {
+	redisStore, server, _ := newTestRedisStore(t)
+	stores := map[string]Store{"memory": NewMemoryStore(), "redis": redisStore}
+	ctx := context.Background()
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			manager := NewManager()
+			manager.AddStore(name, store)
+			manager.SetDefault(name)
+
+			written, err := manager.IncrBatch(ctx, map[string]int64{"a": 1, "b": 2}, 0)
+			require.NoError(t, err)
+			read, err := manager.GetCounters(ctx, []string{"a", "b", "missing"})
+			require.NoError(t, err)
+			assert.Equal(t, written["a"], read["a"])
+			assert.Equal(t, written["b"], read["b"])
+			assert.Equal(t, int64(0), read["missing"])
+		})
+	}
+
+	// 非整数值只影响对应的键
+	server.setRaw("flow:counter:broken", "x")
+	read, err := redisStore.GetCounters(ctx, []string{"a", "broken"})
+	var multiErr *MultiError
+	require.ErrorAs(t, err, &multiErr)
+	assert.Equal(t, []string{"broken"}, multiErr.Keys())
+	assert.Equal(t, int64(1), read["a"])
+}
+
+func TestIncrBatchUnsupportedStore(t *testing.T) {
+	manager := NewManager()
+	manager.AddStore("file", &FileStore{})
+	manager.SetDefault("file")
+	_, err := manager.IncrBatch(context.Background(), map[string]int64{"a": 1}, 0)
+	assert.ErrorIs(t, err, ErrCountersUnsupported)
+}
+
+func TestIncrBatchConcurrent(t *testing.T) {
+	redisStore, _, _ := newTestRedisStore(t)
+	for name, store := range map[string]CounterStore{"memory": NewMemoryStore(), "redis": redisStore} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 25; j++ {
+						_, err := store.IncrBatch(ctx, map[string]int64{"hits": 1, "bytes": 10}, time.Minute)
+						assert.NoError(t, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			values, err := store.GetCounters(ctx, []string{"hits", "bytes"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int64{"hits": 500, "bytes": 5000}, values)
+		})
+	}
+}
+
+func TestWindowCounter_MatchesBruteForce(t *testing.T) {
+	manager := NewManager()
+	manager.AddStore("memory", NewMemoryStore())
+	manager.SetDefault("memory")
+
+	window := time.Second
+	now := time.Unix(1_700_000_000, 0)
+	counter := NewWindowCounter(manager, "api", window, WithWindowClock(func() time.Time { return now }))
+	ctx := context.Background()
+
+	// 参考实现：按事件列表计算两个窗口的计数
+	var events []time.Time
+	reference := func(at time.Time) (int64, float64) {
+		start := at.Truncate(window)
+		var current, previous int64
+		for _, e := range events {
+			switch {
+			case !e.Before(start) && !e.After(at):
+				current++
+			case !e.Before(start.Add(-window)) && e.Before(start):
+				previous++
+			}
+		}
+		elapsed := float64(at.Sub(start)) / float64(window)
+		return current, float64(previous)*(1-elapsed) + float64(current)
+	}
+
+	// 每 7ms 一次请求，持续 3.5 个窗口
+	for i := 0; i < 500; i++ {
+		now = now.Add(7 * time.Millisecond)
+		events = append(events, now)
+		got, err := counter.Add(ctx, "key-1", 1)
+		require.NoError(t, err)
+
+		fixed, sliding := reference(now)
+		require.Equal(t, fixed, got.Fixed, "第 %d 次请求", i)
+		require.InDelta(t, sliding, got.Sliding, 1e-6, "第 %d 次请求", i)
+		assert.Equal(t, now.Truncate(window).Add(window), got.ResetAt)
+	}
+
+	// 匀速请求时滑动窗口估算接近真实的最近一秒请求数
+	var exact int
+	for _, e := range events {
+		if e.After(now.Add(-window)) {
+			exact++
+		}
+	}
+	got, err := counter.Count(ctx, "key-1")
+	require.NoError(t, err)
+	assert.InDelta(t, float64(exact), got.Sliding, 1)
+
+	// 其他键互不影响
+	other, err := counter.Count(ctx, "key-2")
+	require.NoError(t, err)
+	assert.Zero(t, other.Sliding)
+}
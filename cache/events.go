@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/zzliekkas/flow/v2/event"
+	"github.com/zzliekkas/flow/v2/flowctx"
+)
+
+// SetEventPublisher 设置框架事件发布器，未设置时不发布任何事件
+//
+// 默认存储上的未命中会发布 event.CacheMiss，删除与按标签删除会发布 event.CacheEviction
+func (m *Manager) SetEventPublisher(publisher *event.Publisher) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.events = publisher
+}
+
+// publisher 获取事件发布器
+func (m *Manager) publisher() *event.Publisher {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.events
+}
+
+// publishMiss 发布缓存未命中事件，事件带有 ctx 中的请求ID
+func (m *Manager) publishMiss(ctx context.Context, key string) {
+	if p := m.publisher(); p.Enabled(event.EventCacheMiss) {
+		p.Publish(event.NewCacheMiss(m.DefaultName(), key).WithRequestID(flowctx.RequestID(ctx)))
+	}
+}
+
+// publishEviction 发布缓存删除事件
+func (m *Manager) publishEviction(ctx context.Context, reason string, keys ...string) {
+	p := m.publisher()
+	if !p.Enabled(event.EventCacheEviction) {
+		return
+	}
+	store := m.DefaultName()
+	requestID := flowctx.RequestID(ctx)
+	for _, key := range keys {
+		p.Publish(event.NewCacheEviction(store, key, reason).WithRequestID(requestID))
+	}
+}
+
+// getWithEvents 从存储读取缓存，未命中时发布事件
+func (m *Manager) getWithEvents(ctx context.Context, store Store, key string) (interface{}, error) {
+	value, err := store.Get(ctx, key)
+	if err == ErrCacheMiss {
+		m.publishMiss(ctx, key)
+	}
+	return value, err
+}
@@ -500,6 +500,51 @@ func (s *FileStore) Increment(ctx context.Context, key string, value int64) (int
 	return newValue, nil
 }
 
+// TTL 返回缓存项的剩余时间
+func (s *FileStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	_, ttl, err := s.GetWithTTL(ctx, key)
+	return ttl, err
+}
+
+// GetWithTTL 获取缓存项及其剩余时间
+func (s *FileStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	if key == "" {
+		return nil, 0, ErrInvalidKey
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	item, err := s.loadItemFromFile(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if item.Expiration == 0 {
+		return item.Value, NoExpiration, nil
+	}
+	return item.Value, time.Until(time.Unix(0, item.Expiration)), nil
+}
+
+// Touch 重新设置缓存项的过期时间，ttl 小于等于0时移除过期时间
+func (s *FileStore) Touch(ctx context.Context, key string, ttl time.Duration) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, err := s.loadItemFromFile(key)
+	if err != nil {
+		return err
+	}
+	item.Expiration = 0
+	if ttl > 0 {
+		item.Expiration = time.Now().Add(ttl).UnixNano()
+	}
+	return s.saveItemToFile(*item)
+}
+
 // parseInt64 将字符串转换为 int64
 func parseInt64(s string) (int64, error) {
 	var result int64
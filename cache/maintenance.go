@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/app"
+)
+
+// DefaultMaintenanceKey 维护模式状态在缓存中的键
+const DefaultMaintenanceKey = "flow:maintenance"
+
+// maintenanceTTL 维护模式状态的保存期限，避免使用存储的默认过期时间
+const maintenanceTTL = 30 * 24 * time.Hour
+
+// MaintenanceStore 基于缓存的维护模式存储，所有共享同一缓存的实例都能看到状态变化
+type MaintenanceStore struct {
+	store Store
+	key   string
+}
+
+var _ app.MaintenanceStore = (*MaintenanceStore)(nil)
+
+// NewMaintenanceStore 创建缓存维护模式存储，key为空时使用DefaultMaintenanceKey
+func NewMaintenanceStore(store Store, key string) *MaintenanceStore {
+	if key == "" {
+		key = DefaultMaintenanceKey
+	}
+	return &MaintenanceStore{store: store, key: key}
+}
+
+// Load 读取状态
+func (s *MaintenanceStore) Load(ctx context.Context) (*app.MaintenanceState, error) {
+	value, err := s.store.Get(ctx, s.key)
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("维护模式状态格式错误: %T", value)
+	}
+
+	var state app.MaintenanceState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, fmt.Errorf("解析维护模式状态失败: %w", err)
+	}
+	return &state, nil
+}
+
+// Save 保存状态
+func (s *MaintenanceStore) Save(ctx context.Context, state *app.MaintenanceState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, s.key, string(data), WithExpiration(maintenanceTTL))
+}
+
+// Clear 清除状态
+func (s *MaintenanceStore) Clear(ctx context.Context) error {
+	err := s.store.Delete(ctx, s.key)
+	if errors.Is(err, ErrCacheMiss) {
+		return nil
+	}
+	return err
+}
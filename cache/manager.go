@@ -3,16 +3,48 @@ package cache
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/zzliekkas/flow/v2/event"
+	"github.com/zzliekkas/flow/v2/timing"
 )
 
+// ErrStoreNotFound 缓存存储未配置错误，可通过 errors.Is 判断
+var ErrStoreNotFound = errors.New("缓存存储未配置")
+
+// StoreNotFoundError 访问未配置的缓存存储时返回的错误，包含已配置的存储名称
+type StoreNotFoundError struct {
+	Name       string   // 请求的存储名称
+	Configured []string // 已配置的存储名称
+}
+
+// Error 实现error接口
+func (e *StoreNotFoundError) Error() string {
+	return fmt.Sprintf("缓存存储未配置: %s (已配置: %s)", e.Name, strings.Join(e.Configured, ", "))
+}
+
+// Is 支持 errors.Is(err, ErrStoreNotFound)
+func (e *StoreNotFoundError) Is(target error) bool {
+	return target == ErrStoreNotFound
+}
+
 // Manager 缓存管理器
 type Manager struct {
 	stores   map[string]Store  // 存储的缓存实例
 	configs  map[string]Config // 缓存配置
 	mutex    sync.RWMutex      // 并发锁
 	default_ string            // 默认存储
+	stale    staleState        // 过期可用模式状态
+	remember rememberGroup     // Remember 的并发加载合并
+	events   *event.Publisher  // 框架事件发布器，可为nil
+
+	jitterMu   sync.Mutex
+	jitterRand *rand.Rand // 过期时间浮动的随机源
 }
 
 // Config 缓存配置
@@ -26,22 +58,64 @@ type Config struct {
 // NewManager 创建缓存管理器
 func NewManager() *Manager {
 	return &Manager{
-		stores:   make(map[string]Store),
-		configs:  make(map[string]Config),
-		default_: "memory",
+		stores:     make(map[string]Store),
+		configs:    make(map[string]Config),
+		default_:   "memory",
+		jitterRand: rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
 // SetDefault 设置默认存储
+// 存储是延迟创建的，因此只要求名称已注册配置（或已有实例）
 func (m *Manager) SetDefault(name string) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	if _, exists := m.stores[name]; exists {
+	_, hasStore := m.stores[name]
+	_, hasConfig := m.configs[name]
+	if hasStore || hasConfig {
 		m.default_ = name
 	}
 }
 
+// DefaultName 获取默认存储名称
+func (m *Manager) DefaultName() string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.default_
+}
+
+// StoreNames 返回所有已配置的存储名称（按名称排序）
+func (m *Manager) StoreNames() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.configuredNamesLocked()
+}
+
+// configuredNamesLocked 返回已配置或已创建的存储名称，调用方需持有锁
+func (m *Manager) configuredNamesLocked() []string {
+	seen := make(map[string]struct{}, len(m.configs)+len(m.stores))
+	names := make([]string, 0, len(m.configs)+len(m.stores))
+	for name := range m.configs {
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	for name := range m.stores {
+		if _, ok := seen[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddStore 直接注册一个已创建的存储实例
+func (m *Manager) AddStore(name string, store Store) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.stores[name] = store
+}
+
 // Register 注册缓存配置
 func (m *Manager) Register(name string, config Config) error {
 	m.mutex.Lock()
@@ -67,8 +141,9 @@ func (m *Manager) GetStore(name string) (Store, error) {
 	// 获取配置
 	config, exists := m.configs[name]
 	if !exists {
+		configured := m.configuredNamesLocked()
 		m.mutex.RUnlock()
-		return nil, errors.New("缓存配置不存在: " + name)
+		return nil, &StoreNotFoundError{Name: name, Configured: configured}
 	}
 
 	m.mutex.RUnlock()
@@ -77,9 +152,18 @@ func (m *Manager) GetStore(name string) (Store, error) {
 	return m.createStore(name, config)
 }
 
-// Store 获取默认缓存存储
-func (m *Manager) Store() (Store, error) {
-	return m.GetStore(m.default_)
+// Store 获取指定名称的缓存存储，名称为空时返回默认存储
+// 存储在首次访问时根据配置通过驱动注册表延迟创建
+func (m *Manager) Store(name string) (Store, error) {
+	if name == "" {
+		return m.DefaultStore()
+	}
+	return m.GetStore(name)
+}
+
+// DefaultStore 获取默认缓存存储
+func (m *Manager) DefaultStore() (Store, error) {
+	return m.GetStore(m.DefaultName())
 }
 
 // 创建缓存存储
@@ -101,7 +185,7 @@ func (m *Manager) createStore(name string, config Config) (Store, error) {
 	// 创建存储
 	store, err := driver.New(config.Config)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("创建缓存存储 %s 失败: %w", name, err)
 	}
 
 	// 保存到缓存
@@ -110,38 +194,52 @@ func (m *Manager) createStore(name string, config Config) (Store, error) {
 	return store, nil
 }
 
-// 以下方法是对默认存储的操作的便捷封装
+// 以下方法是对默认存储的操作的便捷封装，耗时计入 Server-Timing 的 cache 层（见 timing 包）
 
 // Get 从默认存储获取缓存
+//
+// 通过 WithStaleTTL 写入的键在陈旧期内同时返回旧值与非nil的 ErrStale，
+// 调用方应先用 IsStale 判断，不能只凭 err != nil 丢弃返回值
 func (m *Manager) Get(ctx context.Context, key string) (interface{}, error) {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return nil, err
 	}
-	return store.Get(ctx, key)
+	return m.getWithEvents(ctx, store, key)
 }
 
 // Set 向默认存储设置缓存
 func (m *Manager) Set(ctx context.Context, key string, value interface{}, opts ...Option) error {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return err
 	}
+	if options := applyOptions(opts...); options.Jitter > 0 && options.Expiration > 0 {
+		opts = append(opts, WithExpiration(m.jitter(options.Expiration, options.Jitter)))
+	}
 	return store.Set(ctx, key, value, opts...)
 }
 
 // Delete 从默认存储删除缓存
 func (m *Manager) Delete(ctx context.Context, key string) error {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return err
 	}
-	return store.Delete(ctx, key)
+	if err := store.Delete(ctx, key); err != nil {
+		return err
+	}
+	m.publishEviction(ctx, "delete", key)
+	return nil
 }
 
 // Has 检查默认存储中是否存在缓存
 func (m *Manager) Has(ctx context.Context, key string) bool {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return false
 	}
@@ -150,7 +248,8 @@ func (m *Manager) Has(ctx context.Context, key string) bool {
 
 // Clear 清空默认存储
 func (m *Manager) Clear(ctx context.Context) error {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return err
 	}
@@ -159,7 +258,8 @@ func (m *Manager) Clear(ctx context.Context) error {
 
 // Increment 增加计数器值
 func (m *Manager) Increment(ctx context.Context, key string, value int64) (int64, error) {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return 0, err
 	}
@@ -168,43 +268,147 @@ func (m *Manager) Increment(ctx context.Context, key string, value int64) (int64
 
 // Decrement 减少计数器值
 func (m *Manager) Decrement(ctx context.Context, key string, value int64) (int64, error) {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return 0, err
 	}
 	return store.Decrement(ctx, key, value)
 }
 
+// TTL 返回默认存储中缓存项的剩余时间，键不存在时返回 ErrCacheMiss，没有过期时间时返回 NoExpiration
+func (m *Manager) TTL(ctx context.Context, key string) (time.Duration, error) {
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
+	if err != nil {
+		return 0, err
+	}
+	return store.TTL(ctx, key)
+}
+
+// Touch 重新设置默认存储中缓存项的过期时间而不改写值
+func (m *Manager) Touch(ctx context.Context, key string, ttl time.Duration) error {
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
+	if err != nil {
+		return err
+	}
+	return store.Touch(ctx, key, ttl)
+}
+
+// GetWithTTL 从默认存储获取缓存及其剩余时间，未命中时发布事件
+func (m *Manager) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
+	if err != nil {
+		return nil, 0, err
+	}
+	value, ttl, err := store.GetWithTTL(ctx, key)
+	if err == ErrCacheMiss {
+		m.publishMiss(ctx, key)
+	}
+	return value, ttl, err
+}
+
 // GetMultiple 获取多个缓存项
 func (m *Manager) GetMultiple(ctx context.Context, keys []string) (map[string]interface{}, error) {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return nil, err
 	}
 	return store.GetMultiple(ctx, keys)
 }
 
-// SetMultiple 设置多个缓存项
+// SetMultiple 设置多个缓存项，使用 WithTTLJitter 时每项的过期时间单独浮动
 func (m *Manager) SetMultiple(ctx context.Context, items map[string]interface{}, opts ...Option) error {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
+	if err != nil {
+		return err
+	}
+	options := applyOptions(opts...)
+	if options.Jitter <= 0 || options.Expiration <= 0 {
+		return store.SetMultiple(ctx, items, opts...)
+	}
+
+	entries := make([]Entry, 0, len(items))
+	for key, value := range items {
+		entries = append(entries, Entry{Key: key, Value: value, TTL: options.Expiration, Tags: options.Tags})
+	}
+	return m.setMany(ctx, store, entries, options.Jitter)
+}
+
+// SetMany 批量写入缓存项，每项有自己的过期时间与标签
+// opts 中的过期时间用于 TTL 为0的项，WithTTLJitter 对每项单独生效；存储实现 BatchSetter 时原生批量写入
+func (m *Manager) SetMany(ctx context.Context, entries []Entry, opts ...Option) error {
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return err
 	}
-	return store.SetMultiple(ctx, items, opts...)
+	options := applyOptions(opts...)
+	resolved := make([]Entry, len(entries))
+	for i, entry := range entries {
+		if entry.TTL <= 0 {
+			entry.TTL = options.Expiration
+		}
+		resolved[i] = entry
+	}
+	return m.setMany(ctx, store, resolved, options.Jitter)
+}
+
+// setMany 应用过期时间浮动后写入存储
+func (m *Manager) setMany(ctx context.Context, store Store, entries []Entry, jitter float64) error {
+	if jitter > 0 {
+		for i := range entries {
+			entries[i].TTL = m.jitter(entries[i].TTL, jitter)
+		}
+	}
+	if batch, ok := store.(BatchSetter); ok {
+		return batch.SetMany(ctx, entries)
+	}
+	for _, entry := range entries {
+		if err := store.Set(ctx, entry.Key, entry.Value, WithExpiration(entry.TTL), WithTags(entry.Tags...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetJitterSeed 设置过期时间浮动的随机种子，用于测试中得到确定的结果
+func (m *Manager) SetJitterSeed(seed int64) {
+	m.jitterMu.Lock()
+	defer m.jitterMu.Unlock()
+	m.jitterRand = rand.New(rand.NewSource(seed))
+}
+
+// jitter 按比例随机调整过期时间
+func (m *Manager) jitter(ttl time.Duration, fraction float64) time.Duration {
+	m.jitterMu.Lock()
+	r := m.jitterRand.Float64()
+	m.jitterMu.Unlock()
+	return jitterTTL(ttl, fraction, r)
 }
 
 // DeleteMultiple 删除多个缓存项
 func (m *Manager) DeleteMultiple(ctx context.Context, keys []string) error {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return err
 	}
-	return store.DeleteMultiple(ctx, keys)
+	if err := store.DeleteMultiple(ctx, keys); err != nil {
+		return err
+	}
+	m.publishEviction(ctx, "delete", keys...)
+	return nil
 }
 
 // TaggedGet 获取带有标签的缓存项
 func (m *Manager) TaggedGet(ctx context.Context, tag string) (map[string]interface{}, error) {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return nil, err
 	}
@@ -213,11 +417,16 @@ func (m *Manager) TaggedGet(ctx context.Context, tag string) (map[string]interfa
 
 // TaggedDelete 删除带有标签的缓存项
 func (m *Manager) TaggedDelete(ctx context.Context, tag string) error {
-	store, err := m.Store()
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
 	if err != nil {
 		return err
 	}
-	return store.TaggedDelete(ctx, tag)
+	if err := store.TaggedDelete(ctx, tag); err != nil {
+		return err
+	}
+	m.publishEviction(ctx, "tag", tag)
+	return nil
 }
 
 // WithPrefix 创建带有前缀的缓存管理器
@@ -273,12 +482,28 @@ func (p *PrefixedManager) Has(ctx context.Context, key string) bool {
 	return p.manager.Has(ctx, p.prefixKey(key))
 }
 
+// TTL 返回缓存项的剩余时间
+func (p *PrefixedManager) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return p.manager.TTL(ctx, p.prefixKey(key))
+}
+
+// Touch 重新设置缓存项的过期时间
+func (p *PrefixedManager) Touch(ctx context.Context, key string, ttl time.Duration) error {
+	return p.manager.Touch(ctx, p.prefixKey(key), ttl)
+}
+
+// GetWithTTL 获取缓存及其剩余时间
+func (p *PrefixedManager) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	return p.manager.GetWithTTL(ctx, p.prefixKey(key))
+}
+
 // GetMultiple 获取多个缓存项
 func (p *PrefixedManager) GetMultiple(ctx context.Context, keys []string) (map[string]interface{}, error) {
 	prefixedKeys, mapping := p.prefixKeys(keys)
 
 	prefixedResult, err := p.manager.GetMultiple(ctx, prefixedKeys)
-	if err != nil {
+	var multiErr *MultiError
+	if err != nil && !errors.As(err, &multiErr) {
 		return nil, err
 	}
 
@@ -290,6 +515,18 @@ func (p *PrefixedManager) GetMultiple(ctx context.Context, keys []string) (map[s
 		}
 	}
 
+	// 部分失败时同样转换失败键
+	if multiErr != nil {
+		mapped := &MultiError{Errors: make(map[string]error, len(multiErr.Errors))}
+		for prefixedKey, keyErr := range multiErr.Errors {
+			if originalKey, exists := mapping[prefixedKey]; exists {
+				prefixedKey = originalKey
+			}
+			mapped.Errors[prefixedKey] = keyErr
+		}
+		return result, mapped
+	}
+
 	return result, nil
 }
 
@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/event"
+	"gopkg.in/yaml.v3"
+)
+
+const twoStoreYAML = `
+cache:
+  default: primary
+  stores:
+    primary:
+      driver: memory
+      ttl: 300
+    local:
+      driver: memory
+      ttl: 1m
+`
+
+// newTestManager 从YAML配置创建缓存管理器
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	var raw map[string]map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(twoStoreYAML), &raw))
+
+	manager := NewManager()
+	require.NoError(t, manager.LoadConfig(raw["cache"]))
+	return manager
+}
+
+func TestManager_LoadConfig(t *testing.T) {
+	manager := newTestManager(t)
+
+	assert.Equal(t, "primary", manager.DefaultName(), "默认存储应来自配置")
+	assert.Equal(t, []string{"local", "primary"}, manager.StoreNames())
+
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	assert.Equal(t, "5m0s", manager.configs["primary"].TTL.String())
+	assert.Equal(t, "1m0s", manager.configs["local"].TTL.String())
+}
+
+func TestManager_StoreIsolation(t *testing.T) {
+	manager := newTestManager(t)
+	ctx := context.Background()
+
+	local, err := manager.Store("local")
+	require.NoError(t, err)
+
+	// 便捷方法作用于默认存储
+	require.NoError(t, manager.Set(ctx, "key", "primary-value"))
+	require.NoError(t, local.Set(ctx, "key", "local-value"))
+
+	value, err := manager.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "primary-value", value)
+
+	value, err = local.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "local-value", value)
+
+	// 同名存储返回同一实例
+	again, err := manager.Store("local")
+	require.NoError(t, err)
+	assert.Same(t, local, again)
+
+	// 空名称返回默认存储
+	primary, err := manager.Store("")
+	require.NoError(t, err)
+	assert.NotSame(t, local, primary)
+}
+
+func TestManager_TagsScopedToStore(t *testing.T) {
+	manager := newTestManager(t)
+	ctx := context.Background()
+
+	primary, err := manager.Store("primary")
+	require.NoError(t, err)
+	local, err := manager.Store("local")
+	require.NoError(t, err)
+
+	require.NoError(t, primary.Set(ctx, "user:1", "a", WithTags("users")))
+	require.NoError(t, local.Set(ctx, "user:1", "b", WithTags("users")))
+
+	// 删除一个存储中的标签不影响另一个存储
+	require.NoError(t, local.TaggedDelete(ctx, "users"))
+
+	assert.False(t, local.Has(ctx, "user:1"))
+	assert.True(t, primary.Has(ctx, "user:1"))
+
+	items, err := primary.TaggedGet(ctx, "users")
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestManager_UnknownStore(t *testing.T) {
+	manager := newTestManager(t)
+
+	_, err := manager.Store("missing")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrStoreNotFound))
+
+	var notFound *StoreNotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "missing", notFound.Name)
+	assert.Equal(t, []string{"local", "primary"}, notFound.Configured)
+}
+
+func TestManager_ConcurrentLazyCreation(t *testing.T) {
+	manager := newTestManager(t)
+
+	var wg sync.WaitGroup
+	stores := make([]Store, 20)
+	for i := range stores {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store, err := manager.Store("local")
+			assert.NoError(t, err)
+			stores[i] = store
+		}(i)
+	}
+	wg.Wait()
+
+	// 并发访问只会创建一个实例
+	for _, store := range stores {
+		assert.Same(t, stores[0], store)
+	}
+}
+
+func TestManager_PublishesCacheEvents(t *testing.T) {
+	manager := newTestManager(t)
+	ctx := context.Background()
+
+	// 未设置发布器时正常工作
+	_, err := manager.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	dispatcher := event.NewDispatcher(10)
+	defer dispatcher.Stop()
+	manager.SetEventPublisher(event.NewPublisher(dispatcher))
+
+	received := make(chan event.Event, 4)
+	listener := func(e event.Event) error {
+		received <- e
+		return nil
+	}
+	require.NoError(t, dispatcher.AddListenerFunc(event.EventCacheMiss, listener))
+	require.NoError(t, dispatcher.AddListenerFunc(event.EventCacheEviction, listener))
+
+	_, err = manager.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	require.NoError(t, manager.Set(ctx, "k", "v"))
+	require.NoError(t, manager.Delete(ctx, "k"))
+
+	next := func() event.Event {
+		select {
+		case e := <-received:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("未收到缓存事件")
+			return nil
+		}
+	}
+
+	miss, ok := next().(*event.CacheMiss)
+	require.True(t, ok)
+	assert.Equal(t, "missing", miss.Key)
+	assert.Equal(t, "primary", miss.Store)
+
+	eviction, ok := next().(*event.CacheEviction)
+	require.True(t, ok)
+	assert.Equal(t, "k", eviction.Key)
+	assert.Equal(t, "delete", eviction.Reason)
+}
@@ -3,6 +3,8 @@ package cache
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
 	"sync"
 	"time"
 )
@@ -28,19 +30,48 @@ func init() {
 // MemoryStore 内存缓存存储实现
 type MemoryStore struct {
 	items      map[string]Item
+	counters   map[string]memoryCounter
 	mutex      sync.RWMutex
 	tagManager TagManager
+	clock      func() time.Time
+}
+
+// memoryCounter 内存中的计数器，expiresAt 为零值时不过期
+type memoryCounter struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// expired 判断计数器是否已过期
+func (c memoryCounter) expired(now time.Time) bool {
+	return !c.expiresAt.IsZero() && !now.Before(c.expiresAt)
 }
 
 // NewMemoryStore 创建新的内存缓存存储
 func NewMemoryStore() *MemoryStore {
 	store := &MemoryStore{
-		items: make(map[string]Item),
+		items:    make(map[string]Item),
+		counters: make(map[string]memoryCounter),
 	}
 	store.tagManager = NewTagManager(store)
 	return store
 }
 
+// SetClock 设置判断过期与陈旧期使用的时钟，主要用于测试，应在使用存储之前调用
+func (s *MemoryStore) SetClock(clock func() time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clock = clock
+}
+
+// now 返回当前时间
+func (s *MemoryStore) now() time.Time {
+	if s.clock != nil {
+		return s.clock()
+	}
+	return time.Now()
+}
+
 // Get 获取缓存项
 func (s *MemoryStore) Get(ctx context.Context, key string) (interface{}, error) {
 	s.mutex.RLock()
@@ -51,9 +82,12 @@ func (s *MemoryStore) Get(ctx context.Context, key string) (interface{}, error)
 		return nil, ErrCacheMiss
 	}
 
-	// 检查过期时间
-	if !item.CreatedAt.IsZero() && item.Expiration > 0 &&
-		time.Now().After(item.CreatedAt.Add(item.Expiration)) {
+	// 检查过期时间，陈旧期内返回旧值
+	now := s.now()
+	if item.stale(now) {
+		return item.Value, ErrStale
+	}
+	if item.expired(now) {
 		return nil, ErrCacheMiss
 	}
 
@@ -74,8 +108,7 @@ func (s *MemoryStore) GetMultiple(ctx context.Context, keys []string) (map[strin
 		}
 
 		// 检查过期时间
-		if !item.CreatedAt.IsZero() && item.Expiration > 0 &&
-			time.Now().After(item.CreatedAt.Add(item.Expiration)) {
+		if item.expired(s.now()) {
 			continue
 		}
 
@@ -92,13 +125,7 @@ func (s *MemoryStore) Set(ctx context.Context, key string, value interface{}, op
 		opt(options)
 	}
 
-	item := Item{
-		Key:        key,
-		Value:      value,
-		Expiration: options.Expiration,
-		CreatedAt:  time.Now(),
-		Tags:       options.Tags,
-	}
+	item := newItem(key, value, options.Tags, options.Expiration, s.now()).withStale(options.StaleTTL)
 
 	s.mutex.Lock()
 	s.items[key] = item
@@ -122,16 +149,10 @@ func (s *MemoryStore) SetMultiple(ctx context.Context, items map[string]interfac
 		opt(options)
 	}
 
+	now := s.now()
 	s.mutex.Lock()
 	for key, value := range items {
-		item := Item{
-			Key:        key,
-			Value:      value,
-			Expiration: options.Expiration,
-			CreatedAt:  time.Now(),
-			Tags:       options.Tags,
-		}
-		s.items[key] = item
+		s.items[key] = newItem(key, value, options.Tags, options.Expiration, now).withStale(options.StaleTTL)
 	}
 	s.mutex.Unlock()
 
@@ -148,6 +169,26 @@ func (s *MemoryStore) SetMultiple(ctx context.Context, items map[string]interfac
 	return nil
 }
 
+// SetMany 批量写入缓存项，每项使用自己的过期时间与标签
+func (s *MemoryStore) SetMany(ctx context.Context, entries []Entry) error {
+	now := s.now()
+	s.mutex.Lock()
+	for _, entry := range entries {
+		s.items[entry.Key] = newItem(entry.Key, entry.Value, entry.Tags, entry.TTL, now)
+	}
+	s.mutex.Unlock()
+
+	for _, entry := range entries {
+		if len(entry.Tags) == 0 {
+			continue
+		}
+		if err := s.tagManager.AddTagsToKey(ctx, entry.Key, entry.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Delete 删除缓存项
 func (s *MemoryStore) Delete(ctx context.Context, key string) error {
 	s.mutex.Lock()
@@ -188,6 +229,7 @@ func (s *MemoryStore) Clear(ctx context.Context) error {
 	defer s.mutex.Unlock()
 
 	s.items = make(map[string]Item)
+	s.counters = make(map[string]memoryCounter)
 	// 重置标签管理器
 	s.tagManager = NewTagManager(s)
 
@@ -205,8 +247,7 @@ func (s *MemoryStore) Has(ctx context.Context, key string) bool {
 	}
 
 	// 检查是否已过期
-	if !item.CreatedAt.IsZero() && item.Expiration > 0 &&
-		time.Now().After(item.CreatedAt.Add(item.Expiration)) {
+	if item.expired(s.now()) {
 		return false
 	}
 
@@ -222,9 +263,9 @@ func (s *MemoryStore) Increment(ctx context.Context, key string, value int64) (i
 	item, exists := s.items[key]
 	var current int64
 	if exists {
-		// 检查是否已过期
-		if !item.CreatedAt.IsZero() && item.Expiration > 0 &&
-			time.Now().After(item.CreatedAt.Add(item.Expiration)) {
+		// 检查是否已过期，陈旧期内的缓存项在旧值上累加并保持陈旧
+		now := s.now()
+		if item.expired(now) && !item.stale(now) {
 			exists = false
 		} else {
 			// 尝试转换为 int64
@@ -248,24 +289,56 @@ func (s *MemoryStore) Increment(ctx context.Context, key string, value int64) (i
 	// 计算新值
 	newValue := current + value
 
-	// 如果不存在，创建新项；如果存在，更新现有项
-	expiration := time.Duration(0)
-	tags := []string{}
-	if exists {
-		expiration = item.Expiration
-		tags = item.Tags
+	// 如果不存在，创建不过期的新项；如果存在，只更新值，保留剩余的过期时间
+	if !exists {
+		item = newItem(key, nil, []string{}, 0, s.now())
 	}
+	item.Value = newValue
+	s.items[key] = item
 
-	// 更新缓存
-	s.items[key] = Item{
-		Key:        key,
-		Value:      newValue,
-		Expiration: expiration,
-		CreatedAt:  time.Now(),
-		Tags:       tags,
+	return newValue, nil
+}
+
+// TTL 返回缓存项的剩余时间
+func (s *MemoryStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	_, ttl, err := s.GetWithTTL(ctx, key)
+	return ttl, err
+}
+
+// GetWithTTL 获取缓存项及其剩余时间
+func (s *MemoryStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := s.now()
+	item, found := s.items[key]
+	if found && item.stale(now) {
+		return item.Value, 0, ErrStale
 	}
+	if !found || item.expired(now) {
+		return nil, 0, ErrCacheMiss
+	}
+	return item.Value, item.ttl(now), nil
+}
 
-	return newValue, nil
+// Touch 重新设置缓存项的过期时间，ttl 小于等于0时移除过期时间
+func (s *MemoryStore) Touch(ctx context.Context, key string, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	item, found := s.items[key]
+	if !found || item.expired(now) {
+		return ErrCacheMiss
+	}
+	staleTTL := item.StaleUntil.Sub(item.ExpiresAt)
+	item.Expiration, item.ExpiresAt, item.StaleUntil = ttl, time.Time{}, time.Time{}
+	if ttl > 0 {
+		item.ExpiresAt = now.Add(ttl)
+	}
+	// 保持原有的陈旧期长度
+	s.items[key] = item.withStale(staleTTL)
+	return nil
 }
 
 // Decrement 减少计数器值
@@ -273,6 +346,45 @@ func (s *MemoryStore) Decrement(ctx context.Context, key string, value int64) (i
 	return s.Increment(ctx, key, -value)
 }
 
+// IncrBatch 在同一把锁内增加多个计数器，ttl 只在计数器首次创建（或过期后重建）时设置
+func (s *MemoryStore) IncrBatch(ctx context.Context, deltas map[string]int64, ttl time.Duration) (map[string]int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	result := make(map[string]int64, len(deltas))
+	for key, delta := range deltas {
+		counter, ok := s.counters[key]
+		if !ok || counter.expired(now) {
+			counter = memoryCounter{}
+			if ttl > 0 {
+				counter.expiresAt = now.Add(ttl)
+			}
+		}
+		counter.value += delta
+		s.counters[key] = counter
+		result[key] = counter.value
+	}
+	return result, nil
+}
+
+// GetCounters 读取多个计数器，结果来自同一时刻的快照
+func (s *MemoryStore) GetCounters(ctx context.Context, keys []string) (map[string]int64, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := s.now()
+	result := make(map[string]int64, len(keys))
+	for _, key := range keys {
+		if counter, ok := s.counters[key]; ok && !counter.expired(now) {
+			result[key] = counter.value
+		} else {
+			result[key] = 0
+		}
+	}
+	return result, nil
+}
+
 // TaggedGet 获取带有指定标签的所有项
 func (s *MemoryStore) TaggedGet(ctx context.Context, tag string) (map[string]interface{}, error) {
 	keys, err := s.tagManager.GetKeysByTag(ctx, tag)
@@ -316,12 +428,11 @@ func (s *MemoryStore) Count(ctx context.Context) int64 {
 	defer s.mutex.RUnlock()
 
 	var count int64
-	now := time.Now()
+	now := s.now()
 
 	for _, item := range s.items {
 		// 只计算未过期的项
-		if !item.CreatedAt.IsZero() && item.Expiration > 0 &&
-			now.After(item.CreatedAt.Add(item.Expiration)) {
+		if item.expired(now) {
 			continue
 		}
 		count++
@@ -335,19 +446,23 @@ func (s *MemoryStore) Flush(ctx context.Context) error {
 	return s.Clear(ctx)
 }
 
-// GC 垃圾回收，清理过期的缓存项
+// GC 垃圾回收，清理过期的缓存项，陈旧期内的缓存项保留到陈旧期结束
 func (s *MemoryStore) GC(ctx context.Context) error {
-	now := time.Now()
+	now := s.now()
 	expiredKeys := make([]string, 0)
 
-	s.mutex.RLock()
+	s.mutex.Lock()
 	for key, item := range s.items {
-		if !item.CreatedAt.IsZero() && item.Expiration > 0 &&
-			now.After(item.CreatedAt.Add(item.Expiration)) {
+		if item.expired(now) && !item.stale(now) {
 			expiredKeys = append(expiredKeys, key)
 		}
 	}
-	s.mutex.RUnlock()
+	for key, counter := range s.counters {
+		if counter.expired(now) {
+			delete(s.counters, key)
+		}
+	}
+	s.mutex.Unlock()
 
 	if len(expiredKeys) > 0 {
 		return s.DeleteMultiple(ctx, expiredKeys)
@@ -355,3 +470,134 @@ func (s *MemoryStore) GC(ctx context.Context) error {
 
 	return nil
 }
+
+// memoryPipe MemoryStore 的操作队列
+type memoryPipe struct {
+	pipeQueue
+	store    *MemoryStore
+	snapshot map[string]memorySnapshot
+}
+
+// memorySnapshot Watch 时缓存项的快照
+type memorySnapshot struct {
+	item   Item
+	exists bool
+}
+
+// Watch 记录键的当前状态，提交时与之比较
+func (p *memoryPipe) Watch(keys ...string) error {
+	p.watched = true
+	p.store.mutex.RLock()
+	defer p.store.mutex.RUnlock()
+	now := p.store.now()
+	for _, key := range keys {
+		if _, ok := p.snapshot[key]; ok {
+			continue
+		}
+		item, exists := p.store.items[key]
+		p.snapshot[key] = memorySnapshot{item: item, exists: exists && !item.expired(now)}
+	}
+	return nil
+}
+
+// Pipeline 在同一把锁内校验并应用队列中的全部操作，实现 PipelineStore 接口
+//
+// 任一操作无效（例如对非整数值 Increment、Touch 不存在的键）时不应用任何操作
+func (s *MemoryStore) Pipeline(ctx context.Context, fn func(p Pipe) error) error {
+	p := &memoryPipe{store: s, snapshot: make(map[string]memorySnapshot)}
+	if err := fn(p); err != nil {
+		return err
+	}
+	if p.err != nil {
+		return p.err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	for key, snap := range p.snapshot {
+		item, exists := s.items[key]
+		exists = exists && !item.expired(now)
+		if exists != snap.exists || exists && !reflect.DeepEqual(item, snap.item) {
+			return ErrCasConflict
+		}
+	}
+
+	// 先在副本上计算全部操作，全部有效后再写入
+	staged := make(map[string]*Item)
+	results := make(map[*IntResult]int64)
+	current := func(key string) *Item {
+		if item, ok := staged[key]; ok {
+			return item
+		}
+		if item, ok := s.items[key]; ok && !item.expired(now) {
+			return &item
+		}
+		return nil
+	}
+	for _, op := range p.ops {
+		switch op.kind {
+		case pipeSet:
+			item := newItem(op.key, op.value, op.opts.Tags, op.opts.Expiration, now)
+			staged[op.key] = &item
+		case pipeDelete:
+			staged[op.key] = nil
+		case pipeIncrement:
+			item := current(op.key)
+			var value int64
+			if item == nil {
+				created := newItem(op.key, nil, []string{}, 0, now)
+				item = &created
+			} else {
+				copied := *item
+				item = &copied
+				v, err := counterValue(op.key, item.Value)
+				if err != nil {
+					return err
+				}
+				value = v
+			}
+			item.Value = value + op.delta
+			results[op.result] = value + op.delta
+			staged[op.key] = item
+		case pipeTouch:
+			item := current(op.key)
+			if item == nil {
+				return fmt.Errorf("%w: %s", ErrCacheMiss, op.key)
+			}
+			copied := *item
+			copied.Expiration, copied.ExpiresAt = op.ttl, time.Time{}
+			if op.ttl > 0 {
+				copied.ExpiresAt = now.Add(op.ttl)
+			}
+			staged[op.key] = &copied
+		}
+	}
+
+	// 应用到存储与标签，标签管理器使用自己的锁
+	for _, op := range p.ops {
+		switch op.kind {
+		case pipeSet:
+			if len(op.opts.Tags) > 0 {
+				if err := s.tagManager.AddTagsToKey(ctx, op.key, op.opts.Tags); err != nil {
+					return err
+				}
+			}
+		case pipeDelete:
+			if err := s.tagManager.RemoveKeyFromAllTags(ctx, op.key); err != nil {
+				return err
+			}
+		}
+	}
+	for key, item := range staged {
+		if item == nil {
+			delete(s.items, key)
+			continue
+		}
+		s.items[key] = *item
+	}
+	for result, value := range results {
+		result.val = value
+	}
+	return nil
+}
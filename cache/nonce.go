@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultNoncePrefix 随机数在缓存中的键前缀
+const DefaultNoncePrefix = "flow:nonce:"
+
+// NonceStore 基于缓存的随机数存储，用于请求签名的防重放，所有共享同一缓存的实例都能识别重放
+// 实现了 middleware.NonceStore
+type NonceStore struct {
+	store  Store
+	prefix string
+}
+
+// NewNonceStore 创建随机数存储，prefix为空时使用DefaultNoncePrefix
+func NewNonceStore(store Store, prefix string) *NonceStore {
+	if prefix == "" {
+		prefix = DefaultNoncePrefix
+	}
+	return &NonceStore{store: store, prefix: prefix}
+}
+
+// Claim 首次使用随机数时返回 true
+// 依赖存储计数器的原子递增判断是否首次出现，随后写入过期时间
+func (s *NonceStore) Claim(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	key := s.prefix + nonce
+	count, err := s.store.Increment(ctx, key, 1)
+	if err != nil {
+		return false, err
+	}
+	if count != 1 {
+		return false, nil
+	}
+	if err := s.store.Set(ctx, key, count, WithExpiration(ttl)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/timing"
+)
+
+// Pipeline 相关错误
+var (
+	// ErrCasConflict Watch 的键在提交前被修改，队列中的操作都未执行，调用方可以重新读取后重试
+	ErrCasConflict = errors.New("缓存键已被修改，事务未执行")
+	// ErrPipelineNotSupported 缓存存储没有实现 PipelineStore
+	ErrPipelineNotSupported = errors.New("缓存存储不支持 Pipeline")
+)
+
+// Pipe 多键操作队列，操作在回调返回后一起提交
+//
+// Set、Delete、Increment 与 Touch 只加入队列，回调中不会生效；Watch 立即生效，
+// 应在读取被依赖的键之前调用
+type Pipe interface {
+	// Watch 监视键，从调用时起到提交前这些键被修改时 Pipeline 返回 ErrCasConflict
+	Watch(keys ...string) error
+	// Set 写入缓存项，标签关联与值在同一次提交中写入
+	Set(key string, value interface{}, opts ...Option)
+	// Delete 删除缓存项及其标签关联
+	Delete(key string)
+	// Increment 增加整数值，保留键的剩余过期时间，结果在 Pipeline 成功返回后可用
+	Increment(key string, delta int64) *IntResult
+	// Touch 重新设置过期时间，键不存在时 Pipeline 返回 ErrCacheMiss
+	Touch(key string, ttl time.Duration)
+}
+
+// PipelineStore 支持多键原子提交的存储
+//
+// 各存储的保证：
+//   - MemoryStore：在同一把锁内校验并应用全部操作，其他读写看不到中间状态；
+//     Watch 比较监视时与提交时的缓存项快照
+//   - RedisStore：在客户端校验并序列化全部操作后，用 WATCH 与 MULTI/EXEC 一次提交，包括
+//     RedisTagManager 的标签关联；Increment、Delete 与 Touch 需要读取的键同样被监视，
+//     未调用 Watch 时因这些键被并发修改而失败的提交会自动重试。Redis 不回滚 EXEC 中
+//     执行出错的单条命令（例如标签集合的类型错误），此时返回错误但其他命令已经生效；
+//     自定义标签管理器的标签在提交成功后再关联，不在同一事务中
+type PipelineStore interface {
+	Pipeline(ctx context.Context, fn func(p Pipe) error) error
+}
+
+// IntResult Pipe.Increment 的结果
+type IntResult struct {
+	val int64
+}
+
+// Val 返回增加后的值，Pipeline 未成功时为0
+func (r *IntResult) Val() int64 {
+	return r.val
+}
+
+// pipeOpKind 队列中的操作类型
+type pipeOpKind int
+
+const (
+	pipeSet pipeOpKind = iota
+	pipeDelete
+	pipeIncrement
+	pipeTouch
+)
+
+// pipeOp 队列中的单个操作
+type pipeOp struct {
+	kind   pipeOpKind
+	key    string
+	value  interface{}
+	opts   Options
+	delta  int64
+	ttl    time.Duration
+	result *IntResult
+}
+
+// pipeQueue 各存储共用的操作队列，实现 Pipe 除 Watch 之外的方法
+type pipeQueue struct {
+	ops     []pipeOp
+	err     error // 第一个无效操作的错误
+	watched bool  // 是否调用过 Watch
+}
+
+// Set 加入写入操作
+func (q *pipeQueue) Set(key string, value interface{}, opts ...Option) {
+	q.add(pipeOp{kind: pipeSet, key: key, value: value, opts: applyOptions(opts...)})
+}
+
+// Delete 加入删除操作
+func (q *pipeQueue) Delete(key string) {
+	q.add(pipeOp{kind: pipeDelete, key: key})
+}
+
+// Increment 加入增加操作
+func (q *pipeQueue) Increment(key string, delta int64) *IntResult {
+	result := &IntResult{}
+	q.add(pipeOp{kind: pipeIncrement, key: key, delta: delta, result: result})
+	return result
+}
+
+// Touch 加入设置过期时间操作
+func (q *pipeQueue) Touch(key string, ttl time.Duration) {
+	q.add(pipeOp{kind: pipeTouch, key: key, ttl: ttl})
+}
+
+// add 校验并加入操作
+func (q *pipeQueue) add(op pipeOp) {
+	if op.key == "" && q.err == nil {
+		q.err = ErrInvalidKey
+	}
+	q.ops = append(q.ops, op)
+}
+
+// readKeys 返回需要读取当前值的键（Delete、Increment、Touch），按首次出现的顺序去重
+func (q *pipeQueue) readKeys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, op := range q.ops {
+		if op.kind != pipeSet && !seen[op.key] {
+			seen[op.key] = true
+			keys = append(keys, op.key)
+		}
+	}
+	return keys
+}
+
+// counterValue 将缓存值转换为整数，JSON 反序列化的数字为 float64
+func counterValue(key string, value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case float32:
+		return int64(v), nil
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s 不是整数", ErrInvalidValue, key)
+}
+
+// Pipeline 在默认存储上原子地执行多键操作，fn 返回错误时不执行任何操作
+//
+//	err := manager.Pipeline(ctx, func(p cache.Pipe) error {
+//		p.Set("post:42", post, cache.WithTags("posts"))
+//		p.Set("posts:latest", ids)
+//		p.Increment("posts:count", 1)
+//		return nil
+//	})
+//
+// 需要基于读取的值写入时先 Watch 再读取，返回 ErrCasConflict 时重试：
+//
+//	for {
+//		err := manager.Pipeline(ctx, func(p cache.Pipe) error {
+//			if err := p.Watch("posts:latest"); err != nil {
+//				return err
+//			}
+//			ids, _ := manager.Get(ctx, "posts:latest")
+//			p.Set("posts:latest", prepend(ids, 42))
+//			return nil
+//		})
+//		if !errors.Is(err, cache.ErrCasConflict) {
+//			return err
+//		}
+//	}
+//
+// 各存储的原子性保证见 PipelineStore；存储未实现 PipelineStore 时返回 ErrPipelineNotSupported
+func (m *Manager) Pipeline(ctx context.Context, fn func(p Pipe) error) error {
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.DefaultStore()
+	if err != nil {
+		return err
+	}
+	pipelined, ok := store.(PipelineStore)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrPipelineNotSupported, store)
+	}
+
+	var deleted []string
+	err = pipelined.Pipeline(ctx, func(p Pipe) error {
+		return fn(&recordingPipe{Pipe: p, deleted: &deleted})
+	})
+	if err != nil {
+		return err
+	}
+	m.publishEviction(ctx, "delete", deleted...)
+	return nil
+}
+
+// recordingPipe 记录删除的键，提交成功后发布缓存删除事件
+type recordingPipe struct {
+	Pipe
+	deleted *[]string
+}
+
+// Delete 加入删除操作并记录键
+func (p *recordingPipe) Delete(key string) {
+	p.Pipe.Delete(key)
+	*p.deleted = append(*p.deleted, key)
+}
@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dump 测试中复制服务器的全部字符串与集合，用于确认没有任何写入
+func (f *fakeRedis) dump() (map[string]string, map[string][]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	strs := make(map[string]string, len(f.strings))
+	for k, v := range f.strings {
+		strs[k] = v
+	}
+	sets := make(map[string][]string, len(f.sets))
+	for k := range f.sets {
+		if members := f.members(k); len(members) > 0 {
+			sets[k] = members
+		}
+	}
+	return strs, sets
+}
+
+func TestRedisPipeline_Atomic(t *testing.T) {
+	store, server, hook := newTestRedisStore(t)
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "post:1", "old", WithTags("posts")))
+	require.NoError(t, store.Set(ctx, "posts:count", 1))
+
+	var count *IntResult
+	atomic.StoreInt64(&hook.count, 0)
+	err := store.Pipeline(ctx, func(p Pipe) error {
+		p.Delete("post:1")
+		p.Set("post:2", "new", WithTags("posts"))
+		p.Set("posts:latest", []string{"post:2"})
+		count = p.Increment("posts:count", 1)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count.Val())
+	assert.Equal(t, int64(4), atomic.LoadInt64(&hook.count), "WATCH、读取、MULTI/EXEC 与 UNWATCH，不随键的数量增加")
+
+	// 值与标签在同一事务中写入
+	_, err = store.Get(ctx, "post:1")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	assert.Equal(t, []string{"flow:post:2"}, server.setMembers("flow:tag:posts"))
+	assert.Empty(t, server.setMembers("flow:key_tags:post:1"))
+	value, err := store.Get(ctx, "posts:count")
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), value)
+	assert.Equal(t, 5*time.Minute, server.ttl("flow:posts:count"), "Increment 保留过期时间")
+
+	// 队列中途出错时不执行任何操作
+	strs, sets := server.dump()
+	failures := map[string]func(p Pipe) error{
+		"回调返回错误": func(p Pipe) error {
+			p.Set("post:3", "x", WithTags("posts"))
+			p.Delete("post:2")
+			return errors.New("abort")
+		},
+		"无法序列化": func(p Pipe) error {
+			p.Set("post:3", "x", WithTags("posts"))
+			p.Set("post:4", make(chan int))
+			p.Delete("post:2")
+			return nil
+		},
+		"非整数计数": func(p Pipe) error {
+			p.Delete("post:2")
+			p.Increment("posts:latest", 1)
+			return nil
+		},
+		"键不存在": func(p Pipe) error {
+			p.Set("post:3", "x")
+			p.Touch("missing", time.Minute)
+			return nil
+		},
+		"空键": func(p Pipe) error {
+			p.Set("", "x")
+			return nil
+		},
+	}
+	for name, fn := range failures {
+		assert.Error(t, store.Pipeline(ctx, fn), name)
+		gotStrs, gotSets := server.dump()
+		assert.Equal(t, strs, gotStrs, name)
+		assert.Equal(t, sets, gotSets, name)
+	}
+}
+
+func TestRedisPipeline_WatchConflictRetry(t *testing.T) {
+	store, _, _ := newTestRedisStore(t)
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "list", []interface{}{"a"}))
+
+	attempts := 0
+	for {
+		err := store.Pipeline(ctx, func(p Pipe) error {
+			attempts++
+			require.NoError(t, p.Watch("list"))
+			value, err := store.Get(ctx, "list")
+			if err != nil {
+				return err
+			}
+			if attempts == 1 {
+				// 读取之后其他客户端修改了列表
+				require.NoError(t, store.Set(ctx, "list", []interface{}{"a", "b"}))
+			}
+			p.Set("list", append(value.([]interface{}), "c"))
+			return nil
+		})
+		if errors.Is(err, ErrCasConflict) {
+			continue
+		}
+		require.NoError(t, err)
+		break
+	}
+	assert.Equal(t, 2, attempts)
+	value, err := store.Get(ctx, "list")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, value)
+}
+
+func TestMemoryPipeline_Atomicity(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	// 并发的读取总是看到两个键相等
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	var torn atomic.Int64
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			values, _ := store.GetMultiple(ctx, []string{"a", "b"})
+			if values["a"] != values["b"] {
+				torn.Add(1)
+			}
+		}
+	}()
+	var writers sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for j := 0; j < 50; j++ {
+				require.NoError(t, store.Pipeline(ctx, func(p Pipe) error {
+					p.Increment("a", 1)
+					p.Increment("b", 1)
+					return nil
+				}))
+			}
+		}()
+	}
+	writers.Wait()
+	close(stop)
+	wg.Wait()
+
+	assert.Zero(t, torn.Load())
+	values, _ := store.GetMultiple(ctx, []string{"a", "b"})
+	assert.Equal(t, int64(1000), values["a"])
+
+	// 监视的键被修改时不执行
+	err := store.Pipeline(ctx, func(p Pipe) error {
+		require.NoError(t, p.Watch("a"))
+		_, _ = store.Increment(ctx, "a", 1)
+		p.Set("b", 0)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrCasConflict)
+	values, _ = store.GetMultiple(ctx, []string{"b"})
+	assert.Equal(t, int64(1000), values["b"])
+
+	// 中途出错时不执行任何操作
+	err = store.Pipeline(ctx, func(p Pipe) error {
+		p.Set("c", "x")
+		p.Delete("a")
+		p.Touch("missing", time.Minute)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	assert.False(t, store.Has(ctx, "c"))
+	assert.True(t, store.Has(ctx, "a"))
+}
+
+func TestManagerPipeline_Tags(t *testing.T) {
+	ctx := context.Background()
+	redisStore, _, _ := newTestRedisStore(t)
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "redis": redisStore} {
+		manager := NewManager()
+		manager.AddStore("test", store)
+		manager.SetDefault("test")
+		require.NoError(t, manager.Set(ctx, "user:1", "alice", WithTags("users")))
+
+		err := manager.Pipeline(ctx, func(p Pipe) error {
+			p.Delete("user:1")
+			p.Set("user:2", "bob", WithTags("users", "admins"))
+			p.Set("user:3", "carol", WithTags("users"))
+			return nil
+		})
+		require.NoError(t, err, name)
+
+		users, err := manager.TaggedGet(ctx, "users")
+		require.NoError(t, err, name)
+		assert.Equal(t, map[string]interface{}{"user:2": "bob", "user:3": "carol"}, users, name)
+		admins, _ := manager.TaggedGet(ctx, "admins")
+		assert.Len(t, admins, 1, name)
+
+		// 删除标签后键一并删除
+		require.NoError(t, manager.TaggedDelete(ctx, "admins"), name)
+		assert.False(t, manager.Has(ctx, "user:2"), name)
+	}
+
+	// 存储没有实现 PipelineStore
+	manager := NewManager()
+	manager.AddStore("plain", struct{ Store }{NewMemoryStore()})
+	manager.SetDefault("plain")
+	err := manager.Pipeline(ctx, func(p Pipe) error { return nil })
+	assert.ErrorIs(t, err, ErrPipelineNotSupported)
+}
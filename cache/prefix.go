@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/event"
+	"github.com/zzliekkas/flow/v2/timing"
+)
+
+// 按前缀操作相关错误
+var (
+	// ErrPrefixNotSupported 缓存存储没有实现 PrefixStore
+	ErrPrefixNotSupported = errors.New("缓存存储不支持按前缀删除")
+	// ErrEmptyPrefix 前缀为空，按前缀删除不允许清空整个缓存
+	ErrEmptyPrefix = errors.New("缓存键前缀不能为空")
+)
+
+// redisScanCount 每次 SCAN 建议返回的键数量
+const redisScanCount = 500
+
+// PrefixStore 支持按键前缀查找与删除的存储，用于清除某个用户命名空间（如 "user:42:"）下的全部缓存
+//
+// 缓存项与计数器都包括在内；Redis 使用 SCAN 遍历，不阻塞服务器，但遍历期间新写入的键可能不被删除
+type PrefixStore interface {
+	// KeysByPrefix 返回以 prefix 开头的未过期缓存项与计数器的键，按字典序排列
+	KeysByPrefix(ctx context.Context, prefix string) ([]string, error)
+	// DeleteByPrefix 删除以 prefix 开头的缓存项与计数器，返回删除的数量
+	DeleteByPrefix(ctx context.Context, prefix string) (int64, error)
+}
+
+// KeysByPrefix 返回以 prefix 开头的键
+func (s *MemoryStore) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	if prefix == "" {
+		return nil, ErrEmptyPrefix
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.keysByPrefixLocked(prefix, time.Now()), nil
+}
+
+// keysByPrefixLocked 查找以 prefix 开头的键，调用方持有锁
+func (s *MemoryStore) keysByPrefixLocked(prefix string, now time.Time) []string {
+	seen := make(map[string]struct{})
+	for key, item := range s.items {
+		if strings.HasPrefix(key, prefix) && !item.expired(now) {
+			seen[key] = struct{}{}
+		}
+	}
+	for key, counter := range s.counters {
+		if strings.HasPrefix(key, prefix) && !counter.expired(now) {
+			seen[key] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DeleteByPrefix 删除以 prefix 开头的缓存项与计数器
+func (s *MemoryStore) DeleteByPrefix(ctx context.Context, prefix string) (int64, error) {
+	if prefix == "" {
+		return 0, ErrEmptyPrefix
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := s.keysByPrefixLocked(prefix, time.Now())
+	for _, key := range keys {
+		if _, ok := s.items[key]; ok {
+			delete(s.items, key)
+			if err := s.tagManager.RemoveKeyFromAllTags(ctx, key); err != nil {
+				return 0, err
+			}
+		}
+		delete(s.counters, key)
+	}
+	return int64(len(keys)), nil
+}
+
+// KeysByPrefix 使用 SCAN 查找以 prefix 开头的缓存项与计数器
+func (r *RedisStore) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	items, counters, err := r.scanPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := append(items, counters...)
+	sort.Strings(keys)
+	// 同名的缓存项与计数器只返回一次
+	result := keys[:0]
+	for i, key := range keys {
+		if i == 0 || key != keys[i-1] {
+			result = append(result, key)
+		}
+	}
+	return result, nil
+}
+
+// DeleteByPrefix 删除以 prefix 开头的缓存项及其标签关联、计数器
+func (r *RedisStore) DeleteByPrefix(ctx context.Context, prefix string) (int64, error) {
+	items, counters, err := r.scanPrefix(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.DeleteMultiple(ctx, items); err != nil {
+		return 0, err
+	}
+	if len(counters) > 0 {
+		counterKeys := make([]string, len(counters))
+		for i, key := range counters {
+			counterKeys[i] = r.counterKey(key)
+		}
+		if err := r.client.Del(ctx, counterKeys...).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(items) + len(counters)), nil
+}
+
+// scanPrefix 分别返回以 prefix 开头的缓存项与计数器的键（不含存储前缀）
+func (r *RedisStore) scanPrefix(ctx context.Context, prefix string) (items, counters []string, err error) {
+	if prefix == "" {
+		return nil, nil, ErrEmptyPrefix
+	}
+	counterPrefix := r.prefix + CounterKeyPrefix
+	items, err = r.scan(ctx, r.prefix+prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 存储前缀为空时计数器也会匹配缓存项的模式，需要排除
+	filtered := items[:0]
+	for _, key := range items {
+		if !strings.HasPrefix(key, counterPrefix) {
+			filtered = append(filtered, strings.TrimPrefix(key, r.prefix))
+		}
+	}
+	items = filtered
+
+	counters, err = r.scan(ctx, counterPrefix+prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, key := range counters {
+		counters[i] = strings.TrimPrefix(key, counterPrefix)
+	}
+	return items, counters, nil
+}
+
+// scan 使用 SCAN 遍历以 prefix 开头的键，SCAN 可能重复返回同一个键，结果已去重
+func (r *RedisStore) scan(ctx context.Context, prefix string) ([]string, error) {
+	seen := make(map[string]struct{})
+	var keys []string
+	iter := r.client.Scan(ctx, 0, escapeGlob(prefix)+"*", redisScanCount).Iterator()
+	for iter.Next(ctx) {
+		if _, ok := seen[iter.Val()]; !ok {
+			seen[iter.Val()] = struct{}{}
+			keys = append(keys, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// escapeGlob 转义 Redis 模式中的特殊字符
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\', '^', '-':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// KeysByPrefix 通过默认存储查找以 prefix 开头的键，存储未实现 PrefixStore 时返回 ErrPrefixNotSupported
+func (m *Manager) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.prefixStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.KeysByPrefix(ctx, prefix)
+}
+
+// DeleteByPrefix 通过默认存储删除以 prefix 开头的缓存项与计数器，存储未实现 PrefixStore 时返回 ErrPrefixNotSupported
+func (m *Manager) DeleteByPrefix(ctx context.Context, prefix string) (int64, error) {
+	defer timing.Measure(ctx, timing.LayerCache)()
+	store, err := m.prefixStore()
+	if err != nil {
+		return 0, err
+	}
+	// 只有订阅了淘汰事件时才需要逐个键发布
+	var keys []string
+	if m.publisher().Enabled(event.EventCacheEviction) {
+		if keys, err = store.KeysByPrefix(ctx, prefix); err != nil {
+			return 0, err
+		}
+	}
+	n, err := store.DeleteByPrefix(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	m.publishEviction(ctx, "delete", keys...)
+	return n, nil
+}
+
+// prefixStore 返回实现了 PrefixStore 的默认存储
+func (m *Manager) prefixStore() (PrefixStore, error) {
+	store, err := m.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+	prefixed, ok := store.(PrefixStore)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrPrefixNotSupported, store)
+	}
+	return prefixed, nil
+}
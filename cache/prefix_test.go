@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteByPrefix(t *testing.T) {
+	redisStore, _, _ := newTestRedisStore(t)
+	stores := map[string]Store{"memory": NewMemoryStore(), "redis": redisStore}
+	ctx := context.Background()
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			manager := NewManager()
+			manager.AddStore(name, store)
+			manager.SetDefault(name)
+
+			require.NoError(t, manager.Set(ctx, "user:42:profile", "p", WithTags("profiles")))
+			require.NoError(t, manager.Set(ctx, "user:42:settings", "s"))
+			require.NoError(t, manager.Set(ctx, "user:420:profile", "other"))
+			require.NoError(t, manager.Set(ctx, "user:7:profile", "other", WithTags("profiles")))
+			require.NoError(t, manager.Set(ctx, "a*b:1", "glob"))
+			require.NoError(t, manager.Set(ctx, "axb:1", "glob"))
+			_, err := manager.IncrBatch(ctx, map[string]int64{"user:42:logins": 3}, 0)
+			require.NoError(t, err)
+
+			keys, err := manager.KeysByPrefix(ctx, "user:42:")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"user:42:logins", "user:42:profile", "user:42:settings"}, keys)
+
+			n, err := manager.DeleteByPrefix(ctx, "user:42:")
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), n)
+			assert.False(t, manager.Has(ctx, "user:42:profile"))
+			assert.True(t, manager.Has(ctx, "user:420:profile"))
+			counters, err := manager.GetCounters(ctx, []string{"user:42:logins"})
+			require.NoError(t, err)
+			assert.Zero(t, counters["user:42:logins"])
+
+			// 标签关联一并删除
+			tagged, err := manager.TaggedGet(ctx, "profiles")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"user:7:profile": "other"}, tagged)
+
+			// 前缀中的模式字符按字面匹配
+			n, err = manager.DeleteByPrefix(ctx, "a*b:")
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+			assert.True(t, manager.Has(ctx, "axb:1"))
+
+			_, err = manager.DeleteByPrefix(ctx, "")
+			assert.ErrorIs(t, err, ErrEmptyPrefix)
+		})
+	}
+
+	manager := NewManager()
+	manager.AddStore("file", &FileStore{})
+	manager.SetDefault("file")
+	_, err := manager.DeleteByPrefix(ctx, "user:42:")
+	assert.ErrorIs(t, err, ErrPrefixNotSupported)
+}
@@ -11,6 +11,63 @@ import (
 // CacheProvider 缓存服务提供者
 type CacheProvider struct {
 	*app.BaseProvider
+	config *ProviderConfig // 启动时验证通过的 cache 配置段
+}
+
+// ProviderConfig cache 配置段
+//
+//	cache:
+//	  default: redis
+//	  stores:
+//	    redis:
+//	      driver: redis
+//	      prefix: "app:"
+//	      ttl: 300
+//	      codec: msgpack # 缓存项编码：json（默认）、gob、msgpack
+type ProviderConfig struct {
+	Default string                 `mapstructure:"default" json:"default"`
+	Stores  map[string]StoreConfig `mapstructure:"stores" json:"stores" validate:"dive"`
+}
+
+// StoreConfig 单个缓存存储的配置，驱动特定的配置项保留在 Options 中
+type StoreConfig struct {
+	Driver  string                 `mapstructure:"driver" json:"driver" validate:"required"`
+	Prefix  string                 `mapstructure:"prefix" json:"prefix"`
+	Options map[string]interface{} `mapstructure:",remain" json:"-"`
+}
+
+var _ app.ConfigProvider = (*CacheProvider)(nil)
+
+// ConfigSection 配置段名称
+func (p *CacheProvider) ConfigSection() string {
+	return "cache"
+}
+
+// ConfigSchema 配置结构
+func (p *CacheProvider) ConfigSchema() interface{} {
+	return &ProviderConfig{}
+}
+
+// Configure 检查驱动与默认存储并保存配置
+func (p *CacheProvider) Configure(cfg interface{}) error {
+	c := cfg.(*ProviderConfig)
+	for name, store := range c.Stores {
+		if _, ok := GetDriver(store.Driver); !ok {
+			return &app.ConfigError{Path: "cache.stores." + name + ".driver", Message: "缓存驱动不存在: " + store.Driver}
+		}
+		if codec, ok := store.Options["codec"].(string); ok {
+			if _, err := CodecByName(codec); err != nil {
+				return &app.ConfigError{Path: "cache.stores." + name + ".codec", Message: err.Error()}
+			}
+		}
+	}
+	if c.Default != "" && len(c.Stores) > 0 {
+		if _, ok := c.Stores[c.Default]; !ok {
+			return &app.ConfigError{Path: "cache.default", Message: "默认缓存存储未配置: " + c.Default}
+		}
+	}
+	p.config = c
+	return nil
 }
 
 // NewCacheProvider 创建缓存服务提供者
@@ -27,8 +84,12 @@ func (p *CacheProvider) Register(application *app.Application) error {
 	// 创建缓存管理器
 	manager := NewManager()
 
-	// 从配置加载缓存设置
-	p.loadCacheConfig(application, manager)
+	// 从配置加载缓存设置，启动流程中已验证的配置段优先
+	if p.config != nil {
+		p.applyConfig(application, manager)
+	} else {
+		p.loadCacheConfig(application, manager)
+	}
 
 	// 向DI容器注册缓存管理器
 	return application.Engine().Provide(func() *Manager {
@@ -78,58 +139,44 @@ func (p *CacheProvider) loadCacheConfig(application *app.Application, manager *M
 		return
 	}
 
-	// 获取默认缓存驱动
-	defaultStore := "memory"
-	if def, ok := cacheConfig["default"].(string); ok && def != "" {
-		defaultStore = def
+	// 注册所有缓存存储配置
+	if err := manager.LoadConfig(cacheConfig); err != nil {
+		application.Logger().Warnf("加载缓存配置失败: %v", err)
+		if len(manager.StoreNames()) == 0 {
+			p.registerDefaultConfig(manager)
+		}
 	}
 
-	// 获取存储配置
-	stores := make(map[string]interface{})
-	if storesConfig, ok := cacheConfig["stores"].(map[string]interface{}); ok {
-		stores = storesConfig
+	for _, name := range manager.StoreNames() {
+		application.Logger().Infof("已注册缓存存储: %s", name)
 	}
+	application.Logger().Infof("默认缓存存储: %s", manager.DefaultName())
+}
 
-	// 注册所有缓存配置
-	for storeName, storeConfig := range stores {
-		if config, ok := storeConfig.(map[string]interface{}); ok {
-			// 获取驱动类型
-			driver := "memory"
-			if d, ok := config["driver"].(string); ok && d != "" {
-				driver = d
-			}
-
-			// 获取键前缀
-			prefix := ""
-			if p, ok := config["prefix"].(string); ok {
-				prefix = p
-			}
-
-			// 获取过期时间
-			var ttl time.Duration
-			if t, ok := config["ttl"].(int); ok && t > 0 {
-				ttl = time.Duration(t) * time.Second
-			} else if t, ok := config["ttl"].(string); ok && t != "" {
-				if parsedTTL, err := time.ParseDuration(t); err == nil {
-					ttl = parsedTTL
-				}
-			}
-
-			// 注册配置
-			manager.Register(storeName, Config{
-				Driver: driver,
-				Prefix: prefix,
-				TTL:    ttl,
-				Config: config,
-			})
+// applyConfig 注册已验证的缓存配置
+func (p *CacheProvider) applyConfig(application *app.Application, manager *Manager) {
+	if len(p.config.Stores) == 0 {
+		p.registerDefaultConfig(manager)
+		return
+	}
 
-			application.Logger().Infof("已注册缓存存储: %s (驱动: %s)", storeName, driver)
+	for name, store := range p.config.Stores {
+		cfg := make(map[string]interface{}, len(store.Options)+2)
+		for k, v := range store.Options {
+			cfg[k] = v
 		}
+		cfg["driver"] = store.Driver
+		cfg["prefix"] = store.Prefix
+		manager.Register(name, parseStoreConfig(cfg))
+		application.Logger().Infof("已注册缓存存储: %s", name)
 	}
 
-	// 设置默认存储
+	defaultStore := p.config.Default
+	if defaultStore == "" {
+		defaultStore = "memory"
+	}
 	manager.SetDefault(defaultStore)
-	application.Logger().Infof("默认缓存存储: %s", defaultStore)
+	application.Logger().Infof("默认缓存存储: %s", manager.DefaultName())
 }
 
 // 注册默认配置
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/db"
+)
+
+// QueryCache 基于缓存管理器的数据库查询缓存，实现 db.QueryCache 接口
+// 数据以base64字符串保存，保证经过JSON序列化的存储（如Redis）也能原样读取
+type QueryCache struct {
+	manager *Manager
+	store   string
+}
+
+var _ db.QueryCache = (*QueryCache)(nil)
+
+// NewQueryCache 创建查询缓存，store为空时使用默认存储
+func NewQueryCache(manager *Manager, store string) *QueryCache {
+	return &QueryCache{manager: manager, store: store}
+}
+
+// Get 获取缓存数据
+func (q *QueryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	store, err := q.manager.Store(q.store)
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, err := store.Get(ctx, key)
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	encoded, ok := value.(string)
+	if !ok {
+		return nil, false, fmt.Errorf("查询缓存数据格式错误: %T", value)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set 写入缓存数据并关联标签
+func (q *QueryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error {
+	store, err := q.manager.Store(q.store)
+	if err != nil {
+		return err
+	}
+	return store.Set(ctx, key, base64.StdEncoding.EncodeToString(value), WithExpiration(ttl), WithTags(tags...))
+}
+
+// InvalidateTags 删除标签关联的缓存
+func (q *QueryCache) InvalidateTags(ctx context.Context, tags []string) error {
+	store, err := q.manager.Store(q.store)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, tag := range tags {
+		if err := store.TaggedDelete(ctx, tag); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
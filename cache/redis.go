@@ -2,9 +2,10 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,6 +30,8 @@ type RedisStore struct {
 	healthTicker  *time.Ticker
 	stopChan      chan struct{}
 	tagManager    TagManager
+	onDecodeError func(key string, err error)
+	codec         Codec
 }
 
 // RedisOptions 用于配置Redis缓存
@@ -41,6 +44,8 @@ type RedisOptions struct {
 	MaxRetries          int
 	PoolSize            int
 	MinIdleConns        int
+	DecodeErrorHook     func(key string, err error)
+	Codec               Codec
 }
 
 // WithRedisPrefix 设置缓存键前缀
@@ -74,6 +79,20 @@ func WithRedisHealthCheck(enabled bool, interval time.Duration) func(*RedisOptio
 	}
 }
 
+// WithRedisDecodeErrorHook 设置批量读取时单个键解码失败的回调，可用于统计损坏的缓存值
+func WithRedisDecodeErrorHook(hook func(key string, err error)) func(*RedisOptions) {
+	return func(o *RedisOptions) {
+		o.DecodeErrorHook = hook
+	}
+}
+
+// WithRedisCodec 设置缓存项的编码，默认 JSONCodec；切换编码后仍能读取之前以内置编码写入的值
+func WithRedisCodec(codec Codec) func(*RedisOptions) {
+	return func(o *RedisOptions) {
+		o.Codec = codec
+	}
+}
+
 // WithRedisPool 设置连接池选项
 func WithRedisPool(maxRetries, poolSize, minIdleConns int) func(*RedisOptions) {
 	return func(o *RedisOptions) {
@@ -106,6 +125,11 @@ func NewRedisStore(client *redis.Client, opts ...func(*RedisOptions)) *RedisStor
 		defaultExpiry: options.DefaultExpiry,
 		healthStatus:  ConnStatusUnknown,
 		stopChan:      make(chan struct{}),
+		onDecodeError: options.DecodeErrorHook,
+		codec:         options.Codec,
+	}
+	if store.codec == nil {
+		store.codec = JSONCodec{}
 	}
 
 	// 初始化标签管理器
@@ -189,13 +213,24 @@ func (r *RedisStore) Get(ctx context.Context, key string) (interface{}, error) {
 	}
 
 	var item Item
-	if err := json.Unmarshal([]byte(val), &item); err != nil {
+	if err := r.unmarshalItem([]byte(val), &item); err != nil {
 		return nil, err
 	}
+	if item.stale(time.Now()) {
+		return item.Value, ErrStale
+	}
 
 	return item.Value, nil
 }
 
+// keyExpiration 返回 Redis 键的过期时间，有陈旧期时键保留到陈旧期结束
+func keyExpiration(item Item, expiration time.Duration) time.Duration {
+	if item.StaleUntil.IsZero() {
+		return expiration
+	}
+	return item.StaleUntil.Sub(item.CreatedAt)
+}
+
 // Set 将一个项目放入缓存
 func (r *RedisStore) Set(ctx context.Context, key string, value interface{}, options ...Option) error {
 	opts := applyOptions(options...)
@@ -204,38 +239,15 @@ func (r *RedisStore) Set(ctx context.Context, key string, value interface{}, opt
 		expiration = opts.Expiration
 	}
 
-	item := Item{
-		Key:        key,
-		Value:      value,
-		Tags:       opts.Tags,
-		Expiration: expiration,
-		CreatedAt:  time.Now(),
-	}
+	item := newItem(key, value, opts.Tags, expiration, time.Now()).withStale(opts.StaleTTL)
 
 	// 序列化缓存项
-	jsonData, err := json.Marshal(item)
+	data, err := r.marshalItem(item)
 	if err != nil {
 		return err
 	}
 
-	prefixedKey := r.prefixKey(key)
-
-	// 使用管道执行多个命令
-	pipe := r.client.Pipeline()
-
-	// 设置主键值
-	pipe.Set(ctx, prefixedKey, jsonData, expiration)
-
-	// 处理标签
-	if len(opts.Tags) > 0 {
-		// 使用标签管理器关联标签和键
-		if err := r.tagManager.AddTagsToKey(ctx, key, opts.Tags); err != nil {
-			return err
-		}
-	}
-
-	_, err = pipe.Exec(ctx)
-	return err
+	return r.writeItems(ctx, []redisWrite{{key: key, data: data, expiration: keyExpiration(item, expiration), tags: opts.Tags}})
 }
 
 // Delete 从缓存中删除一个项目
@@ -252,7 +264,7 @@ func (r *RedisStore) Delete(ctx context.Context, key string) error {
 	}
 
 	var item Item
-	if err := json.Unmarshal([]byte(val), &item); err != nil {
+	if err := r.unmarshalItem([]byte(val), &item); err != nil {
 		// 如果解析失败，仍删除主键
 		return r.client.Del(ctx, prefixedKey).Err()
 	}
@@ -293,7 +305,8 @@ func (r *RedisStore) Clear(ctx context.Context) error {
 	return r.client.Del(ctx, keys...).Err()
 }
 
-// GetMultiple 批量获取多个缓存项
+// GetMultiple 批量获取多个缓存项，使用单条 MGET 命令读取
+// 个别键的值无法解码时跳过该键，返回其余结果以及描述失败键的 *MultiError
 func (r *RedisStore) GetMultiple(ctx context.Context, keys []string) (map[string]interface{}, error) {
 	if len(keys) == 0 {
 		return make(map[string]interface{}), nil
@@ -304,40 +317,64 @@ func (r *RedisStore) GetMultiple(ctx context.Context, keys []string) (map[string
 		prefixedKeys[i] = r.prefixKey(key)
 	}
 
-	pipeline := r.client.Pipeline()
-	cmds := make([]*redis.StringCmd, len(prefixedKeys))
-
-	for i, key := range prefixedKeys {
-		cmds[i] = pipeline.Get(ctx, key)
-	}
-
-	_, err := pipeline.Exec(ctx)
-	if err != nil && err != redis.Nil {
+	values, err := r.client.MGet(ctx, prefixedKeys...).Result()
+	if err != nil {
 		return nil, err
 	}
 
 	result := make(map[string]interface{}, len(keys))
-	for i, cmd := range cmds {
-		val, err := cmd.Result()
-		if err == redis.Nil {
+	var multiErr *MultiError
+	for i, val := range values {
+		// 不存在的键返回 nil
+		if val == nil {
 			continue
 		}
+
+		item, err := r.decodeItem(val)
 		if err != nil {
-			return nil, err
+			if r.onDecodeError != nil {
+				r.onDecodeError(keys[i], err)
+			}
+			multiErr = multiErr.add(keys[i], err)
+			continue
 		}
 
-		var item Item
-		if err := json.Unmarshal([]byte(val), &item); err != nil {
-			return nil, err
+		// 陈旧期内的键视为不存在
+		if item.stale(time.Now()) {
+			continue
 		}
 
 		// 去掉前缀，返回原始键
 		result[keys[i]] = item.Value
 	}
 
+	if multiErr != nil {
+		return result, multiErr
+	}
 	return result, nil
 }
 
+// decodeItem 解码 MGET 返回的单个值
+func (r *RedisStore) decodeItem(val interface{}) (Item, error) {
+	var item Item
+	raw, ok := val.(string)
+	if !ok {
+		return item, fmt.Errorf("%w: %T", ErrInvalidValue, val)
+	}
+	err := r.unmarshalItem([]byte(raw), &item)
+	return item, err
+}
+
+// marshalItem 使用配置的编码序列化缓存项
+func (r *RedisStore) marshalItem(item Item) ([]byte, error) {
+	return r.codec.Marshal(item)
+}
+
+// unmarshalItem 按数据的格式前缀解码缓存项
+func (r *RedisStore) unmarshalItem(data []byte, item *Item) error {
+	return decodeWith(r.codec, data, item)
+}
+
 // SetMultiple 批量设置多个缓存项
 func (r *RedisStore) SetMultiple(ctx context.Context, items map[string]interface{}, options ...Option) error {
 	if len(items) == 0 {
@@ -352,43 +389,110 @@ func (r *RedisStore) SetMultiple(ctx context.Context, items map[string]interface
 
 	now := time.Now()
 
-	pipeline := r.client.Pipeline()
-	var allKeys []string
-
+	writes := make([]redisWrite, 0, len(items))
 	for key, value := range items {
-		item := Item{
-			Key:        key,
-			Value:      value,
-			Tags:       opts.Tags,
-			Expiration: expiration,
-			CreatedAt:  now,
-		}
+		item := newItem(key, value, opts.Tags, expiration, now).withStale(opts.StaleTTL)
 
 		// 序列化缓存项
-		jsonData, err := json.Marshal(item)
+		data, err := r.marshalItem(item)
 		if err != nil {
 			return err
 		}
+		writes = append(writes, redisWrite{key: key, data: data, expiration: keyExpiration(item, expiration), tags: opts.Tags})
+	}
+
+	return r.writeItems(ctx, writes)
+}
 
-		prefixedKey := r.prefixKey(key)
-		allKeys = append(allKeys, key)
+// SetMany 批量写入缓存项，每项使用自己的过期时间与标签，在一次往返中提交
+func (r *RedisStore) SetMany(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
 
-		// 设置主键值
-		pipeline.Set(ctx, prefixedKey, jsonData, expiration)
+	now := time.Now()
+	writes := make([]redisWrite, 0, len(entries))
+	for _, entry := range entries {
+		expiration := r.defaultExpiry
+		if entry.TTL > 0 {
+			expiration = entry.TTL
+		}
+		data, err := r.marshalItem(newItem(entry.Key, entry.Value, entry.Tags, expiration, now))
+		if err != nil {
+			return err
+		}
+		writes = append(writes, redisWrite{key: entry.Key, data: data, expiration: expiration, tags: entry.Tags})
 	}
 
-	// 处理标签
-	if len(opts.Tags) > 0 {
-		for _, key := range allKeys {
+	return r.writeItems(ctx, writes)
+}
+
+// redisWrite 待写入的单个键
+type redisWrite struct {
+	key        string
+	data       []byte
+	expiration time.Duration
+	tags       []string
+}
+
+// writeItems 在一个事务管道中写入缓存值与标签关联
+//
+// 使用 Redis 标签管理器时，SET 与 SADD 通过 MULTI/EXEC 一次往返提交；
+// 某个键的 SET 失败时撤销本次新增的标签成员，避免标签指向未写入的键。
+// 自定义标签管理器在所有值写入成功后再关联标签
+func (r *RedisStore) writeItems(ctx context.Context, writes []redisWrite) error {
+	redisTags, sameClient := r.tagManager.(*RedisTagManager)
+
+	type pending struct {
+		write redisWrite
+		set   *redis.StatusCmd
+		tags  []*redis.IntCmd
+	}
+	cmds := make([]*pending, 0, len(writes))
+
+	pipe := r.client.TxPipeline()
+	for _, w := range writes {
+		p := &pending{write: w, set: pipe.Set(ctx, r.prefixKey(w.key), w.data, w.expiration)}
+		if sameClient && len(w.tags) > 0 {
+			p.tags = redisTags.queueTags(ctx, pipe, w.key, w.tags)
+		}
+		cmds = append(cmds, p)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		if sameClient {
+			// 撤销SET失败的键在本次新增的标签成员
+			undo := r.client.Pipeline()
+			for _, p := range cmds {
+				if p.set.Err() == nil {
+					continue
+				}
+				for i, cmd := range p.tags {
+					if cmd.Err() == nil && cmd.Val() > 0 {
+						undo.SRem(ctx, redisTags.tagKey(p.write.tags[i]), redisTags.prefixKey(p.write.key))
+					}
+				}
+			}
+			if _, undoErr := undo.Exec(ctx); undoErr != nil && undoErr != redis.Nil {
+				return fmt.Errorf("%w (撤销标签失败: %v)", err, undoErr)
+			}
+		}
+		return err
+	}
+
+	if !sameClient {
+		for _, w := range writes {
+			if len(w.tags) == 0 {
+				continue
+			}
 			// 使用标签管理器关联标签和键
-			if err := r.tagManager.AddTagsToKey(ctx, key, opts.Tags); err != nil {
+			if err := r.tagManager.AddTagsToKey(ctx, w.key, w.tags); err != nil {
 				return err
 			}
 		}
 	}
-
-	_, err := pipeline.Exec(ctx)
-	return err
+	return nil
 }
 
 // DeleteMultiple 批量删除多个缓存项
@@ -414,175 +518,328 @@ func (r *RedisStore) DeleteMultiple(ctx context.Context, keys []string) error {
 	return r.client.Del(ctx, prefixedKeys...).Err()
 }
 
-// Increment 增加缓存项的整数值
+// Increment 增加缓存项的整数值，保留键的剩余过期时间；键不存在时以默认过期时间创建
+//
+// 读取、计算与写回由 counterScript 在 Redis 内一次完成，并发修改同一个键时不会丢失更新
 func (r *RedisStore) Increment(ctx context.Context, key string, value int64) (int64, error) {
-	prefixedKey := r.prefixKey(key)
-
-	// 检查键是否存在
-	exists, err := r.client.Exists(ctx, prefixedKey).Result()
+	res, err := r.updateCounter(ctx, key, value, strconv.FormatInt(value, 10), "int")
 	if err != nil {
 		return 0, err
 	}
+	return strconv.ParseInt(res, 10, 64)
+}
 
-	if exists == 0 {
-		// 键不存在，创建一个新的缓存项
-		item := Item{
-			Key:        key,
-			Value:      value,
-			Tags:       []string{},
-			Expiration: r.defaultExpiry,
-			CreatedAt:  time.Now(),
-		}
-
-		jsonData, err := json.Marshal(item)
-		if err != nil {
-			return 0, err
-		}
-
-		// 设置初始值
-		if err := r.client.Set(ctx, prefixedKey, jsonData, r.defaultExpiry).Err(); err != nil {
-			return 0, err
-		}
-
-		return value, nil
-	}
-
-	// 键存在，获取当前值
-	val, err := r.client.Get(ctx, prefixedKey).Result()
+// IncrementFloat 增加缓存项的浮点值，保留键的剩余过期时间；键不存在时以默认过期时间创建，与 Increment 同样是原子的
+func (r *RedisStore) IncrementFloat(ctx context.Context, key string, value float64) (float64, error) {
+	res, err := r.updateCounter(ctx, key, value, strconv.FormatFloat(value, 'g', -1, 64), "float")
 	if err != nil {
 		return 0, err
 	}
+	return strconv.ParseFloat(res, 64)
+}
 
-	var item Item
-	if err := json.Unmarshal([]byte(val), &item); err != nil {
-		return 0, err
-	}
-
-	// 将当前值转换为int64
-	var currentVal int64 = 0
-	switch v := item.Value.(type) {
+// counterInt 将缓存值转换为int64，无法转换时为0
+func counterInt(value interface{}) int64 {
+	switch v := value.(type) {
 	case float64:
-		currentVal = int64(v)
+		return int64(v)
 	case int:
-		currentVal = int64(v)
+		return int64(v)
 	case int64:
-		currentVal = v
+		return v
 	case string:
 		// 尝试从字符串解析
 		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
-			currentVal = i
+			return i
 		}
 	}
+	return 0
+}
 
-	// 增加值
-	newVal := currentVal + value
-	item.Value = newVal
+// counterFloat 将缓存值转换为float64，无法转换时为0
+func counterFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case string:
+		// 尝试从字符串解析
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return 0
+}
 
-	// 保存回缓存
-	jsonData, err := json.Marshal(item)
+// counterNormalize 为 counterScript 无法直接改写缓存项时返回的错误前缀
+const counterNormalize = "FLOWCOUNTER"
+
+// counterScript 原子地增加缓存项的数值并返回新值
+//
+// 键不存在时写入 ARGV[3] 给出的新缓存项（ARGV[4] 为过期毫秒数）；键存在时在 JSON 编码的缓存项中
+// 就地改写 Value 字段并以 KEEPTTL 写回，ExpiresAt、StaleUntil 等其余字段保持不变。
+// 非 JSON 编码或 Value 不是数字、简单字符串与字面量时返回 counterNormalize 错误，由调用方规范化后重试
+var counterScript = redis.NewScript(`
+local raw = redis.call('GET', KEYS[1])
+if not raw then
+  if tonumber(ARGV[4]) > 0 then
+    redis.call('SET', KEYS[1], ARGV[3], 'PX', ARGV[4])
+  else
+    redis.call('SET', KEYS[1], ARGV[3])
+  end
+  return ARGV[1]
+end
+local _, e = string.find(raw, ',"Value":', 1, true)
+if string.sub(raw, 1, 1) ~= '{' or not e then
+  return redis.error_reply('` + counterNormalize + ` normalize')
+end
+local rest = string.sub(raw, e + 1)
+local token = string.match(rest, '^%-?[%d%.eE%+%-]+')
+local current
+if token then
+  current = tonumber(token)
+else
+  local str = string.match(rest, '^"([^"\\]*)"')
+  if str then
+    token, current = '"' .. str .. '"', tonumber(str) or 0
+  else
+    for _, literal in ipairs({'true', 'false', 'null'}) do
+      if string.sub(rest, 1, #literal) == literal then
+        token, current = literal, 0
+      end
+    end
+  end
+end
+if not token or not current then
+  return redis.error_reply('` + counterNormalize + ` normalize')
+end
+local result
+if ARGV[2] == 'int' then
+  if current >= 0 then current = math.floor(current) else current = math.ceil(current) end
+  result = string.format('%d', current + tonumber(ARGV[1]))
+else
+  result = string.format('%.17g', current + tonumber(ARGV[1]))
+end
+redis.call('SET', KEYS[1], string.sub(raw, 1, e) .. result .. string.sub(rest, #token + 1), 'KEEPTTL')
+return result
+`)
+
+// updateCounter 以 counterScript 增加 delta 并返回新值的文本形式，mode 为 "int" 或 "float"
+//
+// 新建的计数器总以 JSON 编码写入，脚本才能就地改写；其他编解码器写入的已有缓存项先规范化为 JSON 再重试
+func (r *RedisStore) updateCounter(ctx context.Context, key string, value interface{}, delta, mode string) (string, error) {
+	initial, err := JSONCodec{}.Marshal(newItem(key, value, []string{}, r.defaultExpiry, time.Now()))
 	if err != nil {
-		return 0, err
+		return "", err
 	}
-
-	// 使用原始过期时间
-	ttl := item.Expiration
-	if ttl <= 0 {
-		ttl = r.defaultExpiry
+	keys := []string{r.prefixKey(key)}
+	for {
+		res, err := counterScript.Run(ctx, r.client, keys, delta, mode, initial, r.defaultExpiry.Milliseconds()).Text()
+		if err == nil || !strings.HasPrefix(err.Error(), counterNormalize) {
+			return res, err
+		}
+		if err := r.normalizeCounter(ctx, key, mode); err != nil && !errors.Is(err, redis.TxFailedErr) && !errors.Is(err, ErrCacheMiss) {
+			return "", err
+		}
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
 	}
+}
 
-	if err := r.client.Set(ctx, prefixedKey, jsonData, ttl).Err(); err != nil {
-		return 0, err
-	}
+// normalizeCounter 将缓存项改写为 Value 为数字的 JSON 编码，保留剩余过期时间与其余字段；
+// 键在提交前被并发修改时返回 redis.TxFailedErr，由 updateCounter 重新执行脚本
+func (r *RedisStore) normalizeCounter(ctx context.Context, key, mode string) error {
+	prefixedKey := r.prefixKey(key)
+	return r.client.Watch(ctx, func(tx *redis.Tx) error {
+		item, _, err := r.readItemFrom(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		if mode == "int" {
+			item.Value = counterInt(item.Value)
+		} else {
+			item.Value = counterFloat(item.Value)
+		}
+		data, err := JSONCodec{}.Marshal(item)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, prefixedKey, data, redis.KeepTTL)
+			return nil
+		})
+		return err
+	}, prefixedKey)
+}
 
-	return newVal, nil
+// readItem 在一次往返中读取缓存项与 PTTL，Redis 的 PTTL 为剩余时间的准确来源
+func (r *RedisStore) readItem(ctx context.Context, key string) (Item, time.Duration, error) {
+	return r.readItemFrom(ctx, r.client, key)
 }
 
-// IncrementFloat 增加缓存项的浮点值
-func (r *RedisStore) IncrementFloat(ctx context.Context, key string, value float64) (float64, error) {
+// readItemFrom 与 readItem 相同，通过指定的连接读取（如 WATCH 所在的事务连接）
+func (r *RedisStore) readItemFrom(ctx context.Context, c redis.Cmdable, key string) (Item, time.Duration, error) {
 	prefixedKey := r.prefixKey(key)
+	pipe := c.Pipeline()
+	get := pipe.Get(ctx, prefixedKey)
+	pttl := pipe.PTTL(ctx, prefixedKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return Item{}, 0, err
+	}
+	if get.Err() == redis.Nil {
+		return Item{}, 0, ErrCacheMiss
+	}
+
+	var item Item
+	if err := r.unmarshalItem([]byte(get.Val()), &item); err != nil {
+		return Item{}, 0, err
+	}
+	ttl := pttl.Val()
+	switch {
+	case ttl == -2:
+		// 读取之间已过期
+		return Item{}, 0, ErrCacheMiss
+	case ttl < 0:
+		ttl = NoExpiration
+	}
+	return item, ttl, nil
+}
 
-	// 检查键是否存在
-	exists, err := r.client.Exists(ctx, prefixedKey).Result()
+// TTL 使用 PTTL 返回缓存项的剩余时间
+func (r *RedisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, r.prefixKey(key)).Result()
 	if err != nil {
 		return 0, err
 	}
+	switch {
+	case ttl == -2:
+		return 0, ErrCacheMiss
+	case ttl < 0:
+		return NoExpiration, nil
+	}
+	return ttl, nil
+}
 
-	if exists == 0 {
-		// 键不存在，创建一个新的缓存项
-		item := Item{
-			Key:        key,
-			Value:      value,
-			Tags:       []string{},
-			Expiration: r.defaultExpiry,
-			CreatedAt:  time.Now(),
+// GetWithTTL 获取缓存项及其剩余时间
+func (r *RedisStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	item, ttl, err := r.readItem(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !item.StaleUntil.IsZero() {
+		// PTTL 包含陈旧期，剩余时间以信封中的过期时间为准
+		now := time.Now()
+		if item.stale(now) {
+			return item.Value, 0, ErrStale
 		}
+		ttl = item.ExpiresAt.Sub(now)
+	}
+	return item.Value, ttl, nil
+}
 
-		jsonData, err := json.Marshal(item)
+// Touch 使用 PEXPIRE 重新设置过期时间，不读取也不改写值；ttl 小于等于0时使用 PERSIST 移除过期时间
+//
+// 信封中的绝对过期时间不随之更新，剩余时间以 TTL 与 GetWithTTL 的结果为准
+func (r *RedisStore) Touch(ctx context.Context, key string, ttl time.Duration) error {
+	prefixedKey := r.prefixKey(key)
+	if ttl > 0 {
+		ok, err := r.client.PExpire(ctx, prefixedKey, ttl).Result()
 		if err != nil {
-			return 0, err
+			return err
 		}
-
-		// 设置初始值
-		if err := r.client.Set(ctx, prefixedKey, jsonData, r.defaultExpiry).Err(); err != nil {
-			return 0, err
+		if !ok {
+			return ErrCacheMiss
 		}
-
-		return value, nil
+		return nil
 	}
 
-	// 键存在，获取当前值
-	val, err := r.client.Get(ctx, prefixedKey).Result()
-	if err != nil {
-		return 0, err
+	// PERSIST 对不存在的键与没有过期时间的键都返回0，需要再确认键是否存在
+	if ok, err := r.client.Persist(ctx, prefixedKey).Result(); err != nil || ok {
+		return err
 	}
-
-	var item Item
-	if err := json.Unmarshal([]byte(val), &item); err != nil {
-		return 0, err
+	if !r.Has(ctx, key) {
+		return ErrCacheMiss
 	}
+	return nil
+}
 
-	// 将当前值转换为float64
-	var currentVal float64 = 0
-	switch v := item.Value.(type) {
-	case float64:
-		currentVal = v
-	case int:
-		currentVal = float64(v)
-	case int64:
-		currentVal = float64(v)
-	case string:
-		// 尝试从字符串解析
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			currentVal = f
+// Decrement 减少缓存项的整数值
+func (r *RedisStore) Decrement(ctx context.Context, key string, value int64) (int64, error) {
+	return r.Increment(ctx, key, -value)
+}
+
+// IncrBatch 在一个事务中为每个计数器执行 INCRBY，ttl 大于0时同时执行 EXPIRE NX，只在计数器首次创建时设置过期时间
+//
+// 计数器保存为纯整数，键为 前缀 + CounterKeyPrefix + 键；EXPIRE NX 需要 Redis 7.0 及以上
+func (r *RedisStore) IncrBatch(ctx context.Context, deltas map[string]int64, ttl time.Duration) (map[string]int64, error) {
+	if len(deltas) == 0 {
+		return make(map[string]int64), nil
+	}
+
+	cmds := make(map[string]*redis.IntCmd, len(deltas))
+	pipe := r.client.TxPipeline()
+	for key, delta := range deltas {
+		counterKey := r.counterKey(key)
+		cmds[key] = pipe.IncrBy(ctx, counterKey, delta)
+		if ttl > 0 {
+			pipe.ExpireNX(ctx, counterKey, ttl)
 		}
 	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
 
-	// 增加值
-	newVal := currentVal + value
-	item.Value = newVal
-
-	// 保存回缓存
-	jsonData, err := json.Marshal(item)
-	if err != nil {
-		return 0, err
+	result := make(map[string]int64, len(cmds))
+	for key, cmd := range cmds {
+		result[key] = cmd.Val()
 	}
+	return result, nil
+}
 
-	// 使用原始过期时间
-	ttl := item.Expiration
-	if ttl <= 0 {
-		ttl = r.defaultExpiry
+// GetCounters 使用单条 MGET 读取多个计数器，不存在的键为0
+// 个别键的值不是整数时跳过该键，返回其余结果以及描述失败键的 *MultiError
+func (r *RedisStore) GetCounters(ctx context.Context, keys []string) (map[string]int64, error) {
+	result := make(map[string]int64, len(keys))
+	if len(keys) == 0 {
+		return result, nil
 	}
 
-	if err := r.client.Set(ctx, prefixedKey, jsonData, ttl).Err(); err != nil {
-		return 0, err
+	counterKeys := make([]string, len(keys))
+	for i, key := range keys {
+		counterKeys[i] = r.counterKey(key)
+	}
+	values, err := r.client.MGet(ctx, counterKeys...).Result()
+	if err != nil {
+		return nil, err
 	}
 
-	return newVal, nil
+	var multiErr *MultiError
+	for i, key := range keys {
+		raw, ok := values[i].(string)
+		if !ok {
+			result[key] = 0
+			continue
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			multiErr = multiErr.add(key, fmt.Errorf("计数器值不是整数: %w", err))
+			continue
+		}
+		result[key] = n
+	}
+	if multiErr != nil {
+		return result, multiErr
+	}
+	return result, nil
 }
 
-// Decrement 减少缓存项的整数值
-func (r *RedisStore) Decrement(ctx context.Context, key string, value int64) (int64, error) {
-	return r.Increment(ctx, key, -value)
+// counterKey 返回计数器在 Redis 中的键
+func (r *RedisStore) counterKey(key string) string {
+	return r.prefix + CounterKeyPrefix + key
 }
 
 // DecrementFloat 减少缓存项的浮点值
@@ -590,7 +847,7 @@ func (r *RedisStore) DecrementFloat(ctx context.Context, key string, value float
 	return r.IncrementFloat(ctx, key, -value)
 }
 
-// TaggedGet 获取带标签的缓存项
+// TaggedGet 获取带标签的缓存项，部分值损坏时同 GetMultiple 返回其余结果和 *MultiError
 func (r *RedisStore) TaggedGet(ctx context.Context, tag string) (map[string]interface{}, error) {
 	// 使用标签管理器获取标签关联的所有键
 	keys, err := r.tagManager.GetKeysByTag(ctx, tag)
@@ -605,6 +862,27 @@ func (r *RedisStore) TaggedGet(ctx context.Context, tag string) (map[string]inte
 	return r.GetMultiple(ctx, keys)
 }
 
+// TaggedGetMultiple 获取带有多个标签的缓存项，AnyOf 取并集，AllOf 取交集
+// 使用 Redis 标签管理器时在服务端通过 SUNION/SINTER 计算键集合
+func (r *RedisStore) TaggedGetMultiple(ctx context.Context, tags []string, mode TagMatch) (map[string]interface{}, error) {
+	var keys []string
+	var err error
+	if m, ok := r.tagManager.(*RedisTagManager); ok {
+		keys, err = m.GetKeysByTags(ctx, tags, mode)
+	} else {
+		keys, err = keysByTags(ctx, r.tagManager, tags, mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return make(map[string]interface{}), nil
+	}
+
+	return r.GetMultiple(ctx, keys)
+}
+
 // TaggedDelete 删除带标签的所有缓存项
 func (r *RedisStore) TaggedDelete(ctx context.Context, tag string) error {
 	// 使用标签管理器获取标签关联的所有键
@@ -705,21 +983,26 @@ func (m *RedisTagManager) AddTagsToKey(ctx context.Context, key string, tags []s
 		return nil
 	}
 
-	prefixedKey := m.prefixKey(key)
-	keyTagsKey := m.keyTagsKey(key)
 	pipe := m.client.Pipeline()
+	m.queueTags(ctx, pipe, key, tags)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// queueTags 将标签关联命令加入管道，返回与 tags 一一对应的 SADD 命令
+func (m *RedisTagManager) queueTags(ctx context.Context, pipe redis.Pipeliner, key string, tags []string) []*redis.IntCmd {
+	prefixedKey := m.prefixKey(key)
+	cmds := make([]*redis.IntCmd, len(tags))
 
 	// 将键添加到每个标签的集合中
-	for _, tag := range tags {
-		tagKey := m.tagKey(tag)
-		pipe.SAdd(ctx, tagKey, prefixedKey)
+	for i, tag := range tags {
+		cmds[i] = pipe.SAdd(ctx, m.tagKey(tag), prefixedKey)
 	}
 
 	// 存储键关联的所有标签
-	pipe.SAdd(ctx, keyTagsKey, tags)
-
-	_, err := pipe.Exec(ctx)
-	return err
+	pipe.SAdd(ctx, m.keyTagsKey(key), tags)
+	return cmds
 }
 
 // RemoveTagsFromKey 从缓存键中移除标签
@@ -767,6 +1050,35 @@ func (m *RedisTagManager) GetKeysByTag(ctx context.Context, tag string) ([]strin
 	return keys, nil
 }
 
+// GetKeysByTags 根据多个标签获取键，AnyOf 使用 SUNION，AllOf 使用 SINTER
+func (m *RedisTagManager) GetKeysByTags(ctx context.Context, tags []string, mode TagMatch) ([]string, error) {
+	if len(tags) == 0 {
+		return []string{}, nil
+	}
+
+	tagKeys := make([]string, len(tags))
+	for i, tag := range tags {
+		tagKeys[i] = m.tagKey(tag)
+	}
+
+	var prefixedKeys []string
+	var err error
+	if mode == AllOf {
+		prefixedKeys, err = m.client.SInter(ctx, tagKeys...).Result()
+	} else {
+		prefixedKeys, err = m.client.SUnion(ctx, tagKeys...).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(prefixedKeys))
+	for i, prefixedKey := range prefixedKeys {
+		keys[i] = strings.TrimPrefix(prefixedKey, m.prefix)
+	}
+	return keys, nil
+}
+
 // RemoveTag 移除标签及其所有关联
 func (m *RedisTagManager) RemoveTag(ctx context.Context, tag string) error {
 	tagKey := m.tagKey(tag)
@@ -840,6 +1152,13 @@ func (d *RedisDriver) New(config map[string]interface{}) (Store, error) {
 	var client *redis.Client
 	ctx := context.Background()
 
+	// 编码：json（默认）、gob、msgpack
+	codecName, _ := config["codec"].(string)
+	codec, err := CodecByName(codecName)
+	if err != nil {
+		return nil, err
+	}
+
 	// 检查客户端是否已通过DI传入
 	if c, ok := config["client"].(*redis.Client); ok {
 		client = c
@@ -943,6 +1262,7 @@ func (d *RedisDriver) New(config map[string]interface{}) (Store, error) {
 		WithRedisExpiry(expiry),
 		WithRedisTagManager(tagManager),
 		WithRedisHealthCheck(healthCheck, healthCheckInterval),
+		WithRedisCodec(codec),
 	)
 
 	return store, nil
@@ -952,3 +1272,267 @@ func (d *RedisDriver) New(config map[string]interface{}) (Store, error) {
 func init() {
 	RegisterDriver("redis", &RedisDriver{})
 }
+
+// TryLock 使用SETNX获取锁，实现 Locker 接口
+func (r *RedisStore) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, r.prefixKey("lock:"+key), 1, ttl).Result()
+}
+
+// Unlock 释放锁
+func (r *RedisStore) Unlock(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefixKey("lock:"+key)).Err()
+}
+
+// redisPipelineRetries 未调用 Watch 时，因读取的键被并发修改而重新提交的次数
+const redisPipelineRetries = 3
+
+// redisPipe RedisStore 的操作队列，Watch 在事务连接上立即执行
+type redisPipe struct {
+	pipeQueue
+	ctx   context.Context
+	store *RedisStore
+	tx    *redis.Tx
+}
+
+// Watch 在事务连接上执行 WATCH
+func (p *redisPipe) Watch(keys ...string) error {
+	p.watched = true
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = p.store.prefixKey(key)
+	}
+	return p.tx.Watch(p.ctx, prefixed...).Err()
+}
+
+// Pipeline 使用 WATCH 与 MULTI/EXEC 提交队列中的全部操作，实现 PipelineStore 接口
+func (r *RedisStore) Pipeline(ctx context.Context, fn func(p Pipe) error) error {
+	var p *redisPipe
+	attempt := func(tx *redis.Tx) error {
+		if p == nil {
+			p = &redisPipe{ctx: ctx, store: r, tx: tx}
+			if err := fn(p); err != nil {
+				return err
+			}
+			if p.err != nil {
+				return p.err
+			}
+		}
+		return r.commitPipe(ctx, tx, p)
+	}
+
+	for i := 0; ; i++ {
+		err := r.client.Watch(ctx, attempt)
+		if errors.Is(err, redis.TxFailedErr) {
+			// 调用方 Watch 的键只在第一次提交前监视，冲突时交给调用方重试
+			if !p.watched && i < redisPipelineRetries {
+				continue
+			}
+			return ErrCasConflict
+		}
+		return err
+	}
+}
+
+// redisKeyState 提交前读取并随队列中的操作更新的键状态
+type redisKeyState struct {
+	item   Item
+	exists bool
+	ttl    time.Duration
+	tags   []string // 键关联的标签，使用 RedisTagManager 时有效
+}
+
+// commitPipe 监视并读取需要的键，在客户端计算并序列化全部操作后一次提交
+func (r *RedisStore) commitPipe(ctx context.Context, tx *redis.Tx, p *redisPipe) error {
+	redisTags, sameClient := r.tagManager.(*RedisTagManager)
+	states, err := r.readPipeKeys(ctx, tx, p.readKeys(), redisTags, sameClient)
+	if err != nil {
+		return err
+	}
+	state := func(key string) *redisKeyState {
+		if s, ok := states[key]; ok {
+			return s
+		}
+		s := &redisKeyState{}
+		states[key] = s
+		return s
+	}
+
+	now := time.Now()
+	var writes []func(pipe redis.Pipeliner)
+	var customTags []redisWrite
+	var customUntag []string
+	results := make(map[*IntResult]int64)
+	for _, op := range p.ops {
+		op := op
+		prefixedKey := r.prefixKey(op.key)
+		s := state(op.key)
+		switch op.kind {
+		case pipeSet:
+			expiration := r.defaultExpiry
+			if op.opts.Expiration > 0 {
+				expiration = op.opts.Expiration
+			}
+			item := newItem(op.key, op.value, op.opts.Tags, expiration, now)
+			data, err := r.marshalItem(item)
+			if err != nil {
+				return fmt.Errorf("序列化缓存项 %s 失败: %w", op.key, err)
+			}
+			writes = append(writes, func(pipe redis.Pipeliner) {
+				pipe.Set(ctx, prefixedKey, data, expiration)
+				if sameClient && len(op.opts.Tags) > 0 {
+					redisTags.queueTags(ctx, pipe, op.key, op.opts.Tags)
+				}
+			})
+			if !sameClient && len(op.opts.Tags) > 0 {
+				customTags = append(customTags, redisWrite{key: op.key, tags: op.opts.Tags})
+			}
+			s.item, s.exists, s.ttl = item, true, expiration
+			s.tags = append(s.tags, op.opts.Tags...)
+
+		case pipeDelete:
+			tags := s.tags
+			writes = append(writes, func(pipe redis.Pipeliner) {
+				pipe.Del(ctx, prefixedKey)
+				if sameClient {
+					for _, tag := range tags {
+						pipe.SRem(ctx, redisTags.tagKey(tag), redisTags.prefixKey(op.key))
+					}
+					pipe.Del(ctx, redisTags.keyTagsKey(op.key))
+				}
+			})
+			if !sameClient {
+				customUntag = append(customUntag, op.key)
+			}
+			*s = redisKeyState{}
+
+		case pipeIncrement:
+			var current int64
+			keepTTL := s.exists
+			if s.exists {
+				value, err := counterValue(op.key, s.item.Value)
+				if err != nil {
+					return err
+				}
+				current = value
+			} else {
+				s.item, s.exists, s.ttl = newItem(op.key, nil, []string{}, r.defaultExpiry, now), true, r.defaultExpiry
+				if s.ttl <= 0 {
+					s.ttl = NoExpiration
+				}
+			}
+			s.item.Value = current + op.delta
+			s.item.ExpiresAt = time.Time{}
+			if s.ttl > 0 {
+				s.item.ExpiresAt = now.Add(s.ttl)
+			}
+			data, err := r.marshalItem(s.item)
+			if err != nil {
+				return err
+			}
+			expiration := r.defaultExpiry
+			if keepTTL {
+				expiration = redis.KeepTTL
+			}
+			writes = append(writes, func(pipe redis.Pipeliner) {
+				pipe.Set(ctx, prefixedKey, data, expiration)
+			})
+			results[op.result] = current + op.delta
+
+		case pipeTouch:
+			if !s.exists {
+				return fmt.Errorf("%w: %s", ErrCacheMiss, op.key)
+			}
+			writes = append(writes, func(pipe redis.Pipeliner) {
+				if op.ttl > 0 {
+					pipe.PExpire(ctx, prefixedKey, op.ttl)
+				} else {
+					pipe.Persist(ctx, prefixedKey)
+				}
+			})
+			s.ttl = op.ttl
+			if op.ttl <= 0 {
+				s.ttl = NoExpiration
+			}
+		}
+	}
+
+	if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, write := range writes {
+			write(pipe)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// 自定义标签管理器不在同一事务中
+	for _, key := range customUntag {
+		if err := r.tagManager.RemoveKeyFromAllTags(ctx, key); err != nil {
+			return err
+		}
+	}
+	for _, w := range customTags {
+		if err := r.tagManager.AddTagsToKey(ctx, w.key, w.tags); err != nil {
+			return err
+		}
+	}
+	for result, value := range results {
+		result.val = value
+	}
+	return nil
+}
+
+// readPipeKeys 监视并在一次往返中读取键的缓存项、剩余时间与关联标签
+func (r *RedisStore) readPipeKeys(ctx context.Context, tx *redis.Tx, keys []string, redisTags *RedisTagManager, sameClient bool) (map[string]*redisKeyState, error) {
+	states := make(map[string]*redisKeyState, len(keys))
+	if len(keys) == 0 {
+		return states, nil
+	}
+
+	watch := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		watch = append(watch, r.prefixKey(key))
+		if sameClient {
+			watch = append(watch, redisTags.keyTagsKey(key))
+		}
+	}
+	if err := tx.Watch(ctx, watch...).Err(); err != nil {
+		return nil, err
+	}
+
+	type readCmds struct {
+		get  *redis.StringCmd
+		pttl *redis.DurationCmd
+		tags *redis.StringSliceCmd
+	}
+	cmds := make(map[string]readCmds, len(keys))
+	pipe := tx.Pipeline()
+	for _, key := range keys {
+		c := readCmds{get: pipe.Get(ctx, r.prefixKey(key)), pttl: pipe.PTTL(ctx, r.prefixKey(key))}
+		if sameClient {
+			c.tags = pipe.SMembers(ctx, redisTags.keyTagsKey(key))
+		}
+		cmds[key] = c
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for key, c := range cmds {
+		s := &redisKeyState{}
+		if c.get.Err() == nil && c.pttl.Val() != -2 {
+			if err := r.unmarshalItem([]byte(c.get.Val()), &s.item); err != nil {
+				return nil, fmt.Errorf("解析缓存项 %s 失败: %w", key, err)
+			}
+			s.exists, s.ttl = true, c.pttl.Val()
+			if s.ttl < 0 {
+				s.ttl = NoExpiration
+			}
+		}
+		if c.tags != nil {
+			s.tags = c.tags.Val()
+		}
+		states[key] = s
+	}
+	return states, nil
+}
@@ -0,0 +1,723 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis 支持测试所需命令子集的RESP2服务器
+type fakeRedis struct {
+	mu      sync.Mutex
+	strings map[string]string
+	sets    map[string]map[string]bool
+	failSet map[string]bool // SET 这些键时返回错误
+	ttls    map[string]time.Duration
+	version map[string]int // 键的修改次数，用于 WATCH
+	subs    map[string][]*fakeConn
+}
+
+// fakeConn 客户端连接，PUBLISH 与连接自身的回复可能并发写入
+type fakeConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (c *fakeConn) write(reply string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := io.WriteString(c.conn, reply)
+	return err
+}
+
+func newFakeRedis(t testing.TB) (*fakeRedis, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{
+		strings: make(map[string]string),
+		sets:    make(map[string]map[string]bool),
+		failSet: make(map[string]bool),
+		ttls:    make(map[string]time.Duration),
+		version: make(map[string]int),
+		subs:    make(map[string][]*fakeConn),
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	fc := &fakeConn{conn: conn}
+	defer conn.Close()
+	defer f.unsubscribe(fc)
+	r := bufio.NewReader(conn)
+	subscribed := false
+	var queued [][]string
+	inMulti := false
+	watched := make(map[string]int)
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+
+		var reply string
+		switch {
+		case name == "SUBSCRIBE":
+			subscribed = true
+			f.mu.Lock()
+			for i, channel := range args[1:] {
+				f.subs[channel] = append(f.subs[channel], fc)
+				reply += "*3\r\n" + bulk("subscribe") + bulk(channel) + fmt.Sprintf(":%d\r\n", i+1)
+			}
+			f.mu.Unlock()
+		case name == "PING" && subscribed:
+			reply = "*2\r\n" + bulk("pong") + bulk("")
+		case name == "PUBLISH":
+			f.mu.Lock()
+			subs := append([]*fakeConn(nil), f.subs[args[1]]...)
+			f.mu.Unlock()
+			for _, sub := range subs {
+				_ = sub.write("*3\r\n" + bulk("message") + bulk(args[1]) + bulk(args[2]))
+			}
+			reply = fmt.Sprintf(":%d\r\n", len(subs))
+		case name == "MULTI":
+			inMulti, queued, reply = true, nil, "+OK\r\n"
+		case name == "WATCH":
+			f.mu.Lock()
+			for _, key := range args[1:] {
+				if _, ok := watched[key]; !ok {
+					watched[key] = f.version[key]
+				}
+			}
+			f.mu.Unlock()
+			reply = "+OK\r\n"
+		case name == "UNWATCH":
+			watched, reply = make(map[string]int), "+OK\r\n"
+		case name == "EXEC":
+			f.mu.Lock()
+			aborted := false
+			for key, version := range watched {
+				if f.version[key] != version {
+					aborted = true
+				}
+			}
+			if aborted {
+				reply = "*-1\r\n"
+			} else {
+				reply = fmt.Sprintf("*%d\r\n", len(queued))
+				for _, cmd := range queued {
+					reply += f.exec(cmd)
+				}
+			}
+			f.mu.Unlock()
+			inMulti, queued, watched = false, nil, make(map[string]int)
+		case inMulti:
+			queued, reply = append(queued, args), "+QUEUED\r\n"
+		default:
+			f.mu.Lock()
+			reply = f.exec(args)
+			f.mu.Unlock()
+		}
+		if err := fc.write(reply); err != nil {
+			return
+		}
+	}
+}
+
+// unsubscribe 连接关闭时移除订阅
+func (f *fakeRedis) unsubscribe(fc *fakeConn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for channel, subs := range f.subs {
+		for i, sub := range subs {
+			if sub == fc {
+				f.subs[channel] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// exec 执行单个命令，调用方持有锁
+func (f *fakeRedis) exec(args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "SET", "DEL", "INCRBY", "EXPIRE", "PEXPIRE", "PERSIST", "SADD", "SREM":
+		for _, key := range args[1:2] {
+			f.version[key]++
+		}
+	case "EVAL":
+		f.version[args[3]]++
+	}
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "EVALSHA":
+		return "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+	case "EVAL":
+		return f.evalCounter(args[3], args[4:])
+	case "SET":
+		if f.failSet[args[1]] {
+			return "-ERR injected failure\r\n"
+		}
+		f.strings[args[1]] = args[2]
+		if len(args) == 4 && strings.ToUpper(args[3]) == "KEEPTTL" {
+			return "+OK\r\n"
+		}
+		delete(f.ttls, args[1])
+		if len(args) == 5 {
+			n, _ := strconv.ParseInt(args[4], 10, 64)
+			switch strings.ToUpper(args[3]) {
+			case "EX":
+				f.ttls[args[1]] = time.Duration(n) * time.Second
+			case "PX":
+				f.ttls[args[1]] = time.Duration(n) * time.Millisecond
+			}
+		}
+		return "+OK\r\n"
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := f.strings[key]; ok {
+				n++
+			}
+			if _, ok := f.sets[key]; ok {
+				n++
+			}
+			delete(f.strings, key)
+			delete(f.ttls, key)
+			delete(f.sets, key)
+			f.version[key]++
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "GET":
+		v, ok := f.strings[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "INCRBY":
+		current, err := strconv.ParseInt(f.strings[args[1]], 10, 64)
+		if _, ok := f.strings[args[1]]; ok && err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		delta, _ := strconv.ParseInt(args[2], 10, 64)
+		f.strings[args[1]] = strconv.FormatInt(current+delta, 10)
+		return fmt.Sprintf(":%d\r\n", current+delta)
+	case "EXPIRE":
+		if _, ok := f.strings[args[1]]; !ok {
+			return ":0\r\n"
+		}
+		if _, ok := f.ttls[args[1]]; ok && len(args) == 4 && strings.ToUpper(args[3]) == "NX" {
+			return ":0\r\n"
+		}
+		n, _ := strconv.ParseInt(args[2], 10, 64)
+		f.ttls[args[1]] = time.Duration(n) * time.Second
+		return ":1\r\n"
+	case "PEXPIRE":
+		if _, ok := f.strings[args[1]]; !ok {
+			return ":0\r\n"
+		}
+		n, _ := strconv.ParseInt(args[2], 10, 64)
+		f.ttls[args[1]] = time.Duration(n) * time.Millisecond
+		return ":1\r\n"
+	case "PERSIST":
+		if _, ok := f.ttls[args[1]]; !ok {
+			return ":0\r\n"
+		}
+		delete(f.ttls, args[1])
+		return ":1\r\n"
+	case "PTTL":
+		if _, ok := f.strings[args[1]]; !ok {
+			return ":-2\r\n"
+		}
+		ttl, ok := f.ttls[args[1]]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", ttl.Milliseconds())
+	case "EXISTS":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := f.strings[key]; ok {
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "MGET":
+		var reply strings.Builder
+		fmt.Fprintf(&reply, "*%d\r\n", len(args)-1)
+		for _, key := range args[1:] {
+			if v, ok := f.strings[key]; ok {
+				reply.WriteString(bulk(v))
+			} else {
+				reply.WriteString("$-1\r\n")
+			}
+		}
+		return reply.String()
+	case "SADD":
+		set := f.sets[args[1]]
+		if set == nil {
+			set = make(map[string]bool)
+			f.sets[args[1]] = set
+		}
+		added := 0
+		for _, m := range args[2:] {
+			if !set[m] {
+				set[m] = true
+				added++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", added)
+	case "SREM":
+		removed := 0
+		for _, m := range args[2:] {
+			if f.sets[args[1]][m] {
+				delete(f.sets[args[1]], m)
+				removed++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", removed)
+	case "SCAN":
+		// 一次返回全部匹配的键，只支持以 * 结尾的前缀模式
+		var match string
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.ToUpper(args[i]) == "MATCH" {
+				match = args[i+1]
+			}
+		}
+		prefix := strings.NewReplacer(`\\`, `\`, `\*`, "*", `\?`, "?", `\[`, "[", `\]`, "]", `\^`, "^", `\-`, "-").
+			Replace(strings.TrimSuffix(match, "*"))
+		var keys []string
+		for key := range f.strings {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		for key := range f.sets {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		return "*2\r\n" + bulk("0") + array(keys)
+	case "KEYS":
+		var keys []string
+		for key := range f.strings {
+			if strings.HasPrefix(key, strings.TrimSuffix(args[1], "*")) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		return array(keys)
+	case "SMEMBERS":
+		return array(f.members(args[1]))
+	case "SUNION", "SINTER":
+		counts := make(map[string]int)
+		for _, key := range args[1:] {
+			for _, m := range f.members(key) {
+				counts[m]++
+			}
+		}
+		var result []string
+		for m, n := range counts {
+			if strings.ToUpper(args[0]) == "SUNION" || n == len(args)-1 {
+				result = append(result, m)
+			}
+		}
+		return array(result)
+	default:
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
+
+func (f *fakeRedis) members(key string) []string {
+	var result []string
+	for m := range f.sets[key] {
+		result = append(result, m)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// setMembers 测试中读取集合成员
+func (f *fakeRedis) setMembers(key string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.members(key)
+}
+
+// counterToken 计数器脚本识别的 Value 取值：数字、无转义的字符串与字面量
+var counterToken = regexp.MustCompile(`^(-?[0-9.eE+-]+|"[^"\\]*"|true|false|null)`)
+
+// evalCounter 按 counterScript 的语义执行 EVAL，调用方持有锁
+func (f *fakeRedis) evalCounter(key string, argv []string) string {
+	raw, ok := f.strings[key]
+	if !ok {
+		f.strings[key] = argv[2]
+		delete(f.ttls, key)
+		if ms, _ := strconv.ParseInt(argv[3], 10, 64); ms > 0 {
+			f.ttls[key] = time.Duration(ms) * time.Millisecond
+		}
+		return bulk(argv[0])
+	}
+	i := strings.Index(raw, `,"Value":`)
+	if !strings.HasPrefix(raw, "{") || i < 0 {
+		return "-FLOWCOUNTER normalize\r\n"
+	}
+	i += len(`,"Value":`)
+	token := counterToken.FindString(raw[i:])
+	if token == "" {
+		return "-FLOWCOUNTER normalize\r\n"
+	}
+	current, err := strconv.ParseFloat(strings.Trim(token, `"`), 64)
+	if err != nil {
+		if !strings.HasPrefix(token, `"`) && token != "true" && token != "false" && token != "null" {
+			return "-FLOWCOUNTER normalize\r\n"
+		}
+		current = 0
+	}
+	delta, _ := strconv.ParseFloat(argv[0], 64)
+	var result string
+	if argv[1] == "int" {
+		result = strconv.FormatInt(int64(current)+int64(delta), 10)
+	} else {
+		result = strconv.FormatFloat(current+delta, 'g', 17, 64)
+	}
+	f.strings[key] = raw[:i] + result + raw[i+len(token):]
+	return bulk(result)
+}
+
+// ttl 测试中读取键的过期时间
+func (f *fakeRedis) ttl(key string) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ttls[key]
+}
+
+// setTTL 测试中直接修改键的过期时间，用于模拟时间流逝
+func (f *fakeRedis) setTTL(key string, ttl time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ttls[key] = ttl
+}
+
+// setRaw 测试中直接写入原始值，用于模拟损坏的缓存数据
+func (f *fakeRedis) setRaw(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.strings[key] = value
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		// 按长度读取，值可能是包含换行的二进制数据
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:size])
+	}
+	return args, nil
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func array(items []string) string {
+	reply := fmt.Sprintf("*%d\r\n", len(items))
+	for _, item := range items {
+		reply += bulk(item)
+	}
+	return reply
+}
+
+// roundTripHook 统计客户端与服务器之间的往返次数
+type roundTripHook struct {
+	count int64
+}
+
+func (h *roundTripHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *roundTripHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		atomic.AddInt64(&h.count, 1)
+		return next(ctx, cmd)
+	}
+}
+
+func (h *roundTripHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		atomic.AddInt64(&h.count, 1)
+		return next(ctx, cmds)
+	}
+}
+
+func newTestRedisStore(t testing.TB) (*RedisStore, *fakeRedis, *roundTripHook) {
+	server, addr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { client.Close() })
+
+	hook := &roundTripHook{}
+	client.AddHook(hook)
+	return NewRedisStore(client, WithRedisHealthCheck(false, 0)), server, hook
+}
+
+func TestRedisStore_SetWithTagsSingleRoundTrip(t *testing.T) {
+	store, server, hook := newTestRedisStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "user:1", "alice", WithTags("users", "team:a", "active")))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&hook.count))
+
+	for _, tag := range []string{"users", "team:a", "active"} {
+		assert.Equal(t, []string{"flow:user:1"}, server.setMembers("flow:tag:"+tag))
+	}
+
+	atomic.StoreInt64(&hook.count, 0)
+	require.NoError(t, store.SetMultiple(ctx, map[string]interface{}{"user:2": "bob", "user:3": "carol"}, WithTags("users")))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&hook.count))
+	assert.Equal(t, []string{"flow:user:1", "flow:user:2", "flow:user:3"}, server.setMembers("flow:tag:users"))
+}
+
+func TestRedisStore_FailedSetLeavesNoDanglingTags(t *testing.T) {
+	store, server, _ := newTestRedisStore(t)
+	ctx := context.Background()
+
+	// 旧值已关联 users 标签，失败的写入不能移除已有关联
+	require.NoError(t, store.Set(ctx, "user:1", "alice", WithTags("users")))
+	server.failSet["flow:user:1"] = true
+	server.failSet["flow:user:2"] = true
+
+	err := store.Set(ctx, "user:1", "alice2", WithTags("users", "admins"))
+	require.Error(t, err)
+	assert.Equal(t, []string{"flow:user:1"}, server.setMembers("flow:tag:users"))
+	assert.Empty(t, server.setMembers("flow:tag:admins"))
+
+	// 批量写入时只撤销失败的键
+	err = store.SetMultiple(ctx, map[string]interface{}{"user:2": "bob", "user:3": "carol"}, WithTags("team"))
+	require.Error(t, err)
+	assert.Equal(t, []string{"flow:user:3"}, server.setMembers("flow:tag:team"))
+}
+
+func TestRedisStore_TaggedGetMultiple(t *testing.T) {
+	store, _, hook := newTestRedisStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "a", "1", WithTags("red", "round")))
+	require.NoError(t, store.Set(ctx, "b", "2", WithTags("red")))
+	require.NoError(t, store.Set(ctx, "c", "3", WithTags("round")))
+
+	atomic.StoreInt64(&hook.count, 0)
+	items, err := store.TaggedGetMultiple(ctx, []string{"red", "round"}, AnyOf)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2", "c": "3"}, items)
+	// 一次集合运算加一次批量读取
+	assert.Equal(t, int64(2), atomic.LoadInt64(&hook.count))
+
+	items, err = store.TaggedGetMultiple(ctx, []string{"red", "round"}, AllOf)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1"}, items)
+
+	items, err = store.TaggedGetMultiple(ctx, []string{"red", "missing"}, AllOf)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestRedisStore_GetMultiplePartialResults(t *testing.T) {
+	server, addr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { client.Close() })
+	hook := &roundTripHook{}
+	client.AddHook(hook)
+
+	var decodeFailures []string
+	store := NewRedisStore(client, WithRedisHealthCheck(false, 0), WithRedisDecodeErrorHook(func(key string, err error) {
+		decodeFailures = append(decodeFailures, key)
+	}))
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "a", "1", WithTags("group")))
+	require.NoError(t, store.Set(ctx, "c", "3", WithTags("group")))
+	require.NoError(t, store.Set(ctx, "b", "2", WithTags("group")))
+	// 损坏的值不能导致整批读取失败
+	server.setRaw("flow:b", "{not json")
+
+	atomic.StoreInt64(&hook.count, 0)
+	items, err := store.GetMultiple(ctx, []string{"a", "b", "c", "missing"})
+	assert.Equal(t, map[string]interface{}{"a": "1", "c": "3"}, items)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&hook.count))
+
+	var multiErr *MultiError
+	require.ErrorAs(t, err, &multiErr)
+	assert.Equal(t, []string{"b"}, multiErr.Keys())
+	assert.Contains(t, err.Error(), "b:")
+	assert.Equal(t, []string{"b"}, decodeFailures)
+
+	items, err = store.TaggedGet(ctx, "group")
+	require.ErrorAs(t, err, &multiErr)
+	assert.Equal(t, map[string]interface{}{"a": "1", "c": "3"}, items)
+
+	// 前缀管理器转换回原始键
+	require.NoError(t, store.Set(ctx, "app:a", "1"))
+	server.setRaw("flow:app:b", "{not json")
+	manager := NewManager()
+	manager.AddStore("redis", store)
+	manager.SetDefault("redis")
+	items, err = manager.WithPrefix("app").GetMultiple(ctx, []string{"a", "b"})
+	require.ErrorAs(t, err, &multiErr)
+	assert.Equal(t, map[string]interface{}{"a": "1"}, items)
+	assert.Equal(t, []string{"b"}, multiErr.Keys())
+}
+
+func TestRedisStore_IncrementConcurrent(t *testing.T) {
+	store, _, _ := newTestRedisStore(t)
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "ratio", 0.0, WithTags("stats")))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.Increment(ctx, "hits", 1)
+			assert.NoError(t, err)
+			_, err = store.Decrement(ctx, "stock", 2)
+			assert.NoError(t, err)
+			_, err = store.IncrementFloat(ctx, "ratio", 0.5)
+			assert.NoError(t, err)
+			_, err = store.DecrementFloat(ctx, "balance", 0.25)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// 并发更新不丢失
+	for key, want := range map[string]interface{}{"hits": 100.0, "stock": -200.0, "ratio": 50.0, "balance": -25.0} {
+		v, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, want, v, key)
+	}
+	// 改写保留标签关联
+	tagged, err := store.TaggedGet(ctx, "stats")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ratio": 50.0}, tagged)
+}
+
+func TestRedisStore_IncrementNormalizesOtherCodecs(t *testing.T) {
+	server, addr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { client.Close() })
+	store := NewRedisStore(client, WithRedisHealthCheck(false, 0), WithRedisCodec(GobCodec{}))
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "hits", int64(1), WithExpiration(time.Minute)))
+	v, err := store.Increment(ctx, "hits", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), v)
+	assert.Equal(t, time.Minute, server.ttl("flow:hits"), "保留剩余过期时间")
+
+	got, err := store.Get(ctx, "hits")
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, got)
+
+	// 新建的计数器同样能以配置的编码读取
+	f, err := store.IncrementFloat(ctx, "ratio", 0.5)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, f)
+	got, err = store.Get(ctx, "ratio")
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, got)
+}
+
+func BenchmarkRedisStore_GetMultiple(b *testing.B) {
+	store, _, _ := newTestRedisStore(b)
+	ctx := context.Background()
+
+	keys := make([]string, 100)
+	items := make(map[string]interface{}, len(keys))
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+		items[keys[i]] = i
+	}
+	require.NoError(b, store.SetMultiple(ctx, items))
+
+	b.Run("MGET", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := store.GetMultiple(ctx, keys); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// 原先的实现：管道中逐个 GET
+	b.Run("PipelinedGET", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pipe := store.client.Pipeline()
+			for _, key := range keys {
+				pipe.Get(ctx, store.prefixKey(key))
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRedisStore_SetWithTags(b *testing.B) {
+	store, _, hook := newTestRedisStore(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := store.Set(ctx, "key", i, WithTags("a", "b", "c")); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&hook.count))/float64(b.N), "roundtrips/op")
+}
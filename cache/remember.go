@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rememberCall 一次正在执行的加载，同一个键的并发调用等待同一个结果
+type rememberCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// rememberGroup 按键合并并发的加载
+type rememberGroup struct {
+	mu    sync.Mutex
+	calls map[string]*rememberCall
+}
+
+// Remember 获取缓存，未命中时调用fn并以ttl与opts（如 WithTags）写入默认存储后返回结果
+//
+// 同一个键在进程内的并发未命中只执行一次fn，其余调用等待并共享结果，避免热点键失效时击穿数据库；
+// 等待中的调用在自身上下文结束时返回。fn返回错误时不写入缓存，错误返回给本次合并的全部调用。
+// 写入缓存失败不影响返回值。命中时返回的是存储中的值，Redis等序列化存储返回JSON解码后的结果。
+//
+// 通过 WithStaleTTL 设置陈旧期时，过期后的陈旧期内直接返回旧值，并在后台执行一次fn刷新缓存；
+// 后台刷新与 RememberStale 共用去重与 SetRefreshLocker 设置的分布式锁，
+// 失败计入 StaleStats 并调用 OnRefreshError 设置的回调，超时时间见 SetRefreshTimeout
+func (m *Manager) Remember(ctx context.Context, key string, ttl time.Duration, fn func() (interface{}, error), opts ...Option) (interface{}, error) {
+	value, err := m.Get(ctx, key)
+	if err == nil {
+		return value, nil
+	}
+	if IsStale(err) {
+		m.stale.staleHits.Add(1)
+		m.refreshInBackground(key, func(ctx context.Context) error {
+			value, err := fn()
+			if err != nil {
+				return err
+			}
+			return m.Set(ctx, key, value, append(append([]Option(nil), opts...), WithExpiration(ttl))...)
+		})
+		return value, nil
+	}
+	if !errors.Is(err, ErrCacheMiss) {
+		return nil, err
+	}
+
+	m.remember.mu.Lock()
+	if m.remember.calls == nil {
+		m.remember.calls = make(map[string]*rememberCall)
+	}
+	if call, ok := m.remember.calls[key]; ok {
+		m.remember.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.value, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &rememberCall{done: make(chan struct{})}
+	m.remember.calls[key] = call
+	m.remember.mu.Unlock()
+
+	m.loadAndRemember(ctx, key, ttl, fn, opts, call)
+	return call.value, call.err
+}
+
+// RememberForever 与 Remember 相同，但写入时不设置过期时间；存储配置了默认过期时间时（如Redis）使用默认值
+func (m *Manager) RememberForever(ctx context.Context, key string, fn func() (interface{}, error), opts ...Option) (interface{}, error) {
+	return m.Remember(ctx, key, 0, fn, opts...)
+}
+
+// loadAndRemember 执行加载并写入缓存，结束后唤醒等待的调用；fn panic 时等待的调用收到错误，panic 继续向上传递
+func (m *Manager) loadAndRemember(ctx context.Context, key string, ttl time.Duration, fn func() (interface{}, error), opts []Option, call *rememberCall) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.err = fmt.Errorf("加载缓存 %s 时发生panic: %v", key, r)
+			m.finishRemember(key, call)
+			panic(r)
+		}
+		m.finishRemember(key, call)
+	}()
+
+	// 首次检查之后、成为执行者之前，上一次加载可能已经写入缓存；陈旧值不算命中
+	if store, err := m.DefaultStore(); err == nil {
+		if value, err := store.Get(ctx, key); err == nil {
+			call.value = value
+			return
+		}
+	}
+
+	call.value, call.err = fn()
+	if call.err != nil {
+		return
+	}
+	// 过期时间放在最后，优先于 opts 中的 WithExpiration
+	_ = m.Set(ctx, key, call.value, append(append([]Option(nil), opts...), WithExpiration(ttl))...)
+}
+
+// finishRemember 移除正在执行的加载并唤醒等待的调用
+func (m *Manager) finishRemember(key string, call *rememberCall) {
+	m.remember.mu.Lock()
+	delete(m.remember.calls, key)
+	m.remember.mu.Unlock()
+	close(call.done)
+}
+
+// GetOrSetMultiple 批量获取缓存，对未命中的键调用一次fn加载，并以ttl与opts写入默认存储后返回全部结果
+//
+// fn 接收未命中的键，返回的结果中缺少的键视为不存在，不写入缓存也不出现在返回值中。
+// 无法解码的键按未命中处理；fn返回错误时不写入缓存，写入缓存失败不影响返回值
+func (m *Manager) GetOrSetMultiple(ctx context.Context, keys []string, ttl time.Duration, fn func(missing []string) (map[string]interface{}, error), opts ...Option) (map[string]interface{}, error) {
+	result, err := m.GetMultiple(ctx, keys)
+	var multiErr *MultiError
+	if err != nil && !errors.As(err, &multiErr) {
+		return nil, err
+	}
+	if result == nil {
+		result = make(map[string]interface{}, len(keys))
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if _, ok := result[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	loaded, err := fn(missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(loaded) == 0 {
+		return result, nil
+	}
+	_ = m.SetMultiple(ctx, loaded, append(append([]Option(nil), opts...), WithExpiration(ttl))...)
+	for key, value := range loaded {
+		result[key] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRememberTestManagers 返回分别使用内存与Redis存储的管理器
+func newRememberTestManagers(t *testing.T) map[string]*Manager {
+	redisStore, _, _ := newTestRedisStore(t)
+	managers := make(map[string]*Manager)
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "redis": redisStore} {
+		manager := NewManager()
+		manager.AddStore(name, store)
+		manager.SetDefault(name)
+		managers[name] = manager
+	}
+	return managers
+}
+
+func TestRemember_Concurrent(t *testing.T) {
+	for name, manager := range newRememberTestManagers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			var calls atomic.Int32
+			release := make(chan struct{})
+			fn := func() (interface{}, error) {
+				calls.Add(1)
+				<-release
+				return "alice", nil
+			}
+
+			var wg sync.WaitGroup
+			results := make([]interface{}, 50)
+			for i := range results {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					v, err := manager.Remember(ctx, "user:1", time.Minute, fn)
+					assert.NoError(t, err)
+					results[i] = v
+				}(i)
+			}
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			// 并发未命中只执行一次加载
+			assert.Equal(t, int32(1), calls.Load())
+			for _, v := range results {
+				assert.Equal(t, "alice", v)
+			}
+
+			v, err := manager.Remember(ctx, "user:1", time.Minute, fn)
+			require.NoError(t, err)
+			assert.Equal(t, "alice", v)
+			assert.Equal(t, int32(1), calls.Load())
+			ttl, err := manager.TTL(ctx, "user:1")
+			require.NoError(t, err)
+			assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+		})
+	}
+}
+
+func TestRemember_ErrorNotCached(t *testing.T) {
+	for name, manager := range newRememberTestManagers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			errDB := errors.New("db down")
+
+			_, err := manager.Remember(ctx, "user:2", time.Minute, func() (interface{}, error) {
+				return nil, errDB
+			})
+			assert.ErrorIs(t, err, errDB)
+			assert.False(t, manager.Has(ctx, "user:2"))
+
+			v, err := manager.Remember(ctx, "user:2", time.Minute, func() (interface{}, error) {
+				return "bob", nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "bob", v)
+		})
+	}
+}
+
+func TestRemember_Tags(t *testing.T) {
+	for name, manager := range newRememberTestManagers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			_, err := manager.Remember(ctx, "post:1", time.Minute, func() (interface{}, error) {
+				return "hello", nil
+			}, WithTags("posts"), WithExpiration(time.Hour))
+			require.NoError(t, err)
+			_, err = manager.RememberForever(ctx, "post:2", func() (interface{}, error) {
+				return "world", nil
+			}, WithTags("posts"))
+			require.NoError(t, err)
+
+			tagged, err := manager.TaggedGet(ctx, "posts")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"post:1": "hello", "post:2": "world"}, tagged)
+
+			// ttl 参数优先于 WithExpiration
+			ttl, err := manager.TTL(ctx, "post:1")
+			require.NoError(t, err)
+			assert.LessOrEqual(t, ttl, time.Minute)
+
+			require.NoError(t, manager.TaggedDelete(ctx, "posts"))
+			assert.False(t, manager.Has(ctx, "post:1"))
+		})
+	}
+}
+
+func TestRemember_WaiterContext(t *testing.T) {
+	manager := NewManager()
+	manager.AddStore("memory", NewMemoryStore())
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go func() {
+		_, _ = manager.Remember(context.Background(), "slow", time.Minute, func() (interface{}, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+
+	// 等待中的调用在自身上下文结束时返回
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := manager.Remember(ctx, "slow", time.Minute, func() (interface{}, error) {
+		t.Fatal("不应再次加载")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	close(release)
+}
+
+func TestRemember_Panic(t *testing.T) {
+	manager := NewManager()
+	manager.AddStore("memory", NewMemoryStore())
+	ctx := context.Background()
+
+	assert.Panics(t, func() {
+		_, _ = manager.Remember(ctx, "boom", time.Minute, func() (interface{}, error) {
+			panic("boom")
+		})
+	})
+
+	// panic 后键不再处于加载中
+	v, err := manager.Remember(ctx, "boom", time.Minute, func() (interface{}, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", v)
+}
+
+func TestGetOrSetMultiple(t *testing.T) {
+	for name, manager := range newRememberTestManagers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, manager.Set(ctx, "user:1", "alice"))
+
+			var missing []string
+			load := func(keys []string) (map[string]interface{}, error) {
+				missing = keys
+				// user:4 不存在
+				return map[string]interface{}{"user:2": "bob", "user:3": "carol"}, nil
+			}
+			keys := []string{"user:1", "user:2", "user:3", "user:4"}
+			items, err := manager.GetOrSetMultiple(ctx, keys, time.Minute, load, WithTags("users"))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"user:2", "user:3", "user:4"}, missing)
+			assert.Equal(t, map[string]interface{}{"user:1": "alice", "user:2": "bob", "user:3": "carol"}, items)
+
+			tagged, err := manager.TaggedGet(ctx, "users")
+			require.NoError(t, err)
+			assert.Len(t, tagged, 2)
+			ttl, err := manager.TTL(ctx, "user:2")
+			require.NoError(t, err)
+			assert.LessOrEqual(t, ttl, time.Minute)
+
+			// 全部命中时不调用加载
+			_, err = manager.GetOrSetMultiple(ctx, keys[:3], time.Minute, func([]string) (map[string]interface{}, error) {
+				t.Fatal("不应加载")
+				return nil, nil
+			})
+			require.NoError(t, err)
+
+			errDB := errors.New("db down")
+			_, err = manager.GetOrSetMultiple(ctx, keys, time.Minute, func([]string) (map[string]interface{}, error) {
+				return nil, errDB
+			})
+			assert.ErrorIs(t, err, errDB)
+		})
+	}
+}
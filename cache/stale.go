@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoaderFunc 缓存未命中时加载数据的函数
+type LoaderFunc func(ctx context.Context) (interface{}, error)
+
+// Locker 分布式锁接口，用于在集群范围内对后台刷新去重
+type Locker interface {
+	// TryLock 尝试获取锁，已被占用时返回false
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Unlock 释放锁
+	Unlock(ctx context.Context, key string) error
+}
+
+// StaleStats 过期可用模式的命中统计
+type StaleStats struct {
+	FreshHits int64 // 新鲜期内命中
+	StaleHits int64 // 陈旧期内命中（返回旧值并触发后台刷新）
+	Misses    int64 // 未命中或超过陈旧期
+	Refreshes int64 // 已启动的后台刷新次数
+	Failures  int64 // 后台刷新失败次数
+}
+
+// staleState 管理器中过期可用模式的状态
+type staleState struct {
+	mu             sync.Mutex
+	refreshing     map[string]bool
+	onRefreshError func(key string, err error)
+	refreshTimeout time.Duration
+	locker         Locker
+
+	freshHits atomic.Int64
+	staleHits atomic.Int64
+	misses    atomic.Int64
+	refreshes atomic.Int64
+	failures  atomic.Int64
+}
+
+// OnRefreshError 设置后台刷新失败时的回调，失败时继续提供旧值直到陈旧期结束；
+// RememberStale 同步加载后写入缓存失败时同样调用该回调
+func (m *Manager) OnRefreshError(hook func(key string, err error)) {
+	m.stale.mu.Lock()
+	defer m.stale.mu.Unlock()
+	m.stale.onRefreshError = hook
+}
+
+// SetRefreshTimeout 设置后台刷新的超时时间，默认30秒
+func (m *Manager) SetRefreshTimeout(timeout time.Duration) {
+	m.stale.mu.Lock()
+	defer m.stale.mu.Unlock()
+	m.stale.refreshTimeout = timeout
+}
+
+// SetRefreshLocker 设置分布式锁，使同一个键在整个集群中只有一个实例执行后台刷新
+func (m *Manager) SetRefreshLocker(locker Locker) {
+	m.stale.mu.Lock()
+	defer m.stale.mu.Unlock()
+	m.stale.locker = locker
+}
+
+// StaleStats 返回过期可用模式的命中统计
+func (m *Manager) StaleStats() StaleStats {
+	return StaleStats{
+		FreshHits: m.stale.freshHits.Load(),
+		StaleHits: m.stale.staleHits.Load(),
+		Misses:    m.stale.misses.Load(),
+		Refreshes: m.stale.refreshes.Load(),
+		Failures:  m.stale.failures.Load(),
+	}
+}
+
+// RememberStale 以过期可用（stale-while-revalidate）方式获取缓存
+//   - 新鲜期（ttl）内直接返回缓存值
+//   - 陈旧期（staleFor）内立即返回旧值，并在后台刷新一次（同一键在进程内只刷新一次，
+//     设置了 SetRefreshLocker 时在集群内只刷新一次）
+//   - 超过陈旧期或未命中时同步调用loader
+//
+// 缓存值以 WithExpiration(ttl) 与 WithStaleTTL(staleFor) 写入，与 Remember 及 Get 返回的 ErrStale 使用同一种格式，
+// 新鲜期与陈旧期由存储判断
+func (m *Manager) RememberStale(ctx context.Context, key string, ttl, staleFor time.Duration, loader LoaderFunc) (interface{}, error) {
+	store, err := m.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := store.Get(ctx, key)
+	switch {
+	case err == nil:
+		m.stale.freshHits.Add(1)
+		return cached, nil
+	case IsStale(err):
+		m.stale.staleHits.Add(1)
+		m.refreshInBackground(key, func(ctx context.Context) error {
+			value, err := loader(ctx)
+			if err != nil {
+				return err
+			}
+			return storeStale(ctx, store, key, value, ttl, staleFor)
+		})
+		return cached, nil
+	}
+
+	m.stale.misses.Add(1)
+	value, err := loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := storeStale(ctx, store, key, value, ttl, staleFor); err != nil {
+		m.reportRefreshError(key, err)
+	}
+	return value, nil
+}
+
+// refreshInBackground 启动后台刷新，RememberStale 与带陈旧期的 Remember 共用
+// 同一键在进程内同时只有一个刷新在执行，设置了 SetRefreshLocker 时在集群范围内去重；
+// refresh 的错误与panic计入失败并调用 OnRefreshError 设置的回调
+func (m *Manager) refreshInBackground(key string, refresh func(ctx context.Context) error) {
+	m.stale.mu.Lock()
+	if m.stale.refreshing == nil {
+		m.stale.refreshing = make(map[string]bool)
+	}
+	if m.stale.refreshing[key] {
+		m.stale.mu.Unlock()
+		return
+	}
+	m.stale.refreshing[key] = true
+	timeout := m.stale.refreshTimeout
+	locker := m.stale.locker
+	hook := m.stale.onRefreshError
+	m.stale.mu.Unlock()
+
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	m.stale.refreshes.Add(1)
+	go func() {
+		defer func() {
+			m.stale.mu.Lock()
+			delete(m.stale.refreshing, key)
+			m.stale.mu.Unlock()
+		}()
+
+		// 使用独立的上下文，避免请求结束导致刷新被取消
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if locker != nil {
+			lockKey := "refresh:" + key
+			acquired, err := locker.TryLock(ctx, lockKey, timeout)
+			if err != nil || !acquired {
+				return
+			}
+			defer locker.Unlock(context.Background(), lockKey)
+		}
+
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("刷新缓存 %s 时发生panic: %v", key, r)
+			}
+			if err != nil {
+				m.stale.failures.Add(1)
+				if hook != nil {
+					hook(key, err)
+				}
+			}
+		}()
+		err = refresh(ctx)
+	}()
+}
+
+// storeStale 写入带新鲜期与陈旧期的缓存值
+func storeStale(ctx context.Context, store Store, key string, value interface{}, ttl, staleFor time.Duration) error {
+	if err := store.Set(ctx, key, value, WithExpiration(ttl), WithStaleTTL(staleFor)); err != nil {
+		return fmt.Errorf("写入缓存 %s 失败: %w", key, err)
+	}
+	return nil
+}
+
+// reportRefreshError 调用 OnRefreshError 设置的回调
+func (m *Manager) reportRefreshError(key string, err error) {
+	m.stale.mu.Lock()
+	hook := m.stale.onRefreshError
+	m.stale.mu.Unlock()
+	if hook != nil {
+		hook(key, err)
+	}
+}
@@ -0,0 +1,452 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newStaleTestManager() (*Manager, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.SetClock(clock.Now)
+	manager := NewManager()
+	manager.AddStore("memory", store)
+	return manager, clock
+}
+
+func TestRememberStale_FreshStaleExpired(t *testing.T) {
+	manager, clock := newStaleTestManager()
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	refreshed := make(chan struct{}, 1)
+	loader := func(ctx context.Context) (interface{}, error) {
+		n := calls.Add(1)
+		if n > 1 {
+			defer func() { refreshed <- struct{}{} }()
+		}
+		return int(n), nil
+	}
+
+	v, err := manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	// 新鲜期内不调用loader
+	clock.Advance(30 * time.Second)
+	v, _ = manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 陈旧期内立即返回旧值，后台刷新
+	clock.Advance(time.Minute)
+	v, _ = manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+	assert.Equal(t, 1, v)
+	<-refreshed
+	require.Eventually(t, func() bool {
+		v, _ := manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+		return v == 2
+	}, time.Second, time.Millisecond)
+
+	// 超过陈旧期时同步加载
+	clock.Advance(2 * time.Hour)
+	v, _ = manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+	assert.Equal(t, 3, v)
+
+	stats := manager.StaleStats()
+	assert.Equal(t, int64(1), stats.StaleHits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.GreaterOrEqual(t, stats.FreshHits, int64(2))
+}
+
+func TestRememberStale_SingleBackgroundRefresh(t *testing.T) {
+	manager, clock := newStaleTestManager()
+	ctx := context.Background()
+
+	_, err := manager.RememberStale(ctx, "k", time.Minute, time.Hour, func(ctx context.Context) (interface{}, error) {
+		return "old", nil
+	})
+	require.NoError(t, err)
+	clock.Advance(2 * time.Minute)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	done := make(chan struct{})
+	loader := func(ctx context.Context) (interface{}, error) {
+		calls.Add(1)
+		<-release
+		defer close(done)
+		// 后台刷新使用独立的带超时上下文
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		return "new", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reqCtx, cancel := context.WithCancel(ctx)
+			v, err := manager.RememberStale(reqCtx, "k", time.Minute, time.Hour, loader)
+			cancel()
+			assert.NoError(t, err)
+			assert.Equal(t, "old", v)
+		}()
+	}
+	wg.Wait()
+	close(release)
+	<-done
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int64(100), manager.StaleStats().StaleHits)
+	assert.Equal(t, int64(1), manager.StaleStats().Refreshes)
+}
+
+func TestRememberStale_RefreshFailureKeepsStale(t *testing.T) {
+	manager, clock := newStaleTestManager()
+	ctx := context.Background()
+
+	failed := make(chan string, 1)
+	manager.OnRefreshError(func(key string, err error) {
+		failed <- key
+	})
+
+	_, _ = manager.RememberStale(ctx, "k", time.Minute, time.Hour, func(ctx context.Context) (interface{}, error) {
+		return "old", nil
+	})
+	clock.Advance(2 * time.Minute)
+
+	loader := func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("db down")
+	}
+	v, err := manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+	require.NoError(t, err)
+	assert.Equal(t, "old", v)
+	assert.Equal(t, "k", <-failed)
+
+	// 刷新失败后仍然提供旧值
+	v, err = manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", v)
+
+	// 陈旧期结束后同步加载的错误直接返回
+	clock.Advance(2 * time.Hour)
+	_, err = manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+	assert.EqualError(t, err, "db down")
+}
+
+func TestRememberStale_SharesStaleTTLFormat(t *testing.T) {
+	manager, clock := newStaleTestManager()
+	ctx := context.Background()
+
+	_, err := manager.RememberStale(ctx, "k", time.Minute, time.Hour, func(ctx context.Context) (interface{}, error) {
+		return "v", nil
+	})
+	require.NoError(t, err)
+
+	// 写入的是普通缓存值，Get 与 Remember 可以直接读取
+	v, err := manager.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+
+	clock.Advance(2 * time.Minute)
+	v, err = manager.Get(ctx, "k")
+	assert.True(t, IsStale(err))
+	assert.Equal(t, "v", v)
+}
+
+func TestRememberStale_GobCodecRedisStore(t *testing.T) {
+	_, addr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { client.Close() })
+	manager := NewManager()
+	manager.AddStore("redis", NewRedisStore(client, WithRedisHealthCheck(false, 0), WithRedisCodec(GobCodec{})))
+	manager.SetDefault("redis")
+	ctx := context.Background()
+
+	failed := make(chan error, 1)
+	manager.OnRefreshError(func(key string, err error) { failed <- err })
+
+	loader := func(ctx context.Context) (interface{}, error) { return "v", nil }
+	v, err := manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+
+	v, err = manager.RememberStale(ctx, "k", time.Minute, time.Hour, loader)
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+	assert.Equal(t, int64(1), manager.StaleStats().FreshHits)
+	assert.Empty(t, failed)
+}
+
+func TestRememberStale_ReportsStoreError(t *testing.T) {
+	server, addr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { client.Close() })
+	manager := NewManager()
+	manager.AddStore("redis", NewRedisStore(client, WithRedisHealthCheck(false, 0)))
+	manager.SetDefault("redis")
+	ctx := context.Background()
+
+	var reported error
+	manager.OnRefreshError(func(key string, err error) { reported = err })
+	server.mu.Lock()
+	server.failSet["flow:k"] = true
+	server.mu.Unlock()
+
+	// 写入失败不影响返回值，但通过回调报告
+	v, err := manager.RememberStale(ctx, "k", time.Minute, time.Hour, func(ctx context.Context) (interface{}, error) {
+		return "v", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+	require.Error(t, reported)
+	assert.Contains(t, reported.Error(), "injected failure")
+}
+
+func TestWithStaleTTL_MemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "k", "v", WithExpiration(50*time.Millisecond), WithStaleTTL(100*time.Millisecond)))
+	require.NoError(t, store.Set(ctx, "plain", "v", WithExpiration(50*time.Millisecond)))
+	v, err := store.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+
+	// 过期后进入陈旧期
+	time.Sleep(70 * time.Millisecond)
+	v, err = store.Get(ctx, "k")
+	assert.True(t, IsStale(err))
+	assert.Equal(t, "v", v)
+	_, _, err = store.GetWithTTL(ctx, "k")
+	assert.ErrorIs(t, err, ErrStale)
+	items, err := store.GetMultiple(ctx, []string{"k"})
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.False(t, store.Has(ctx, "k"))
+	_, err = store.Get(ctx, "plain")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	// GC 保留陈旧期内的缓存项
+	require.NoError(t, store.GC(ctx))
+	_, err = store.Get(ctx, "k")
+	assert.ErrorIs(t, err, ErrStale)
+
+	// 陈旧期结束后移除
+	time.Sleep(100 * time.Millisecond)
+	_, err = store.Get(ctx, "k")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	require.NoError(t, store.GC(ctx))
+	store.mutex.RLock()
+	assert.Empty(t, store.items)
+	store.mutex.RUnlock()
+}
+
+func TestWithStaleTTL_RedisStore(t *testing.T) {
+	store, server, _ := newTestRedisStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "k", "v", WithExpiration(50*time.Millisecond), WithStaleTTL(time.Minute)))
+	// Redis 中的键保留到陈旧期结束
+	server.mu.Lock()
+	assert.Equal(t, time.Minute+50*time.Millisecond, server.ttls["flow:k"])
+	server.mu.Unlock()
+
+	_, ttl, err := store.GetWithTTL(ctx, "k")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, ttl, 50*time.Millisecond)
+
+	time.Sleep(70 * time.Millisecond)
+	v, err := store.Get(ctx, "k")
+	assert.True(t, IsStale(err))
+	assert.Equal(t, "v", v)
+	_, _, err = store.GetWithTTL(ctx, "k")
+	assert.ErrorIs(t, err, ErrStale)
+	items, err := store.GetMultiple(ctx, []string{"k"})
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	// 键被 Redis 删除后未命中
+	require.NoError(t, store.Delete(ctx, "k"))
+	_, err = store.Get(ctx, "k")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestWithStaleTTL_IncrementKeepsStale(t *testing.T) {
+	redisStore, _, _ := newTestRedisStore(t)
+	stores := map[string]Store{"memory": NewMemoryStore(), "redis": redisStore}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, store.Set(ctx, "hits", 1, WithExpiration(50*time.Millisecond), WithStaleTTL(time.Minute)))
+			_, err := store.Get(ctx, "hits")
+			require.NoError(t, err, "新鲜")
+
+			time.Sleep(70 * time.Millisecond)
+			_, err = store.Get(ctx, "hits")
+			require.True(t, IsStale(err), "陈旧")
+
+			n, err := store.Increment(ctx, "hits", 2)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), n, "在旧值上累加")
+
+			v, err := store.Get(ctx, "hits")
+			assert.True(t, IsStale(err), "累加不会让陈旧的计数器重新变为新鲜")
+			assert.EqualValues(t, 3, v)
+		})
+	}
+}
+
+func TestRemember_StaleRefresh(t *testing.T) {
+	manager := NewManager()
+	manager.AddStore("memory", NewMemoryStore())
+	ctx := context.Background()
+	stale := WithStaleTTL(200 * time.Millisecond)
+
+	v, err := manager.Remember(ctx, "k", 50*time.Millisecond, func() (interface{}, error) { return "v1", nil }, stale)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", v)
+	time.Sleep(70 * time.Millisecond)
+
+	// 陈旧期内的并发调用都返回旧值，只在后台刷新一次
+	var calls atomic.Int32
+	release := make(chan struct{})
+	refresh := func() (interface{}, error) {
+		calls.Add(1)
+		<-release
+		return "v2", nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := manager.Remember(ctx, "k", 50*time.Millisecond, refresh, stale)
+			assert.NoError(t, err)
+			assert.Equal(t, "v1", v)
+		}()
+	}
+	wg.Wait()
+	close(release)
+	assert.Eventually(t, func() bool {
+		v, err := manager.Get(ctx, "k")
+		return err == nil && v == "v2"
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int64(1), manager.StaleStats().Refreshes)
+
+	// 后台刷新失败时继续提供旧值并调用回调
+	failed := make(chan string, 1)
+	manager.OnRefreshError(func(key string, err error) { failed <- key })
+	time.Sleep(70 * time.Millisecond)
+	v, err = manager.Remember(ctx, "k", 50*time.Millisecond, func() (interface{}, error) {
+		return nil, errors.New("db down")
+	}, stale)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", v)
+	select {
+	case key := <-failed:
+		assert.Equal(t, "k", key)
+	case <-time.After(time.Second):
+		t.Fatal("未调用刷新失败回调")
+	}
+	assert.Equal(t, int64(1), manager.StaleStats().Failures)
+
+	// 陈旧期结束后同步加载
+	time.Sleep(250 * time.Millisecond)
+	v, err = manager.Remember(ctx, "k", time.Minute, func() (interface{}, error) { return "v3", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "v3", v)
+}
+
+// heldLocker 锁总是被其他实例持有
+type heldLocker struct {
+	attempts atomic.Int32
+}
+
+func (l *heldLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	l.attempts.Add(1)
+	return false, nil
+}
+
+func (l *heldLocker) Unlock(ctx context.Context, key string) error { return nil }
+
+func TestRemember_StaleRefreshHonoursLocker(t *testing.T) {
+	manager := NewManager()
+	manager.AddStore("memory", NewMemoryStore())
+	locker := &heldLocker{}
+	manager.SetRefreshLocker(locker)
+	ctx := context.Background()
+	stale := WithStaleTTL(time.Minute)
+
+	_, err := manager.Remember(ctx, "k", 20*time.Millisecond, func() (interface{}, error) { return "v1", nil }, stale)
+	require.NoError(t, err)
+	time.Sleep(40 * time.Millisecond)
+
+	// 其他实例正在刷新时本实例不执行fn，继续返回旧值
+	var calls atomic.Int32
+	v, err := manager.Remember(ctx, "k", 20*time.Millisecond, func() (interface{}, error) {
+		calls.Add(1)
+		return "v2", nil
+	}, stale)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", v)
+	assert.Eventually(t, func() bool { return locker.attempts.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, calls.Load())
+}
+
+func TestRefreshInBackground_SharedDedup(t *testing.T) {
+	manager := NewManager()
+	release := make(chan struct{})
+	var calls atomic.Int32
+	refresh := func(ctx context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}
+
+	// RememberStale 与 Remember 的后台刷新共用同一个去重表
+	manager.refreshInBackground("k", refresh)
+	manager.refreshInBackground("k", refresh)
+	close(release)
+	assert.Eventually(t, func() bool {
+		manager.stale.mu.Lock()
+		defer manager.stale.mu.Unlock()
+		return len(manager.stale.refreshing) == 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int64(1), manager.StaleStats().Refreshes)
+
+	// 刷新panic时计为失败，不终止进程
+	manager.refreshInBackground("k", func(ctx context.Context) error { panic("boom") })
+	assert.Eventually(t, func() bool { return manager.StaleStats().Failures == 1 }, time.Second, time.Millisecond)
+}
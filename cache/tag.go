@@ -27,6 +27,45 @@ type TagManager interface {
 	GetKeyTags(ctx context.Context, key string) ([]string, error)
 }
 
+// TagMatch 多标签查询的匹配方式
+type TagMatch int
+
+const (
+	AnyOf TagMatch = iota // 带有任一标签
+	AllOf                 // 同时带有所有标签
+)
+
+// keysByTags 逐个标签查询后在客户端合并，用于不支持集合运算的标签管理器
+func keysByTags(ctx context.Context, manager TagManager, tags []string, mode TagMatch) ([]string, error) {
+	counts := make(map[string]int)
+	var order []string
+	for _, tag := range tags {
+		keys, err := manager.GetKeysByTag(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if counts[key] == 0 {
+				order = append(order, key)
+			}
+			counts[key]++
+		}
+	}
+
+	result := make([]string, 0, len(order))
+	for _, key := range order {
+		if mode == AnyOf || counts[key] == len(tags) {
+			result = append(result, key)
+		}
+	}
+	return result, nil
+}
+
 // StandardTagManager 标准标签管理器实现
 type StandardTagManager struct {
 	// 标签到键的映射
@@ -0,0 +1,472 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultInvalidationChannel 两级缓存失效广播的默认频道
+const DefaultInvalidationChannel = "flow:cache:invalidate"
+
+// TieredOptions 两级缓存配置
+type TieredOptions struct {
+	L1TTL              time.Duration   // L1 中缓存项的最长存活时间，默认1分钟
+	L1MaxEntries       int             // L1 最多保存的键数，超过时淘汰最久未使用的键，小于等于0时不限制；默认10000
+	TagBypass          bool            // 标签操作是否绕过 L1：带标签的写入只写 L2，TaggedGet 不回填 L1
+	Client             *redis.Client   // 发布与订阅失效消息的客户端，L2 为 RedisStore 时默认使用它的客户端
+	Channel            string          // 失效广播频道，默认 DefaultInvalidationChannel
+	BroadcastErrorHook func(err error) // 发布或接收失效消息失败时的回调
+}
+
+// WithTieredL1TTL 设置 L1 中缓存项的最长存活时间，回填与写入 L1 时取它与剩余时间中较短的一个
+func WithTieredL1TTL(ttl time.Duration) func(*TieredOptions) {
+	return func(o *TieredOptions) {
+		o.L1TTL = ttl
+	}
+}
+
+// WithTieredL1MaxEntries 设置 L1 最多保存的键数
+func WithTieredL1MaxEntries(n int) func(*TieredOptions) {
+	return func(o *TieredOptions) {
+		o.L1MaxEntries = n
+	}
+}
+
+// WithTieredTagBypass 设置标签操作是否绕过 L1
+func WithTieredTagBypass(bypass bool) func(*TieredOptions) {
+	return func(o *TieredOptions) {
+		o.TagBypass = bypass
+	}
+}
+
+// WithTieredInvalidation 设置失效广播使用的Redis客户端与频道，channel 为空时使用默认频道
+func WithTieredInvalidation(client *redis.Client, channel string) func(*TieredOptions) {
+	return func(o *TieredOptions) {
+		o.Client = client
+		if channel != "" {
+			o.Channel = channel
+		}
+	}
+}
+
+// WithTieredBroadcastErrorHook 设置发布或接收失效消息失败时的回调，失败时其他节点的 L1 最多在 L1TTL 后过期
+func WithTieredBroadcastErrorHook(hook func(err error)) func(*TieredOptions) {
+	return func(o *TieredOptions) {
+		o.BroadcastErrorHook = hook
+	}
+}
+
+// invalidation 失效广播消息
+type invalidation struct {
+	Node  string   `json:"node"`            // 发送节点，节点忽略自己发出的消息
+	Keys  []string `json:"keys,omitempty"`  // 需要从 L1 移除的键
+	Flush bool     `json:"flush,omitempty"` // 清空 L1
+}
+
+// TieredStore 两级缓存：进程内 L1（通常为 MemoryStore）在前，共享的 L2（通常为 RedisStore）在后
+//
+// 读取先查 L1，未命中时读取 L2 并以较短的过期时间回填 L1；写入与删除同时作用于两级。
+// 本节点修改或删除键后通过Redis发布订阅广播失效消息，其他节点从各自的 L1 中移除对应的键。
+// 计数器、过期时间与统计操作直接使用 L2。L1 保存本节点写入时的原值，从 L2 回填的值与直接读取 L2 的结果相同
+type TieredStore struct {
+	l1, l2 Store
+	opts   TieredOptions
+	node   string
+
+	mu  sync.Mutex
+	lru *list.List               // L1 中的键，最近使用的在前
+	idx map[string]*list.Element // 键到 lru 元素
+
+	pubsub *redis.PubSub
+	done   chan struct{}
+}
+
+// NewTieredStore 创建两级缓存，配置了Redis客户端时订阅失效频道，订阅确认后返回
+func NewTieredStore(l1, l2 Store, opts ...func(*TieredOptions)) (*TieredStore, error) {
+	options := TieredOptions{
+		L1TTL:        time.Minute,
+		L1MaxEntries: 10000,
+		Channel:      DefaultInvalidationChannel,
+	}
+	if redisStore, ok := l2.(*RedisStore); ok {
+		options.Client = redisStore.GetClient()
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.L1TTL <= 0 {
+		return nil, errors.New("L1 的存活时间必须大于0")
+	}
+
+	node := make([]byte, 8)
+	if _, err := rand.Read(node); err != nil {
+		return nil, err
+	}
+	s := &TieredStore{
+		l1:   l1,
+		l2:   l2,
+		opts: options,
+		node: hex.EncodeToString(node),
+		lru:  list.New(),
+		idx:  make(map[string]*list.Element),
+		done: make(chan struct{}),
+	}
+
+	if options.Client != nil {
+		ctx := context.Background()
+		s.pubsub = options.Client.Subscribe(ctx, options.Channel)
+		if _, err := s.pubsub.Receive(ctx); err != nil {
+			s.pubsub.Close()
+			return nil, fmt.Errorf("订阅缓存失效频道失败: %w", err)
+		}
+		go s.listen()
+	}
+	return s, nil
+}
+
+// Close 停止接收失效消息，不关闭 L1 与 L2
+func (s *TieredStore) Close() error {
+	select {
+	case <-s.done:
+		return nil
+	default:
+		close(s.done)
+	}
+	if s.pubsub != nil {
+		return s.pubsub.Close()
+	}
+	return nil
+}
+
+// L1 返回进程内缓存
+func (s *TieredStore) L1() Store {
+	return s.l1
+}
+
+// L2 返回共享缓存
+func (s *TieredStore) L2() Store {
+	return s.l2
+}
+
+// listen 接收其他节点的失效消息并移除本地 L1 中的键
+func (s *TieredStore) listen() {
+	ch := s.pubsub.Channel()
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				s.broadcastErr(fmt.Errorf("解析缓存失效消息失败: %w", err))
+				continue
+			}
+			if inv.Node == s.node {
+				continue
+			}
+			ctx := context.Background()
+			if inv.Flush {
+				s.flushL1(ctx)
+				continue
+			}
+			s.evictL1(ctx, inv.Keys...)
+		}
+	}
+}
+
+// broadcast 向其他节点发布失效消息，keys 为空且 flush 为false时不发布
+func (s *TieredStore) broadcast(ctx context.Context, keys []string, flush bool) {
+	if s.opts.Client == nil || (len(keys) == 0 && !flush) {
+		return
+	}
+	data, err := json.Marshal(invalidation{Node: s.node, Keys: keys, Flush: flush})
+	if err == nil {
+		err = s.opts.Client.Publish(ctx, s.opts.Channel, data).Err()
+	}
+	if err != nil {
+		s.broadcastErr(fmt.Errorf("发布缓存失效消息失败: %w", err))
+	}
+}
+
+// broadcastErr 调用广播失败回调
+func (s *TieredStore) broadcastErr(err error) {
+	if s.opts.BroadcastErrorHook != nil {
+		s.opts.BroadcastErrorHook(err)
+	}
+}
+
+// l1TTL 返回写入 L1 的过期时间，不超过 L1TTL
+func (s *TieredStore) l1TTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > s.opts.L1TTL {
+		return s.opts.L1TTL
+	}
+	return ttl
+}
+
+// fillL1 写入 L1 并记录使用顺序，超过上限时淘汰最久未使用的键
+func (s *TieredStore) fillL1(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if err := s.l1.Set(ctx, key, value, WithExpiration(s.l1TTL(ttl))); err != nil {
+		return
+	}
+
+	var evicted []string
+	s.mu.Lock()
+	if el, ok := s.idx[key]; ok {
+		s.lru.MoveToFront(el)
+	} else {
+		s.idx[key] = s.lru.PushFront(key)
+	}
+	for s.opts.L1MaxEntries > 0 && s.lru.Len() > s.opts.L1MaxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.idx, oldest.Value.(string))
+		evicted = append(evicted, oldest.Value.(string))
+	}
+	s.mu.Unlock()
+
+	if len(evicted) > 0 {
+		_ = s.l1.DeleteMultiple(ctx, evicted)
+	}
+}
+
+// touchL1 L1 命中时更新使用顺序
+func (s *TieredStore) touchL1(key string) {
+	s.mu.Lock()
+	if el, ok := s.idx[key]; ok {
+		s.lru.MoveToFront(el)
+	}
+	s.mu.Unlock()
+}
+
+// evictL1 从 L1 移除键
+func (s *TieredStore) evictL1(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	s.mu.Lock()
+	for _, key := range keys {
+		if el, ok := s.idx[key]; ok {
+			s.lru.Remove(el)
+			delete(s.idx, key)
+		}
+	}
+	s.mu.Unlock()
+	_ = s.l1.DeleteMultiple(ctx, keys)
+}
+
+// flushL1 清空 L1
+func (s *TieredStore) flushL1(ctx context.Context) {
+	s.mu.Lock()
+	s.lru.Init()
+	s.idx = make(map[string]*list.Element)
+	s.mu.Unlock()
+	_ = s.l1.Clear(ctx)
+}
+
+// invalidate 移除本地 L1 中的键并通知其他节点
+func (s *TieredStore) invalidate(ctx context.Context, keys ...string) {
+	s.evictL1(ctx, keys...)
+	s.broadcast(ctx, keys, false)
+}
+
+// Get 先读 L1，未命中时读 L2 并回填 L1
+func (s *TieredStore) Get(ctx context.Context, key string) (interface{}, error) {
+	if value, err := s.l1.Get(ctx, key); err == nil {
+		s.touchL1(key)
+		return value, nil
+	}
+	value, ttl, err := s.l2.GetWithTTL(ctx, key)
+	if errors.Is(err, ErrStale) {
+		// 陈旧值不回填 L1
+		return value, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.fillL1(ctx, key, value, ttl)
+	return value, nil
+}
+
+// Set 写入 L2 后写入 L1，并通知其他节点移除旧值；TagBypass 时带标签的值只写 L2
+func (s *TieredStore) Set(ctx context.Context, key string, value interface{}, options ...Option) error {
+	if err := s.l2.Set(ctx, key, value, options...); err != nil {
+		return err
+	}
+	s.broadcast(ctx, []string{key}, false)
+
+	opts := applyOptions(options...)
+	if s.opts.TagBypass && len(opts.Tags) > 0 {
+		s.evictL1(ctx, key)
+		return nil
+	}
+	s.fillL1(ctx, key, value, opts.Expiration)
+	return nil
+}
+
+// Delete 从两级删除并通知其他节点
+func (s *TieredStore) Delete(ctx context.Context, key string) error {
+	if err := s.l2.Delete(ctx, key); err != nil {
+		return err
+	}
+	s.invalidate(ctx, key)
+	return nil
+}
+
+// Has 检查 L1 或 L2 中是否存在缓存
+func (s *TieredStore) Has(ctx context.Context, key string) bool {
+	return s.l1.Has(ctx, key) || s.l2.Has(ctx, key)
+}
+
+// Clear 清空两级并通知其他节点清空 L1
+func (s *TieredStore) Clear(ctx context.Context) error {
+	if err := s.l2.Clear(ctx); err != nil {
+		return err
+	}
+	s.flushL1(ctx)
+	s.broadcast(ctx, nil, true)
+	return nil
+}
+
+// GetMultiple 先读 L1，其余的键从 L2 批量读取并回填 L1
+func (s *TieredStore) GetMultiple(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	result, err := s.l1.GetMultiple(ctx, keys)
+	if err != nil || result == nil {
+		result = make(map[string]interface{}, len(keys))
+	}
+	var missing []string
+	for _, key := range keys {
+		if _, ok := result[key]; ok {
+			s.touchL1(key)
+			continue
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	values, err := s.l2.GetMultiple(ctx, missing)
+	for key, value := range values {
+		result[key] = value
+		s.fillL1(ctx, key, value, 0)
+	}
+	return result, err
+}
+
+// SetMultiple 写入两级并通知其他节点
+func (s *TieredStore) SetMultiple(ctx context.Context, items map[string]interface{}, options ...Option) error {
+	if err := s.l2.SetMultiple(ctx, items, options...); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	s.broadcast(ctx, keys, false)
+
+	opts := applyOptions(options...)
+	if s.opts.TagBypass && len(opts.Tags) > 0 {
+		s.evictL1(ctx, keys...)
+		return nil
+	}
+	for key, value := range items {
+		s.fillL1(ctx, key, value, opts.Expiration)
+	}
+	return nil
+}
+
+// DeleteMultiple 从两级删除并通知其他节点
+func (s *TieredStore) DeleteMultiple(ctx context.Context, keys []string) error {
+	if err := s.l2.DeleteMultiple(ctx, keys); err != nil {
+		return err
+	}
+	s.invalidate(ctx, keys...)
+	return nil
+}
+
+// Increment 在 L2 中增加计数器并移除各节点 L1 中的旧值
+func (s *TieredStore) Increment(ctx context.Context, key string, value int64) (int64, error) {
+	n, err := s.l2.Increment(ctx, key, value)
+	if err != nil {
+		return 0, err
+	}
+	s.invalidate(ctx, key)
+	return n, nil
+}
+
+// Decrement 在 L2 中减少计数器并移除各节点 L1 中的旧值
+func (s *TieredStore) Decrement(ctx context.Context, key string, value int64) (int64, error) {
+	n, err := s.l2.Decrement(ctx, key, value)
+	if err != nil {
+		return 0, err
+	}
+	s.invalidate(ctx, key)
+	return n, nil
+}
+
+// TTL 返回 L2 中的剩余时间
+func (s *TieredStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return s.l2.TTL(ctx, key)
+}
+
+// Touch 重新设置 L2 中的过期时间，L1 中的值仍按 L1TTL 过期
+func (s *TieredStore) Touch(ctx context.Context, key string, ttl time.Duration) error {
+	return s.l2.Touch(ctx, key, ttl)
+}
+
+// GetWithTTL 从 L2 读取值与剩余时间
+func (s *TieredStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	return s.l2.GetWithTTL(ctx, key)
+}
+
+// TaggedGet 从 L2 读取标签关联的缓存项，未设置 TagBypass 时回填 L1
+func (s *TieredStore) TaggedGet(ctx context.Context, tag string) (map[string]interface{}, error) {
+	items, err := s.l2.TaggedGet(ctx, tag)
+	if !s.opts.TagBypass {
+		for key, value := range items {
+			s.fillL1(ctx, key, value, 0)
+		}
+	}
+	return items, err
+}
+
+// TaggedDelete 删除 L2 中标签关联的缓存项，并从各节点的 L1 中移除这些键
+func (s *TieredStore) TaggedDelete(ctx context.Context, tag string) error {
+	items, _ := s.l2.TaggedGet(ctx, tag)
+	if err := s.l2.TaggedDelete(ctx, tag); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	s.invalidate(ctx, keys...)
+	return nil
+}
+
+// Count 返回 L2 中的缓存项数量
+func (s *TieredStore) Count(ctx context.Context) int64 {
+	return s.l2.Count(ctx)
+}
+
+// Flush 清空两级并通知其他节点清空 L1
+func (s *TieredStore) Flush(ctx context.Context) error {
+	if err := s.l2.Flush(ctx); err != nil {
+		return err
+	}
+	s.flushL1(ctx)
+	s.broadcast(ctx, nil, true)
+	return nil
+}
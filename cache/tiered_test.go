@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTieredNodes 创建共享同一个Redis的多个两级缓存节点
+func newTieredNodes(t *testing.T, n int, opts ...func(*TieredOptions)) ([]*TieredStore, *fakeRedis) {
+	server, addr := newFakeRedis(t)
+	nodes := make([]*TieredStore, n)
+	for i := range nodes {
+		client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2, DisableIdentity: true})
+		t.Cleanup(func() { client.Close() })
+		node, err := NewTieredStore(NewMemoryStore(), NewRedisStore(client, WithRedisHealthCheck(false, 0)), opts...)
+		require.NoError(t, err)
+		t.Cleanup(func() { node.Close() })
+		nodes[i] = node
+	}
+	return nodes, server
+}
+
+func TestTieredStore_ReadThroughAndBackfill(t *testing.T) {
+	nodes, server := newTieredNodes(t, 1, WithTieredL1TTL(time.Minute))
+	store := nodes[0]
+	ctx := context.Background()
+
+	require.NoError(t, store.L2().Set(ctx, "user:1", "alice", WithExpiration(30*time.Second)))
+	v, err := store.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", v)
+
+	// 回填 L1 的过期时间不超过 L2 的剩余时间
+	ttl, err := store.L1().TTL(ctx, "user:1")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, ttl, 30*time.Second)
+
+	// L1 命中时不访问 L2
+	server.setRaw("flow:user:1", "{not json")
+	v, err = store.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", v)
+
+	// 写入的过期时间较长时 L1 使用上限
+	require.NoError(t, store.Set(ctx, "user:2", "bob", WithExpiration(time.Hour)))
+	ttl, err = store.L1().TTL(ctx, "user:2")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, ttl, time.Minute)
+	assert.True(t, store.L2().Has(ctx, "user:2"))
+
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestTieredStore_CrossNodeInvalidation(t *testing.T) {
+	nodes, _ := newTieredNodes(t, 2)
+	a, b := nodes[0], nodes[1]
+	ctx := context.Background()
+
+	require.NoError(t, a.Set(ctx, "post:1", "v1", WithTags("posts")))
+	require.NoError(t, a.Set(ctx, "post:2", "v1", WithTags("posts")))
+	require.NoError(t, a.Set(ctx, "post:3", "v1"))
+	for _, key := range []string{"post:1", "post:2", "post:3"} {
+		v, err := b.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "v1", v)
+		assert.True(t, b.L1().Has(ctx, key))
+	}
+
+	// 删除在其他节点的 L1 中生效
+	require.NoError(t, a.Delete(ctx, "post:3"))
+	assert.Eventually(t, func() bool { return !b.L1().Has(ctx, "post:3") }, time.Second, 5*time.Millisecond)
+	_, err := b.Get(ctx, "post:3")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	// 覆盖写入同样使其他节点的旧值失效
+	require.NoError(t, a.Set(ctx, "post:1", "v2", WithTags("posts")))
+	assert.Eventually(t, func() bool {
+		v, err := b.Get(ctx, "post:1")
+		return err == nil && v == "v2"
+	}, time.Second, 5*time.Millisecond)
+
+	// 标签删除移除各节点 L1 中关联的键
+	require.NoError(t, b.TaggedDelete(ctx, "posts"))
+	assert.False(t, b.L1().Has(ctx, "post:1"))
+	assert.Eventually(t, func() bool {
+		return !a.L1().Has(ctx, "post:1") && !a.L1().Has(ctx, "post:2")
+	}, time.Second, 5*time.Millisecond)
+	assert.False(t, a.Has(ctx, "post:2"))
+
+	// 清空广播到所有节点
+	require.NoError(t, a.Set(ctx, "k", "v"))
+	_, err = b.Get(ctx, "k")
+	require.NoError(t, err)
+	require.NoError(t, a.Flush(ctx))
+	assert.Eventually(t, func() bool { return !b.L1().Has(ctx, "k") }, time.Second, 5*time.Millisecond)
+}
+
+func TestTieredStore_MaxEntries(t *testing.T) {
+	nodes, _ := newTieredNodes(t, 1, WithTieredL1MaxEntries(2))
+	store := nodes[0]
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "a", 1))
+	require.NoError(t, store.Set(ctx, "b", 2))
+	// 访问 a 后 b 成为最久未使用的键
+	_, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, store.Set(ctx, "c", 3))
+
+	assert.True(t, store.L1().Has(ctx, "a"))
+	assert.False(t, store.L1().Has(ctx, "b"))
+	assert.True(t, store.L1().Has(ctx, "c"))
+	v, err := store.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), v, "从 L2 读取")
+}
+
+func TestTieredStore_TagBypass(t *testing.T) {
+	nodes, _ := newTieredNodes(t, 1, WithTieredTagBypass(true))
+	store := nodes[0]
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "tagged", "v", WithTags("t")))
+	require.NoError(t, store.Set(ctx, "plain", "v"))
+	assert.False(t, store.L1().Has(ctx, "tagged"))
+	assert.True(t, store.L1().Has(ctx, "plain"))
+
+	items, err := store.TaggedGet(ctx, "t")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"tagged": "v"}, items)
+	assert.False(t, store.L1().Has(ctx, "tagged"))
+}
+
+func TestTieredStore_Counters(t *testing.T) {
+	nodes, _ := newTieredNodes(t, 2)
+	a, b := nodes[0], nodes[1]
+	ctx := context.Background()
+
+	_, err := a.Increment(ctx, "hits", 1)
+	require.NoError(t, err)
+	v, err := b.Get(ctx, "hits")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), v)
+
+	n, err := a.Increment(ctx, "hits", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Eventually(t, func() bool {
+		v, err := b.Get(ctx, "hits")
+		return err == nil && v == float64(3)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestTieredStore_WithoutBroadcast(t *testing.T) {
+	store, err := NewTieredStore(NewMemoryStore(), NewMemoryStore())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "k", "v"))
+	items, err := store.GetMultiple(ctx, []string{"k", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"k": "v"}, items)
+	require.NoError(t, store.Clear(ctx))
+	assert.False(t, store.Has(ctx, "k"))
+	require.NoError(t, store.Close())
+
+	_, err = NewTieredStore(NewMemoryStore(), NewMemoryStore(), WithTieredL1TTL(-1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_TTLAndTouch(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "session", "alice", WithExpiration(time.Minute)))
+	require.NoError(t, store.Set(ctx, "forever", "x"))
+
+	ttl, err := store.TTL(ctx, "session")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(50*time.Millisecond))
+
+	ttl, err = store.TTL(ctx, "forever")
+	require.NoError(t, err)
+	assert.Equal(t, NoExpiration, ttl)
+
+	// Touch 延长过期时间，值不变
+	require.NoError(t, store.Touch(ctx, "session", time.Hour))
+	value, ttl, err := store.GetWithTTL(ctx, "session")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", value)
+	assert.InDelta(t, time.Hour, ttl, float64(50*time.Millisecond))
+
+	// ttl 小于等于0时移除过期时间
+	require.NoError(t, store.Touch(ctx, "session", 0))
+	ttl, _ = store.TTL(ctx, "session")
+	assert.Equal(t, NoExpiration, ttl)
+
+	// 不存在与已过期的键
+	require.NoError(t, store.Set(ctx, "gone", 1, WithExpiration(time.Millisecond)))
+	time.Sleep(5 * time.Millisecond)
+	for _, key := range []string{"missing", "gone"} {
+		_, err = store.TTL(ctx, key)
+		assert.ErrorIs(t, err, ErrCacheMiss, key)
+		assert.ErrorIs(t, store.Touch(ctx, key, time.Minute), ErrCacheMiss, key)
+		_, _, err = store.GetWithTTL(ctx, key)
+		assert.ErrorIs(t, err, ErrCacheMiss, key)
+	}
+}
+
+func TestMemoryStore_IncrementPreservesRemainingTTL(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "hits", 1, WithExpiration(300*time.Millisecond)))
+	deadline := store.items["hits"].ExpiresAt
+
+	time.Sleep(150 * time.Millisecond)
+	n, err := store.Increment(ctx, "hits", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	// 以前 Increment 按原始时长重新计算过期时间
+	assert.Equal(t, deadline, store.items["hits"].ExpiresAt)
+	ttl, err := store.TTL(ctx, "hits")
+	require.NoError(t, err)
+	assert.Less(t, ttl, 200*time.Millisecond)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, store.Has(ctx, "hits"), "按最初的过期时间过期")
+}
+
+func TestFileStore_TTLAndTouch(t *testing.T) {
+	s, err := (&FileDriver{}).New(map[string]interface{}{"directory": t.TempDir()})
+	require.NoError(t, err)
+	store := s.(*FileStore)
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "report", "v1", WithExpiration(time.Minute)))
+
+	require.NoError(t, store.Touch(ctx, "report", time.Hour))
+	value, ttl, err := store.GetWithTTL(ctx, "report")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", value)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Second))
+
+	_, err = store.TTL(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	assert.ErrorIs(t, store.Touch(ctx, "missing", time.Minute), ErrCacheMiss)
+}
+
+func TestRedisStore_TTLAndTouch(t *testing.T) {
+	store, server, hook := newTestRedisStore(t)
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "session", "alice", WithExpiration(time.Minute)))
+
+	ttl, err := store.TTL(ctx, "session")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	// Touch 只执行 PEXPIRE，不读写值
+	before := server.strings["flow:session"]
+	require.NoError(t, store.Touch(ctx, "session", time.Hour))
+	assert.Equal(t, time.Hour, server.ttl("flow:session"))
+	assert.Equal(t, before, server.strings["flow:session"])
+
+	hook.count = 0
+	value, ttl, err := store.GetWithTTL(ctx, "session")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", value)
+	assert.Equal(t, time.Hour, ttl)
+	assert.Equal(t, int64(1), hook.count, "GET 与 PTTL 在一次往返中完成")
+
+	require.NoError(t, store.Touch(ctx, "session", 0))
+	ttl, _ = store.TTL(ctx, "session")
+	assert.Equal(t, NoExpiration, ttl)
+	require.NoError(t, store.Touch(ctx, "session", 0), "没有过期时间的键再次移除不报错")
+
+	_, err = store.TTL(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	_, _, err = store.GetWithTTL(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	assert.ErrorIs(t, store.Touch(ctx, "missing", time.Minute), ErrCacheMiss)
+	assert.ErrorIs(t, store.Touch(ctx, "missing", 0), ErrCacheMiss)
+}
+
+func TestRedisStore_IncrementPreservesRemainingTTL(t *testing.T) {
+	store, server, _ := newTestRedisStore(t)
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "hits", 1, WithExpiration(time.Hour)))
+
+	// 模拟时间流逝，剩余10分钟
+	server.setTTL("flow:hits", 10*time.Minute)
+	n, err := store.Increment(ctx, "hits", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, 10*time.Minute, server.ttl("flow:hits"), "不再重置为写入时的一小时")
+
+	f, err := store.IncrementFloat(ctx, "hits", 0.5)
+	require.NoError(t, err)
+	assert.Equal(t, 3.5, f)
+	assert.Equal(t, 10*time.Minute, server.ttl("flow:hits"))
+
+	// 只改写值，信封中写入时记录的绝对过期时间保持不变
+	var item Item
+	require.NoError(t, json.Unmarshal([]byte(server.strings["flow:hits"]), &item))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), item.ExpiresAt, time.Second)
+
+	// 不存在的键以默认过期时间创建
+	n, err = store.Increment(ctx, "new", 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.Equal(t, store.GetDefaultExpiry(), server.ttl("flow:new"))
+}
+
+func TestManager_TTL(t *testing.T) {
+	manager := NewManager()
+	manager.AddStore("memory", NewMemoryStore())
+	prefixed := manager.WithPrefix("app")
+	ctx := context.Background()
+
+	require.NoError(t, prefixed.Set(ctx, "k", "v", WithExpiration(time.Minute)))
+	require.NoError(t, prefixed.Touch(ctx, "k", time.Hour))
+	value, ttl, err := prefixed.GetWithTTL(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", value)
+	assert.InDelta(t, time.Hour, ttl, float64(50*time.Millisecond))
+
+	_, err = manager.TTL(ctx, "k")
+	assert.ErrorIs(t, err, ErrCacheMiss, "未加前缀的键不存在")
+}
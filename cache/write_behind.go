@@ -0,0 +1,440 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/app"
+)
+
+// ErrWriteBehindClosed 延迟写入缓冲已关闭
+var ErrWriteBehindClosed = errors.New("延迟写入缓冲已关闭")
+
+// OverflowPolicy 缓冲已满时新写入的处理方式
+type OverflowPolicy int
+
+const (
+	// OverflowDropOldest 丢弃最早的待写入键，写入不会阻塞
+	OverflowDropOldest OverflowPolicy = iota
+	// OverflowBlock 立即刷新并阻塞写入，直到缓冲有空位或上下文取消
+	OverflowBlock
+)
+
+// WriteBehindStats 延迟写入的统计
+type WriteBehindStats struct {
+	Pending int   // 缓冲中待写入的键数（合并后）
+	Dropped int64 // 因缓冲已满被丢弃的写入
+	Flushed int64 // 已写入存储的键数
+	Flushes int64 // 已完成的刷新次数
+	Errors  int64 // 失败的刷新次数
+}
+
+// WriteBehindOption 延迟写入选项
+type WriteBehindOption func(*WriteBehind)
+
+// WithFlushInterval 设置定时刷新的间隔，默认1秒
+func WithFlushInterval(interval time.Duration) WriteBehindOption {
+	return func(w *WriteBehind) {
+		w.interval = interval
+	}
+}
+
+// WithMaxPending 设置缓冲最多保存的键数（合并后），默认10000，达到上限时立即刷新
+func WithMaxPending(n int) WriteBehindOption {
+	return func(w *WriteBehind) {
+		w.maxPending = n
+	}
+}
+
+// WithOverflowPolicy 设置缓冲已满时的处理方式，默认 OverflowDropOldest
+func WithOverflowPolicy(policy OverflowPolicy) WriteBehindOption {
+	return func(w *WriteBehind) {
+		w.policy = policy
+	}
+}
+
+// WithCounterTTL 设置计数器的过期时间，只在计数器首次创建时设置，默认不过期
+func WithCounterTTL(ttl time.Duration) WriteBehindOption {
+	return func(w *WriteBehind) {
+		w.counterTTL = ttl
+	}
+}
+
+// WithFlushErrorHandler 设置后台刷新失败时的回调，失败的写入会放回缓冲等待下次刷新
+func WithFlushErrorHandler(handler func(err error)) WriteBehindOption {
+	return func(w *WriteBehind) {
+		w.onError = handler
+	}
+}
+
+// pendingKey 缓冲中的键，普通缓存项与计数器互不可见，分开保存
+type pendingKey struct {
+	counter bool
+	key     string
+}
+
+// pendingBatch 一批待写入的内容
+type pendingBatch struct {
+	sets   map[string]Entry
+	deltas map[string]int64
+}
+
+func newPendingBatch() pendingBatch {
+	return pendingBatch{sets: make(map[string]Entry), deltas: make(map[string]int64)}
+}
+
+func (b pendingBatch) len() int {
+	return len(b.sets) + len(b.deltas)
+}
+
+// WriteBehind 延迟写入缓冲，适用于不要求同步持久化的高频写入，例如计数与分析数据
+//
+// Set 与 Increment 先写入进程内的缓冲，同一个键在一个刷新间隔内的多次写入会合并：
+// Set 保留最后一次写入，Increment 累加增量。缓冲按间隔或在达到上限时通过 SetMany 与
+// IncrBatch 批量写入存储。Get 与 GetCounter 会合并尚未写入的内容，因此同一进程内总能读到自己的写入。
+//
+// 进程异常退出时缓冲中的写入会丢失，需要可靠写入的数据不应使用
+type WriteBehind struct {
+	store      Store
+	interval   time.Duration
+	maxPending int
+	policy     OverflowPolicy
+	counterTTL time.Duration
+	onError    func(err error)
+
+	mu      sync.Mutex
+	pending pendingBatch
+	order   *list.List // 待写入键的先后顺序，用于丢弃最早的键
+	index   map[pendingKey]*list.Element
+	// inflight 正在写入存储的一批，写入期间读取仍能看到
+	inflight pendingBatch
+	// space 缓冲腾出空位时关闭并替换，阻塞的写入等待它
+	space  chan struct{}
+	closed bool
+
+	// flushMu 串行化刷新；读取计数器时持有读锁，避免增量在写入存储后又被重复计入
+	flushMu sync.RWMutex
+	kick    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+
+	dropped atomic.Int64
+	flushed atomic.Int64
+	flushes atomic.Int64
+	errors  atomic.Int64
+}
+
+// NewWriteBehind 创建延迟写入缓冲并启动后台刷新，不再使用时调用 Close
+//
+// 存储实现 BatchSetter 时使用一次 SetMany 写入，否则逐项 Set；Increment 要求存储实现 CounterStore
+func NewWriteBehind(store Store, opts ...WriteBehindOption) *WriteBehind {
+	w := &WriteBehind{
+		store:      store,
+		interval:   time.Second,
+		maxPending: 10000,
+		policy:     OverflowDropOldest,
+		pending:    newPendingBatch(),
+		inflight:   newPendingBatch(),
+		order:      list.New(),
+		index:      make(map[pendingKey]*list.Element),
+		space:      make(chan struct{}),
+		kick:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.interval <= 0 {
+		w.interval = time.Second
+	}
+	if w.maxPending <= 0 {
+		w.maxPending = 10000
+	}
+	go w.loop()
+	return w
+}
+
+// Set 缓冲一次写入，刷新时使用选项中的过期时间与标签
+func (w *WriteBehind) Set(ctx context.Context, key string, value interface{}, opts ...Option) error {
+	options := applyOptions(opts...)
+	entry := Entry{Key: key, Value: value, TTL: options.Expiration, Tags: options.Tags}
+	return w.enqueue(ctx, pendingKey{key: key}, func(b pendingBatch) {
+		b.sets[key] = entry
+	})
+}
+
+// Increment 缓冲计数器增量，刷新时与同一个键的其他增量合并后通过 IncrBatch 写入
+//
+// 与 Store.Increment 不同，操作的是 CounterKeyPrefix 下的原生计数器，使用 GetCounter 读取
+func (w *WriteBehind) Increment(ctx context.Context, key string, delta int64) error {
+	if _, ok := w.store.(CounterStore); !ok {
+		return fmt.Errorf("%w: %T", ErrCountersUnsupported, w.store)
+	}
+	return w.enqueue(ctx, pendingKey{counter: true, key: key}, func(b pendingBatch) {
+		b.deltas[key] += delta
+	})
+}
+
+// enqueue 在缓冲中加入或合并一次写入，缓冲已满时按溢出策略处理
+func (w *WriteBehind) enqueue(ctx context.Context, pk pendingKey, apply func(pendingBatch)) error {
+	for {
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			return ErrWriteBehindClosed
+		}
+		_, exists := w.index[pk]
+		if exists || w.pending.len() < w.maxPending {
+			break
+		}
+		if w.policy == OverflowDropOldest {
+			w.dropOldestLocked()
+			break
+		}
+		space := w.space
+		w.mu.Unlock()
+		w.requestFlush()
+		select {
+		case <-space:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer w.mu.Unlock()
+
+	apply(w.pending)
+	if _, exists := w.index[pk]; !exists {
+		w.index[pk] = w.order.PushBack(pk)
+	}
+	if w.pending.len() >= w.maxPending {
+		w.requestFlush()
+	}
+	return nil
+}
+
+// dropOldestLocked 丢弃最早的待写入键
+func (w *WriteBehind) dropOldestLocked() {
+	front := w.order.Front()
+	if front == nil {
+		return
+	}
+	pk := w.order.Remove(front).(pendingKey)
+	delete(w.index, pk)
+	if pk.counter {
+		delete(w.pending.deltas, pk.key)
+	} else {
+		delete(w.pending.sets, pk.key)
+	}
+	w.dropped.Add(1)
+}
+
+// Get 读取缓存项，缓冲中尚未写入的值优先
+func (w *WriteBehind) Get(ctx context.Context, key string) (interface{}, error) {
+	w.mu.Lock()
+	entry, ok := w.pending.sets[key]
+	if !ok {
+		entry, ok = w.inflight.sets[key]
+	}
+	w.mu.Unlock()
+	if ok {
+		return entry.Value, nil
+	}
+	return w.store.Get(ctx, key)
+}
+
+// GetCounter 读取计数器，包含尚未写入的增量
+func (w *WriteBehind) GetCounter(ctx context.Context, key string) (int64, error) {
+	values, err := w.GetCounters(ctx, []string{key})
+	if err != nil {
+		return 0, err
+	}
+	return values[key], nil
+}
+
+// GetCounters 批量读取计数器，包含尚未写入的增量，不存在的键为0
+//
+// 刷新正在进行时等待它完成，避免同一份增量被计入两次
+func (w *WriteBehind) GetCounters(ctx context.Context, keys []string) (map[string]int64, error) {
+	counters, ok := w.store.(CounterStore)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrCountersUnsupported, w.store)
+	}
+
+	w.flushMu.RLock()
+	defer w.flushMu.RUnlock()
+
+	values, err := counters.GetCounters(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, key := range keys {
+		values[key] += w.pending.deltas[key]
+	}
+	return values, nil
+}
+
+// Flush 立即把缓冲写入存储，失败的写入放回缓冲
+func (w *WriteBehind) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	batch := w.pending
+	w.pending, w.inflight = newPendingBatch(), batch
+	w.order.Init()
+	w.index = make(map[pendingKey]*list.Element)
+	w.notifySpaceLocked()
+	w.mu.Unlock()
+
+	if batch.len() == 0 {
+		return nil
+	}
+	setsWritten, err := w.write(ctx, batch)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.inflight = newPendingBatch()
+	if err != nil {
+		w.errors.Add(1)
+		if setsWritten {
+			// 缓存项已写入，重试时不再重复写入
+			batch.sets = nil
+		}
+		w.requeueLocked(batch)
+		return err
+	}
+	w.flushes.Add(1)
+	w.flushed.Add(int64(batch.len()))
+	return nil
+}
+
+// write 把一批写入存储，返回缓存项是否已全部写入
+func (w *WriteBehind) write(ctx context.Context, batch pendingBatch) (bool, error) {
+	if len(batch.sets) > 0 {
+		entries := make([]Entry, 0, len(batch.sets))
+		for _, entry := range batch.sets {
+			entries = append(entries, entry)
+		}
+		if setter, ok := w.store.(BatchSetter); ok {
+			if err := setter.SetMany(ctx, entries); err != nil {
+				return false, fmt.Errorf("延迟写入缓存项失败: %w", err)
+			}
+		} else {
+			for _, entry := range entries {
+				if err := w.store.Set(ctx, entry.Key, entry.Value, WithExpiration(entry.TTL), WithTags(entry.Tags...)); err != nil {
+					return false, fmt.Errorf("延迟写入缓存项失败: %w", err)
+				}
+			}
+		}
+	}
+
+	if len(batch.deltas) > 0 {
+		// Increment 已检查存储支持计数器
+		counters := w.store.(CounterStore)
+		if _, err := counters.IncrBatch(ctx, batch.deltas, w.counterTTL); err != nil {
+			return true, fmt.Errorf("延迟写入计数器失败: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// requeueLocked 把写入失败的一批放回缓冲，缓冲中更新的值优先，增量累加
+func (w *WriteBehind) requeueLocked(batch pendingBatch) {
+	for key, entry := range batch.sets {
+		if _, ok := w.pending.sets[key]; !ok {
+			w.pending.sets[key] = entry
+			pk := pendingKey{key: key}
+			w.index[pk] = w.order.PushFront(pk)
+		}
+	}
+	for key, delta := range batch.deltas {
+		pk := pendingKey{counter: true, key: key}
+		if _, ok := w.index[pk]; !ok {
+			w.index[pk] = w.order.PushFront(pk)
+		}
+		w.pending.deltas[key] += delta
+	}
+}
+
+// notifySpaceLocked 唤醒等待空位的写入
+func (w *WriteBehind) notifySpaceLocked() {
+	close(w.space)
+	w.space = make(chan struct{})
+}
+
+// requestFlush 请求后台立即刷新
+func (w *WriteBehind) requestFlush() {
+	select {
+	case w.kick <- struct{}{}:
+	default:
+	}
+}
+
+// loop 按间隔或在请求时刷新
+func (w *WriteBehind) loop() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		case <-w.kick:
+		}
+		if err := w.Flush(context.Background()); err != nil && w.onError != nil {
+			w.onError(err)
+		}
+	}
+}
+
+// Close 停止后台刷新并把缓冲全部写入存储，之后的写入返回 ErrWriteBehindClosed
+func (w *WriteBehind) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	// 唤醒阻塞的写入，它们会返回 ErrWriteBehindClosed
+	w.notifySpaceLocked()
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+	return w.Flush(ctx)
+}
+
+// CloseOnShutdown 在应用关闭前写入缓冲，先于 CacheProvider 的 flush_cache 钩子执行
+func (w *WriteBehind) CloseOnShutdown(application *app.Application) {
+	application.OnBeforeShutdown("cache_write_behind", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := w.Close(ctx); err != nil {
+			application.Logger().Errorf("写入延迟缓冲失败: %v", err)
+		}
+	}, 50)
+}
+
+// Stats 返回延迟写入的统计
+func (w *WriteBehind) Stats() WriteBehindStats {
+	w.mu.Lock()
+	pending := w.pending.len()
+	w.mu.Unlock()
+	return WriteBehindStats{
+		Pending: pending,
+		Dropped: w.dropped.Load(),
+		Flushed: w.flushed.Load(),
+		Flushes: w.flushes.Load(),
+		Errors:  w.errors.Load(),
+	}
+}
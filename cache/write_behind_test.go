@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/app"
+)
+
+// recordingStore 记录批量写入的内存存储，可以注入失败或阻塞写入
+type recordingStore struct {
+	*MemoryStore
+	mu      sync.Mutex
+	batches [][]Entry
+	incrs   []map[string]int64
+	fail    error
+	gate    chan struct{}
+}
+
+func newRecordingStore() *recordingStore {
+	return &recordingStore{MemoryStore: NewMemoryStore()}
+}
+
+func (s *recordingStore) SetMany(ctx context.Context, entries []Entry) error {
+	s.mu.Lock()
+	gate, fail := s.gate, s.fail
+	s.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
+	if fail != nil {
+		return fail
+	}
+	s.mu.Lock()
+	s.batches = append(s.batches, entries)
+	s.mu.Unlock()
+	return s.MemoryStore.SetMany(ctx, entries)
+}
+
+func (s *recordingStore) IncrBatch(ctx context.Context, deltas map[string]int64, ttl time.Duration) (map[string]int64, error) {
+	s.mu.Lock()
+	fail := s.fail
+	s.mu.Unlock()
+	if fail != nil {
+		return nil, fail
+	}
+	s.mu.Lock()
+	s.incrs = append(s.incrs, deltas)
+	s.mu.Unlock()
+	return s.MemoryStore.IncrBatch(ctx, deltas, ttl)
+}
+
+// 默认间隔很长，测试中手动刷新
+func newTestWriteBehind(t *testing.T, store Store, opts ...WriteBehindOption) *WriteBehind {
+	w := NewWriteBehind(store, append([]WriteBehindOption{WithFlushInterval(time.Hour)}, opts...)...)
+	t.Cleanup(func() { _ = w.Close(context.Background()) })
+	return w
+}
+
+func TestWriteBehind_Coalescing(t *testing.T) {
+	store := newRecordingStore()
+	w := newTestWriteBehind(t, store)
+	ctx := context.Background()
+
+	require.NoError(t, w.Set(ctx, "a", 1))
+	require.NoError(t, w.Set(ctx, "a", 2, WithExpiration(time.Minute)))
+	require.NoError(t, w.Set(ctx, "b", "x"))
+	assert.Equal(t, 2, w.Stats().Pending)
+	assert.False(t, store.Has(ctx, "a"), "刷新前不写入存储")
+
+	require.NoError(t, w.Flush(ctx))
+	require.Len(t, store.batches, 1, "一次刷新只有一次批量写入")
+	assert.Len(t, store.batches[0], 2)
+
+	// 最后一次写入生效，并使用它的选项
+	value, ttl, err := store.GetWithTTL(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	stats := w.Stats()
+	assert.Equal(t, 0, stats.Pending)
+	assert.Equal(t, int64(2), stats.Flushed)
+	assert.Equal(t, int64(1), stats.Flushes)
+
+	// 没有待写入的内容时不访问存储
+	require.NoError(t, w.Flush(ctx))
+	assert.Len(t, store.batches, 1)
+}
+
+func TestWriteBehind_DeltaSummation(t *testing.T) {
+	store := newRecordingStore()
+	w := newTestWriteBehind(t, store, WithCounterTTL(time.Minute))
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, w.Increment(ctx, "views", 1))
+	}
+	require.NoError(t, w.Increment(ctx, "views", -3))
+	require.NoError(t, w.Increment(ctx, "clicks", 2))
+	require.NoError(t, w.Flush(ctx))
+
+	require.Len(t, store.incrs, 1)
+	assert.Equal(t, map[string]int64{"views": 7, "clicks": 2}, store.incrs[0])
+
+	require.NoError(t, w.Increment(ctx, "views", 5))
+	require.NoError(t, w.Flush(ctx))
+	values, err := store.GetCounters(ctx, []string{"views", "clicks"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"views": 12, "clicks": 2}, values)
+
+	// 不支持计数器的存储
+	plain := newTestWriteBehind(t, struct{ Store }{NewMemoryStore()})
+	assert.ErrorIs(t, plain.Increment(ctx, "views", 1), ErrCountersUnsupported)
+}
+
+func TestWriteBehind_ReadYourWrites(t *testing.T) {
+	store := newRecordingStore()
+	w := newTestWriteBehind(t, store)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "name", "old"))
+	_, err := store.IncrBatch(ctx, map[string]int64{"hits": 10}, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Set(ctx, "name", "new"))
+	require.NoError(t, w.Increment(ctx, "hits", 5))
+
+	value, err := w.Get(ctx, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "new", value)
+	hits, err := w.GetCounter(ctx, "hits")
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), hits)
+
+	// 未缓冲的键从存储读取
+	_, err = w.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	// 刷新后数值不变，增量不会重复计入
+	require.NoError(t, w.Flush(ctx))
+	hits, err = w.GetCounter(ctx, "hits")
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), hits)
+	value, err = w.Get(ctx, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "new", value)
+}
+
+func TestWriteBehind_ReadDuringFlush(t *testing.T) {
+	store := newRecordingStore()
+	store.gate = make(chan struct{})
+	w := newTestWriteBehind(t, store)
+	ctx := context.Background()
+
+	require.NoError(t, w.Set(ctx, "k", "v"))
+	done := make(chan error, 1)
+	go func() { done <- w.Flush(ctx) }()
+
+	// 正在写入存储的值仍然可以读到
+	require.Eventually(t, func() bool { return w.Stats().Pending == 0 }, time.Second, time.Millisecond)
+	value, err := w.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", value)
+
+	close(store.gate)
+	require.NoError(t, <-done)
+}
+
+func TestWriteBehind_OverflowDropOldest(t *testing.T) {
+	store := newRecordingStore()
+	store.gate = make(chan struct{})
+	w := newTestWriteBehind(t, store, WithMaxPending(2))
+	ctx := context.Background()
+
+	// 第一批阻塞在写入中，之后的写入留在缓冲
+	require.NoError(t, w.Set(ctx, "first", 0))
+	require.NoError(t, w.Set(ctx, "second", 0))
+	require.Eventually(t, func() bool { return w.Stats().Pending == 0 }, time.Second, time.Millisecond)
+
+	require.NoError(t, w.Set(ctx, "a", 1))
+	require.NoError(t, w.Increment(ctx, "b", 1))
+	require.NoError(t, w.Set(ctx, "c", 3))
+	// 已缓冲的键合并，不占用新位置
+	require.NoError(t, w.Increment(ctx, "b", 1))
+
+	stats := w.Stats()
+	assert.Equal(t, 2, stats.Pending)
+	assert.Equal(t, int64(1), stats.Dropped)
+	_, err := w.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrCacheMiss, "最早的键被丢弃")
+
+	// 读取计数器等待正在进行的刷新完成
+	close(store.gate)
+	b, err := w.GetCounter(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), b)
+}
+
+func TestWriteBehind_OverflowBlock(t *testing.T) {
+	store := newRecordingStore()
+	store.gate = make(chan struct{})
+	w := newTestWriteBehind(t, store, WithMaxPending(1), WithOverflowPolicy(OverflowBlock))
+	ctx := context.Background()
+
+	require.NoError(t, w.Set(ctx, "first", 0))
+	require.Eventually(t, func() bool { return w.Stats().Pending == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, w.Set(ctx, "second", 0))
+
+	// 缓冲已满且刷新未完成，写入阻塞直到上下文取消
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Set(timeout, "third", 0), context.DeadlineExceeded)
+
+	// 刷新完成后写入继续
+	done := make(chan error, 1)
+	go func() { done <- w.Set(ctx, "third", 3) }()
+	close(store.gate)
+	require.NoError(t, <-done)
+
+	require.NoError(t, w.Flush(ctx))
+	for _, key := range []string{"first", "second", "third"} {
+		assert.True(t, store.Has(ctx, key), key)
+	}
+	assert.Zero(t, w.Stats().Dropped)
+}
+
+func TestWriteBehind_FlushErrorRequeues(t *testing.T) {
+	store := newRecordingStore()
+	store.fail = errors.New("connection refused")
+	w := newTestWriteBehind(t, store)
+	ctx := context.Background()
+
+	require.NoError(t, w.Set(ctx, "k", "old"))
+	require.NoError(t, w.Increment(ctx, "n", 2))
+	assert.ErrorIs(t, w.Flush(ctx), store.fail)
+
+	// 失败的写入放回缓冲，缓冲中更新的值优先
+	require.NoError(t, w.Set(ctx, "k", "new"))
+	require.NoError(t, w.Increment(ctx, "n", 3))
+	assert.Equal(t, 2, w.Stats().Pending)
+	assert.Equal(t, int64(1), w.Stats().Errors)
+
+	store.mu.Lock()
+	store.fail = nil
+	store.mu.Unlock()
+	require.NoError(t, w.Flush(ctx))
+	value, err := store.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "new", value)
+	values, err := store.GetCounters(ctx, []string{"n"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), values["n"])
+}
+
+func TestWriteBehind_IntervalFlush(t *testing.T) {
+	store := newRecordingStore()
+	w := NewWriteBehind(store, WithFlushInterval(10*time.Millisecond))
+	defer w.Close(context.Background())
+
+	require.NoError(t, w.Set(context.Background(), "k", "v"))
+	assert.Eventually(t, func() bool { return store.Has(context.Background(), "k") }, time.Second, 5*time.Millisecond)
+}
+
+func TestWriteBehind_DrainOnShutdown(t *testing.T) {
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	application := app.New(flow.New(flow.WithMode("test")))
+	store := newRecordingStore()
+	w := newTestWriteBehind(t, store)
+	w.CloseOnShutdown(application)
+	ctx := context.Background()
+
+	require.NoError(t, w.Set(ctx, "k", "v"))
+	require.NoError(t, w.Increment(ctx, "n", 4))
+	_ = application.Shutdown(time.Second)
+
+	assert.True(t, store.Has(ctx, "k"))
+	values, err := store.GetCounters(ctx, []string{"n"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), values["n"])
+
+	// 关闭后不再接受写入
+	assert.ErrorIs(t, w.Set(ctx, "k", "v2"), ErrWriteBehindClosed)
+	assert.NoError(t, w.Close(ctx))
+}
@@ -72,11 +72,11 @@ func (a *App) Run() error {
 	bannerSize := os.Getenv("FLOW_BANNER_SIZE")
 	if bannerSize == "" || bannerSize == "small" {
 		// 使用小型Banner
-		fmt.Printf(SmallBanner, a.Version, a.Description)
+		fmt.Printf(SmallBanner, a.bannerVersion(), a.Description)
 	} else {
 		// 如果用户要求不显示banner
 		if bannerSize != "none" {
-			fmt.Printf(SmallBanner, a.Version, a.Description)
+			fmt.Printf(SmallBanner, a.bannerVersion(), a.Description)
 		}
 	}
 
@@ -91,6 +91,15 @@ func (a *App) Run() error {
 	return a.rootCmd.Execute()
 }
 
+// bannerVersion 返回Banner中显示的版本，框架版本附带构建时的提交，与应用启动报告一致
+func (a *App) bannerVersion() string {
+	info := flow.ReadBuildInfo()
+	if a.Version != info.Version {
+		return a.Version
+	}
+	return info.String()
+}
+
 // NewFlowCLI 创建默认的Flow CLI应用程序
 func NewFlowCLI() *App {
 	return NewApp("flow", flow.Version, "Flow框架命令行工具")
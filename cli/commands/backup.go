@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2/backup"
+	"github.com/zzliekkas/flow/v2/cli"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/db"
+)
+
+// NewBackupCommand 创建备份命令
+func NewBackupCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "备份与恢复数据库",
+		Long:  `创建、列出和恢复数据库备份。备份包含清单与校验和，可选使用AES-GCM加密。`,
+	}
+
+	cmd.PersistentFlags().String("config", "./config", "配置文件目录")
+	cmd.PersistentFlags().String("dir", "", "备份目录（默认读取 backup.dir，未配置时为 ./storage/backups）")
+
+	cmd.AddCommand(newBackupCreateCommand())
+	cmd.AddCommand(newBackupRestoreCommand())
+	cmd.AddCommand(newBackupListCommand())
+
+	return cmd
+}
+
+// newBackupCreateCommand 创建备份子命令
+func newBackupCreateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "创建备份",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := newBackupManager(cmd)
+			if err != nil {
+				cli.PrintError("%v", err)
+				return err
+			}
+
+			manifest, err := manager.Create(context.Background())
+			if err != nil {
+				cli.PrintError("备份失败: %v", err)
+				return err
+			}
+
+			cli.PrintSuccess("备份已创建: %s", manifest.ID)
+			for _, entry := range manifest.Entries {
+				cli.PrintInfo("  %s (%s) %d 字节 sha256:%s", entry.Connection, entry.Driver, entry.Size, entry.SHA256[:12])
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("connection", nil, "只备份指定的数据库连接")
+	cmd.Flags().Int("retention", -1, "保留的备份数量（默认读取 backup.retention）")
+
+	return cmd
+}
+
+// newBackupRestoreCommand 恢复备份子命令
+func newBackupRestoreCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <backup-id>",
+		Short: "校验并恢复备份",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := newBackupManager(cmd)
+			if err != nil {
+				cli.PrintError("%v", err)
+				return err
+			}
+
+			force, _ := cmd.Flags().GetBool("force")
+			connections, _ := cmd.Flags().GetStringSlice("connection")
+			if err := manager.Restore(context.Background(), args[0], backup.RestoreOptions{
+				Force:       force,
+				Connections: connections,
+			}); err != nil {
+				cli.PrintError("恢复失败: %v", err)
+				return err
+			}
+
+			cli.PrintSuccess("备份 %s 已恢复", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("force", false, "目标非空时强制覆盖")
+	cmd.Flags().StringSlice("connection", nil, "只恢复指定的数据库连接")
+
+	return cmd
+}
+
+// newBackupListCommand 列出备份子命令
+func newBackupListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "列出已有备份",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := newBackupManager(cmd)
+			if err != nil {
+				cli.PrintError("%v", err)
+				return err
+			}
+
+			manifests, err := manager.List()
+			if err != nil {
+				return err
+			}
+			if len(manifests) == 0 {
+				cli.PrintInfo("没有找到备份")
+				return nil
+			}
+			for _, m := range manifests {
+				encrypted := ""
+				if m.Encrypted {
+					encrypted = " [加密]"
+				}
+				fmt.Printf("%s  %s  %d 个文件%s\n", m.ID, m.CreatedAt.Format("2006-01-02 15:04:05"), len(m.Entries), encrypted)
+			}
+			return nil
+		},
+	}
+}
+
+// newBackupManager 根据配置文件与命令行参数创建备份管理器
+//
+//	backup:
+//	  dir: ./storage/backups
+//	  retention: 7
+//	  encryption_key: <base64>   # 也可通过 FLOW_BACKUP_KEY 环境变量提供
+func newBackupManager(cmd *cobra.Command) (*backup.Manager, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	cm := config.NewConfigManager(config.WithConfigPath(configPath))
+	if err := cm.Load(); err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	databases := db.NewManager()
+	if err := databases.FromConfig(cm); err != nil {
+		return nil, fmt.Errorf("加载数据库配置失败: %w", err)
+	}
+
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		dir = cm.GetString("backup.dir")
+	}
+	if dir == "" {
+		dir = "./storage/backups"
+	}
+
+	var options []backup.Option
+	retention := cm.GetInt("backup.retention")
+	if cmd.Flags().Lookup("retention") != nil {
+		if r, _ := cmd.Flags().GetInt("retention"); r >= 0 {
+			retention = r
+		}
+	}
+	options = append(options, backup.WithRetention(retention))
+
+	if cmd.Flags().Lookup("connection") != nil {
+		if connections, _ := cmd.Flags().GetStringSlice("connection"); len(connections) > 0 {
+			options = append(options, backup.WithConnections(connections...))
+		}
+	}
+
+	encoded := os.Getenv("FLOW_BACKUP_KEY")
+	if encoded == "" {
+		encoded = cm.GetString("backup.encryption_key")
+	}
+	if encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("备份加密密钥必须为base64编码: %w", err)
+		}
+		options = append(options, backup.WithEncryptionKey(key))
+	}
+
+	return backup.NewManager(dir, databases, options...), nil
+}
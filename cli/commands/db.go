@@ -1,8 +1,13 @@
 package commands
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"github.com/zzliekkas/flow/v2/cli"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/db"
 )
 
 // NewDBCommand 创建数据库命令
@@ -19,6 +24,7 @@ func NewDBCommand() *cobra.Command {
 	cmd.AddCommand(newDBSeedCommand())
 	cmd.AddCommand(newDBResetCommand())
 	cmd.AddCommand(newDBStatusCommand())
+	cmd.AddCommand(newDBDiffCommand())
 
 	return cmd
 }
@@ -91,6 +97,28 @@ func newDBStatusCommand() *cobra.Command {
 	return cmd
 }
 
+// newDBDiffCommand 创建数据库结构对比命令
+func newDBDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "对比数据库结构与模型",
+		Long: `读取数据库中的表、列、索引与外键，与 db.RegisterSchemaModels 注册的模型对比并输出差异。
+--check 在存在差异时以非零状态退出，可用于部署前的CI检查；--migration 将差异生成迁移文件，
+新增的结构生成可执行语句，删除与修改以注释形式输出。`,
+		RunE: runDBDiff,
+	}
+
+	cmd.Flags().String("config", "./config", "配置文件目录")
+	cmd.Flags().StringP("connection", "c", "", "指定数据库连接")
+	cmd.Flags().Bool("check", false, "存在差异时以非零状态退出")
+	cmd.Flags().Bool("json", false, "以JSON格式输出差异")
+	cmd.Flags().String("migration", "", "将差异生成为指定名称的迁移文件")
+	cmd.Flags().String("dir", "database/migrations", "迁移文件目录")
+	cmd.Flags().StringSlice("ignore", nil, "不参与对比的表")
+
+	return cmd
+}
+
 // runDBMigrate 运行数据库迁移的函数
 func runDBMigrate(cmd *cobra.Command, args []string) {
 	// 获取命令行参数
@@ -204,3 +232,68 @@ func showDBStatus(connection string) {
 	cli.PrintInfo("未执行的迁移:")
 	// 这里将来会集成实际的迁移状态检查逻辑
 }
+
+// runDBDiff 对比数据库结构与模型
+func runDBDiff(cmd *cobra.Command, args []string) error {
+	models := db.SchemaModels()
+	if len(models) == 0 {
+		return fmt.Errorf("未注册模型，请在应用中调用 db.RegisterSchemaModels")
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	cm := config.NewConfigManager(config.WithConfigPath(configPath))
+	if err := cm.Load(); err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	databases := db.NewManager()
+	if err := databases.FromConfig(cm); err != nil {
+		return fmt.Errorf("加载数据库配置失败: %w", err)
+	}
+	defer databases.Close()
+
+	connection, _ := cmd.Flags().GetString("connection")
+	conn, err := databases.Default()
+	if connection != "" {
+		conn, err = databases.Connection(connection)
+	}
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %w", err)
+	}
+
+	inspector := db.NewSchemaInspector(conn)
+	expected, err := inspector.ModelSchema(models...)
+	if err != nil {
+		return err
+	}
+	actual, err := inspector.Inspect()
+	if err != nil {
+		return err
+	}
+	ignore, _ := cmd.Flags().GetStringSlice("ignore")
+	diff := db.DiffSchemas(inspector.Dialect(), expected, actual, db.DiffOptions{IgnoreTables: ignore})
+
+	out := cmd.OutOrStdout()
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(out, diff.String())
+	}
+
+	if name, _ := cmd.Flags().GetString("migration"); name != "" && diff.HasDrift() {
+		dir, _ := cmd.Flags().GetString("dir")
+		path, err := diff.WriteMigrationStub(dir, name)
+		if err != nil {
+			return err
+		}
+		cli.PrintSuccess("迁移文件已生成: %s", path)
+	}
+
+	if check, _ := cmd.Flags().GetBool("check"); check && diff.HasDrift() {
+		return fmt.Errorf("%w: %d 项差异", db.ErrSchemaDrift, len(diff.Changes))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2/cli"
+	"github.com/zzliekkas/flow/v2/diagnostics"
+)
+
+// NewDiagnosticsCommand 创建诊断命令
+func NewDiagnosticsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diagnostics",
+		Short: "查看运行中应用最近的错误与慢请求",
+		Long:  `通过应用的调试端点（默认 /_debug/diagnostics）读取进程内保留的最近错误与慢请求。`,
+	}
+
+	cmd.AddCommand(newDiagnosticsTailCommand())
+	return cmd
+}
+
+// newDiagnosticsTailCommand 创建tail子命令
+func newDiagnosticsTailCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tail [errors|slow]",
+		Short: "输出最近的诊断记录，--follow 持续输出新记录",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind := "errors"
+			if len(args) > 0 {
+				kind = args[0]
+			}
+			if kind != "errors" && kind != "slow" {
+				return fmt.Errorf("未知的诊断类型: %s，可选 errors 或 slow", kind)
+			}
+
+			baseURL, _ := cmd.Flags().GetString("url")
+			follow, _ := cmd.Flags().GetBool("follow")
+			interval, _ := cmd.Flags().GetDuration("interval")
+
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			defer stop()
+
+			if err := tailDiagnostics(ctx, cmd.OutOrStdout(), baseURL, kind, follow, interval); err != nil {
+				cli.PrintError("读取诊断记录失败: %v", err)
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("url", "u", "http://localhost:8080/_debug", "应用调试端点地址")
+	cmd.Flags().BoolP("follow", "f", false, "持续输出新记录")
+	cmd.Flags().Duration("interval", 2*time.Second, "follow 模式的轮询间隔")
+
+	return cmd
+}
+
+// diagnosticsResponse 诊断端点的响应
+type diagnosticsResponse struct {
+	Entries []diagnostics.Entry `json:"entries"`
+	Dropped uint64              `json:"dropped"`
+}
+
+// tailDiagnostics 输出诊断记录，follow 模式下按间隔轮询直到上下文结束
+func tailDiagnostics(ctx context.Context, out io.Writer, baseURL, kind string, follow bool, interval time.Duration) error {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	target := strings.TrimRight(baseURL, "/") + "/diagnostics/" + kind
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var since uint64
+	for {
+		resp, err := fetchDiagnostics(ctx, client, fmt.Sprintf("%s?since=%d", target, since))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		for _, entry := range resp.Entries {
+			fmt.Fprintln(out, formatDiagnosticsEntry(entry))
+			since = entry.Seq
+		}
+
+		if !follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// fetchDiagnostics 请求诊断端点
+func fetchDiagnostics(ctx context.Context, client *http.Client, target string) (*diagnosticsResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("调试端点返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result diagnosticsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析诊断记录失败: %w", err)
+	}
+	return &result, nil
+}
+
+// formatDiagnosticsEntry 格式化单条记录
+func formatDiagnosticsEntry(e diagnostics.Entry) string {
+	parts := []string{
+		e.Time.Local().Format("2006-01-02 15:04:05"),
+		string(e.Kind),
+	}
+	if e.Status > 0 {
+		parts = append(parts, fmt.Sprint(e.Status))
+	}
+	parts = append(parts, e.Duration.String())
+
+	target := strings.TrimSpace(e.Method + " " + e.Path)
+	if e.Kind == diagnostics.KindSlowQuery {
+		target = e.Route + ": " + e.Path
+	}
+	parts = append(parts, target)
+	if e.RequestID != "" {
+		parts = append(parts, "req="+e.RequestID)
+	}
+	if len(e.Timing) > 0 {
+		layers := make([]string, 0, len(e.Timing))
+		for _, t := range e.Timing {
+			layers = append(layers, fmt.Sprintf("%s=%s", t.Name, t.Duration.Round(time.Microsecond)))
+		}
+		parts = append(parts, strings.Join(layers, " "))
+	}
+	if e.Error != "" {
+		parts = append(parts, e.Error)
+	}
+	return strings.Join(parts, " | ")
+}
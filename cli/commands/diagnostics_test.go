@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/diagnostics"
+	"github.com/zzliekkas/flow/v2/profiler"
+)
+
+// syncBuffer 可并发读写的输出缓冲
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDiagnosticsTail_Follow(t *testing.T) {
+	collector := diagnostics.New(diagnostics.DefaultConfig())
+	enabled := true
+	e := flow.New()
+	_, err := profiler.MountDebugEndpoints(e, profiler.DebugConfig{Enabled: &enabled, Diagnostics: collector})
+	require.NoError(t, err)
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	collector.RecordRequest(diagnostics.Entry{Method: "GET", Path: "/first", Status: 500, Error: "first failure"})
+
+	out := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- tailDiagnostics(ctx, out, server.URL+"/_debug", "errors", true, 10*time.Millisecond)
+	}()
+
+	assert.Eventually(t, func() bool { return strings.Contains(out.String(), "first failure") }, time.Second, 5*time.Millisecond)
+
+	// follow 模式只输出新增的记录
+	collector.RecordRequest(diagnostics.Entry{Method: "POST", Path: "/second", Status: 503, Error: "second failure"})
+	assert.Eventually(t, func() bool { return strings.Contains(out.String(), "second failure") }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, strings.Count(out.String(), "first failure"))
+	assert.Contains(t, out.String(), "| 503 |")
+}
+
+func TestDiagnosticsTail_Once(t *testing.T) {
+	collector := diagnostics.New(diagnostics.Config{SlowThreshold: time.Millisecond})
+	enabled := true
+	e := flow.New()
+	_, err := profiler.MountDebugEndpoints(e, profiler.DebugConfig{Enabled: &enabled, Diagnostics: collector})
+	require.NoError(t, err)
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	collector.RecordSlowQuery("default", "SELECT 1", time.Second)
+
+	cmd := NewDiagnosticsCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"tail", "slow", "--url", server.URL + "/_debug"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "slow_query | 1s | default: SELECT 1")
+
+	cmd.SetArgs([]string{"tail", "unknown", "--url", server.URL + "/_debug"})
+	assert.Error(t, cmd.Execute())
+}
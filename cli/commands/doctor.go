@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// errDoctorFindings 诊断发现问题，命令以非零状态退出
+var errDoctorFindings = errors.New("启动诊断未通过")
+
+// doctorSource 启动诊断，由应用通过SetDoctorSource注入
+var doctorSource func() error
+
+// SetDoctorSource 设置启动诊断，通常在完成服务与路由注册后传入 application.Diagnose
+func SetDoctorSource(source func() error) {
+	doctorSource = source
+}
+
+// NewDoctorCommand 创建启动诊断命令
+func NewDoctorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "执行启动诊断，发现问题时以非零状态退出",
+		Long: `执行全部启动诊断而不启动服务：重复注册的服务提供者与依赖、重叠的路由以及缺少的依赖。
+发现问题时逐条输出并以非零状态退出，可用于CI。`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(cmd.OutOrStdout())
+		},
+	}
+}
+
+// runDoctor 执行诊断并输出结果，发现问题时返回 errDoctorFindings
+func runDoctor(out io.Writer) error {
+	if doctorSource == nil {
+		return errors.New("未设置启动诊断，请在应用中调用 commands.SetDoctorSource(application.Diagnose)")
+	}
+
+	err := doctorSource()
+	if err == nil {
+		fmt.Fprintln(out, "✓ 启动诊断通过")
+		return nil
+	}
+
+	findings := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		findings = joined.Unwrap()
+	}
+	fmt.Fprintf(out, "启动诊断发现 %d 个问题:\n", len(findings))
+	for _, finding := range findings {
+		fmt.Fprintf(out, "  ✗ %v\n", finding)
+	}
+	return fmt.Errorf("%w: %d 个问题", errDoctorFindings, len(findings))
+}
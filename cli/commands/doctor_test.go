@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/app"
+)
+
+// executeDoctor 执行 doctor 命令，返回输出与错误（错误时 CLI 以非零状态退出）
+func executeDoctor(t *testing.T, source func() error) (string, error) {
+	t.Helper()
+	SetDoctorSource(source)
+	t.Cleanup(func() { SetDoctorSource(nil) })
+
+	cmd := NewDoctorCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(nil)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestDoctor_ExitCodes(t *testing.T) {
+	t.Setenv("FLOW_ENV", "test")
+	e := flow.New(flow.WithMode("release"))
+	application := app.New(e)
+	e.GET("/users/:id", func(c *flow.Context) {})
+
+	out, err := executeDoctor(t, application.Diagnose)
+	require.NoError(t, err)
+	assert.Contains(t, out, "启动诊断通过")
+
+	e.GET("/users/new", func(c *flow.Context) {})
+	out, err = executeDoctor(t, application.Diagnose)
+	assert.ErrorIs(t, err, errDoctorFindings)
+	assert.Contains(t, out, "发现 1 个问题")
+	assert.Contains(t, out, "doctor_test.go:")
+	assert.NotContains(t, out, "Usage:", "发现问题时不输出用法")
+
+	// 单个错误同样视为发现问题
+	_, err = executeDoctor(t, func() error { return errors.New("boom") })
+	assert.ErrorIs(t, err, errDoctorFindings)
+
+	// 未设置诊断时失败
+	_, err = executeDoctor(t, nil)
+	assert.Error(t, err)
+}
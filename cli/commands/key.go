@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2/cli"
+	"github.com/zzliekkas/flow/v2/crypto"
+)
+
+// appKeyEnv 应用密钥的环境变量名称，对应配置 app.key
+const appKeyEnv = "FLOW_APP_KEY"
+
+// NewKeyGenerateCommand 创建生成应用密钥命令
+func NewKeyGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key:generate",
+		Short: "生成应用密钥",
+		Long: `生成用于加密和签名的应用密钥，并以 FLOW_APP_KEY 写入环境文件。
+已存在密钥时不会覆盖，除非指定 --force；--rotate 生成新密钥并保留旧密钥用于解密。`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			show, _ := cmd.Flags().GetBool("show")
+			if show {
+				key, err := crypto.GenerateKey()
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), crypto.EncodeKey(key))
+				return nil
+			}
+
+			envFile, _ := cmd.Flags().GetString("env-file")
+			force, _ := cmd.Flags().GetBool("force")
+			rotate, _ := cmd.Flags().GetBool("rotate")
+
+			changed, err := writeAppKey(envFile, force, rotate)
+			if err != nil {
+				cli.PrintError("写入应用密钥失败: %v", err)
+				return err
+			}
+			if !changed {
+				cli.PrintInfo("%s 中已存在应用密钥，使用 --force 覆盖或 --rotate 轮换", envFile)
+				return nil
+			}
+			if rotate {
+				cli.PrintSuccess("已生成新的应用密钥，旧密钥保留用于解密: %s", envFile)
+			} else {
+				cli.PrintSuccess("应用密钥已写入 %s", envFile)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("env-file", ".env", "写入密钥的环境文件")
+	cmd.Flags().Bool("show", false, "只输出新密钥，不写入文件")
+	cmd.Flags().Bool("force", false, "覆盖已存在的密钥")
+	cmd.Flags().Bool("rotate", false, "生成新密钥并保留现有密钥用于解密")
+
+	return cmd
+}
+
+// writeAppKey 在环境文件中写入应用密钥，返回文件是否被修改
+// 已存在密钥且未指定 force 或 rotate 时不做修改
+func writeAppKey(path string, force, rotate bool) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+
+	index, existing := -1, ""
+	for i, line := range lines {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), appKeyEnv+"="); ok {
+			index, existing = i, strings.Trim(value, `"'`)
+		}
+	}
+	if existing != "" && !force && !rotate {
+		return false, nil
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return false, err
+	}
+	value := crypto.EncodeKey(key)
+	if rotate && existing != "" {
+		value += "," + existing
+	}
+
+	line := appKeyEnv + "=" + value
+	if index >= 0 {
+		lines[index] = line
+	} else {
+		lines = append(lines, line)
+	}
+	return true, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
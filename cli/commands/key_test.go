@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/crypto"
+)
+
+func TestWriteAppKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("FLOW_APP_NAME=demo\n"), 0600))
+
+	changed, err := writeAppKey(path, false, false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	first, _ := os.ReadFile(path)
+	assert.True(t, strings.HasPrefix(string(first), "FLOW_APP_NAME=demo\nFLOW_APP_KEY=base64:"))
+
+	// 已存在密钥时再次执行不修改文件
+	changed, err = writeAppKey(path, false, false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	second, _ := os.ReadFile(path)
+	assert.Equal(t, string(first), string(second))
+
+	// 轮换时新密钥在前，保留旧密钥
+	changed, err = writeAppKey(path, false, true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	rotated, _ := os.ReadFile(path)
+	oldValue := strings.TrimPrefix(strings.TrimSpace(strings.Split(string(first), "\n")[1]), "FLOW_APP_KEY=")
+	newValue := strings.TrimPrefix(strings.TrimSpace(strings.Split(string(rotated), "\n")[1]), "FLOW_APP_KEY=")
+	assert.True(t, strings.HasSuffix(newValue, ","+oldValue))
+	keys, err := crypto.ParseKeys(newValue)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
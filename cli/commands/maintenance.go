@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2/app"
+	"github.com/zzliekkas/flow/v2/cache"
+	"github.com/zzliekkas/flow/v2/cli"
+	"github.com/zzliekkas/flow/v2/config"
+)
+
+// NewDownCommand 创建进入维护模式命令
+func NewDownCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "down",
+		Short: "进入维护模式",
+		Long:  `将应用切换到维护模式，除绕过密钥、允许的网段和排除路径外，所有请求返回503。`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := newMaintenanceStore(cmd)
+			if err != nil {
+				cli.PrintError("%v", err)
+				return err
+			}
+
+			retry, _ := cmd.Flags().GetInt("retry")
+			secret, _ := cmd.Flags().GetString("secret")
+			message, _ := cmd.Flags().GetString("message")
+			allow, _ := cmd.Flags().GetStringSlice("allow")
+
+			state := &app.MaintenanceState{
+				Since:      time.Now(),
+				RetryAfter: retry,
+				Secret:     secret,
+				Message:    message,
+				AllowCIDRs: allow,
+			}
+			if err := store.Save(context.Background(), state); err != nil {
+				cli.PrintError("进入维护模式失败: %v", err)
+				return err
+			}
+
+			cli.PrintSuccess("应用已进入维护模式")
+			if secret != "" {
+				cli.PrintInfo("访问 /%s 或携带请求头 X-Maintenance-Bypass 可绕过维护模式", secret)
+			}
+			return nil
+		},
+	}
+
+	addMaintenanceStoreFlags(cmd)
+	cmd.Flags().Int("retry", 0, "Retry-After 响应头的秒数")
+	cmd.Flags().String("secret", "", "绕过维护模式的密钥")
+	cmd.Flags().String("message", "", "维护提示信息")
+	cmd.Flags().StringSlice("allow", nil, "允许访问的IP或网段，可多次指定")
+
+	return cmd
+}
+
+// NewUpCommand 创建退出维护模式命令
+func NewUpCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "退出维护模式",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := newMaintenanceStore(cmd)
+			if err != nil {
+				cli.PrintError("%v", err)
+				return err
+			}
+
+			if err := store.Clear(context.Background()); err != nil {
+				cli.PrintError("退出维护模式失败: %v", err)
+				return err
+			}
+
+			cli.PrintSuccess("应用已退出维护模式")
+			return nil
+		},
+	}
+
+	addMaintenanceStoreFlags(cmd)
+	return cmd
+}
+
+// addMaintenanceStoreFlags 添加维护模式存储相关参数
+func addMaintenanceStoreFlags(cmd *cobra.Command) {
+	cmd.Flags().String("config", "./config", "配置文件目录")
+	cmd.Flags().String("store", "", "状态存储：file 或缓存存储名称（默认读取 maintenance.store）")
+}
+
+// newMaintenanceStore 根据配置创建维护模式存储
+//
+//	maintenance:
+//	  store: redis          # file 或 cache.stores 中的存储名称
+//	  file: storage/framework/down
+//	  key: flow:maintenance
+func newMaintenanceStore(cmd *cobra.Command) (app.MaintenanceStore, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	cm := config.NewConfigManager(config.WithConfigPath(configPath))
+	if err := cm.Load(); err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	storeName, _ := cmd.Flags().GetString("store")
+	if storeName == "" {
+		storeName = cm.GetString("maintenance.store")
+	}
+	if storeName == "" || storeName == "file" {
+		return app.NewFileMaintenanceStore(cm.GetString("maintenance.file")), nil
+	}
+
+	manager := cache.NewManager()
+	if err := manager.LoadConfig(cm.GetStringMap("cache")); err != nil {
+		return nil, fmt.Errorf("加载缓存配置失败: %w", err)
+	}
+	store, err := manager.Store(storeName)
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewMaintenanceStore(store, cm.GetString("maintenance.key")), nil
+}
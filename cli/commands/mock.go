@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	flow "github.com/zzliekkas/flow/v2"
+)
+
+// NewMockCommand 创建模拟服务命令
+func NewMockCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mock",
+		Short: "模拟接口服务",
+		Long:  `根据 YAML/JSON 定义启动模拟接口服务，供前端在没有真实后端时开发。`,
+	}
+
+	cmd.AddCommand(newMockServeCommand())
+
+	return cmd
+}
+
+// newMockServeCommand 创建启动模拟服务子命令
+func newMockServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "启动模拟接口服务",
+		Long: `加载目录下的模拟定义并启动HTTP服务，文件修改后自动重新加载。
+
+示例:
+  flow mock serve --spec ./mocks --addr :8080 --seed 42`,
+		RunE: runMockServe,
+	}
+
+	cmd.Flags().String("spec", "./mocks", "模拟定义目录")
+	cmd.Flags().String("addr", ":8080", "监听地址")
+	cmd.Flags().Int64("seed", 0, "随机种子，相同种子下生成的数据可复现，0 表示随机")
+
+	return cmd
+}
+
+// runMockServe 启动模拟服务
+func runMockServe(cmd *cobra.Command, args []string) error {
+	dir, _ := cmd.Flags().GetString("spec")
+	addr, _ := cmd.Flags().GetString("addr")
+	seed, _ := cmd.Flags().GetInt64("seed")
+
+	opts := []flow.MockOption{flow.WithMockReload(true)}
+	if seed != 0 {
+		opts = append(opts, flow.WithMockSeed(seed))
+	}
+
+	engine := flow.New(flow.WithMode("debug"))
+	mocks, err := engine.MountMocks(dir, opts...)
+	if err != nil {
+		return err
+	}
+	defer mocks.Close()
+
+	fmt.Printf("模拟服务已启动: %s (定义目录 %s)\n", addr, dir)
+	return engine.Run(addr)
+}
@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2/cli"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/crypto"
+	"github.com/zzliekkas/flow/v2/db"
+	"github.com/zzliekkas/flow/v2/privacy"
+)
+
+// NewPrivacyCommand 创建个人数据命令
+func NewPrivacyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "privacy",
+		Short: "个人数据清除",
+		Long:  `按删除请求清除用户在数据库、文件存储与缓存中的数据。处理器需在应用中通过 privacy.Register 注册。`,
+	}
+
+	cmd.PersistentFlags().String("config", "./config", "配置文件目录")
+	cmd.AddCommand(newPrivacyEraseCommand())
+
+	return cmd
+}
+
+// newPrivacyEraseCommand 创建清除子命令
+func newPrivacyEraseCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "erase",
+		Short: "清除用户的个人数据",
+		Long: `按注册顺序执行全部清除处理器，输出按处理器统计的报告，报告使用 app.key 签名并写入审计日志。
+应用没有注册处理器时使用配置中的 privacy.tables 规则清除数据库。
+建议先使用 --dry-run 查看将要清除的数据；需要异步执行时在应用中使用 privacy.Dispatch。
+例如: flow privacy erase --subject 42 --dry-run`,
+		RunE: runPrivacyErase,
+	}
+
+	cmd.Flags().String("subject", "", "用户ID")
+	cmd.Flags().Bool("dry-run", false, "只统计将要清除的数据，不做修改")
+	cmd.Flags().Duration("timeout", 0, "每个处理器的最长执行时间，默认1分钟")
+	cmd.Flags().Bool("json", false, "以JSON格式输出报告")
+	cmd.Flags().StringP("connection", "c", "", "指定数据库连接")
+	_ = cmd.MarkFlagRequired("subject")
+
+	return cmd
+}
+
+// runPrivacyErase 清除用户的个人数据
+func runPrivacyErase(cmd *cobra.Command, args []string) error {
+	configPath, _ := cmd.Flags().GetString("config")
+	cm := config.NewConfigManager(config.WithConfigPath(configPath))
+	if err := cm.Load(); err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	eraser := privacy.Default()
+	keys, keyErr := crypto.LoadKeys(cm)
+	if !eraser.Signed() {
+		if keyErr != nil {
+			cli.PrintWarning("未配置 app.key，报告不签名")
+		} else {
+			signer, err := crypto.NewSigner(keys)
+			if err != nil {
+				return err
+			}
+			eraser.Configure(privacy.WithSigner(signer))
+		}
+	}
+	if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		eraser.Configure(privacy.WithTimeout(timeout))
+	}
+
+	if len(eraser.Handlers()) == 0 {
+		closeDB, err := registerConfiguredTables(cmd, cm, eraser, keys)
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+	}
+
+	subject, _ := cmd.Flags().GetString("subject")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	ctx := context.Background()
+	if dryRun {
+		ctx = privacy.WithDryRun(ctx)
+	}
+	report, err := eraser.Erase(ctx, subject)
+	if report != nil {
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			_ = encoder.Encode(report)
+		} else {
+			printErasureReport(report)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		cli.PrintInfo("试运行：将清除用户 %s 的 %d 项数据，未做任何修改", subject, report.Total())
+		return nil
+	}
+	cli.PrintSuccess("已清除用户 %s 的 %d 项数据", subject, report.Total())
+	return nil
+}
+
+// registerConfiguredTables 按配置中的 privacy.tables 注册数据库处理器
+func registerConfiguredTables(cmd *cobra.Command, cm *config.ConfigManager, eraser *privacy.Eraser, keys [][]byte) (func(), error) {
+	rules, err := privacy.LoadTableRules(cm)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, privacy.ErrNoHandlers
+	}
+
+	var opts []privacy.HandlerOption
+	if len(keys) > 0 {
+		pseudonymizer, err := privacy.NewPseudonymizer(keys[0])
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, privacy.WithPseudonymizer(pseudonymizer))
+	}
+
+	databases := db.NewManager()
+	if err := databases.FromConfig(cm); err != nil {
+		return nil, fmt.Errorf("加载数据库配置失败: %w", err)
+	}
+	connection, _ := cmd.Flags().GetString("connection")
+	conn, err := databases.Default()
+	if connection != "" {
+		conn, err = databases.Connection(connection)
+	}
+	if err != nil {
+		databases.Close()
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+
+	handler, err := privacy.NewDBHandler(conn, rules, opts...)
+	if err != nil {
+		databases.Close()
+		return nil, err
+	}
+	eraser.Register(handler)
+	return func() { databases.Close() }, nil
+}
+
+// printErasureReport 按处理器输出报告
+func printErasureReport(report *privacy.Report) {
+	for _, h := range report.Handlers {
+		targets := make([]string, 0, len(h.Counts))
+		for target, n := range h.Counts {
+			targets = append(targets, fmt.Sprintf("%s=%d", target, n))
+		}
+		sort.Strings(targets)
+		status := "完成"
+		if h.Error != "" {
+			status = "失败: " + h.Error
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\n", h.Handler, strings.Join(targets, ","), h.Duration.Round(time.Millisecond), status)
+	}
+	if report.Signature != "" {
+		fmt.Printf("签名: %s\n", report.Signature)
+	}
+}
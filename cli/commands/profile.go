@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2/cli"
+)
+
+// NewProfileCommand 创建性能分析命令
+func NewProfileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "从运行中的应用采集性能分析数据",
+		Long:  `通过应用的调试端点（默认 /_debug/pprof）采集CPU、堆内存等分析数据并保存到本地文件。`,
+	}
+
+	cmd.PersistentFlags().StringP("url", "u", "http://localhost:8080/_debug", "应用调试端点地址")
+	cmd.PersistentFlags().StringP("output", "o", "", "输出文件路径")
+	cmd.PersistentFlags().Duration("timeout", 0, "请求超时时间（默认为采集时长加30秒）")
+
+	cmd.AddCommand(newProfileTypeCommand("cpu", "profile", "采集CPU分析数据", true))
+	cmd.AddCommand(newProfileTypeCommand("heap", "heap", "采集堆内存分析数据", false))
+	cmd.AddCommand(newProfileTypeCommand("allocs", "allocs", "采集内存分配分析数据", false))
+	cmd.AddCommand(newProfileTypeCommand("goroutine", "goroutine", "采集协程分析数据", false))
+	cmd.AddCommand(newProfileTypeCommand("trace", "trace", "采集执行追踪数据", true))
+
+	return cmd
+}
+
+// newProfileTypeCommand 创建指定类型的采集子命令
+func newProfileTypeCommand(use, endpoint, short string, timed bool) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseURL, _ := cmd.Flags().GetString("url")
+			output, _ := cmd.Flags().GetString("output")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+
+			seconds := 0
+			if timed {
+				seconds, _ = cmd.Flags().GetInt("seconds")
+			}
+			if output == "" {
+				output = use + ".pb.gz"
+				if use == "trace" {
+					output = "trace.out"
+				}
+			}
+			if timeout == 0 {
+				timeout = time.Duration(seconds)*time.Second + 30*time.Second
+			}
+
+			if seconds > 0 {
+				cli.PrintInfo("正在采集%s，持续 %d 秒...", use, seconds)
+			}
+			size, err := downloadProfile(baseURL, endpoint, seconds, output, timeout)
+			if err != nil {
+				cli.PrintError("采集失败: %v", err)
+				return err
+			}
+
+			cli.PrintSuccess("已保存到 %s (%d 字节)", output, size)
+			return nil
+		},
+	}
+
+	if timed {
+		cmd.Flags().IntP("seconds", "s", 30, "采集时长（秒）")
+	}
+
+	return cmd
+}
+
+// downloadProfile 请求调试端点并将分析数据写入文件
+func downloadProfile(baseURL, endpoint string, seconds int, output string, timeout time.Duration) (int64, error) {
+	target := strings.TrimRight(baseURL, "/") + "/pprof/" + endpoint
+	if seconds > 0 {
+		target += "?" + url.Values{"seconds": {fmt.Sprint(seconds)}}.Encode()
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(target)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return 0, fmt.Errorf("调试端点返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return io.Copy(file, resp.Body)
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileCommand_DownloadsCPUProfile(t *testing.T) {
+	var gotPath, gotSeconds string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotSeconds = r.URL.Query().Get("seconds")
+		_, _ = w.Write([]byte("profile-data"))
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "cpu.pb.gz")
+	cmd := NewProfileCommand()
+	cmd.SetArgs([]string{"cpu", "--url", server.URL + "/_debug", "--seconds", "2", "--output", output})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "/_debug/pprof/profile", gotPath)
+	assert.Equal(t, "2", gotSeconds)
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "profile-data", string(data))
+}
+
+func TestDownloadProfile_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "heap.pb.gz")
+	_, err := downloadProfile(server.URL, "heap", 0, output, time.Second)
+	assert.Error(t, err)
+	_, statErr := os.Stat(output)
+	assert.True(t, os.IsNotExist(statErr), "失败时不应创建输出文件")
+}
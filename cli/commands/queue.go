@@ -1,16 +1,22 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/zzliekkas/flow/v2/cli"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/crypto"
+	flowqueue "github.com/zzliekkas/flow/v2/queue"
 )
 
 // 初始化随机数种子
@@ -57,6 +63,30 @@ func NewQueueCommand() *cobra.Command {
 	return cmd
 }
 
+// queueSource 队列管理器来源，由应用通过SetQueueSource注入
+var queueSource func() *flowqueue.QueueManager
+
+// SetQueueSource 设置队列管理器来源，工作进程与统计命令在其中按连接名称查找队列
+// 应用需在返回前注册好任务处理器
+func SetQueueSource(source func() *flowqueue.QueueManager) {
+	queueSource = source
+}
+
+// resolveQueue 按连接名称查找队列，default 在未注册同名队列时使用默认队列
+func resolveQueue(connection string) (flowqueue.Queue, error) {
+	if queueSource == nil {
+		return nil, fmt.Errorf("未设置队列来源，请在应用中调用 commands.SetQueueSource")
+	}
+	manager := queueSource()
+	if manager == nil {
+		return nil, fmt.Errorf("队列来源未返回队列管理器")
+	}
+	if manager.HasQueue(connection) || connection != "default" {
+		return manager.GetQueue(connection)
+	}
+	return manager.GetDefaultQueue()
+}
+
 // newQueueWorkCommand 创建队列工作命令
 func newQueueWorkCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -68,13 +98,16 @@ func newQueueWorkCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringP("connection", "c", "default", "队列连接名称")
-	cmd.Flags().StringP("queue", "q", "", "要处理的队列名称")
+	cmd.Flags().StringP("queue", "q", "", "要处理的队列及权重，如 critical:3,default:2,bulk:1")
+	cmd.Flags().Bool("steal", false, "允许队列借用其他空闲队列的并发额度")
+	cmd.Flags().Duration("aging", 0, "队列超过该时长未被调度时优先调度，0表示关闭")
 	cmd.Flags().IntP("tries", "t", 3, "任务最大尝试次数")
 	cmd.Flags().IntP("memory", "m", 128, "内存限制(MB)，超过此值将重启工作进程")
 	cmd.Flags().IntP("timeout", "", 60, "任务执行超时时间(秒)")
 	cmd.Flags().IntP("sleep", "s", 3, "队列为空时的睡眠时间(秒)")
 	cmd.Flags().BoolP("daemon", "d", false, "作为守护进程运行")
 	cmd.Flags().BoolP("force", "f", false, "即使队列中有任务在执行中也强制启动")
+	cmd.Flags().Duration("stats-interval", time.Minute, "输出队列统计信息的间隔，0表示只在退出时输出")
 
 	return cmd
 }
@@ -112,11 +145,58 @@ func newQueueFailedCommand() *cobra.Command {
 	cmd.Flags().StringP("queue", "q", "", "要查看的队列名称")
 	cmd.Flags().StringP("error", "e", "", "按错误信息筛选")
 	cmd.Flags().IntP("limit", "l", 25, "显示的最大任务数量")
-	cmd.Flags().BoolP("full", "f", false, "显示完整的任务信息")
+	cmd.Flags().BoolP("full", "f", false, "显示完整的任务信息，受保护的负载只显示保护策略与密钥ID")
+	cmd.Flags().Bool("reveal", false, "解密受保护的负载，需要通过 --token 提供具有 "+revealScope+" 权限的访问令牌")
+	cmd.Flags().String("token", "", "查看受保护负载使用的访问令牌")
+	cmd.Flags().String("config", "./config", "配置文件目录")
 
 	return cmd
 }
 
+// revealScope 查看受保护任务负载需要的令牌权限
+const revealScope = "queue:reveal"
+
+// failedJobInspector 创建失败任务查看器，--reveal 时加载应用密钥，并以访问令牌的权限作为第二道检查
+func failedJobInspector(cmd *cobra.Command) (*flowqueue.Inspector, func(), error) {
+	if reveal, _ := cmd.Flags().GetBool("reveal"); !reveal {
+		return flowqueue.NewInspector(nil, nil), func() {}, nil
+	}
+	token, _ := cmd.Flags().GetString("token")
+	if token == "" {
+		return nil, nil, fmt.Errorf("--reveal 需要通过 --token 提供具有 %s 权限的访问令牌", revealScope)
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	cm := config.NewConfigManager(config.WithConfigPath(configPath))
+	if err := cm.Load(); err != nil {
+		return nil, nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	appKeys, err := crypto.LoadKeys(cm)
+	if err != nil {
+		return nil, nil, err
+	}
+	payloadKeys, err := flowqueue.NewPayloadKeys(appKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	manager, closeDB, err := openTokenManager(cm, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authorize := func(ctx context.Context, job *flowqueue.Job) error {
+		t, err := manager.Authenticate(ctx, token)
+		if err != nil {
+			return err
+		}
+		if !t.HasScope(revealScope) {
+			return fmt.Errorf("令牌缺少 %s 权限", revealScope)
+		}
+		return nil
+	}
+	return flowqueue.NewInspector(payloadKeys, authorize), closeDB, nil
+}
+
 // newQueueRetryCommand 创建重试任务命令
 func newQueueRetryCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -162,8 +242,7 @@ func newQueueStatsCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringP("connection", "c", "default", "队列连接名称")
-	cmd.Flags().StringP("queue", "q", "", "队列名称")
-	cmd.Flags().StringP("period", "p", "hour", "统计周期 (hour, day, week, month)")
+	cmd.Flags().StringP("queue", "q", "", "队列名称，多个队列用逗号分隔，默认default")
 	cmd.Flags().BoolP("live", "l", false, "实时更新统计信息")
 	cmd.Flags().StringP("format", "", "table", "输出格式 (table, json)")
 
@@ -180,23 +259,44 @@ func runQueueWorker(cmd *cobra.Command, args []string) {
 	sleep, _ := cmd.Flags().GetInt("sleep")
 	daemon, _ := cmd.Flags().GetBool("daemon")
 	force, _ := cmd.Flags().GetBool("force")
+	steal, _ := cmd.Flags().GetBool("steal")
+	aging, _ := cmd.Flags().GetDuration("aging")
+	statsInterval, _ := cmd.Flags().GetDuration("stats-interval")
+
+	// 解析队列规格
+	if queue == "" {
+		queue = "default"
+	}
+	specs, err := flowqueue.ParseQueueSpec(queue)
+	if err != nil {
+		cli.PrintError("%v", err)
+		return
+	}
+
+	q, err := resolveQueue(connection)
+	if err != nil {
+		cli.PrintError("%v", err)
+		return
+	}
 
 	// 将force变量用于日志记录
 	if force {
 		cli.PrintInfo("强制模式已启用")
 	}
 
-	// 构建队列工作进程的描述
-	description := fmt.Sprintf("队列工作进程 (连接: %s", connection)
-	if queue != "" {
-		description += fmt.Sprintf(", 队列: %s", queue)
-	}
-	description += ")"
-
 	// 显示启动信息
-	cli.PrintInfo("启动%s", description)
+	cli.PrintInfo("启动队列工作进程 (连接: %s, 队列: %s)", connection, queue)
 	cli.PrintInfo("最大尝试次数: %d, 超时: %d秒, 休眠间隔: %d秒", tries, timeout, sleep)
 	cli.PrintInfo("内存限制: %dMB", memoryLimit)
+	for _, spec := range specs {
+		cli.PrintInfo("队列 %s: 权重/最大并发 %d", spec.Name, spec.Weight)
+	}
+	if steal {
+		cli.PrintInfo("已启用并发额度借用")
+	}
+	if aging > 0 {
+		cli.PrintInfo("饥饿保护阈值: %v", aging)
+	}
 
 	if daemon {
 		cli.PrintInfo("作为守护进程运行")
@@ -206,65 +306,57 @@ func runQueueWorker(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	// 在实际应用中，此处应该连接到实际的队列系统并开始处理任务
-	// 以下是一个示例实现，模拟处理任务
+	worker, err := flowqueue.NewWorker(q, specs,
+		flowqueue.WithWorkStealing(steal),
+		flowqueue.WithAging(aging),
+		flowqueue.WithPollInterval(time.Duration(sleep)*time.Second),
+	)
+	if err != nil {
+		cli.PrintError("%v", err)
+		return
+	}
 
 	// 监听中断信号以便优雅退出
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-
-	// 模拟任务处理
-	go func() {
-		jobCounter := 0
-		for {
-			// 模拟从队列获取任务
-			time.Sleep(time.Duration(2+jobCounter%3) * time.Second)
-			jobCounter++
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-			// 打印任务处理信息
-			jobType := []string{"EmailJob", "ImageProcessJob", "ReportGenerationJob"}[jobCounter%3]
-			jobId := fmt.Sprintf("job-%d", jobCounter)
-
-			cli.PrintInfo("处理任务: %s [%s]", jobId, jobType)
-
-			// 模拟任务处理
-			processingTime := time.Duration((500+jobCounter*200)%2000) * time.Millisecond
-			time.Sleep(processingTime)
-
-			// 根据任务ID决定任务是否成功
-			if jobCounter%7 == 0 {
-				cli.PrintError("任务失败: %s (尝试: %d/%d) - 数据库连接错误", jobId, 1, tries)
-			} else {
-				cli.PrintSuccess("任务完成: %s (耗时: %v)", jobId, processingTime)
-			}
-
-			// 每5个任务显示一次队列统计
-			if jobCounter%5 == 0 {
-				waiting := 8 - jobCounter%7
-				if waiting < 0 {
-					waiting = 0
-				}
-				fmt.Println()
-				cli.PrintInfo("队列状态: %d 个等待中, %d 个处理中, %d 个已完成, %d 个失败",
-					waiting, 1, jobCounter-jobCounter/7, jobCounter/7)
-				fmt.Println()
-			}
+	if err := worker.Start(ctx); err != nil {
+		cli.PrintError("%v", err)
+		return
+	}
+	cli.PrintSuccess("工作进程已启动，按Ctrl+C停止")
 
-			// 模拟队列空
-			if jobCounter%10 == 9 {
-				cli.PrintInfo("队列为空，等待 %d 秒...", sleep)
-				time.Sleep(time.Duration(sleep) * time.Second)
-			}
+	// 定期输出各队列的统计信息
+	var ticks <-chan time.Time
+	if statsInterval > 0 {
+		ticker := time.NewTicker(statsInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	for {
+		select {
+		case <-ticks:
+			fmt.Println()
+			printWorkerStats(worker.Stats(ctx))
+		case <-ctx.Done():
+			cli.PrintInfo("正在关闭工作进程，等待正在执行的任务完成...")
+			worker.Stop()
+			printWorkerStats(worker.Stats(context.Background()))
+			cli.PrintSuccess("工作进程已停止")
+			return
 		}
-	}()
-
-	cli.PrintSuccess("工作进程已启动，按Ctrl+C停止")
+	}
+}
 
-	// 等待终止信号
-	<-quit
-	cli.PrintInfo("正在关闭工作进程...")
-	time.Sleep(500 * time.Millisecond) // 给处理中的任务一点时间完成
-	cli.PrintSuccess("工作进程已停止")
+// printWorkerStats 以表格形式打印各队列的统计信息
+func printWorkerStats(stats []flowqueue.QueueStats) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "队列\t权重\t等待中\t处理中\t已处理\t失败\t吞吐量(任务/秒)")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%.2f\n",
+			s.Name, s.Weight, s.Depth, s.Running, s.Processed, s.Failed, s.Throughput)
+	}
+	w.Flush()
 }
 
 // listQueueJobs 列出队列任务
@@ -342,6 +434,15 @@ func listFailedJobs(cmd *cobra.Command, args []string) {
 	errorFilter, _ := cmd.Flags().GetString("error")
 	limit, _ := cmd.Flags().GetInt("limit")
 	full, _ := cmd.Flags().GetBool("full")
+	reveal, _ := cmd.Flags().GetBool("reveal")
+	full = full || reveal
+
+	inspector, closeInspector, err := failedJobInspector(cmd)
+	if err != nil {
+		cli.PrintError("%v", err)
+		return
+	}
+	defer closeInspector()
 
 	// 显示查询信息
 	queueInfo := ""
@@ -400,7 +501,7 @@ func listFailedJobs(cmd *cobra.Command, args []string) {
 		// 如果启用了完整模式，显示任务详情
 		if full {
 			fmt.Printf("  完整错误: %s\n", job.Error)
-			fmt.Printf("  Payload: %s\n", job.Payload)
+			fmt.Printf("  Payload: %s\n", failedJobPayload(cmd.Context(), inspector, job, reveal))
 			fmt.Println()
 		}
 	}
@@ -410,6 +511,31 @@ func listFailedJobs(cmd *cobra.Command, args []string) {
 	cli.PrintInfo("使用 'flow queue retry <id>' 重试特定任务或 'flow queue retry --all' 重试所有失败的任务")
 }
 
+// failedJobPayload 返回失败任务负载的展示内容，未指定 reveal 时受保护的负载只显示保护策略与密钥ID
+func failedJobPayload(ctx context.Context, inspector *flowqueue.Inspector, job queueJob, reveal bool) string {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	stored := &flowqueue.Job{ID: job.ID, Queue: job.Queue, Name: job.Type}
+	if err := json.Unmarshal([]byte(job.Payload), &stored.Payload); err != nil {
+		return "[无法解析的负载]"
+	}
+
+	view := inspector.View(stored)
+	if view.Masked() {
+		if !reveal {
+			return fmt.Sprintf("[受保护: %s, 密钥 %s，使用 --reveal 查看]", view.Protection, view.KeyID)
+		}
+		revealed, err := inspector.Reveal(ctx, stored)
+		if err != nil {
+			return fmt.Sprintf("[受保护: %s, 无法查看: %v]", view.Protection, err)
+		}
+		view = revealed
+	}
+	data, _ := json.Marshal(view.Payload)
+	return string(data)
+}
+
 // retryFailedJobs 重试失败的任务
 func retryFailedJobs(cmd *cobra.Command, args []string) {
 	connection, _ := cmd.Flags().GetString("connection")
@@ -523,31 +649,41 @@ func clearQueueJobs(cmd *cobra.Command, args []string) {
 func showQueueStats(cmd *cobra.Command, args []string) {
 	connection, _ := cmd.Flags().GetString("connection")
 	queue, _ := cmd.Flags().GetString("queue")
-	period, _ := cmd.Flags().GetString("period")
 	live, _ := cmd.Flags().GetBool("live")
 	format, _ := cmd.Flags().GetString("format")
 
-	// 构建描述
-	queueDesc := "所有队列"
-	if queue != "" {
-		queueDesc = fmt.Sprintf("队列 '%s'", queue)
+	if queue == "" {
+		queue = "default"
+	}
+	specs, err := flowqueue.ParseQueueSpec(queue)
+	if err != nil {
+		cli.PrintError("%v", err)
+		return
+	}
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
 	}
 
-	// 显示查询信息
-	cli.PrintInfo("获取%s的统计信息 (连接: %s, 周期: %s)", queueDesc, connection, period)
-
-	// 在实际应用中，此处应该查询实际的队列系统
-	// 以下是一个示例实现，返回模拟的统计信息
+	q, err := resolveQueue(connection)
+	if err != nil {
+		cli.PrintError("%v", err)
+		return
+	}
 
-	printQueueStats(queue, connection, format)
+	cli.PrintInfo("获取队列 %s 的统计信息 (连接: %s)", strings.Join(names, ", "), connection)
+	if err := printQueueStats(context.Background(), q, names, format); err != nil {
+		cli.PrintError("%v", err)
+		return
+	}
 
 	// 如果是实时模式，每3秒更新一次统计信息
 	if live {
 		cli.PrintInfo("实时统计模式已启动，按Ctrl+C退出")
 
 		// 监听中断信号以便优雅退出
-		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
 		ticker := time.NewTicker(3 * time.Second)
 		defer ticker.Stop()
@@ -559,17 +695,19 @@ func showQueueStats(cmd *cobra.Command, args []string) {
 				fmt.Print("\033[H\033[2J")
 
 				// 重新显示标题
-				cli.PrintInfo("获取%s的实时统计信息 (连接: %s, 周期: %s)", queueDesc, connection, period)
+				cli.PrintInfo("获取队列 %s 的实时统计信息 (连接: %s)", strings.Join(names, ", "), connection)
 				cli.PrintInfo("每3秒自动更新，按Ctrl+C退出")
 				fmt.Println()
 
 				// 显示最新统计
-				printQueueStats(queue, connection, format)
+				if err := printQueueStats(ctx, q, names, format); err != nil {
+					cli.PrintError("%v", err)
+				}
 
 				// 显示更新时间
 				fmt.Printf("\n最后更新: %s\n", time.Now().Format("15:04:05"))
 
-			case <-quit:
+			case <-ctx.Done():
 				fmt.Println()
 				cli.PrintInfo("实时统计已停止")
 				return
@@ -669,102 +807,27 @@ func generateSampleJobs(count int, status string, queue string) []queueJob {
 	return jobs
 }
 
-// 打印队列统计信息
-func printQueueStats(queue, connection, format string) {
-	// 模拟统计数据
-	stats := struct {
-		Waiting    int
-		Reserved   int
-		Failed     int
-		Completed  int
-		Total      int
-		AvgTime    float64
-		ErrorRate  float64
-		Throughput int
-	}{
-		Waiting:    12 + rand.Intn(5),
-		Reserved:   2 + rand.Intn(3),
-		Failed:     8 + rand.Intn(4),
-		Completed:  120 + rand.Intn(20),
-		AvgTime:    234.5 + float64(rand.Intn(100))/10.0,
-		ErrorRate:  5.8 + float64(rand.Intn(30))/10.0,
-		Throughput: 42 + rand.Intn(8),
-	}
-	stats.Total = stats.Waiting + stats.Reserved + stats.Failed + stats.Completed
-
-	// 按队列划分的细节
-	queueDetails := map[string]struct {
-		Waiting   int
-		Reserved  int
-		Failed    int
-		Completed int
-		AvgTime   float64
-	}{
-		"default": {
-			Waiting:   5 + rand.Intn(3),
-			Reserved:  1,
-			Failed:    3 + rand.Intn(2),
-			Completed: 60 + rand.Intn(10),
-			AvgTime:   215.3 + float64(rand.Intn(50))/10.0,
-		},
-		"emails": {
-			Waiting:   4 + rand.Intn(2),
-			Reserved:  0,
-			Failed:    2 + rand.Intn(2),
-			Completed: 35 + rand.Intn(8),
-			AvgTime:   189.7 + float64(rand.Intn(40))/10.0,
-		},
-		"processing": {
-			Waiting:   3 + rand.Intn(3),
-			Reserved:  1,
-			Failed:    3 + rand.Intn(1),
-			Completed: 25 + rand.Intn(6),
-			AvgTime:   312.5 + float64(rand.Intn(80))/10.0,
-		},
-	}
-
-	// 打印总体统计
-	fmt.Println("队列统计概览:")
-	fmt.Println("---------------------")
-	fmt.Printf("总任务数: %d\n", stats.Total)
-	fmt.Printf("等待中: %d\n", stats.Waiting)
-	fmt.Printf("处理中: %d\n", stats.Reserved)
-	fmt.Printf("已完成: %d\n", stats.Completed)
-	fmt.Printf("失败数: %d\n", stats.Failed)
-	fmt.Printf("错误率: %.1f%%\n", stats.ErrorRate)
-	fmt.Printf("平均处理时间: %.1f ms\n", stats.AvgTime)
-	fmt.Printf("每分钟处理量: %d 任务/分钟\n", stats.Throughput)
-
-	// 如果没有指定特定队列，显示队列明细
-	if queue == "" {
-		fmt.Println("\n按队列统计:")
-		fmt.Println("队列名称\t等待中\t处理中\t已完成\t失败\t平均时间(ms)")
-		fmt.Println("--------\t------\t------\t------\t----\t-----------")
-
-		for qName, qStat := range queueDetails {
-			fmt.Printf("%s\t%d\t%d\t%d\t%d\t%.1f\n",
-				qName,
-				qStat.Waiting,
-				qStat.Reserved,
-				qStat.Completed,
-				qStat.Failed,
-				qStat.AvgTime,
-			)
+// printQueueStats 打印各队列的长度
+// 处理量与吞吐量只能由工作进程统计，见 queue work 的 --stats-interval
+func printQueueStats(ctx context.Context, q flowqueue.Queue, names []string, format string) error {
+	stats, err := flowqueue.NewInspector(nil, nil).Stats(ctx, q, names, nil)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
 		}
+		fmt.Println(string(data))
+		return nil
 	}
 
-	// 按任务类型统计
-	fmt.Println("\n按任务类型统计:")
-	fmt.Println("任务类型\t\t\t总数\t成功率\t平均时间(ms)")
-	fmt.Println("--------\t\t\t----\t------\t-----------")
-	fmt.Printf("SendEmailJob\t\t\t%d\t%.1f%%\t%.1f\n",
-		42+rand.Intn(10), 94.5+float64(rand.Intn(50))/10.0, 186.2+float64(rand.Intn(40)))
-	fmt.Printf("ProcessPaymentJob\t\t%d\t%.1f%%\t%.1f\n",
-		28+rand.Intn(8), 92.3+float64(rand.Intn(70))/10.0, 234.5+float64(rand.Intn(50)))
-	fmt.Printf("GenerateReportJob\t\t%d\t%.1f%%\t%.1f\n",
-		18+rand.Intn(5), 98.1+float64(rand.Intn(20))/10.0, 345.7+float64(rand.Intn(100)))
-	fmt.Printf("ImageProcessingJob\t\t%d\t%.1f%%\t%.1f\n",
-		35+rand.Intn(10), 91.2+float64(rand.Intn(50))/10.0, 512.8+float64(rand.Intn(150)))
-	fmt.Printf("NotificationJob\t\t\t%d\t%.1f%%\t%.1f\n",
-		52+rand.Intn(15), 99.2+float64(rand.Intn(10))/10.0, 156.3+float64(rand.Intn(30)))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "队列\t等待中")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%d\n", s.Name, s.Depth)
+	}
+	return w.Flush()
 }
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	flowqueue "github.com/zzliekkas/flow/v2/queue"
+	"github.com/zzliekkas/flow/v2/queue/memory"
+)
+
+func TestResolveQueue(t *testing.T) {
+	_, err := resolveQueue("default")
+	assert.Error(t, err, "未设置队列来源时报错")
+
+	manager := flowqueue.NewQueueManager()
+	primary, bulk := memory.New(3), memory.New(3)
+	require.NoError(t, manager.AddQueue("primary", primary))
+	require.NoError(t, manager.AddQueue("bulk", bulk))
+	require.NoError(t, manager.SetDefaultQueue("primary"))
+	SetQueueSource(func() *flowqueue.QueueManager { return manager })
+	t.Cleanup(func() { SetQueueSource(nil) })
+
+	q, err := resolveQueue("default")
+	require.NoError(t, err)
+	assert.Same(t, primary, q)
+
+	q, err = resolveQueue("bulk")
+	require.NoError(t, err)
+	assert.Same(t, bulk, q)
+
+	_, err = resolveQueue("missing")
+	assert.ErrorIs(t, err, flowqueue.ErrQueueNotFound)
+
+	_, err = bulk.Push(context.Background(), "emails", "send", nil)
+	require.NoError(t, err)
+	assert.NoError(t, printQueueStats(context.Background(), bulk, []string{"emails"}, "json"))
+}
@@ -6,6 +6,7 @@ import "github.com/zzliekkas/flow/v2/cli"
 func RegisterCommands(app *cli.App) {
 	// 服务器命令
 	app.AddCommand(NewServerCommand())
+	app.AddCommand(NewRestartCommand())
 
 	// 数据库命令
 	app.AddCommand(NewDBCommand())
@@ -31,6 +32,40 @@ func RegisterCommands(app *cli.App) {
 	// 存储命令
 	app.AddCommand(NewStorageCommand())
 
+	// 性能分析命令
+	app.AddCommand(NewProfileCommand())
+
+	// 诊断命令
+	app.AddCommand(NewDiagnosticsCommand())
+
+	// 启动诊断命令
+	app.AddCommand(NewDoctorCommand())
+
+	// 备份命令
+	app.AddCommand(NewBackupCommand())
+
+	// 维护模式命令
+	app.AddCommand(NewDownCommand())
+	app.AddCommand(NewUpCommand())
+
+	// 应用密钥命令
+	app.AddCommand(NewKeyGenerateCommand())
+
+	// 搜索命令
+	app.AddCommand(NewSearchCommand())
+
+	// 访问令牌命令
+	app.AddCommand(NewTokenCommand())
+
+	// 模拟服务命令
+	app.AddCommand(NewMockCommand())
+
+	// 请求重放命令
+	app.AddCommand(NewReplayCommand())
+
+	// 个人数据清除命令
+	app.AddCommand(NewPrivacyCommand())
+
 	// 可以在此处添加更多命令
 	// app.AddCommand(NewStorageCommand())
 	// 等等...
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2/middleware"
+)
+
+// errReplayDiff 重放的响应与捕获的响应不一致，命令以非零状态退出
+var errReplayDiff = errors.New("重放的响应与记录不一致")
+
+// replayHandler 进程内重放使用的处理器，由应用通过SetReplayHandler注入
+var replayHandler http.Handler
+
+// SetReplayHandler 设置进程内重放使用的处理器，通常传入 *flow.Engine
+func SetReplayHandler(handler http.Handler) {
+	replayHandler = handler
+}
+
+// NewReplayCommand 创建请求重放命令
+func NewReplayCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay <file>",
+		Short: "重放 middleware.Capture 捕获的请求并与记录的响应比较",
+		Long: `读取 middleware.Capture 保存的捕获文件，向本地实例重新发送请求，输出响应并与记录的响应比较。
+重放的请求携带 X-Flow-Replay 请求头，有副作用的处理函数可据此跳过；已脱敏的请求头不会发送，用 --override-header 补充。
+--dry-run 不经过网络，直接在进程内由 SetReplayHandler 注入的引擎处理。`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, _ := cmd.Flags().GetString("target")
+			overrides, _ := cmd.Flags().GetStringArray("override-header")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			return runReplay(cmd, args[0], target, overrides, dryRun)
+		},
+	}
+
+	cmd.Flags().String("target", "http://localhost:8080", "目标地址")
+	cmd.Flags().StringArray("override-header", nil, "覆盖请求头，格式 名称=值，可重复")
+	cmd.Flags().Bool("dry-run", false, "在进程内重放，不经过网络")
+	return cmd
+}
+
+// runReplay 重放捕获文件并输出结果，响应不一致时返回 errReplayDiff
+func runReplay(cmd *cobra.Command, path, target string, overrides []string, dryRun bool) error {
+	record, err := middleware.ReadCaptureFile(path)
+	if err != nil {
+		return err
+	}
+
+	opts := middleware.ReplayOptions{Target: target, Headers: http.Header{}}
+	for _, override := range overrides {
+		name, value, ok := strings.Cut(override, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("无效的请求头覆盖: %s，格式为 名称=值", override)
+		}
+		opts.Headers.Add(strings.TrimSpace(name), value)
+	}
+	if dryRun {
+		if replayHandler == nil {
+			return errors.New("未设置进程内处理器，请在应用中调用 commands.SetReplayHandler(engine)")
+		}
+		opts.Handler = replayHandler
+	}
+
+	result, err := middleware.Replay(cmd.Context(), record, opts)
+	if err != nil {
+		return fmt.Errorf("重放失败: %w", err)
+	}
+	printReplayResult(cmd.OutOrStdout(), record, result)
+	if len(result.Diff) > 0 {
+		return fmt.Errorf("%w: %d 处差异", errReplayDiff, len(result.Diff))
+	}
+	return nil
+}
+
+// printReplayResult 输出重放的响应与差异
+func printReplayResult(out io.Writer, record *middleware.CaptureRecord, result *middleware.ReplayResult) {
+	fmt.Fprintf(out, "%s %s (捕获于 %s，ID %s)\n", record.Request.Method, record.Request.Path,
+		record.CapturedAt.Format("2006-01-02 15:04:05"), record.ID)
+	fmt.Fprintf(out, "HTTP %d · %s\n", result.Status, result.Latency.Round(time.Microsecond))
+
+	names := make([]string, 0, len(result.Header))
+	for name := range result.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "%s: %s\n", name, strings.Join(result.Header[name], ", "))
+	}
+	fmt.Fprintf(out, "\n%s\n\n", result.Body)
+
+	if len(result.Diff) == 0 {
+		fmt.Fprintln(out, "✓ 与记录的响应一致")
+		return
+	}
+	fmt.Fprintf(out, "与记录的响应有 %d 处差异:\n", len(result.Diff))
+	for _, diff := range result.Diff {
+		fmt.Fprintf(out, "  ✗ %s\n", diff)
+	}
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/middleware"
+)
+
+// executeReplay 执行 replay 命令，返回输出与错误
+func executeReplay(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewReplayCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestReplay_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, middleware.NewDirCaptureSink(dir).WriteCapture(context.Background(), &middleware.CaptureRecord{
+		Version:    middleware.CaptureFormatVersion,
+		ID:         "req-1",
+		CapturedAt: time.Now(),
+		Request:    middleware.CapturedRequest{Method: http.MethodGet, Path: "/users/7", Header: http.Header{"Authorization": {"***MASKED***"}}},
+		Response:   middleware.CapturedResponse{Status: http.StatusOK, Header: http.Header{"Content-Type": {"application/json; charset=utf-8"}}, Body: `{"id":"7"}`},
+	}))
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.Len(t, files, 1)
+
+	e := flow.New(flow.WithMode("test"))
+	e.GET("/users/:id", func(c *flow.Context) {
+		if c.GetHeader("Authorization") != "Bearer local" || !middleware.IsReplay(c.Request) {
+			c.JSON(http.StatusUnauthorized, flow.H{"error": "unauthorized"})
+			return
+		}
+		c.JSON(http.StatusOK, flow.H{"id": c.Param("id")})
+	})
+	SetReplayHandler(e)
+	t.Cleanup(func() { SetReplayHandler(nil) })
+
+	out, err := executeReplay(t, files[0], "--dry-run", "--override-header", "Authorization=Bearer local")
+	require.NoError(t, err, out)
+	assert.Contains(t, out, "HTTP 200")
+	assert.Contains(t, out, "与记录的响应一致")
+
+	// 未覆盖已脱敏的请求头时响应不同，以非零状态退出
+	out, err = executeReplay(t, files[0], "--dry-run")
+	assert.ErrorIs(t, err, errReplayDiff)
+	assert.Contains(t, out, "状态码: 记录 200，重放 401")
+
+	// 未设置进程内处理器
+	SetReplayHandler(nil)
+	_, err = executeReplay(t, files[0], "--dry-run")
+	assert.Error(t, err)
+
+	// 向目标地址重放
+	server := httptest.NewServer(e)
+	defer server.Close()
+	out, err = executeReplay(t, files[0], "--target", server.URL, "--override-header", "Authorization=Bearer local")
+	require.NoError(t, err, out)
+
+	_, err = executeReplay(t, files[0], "--override-header", "invalid")
+	assert.True(t, err != nil && strings.Contains(err.Error(), "无效的请求头覆盖"))
+}
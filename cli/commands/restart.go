@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/cli"
+)
+
+// restartPollInterval 等待新进程写入PID文件的检查间隔
+const restartPollInterval = 200 * time.Millisecond
+
+// NewRestartCommand 创建平滑重启命令
+func NewRestartCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restart",
+		Short: "平滑重启正在运行的应用",
+		Long: `向PID文件中的进程发送 SIGUSR2，应用需要启用 flow.WithGracefulRestart 与 flow.WithPIDFile。
+新进程接管监听器并就绪后旧进程处理完进行中的请求再退出，期间不中断连接。仅支持 Linux 等 Unix 系统。`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pidFile, _ := cmd.Flags().GetString("pid-file")
+			wait, _ := cmd.Flags().GetDuration("wait")
+
+			pid, err := restartApp(pidFile, wait, restartPollInterval)
+			if err != nil {
+				cli.PrintError("%v", err)
+				return err
+			}
+			if wait <= 0 {
+				cli.PrintSuccess("已向进程 %d 发送平滑重启信号", pid)
+				return nil
+			}
+			cli.PrintSuccess("平滑重启完成，新进程: %d", pid)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("pid-file", "flow.pid", "应用的PID文件")
+	cmd.Flags().Duration("wait", time.Minute, "等待新进程就绪的最长时间，为0时发送信号后立即返回")
+
+	return cmd
+}
+
+// restartApp 发送平滑重启信号，wait 大于0时等待PID文件更新为新进程，返回新进程（不等待时为旧进程）的ID
+func restartApp(pidFile string, wait, interval time.Duration) (int, error) {
+	pid, err := flow.ReadPIDFile(pidFile)
+	if err != nil {
+		return 0, fmt.Errorf("读取PID文件失败: %w", err)
+	}
+	if err := flow.SignalRestart(pid); err != nil {
+		if errors.Is(err, flow.ErrRestartUnsupported) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("向进程 %d 发送信号失败: %w", pid, err)
+	}
+	if wait <= 0 {
+		return pid, nil
+	}
+
+	cli.PrintInfo("已向进程 %d 发送平滑重启信号，等待新进程就绪...", pid)
+	deadline := time.Now().Add(wait)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+		if current, err := flow.ReadPIDFile(pidFile); err == nil && current != pid {
+			return current, nil
+		}
+	}
+	return 0, fmt.Errorf("%s 内PID文件未更新，平滑重启可能失败，请检查应用日志", wait)
+}
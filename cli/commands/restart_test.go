@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartApp(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "app.pid")
+	_, err := restartApp(pidFile, 0, time.Millisecond)
+	assert.ErrorContains(t, err, "读取PID文件失败")
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// 收到信号的进程（这里用 sleep 代替应用）不会更新PID文件
+	old := exec.Command("sleep", "10")
+	require.NoError(t, old.Start())
+	defer old.Process.Kill()
+	require.NoError(t, os.WriteFile(pidFile, []byte(strconv.Itoa(old.Process.Pid)), 0o644))
+	_, err = restartApp(pidFile, 50*time.Millisecond, 10*time.Millisecond)
+	assert.ErrorContains(t, err, "PID文件未更新")
+	old.Wait()
+
+	// 新进程就绪后写入自己的PID
+	old = exec.Command("sleep", "10")
+	require.NoError(t, old.Start())
+	defer old.Process.Kill()
+	require.NoError(t, os.WriteFile(pidFile, []byte(strconv.Itoa(old.Process.Pid)), 0o644))
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		os.WriteFile(pidFile, []byte("12345\n"), 0o644)
+	}()
+	pid, err := restartApp(pidFile, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 12345, pid)
+}
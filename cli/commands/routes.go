@@ -8,9 +8,18 @@ import (
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2"
 	"github.com/zzliekkas/flow/v2/cli"
 )
 
+// routeSource 路由信息来源，由应用通过SetRouteSource注入
+var routeSource func() []flow.RouteInfo
+
+// SetRouteSource 设置路由信息来源，通常传入 engine.RouteList
+func SetRouteSource(source func() []flow.RouteInfo) {
+	routeSource = source
+}
+
 // NewRoutesCommand 创建路由列表命令
 func NewRoutesCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -25,6 +34,7 @@ func NewRoutesCommand() *cobra.Command {
 	cmd.Flags().StringP("method", "m", "", "按HTTP方法筛选 (GET, POST, PUT, DELETE等)")
 	cmd.Flags().StringP("path", "p", "", "按路径筛选 (支持部分匹配)")
 	cmd.Flags().BoolP("verbose", "v", false, "显示详细信息，包括中间件")
+	cmd.Flags().Bool("middleware", false, "显示每个路由实际生效的中间件链（按执行顺序）")
 	cmd.Flags().BoolP("reverse", "r", false, "反向排序")
 
 	return cmd
@@ -32,10 +42,13 @@ func NewRoutesCommand() *cobra.Command {
 
 // 路由信息结构体
 type routeInfo struct {
+	Host       string
 	Method     string
 	Path       string
 	Handler    string
 	Middleware []string
+	Skipped    []string
+	Attrs      map[string]interface{}
 }
 
 // listRoutes 列出所有路由
@@ -44,6 +57,7 @@ func listRoutes(cmd *cobra.Command, args []string) {
 	methodFilter, _ := cmd.Flags().GetString("method")
 	pathFilter, _ := cmd.Flags().GetString("path")
 	verbose, _ := cmd.Flags().GetBool("verbose")
+	showMiddleware, _ := cmd.Flags().GetBool("middleware")
 	reverse, _ := cmd.Flags().GetBool("reverse")
 
 	// 收集路由信息
@@ -64,6 +78,9 @@ func listRoutes(cmd *cobra.Command, args []string) {
 	// 排序
 	sort.Slice(filteredRoutes, func(i, j int) bool {
 		if filteredRoutes[i].Path == filteredRoutes[j].Path {
+			if filteredRoutes[i].Method == filteredRoutes[j].Method {
+				return filteredRoutes[i].Host < filteredRoutes[j].Host
+			}
 			return filteredRoutes[i].Method < filteredRoutes[j].Method
 		}
 		result := filteredRoutes[i].Path < filteredRoutes[j].Path
@@ -83,33 +100,95 @@ func listRoutes(cmd *cobra.Command, args []string) {
 
 	// 使用tabwriter格式化输出
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
-	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER")
-	fmt.Fprintln(w, "------\t----\t-------")
+	// 存在绑定主机的路由时增加主机列，默认主机显示为 -
+	hasHost := false
+	for _, route := range filteredRoutes {
+		hasHost = hasHost || route.Host != ""
+	}
+	if hasHost {
+		fmt.Fprintln(w, "HOST\tMETHOD\tPATH\tHANDLER")
+		fmt.Fprintln(w, "----\t------\t----\t-------")
+	} else {
+		fmt.Fprintln(w, "METHOD\tPATH\tHANDLER")
+		fmt.Fprintln(w, "------\t----\t-------")
+	}
 
 	for _, route := range filteredRoutes {
+		if hasHost {
+			host := route.Host
+			if host == "" {
+				host = "-"
+			}
+			fmt.Fprintf(w, "%s\t", host)
+		}
 		fmt.Fprintf(w, "%s\t%s\t%s\n", route.Method, route.Path, route.Handler)
 
 		// 显示中间件（如果启用详细模式）
-		if verbose && len(route.Middleware) > 0 {
-			fmt.Fprintf(w, "\t└── 中间件: %s\n", strings.Join(route.Middleware, ", "))
+		if (verbose || showMiddleware) && len(route.Middleware) > 0 {
+			fmt.Fprintf(w, "\t└── 中间件: %s\n", strings.Join(effectiveMiddleware(route), " → "))
+		}
+		if showMiddleware && len(route.Skipped) > 0 {
+			fmt.Fprintf(w, "\t└── 已跳过: %s\n", strings.Join(route.Skipped, ", "))
+		}
+		if verbose && len(route.Attrs) > 0 {
+			fmt.Fprintf(w, "\t└── 属性: %s\n", formatAttrs(route.Attrs))
 		}
 	}
 	w.Flush()
 }
 
 // collectRoutes 收集应用中所有路由信息
-// 注意：这是一个框架占位函数，实际实现需要根据框架路由系统来完成
 func collectRoutes() []routeInfo {
-	// 在实际实现中，这里应该连接到框架的路由收集器
-	// 目前返回一些示例数据用于演示
-	return []routeInfo{
-		{Method: "GET", Path: "/", Handler: "HomeController@index", Middleware: []string{"web", "auth"}},
-		{Method: "GET", Path: "/users", Handler: "UserController@index", Middleware: []string{"web", "auth"}},
-		{Method: "POST", Path: "/users", Handler: "UserController@store", Middleware: []string{"web", "auth", "csrf"}},
-		{Method: "GET", Path: "/users/:id", Handler: "UserController@show", Middleware: []string{"web", "auth"}},
-		{Method: "PUT", Path: "/users/:id", Handler: "UserController@update", Middleware: []string{"web", "auth", "csrf"}},
-		{Method: "DELETE", Path: "/users/:id", Handler: "UserController@destroy", Middleware: []string{"web", "auth", "csrf"}},
-		{Method: "GET", Path: "/api/users", Handler: "Api\\UserController@index", Middleware: []string{"api", "auth:api"}},
-		{Method: "POST", Path: "/api/users", Handler: "Api\\UserController@store", Middleware: []string{"api", "auth:api"}},
+	if routeSource == nil {
+		cli.PrintWarning("未设置路由来源，请在应用中调用 commands.SetRouteSource(engine.RouteList)")
+		return nil
+	}
+
+	source := routeSource()
+	routes := make([]routeInfo, 0, len(source))
+	for _, r := range source {
+		routes = append(routes, routeInfo{
+			Host:       r.Host,
+			Method:     r.Method,
+			Path:       r.Path,
+			Handler:    r.Handler,
+			Middleware: r.Middleware,
+			Skipped:    r.Skipped,
+			Attrs:      r.Attrs,
+		})
+	}
+	return routes
+}
+
+// formatAttrs 按名称排序格式化路由属性
+func formatAttrs(attrs map[string]interface{}) string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%v", name, attrs[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// effectiveMiddleware 返回排除已跳过中间件后的执行顺序
+func effectiveMiddleware(route routeInfo) []string {
+	chain := make([]string, 0, len(route.Middleware))
+	for _, name := range route.Middleware {
+		skipped := false
+		for _, s := range route.Skipped {
+			if s == name {
+				skipped = true
+				break
+			}
+		}
+		if !skipped {
+			chain = append(chain, name)
+		}
 	}
+	return chain
 }
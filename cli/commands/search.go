@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2/cli"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/db"
+	"github.com/zzliekkas/flow/v2/search"
+)
+
+// NewSearchCommand 创建搜索命令
+func NewSearchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search",
+		Short: "搜索索引管理",
+		Long:  `管理全文搜索索引。模型需在应用中通过 search.RegisterModels 注册。`,
+	}
+
+	cmd.PersistentFlags().String("config", "./config", "配置文件目录")
+	cmd.AddCommand(newSearchReindexCommand())
+
+	return cmd
+}
+
+// newSearchReindexCommand 创建重建索引子命令
+func newSearchReindexCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "从数据库重建模型的搜索索引",
+		Long: `按主键顺序分批读取模型并写入搜索引擎。
+中断后可使用输出的最后主键通过 --after 继续。`,
+		RunE: runSearchReindex,
+	}
+
+	cmd.Flags().String("model", "", "模型类型名或索引名，例如 Product")
+	cmd.Flags().Int("chunk", 500, "每批处理的记录数")
+	cmd.Flags().String("after", "", "从主键大于该值的记录开始")
+	cmd.Flags().StringP("connection", "c", "", "指定数据库连接")
+	_ = cmd.MarkFlagRequired("model")
+
+	return cmd
+}
+
+// runSearchReindex 重建搜索索引
+func runSearchReindex(cmd *cobra.Command, args []string) error {
+	name, _ := cmd.Flags().GetString("model")
+	model, err := search.LookupModel(name)
+	if err != nil {
+		return err
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	cm := config.NewConfigManager(config.WithConfigPath(configPath))
+	if err := cm.Load(); err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	var cfg search.Config
+	if err := cm.Unmarshal("search", &cfg); err != nil {
+		return fmt.Errorf("加载搜索配置失败: %w", err)
+	}
+	engine, err := search.NewEngine(cfg)
+	if err != nil {
+		return err
+	}
+
+	databases := db.NewManager()
+	if err := databases.FromConfig(cm); err != nil {
+		return fmt.Errorf("加载数据库配置失败: %w", err)
+	}
+	defer databases.Close()
+	connection, _ := cmd.Flags().GetString("connection")
+	conn, err := databases.Default()
+	if connection != "" {
+		conn, err = databases.Connection(connection)
+	}
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %w", err)
+	}
+
+	chunk, _ := cmd.Flags().GetInt("chunk")
+	after, _ := cmd.Flags().GetString("after")
+	progress, err := search.Reindex(context.Background(), conn, engine, model, search.ReindexOptions{
+		Chunk: chunk,
+		After: after,
+		Progress: func(p search.ReindexProgress) {
+			cli.PrintInfo("已写入 %d 条，最后主键 %s", p.Indexed, p.LastID)
+		},
+	})
+	if err != nil {
+		if progress.LastID != "" {
+			cli.PrintInfo("可使用 --after %s 从中断处继续", progress.LastID)
+		}
+		return fmt.Errorf("重建索引失败: %w", err)
+	}
+
+	cli.PrintSuccess("%s 索引重建完成，共 %d 条", model.SearchIndex(), progress.Indexed)
+	return nil
+}
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zzliekkas/flow/v2/auth/tokens"
+	"github.com/zzliekkas/flow/v2/cli"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/db"
+)
+
+// NewTokenCommand 创建访问令牌命令
+func NewTokenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "个人访问令牌管理",
+		Long:  `签发、列出和撤销供脚本与集成使用的个人访问令牌。`,
+	}
+
+	cmd.PersistentFlags().String("config", "./config", "配置文件目录")
+	cmd.PersistentFlags().StringP("connection", "c", "", "指定数据库连接")
+	cmd.AddCommand(newTokenIssueCommand())
+	cmd.AddCommand(newTokenListCommand())
+	cmd.AddCommand(newTokenRevokeCommand())
+
+	return cmd
+}
+
+// newTokenIssueCommand 创建签发令牌子命令
+func newTokenIssueCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "issue",
+		Short: "签发访问令牌",
+		Long: `为用户签发访问令牌，明文只输出这一次，请立即保存。
+例如: flow token issue --user 42 --scopes read:users`,
+		RunE: runTokenIssue,
+	}
+
+	cmd.Flags().String("user", "", "用户ID")
+	cmd.Flags().String("name", "cli", "令牌名称")
+	cmd.Flags().StringSlice("scopes", nil, "权限范围，多个以逗号分隔")
+	cmd.Flags().Duration("expires", 0, "有效期，例如 720h，默认永不过期")
+	_ = cmd.MarkFlagRequired("user")
+
+	return cmd
+}
+
+// newTokenListCommand 创建列出令牌子命令
+func newTokenListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出用户的访问令牌",
+		RunE:  runTokenList,
+	}
+
+	cmd.Flags().String("user", "", "用户ID")
+	_ = cmd.MarkFlagRequired("user")
+
+	return cmd
+}
+
+// newTokenRevokeCommand 创建撤销令牌子命令
+func newTokenRevokeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke [id]",
+		Short: "撤销访问令牌",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTokenRevoke,
+	}
+}
+
+// runTokenIssue 签发访问令牌
+func runTokenIssue(cmd *cobra.Command, args []string) error {
+	manager, closeDB, err := tokenManager(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	user, _ := cmd.Flags().GetString("user")
+	name, _ := cmd.Flags().GetString("name")
+	scopes, _ := cmd.Flags().GetStringSlice("scopes")
+	expires, _ := cmd.Flags().GetDuration("expires")
+	var expiresAt time.Time
+	if expires > 0 {
+		expiresAt = time.Now().Add(expires)
+	}
+
+	plaintext, token, err := manager.IssueToken(context.Background(), user, name, scopes, expiresAt)
+	if err != nil {
+		return fmt.Errorf("签发令牌失败: %w", err)
+	}
+
+	cli.PrintSuccess("已为用户 %s 签发令牌 #%d（%s）", token.UserID, token.ID, strings.Join(token.Scopes, ","))
+	cli.PrintInfo("令牌只显示这一次，请立即保存:")
+	fmt.Println(plaintext)
+	return nil
+}
+
+// runTokenList 列出用户的访问令牌
+func runTokenList(cmd *cobra.Command, args []string) error {
+	manager, closeDB, err := tokenManager(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	user, _ := cmd.Flags().GetString("user")
+	list, err := manager.ListTokens(context.Background(), user)
+	if err != nil {
+		return fmt.Errorf("读取令牌失败: %w", err)
+	}
+	if len(list) == 0 {
+		cli.PrintInfo("用户 %s 没有访问令牌", user)
+		return nil
+	}
+
+	for _, token := range list {
+		status := "有效"
+		switch {
+		case token.RevokedAt != nil:
+			status = "已撤销"
+		case token.ExpiresAt != nil && !time.Now().Before(*token.ExpiresAt):
+			status = "已过期"
+		}
+		lastUsed := "从未使用"
+		if token.LastUsedAt != nil {
+			lastUsed = token.LastUsedAt.Format(time.DateTime)
+		}
+		fmt.Printf("#%d\t%s\t%s...\t%s\t%s\t%s\n", token.ID, token.Name, token.Prefix,
+			strings.Join(token.Scopes, ","), status, lastUsed)
+	}
+	return nil
+}
+
+// runTokenRevoke 撤销访问令牌
+func runTokenRevoke(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的令牌ID: %s", args[0])
+	}
+
+	manager, closeDB, err := tokenManager(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if err := manager.RevokeToken(context.Background(), uint(id)); err != nil {
+		return fmt.Errorf("撤销令牌失败: %w", err)
+	}
+	cli.PrintSuccess("令牌 #%d 已撤销", id)
+	return nil
+}
+
+// tokenManager 按命令参数连接数据库并创建令牌管理器，令牌表不存在时自动创建
+func tokenManager(cmd *cobra.Command) (*tokens.Manager, func(), error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	cm := config.NewConfigManager(config.WithConfigPath(configPath))
+	if err := cm.Load(); err != nil {
+		return nil, nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	connection, _ := cmd.Flags().GetString("connection")
+	return openTokenManager(cm, connection)
+}
+
+// openTokenManager 使用配置中的数据库连接创建令牌管理器，connection 为空时使用默认连接
+func openTokenManager(cm *config.ConfigManager, connection string) (*tokens.Manager, func(), error) {
+	databases := db.NewManager()
+	if err := databases.FromConfig(cm); err != nil {
+		return nil, nil, fmt.Errorf("加载数据库配置失败: %w", err)
+	}
+	closeDB := func() { _ = databases.Close() }
+	conn, err := databases.Default()
+	if connection != "" {
+		conn, err = databases.Connection(connection)
+	}
+	if err != nil {
+		closeDB()
+		return nil, nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+
+	manager := tokens.NewManager(conn)
+	if err := manager.Migrate(context.Background()); err != nil {
+		closeDB()
+		return nil, nil, fmt.Errorf("创建令牌表失败: %w", err)
+	}
+	return manager, closeDB, nil
+}
@@ -0,0 +1,227 @@
+package flow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrCoalesceTimeout 跟随请求在领头请求完成之前到达自身截止时间
+var ErrCoalesceTimeout = errors.New("等待合并请求的响应超时")
+
+// DefaultCoalesceMaxBody 默认可合并的响应体大小上限
+const DefaultCoalesceMaxBody = 1 << 20
+
+// CoalescedHeader 跟随请求的响应上设置的头，值为 true
+const CoalescedHeader = "X-Coalesced"
+
+// CoalescedError 领头请求处理器记录的错误，复制到跟随请求时包装为该类型，
+// 重试策略可通过 errors.As 区分错误是否来自合并的请求
+type CoalescedError struct {
+	Err error
+}
+
+// Error 实现error接口
+func (e *CoalescedError) Error() string {
+	return fmt.Sprintf("合并请求: %v", e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *CoalescedError) Unwrap() error {
+	return e.Err
+}
+
+// coalesceOptions 请求合并配置
+type coalesceOptions struct {
+	maxBody int
+}
+
+// CoalesceOption 请求合并选项
+type CoalesceOption func(*coalesceOptions)
+
+// WithCoalesceMaxBody 设置可合并的响应体大小上限，超过上限的响应不复制，跟随请求各自执行处理器
+func WithCoalesceMaxBody(n int) CoalesceOption {
+	return func(o *coalesceOptions) {
+		o.maxBody = n
+	}
+}
+
+// coalesceFlight 进行中的领头请求
+type coalesceFlight struct {
+	done   chan struct{}
+	status int
+	header http.Header
+	body   []byte
+	errs   []error
+	bypass bool // 响应超过大小上限、不可共享或处理器panic，跟随请求需自行处理
+}
+
+// coalescePerRequestHeaders 属于单个请求的响应头，不复制给跟随请求
+var coalescePerRequestHeaders = map[string]bool{
+	"Date":         true,
+	"Age":          true,
+	"Set-Cookie":   true,
+	"X-Request-Id": true,
+	"Traceparent":  true,
+	"Tracestate":   true,
+}
+
+// coalesceShareable 判断响应能否共享给其他调用方：设置 Cookie 或标记为
+// Cache-Control private/no-store 的响应属于领头请求的调用方，不复制
+func coalesceShareable(header http.Header) bool {
+	if len(header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(name) {
+			case "private", "no-store":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Coalesce 合并相同键的并发 GET 请求，只有第一个请求（领头）执行处理器，
+// 其余请求（跟随）等待并收到领头响应的副本，用于保护代价高的上游资源
+//
+// keyFn 返回空字符串时不合并。复制的响应不包含 Date、Age、Set-Cookie、X-Request-ID 与追踪头，
+// 这些头属于单个请求；领头响应设置了 Cookie 或带有 Cache-Control private/no-store 时不共享，
+// 跟随请求各自执行处理器。跟随请求各自在请求 context 结束时返回 504 并记录 ErrCoalesceTimeout，
+// 不受领头请求耗时影响。
+//
+// 合并的请求共享同一个响应，因此在需要认证的路由上 keyFn 必须包含调用方身份（如用户ID），
+// 否则一个用户的响应会返回给其他用户；下例的键只适用于公开资源
+//
+//	r.GET("/reports/:id", flow.Coalesce(func(c *flow.Context) string {
+//		return c.Request.URL.RequestURI()
+//	}, reportHandler))
+func Coalesce(keyFn func(*Context) string, handler HandlerFunc, opts ...CoalesceOption) HandlerFunc {
+	options := coalesceOptions{maxBody: DefaultCoalesceMaxBody}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var mu sync.Mutex
+	flights := make(map[string]*coalesceFlight)
+
+	return func(c *Context) {
+		if c.Request.Method != http.MethodGet {
+			handler(c)
+			return
+		}
+		key := keyFn(c)
+		if key == "" {
+			handler(c)
+			return
+		}
+
+		mu.Lock()
+		if flight, ok := flights[key]; ok {
+			mu.Unlock()
+			follow(c, flight, handler)
+			return
+		}
+		flight := &coalesceFlight{done: make(chan struct{})}
+		flights[key] = flight
+		mu.Unlock()
+
+		lead(c, flight, handler, options.maxBody, func() {
+			mu.Lock()
+			delete(flights, key)
+			mu.Unlock()
+		})
+	}
+}
+
+// lead 执行处理器并记录响应，完成后唤醒跟随请求
+func lead(c *Context, flight *coalesceFlight, handler HandlerFunc, maxBody int, release func()) {
+	writer := &coalesceWriter{ResponseWriter: c.Writer, max: maxBody}
+	c.Writer = writer
+	errCount := len(c.Errors)
+
+	completed := false
+	defer func() {
+		c.Writer = writer.ResponseWriter
+		// 先移除再唤醒，之后到达的相同请求成为新的领头
+		release()
+		if completed && !writer.overflow && coalesceShareable(writer.Header()) {
+			flight.status = writer.Status()
+			flight.header = writer.Header().Clone()
+			flight.body = writer.buf.Bytes()
+			for _, err := range c.Errors[errCount:] {
+				flight.errs = append(flight.errs, err.Err)
+			}
+		} else {
+			flight.bypass = true
+		}
+		close(flight.done)
+	}()
+
+	handler(c)
+	completed = true
+}
+
+// follow 等待领头请求的响应，在自身 context 结束时退出
+func follow(c *Context, flight *coalesceFlight, handler HandlerFunc) {
+	select {
+	case <-flight.done:
+	case <-c.Request.Context().Done():
+		_ = c.Error(ErrCoalesceTimeout)
+		c.AbortWithStatus(http.StatusGatewayTimeout)
+		return
+	}
+
+	if flight.bypass {
+		handler(c)
+		return
+	}
+
+	header := c.Writer.Header()
+	for key, values := range flight.header {
+		if coalescePerRequestHeaders[key] {
+			continue
+		}
+		header[key] = append([]string(nil), values...)
+	}
+	header.Set(CoalescedHeader, "true")
+	for _, err := range flight.errs {
+		_ = c.Error(&CoalescedError{Err: err})
+	}
+	c.Status(flight.status)
+	_, _ = c.Writer.Write(flight.body)
+	c.Abort()
+}
+
+// coalesceWriter 在写入响应的同时缓存响应体，超过上限后停止缓存
+type coalesceWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	max      int
+	overflow bool
+}
+
+// Write 写入并缓存响应体
+func (w *coalesceWriter) Write(data []byte) (int, error) {
+	if !w.overflow {
+		if w.buf.Len()+len(data) > w.max {
+			w.overflow = true
+			w.buf = bytes.Buffer{}
+		} else {
+			w.buf.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写入并缓存字符串
+func (w *coalesceWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,196 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCoalesceTestEngine 注册被合并的处理器，处理器在 release 关闭前阻塞
+func newCoalesceTestEngine(handler HandlerFunc, opts ...CoalesceOption) (*Engine, *[]error) {
+	e := newRouteTestEngine()
+	var mu sync.Mutex
+	var errs []error
+	e.UseNamed("errors", func(c *Context) {
+		c.Next()
+		mu.Lock()
+		for _, err := range c.Errors {
+			errs = append(errs, err.Err)
+		}
+		mu.Unlock()
+	})
+	e.GET("/reports/:id", Coalesce(func(c *Context) string { return c.Request.URL.RequestURI() }, handler, opts...))
+	return e, &errs
+}
+
+// serveConcurrently 并发发送相同请求，全部发出后关闭 release
+func serveConcurrently(e *Engine, n int, release chan struct{}) []*httptest.ResponseRecorder {
+	recorders := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := range recorders {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/1", nil))
+		}(recorders[i])
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return recorders
+}
+
+func TestCoalesce_SingleUpstreamCall(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	e, _ := newCoalesceTestEngine(func(c *Context) {
+		calls.Add(1)
+		<-release
+		c.Header("Date", "Mon, 01 Jan 2024 00:00:00 GMT")
+		c.Header("X-Report", "1")
+		c.String(http.StatusOK, "report")
+	})
+
+	recorders := serveConcurrently(e, 10, release)
+	assert.Equal(t, int32(1), calls.Load())
+	coalesced := 0
+	for _, w := range recorders {
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "report", w.Body.String())
+		assert.Equal(t, "1", w.Header().Get("X-Report"))
+		if w.Header().Get(CoalescedHeader) == "true" {
+			coalesced++
+			assert.Empty(t, w.Header().Get("Date"), "Date 由服务器重新生成")
+		}
+	}
+	assert.Equal(t, 9, coalesced)
+}
+
+func TestCoalesce_LargeResponseBypasses(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	e, _ := newCoalesceTestEngine(func(c *Context) {
+		calls.Add(1)
+		<-release
+		c.String(http.StatusOK, strings.Repeat("x", 64))
+	}, WithCoalesceMaxBody(16))
+
+	recorders := serveConcurrently(e, 5, release)
+	assert.Equal(t, int32(5), calls.Load(), "超过上限时跟随请求各自执行处理器")
+	for _, w := range recorders {
+		assert.Len(t, w.Body.String(), 64)
+		assert.Empty(t, w.Header().Get(CoalescedHeader))
+	}
+}
+
+func TestCoalesce_FollowerDeadline(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	e, errs := newCoalesceTestEngine(func(c *Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "late")
+	})
+
+	leader := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		e.ServeHTTP(leader, httptest.NewRequest(http.MethodGet, "/reports/1", nil))
+		close(done)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	follower := httptest.NewRecorder()
+	start := time.Now()
+	e.ServeHTTP(follower, httptest.NewRequest(http.MethodGet, "/reports/1", nil).WithContext(ctx))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusGatewayTimeout, follower.Code)
+	require.Len(t, *errs, 1)
+	assert.ErrorIs(t, (*errs)[0], ErrCoalesceTimeout)
+
+	close(release)
+	<-done
+	assert.Equal(t, "late", leader.Body.String())
+}
+
+func TestCoalesce_ErrorPropagation(t *testing.T) {
+	release := make(chan struct{})
+	upstreamErr := errors.New("报表服务不可用")
+	e, errs := newCoalesceTestEngine(func(c *Context) {
+		<-release
+		_ = c.Error(upstreamErr)
+		c.String(http.StatusBadGateway, "unavailable")
+	})
+
+	recorders := serveConcurrently(e, 3, release)
+	for _, w := range recorders {
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	}
+	require.Len(t, *errs, 3)
+	coalesced := 0
+	for _, err := range *errs {
+		assert.ErrorIs(t, err, upstreamErr)
+		var ce *CoalescedError
+		if errors.As(err, &ce) {
+			coalesced++
+		}
+	}
+	assert.Equal(t, 2, coalesced)
+}
+
+func TestCoalesce_PrivateResponsesNotShared(t *testing.T) {
+	for name, setHeader := range map[string]func(c *Context){
+		"Set-Cookie": func(c *Context) { c.SetCookie("session", "leader", 3600, "/", "", true, true) },
+		"private":    func(c *Context) { c.Header("Cache-Control", "max-age=60, private") },
+		"no-store":   func(c *Context) { c.Header("Cache-Control", "no-store") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			release := make(chan struct{})
+			e, _ := newCoalesceTestEngine(func(c *Context) {
+				calls.Add(1)
+				<-release
+				setHeader(c)
+				c.String(http.StatusOK, "mine")
+			})
+
+			recorders := serveConcurrently(e, 5, release)
+			assert.Equal(t, int32(5), calls.Load(), "不可共享的响应由每个请求各自生成")
+			for _, w := range recorders {
+				assert.Empty(t, w.Header().Get(CoalescedHeader))
+			}
+		})
+	}
+}
+
+func TestCoalesce_PerRequestHeadersNotCopied(t *testing.T) {
+	release := make(chan struct{})
+	e, _ := newCoalesceTestEngine(func(c *Context) {
+		<-release
+		c.Header("X-Request-ID", "leader-id")
+		c.Header("Traceparent", "00-leader-01")
+		c.Header("X-Report", "1")
+		c.String(http.StatusOK, "report")
+	})
+
+	for _, w := range serveConcurrently(e, 5, release) {
+		if w.Header().Get(CoalescedHeader) != "true" {
+			continue
+		}
+		assert.Equal(t, "1", w.Header().Get("X-Report"))
+		assert.Empty(t, w.Header().Get("X-Request-ID"))
+		assert.Empty(t, w.Header().Get("Traceparent"))
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import "strings"
+
+// 标准环境名称
+const (
+	EnvDevelopment = "development"
+	EnvTesting     = "testing"
+	EnvProduction  = "production"
+)
+
+// environmentAliases 环境名称的常用别名
+var environmentAliases = map[string]string{
+	"dev":         EnvDevelopment,
+	"develop":     EnvDevelopment,
+	"development": EnvDevelopment,
+	"local":       EnvDevelopment,
+	"test":        EnvTesting,
+	"testing":     EnvTesting,
+	"prod":        EnvProduction,
+	"production":  EnvProduction,
+	"release":     EnvProduction,
+}
+
+// NormalizeEnvironment 将环境名称规范化，例如 dev → development、prod → production
+// 空值视为开发环境，未知名称（如 staging）原样保留为小写
+func NormalizeEnvironment(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return EnvDevelopment
+	}
+	if canonical, ok := environmentAliases[name]; ok {
+		return canonical
+	}
+	return name
+}
@@ -12,7 +12,10 @@ import (
 // Context 是Flow框架的上下文结构体，扩展了Gin的Context
 type Context struct {
 	*gin.Context
-	engine *Engine
+	engine     *Engine
+	attrSink   *[]routeAttr     // 注册路由时收集 WithAttr 属性
+	injectSink *[]*injectTarget // 注册路由时收集 H2 处理函数
+	groupSink  *[]string        // 注册路由时收集 WithMiddlewareGroup 引用的中间件组
 }
 
 // Inject 向上下文注入依赖
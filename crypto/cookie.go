@@ -0,0 +1,37 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/zzliekkas/flow/v2"
+)
+
+// SetCookie 加密Cookie的值后写入响应，Cookie名称参与认证，密文不能被挪用到其他Cookie
+func (e *Encrypter) SetCookie(c *flow.Context, cookie *http.Cookie) error {
+	sealed, err := e.seal([]byte(cookie.Value), []byte(cookie.Name))
+	if err != nil {
+		return err
+	}
+	encrypted := *cookie
+	encrypted.Value = base64.RawURLEncoding.EncodeToString(sealed)
+	http.SetCookie(c.Writer, &encrypted)
+	return nil
+}
+
+// Cookie 读取并解密 SetCookie 写入的Cookie，Cookie不存在时返回 http.ErrNoCookie
+func (e *Encrypter) Cookie(c *flow.Context, name string) (string, error) {
+	value, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	plaintext, err := e.open(sealed, []byte(name))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
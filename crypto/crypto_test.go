@@ -0,0 +1,133 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+)
+
+func mustKey(t *testing.T) []byte {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	return key
+}
+
+func TestEncrypter_RoundTrip(t *testing.T) {
+	enc, err := NewEncrypter([][]byte{mustKey(t)})
+	require.NoError(t, err)
+
+	ciphertext, err := enc.EncryptString("remember-me:42")
+	require.NoError(t, err)
+	plaintext, err := enc.DecryptString(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "remember-me:42", plaintext)
+
+	// 随机nonce，相同明文的密文不同
+	other, err := enc.EncryptString("remember-me:42")
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, other)
+
+	_, err = NewEncrypter([][]byte{[]byte("short")})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestEncrypter_Rotation(t *testing.T) {
+	oldKey, newKey := mustKey(t), mustKey(t)
+	oldEnc, err := NewEncrypter([][]byte{oldKey})
+	require.NoError(t, err)
+	ciphertext, err := oldEnc.EncryptString("session")
+	require.NoError(t, err)
+
+	// 新密钥在前，旧密钥仍可解密
+	rotated, err := NewEncrypter([][]byte{newKey, oldKey})
+	require.NoError(t, err)
+	plaintext, err := rotated.DecryptString(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "session", plaintext)
+
+	// 移除旧密钥后无法解密
+	newOnly, err := NewEncrypter([][]byte{newKey})
+	require.NoError(t, err)
+	_, err = newOnly.DecryptString(ciphertext)
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestEncrypter_TamperDetection(t *testing.T) {
+	enc, err := NewEncrypter([][]byte{mustKey(t)})
+	require.NoError(t, err)
+
+	sealed, err := enc.Encrypt([]byte("value"))
+	require.NoError(t, err)
+	sealed[len(sealed)-1] ^= 1
+	_, err = enc.Decrypt(sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = enc.DecryptString("not-base64!")
+	assert.ErrorIs(t, err, ErrDecrypt)
+	_, err = enc.Decrypt(nil)
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestEncrypter_Cookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enc, err := NewEncrypter([][]byte{mustKey(t)})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	gc, _ := gin.CreateTestContext(w)
+	require.NoError(t, enc.SetCookie(&flow.Context{Context: gc}, &http.Cookie{Name: "session", Value: "user:1"}))
+	cookie := w.Result().Cookies()[0]
+	assert.NotContains(t, cookie.Value, "user:1")
+
+	read := func(name string, c *http.Cookie) (string, error) {
+		gc, _ := gin.CreateTestContext(httptest.NewRecorder())
+		gc.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		gc.Request.AddCookie(&http.Cookie{Name: name, Value: c.Value})
+		return enc.Cookie(&flow.Context{Context: gc}, name)
+	}
+	value, err := read("session", cookie)
+	require.NoError(t, err)
+	assert.Equal(t, "user:1", value)
+
+	// 密文不能挪用到其他Cookie
+	_, err = read("remember", cookie)
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestSigner(t *testing.T) {
+	oldKey := mustKey(t)
+	oldSigner, err := NewSigner([][]byte{oldKey})
+	require.NoError(t, err)
+	signed := oldSigner.Sign("state:abc")
+
+	signer, err := NewSigner([][]byte{mustKey(t), oldKey})
+	require.NoError(t, err)
+	value, err := signer.Verify(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "state:abc", value)
+
+	_, err = signer.Verify("state:abd" + signed[len("state:abc"):])
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = signer.Verify("state:abc")
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestParseKeys(t *testing.T) {
+	a, b := mustKey(t), mustKey(t)
+	keys, err := ParseKeys(EncodeKey(a) + ", " + EncodeKey(b))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{a, b}, keys)
+
+	_, err = ParseKeys("")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = ParseKeys("plain-text-secret")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = ParseKeys(keyPrefix + base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,130 @@
+// Package crypto 提供基于应用密钥的加密与签名工具
+//
+// Encrypter 使用 AES-256-GCM 对值进行认证加密，Signer 使用 HMAC-SHA256 对不需要保密但需要防篡改的值签名。
+// 两者都支持多个密钥：第一个密钥用于加密或签名，所有密钥都可用于解密或验证，便于轮换密钥。
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize 加密密钥长度（AES-256）
+const KeySize = 32
+
+// 密文格式版本
+const version1 byte = 1
+
+// 加密相关错误
+var (
+	// ErrInvalidKey 密钥缺失或长度不正确
+	ErrInvalidKey = errors.New("无效的加密密钥")
+
+	// ErrDecrypt 密文无法解密：格式错误、被篡改或所有密钥都不匹配
+	ErrDecrypt = errors.New("解密失败")
+)
+
+// Encrypter AES-256-GCM 加密器，可安全地并发使用
+type Encrypter struct {
+	aeads []cipher.AEAD
+}
+
+// NewEncrypter 创建加密器，第一个密钥用于加密，所有密钥都可用于解密
+func NewEncrypter(keys [][]byte) (*Encrypter, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个密钥", ErrInvalidKey)
+	}
+
+	e := &Encrypter{aeads: make([]cipher.AEAD, len(keys))}
+	for i, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("%w: 第 %d 个密钥长度为 %d 字节，需要 %d 字节", ErrInvalidKey, i+1, len(key), KeySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		e.aeads[i] = aead
+	}
+	return e, nil
+}
+
+// Encrypt 加密数据，结果格式为 版本(1字节) + 随机nonce + 密文
+func (e *Encrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	return e.seal(plaintext, nil)
+}
+
+// Decrypt 解密 Encrypt 的结果，依次尝试所有密钥
+func (e *Encrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	return e.open(ciphertext, nil)
+}
+
+// Seal 加密数据，additional 参与认证但不加密，解密时必须传入相同的值，
+// 用于把密文绑定到所属的对象上，防止被挪用
+func (e *Encrypter) Seal(plaintext, additional []byte) ([]byte, error) {
+	return e.seal(plaintext, additional)
+}
+
+// Open 解密 Seal 的结果
+func (e *Encrypter) Open(sealed, additional []byte) ([]byte, error) {
+	return e.open(sealed, additional)
+}
+
+// EncryptString 加密字符串，返回 URL 安全的 base64 编码结果
+func (e *Encrypter) EncryptString(plaintext string) (string, error) {
+	sealed, err := e.seal([]byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString 解密 EncryptString 的结果
+func (e *Encrypter) DecryptString(ciphertext string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	plaintext, err := e.open(sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// seal 使用当前密钥加密，additional 为参与认证但不加密的数据
+func (e *Encrypter) seal(plaintext, additional []byte) ([]byte, error) {
+	aead := e.aeads[0]
+	out := make([]byte, 1+aead.NonceSize(), 1+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	out[0] = version1
+	if _, err := rand.Read(out[1:]); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return aead.Seal(out, out[1:], plaintext, additional), nil
+}
+
+// open 解密数据，依次尝试所有密钥
+func (e *Encrypter) open(sealed, additional []byte) ([]byte, error) {
+	if len(sealed) == 0 || sealed[0] != version1 {
+		return nil, ErrDecrypt
+	}
+	for _, aead := range e.aeads {
+		nonceSize := aead.NonceSize()
+		if len(sealed) < 1+nonceSize+aead.Overhead() {
+			return nil, ErrDecrypt
+		}
+		plaintext, err := aead.Open(nil, sealed[1:1+nonceSize], sealed[1+nonceSize:], additional)
+		if err == nil {
+			return plaintext, nil
+		}
+	}
+	return nil, ErrDecrypt
+}
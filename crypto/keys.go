@@ -0,0 +1,74 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/zzliekkas/flow/v2/config"
+)
+
+// keyPrefix 编码后密钥的前缀
+const keyPrefix = "base64:"
+
+// GenerateKey 生成随机的32字节密钥
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+	return key, nil
+}
+
+// EncodeKey 将密钥编码为 "base64:..." 形式，用于写入配置
+func EncodeKey(key []byte) string {
+	return keyPrefix + base64.StdEncoding.EncodeToString(key)
+}
+
+// ParseKey 解析 EncodeKey 编码的密钥
+func ParseKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, fmt.Errorf("%w: 密钥为空", ErrInvalidKey)
+	}
+	if !strings.HasPrefix(encoded, keyPrefix) {
+		return nil, fmt.Errorf("%w: 密钥需以 %q 开头，可通过 flow key:generate 生成", ErrInvalidKey, keyPrefix)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, keyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%w: 密钥长度为 %d 字节，需要 %d 字节", ErrInvalidKey, len(key), KeySize)
+	}
+	return key, nil
+}
+
+// ParseKeys 解析逗号分隔的多个密钥，第一个为当前密钥，其余为轮换前的旧密钥
+func ParseKeys(value string) ([][]byte, error) {
+	var keys [][]byte
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, err := ParseKey(part)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: 未配置 app.key，可通过 flow key:generate 生成", ErrInvalidKey)
+	}
+	return keys, nil
+}
+
+// LoadKeys 从配置的 app.key 加载密钥，也可通过环境变量 FLOW_APP_KEY 设置
+//
+//	app:
+//	  key: "base64:新密钥,base64:旧密钥"
+func LoadKeys(configManager *config.ConfigManager) ([][]byte, error) {
+	return ParseKeys(configManager.GetString("app.key"))
+}
@@ -0,0 +1,69 @@
+package crypto
+
+import (
+	"fmt"
+
+	"github.com/zzliekkas/flow/v2/app"
+	"github.com/zzliekkas/flow/v2/config"
+)
+
+// Provider 加密服务提供者，从 app.key 加载密钥并注册 *Encrypter 与 *Signer
+// 开发环境未配置密钥时使用临时密钥，其他环境缺少密钥或密钥无效时拒绝启动
+type Provider struct {
+	*app.BaseProvider
+}
+
+// NewProvider 创建加密服务提供者
+func NewProvider() *Provider {
+	return &Provider{
+		BaseProvider: app.NewBaseProvider("crypto", 10),
+	}
+}
+
+// Register 加载密钥并注册加密服务
+func (p *Provider) Register(application *app.Application) error {
+	var configManager *config.ConfigManager
+	_ = application.Engine().Invoke(func(cm *config.ConfigManager) {
+		configManager = cm
+	})
+
+	var keys [][]byte
+	var err error
+	if configManager != nil {
+		keys, err = LoadKeys(configManager)
+	} else {
+		keys, err = ParseKeys("")
+	}
+	if err != nil {
+		if !application.Environment().IsDevelopment() {
+			return fmt.Errorf("加载应用密钥失败: %w", err)
+		}
+		// 开发环境使用临时密钥，重启后之前加密的数据无法解密
+		key, genErr := GenerateKey()
+		if genErr != nil {
+			return genErr
+		}
+		keys = [][]byte{key}
+		application.Logger().Warnf("%v，开发环境使用临时密钥", err)
+		application.ReportDevFeature("临时应用密钥")
+	}
+
+	encrypter, err := NewEncrypter(keys)
+	if err != nil {
+		return err
+	}
+	signer, err := NewSigner(keys)
+	if err != nil {
+		return err
+	}
+
+	if err := application.Engine().Provide(func() *Encrypter { return encrypter }); err != nil {
+		return err
+	}
+	return application.Engine().Provide(func() *Signer { return signer })
+}
+
+// Boot 启动加密服务
+func (p *Provider) Boot(application *app.Application) error {
+	return nil
+}
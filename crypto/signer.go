@@ -0,0 +1,77 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSignature 签名缺失或不匹配
+var ErrInvalidSignature = errors.New("签名无效")
+
+// Signer HMAC-SHA256 签名器，用于不需要保密但需要防篡改的值
+type Signer struct {
+	keys [][]byte
+}
+
+// NewSigner 创建签名器，第一个密钥用于签名，所有密钥都可用于验证
+// 签名密钥由传入的密钥派生，与加密使用的密钥互不相同
+func NewSigner(keys [][]byte) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个密钥", ErrInvalidKey)
+	}
+
+	s := &Signer{keys: make([][]byte, len(keys))}
+	for i, key := range keys {
+		if len(key) == 0 {
+			return nil, fmt.Errorf("%w: 第 %d 个密钥为空", ErrInvalidKey, i+1)
+		}
+		s.keys[i] = deriveKey(key, "flow.signer")
+	}
+	return s, nil
+}
+
+// MAC 计算数据的签名
+func (s *Signer) MAC(data []byte) []byte {
+	return mac(s.keys[0], data)
+}
+
+// Sign 返回 "值.签名" 形式的签名字符串
+func (s *Signer) Sign(value string) string {
+	return value + "." + base64.RawURLEncoding.EncodeToString(s.MAC([]byte(value)))
+}
+
+// Verify 验证 Sign 的结果并返回原始值
+func (s *Signer) Verify(signed string) (string, error) {
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", ErrInvalidSignature
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(signed[i+1:])
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	value := signed[:i]
+	for _, key := range s.keys {
+		if hmac.Equal(signature, mac(key, []byte(value))) {
+			return value, nil
+		}
+	}
+	return "", ErrInvalidSignature
+}
+
+// mac 计算 HMAC-SHA256
+func mac(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// deriveKey 为不同用途派生独立的密钥
+func deriveKey(key []byte, purpose string) []byte {
+	return mac(key, []byte(purpose))
+}
@@ -0,0 +1,257 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrBatchInserterClosed 批量写入器已关闭
+var ErrBatchInserterClosed = errors.New("批量写入器已关闭")
+
+// BatchInserter 按表缓冲写入的行，达到行数、字节数或时间间隔时批量插入
+//
+// 适用于 ClickHouse 等不适合逐行写入的数据库。语义为至少一次：写入失败的批次保留在缓冲区，
+// 下一次刷新时重试，因此同一批数据可能被重复写入。Close 会刷新剩余数据
+type BatchInserter struct {
+	conn *gorm.DB
+
+	maxRows  int
+	maxBytes int
+	interval time.Duration
+	onError  func(table string, rows int, err error)
+
+	mu      sync.Mutex
+	buffers map[string]*batchBuffer
+	closed  bool
+
+	// flushMu 保证同一时间只有一个刷新在执行，避免同一批数据被并发写入
+	flushMu sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// batchBuffer 单个表的缓冲区
+type batchBuffer struct {
+	rows  []interface{}
+	bytes int
+}
+
+// BatchOption 批量写入器配置选项
+type BatchOption func(*BatchInserter)
+
+// WithBatchSize 设置触发刷新的行数，默认10000
+func WithBatchSize(rows int) BatchOption {
+	return func(b *BatchInserter) {
+		b.maxRows = rows
+	}
+}
+
+// WithBatchBytes 设置触发刷新的字节数（按行的JSON大小估算），0表示不限制
+func WithBatchBytes(bytes int) BatchOption {
+	return func(b *BatchInserter) {
+		b.maxBytes = bytes
+	}
+}
+
+// WithFlushInterval 设置定时刷新间隔，默认1秒，0表示不定时刷新
+func WithFlushInterval(interval time.Duration) BatchOption {
+	return func(b *BatchInserter) {
+		b.interval = interval
+	}
+}
+
+// WithBatchErrorHandler 设置后台刷新失败时的回调
+func WithBatchErrorHandler(handler func(table string, rows int, err error)) BatchOption {
+	return func(b *BatchInserter) {
+		b.onError = handler
+	}
+}
+
+// NewBatchInserter 创建批量写入器并启动定时刷新
+func NewBatchInserter(conn *gorm.DB, opts ...BatchOption) *BatchInserter {
+	b := &BatchInserter{
+		conn:     conn,
+		maxRows:  10000,
+		interval: time.Second,
+		buffers:  make(map[string]*batchBuffer),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		onError: func(table string, rows int, err error) {
+			log.Printf("[DB] 批量写入 %s 失败（%d 行，将在下次刷新时重试）: %v", table, rows, err)
+		},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if b.interval > 0 {
+		go b.loop()
+	} else {
+		close(b.done)
+	}
+	return b
+}
+
+// Add 向表的缓冲区添加一行，同一个表的行必须是相同类型（结构体、结构体指针或 map[string]interface{}）
+//
+// 缓冲区达到行数或字节数限制时同步刷新该表，刷新失败返回错误但数据仍保留在缓冲区
+func (b *BatchInserter) Add(ctx context.Context, table string, row interface{}) error {
+	size := 0
+	if b.maxBytes > 0 {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return fmt.Errorf("估算行大小失败: %w", err)
+		}
+		size = len(data)
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBatchInserterClosed
+	}
+	buf := b.buffers[table]
+	if buf == nil {
+		buf = &batchBuffer{}
+		b.buffers[table] = buf
+	}
+	if len(buf.rows) > 0 && reflect.TypeOf(buf.rows[0]) != reflect.TypeOf(row) {
+		b.mu.Unlock()
+		return fmt.Errorf("表 %s 的行类型不一致: %T 与 %T", table, buf.rows[0], row)
+	}
+	buf.rows = append(buf.rows, row)
+	buf.bytes += size
+	full := (b.maxRows > 0 && len(buf.rows) >= b.maxRows) || (b.maxBytes > 0 && buf.bytes >= b.maxBytes)
+	b.mu.Unlock()
+
+	if full {
+		return b.flushTable(ctx, table)
+	}
+	return nil
+}
+
+// Pending 返回缓冲区中尚未写入的行数
+func (b *BatchInserter) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	total := 0
+	for _, buf := range b.buffers {
+		total += len(buf.rows)
+	}
+	return total
+}
+
+// Flush 刷新所有表的缓冲区
+func (b *BatchInserter) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	tables := make([]string, 0, len(b.buffers))
+	for table, buf := range b.buffers {
+		if len(buf.rows) > 0 {
+			tables = append(tables, table)
+		}
+	}
+	b.mu.Unlock()
+
+	var errs []error
+	for _, table := range tables {
+		if err := b.flushTable(ctx, table); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 停止定时刷新并写入剩余数据，之后 Add 返回 ErrBatchInserterClosed
+func (b *BatchInserter) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	if b.interval > 0 {
+		close(b.stop)
+	}
+	<-b.done
+	return b.Flush(context.Background())
+}
+
+// CloseOnShutdown 在应用关闭时刷新剩余数据，hooks 通常为 *flow.Engine
+func (b *BatchInserter) CloseOnShutdown(hooks interface {
+	OnShutdown(fn func(), priority ...int)
+}) {
+	hooks.OnShutdown(func() {
+		if err := b.Close(); err != nil {
+			log.Printf("[DB] 关闭批量写入器失败，未写入的数据已丢失: %v", err)
+		}
+	})
+}
+
+// loop 定时刷新
+func (b *BatchInserter) loop() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = b.Flush(context.Background())
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// flushTable 写入一个表的缓冲区，失败时把数据放回缓冲区头部
+func (b *BatchInserter) flushTable(ctx context.Context, table string) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	buf := b.buffers[table]
+	if buf == nil || len(buf.rows) == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	rows, bytes := buf.rows, buf.bytes
+	buf.rows, buf.bytes = nil, 0
+	b.mu.Unlock()
+
+	err := b.insert(ctx, table, rows)
+	if err == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	buf.rows = append(rows, buf.rows...)
+	buf.bytes += bytes
+	b.mu.Unlock()
+
+	err = fmt.Errorf("批量写入表 %s 失败: %w", table, err)
+	if b.onError != nil {
+		b.onError(table, len(rows), err)
+	}
+	return err
+}
+
+// insert 将同类型的行转换为切片后一次性插入
+func (b *BatchInserter) insert(ctx context.Context, table string, rows []interface{}) error {
+	slice := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(rows[0])), 0, len(rows))
+	for _, row := range rows {
+		slice = reflect.Append(slice, reflect.ValueOf(row))
+	}
+	return b.conn.WithContext(ctx).Table(table).CreateInBatches(slice.Interface(), len(rows)).Error
+}
@@ -0,0 +1,281 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"text/template"
+
+	"gorm.io/gorm"
+)
+
+// TagDeleter 按标签删除缓存，cache.Manager 实现了该接口
+type TagDeleter interface {
+	TaggedDelete(ctx context.Context, tag string) error
+}
+
+// InvalidationRule 模型或表与缓存标签的对应关系
+// 标签可以是模板，以变更的模型实例为数据渲染，例如 "product:{{.ID}}"、"tenant:{{.Tenant.Code}}:catalog"
+type InvalidationRule struct {
+	// Model 模型实例，例如 &Product{}，与 Table 二选一
+	Model interface{}
+	// Table 表名
+	Table string
+	// Tags 失效的标签
+	Tags []string
+}
+
+// InvalidateModel 创建按模型类型匹配的失效规则
+func InvalidateModel(model interface{}, tags ...string) InvalidationRule {
+	return InvalidationRule{Model: model, Tags: tags}
+}
+
+// InvalidateTable 创建按表名匹配的失效规则
+func InvalidateTable(table string, tags ...string) InvalidationRule {
+	return InvalidationRule{Table: table, Tags: tags}
+}
+
+// compiledRule 编译后的失效规则
+type compiledRule struct {
+	modelType reflect.Type
+	table     string
+	static    []string
+	templates []*template.Template
+}
+
+// CacheInvalidationPlugin gorm 插件，在写操作提交后按规则使缓存标签失效
+//
+// 标签在创建、更新、删除（包括软删除）后收集，事务提交后去重并统一删除，事务回滚时丢弃；
+// 未开启事务的语句在执行后立即删除。按条件批量更新或删除时没有模型实例，只会删除不含模板的标签。
+// 失效失败会记录日志并计数，默认不影响数据库操作；严格模式下错误从提交或语句返回，此时数据已经写入
+//
+//	conn.Use(db.CacheInvalidation(cacheManager,
+//		db.InvalidateModel(&Product{}, "products", "product:{{.ID}}"),
+//	))
+type CacheInvalidationPlugin struct {
+	cache    TagDeleter
+	rules    []InvalidationRule
+	compiled []compiledRule
+	strict   bool
+	onError  func(error)
+	failures atomic.Int64
+}
+
+// CacheInvalidation 创建缓存失效插件
+func CacheInvalidation(cache TagDeleter, rules ...InvalidationRule) *CacheInvalidationPlugin {
+	return &CacheInvalidationPlugin{
+		cache: cache,
+		rules: rules,
+		onError: func(err error) {
+			log.Printf("[DB] 缓存失效失败: %v", err)
+		},
+	}
+}
+
+// WithStrict 启用严格模式，失效失败时返回错误
+func (p *CacheInvalidationPlugin) WithStrict() *CacheInvalidationPlugin {
+	p.strict = true
+	return p
+}
+
+// WithErrorHandler 设置失效失败时的处理函数，默认记录日志
+func (p *CacheInvalidationPlugin) WithErrorHandler(handler func(error)) *CacheInvalidationPlugin {
+	p.onError = handler
+	return p
+}
+
+// Failures 返回失效失败的次数
+func (p *CacheInvalidationPlugin) Failures() int64 {
+	return p.failures.Load()
+}
+
+// Name 插件名称
+func (p *CacheInvalidationPlugin) Name() string {
+	return "flow:cache_invalidation"
+}
+
+// Initialize 编译规则、注册回调，并包装连接池以在事务提交时触发失效
+func (p *CacheInvalidationPlugin) Initialize(db *gorm.DB) error {
+	for _, rule := range p.rules {
+		compiled := compiledRule{table: rule.Table}
+		if rule.Model != nil {
+			compiled.modelType = indirectType(reflect.TypeOf(rule.Model))
+		}
+		if compiled.modelType == nil && compiled.table == "" {
+			return errors.New("缓存失效规则需要指定模型或表名")
+		}
+		for _, tag := range rule.Tags {
+			if !strings.Contains(tag, "{{") {
+				compiled.static = append(compiled.static, tag)
+				continue
+			}
+			tmpl, err := template.New(tag).Option("missingkey=error").Parse(tag)
+			if err != nil {
+				return fmt.Errorf("缓存标签模板 %q 错误: %w", tag, err)
+			}
+			compiled.templates = append(compiled.templates, tmpl)
+		}
+		p.compiled = append(p.compiled, compiled)
+	}
+
+	installTxHooks(db)
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("flow:cache_invalidation", p.collect); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("flow:cache_invalidation", p.collect); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("flow:cache_invalidation", p.collect)
+}
+
+// collect 收集语句需要失效的标签，事务中暂存到提交时，否则立即失效
+func (p *CacheInvalidationPlugin) collect(tx *gorm.DB) {
+	if tx.Error != nil || tx.DryRun || tx.Statement.Schema == nil {
+		return
+	}
+	tags, err := p.tags(tx)
+	if err != nil {
+		p.report(err)
+		if p.strict {
+			_ = tx.AddError(err)
+		}
+	}
+	if len(tags) == 0 {
+		return
+	}
+
+	if pending, ok := pendingTx(tx); ok {
+		pending.add(p, tags)
+		return
+	}
+	if err := p.invalidate(tx.Statement.Context, tags); err != nil && p.strict {
+		_ = tx.AddError(err)
+	}
+}
+
+// tags 渲染语句涉及的所有标签
+func (p *CacheInvalidationPlugin) tags(tx *gorm.DB) ([]string, error) {
+	stmt := tx.Statement
+	var tags []string
+	var errs []error
+	for _, rule := range p.compiled {
+		if rule.modelType != nil && rule.modelType != stmt.Schema.ModelType {
+			continue
+		}
+		if rule.modelType == nil && rule.table != stmt.Table {
+			continue
+		}
+		tags = append(tags, rule.static...)
+		if len(rule.templates) == 0 {
+			continue
+		}
+		for _, model := range changedInstances(tx) {
+			for _, tmpl := range rule.templates {
+				var buf bytes.Buffer
+				if err := tmpl.Execute(&buf, model); err != nil {
+					errs = append(errs, fmt.Errorf("渲染缓存标签 %q 失败: %w", tmpl.Name(), err))
+					continue
+				}
+				tags = append(tags, buf.String())
+			}
+		}
+	}
+	return tags, errors.Join(errs...)
+}
+
+// invalidate 去重后删除标签
+func (p *CacheInvalidationPlugin) invalidate(ctx context.Context, tags []string) error {
+	var errs []error
+	for _, tag := range uniqueSorted(tags) {
+		if err := p.cache.TaggedDelete(ctx, tag); err != nil {
+			err = fmt.Errorf("删除缓存标签 %s 失败: %w", tag, err)
+			p.report(err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// invalidateCommitted 事务提交后失效暂存的标签，严格模式下错误从提交返回
+func (p *CacheInvalidationPlugin) invalidateCommitted(ctx context.Context, tags []string) error {
+	if err := p.invalidate(ctx, tags); err != nil && p.strict {
+		return err
+	}
+	return nil
+}
+
+// report 记录失效失败
+func (p *CacheInvalidationPlugin) report(err error) {
+	p.failures.Add(1)
+	if p.onError != nil {
+		p.onError(err)
+	}
+}
+
+// changedInstances 取出语句涉及的、主键非零的模型实例
+// 按条件批量更新或删除时没有实例，模板标签无法渲染，因此跳过
+func changedInstances(tx *gorm.DB) []interface{} {
+	primary := tx.Statement.Schema.PrioritizedPrimaryField
+	if primary == nil {
+		return nil
+	}
+
+	var result []interface{}
+	collect := func(rv reflect.Value) {
+		for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+			if rv.IsNil() {
+				return
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Struct {
+			return
+		}
+		if _, zero := primary.ValueOf(tx.Statement.Context, rv); zero {
+			return
+		}
+		result = append(result, rv.Interface())
+	}
+
+	rv := tx.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			collect(rv.Index(i))
+		}
+	default:
+		collect(rv)
+	}
+	return result
+}
+
+// indirectType 去掉指针、切片得到元素类型
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	return t
+}
+
+// uniqueSorted 去重并排序
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return result
+}
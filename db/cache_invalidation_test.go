@@ -0,0 +1,136 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/cache"
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type invTenant struct {
+	Code string
+}
+
+type invProduct struct {
+	ID        uint
+	Name      string
+	Tenant    invTenant `gorm:"embedded;embeddedPrefix:tenant_"`
+	DeletedAt gorm.DeletedAt
+}
+
+// recordingDeleter 记录删除的标签，可注入失败
+type recordingDeleter struct {
+	*cache.Manager
+	mu    sync.Mutex
+	calls []string
+	fail  error
+}
+
+func (r *recordingDeleter) TaggedDelete(ctx context.Context, tag string) error {
+	r.mu.Lock()
+	r.calls = append(r.calls, tag)
+	r.mu.Unlock()
+	if r.fail != nil {
+		return r.fail
+	}
+	return r.Manager.TaggedDelete(ctx, tag)
+}
+
+func newInvalidationDB(t *testing.T, strict bool) (*gorm.DB, *recordingDeleter, *db.CacheInvalidationPlugin) {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&invProduct{}))
+
+	manager := cache.NewManager()
+	manager.AddStore("memory", cache.NewMemoryStore())
+	deleter := &recordingDeleter{Manager: manager}
+	plugin := db.CacheInvalidation(deleter,
+		db.InvalidateModel(&invProduct{}, "products", "product:{{.ID}}", "tenant:{{.Tenant.Code}}:catalog"),
+	).WithErrorHandler(func(error) {})
+	if strict {
+		plugin.WithStrict()
+	}
+	require.NoError(t, conn.Use(plugin))
+	return conn, deleter, plugin
+}
+
+func TestCacheInvalidation_RendersTemplates(t *testing.T) {
+	conn, deleter, _ := newInvalidationDB(t, false)
+
+	product := invProduct{Name: "phone", Tenant: invTenant{Code: "acme"}}
+	require.NoError(t, conn.Create(&product).Error)
+	assert.Equal(t, []string{"product:1", "products", "tenant:acme:catalog"}, deleter.calls)
+
+	// 软删除同样触发失效
+	deleter.calls = nil
+	require.NoError(t, conn.Delete(&product).Error)
+	assert.Contains(t, deleter.calls, "product:1")
+
+	// 按条件更新没有实例，只失效静态标签
+	deleter.calls = nil
+	require.NoError(t, conn.Model(&invProduct{}).Where("name = ?", "x").Update("name", "y").Error)
+	assert.Equal(t, []string{"products"}, deleter.calls)
+}
+
+func TestCacheInvalidation_AfterCommitOnly(t *testing.T) {
+	conn, deleter, _ := newInvalidationDB(t, false)
+	ctx := context.Background()
+	require.NoError(t, deleter.Set(ctx, "catalog", "cached", cache.WithTags("products")))
+
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, tx.Create(&invProduct{Name: "a"}).Error)
+		assert.Empty(t, deleter.calls, "事务提交前不失效")
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+	assert.Empty(t, deleter.calls)
+	assert.True(t, deleter.Has(ctx, "catalog"), "回滚的事务不影响缓存")
+
+	require.NoError(t, conn.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&invProduct{Name: "b"}).Error
+	}))
+	assert.NotEmpty(t, deleter.calls)
+	assert.False(t, deleter.Has(ctx, "catalog"))
+}
+
+func TestCacheInvalidation_BatchesPerTransaction(t *testing.T) {
+	conn, deleter, _ := newInvalidationDB(t, false)
+
+	require.NoError(t, conn.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < 5; i++ {
+			if err := tx.Create(&invProduct{Name: "p", Tenant: invTenant{Code: "acme"}}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&invProduct{}).Where("1 = 1").Update("name", "q").Error
+	}))
+
+	assert.Equal(t, []string{
+		"product:1", "product:2", "product:3", "product:4", "product:5", "products", "tenant:acme:catalog",
+	}, deleter.calls, "同一事务中的标签去重后只删除一次")
+}
+
+func TestCacheInvalidation_StrictMode(t *testing.T) {
+	conn, deleter, plugin := newInvalidationDB(t, false)
+	deleter.fail = errors.New("cache down")
+	require.NoError(t, conn.Create(&invProduct{Name: "a"}).Error, "默认失效失败不影响数据库操作")
+	assert.Equal(t, int64(3), plugin.Failures(), "每个标签的失败单独计数")
+
+	conn, deleter, plugin = newInvalidationDB(t, true)
+	deleter.fail = errors.New("cache down")
+	err := conn.Create(&invProduct{Name: "a"}).Error
+	assert.ErrorIs(t, err, deleter.fail)
+	assert.Equal(t, int64(3), plugin.Failures(), "每个标签的失败单独计数")
+
+	var count int64
+	require.NoError(t, conn.Model(&invProduct{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "严格模式下数据已提交")
+}
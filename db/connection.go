@@ -30,6 +30,8 @@ const (
 	PostgreSQL = "postgres"
 	// SQLite 数据库
 	SQLite = "sqlite"
+	// ClickHouse 数据库，需通过 RegisterDialector 注册 gorm 驱动
+	ClickHouse = "clickhouse"
 )
 
 // 定义错误类型
@@ -73,6 +75,11 @@ type Config struct {
 	LogLevel      logger.LogLevel `yaml:"log_level" json:"log_level"`
 	SlowThreshold time.Duration   `yaml:"slow_threshold" json:"slow_threshold"`
 
+	// 超时配置：传入的上下文没有截止时间时应用默认超时，statement_timeout 为数据库会话级限制
+	QueryTimeout     time.Duration `yaml:"query_timeout" json:"query_timeout"`
+	ExecTimeout      time.Duration `yaml:"exec_timeout" json:"exec_timeout"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" json:"statement_timeout"`
+
 	// 主从配置
 	Replicas []ReplicaConfig `yaml:"replicas" json:"replicas"`
 
@@ -81,6 +88,19 @@ type Config struct {
 	HealthCheckPeriod  time.Duration `yaml:"health_check_period" json:"health_check_period"`
 	HealthCheckTimeout time.Duration `yaml:"health_check_timeout" json:"health_check_timeout"`
 	HealthCheckSQL     string        `yaml:"health_check_sql" json:"health_check_sql"`
+
+	// ClickHouse 配置
+	Cluster     string        `yaml:"cluster" json:"cluster"`         // 集群名称，配合 ClusterTableOptions 使用
+	Secure      bool          `yaml:"secure" json:"secure"`           // 使用TLS连接
+	Compression string        `yaml:"compression" json:"compression"` // 压缩算法：lz4、zstd等
+	DialTimeout time.Duration `yaml:"dial_timeout" json:"dial_timeout"`
+
+	// 命名策略与 GORM 选项
+	Naming NamingConfig `yaml:"naming" json:"naming"`
+	Gorm   GormConfig   `yaml:"gorm" json:"gorm"`
+
+	// SQLite 连接选项
+	SQLite SQLiteConfig `yaml:"sqlite" json:"sqlite"`
 }
 
 // ReplicaConfig 从库配置
@@ -109,6 +129,10 @@ type Manager struct {
 	healthCtx context.Context
 	// 健康检查取消函数
 	healthCancel context.CancelFunc
+	// 连接建立后执行的钩子，键为连接名称，* 表示所有连接
+	connectHooks map[string][]func(*gorm.DB) error
+	// SQLite :memory: 连接共享的内存数据库名称，每个管理器独立
+	memoryName string
 }
 
 // NewManager 创建数据库连接管理器
@@ -120,6 +144,8 @@ func NewManager() *Manager {
 		healthStatus: make(map[string]bool),
 		healthCtx:    ctx,
 		healthCancel: cancel,
+		connectHooks: make(map[string][]func(*gorm.DB) error),
+		memoryName:   fmt.Sprintf("flow_memory_%d", memoryDatabaseSeq.Add(1)),
 	}
 }
 
@@ -141,6 +167,7 @@ func (m *Manager) Register(name string, config Config) error {
 	}
 
 	// 设置默认值
+	maxOpenSet := config.MaxOpenConns > 0
 	if config.MaxIdleConns <= 0 {
 		config.MaxIdleConns = 10
 	}
@@ -159,6 +186,9 @@ func (m *Manager) Register(name string, config Config) error {
 	if config.HealthCheckSQL == "" {
 		config.HealthCheckSQL = "SELECT 1"
 	}
+	if config.Driver == SQLite {
+		applySQLitePoolDefaults(&config, maxOpenSet)
+	}
 
 	// 保存配置
 	m.configs[name] = config
@@ -213,6 +243,24 @@ func (m *Manager) Connect(name string) (*gorm.DB, error) {
 	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
 	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
 
+	// 注册默认超时
+	if config.QueryTimeout > 0 || config.ExecTimeout > 0 {
+		if err := EnableTimeouts(db, TimeoutConfig{Query: config.QueryTimeout, Exec: config.ExecTimeout}); err != nil {
+			return nil, err
+		}
+	}
+
+	// 注册 Server-Timing 耗时统计
+	if err := EnableTiming(db); err != nil {
+		return nil, err
+	}
+
+	// 执行连接钩子，失败时关闭连接，不交给调用方
+	if err := m.runConnectHooks(name, db); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+
 	// 保存连接
 	m.connections[name] = db
 	m.healthStatus[name] = true
@@ -323,6 +371,7 @@ func (m *Manager) createConnection(config Config) (*gorm.DB, error) {
 			config.Charset,
 			config.TimeZone,
 		)
+		dsn, _ = statementTimeoutDSN(config.Driver, dsn, config.StatementTimeout)
 		dialector = mysqldriver.Open(dsn)
 
 	case PostgreSQL:
@@ -336,13 +385,24 @@ func (m *Manager) createConnection(config Config) (*gorm.DB, error) {
 			config.SSLMode,
 			config.TimeZone,
 		)
+		dsn, _ = statementTimeoutDSN(config.Driver, dsn, config.StatementTimeout)
 		dialector = postgres.Open(dsn)
 
 	case SQLite:
-		dialector = sqlite.Open(config.Database)
+		if config.StatementTimeout > 0 {
+			log.Printf("[DB] SQLite 不支持会话级 statement_timeout，已忽略，请使用 query_timeout/exec_timeout")
+		}
+		dialector = sqlite.Open(sqliteDSN(config.Database, config.SQLite, m.memoryName))
 
 	default:
-		return nil, ErrUnsupportedDriver
+		open, ok := lookupDialector(config.Driver)
+		if !ok {
+			if config.Driver == ClickHouse {
+				return nil, fmt.Errorf("%w: clickhouse 需先调用 db.RegisterDialector(db.ClickHouse, clickhouse.Open) 注册 gorm.io/driver/clickhouse", ErrUnsupportedDriver)
+			}
+			return nil, ErrUnsupportedDriver
+		}
+		dialector = open(driverDSN(config))
 	}
 
 	// 创建日志配置
@@ -353,12 +413,20 @@ func (m *Manager) createConnection(config Config) (*gorm.DB, error) {
 		Colorful:                  true,
 	}
 
+	if level, ok := parseLogLevel(config.Gorm.LogLevel); ok {
+		logConfig.LogLevel = level
+	}
+
 	// 创建GORM配置
 	gormConfig := &gorm.Config{
 		Logger: logger.New(
 			log.New(os.Stdout, "\r\n", log.LstdFlags), // 使用标准日志输出
 			logConfig,
 		),
+		NamingStrategy:         config.Naming.Strategy(),
+		PrepareStmt:            config.Gorm.PrepareStmt,
+		SkipDefaultTransaction: config.Gorm.SkipDefaultTransaction,
+		DryRun:                 config.Gorm.DryRun,
 	}
 
 	return gorm.Open(dialector, gormConfig)
@@ -456,7 +524,7 @@ func (m *Manager) fromNestedConfig(configManager *config.ConfigManager) error {
 			TimeZone: getString(connMap, "timezone", "Local"),
 
 			MaxIdleConns:    getInt(connMap, "max_idle_conns", 10),
-			MaxOpenConns:    getInt(connMap, "max_open_conns", 100),
+			MaxOpenConns:    getInt(connMap, "max_open_conns", 0),
 			ConnMaxLifetime: getDuration(connMap, "conn_max_lifetime", time.Hour),
 			ConnMaxIdleTime: getDuration(connMap, "conn_max_idle_time", 30*time.Minute),
 
@@ -464,6 +532,14 @@ func (m *Manager) fromNestedConfig(configManager *config.ConfigManager) error {
 			HealthCheckPeriod:  getDuration(connMap, "health_check_period", 30*time.Second),
 			HealthCheckTimeout: getDuration(connMap, "health_check_timeout", 5*time.Second),
 			HealthCheckSQL:     getString(connMap, "health_check_sql", "SELECT 1"),
+
+			QueryTimeout:     getDuration(connMap, "query_timeout", 0),
+			ExecTimeout:      getDuration(connMap, "exec_timeout", 0),
+			StatementTimeout: getDuration(connMap, "statement_timeout", 0),
+
+			Naming: namingConfigFromMap(connMap["naming"]),
+			Gorm:   gormConfigFromMap(connMap["gorm"]),
+			SQLite: sqliteConfigFromMap(connMap["sqlite"]),
 		}
 
 		// 注册配置
@@ -503,6 +579,19 @@ func (m *Manager) fromFlatConfig(configManager *config.ConfigManager) error {
 		HealthCheckPeriod:  configManager.GetDuration("database.health_check_period"),
 		HealthCheckTimeout: configManager.GetDuration("database.health_check_timeout"),
 		HealthCheckSQL:     configManager.GetString("database.health_check_sql"),
+
+		QueryTimeout:     configManager.GetDuration("database.query_timeout"),
+		ExecTimeout:      configManager.GetDuration("database.exec_timeout"),
+		StatementTimeout: configManager.GetDuration("database.statement_timeout"),
+
+		Cluster:     configManager.GetString("database.cluster"),
+		Secure:      configManager.GetBool("database.secure"),
+		Compression: configManager.GetString("database.compression"),
+		DialTimeout: configManager.GetDuration("database.dial_timeout"),
+
+		Naming: namingConfigFromMap(configManager.Get("database.naming")),
+		Gorm:   gormConfigFromMap(configManager.Get("database.gorm")),
+		SQLite: sqliteConfigFromMap(configManager.Get("database.sqlite")),
 	}
 
 	// 设置默认值
@@ -512,6 +601,8 @@ func (m *Manager) fromFlatConfig(configManager *config.ConfigManager) error {
 			config.Port = 3306
 		case PostgreSQL:
 			config.Port = 5432
+		case ClickHouse:
+			config.Port = 9000
 		}
 	}
 
@@ -523,10 +614,6 @@ func (m *Manager) fromFlatConfig(configManager *config.ConfigManager) error {
 		config.MaxIdleConns = 10
 	}
 
-	if config.MaxOpenConns == 0 {
-		config.MaxOpenConns = 100
-	}
-
 	if config.ConnMaxLifetime == 0 {
 		config.ConnMaxLifetime = time.Hour
 	}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// DialectorOpener 根据DSN创建gorm方言，签名与各 gorm 驱动的 Open 函数一致
+type DialectorOpener func(dsn string) gorm.Dialector
+
+var (
+	dialectorsMu sync.RWMutex
+	dialectors   = make(map[string]DialectorOpener)
+)
+
+// RegisterDialector 注册额外的数据库驱动，使 Config.Driver 可以使用内置驱动以外的数据库
+//
+// 例如 ClickHouse：
+//
+//	import "gorm.io/driver/clickhouse"
+//
+//	db.RegisterDialector(db.ClickHouse, clickhouse.Open)
+func RegisterDialector(driver string, open DialectorOpener) {
+	dialectorsMu.Lock()
+	defer dialectorsMu.Unlock()
+	dialectors[driver] = open
+}
+
+// lookupDialector 查找已注册的驱动
+func lookupDialector(driver string) (DialectorOpener, bool) {
+	dialectorsMu.RLock()
+	defer dialectorsMu.RUnlock()
+	open, ok := dialectors[driver]
+	return open, ok
+}
+
+// driverDSN 为注册的驱动构建DSN
+func driverDSN(config Config) string {
+	if config.Driver == ClickHouse {
+		return ClickHouseDSN(config)
+	}
+	return fmt.Sprintf("%s://%s:%s@%s:%d/%s",
+		config.Driver, url.QueryEscape(config.Username), url.QueryEscape(config.Password),
+		config.Host, config.Port, config.Database)
+}
+
+// ClickHouseDSN 构建 clickhouse-go v2 格式的DSN
+func ClickHouseDSN(config Config) string {
+	dsn := url.URL{
+		Scheme: "clickhouse",
+		Host:   fmt.Sprintf("%s:%d", config.Host, config.Port),
+		Path:   "/" + config.Database,
+	}
+	if config.Username != "" {
+		dsn.User = url.UserPassword(config.Username, config.Password)
+	}
+
+	query := url.Values{}
+	if config.DialTimeout > 0 {
+		query.Set("dial_timeout", config.DialTimeout.String())
+	}
+	if config.Compression != "" {
+		query.Set("compress", strings.ToLower(config.Compression))
+	}
+	if config.Secure {
+		query.Set("secure", "true")
+	}
+	if config.QueryTimeout > 0 {
+		query.Set("read_timeout", config.QueryTimeout.String())
+	}
+	dsn.RawQuery = query.Encode()
+	return dsn.String()
+}
+
+// TableOptions 为 AutoMigrate 创建的表附加建表选项，用于 ClickHouse 的表引擎、排序键等
+//
+//	db.TableOptions(conn, "ENGINE=MergeTree() ORDER BY (tenant_id, created_at)").AutoMigrate(&Event{})
+func TableOptions(conn *gorm.DB, options string) *gorm.DB {
+	return conn.Set("gorm:table_options", options)
+}
+
+// ClusterTableOptions 生成分布式建表选项，cluster 为空时与 TableOptions 相同
+func ClusterTableOptions(conn *gorm.DB, cluster, options string) *gorm.DB {
+	if cluster == "" {
+		return TableOptions(conn, options)
+	}
+	return conn.Set("gorm:table_cluster_options", "ON CLUSTER "+cluster).Set("gorm:table_options", options)
+}
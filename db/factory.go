@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Factory 模型工厂，用于生成测试与开发数据
+//
+//	users := db.DefineFactory(func(f *db.Faker) User {
+//		return User{Name: f.Name(), Email: f.Email()}
+//	}).State("admin", func(u *User) { u.Role = "admin" })
+//
+//	admins, err := users.With("admin").Create(ctx, conn, 3)
+type Factory[T any] struct {
+	build     func(f *Faker) T
+	states    map[string]func(*T)
+	active    []string
+	related   []func(tx *gorm.DB, parent *T) error
+	faker     *Faker
+	batchSize int
+}
+
+// DefineFactory 定义模型工厂，build 描述如何用假数据构建一个模型
+func DefineFactory[T any](build func(f *Faker) T) *Factory[T] {
+	return &Factory[T]{
+		build:     build,
+		states:    make(map[string]func(*T)),
+		faker:     NewFaker(time.Now().UnixNano(), LocaleEnUS),
+		batchSize: 100,
+	}
+}
+
+// State 定义一个状态（变体），With 启用后在构建时依次应用
+func (f *Factory[T]) State(name string, mutator func(*T)) *Factory[T] {
+	f.states[name] = mutator
+	return f
+}
+
+// With 返回启用指定状态的工厂副本
+func (f *Factory[T]) With(states ...string) *Factory[T] {
+	clone := f.clone()
+	clone.active = append(clone.active, states...)
+	return clone
+}
+
+// Seed 返回使用固定种子的工厂副本，相同种子生成相同的数据
+func (f *Factory[T]) Seed(seed int64) *Factory[T] {
+	clone := f.clone()
+	clone.faker = NewFaker(seed, f.faker.Locale())
+	return clone
+}
+
+// Locale 返回使用指定语言的工厂副本
+func (f *Factory[T]) Locale(locale string) *Factory[T] {
+	clone := f.clone()
+	clone.faker = NewFaker(f.faker.rnd.Int63(), locale)
+	return clone
+}
+
+// BatchSize 返回使用指定批量大小的工厂副本
+func (f *Factory[T]) BatchSize(size int) *Factory[T] {
+	clone := f.clone()
+	if size > 0 {
+		clone.batchSize = size
+	}
+	return clone
+}
+
+// Make 构建 n 个模型但不保存，overrides 在状态之后应用
+func (f *Factory[T]) Make(n int, overrides ...func(*T)) ([]T, error) {
+	for _, name := range f.active {
+		if _, ok := f.states[name]; !ok {
+			return nil, fmt.Errorf("工厂状态未定义: %s", name)
+		}
+	}
+
+	items := make([]T, n)
+	for i := range items {
+		item := f.build(f.faker)
+		for _, name := range f.active {
+			f.states[name](&item)
+		}
+		for _, override := range overrides {
+			override(&item)
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// Create 构建并批量保存 n 个模型，返回保存后的模型（包含数据库生成的主键）
+//
+// 模型与关联数据在同一个事务中创建，任一步失败时全部回滚
+func (f *Factory[T]) Create(ctx context.Context, db *gorm.DB, n int, overrides ...func(*T)) ([]T, error) {
+	items, err := f.Make(n, overrides...)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return items, nil
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&items, f.batchSize).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			for _, create := range f.related {
+				if err := create(tx, &items[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Seeder 将工厂包装为种子数据，供 SeederManager 执行
+func (f *Factory[T]) Seeder(name string, n int, dependencies ...string) Seeder {
+	return NewSeeder(name, func(db *gorm.DB) error {
+		_, err := f.Create(context.Background(), db, n)
+		return err
+	}, dependencies...)
+}
+
+// clone 复制工厂，状态定义共享，启用的状态与关联各自独立
+func (f *Factory[T]) clone() *Factory[T] {
+	clone := *f
+	clone.active = append([]string(nil), f.active...)
+	clone.related = append([]func(tx *gorm.DB, parent *T) error(nil), f.related...)
+	return &clone
+}
+
+// WithRelated 返回在创建父模型后创建关联模型的工厂副本
+//
+// children 接收已保存的父模型（主键已生成），返回的子模型应设置外键：
+//
+//	users = db.WithRelated(users, func(u User) []Post {
+//		posts, _ := postFactory.Make(2, func(p *Post) { p.UserID = u.ID })
+//		return posts
+//	})
+func WithRelated[T, C any](f *Factory[T], children func(parent T) []C) *Factory[T] {
+	clone := f.clone()
+	clone.related = append(clone.related, func(tx *gorm.DB, parent *T) error {
+		items := children(*parent)
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&items, f.batchSize).Error
+	})
+	return clone
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type factoryUser struct {
+	ID     uint
+	Name   string
+	Email  string `gorm:"uniqueIndex"`
+	Phone  string
+	Role   string
+	Status string
+}
+
+type factoryPost struct {
+	ID            uint
+	FactoryUserID uint
+	Title         string
+}
+
+func newFactoryDB(t *testing.T) *gorm.DB {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&factoryUser{}, &factoryPost{}))
+	return conn
+}
+
+func userFactory() *Factory[factoryUser] {
+	return DefineFactory(func(f *Faker) factoryUser {
+		return factoryUser{
+			Name:   f.Name(),
+			Email:  f.Email(),
+			Phone:  f.Phone(),
+			Role:   "member",
+			Status: f.Weighted(map[string]int{"active": 8, "banned": 2}),
+		}
+	}).State("admin", func(u *factoryUser) { u.Role = "admin" })
+}
+
+func TestFactory_Deterministic(t *testing.T) {
+	a, err := userFactory().Seed(7).Make(5)
+	require.NoError(t, err)
+	b, err := userFactory().Seed(7).Make(5)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	c, err := userFactory().Seed(8).Make(5)
+	require.NoError(t, err)
+	assert.NotEqual(t, a, c)
+}
+
+func TestFactory_CreateWithStatesAndRelations(t *testing.T) {
+	conn := newFactoryDB(t)
+	posts := DefineFactory(func(f *Faker) factoryPost {
+		return factoryPost{Title: f.Sentence(3)}
+	})
+
+	var userInserts int
+	require.NoError(t, conn.Callback().Create().Before("gorm:create").Register("test:count", func(tx *gorm.DB) {
+		if tx.Statement.Table == "factory_users" {
+			userInserts++
+		}
+	}))
+
+	users := WithRelated(userFactory().Seed(1).BatchSize(2), func(u factoryUser) []factoryPost {
+		items, _ := posts.Make(2, func(p *factoryPost) { p.FactoryUserID = u.ID })
+		return items
+	})
+
+	created, err := users.With("admin").Create(context.Background(), conn, 5, func(u *factoryUser) {
+		u.Status = "active"
+	})
+	require.NoError(t, err)
+	require.Len(t, created, 5)
+	// 批量大小为2，5条记录分3次插入
+	assert.Equal(t, 3, userInserts)
+
+	var count int64
+	require.NoError(t, conn.Model(&factoryUser{}).Where("role = ? AND status = ?", "admin", "active").Count(&count).Error)
+	assert.Equal(t, int64(5), count)
+
+	// 关联数据的外键指向已创建的用户
+	var orphans int64
+	require.NoError(t, conn.Model(&factoryPost{}).
+		Where("factory_user_id NOT IN (?)", conn.Model(&factoryUser{}).Select("id")).Count(&orphans).Error)
+	assert.Zero(t, orphans)
+	require.NoError(t, conn.Model(&factoryPost{}).Count(&count).Error)
+	assert.Equal(t, int64(10), count)
+
+	_, err = users.With("unknown").Make(1)
+	assert.Error(t, err)
+}
+
+func TestFactory_Seeder(t *testing.T) {
+	conn := newFactoryDB(t)
+	manager := NewSeederManager(conn)
+	require.NoError(t, manager.Register(userFactory().Seeder("users", 3)))
+	require.NoError(t, manager.Run())
+
+	var count int64
+	require.NoError(t, conn.Model(&factoryUser{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestFaker_Locale(t *testing.T) {
+	f := NewFaker(1, LocaleZhCN)
+	for i := 0; i < 20; i++ {
+		assert.Regexp(t, regexp.MustCompile(`^1[35789]\d{9}$`), f.Phone())
+		name := f.Name()
+		count := utf8.RuneCountInString(name)
+		assert.True(t, count >= 2 && count <= 3, name)
+		assert.Regexp(t, `^[a-z]+\d+@example\.(cn|com\.cn)$`, f.Email())
+	}
+
+	en := NewFaker(1, LocaleEnUS)
+	assert.Regexp(t, `^\+1-555-\d{3}-\d{4}$`, en.Phone())
+	assert.Regexp(t, `^[A-Z][a-z]+ [A-Z][a-z]+$`, en.Name())
+}
@@ -0,0 +1,211 @@
+package db
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 支持的假数据语言
+const (
+	// LocaleEnUS 英文
+	LocaleEnUS = "en_US"
+	// LocaleZhCN 简体中文
+	LocaleZhCN = "zh_CN"
+)
+
+// fakerLocale 语言相关的假数据
+type fakerLocale struct {
+	firstNames []string
+	lastNames  []string
+	domains    []string
+	words      []string
+	phone      func(f *Faker) string
+	fullName   func(first, last string) string
+}
+
+var fakerLocales = map[string]fakerLocale{
+	LocaleEnUS: {
+		firstNames: []string{"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "William", "Elizabeth", "David", "Susan", "Richard", "Jessica", "Joseph", "Sarah"},
+		lastNames:  []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez", "Wilson", "Anderson", "Taylor", "Thomas", "Moore", "Jackson"},
+		domains:    []string{"example.com", "example.org", "example.net"},
+		words:      []string{"alpha", "bravo", "quick", "lazy", "river", "stone", "cloud", "light", "forest", "signal", "orbit", "harbor", "summit", "velvet", "ember", "meadow"},
+		phone: func(f *Faker) string {
+			return fmt.Sprintf("+1-555-%03d-%04d", f.rnd.Intn(1000), f.rnd.Intn(10000))
+		},
+		fullName: func(first, last string) string {
+			return first + " " + last
+		},
+	},
+	LocaleZhCN: {
+		firstNames: []string{"伟", "芳", "娜", "敏", "静", "丽", "强", "磊", "军", "洋", "勇", "艳", "杰", "娟", "涛", "明", "超", "秀英", "桂英", "志强"},
+		lastNames:  []string{"王", "李", "张", "刘", "陈", "杨", "赵", "黄", "周", "吴", "徐", "孙", "胡", "朱", "高", "林"},
+		domains:    []string{"example.cn", "example.com.cn"},
+		words:      []string{"数据", "系统", "服务", "用户", "订单", "产品", "市场", "管理", "平台", "技术", "设计", "开发", "测试", "运营", "支付", "消息"},
+		phone: func(f *Faker) string {
+			prefixes := []string{"13", "15", "17", "18", "19"}
+			return fmt.Sprintf("%s%d%08d", prefixes[f.rnd.Intn(len(prefixes))], f.rnd.Intn(10), f.rnd.Intn(100000000))
+		},
+		fullName: func(first, last string) string {
+			return last + first
+		},
+	},
+}
+
+// emailNames 用于生成邮箱地址的拉丁字母用户名
+var emailNames = []string{"alex", "chen", "li", "sam", "jordan", "wang", "taylor", "zhang", "morgan", "liu", "casey", "zhao", "riley", "yang", "jamie", "huang"}
+
+// Faker 假数据生成器，相同的种子与语言生成相同的数据序列
+//
+// Faker 不是并发安全的，每个工厂持有自己的实例
+type Faker struct {
+	rnd    *rand.Rand
+	locale fakerLocale
+	name   string
+	seq    int
+}
+
+// NewFaker 创建假数据生成器，不支持的语言使用 en_US
+func NewFaker(seed int64, locale string) *Faker {
+	data, ok := fakerLocales[locale]
+	if !ok {
+		locale = LocaleEnUS
+		data = fakerLocales[LocaleEnUS]
+	}
+	return &Faker{
+		rnd:    rand.New(rand.NewSource(seed)),
+		locale: data,
+		name:   locale,
+	}
+}
+
+// Locale 返回当前语言
+func (f *Faker) Locale() string {
+	return f.name
+}
+
+// Rand 返回底层随机数生成器
+func (f *Faker) Rand() *rand.Rand {
+	return f.rnd
+}
+
+// Int 返回 [min, max] 范围内的整数
+func (f *Faker) Int(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + f.rnd.Intn(max-min+1)
+}
+
+// Float 返回 [min, max) 范围内的浮点数
+func (f *Faker) Float(min, max float64) float64 {
+	return min + f.rnd.Float64()*(max-min)
+}
+
+// Bool 以指定概率返回true
+func (f *Faker) Bool(probability float64) bool {
+	return f.rnd.Float64() < probability
+}
+
+// Pick 从候选值中随机选择一个
+func (f *Faker) Pick(values ...string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[f.rnd.Intn(len(values))]
+}
+
+// Weighted 按权重选择枚举值，如 {"active": 8, "banned": 1}
+func (f *Faker) Weighted(weights map[string]int) string {
+	keys := make([]string, 0, len(weights))
+	total := 0
+	for key, weight := range weights {
+		if weight > 0 {
+			keys = append(keys, key)
+			total += weight
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	// 按键排序，保证相同种子的结果一致
+	sort.Strings(keys)
+
+	n := f.rnd.Intn(total)
+	for _, key := range keys {
+		n -= weights[key]
+		if n < 0 {
+			return key
+		}
+	}
+	return keys[len(keys)-1]
+}
+
+// FirstName 返回名
+func (f *Faker) FirstName() string {
+	return f.Pick(f.locale.firstNames...)
+}
+
+// LastName 返回姓
+func (f *Faker) LastName() string {
+	return f.Pick(f.locale.lastNames...)
+}
+
+// Name 返回符合语言习惯的全名
+func (f *Faker) Name() string {
+	return f.locale.fullName(f.FirstName(), f.LastName())
+}
+
+// Username 返回用户名
+func (f *Faker) Username() string {
+	f.seq++
+	return fmt.Sprintf("%s%d", f.Pick(emailNames...), f.seq)
+}
+
+// Email 返回邮箱地址，同一生成器内不会重复
+func (f *Faker) Email() string {
+	return f.Username() + "@" + f.Pick(f.locale.domains...)
+}
+
+// Phone 返回符合语言格式的电话号码，zh_CN 为11位手机号
+func (f *Faker) Phone() string {
+	return f.locale.phone(f)
+}
+
+// Word 返回一个词
+func (f *Faker) Word() string {
+	return f.Pick(f.locale.words...)
+}
+
+// Sentence 返回由指定数量的词组成的句子
+func (f *Faker) Sentence(words int) string {
+	parts := make([]string, words)
+	for i := range parts {
+		parts[i] = f.Word()
+	}
+	if f.name == LocaleZhCN {
+		return strings.Join(parts, "") + "。"
+	}
+	sentence := strings.Join(parts, " ")
+	if sentence != "" {
+		sentence = strings.ToUpper(sentence[:1]) + sentence[1:] + "."
+	}
+	return sentence
+}
+
+// TimeBetween 返回 [from, to) 范围内的时间
+func (f *Faker) TimeBetween(from, to time.Time) time.Time {
+	span := to.Sub(from)
+	if span <= 0 {
+		return from
+	}
+	return from.Add(time.Duration(f.rnd.Int63n(int64(span))))
+}
+
+// Past 返回过去 within 时间内的时间
+func (f *Faker) Past(within time.Duration) time.Time {
+	now := time.Now()
+	return f.TimeBetween(now.Add(-within), now)
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// NamingConfig 表名与列名的命名策略，对应 schema.NamingStrategy
+//
+//	naming:
+//	  table_prefix: "shop_"
+//	  singular_table: true
+//	  name_replacer: ["CID", "Cid"]   # 成对的替换规则，在转换为蛇形命名之前应用
+type NamingConfig struct {
+	TablePrefix   string   `yaml:"table_prefix" json:"table_prefix"`
+	SingularTable bool     `yaml:"singular_table" json:"singular_table"`
+	NoLowerCase   bool     `yaml:"no_lower_case" json:"no_lower_case"`
+	NameReplacer  []string `yaml:"name_replacer" json:"name_replacer"`
+}
+
+// Strategy 转换为 GORM 命名策略
+func (c NamingConfig) Strategy() schema.NamingStrategy {
+	strategy := schema.NamingStrategy{
+		TablePrefix:   c.TablePrefix,
+		SingularTable: c.SingularTable,
+		NoLowerCase:   c.NoLowerCase,
+	}
+	if len(c.NameReplacer) >= 2 {
+		strategy.NameReplacer = strings.NewReplacer(c.NameReplacer[:len(c.NameReplacer)/2*2]...)
+	}
+	return strategy
+}
+
+// GormConfig GORM 会话选项
+//
+//	gorm:
+//	  prepare_stmt: true
+//	  skip_default_transaction: true
+//	  log_level: warn   # silent、error、warn、info，设置后覆盖 log_level
+type GormConfig struct {
+	PrepareStmt            bool   `yaml:"prepare_stmt" json:"prepare_stmt"`
+	SkipDefaultTransaction bool   `yaml:"skip_default_transaction" json:"skip_default_transaction"`
+	DryRun                 bool   `yaml:"dry_run" json:"dry_run"`
+	LogLevel               string `yaml:"log_level" json:"log_level"`
+}
+
+// parseLogLevel 解析日志级别名称
+func parseLogLevel(level string) (logger.LogLevel, bool) {
+	switch strings.ToLower(level) {
+	case "silent":
+		return logger.Silent, true
+	case "error":
+		return logger.Error, true
+	case "warn", "warning":
+		return logger.Warn, true
+	case "info":
+		return logger.Info, true
+	}
+	return 0, false
+}
+
+// namingConfigFromMap 从配置映射读取命名策略
+func namingConfigFromMap(value interface{}) NamingConfig {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return NamingConfig{}
+	}
+	config := NamingConfig{
+		TablePrefix:   getString(m, "table_prefix", ""),
+		SingularTable: getBool(m, "singular_table", false),
+		NoLowerCase:   getBool(m, "no_lower_case", false),
+	}
+	if pairs, ok := m["name_replacer"].([]interface{}); ok {
+		for _, pair := range pairs {
+			config.NameReplacer = append(config.NameReplacer, fmt.Sprint(pair))
+		}
+	}
+	return config
+}
+
+// gormConfigFromMap 从配置映射读取 GORM 选项
+func gormConfigFromMap(value interface{}) GormConfig {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return GormConfig{}
+	}
+	return GormConfig{
+		PrepareStmt:            getBool(m, "prepare_stmt", false),
+		SkipDefaultTransaction: getBool(m, "skip_default_transaction", false),
+		DryRun:                 getBool(m, "dry_run", false),
+		LogLevel:               getString(m, "log_level", ""),
+	}
+}
+
+// OnConnect 注册连接建立后执行的钩子，用于注册插件、回调等；name 为 * 时作用于所有连接
+// 钩子按注册顺序执行，先执行 * 的钩子；任一钩子返回错误时连接被关闭，Connect 返回该错误。
+// 钩子执行时持有管理器的锁，不能在钩子中调用管理器的方法
+//
+//	manager.OnConnect("default", func(conn *gorm.DB) error {
+//		return conn.Use(search.NewPlugin(engine))
+//	})
+func (m *Manager) OnConnect(name string, fn func(*gorm.DB) error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.connectHooks[name] = append(m.connectHooks[name], fn)
+}
+
+// runConnectHooks 执行连接钩子，调用方需持有写锁
+func (m *Manager) runConnectHooks(name string, conn *gorm.DB) error {
+	hooks := append(append([]func(*gorm.DB) error{}, m.connectHooks["*"]...), m.connectHooks[name]...)
+	for i, hook := range hooks {
+		if err := hook(conn); err != nil {
+			return fmt.Errorf("数据库连接 %s 的第 %d 个连接钩子失败: %w", name, i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package db_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const namingYAML = `
+database:
+  default: main
+  connections:
+    main:
+      driver: sqlite
+      database: ":memory:"
+      naming:
+        table_prefix: "shop_"
+        singular_table: true
+        name_replacer: ["CID", "Cid"]
+      gorm:
+        skip_default_transaction: true
+        dry_run: true
+        log_level: silent
+`
+
+type OrderItem struct {
+	ID         uint
+	ProductCID string
+}
+
+func loadNamingManager(t *testing.T) *db.Manager {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(namingYAML), 0o644))
+	cm := config.NewConfigManager(config.WithConfigPath(dir))
+	require.NoError(t, cm.Load())
+
+	manager := db.NewManager()
+	require.NoError(t, manager.FromConfig(cm))
+	return manager
+}
+
+func TestManager_NamingAndGormConfigFromYAML(t *testing.T) {
+	manager := loadNamingManager(t)
+
+	cfg := manager.Configs()["main"]
+	assert.Equal(t, db.NamingConfig{TablePrefix: "shop_", SingularTable: true, NameReplacer: []string{"CID", "Cid"}}, cfg.Naming)
+	assert.Equal(t, db.GormConfig{SkipDefaultTransaction: true, DryRun: true, LogLevel: "silent"}, cfg.Gorm)
+
+	conn, err := manager.Default()
+	require.NoError(t, err)
+	assert.True(t, conn.Config.SkipDefaultTransaction)
+
+	stmt := conn.Create(&OrderItem{ProductCID: "x"}).Statement
+	assert.Contains(t, stmt.SQL.String(), "INSERT INTO `shop_order_item` (`product_cid`)")
+}
+
+func TestManager_OnConnect(t *testing.T) {
+	manager := db.NewManager()
+	require.NoError(t, manager.Register("main", db.Config{Driver: db.SQLite, Database: ":memory:", LogLevel: logger.Silent}))
+	require.NoError(t, manager.Register("broken", db.Config{Driver: db.SQLite, Database: ":memory:", LogLevel: logger.Silent}))
+
+	var order []string
+	manager.OnConnect("main", func(*gorm.DB) error { order = append(order, "main-1"); return nil })
+	manager.OnConnect("*", func(*gorm.DB) error { order = append(order, "all"); return nil })
+	manager.OnConnect("main", func(*gorm.DB) error { order = append(order, "main-2"); return nil })
+	hookErr := errors.New("插件注册失败")
+	manager.OnConnect("broken", func(*gorm.DB) error { return hookErr })
+
+	_, err := manager.Connection("main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"all", "main-1", "main-2"}, order)
+
+	// 已建立的连接不重复执行钩子
+	_, err = manager.Connection("main")
+	require.NoError(t, err)
+	assert.Len(t, order, 3)
+
+	_, err = manager.Connection("broken")
+	assert.ErrorIs(t, err, hookErr)
+	assert.False(t, manager.HasConnection("broken"), "钩子失败的连接不交给调用方")
+}
@@ -0,0 +1,356 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 索引管理相关错误
+var (
+	// ErrIndexUnsupported 当前数据库不支持的索引选项，例如 MySQL 的部分索引
+	ErrIndexUnsupported = errors.New("当前数据库不支持该索引操作")
+	// ErrIndexDryRun 演练模式没有数据库连接，不能读取索引
+	ErrIndexDryRun = errors.New("演练模式不能读取数据库中的索引")
+)
+
+// IndexSpec 要创建的索引
+type IndexSpec struct {
+	Table   string
+	Name    string
+	Columns []string // 列名或表达式，例如 "lower(email)"、"created_at DESC"
+	Unique  bool
+	// Where 部分索引的条件，MySQL 不支持
+	Where string
+	// Method 索引方法，例如 btree、hash、gin，SQLite 不支持
+	Method string
+	// Concurrent 不阻塞写入地创建索引：PostgreSQL 使用 CREATE INDEX CONCURRENTLY，
+	// MySQL 使用 ALGORITHM=INPLACE LOCK=NONE，SQLite 不支持
+	Concurrent bool
+}
+
+// DropIndexOptions 删除索引的选项
+type DropIndexOptions struct {
+	IfExists   bool
+	Concurrent bool // 不阻塞读写地删除，仅 PostgreSQL 与 MySQL 支持
+}
+
+// IndexProgress PostgreSQL 并发建索引的进度，来自 pg_stat_progress_create_index
+type IndexProgress struct {
+	Table       string
+	Name        string
+	Phase       string
+	BlocksDone  int64
+	BlocksTotal int64
+	TuplesDone  int64
+	TuplesTotal int64
+}
+
+// IndexManagerOption 索引管理器选项
+type IndexManagerOption func(*IndexManager)
+
+// WithIndexProgress 设置并发建索引的进度回调，仅 PostgreSQL 轮询进度
+func WithIndexProgress(fn func(IndexProgress)) IndexManagerOption {
+	return func(m *IndexManager) {
+		m.onProgress = fn
+	}
+}
+
+// WithIndexPollInterval 设置轮询建索引进度的间隔，默认5秒
+func WithIndexPollInterval(interval time.Duration) IndexManagerOption {
+	return func(m *IndexManager) {
+		if interval > 0 {
+			m.pollInterval = interval
+		}
+	}
+}
+
+// IndexManager 在运行时管理索引，按方言生成SQL
+//
+// 索引操作可能耗时较长，不应用连接的默认超时，可通过 ctx 控制；
+// PostgreSQL 的 CREATE INDEX CONCURRENTLY 不能在事务中执行，传入事务连接时返回错误
+type IndexManager struct {
+	db           *gorm.DB
+	dialect      string
+	pollInterval time.Duration
+	onProgress   func(IndexProgress)
+
+	// 演练模式记录的语句
+	mu         sync.Mutex
+	statements []string
+}
+
+// NewIndexManager 创建索引管理器
+func NewIndexManager(db *gorm.DB, opts ...IndexManagerOption) *IndexManager {
+	m := &IndexManager{db: db, dialect: db.Dialector.Name(), pollInterval: 5 * time.Second}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewIndexDryRun 创建演练模式的索引管理器，只记录指定方言的SQL而不执行，
+// 通过 Statements 获取，可用于生成迁移或检查SQL
+func NewIndexDryRun(dialect string) *IndexManager {
+	return &IndexManager{dialect: dialect, pollInterval: 5 * time.Second}
+}
+
+// Dialect 返回数据库方言名称
+func (m *IndexManager) Dialect() string {
+	return m.dialect
+}
+
+// Statements 返回演练模式记录的SQL
+func (m *IndexManager) Statements() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.statements...)
+}
+
+// Exists 判断表上是否存在指定名称的索引
+func (m *IndexManager) Exists(ctx context.Context, table, name string) (bool, error) {
+	if m.db == nil {
+		return false, ErrIndexDryRun
+	}
+	indexes, err := m.List(ctx, table)
+	if err != nil {
+		return false, err
+	}
+	for _, index := range indexes {
+		if index.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// List 返回表的索引，不包含主键与唯一约束自动创建的索引，与结构对比使用相同的结构
+func (m *IndexManager) List(ctx context.Context, table string) ([]IndexSchema, error) {
+	if m.db == nil {
+		return nil, ErrIndexDryRun
+	}
+	indexes, err := NewSchemaInspector(m.db.WithContext(ctx)).inspectIndexes(table)
+	if err != nil {
+		return nil, fmt.Errorf("读取表 %s 的索引失败: %w", table, err)
+	}
+	return indexes, nil
+}
+
+// Create 创建索引
+//
+// PostgreSQL 并发创建失败时会留下无效索引，这里会将其删除，以便重试；
+// 设置了 WithIndexProgress 时，创建期间按间隔回调进度
+func (m *IndexManager) Create(ctx context.Context, spec IndexSpec) error {
+	query, err := m.createSQL(spec)
+	if err != nil {
+		return err
+	}
+	if spec.Concurrent && m.dialect == PostgreSQL {
+		return m.createConcurrently(ctx, spec, query)
+	}
+	if err := m.exec(ctx, query); err != nil {
+		return fmt.Errorf("创建索引 %s 失败: %w", spec.Name, err)
+	}
+	return nil
+}
+
+// Drop 删除索引
+func (m *IndexManager) Drop(ctx context.Context, table, name string, opts DropIndexOptions) error {
+	if name == "" {
+		return fmt.Errorf("%w: 索引名称不能为空", ErrInvalidConfiguration)
+	}
+
+	var query string
+	switch m.dialect {
+	case PostgreSQL:
+		query = "DROP INDEX "
+		if opts.Concurrent {
+			if m.inTransaction() {
+				return fmt.Errorf("%w: DROP INDEX CONCURRENTLY 不能在事务中执行", ErrIndexUnsupported)
+			}
+			query += "CONCURRENTLY "
+		}
+		if opts.IfExists {
+			query += "IF EXISTS "
+		}
+		query += name
+	case MySQL:
+		if opts.IfExists {
+			// MySQL 的 DROP INDEX 不支持 IF EXISTS
+			exists, err := m.Exists(ctx, table, name)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return nil
+			}
+		}
+		query = fmt.Sprintf("DROP INDEX %s ON %s", name, table)
+		if opts.Concurrent {
+			query += " ALGORITHM=INPLACE LOCK=NONE"
+		}
+	default:
+		if opts.Concurrent {
+			return fmt.Errorf("%w: %s 不支持并发删除索引", ErrIndexUnsupported, m.dialect)
+		}
+		query = "DROP INDEX "
+		if opts.IfExists {
+			query += "IF EXISTS "
+		}
+		query += name
+	}
+
+	if err := m.exec(ctx, query); err != nil {
+		return fmt.Errorf("删除索引 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// createSQL 按方言生成建索引语句，不支持的选项返回 ErrIndexUnsupported
+func (m *IndexManager) createSQL(spec IndexSpec) (string, error) {
+	if spec.Table == "" || spec.Name == "" || len(spec.Columns) == 0 {
+		return "", fmt.Errorf("%w: 索引需要表名、名称与至少一列", ErrInvalidConfiguration)
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if spec.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	b.WriteString("INDEX ")
+	columns := strings.Join(spec.Columns, ", ")
+
+	switch m.dialect {
+	case PostgreSQL:
+		if spec.Concurrent {
+			if m.inTransaction() {
+				return "", fmt.Errorf("%w: CREATE INDEX CONCURRENTLY 不能在事务中执行", ErrIndexUnsupported)
+			}
+			b.WriteString("CONCURRENTLY ")
+		}
+		fmt.Fprintf(&b, "%s ON %s", spec.Name, spec.Table)
+		if spec.Method != "" {
+			b.WriteString(" USING " + spec.Method)
+		}
+		fmt.Fprintf(&b, " (%s)", columns)
+		if spec.Where != "" {
+			b.WriteString(" WHERE " + spec.Where)
+		}
+	case MySQL:
+		if spec.Where != "" {
+			return "", fmt.Errorf("%w: MySQL 不支持部分索引（WHERE 条件）", ErrIndexUnsupported)
+		}
+		fmt.Fprintf(&b, "%s ON %s (%s)", spec.Name, spec.Table, columns)
+		if spec.Method != "" {
+			b.WriteString(" USING " + strings.ToUpper(spec.Method))
+		}
+		if spec.Concurrent {
+			b.WriteString(" ALGORITHM=INPLACE LOCK=NONE")
+		}
+	case SQLite:
+		if spec.Method != "" {
+			return "", fmt.Errorf("%w: SQLite 不支持指定索引方法 %s", ErrIndexUnsupported, spec.Method)
+		}
+		if spec.Concurrent {
+			return "", fmt.Errorf("%w: SQLite 不支持并发创建索引", ErrIndexUnsupported)
+		}
+		fmt.Fprintf(&b, "%s ON %s (%s)", spec.Name, spec.Table, columns)
+		if spec.Where != "" {
+			b.WriteString(" WHERE " + spec.Where)
+		}
+	default:
+		if spec.Where != "" || spec.Method != "" || spec.Concurrent {
+			return "", fmt.Errorf("%w: %s 只支持普通索引与唯一索引", ErrIndexUnsupported, m.dialect)
+		}
+		fmt.Fprintf(&b, "%s ON %s (%s)", spec.Name, spec.Table, columns)
+	}
+	return b.String(), nil
+}
+
+// createConcurrently 在 PostgreSQL 上并发创建索引并轮询进度
+func (m *IndexManager) createConcurrently(ctx context.Context, spec IndexSpec, query string) error {
+	if m.db == nil || m.onProgress == nil {
+		return m.finishConcurrent(ctx, spec, m.exec(ctx, query))
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- m.exec(ctx, query)
+	}()
+
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return m.finishConcurrent(ctx, spec, err)
+		case <-ticker.C:
+			if progress, ok := m.progress(ctx, spec); ok {
+				m.onProgress(progress)
+			}
+		}
+	}
+}
+
+// finishConcurrent 并发创建失败时删除残留的无效索引
+func (m *IndexManager) finishConcurrent(ctx context.Context, spec IndexSpec, err error) error {
+	if err == nil {
+		return nil
+	}
+	if m.db != nil {
+		cleanup := context.WithoutCancel(ctx)
+		_ = m.exec(cleanup, "DROP INDEX CONCURRENTLY IF EXISTS "+spec.Name)
+	}
+	return fmt.Errorf("并发创建索引 %s 失败: %w", spec.Name, err)
+}
+
+// progress 读取 PostgreSQL 建索引的进度
+func (m *IndexManager) progress(ctx context.Context, spec IndexSpec) (IndexProgress, bool) {
+	var rows []struct {
+		Phase       string
+		BlocksDone  int64
+		BlocksTotal int64
+		TuplesDone  int64
+		TuplesTotal int64
+	}
+	err := m.db.WithContext(ctx).Raw(`SELECT phase, blocks_done, blocks_total, tuples_done, tuples_total
+		FROM pg_stat_progress_create_index WHERE relid = ?::regclass`, spec.Table).Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return IndexProgress{}, false
+	}
+	row := rows[0]
+	return IndexProgress{
+		Table:       spec.Table,
+		Name:        spec.Name,
+		Phase:       row.Phase,
+		BlocksDone:  row.BlocksDone,
+		BlocksTotal: row.BlocksTotal,
+		TuplesDone:  row.TuplesDone,
+		TuplesTotal: row.TuplesTotal,
+	}, true
+}
+
+// exec 执行语句，演练模式只记录
+func (m *IndexManager) exec(ctx context.Context, query string) error {
+	if m.db == nil {
+		m.mu.Lock()
+		m.statements = append(m.statements, query)
+		m.mu.Unlock()
+		return nil
+	}
+	return m.db.WithContext(WithNoTimeout(ctx)).Exec(query).Error
+}
+
+// inTransaction 判断连接是否处于事务中
+func (m *IndexManager) inTransaction() bool {
+	if m.db == nil {
+		return false
+	}
+	_, ok := m.db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
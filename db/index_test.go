@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexManager_SQLiteRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	manager := NewIndexManager(newSchemaTestDB(t))
+
+	exists, err := manager.Exists(ctx, "users", "idx_users_active_name")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, manager.Create(ctx, IndexSpec{
+		Table:   "users",
+		Name:    "idx_users_active_name",
+		Columns: []string{"name"},
+		Where:   "active = true",
+	}))
+	exists, err = manager.Exists(ctx, "users", "idx_users_active_name")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	indexes, err := manager.List(ctx, "users")
+	require.NoError(t, err)
+	assert.Contains(t, indexes, IndexSchema{Name: "idx_users_active_name", Columns: []string{"name"}})
+
+	require.NoError(t, manager.Drop(ctx, "users", "idx_users_active_name", DropIndexOptions{}))
+	exists, err = manager.Exists(ctx, "users", "idx_users_active_name")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// 不存在的索引
+	assert.Error(t, manager.Drop(ctx, "users", "idx_users_active_name", DropIndexOptions{}))
+	assert.NoError(t, manager.Drop(ctx, "users", "idx_users_active_name", DropIndexOptions{IfExists: true}))
+
+	// 不支持的选项不会执行任何语句
+	err = manager.Create(ctx, IndexSpec{Table: "users", Name: "idx_users_name", Columns: []string{"name"}, Concurrent: true})
+	assert.ErrorIs(t, err, ErrIndexUnsupported)
+	exists, _ = manager.Exists(ctx, "users", "idx_users_name")
+	assert.False(t, exists)
+}
+
+func TestIndexManager_DialectMatrix(t *testing.T) {
+	spec := IndexSpec{Table: "orders", Name: "idx_orders_pending", Columns: []string{"user_id", "created_at"}}
+	partial := spec
+	partial.Where = "status = 'pending'"
+	concurrent := spec
+	concurrent.Concurrent = true
+	method := spec
+	method.Method = "btree"
+	unique := spec
+	unique.Unique = true
+
+	cases := []struct {
+		dialect string
+		spec    IndexSpec
+		want    string // 为空时期望 ErrIndexUnsupported
+	}{
+		{PostgreSQL, unique, "CREATE UNIQUE INDEX idx_orders_pending ON orders (user_id, created_at)"},
+		{PostgreSQL, partial, "CREATE INDEX idx_orders_pending ON orders (user_id, created_at) WHERE status = 'pending'"},
+		{PostgreSQL, concurrent, "CREATE INDEX CONCURRENTLY idx_orders_pending ON orders (user_id, created_at)"},
+		{PostgreSQL, method, "CREATE INDEX idx_orders_pending ON orders USING btree (user_id, created_at)"},
+		{MySQL, unique, "CREATE UNIQUE INDEX idx_orders_pending ON orders (user_id, created_at)"},
+		{MySQL, partial, ""},
+		{MySQL, concurrent, "CREATE INDEX idx_orders_pending ON orders (user_id, created_at) ALGORITHM=INPLACE LOCK=NONE"},
+		{MySQL, method, "CREATE INDEX idx_orders_pending ON orders (user_id, created_at) USING BTREE"},
+		{SQLite, unique, "CREATE UNIQUE INDEX idx_orders_pending ON orders (user_id, created_at)"},
+		{SQLite, partial, "CREATE INDEX idx_orders_pending ON orders (user_id, created_at) WHERE status = 'pending'"},
+		{SQLite, concurrent, ""},
+		{SQLite, method, ""},
+		{ClickHouse, partial, ""},
+	}
+	for _, c := range cases {
+		manager := NewIndexDryRun(c.dialect)
+		err := manager.Create(context.Background(), c.spec)
+		if c.want == "" {
+			assert.ErrorIs(t, err, ErrIndexUnsupported, "%s %+v", c.dialect, c.spec)
+			assert.Empty(t, manager.Statements())
+			continue
+		}
+		require.NoError(t, err, c.dialect)
+		assert.Equal(t, []string{c.want}, manager.Statements(), c.dialect)
+	}
+}
+
+func TestIndexManager_DropMatrix(t *testing.T) {
+	cases := []struct {
+		dialect string
+		opts    DropIndexOptions
+		want    string
+	}{
+		{PostgreSQL, DropIndexOptions{IfExists: true, Concurrent: true}, "DROP INDEX CONCURRENTLY IF EXISTS idx_orders_pending"},
+		{MySQL, DropIndexOptions{Concurrent: true}, "DROP INDEX idx_orders_pending ON orders ALGORITHM=INPLACE LOCK=NONE"},
+		{SQLite, DropIndexOptions{IfExists: true}, "DROP INDEX IF EXISTS idx_orders_pending"},
+	}
+	for _, c := range cases {
+		manager := NewIndexDryRun(c.dialect)
+		require.NoError(t, manager.Drop(context.Background(), "orders", "idx_orders_pending", c.opts), c.dialect)
+		assert.Equal(t, []string{c.want}, manager.Statements(), c.dialect)
+	}
+
+	err := NewIndexDryRun(SQLite).Drop(context.Background(), "orders", "idx_orders_pending", DropIndexOptions{Concurrent: true})
+	assert.ErrorIs(t, err, ErrIndexUnsupported)
+
+	// MySQL 的 IF EXISTS 需要先查询索引，演练模式无法判断
+	err = NewIndexDryRun(MySQL).Drop(context.Background(), "orders", "idx_orders_pending", DropIndexOptions{IfExists: true})
+	assert.ErrorIs(t, err, ErrIndexDryRun)
+}
@@ -225,6 +225,10 @@ func createConfigFromMap(configMap interface{}) (Config, bool) {
 		config.ConnMaxLifetime = time.Duration(lifetimeFloat) * time.Second
 	}
 
+	config.Naming = namingConfigFromMap(m["naming"])
+	config.Gorm = gormConfigFromMap(m["gorm"])
+	config.SQLite = sqliteConfigFromMap(m["sqlite"])
+
 	// 兼容DSN参数
 	if dsn, ok := m["dsn"].(string); ok && dsn != "" {
 		// 如果提供了DSN，使用它代替拆分的连接参数
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// TotalUnknown 跳过总数统计时 PageInfo.Total 的值
+const TotalUnknown int64 = -1
+
+// PageInfo 分页元数据，偏移分页使用 Page 与 Total，游标分页使用 NextCursor 与 PrevCursor
+type PageInfo struct {
+	Page    int   // 当前页码，从1开始，游标分页为0
+	PerPage int   // 每页数量
+	Total   int64 // 总数，为 TotalUnknown 表示跳过了统计
+	HasMore bool  // 跳过总数统计时是否还有下一页
+
+	Cursor     bool   // 是否为游标分页
+	NextCursor string // 下一页游标，为空表示没有下一页
+	PrevCursor string // 上一页游标，为空表示没有上一页
+}
+
+// HasTotal 是否统计了总数
+func (p PageInfo) HasTotal() bool {
+	return p.Total >= 0
+}
+
+// TotalPages 返回总页数，未统计总数时为0
+func (p PageInfo) TotalPages() int {
+	if !p.HasTotal() || p.PerPage <= 0 {
+		return 0
+	}
+	return int((p.Total + int64(p.PerPage) - 1) / int64(p.PerPage))
+}
+
+// HasNext 是否有下一页
+func (p PageInfo) HasNext() bool {
+	switch {
+	case p.Cursor:
+		return p.NextCursor != ""
+	case p.HasTotal():
+		return p.Page < p.TotalPages()
+	default:
+		return p.HasMore
+	}
+}
+
+// HasPrev 是否有上一页
+func (p PageInfo) HasPrev() bool {
+	if p.Cursor {
+		return p.PrevCursor != ""
+	}
+	return p.Page > 1
+}
+
+// Paged 分页结果，供 flow.Context.Paginated 渲染响应
+type Paged interface {
+	// Data 返回当前页的数据
+	Data() interface{}
+	// Info 返回分页元数据
+	Info() PageInfo
+}
+
+// Page 一页查询结果
+type Page[T any] struct {
+	Items []T
+	PageInfo
+}
+
+// Data 返回当前页的数据，没有数据时为空切片
+func (p Page[T]) Data() interface{} {
+	if p.Items == nil {
+		return []T{}
+	}
+	return p.Items
+}
+
+// Info 返回分页元数据
+func (p Page[T]) Info() PageInfo {
+	return p.PageInfo
+}
+
+// NewCursorPage 创建游标分页结果，total 为 TotalUnknown 表示未统计总数
+func NewCursorPage[T any](items []T, perPage int, nextCursor, prevCursor string, total int64) Page[T] {
+	return Page[T]{
+		Items: items,
+		PageInfo: PageInfo{
+			PerPage:    perPage,
+			Total:      total,
+			Cursor:     true,
+			NextCursor: nextCursor,
+			PrevCursor: prevCursor,
+		},
+	}
+}
+
+// FetchPage 执行偏移分页查询
+//
+// skipTotal 为 true 时不执行 COUNT，而是多查询一条记录判断是否还有下一页，适用于大表
+func FetchPage[T any](query *gorm.DB, page, perPage int, skipTotal bool) (Page[T], error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 10
+	}
+	result := Page[T]{PageInfo: PageInfo{Page: page, PerPage: perPage, Total: TotalUnknown}}
+	offset := (page - 1) * perPage
+
+	if skipTotal {
+		if err := query.Limit(perPage + 1).Offset(offset).Find(&result.Items).Error; err != nil {
+			return result, err
+		}
+		if len(result.Items) > perPage {
+			result.Items = result.Items[:perPage]
+			result.HasMore = true
+		}
+		return result, nil
+	}
+
+	// 克隆查询以获取总数
+	if err := query.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		return result, err
+	}
+	if err := query.Limit(perPage).Offset(offset).Find(&result.Items).Error; err != nil {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type pageRow struct {
+	ID uint
+}
+
+func TestFetchPage(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&pageRow{}))
+	for i := 0; i < 25; i++ {
+		require.NoError(t, conn.Create(&pageRow{}).Error)
+	}
+	query := conn.Model(&pageRow{}).Order("id")
+
+	page, err := db.FetchPage[pageRow](query, 3, 10, false)
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 5)
+	assert.Equal(t, int64(25), page.Total)
+	assert.Equal(t, 3, page.TotalPages())
+	assert.False(t, page.HasNext())
+	assert.True(t, page.HasPrev())
+
+	// 跳过总数统计时多查询一条判断是否有下一页
+	page, err = db.FetchPage[pageRow](query, 2, 10, true)
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 10)
+	assert.False(t, page.HasTotal())
+	assert.True(t, page.HasNext())
+	assert.Equal(t, uint(11), page.Items[0].ID)
+
+	page, err = db.FetchPage[pageRow](query, 3, 10, true)
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 5)
+	assert.False(t, page.HasNext())
+}
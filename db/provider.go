@@ -2,6 +2,7 @@ package db
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/zzliekkas/flow/v2/config"
 	"github.com/zzliekkas/flow/v2/di"
@@ -15,6 +16,70 @@ type DatabaseProvider struct {
 	container *di.Container
 }
 
+// ProviderConfig database 配置段（嵌套格式），用于启动时验证
+//
+//	database:
+//	  default: main
+//	  connections:
+//	    main:
+//	      driver: mysql
+//	      host: localhost
+//	      port: 3306
+type ProviderConfig struct {
+	Default     string                      `mapstructure:"default" json:"default"`
+	Connections map[string]ConnectionConfig `mapstructure:"connections" json:"connections" validate:"dive"`
+}
+
+// ConnectionConfig 单个连接需要验证的配置项，其余配置项保留在 Options 中
+type ConnectionConfig struct {
+	Driver       string                 `mapstructure:"driver" json:"driver" validate:"required"`
+	Host         string                 `mapstructure:"host" json:"host"`
+	Port         int                    `mapstructure:"port" json:"port" validate:"gte=0,lte=65535"`
+	Database     string                 `mapstructure:"database" json:"database"`
+	MaxIdleConns int                    `mapstructure:"max_idle_conns" json:"max_idle_conns" validate:"gte=0"`
+	MaxOpenConns int                    `mapstructure:"max_open_conns" json:"max_open_conns" validate:"gte=0"`
+	Options      map[string]interface{} `mapstructure:",remain" json:"-"`
+}
+
+// ConfigSection 配置段名称，与 app.ConfigProvider 约定一致
+func (p *DatabaseProvider) ConfigSection() string {
+	return "database"
+}
+
+// ConfigSchema 配置结构
+func (p *DatabaseProvider) ConfigSchema() interface{} {
+	return &ProviderConfig{}
+}
+
+// Configure 检查驱动与默认连接，连接仍由 Manager.FromConfig 按原有规则加载
+func (p *DatabaseProvider) Configure(cfg interface{}) error {
+	c, ok := cfg.(*ProviderConfig)
+	if !ok {
+		return fmt.Errorf("无效的数据库配置类型: %T", cfg)
+	}
+	for name, conn := range c.Connections {
+		if !isSupportedDriver(conn.Driver) {
+			return fmt.Errorf("database.connections.%s.driver: %w: %s", name, ErrUnsupportedDriver, conn.Driver)
+		}
+	}
+	if c.Default != "" && len(c.Connections) > 0 {
+		if _, ok := c.Connections[c.Default]; !ok {
+			return fmt.Errorf("database.default: 默认连接未配置: %s", c.Default)
+		}
+	}
+	return nil
+}
+
+// isSupportedDriver 判断驱动是否内置或已通过 RegisterDialector 注册
+func isSupportedDriver(driver string) bool {
+	switch driver {
+	case MySQL, PostgreSQL, SQLite:
+		return true
+	}
+	_, ok := lookupDialector(driver)
+	return ok
+}
+
 // NewDatabaseProvider 创建数据库服务提供者
 func NewDatabaseProvider(container *di.Container) *DatabaseProvider {
 	return &DatabaseProvider{
@@ -0,0 +1,471 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+)
+
+// queryCacheTTLKey 在语句上设置缓存时间的键，可通过 db.Set("cache:ttl", d) 或 Cached(d) 设置
+const queryCacheTTLKey = "cache:ttl"
+
+// QueryCache 查询缓存存储接口，cache 包提供了基于缓存管理器的实现
+type QueryCache interface {
+	// Get 获取缓存数据，未命中时返回false
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set 写入缓存数据并关联标签
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error
+	// InvalidateTags 使标签关联的缓存全部失效
+	InvalidateTags(ctx context.Context, tags []string) error
+}
+
+// queryCacheConfig 查询缓存配置
+type queryCacheConfig struct {
+	name    string
+	prefix  string
+	onError func(error)
+}
+
+// QueryCacheOption 查询缓存选项
+type QueryCacheOption func(*queryCacheConfig)
+
+// WithQueryCacheName 设置连接名称，参与缓存键与标签的计算，默认为 default
+func WithQueryCacheName(name string) QueryCacheOption {
+	return func(c *queryCacheConfig) {
+		c.name = name
+	}
+}
+
+// WithQueryCachePrefix 设置缓存键前缀，默认为 db:
+func WithQueryCachePrefix(prefix string) QueryCacheOption {
+	return func(c *queryCacheConfig) {
+		c.prefix = prefix
+	}
+}
+
+// WithQueryCacheErrorHandler 设置缓存读写失败时的回调，失败时查询会回退到数据库
+func WithQueryCacheErrorHandler(handler func(error)) QueryCacheOption {
+	return func(c *queryCacheConfig) {
+		c.onError = handler
+	}
+}
+
+// Cached 返回启用查询缓存的作用域，用法：db.Scopes(db.Cached(time.Minute)).Find(&users)
+func Cached(ttl time.Duration) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Set(queryCacheTTLKey, ttl)
+	}
+}
+
+// bypassQueryCacheKey 上下文中绕过查询缓存的标记
+type bypassQueryCacheKey struct{}
+
+// BypassQueryCache 返回绕过查询缓存的上下文，用于写后立即读取的场景
+func BypassQueryCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassQueryCacheKey{}, true)
+}
+
+// isQueryCacheBypassed 检查上下文是否要求绕过缓存
+func isQueryCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassQueryCacheKey{}).(bool)
+	return bypass
+}
+
+// queryCachePlugin 查询缓存插件
+type queryCachePlugin struct {
+	cache  QueryCache
+	config queryCacheConfig
+}
+
+// EnableQueryCache 为连接注册查询缓存回调
+// 只有显式设置了缓存时间的SELECT会被缓存；INSERT/UPDATE/DELETE会使涉及表的标签失效。
+// 事务中的查询不读写缓存，避免缓存未提交的数据；事务中的写操作在提交后才失效，回滚时不失效
+func EnableQueryCache(conn *gorm.DB, cache QueryCache, opts ...QueryCacheOption) error {
+	config := queryCacheConfig{name: "default", prefix: "db:"}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	p := &queryCachePlugin{cache: cache, config: config}
+	installTxHooks(conn)
+
+	if err := conn.Callback().Query().Replace("gorm:query", p.query); err != nil {
+		return err
+	}
+	if err := conn.Callback().Create().After("gorm:create").Register("flow:query_cache_invalidate", p.invalidate); err != nil {
+		return err
+	}
+	if err := conn.Callback().Update().After("gorm:update").Register("flow:query_cache_invalidate", p.invalidate); err != nil {
+		return err
+	}
+	if err := conn.Callback().Delete().After("gorm:delete").Register("flow:query_cache_invalidate", p.invalidate); err != nil {
+		return err
+	}
+	return conn.Callback().Raw().After("gorm:raw").Register("flow:query_cache_invalidate", p.invalidate)
+}
+
+// query 替换默认的查询回调，命中缓存时从缓存的原始行数据扫描结果
+func (p *queryCachePlugin) query(tx *gorm.DB) {
+	ttl, ok := p.ttl(tx)
+	if !ok || tx.Error != nil || tx.DryRun || isQueryCacheBypassed(tx.Statement.Context) || inTransaction(tx) {
+		callbacks.Query(tx)
+		return
+	}
+
+	callbacks.BuildQuerySQL(tx)
+	if tx.Error != nil {
+		return
+	}
+
+	ctx := tx.Statement.Context
+	sqlText := tx.Statement.SQL.String()
+	key := p.key(sqlText, tx.Statement.Vars)
+
+	if data, hit, err := p.cache.Get(ctx, key); err != nil {
+		p.reportError(err)
+	} else if hit {
+		// 缓存数据损坏时回退到数据库查询
+		var rows cachedRows
+		err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rows)
+		if err == nil {
+			rows.pos = -1
+			gorm.Scan(&rows, tx, 0)
+			return
+		}
+		p.reportError(err)
+	}
+
+	result, err := tx.Statement.ConnPool.QueryContext(ctx, sqlText, tx.Statement.Vars...)
+	if err != nil {
+		tx.AddError(err)
+		return
+	}
+	rows, err := readRows(result)
+	tx.AddError(result.Close())
+	if err != nil {
+		tx.AddError(err)
+		return
+	}
+
+	gorm.Scan(rows, tx, 0)
+	if tx.Error != nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(rows); err != nil {
+		p.reportError(err)
+		return
+	}
+	if err := p.cache.Set(ctx, key, buf.Bytes(), ttl, p.tags(sqlText)); err != nil {
+		p.reportError(err)
+	}
+}
+
+// invalidate 写操作成功后使涉及表的缓存失效，事务中暂存到提交后
+func (p *queryCachePlugin) invalidate(tx *gorm.DB) {
+	if tx.Error != nil || tx.DryRun {
+		return
+	}
+
+	tags := p.tags(tx.Statement.SQL.String())
+	if tx.Statement.Table != "" {
+		tags = appendUnique(tags, p.tag(tx.Statement.Table))
+	}
+	if len(tags) == 0 {
+		return
+	}
+	if pending, ok := pendingTx(tx); ok {
+		pending.add(p, tags)
+		return
+	}
+	_ = p.invalidateCommitted(tx.Statement.Context, tags)
+}
+
+// invalidateCommitted 使标签失效，失败时只报告错误
+func (p *queryCachePlugin) invalidateCommitted(ctx context.Context, tags []string) error {
+	if err := p.cache.InvalidateTags(ctx, uniqueSorted(tags)); err != nil {
+		p.reportError(err)
+	}
+	return nil
+}
+
+// ttl 读取语句上设置的缓存时间
+func (p *queryCachePlugin) ttl(tx *gorm.DB) (time.Duration, bool) {
+	value, ok := tx.Get(queryCacheTTLKey)
+	if !ok {
+		return 0, false
+	}
+	ttl, ok := value.(time.Duration)
+	return ttl, ok && ttl > 0
+}
+
+// key 根据连接、SQL和参数计算缓存键
+func (p *queryCachePlugin) key(sqlText string, vars []interface{}) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", p.config.name, sqlText)
+	for _, v := range vars {
+		fmt.Fprintf(h, "\x00%T:%v", v, v)
+	}
+	return p.config.prefix + "query:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// tag 返回表对应的缓存标签
+func (p *queryCachePlugin) tag(table string) string {
+	return p.config.prefix + "table:" + p.config.name + ":" + table
+}
+
+// tags 返回SQL涉及的表对应的标签
+func (p *queryCachePlugin) tags(sqlText string) []string {
+	var tags []string
+	for _, table := range extractTables(sqlText) {
+		tags = appendUnique(tags, p.tag(table))
+	}
+	return tags
+}
+
+// reportError 报告缓存错误
+func (p *queryCachePlugin) reportError(err error) {
+	if p.config.onError != nil {
+		p.config.onError(err)
+	}
+}
+
+// tablePattern 匹配SQL中 FROM/JOIN/INTO/UPDATE 之后的表名
+var tablePattern = regexp.MustCompile("(?i)\\b(?:from|join|into|update)\\s+[`\"\\[]?([\\w.]+)")
+
+// extractTables 从SQL中提取涉及的表名
+func extractTables(sqlText string) []string {
+	var tables []string
+	for _, match := range tablePattern.FindAllStringSubmatch(sqlText, -1) {
+		table := match[1]
+		if i := strings.LastIndex(table, "."); i >= 0 {
+			table = table[i+1:]
+		}
+		tables = appendUnique(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// appendUnique 追加不重复的元素
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+func init() {
+	gob.Register(time.Time{})
+}
+
+// cachedRows 缓存的原始行数据，实现 gorm.Rows 接口以复用GORM的扫描逻辑
+type cachedRows struct {
+	Cols   []string
+	Values [][]interface{}
+	pos    int
+}
+
+// readRows 读取全部行数据
+func readRows(rows *sql.Rows) (*cachedRows, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &cachedRows{Cols: cols, pos: -1}
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			// 驱动可能复用字节切片，需要复制
+			if b, ok := v.([]byte); ok {
+				values[i] = append([]byte(nil), b...)
+			}
+		}
+		result.Values = append(result.Values, values)
+	}
+	return result, rows.Err()
+}
+
+func (r *cachedRows) Columns() ([]string, error) {
+	return append([]string(nil), r.Cols...), nil
+}
+
+func (r *cachedRows) ColumnTypes() ([]*sql.ColumnType, error) {
+	return nil, nil
+}
+
+func (r *cachedRows) Next() bool {
+	r.pos++
+	return r.pos < len(r.Values)
+}
+
+func (r *cachedRows) Scan(dest ...interface{}) error {
+	if r.pos < 0 || r.pos >= len(r.Values) {
+		return sql.ErrNoRows
+	}
+	row := r.Values[r.pos]
+	if len(dest) != len(row) {
+		return fmt.Errorf("查询缓存: 需要 %d 个扫描目标，实际为 %d", len(row), len(dest))
+	}
+	for i, d := range dest {
+		if err := assignValue(d, row[i]); err != nil {
+			return fmt.Errorf("查询缓存: 扫描列 %s 失败: %w", r.Cols[i], err)
+		}
+	}
+	return nil
+}
+
+func (r *cachedRows) Err() error {
+	return nil
+}
+
+func (r *cachedRows) Close() error {
+	return nil
+}
+
+// assignValue 将驱动返回的值赋给扫描目标，覆盖GORM扫描时使用的常见类型
+func assignValue(dest, src interface{}) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("扫描目标必须是非空指针")
+	}
+	dv = dv.Elem()
+
+	if src == nil {
+		dv.Set(reflect.Zero(dv.Type()))
+		return nil
+	}
+
+	switch dv.Kind() {
+	case reflect.Ptr:
+		value := reflect.New(dv.Type().Elem())
+		if err := assignValue(value.Interface(), src); err != nil {
+			return err
+		}
+		dv.Set(value)
+		return nil
+	case reflect.Interface:
+		dv.Set(reflect.ValueOf(src))
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dv.Type()) {
+		dv.Set(sv)
+		return nil
+	}
+
+	text, isText := asText(src)
+	switch dv.Kind() {
+	case reflect.String:
+		if isText {
+			dv.SetString(text)
+			return nil
+		}
+		if t, ok := src.(time.Time); ok {
+			dv.SetString(t.Format(time.RFC3339Nano))
+			return nil
+		}
+		dv.SetString(fmt.Sprint(src))
+		return nil
+	case reflect.Slice:
+		if dv.Type().Elem().Kind() == reflect.Uint8 && isText {
+			dv.SetBytes([]byte(text))
+			return nil
+		}
+	case reflect.Bool:
+		switch v := src.(type) {
+		case int64:
+			dv.SetBool(v != 0)
+			return nil
+		case string, []byte:
+			b, err := strconv.ParseBool(text)
+			if err != nil {
+				return err
+			}
+			dv.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isText {
+			n, err := strconv.ParseInt(text, 10, dv.Type().Bits())
+			if err != nil {
+				return err
+			}
+			dv.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if isText {
+			n, err := strconv.ParseUint(text, 10, dv.Type().Bits())
+			if err != nil {
+				return err
+			}
+			dv.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if isText {
+			f, err := strconv.ParseFloat(text, dv.Type().Bits())
+			if err != nil {
+				return err
+			}
+			dv.SetFloat(f)
+			return nil
+		}
+	case reflect.Struct:
+		if dv.Type() == reflect.TypeOf(time.Time{}) && isText {
+			t, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return err
+			}
+			dv.Set(reflect.ValueOf(t))
+			return nil
+		}
+	}
+
+	if sv.Type().ConvertibleTo(dv.Type()) && sv.Kind() != reflect.String {
+		dv.Set(sv.Convert(dv.Type()))
+		return nil
+	}
+	return fmt.Errorf("不支持将 %T 赋值给 %s", src, dv.Type())
+}
+
+// asText 返回字符串或字节切片的文本
+func asText(src interface{}) (string, bool) {
+	switch v := src.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
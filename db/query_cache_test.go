@@ -0,0 +1,192 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/cache"
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type cacheAuthor struct {
+	ID       uint
+	Name     string
+	Score    float64
+	Active   bool
+	Nickname *string
+	Born     time.Time
+	Books    []cacheBook `gorm:"foreignKey:AuthorID"`
+}
+
+type cacheBook struct {
+	ID       uint
+	AuthorID uint
+	Title    string
+}
+
+// countingCache 统计查询缓存的读取情况
+type countingCache struct {
+	*cache.QueryCache
+	hits, misses int
+}
+
+func (c *countingCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, hit, err := c.QueryCache.Get(ctx, key)
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return data, hit, err
+}
+
+func newQueryCacheDB(t *testing.T) (*gorm.DB, *countingCache) {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&cacheAuthor{}, &cacheBook{}))
+
+	nick := "lu"
+	born := time.Date(1881, 9, 25, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, conn.Create(&cacheAuthor{Name: "鲁迅", Score: 9.5, Active: true, Nickname: &nick, Born: born,
+		Books: []cacheBook{{Title: "呐喊"}, {Title: "彷徨"}}}).Error)
+	require.NoError(t, conn.Create(&cacheAuthor{Name: "老舍", Born: born}).Error)
+
+	manager := cache.NewManager()
+	manager.AddStore("memory", cache.NewMemoryStore())
+	qc := &countingCache{QueryCache: cache.NewQueryCache(manager, "")}
+	require.NoError(t, db.EnableQueryCache(conn, qc))
+	return conn, qc
+}
+
+func TestQueryCache_HitAndMiss(t *testing.T) {
+	conn, qc := newQueryCacheDB(t)
+
+	var first, second []cacheAuthor
+	require.NoError(t, conn.Scopes(db.Cached(time.Minute)).Order("id").Find(&first).Error)
+	require.NoError(t, conn.Scopes(db.Cached(time.Minute)).Order("id").Find(&second).Error)
+
+	assert.Equal(t, 1, qc.misses)
+	assert.Equal(t, 1, qc.hits)
+	require.Len(t, second, 2)
+	assert.Equal(t, first, second)
+	assert.Equal(t, "lu", *second[0].Nickname)
+	assert.Nil(t, second[1].Nickname)
+	assert.True(t, second[0].Born.Equal(first[0].Born))
+
+	// 未设置缓存时间的查询不经过缓存
+	var count int64
+	conn.Model(&cacheAuthor{}).Count(&count)
+	assert.Equal(t, 2, qc.misses+qc.hits)
+}
+
+func TestQueryCache_InvalidateOnWrite(t *testing.T) {
+	conn, qc := newQueryCacheDB(t)
+
+	var author cacheAuthor
+	require.NoError(t, conn.Set("cache:ttl", time.Minute).First(&author, 1).Error)
+	require.NoError(t, conn.Model(&cacheAuthor{}).Where("id = ?", 1).Update("name", "周树人").Error)
+
+	var fresh cacheAuthor
+	require.NoError(t, conn.Set("cache:ttl", time.Minute).First(&fresh, 1).Error)
+	assert.Equal(t, "周树人", fresh.Name)
+	assert.Equal(t, 0, qc.hits)
+
+	// 其他表的写入不影响缓存
+	require.NoError(t, conn.Create(&cacheBook{AuthorID: 2, Title: "骆驼祥子"}).Error)
+	var again cacheAuthor
+	require.NoError(t, conn.Set("cache:ttl", time.Minute).First(&again, 1).Error)
+	assert.Equal(t, 1, qc.hits)
+}
+
+func TestQueryCache_Preload(t *testing.T) {
+	conn, qc := newQueryCacheDB(t)
+
+	load := func() cacheAuthor {
+		var a cacheAuthor
+		require.NoError(t, conn.Scopes(db.Cached(time.Minute)).Preload("Books").First(&a, 1).Error)
+		return a
+	}
+	first := load()
+	second := load()
+
+	assert.Equal(t, first, second)
+	require.Len(t, second.Books, 2)
+	assert.Equal(t, "呐喊", second.Books[0].Title)
+	assert.Equal(t, 2, qc.hits)
+
+	// 修改关联表后预加载结果随之更新
+	require.NoError(t, conn.Create(&cacheBook{AuthorID: 1, Title: "野草"}).Error)
+	assert.Len(t, load().Books, 3)
+}
+
+func TestQueryCache_Bypass(t *testing.T) {
+	conn, qc := newQueryCacheDB(t)
+
+	var a cacheAuthor
+	require.NoError(t, conn.Scopes(db.Cached(time.Minute)).First(&a, 1).Error)
+
+	ctx := db.BypassQueryCache(context.Background())
+	require.NoError(t, conn.WithContext(ctx).Scopes(db.Cached(time.Minute)).First(&a, 1).Error)
+	assert.Equal(t, 0, qc.hits)
+	assert.Equal(t, 1, qc.misses)
+}
+
+// invalidationSpy 记录标签失效的次数
+type invalidationSpy struct {
+	*countingCache
+	invalidations int
+}
+
+func (c *invalidationSpy) InvalidateTags(ctx context.Context, tags []string) error {
+	c.invalidations++
+	return c.countingCache.InvalidateTags(ctx, tags)
+}
+
+func TestQueryCache_Transactions(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&cacheAuthor{}, &cacheBook{}))
+	// 内存数据库的每个连接相互独立
+	sqlDB, err := conn.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, conn.Create(&cacheAuthor{Name: "鲁迅"}).Error)
+
+	manager := cache.NewManager()
+	manager.AddStore("memory", cache.NewMemoryStore())
+	qc := &invalidationSpy{countingCache: &countingCache{QueryCache: cache.NewQueryCache(manager, "")}}
+	require.NoError(t, db.EnableQueryCache(conn, qc))
+
+	// 事务中的查询不缓存未提交的数据，回滚后不可见
+	tx := conn.Begin()
+	require.NoError(t, tx.Create(&cacheAuthor{Name: "未提交"}).Error)
+	var inTx []cacheAuthor
+	require.NoError(t, tx.Scopes(db.Cached(time.Minute)).Find(&inTx).Error)
+	assert.Len(t, inTx, 2)
+	assert.Equal(t, 0, qc.hits+qc.misses, "事务中不读写缓存")
+	assert.Equal(t, 0, qc.invalidations, "提交前不失效")
+	require.NoError(t, tx.Rollback().Error)
+	assert.Equal(t, 0, qc.invalidations, "回滚时不失效")
+
+	var authors []cacheAuthor
+	require.NoError(t, conn.Scopes(db.Cached(time.Minute)).Find(&authors).Error)
+	assert.Len(t, authors, 1)
+
+	// 事务中的写操作在提交后才失效
+	tx = conn.Begin()
+	require.NoError(t, tx.Model(&cacheAuthor{}).Where("id = ?", 1).Update("name", "周树人").Error)
+	assert.Equal(t, 0, qc.invalidations)
+	require.NoError(t, tx.Commit().Error)
+	assert.Equal(t, 1, qc.invalidations)
+
+	require.NoError(t, conn.Scopes(db.Cached(time.Minute)).Find(&authors).Error)
+	require.Len(t, authors, 1)
+	assert.Equal(t, "周树人", authors[0].Name)
+	assert.Equal(t, 0, qc.hits)
+}
@@ -0,0 +1,334 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// TableSchema 表结构
+type TableSchema struct {
+	Name        string             `json:"name"`
+	Columns     []ColumnSchema     `json:"columns"`
+	Indexes     []IndexSchema      `json:"indexes,omitempty"`
+	ForeignKeys []ForeignKeySchema `json:"foreign_keys,omitempty"`
+}
+
+// ColumnSchema 列结构
+type ColumnSchema struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	Default    string `json:"default,omitempty"`
+	PrimaryKey bool   `json:"primary_key,omitempty"`
+	Unique     bool   `json:"unique,omitempty"`
+}
+
+// IndexSchema 索引结构，不包含主键与唯一约束自动创建的索引
+type IndexSchema struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+// ForeignKeySchema 外键结构
+type ForeignKeySchema struct {
+	Name       string   `json:"name,omitempty"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+}
+
+// signature 外键的比较标识，SQLite 的外键没有名称，因此按列与引用比较
+func (fk ForeignKeySchema) signature() string {
+	return fmt.Sprintf("(%s) -> %s(%s)", strings.Join(fk.Columns, ", "), fk.RefTable, strings.Join(fk.RefColumns, ", "))
+}
+
+// Column 按名称查找列
+func (t *TableSchema) Column(name string) (ColumnSchema, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return ColumnSchema{}, false
+}
+
+var (
+	schemaModelsMu sync.RWMutex
+	schemaModels   []interface{}
+)
+
+// RegisterSchemaModels 注册用于结构对比的模型，flow db diff 以这些模型推导期望的表结构
+//
+//	func init() {
+//		db.RegisterSchemaModels(&User{}, &Order{})
+//	}
+func RegisterSchemaModels(models ...interface{}) {
+	schemaModelsMu.Lock()
+	defer schemaModelsMu.Unlock()
+	schemaModels = append(schemaModels, models...)
+}
+
+// SchemaModels 返回已注册的模型
+func SchemaModels() []interface{} {
+	schemaModelsMu.RLock()
+	defer schemaModelsMu.RUnlock()
+	return append([]interface{}(nil), schemaModels...)
+}
+
+// SchemaInspector 读取数据库实际结构与模型推导的结构
+type SchemaInspector struct {
+	db *gorm.DB
+}
+
+// NewSchemaInspector 创建结构检查器
+func NewSchemaInspector(db *gorm.DB) *SchemaInspector {
+	return &SchemaInspector{db: db}
+}
+
+// Dialect 数据库方言名称，例如 sqlite、mysql、postgres
+func (i *SchemaInspector) Dialect() string {
+	return i.db.Dialector.Name()
+}
+
+// Inspect 读取数据库中的表结构，未指定表名时读取所有表
+func (i *SchemaInspector) Inspect(tables ...string) ([]TableSchema, error) {
+	migrator := i.db.Migrator()
+	if len(tables) == 0 {
+		names, err := migrator.GetTables()
+		if err != nil {
+			return nil, fmt.Errorf("读取表列表失败: %w", err)
+		}
+		tables = names
+	}
+	sort.Strings(tables)
+
+	result := make([]TableSchema, 0, len(tables))
+	for _, table := range tables {
+		if i.Dialect() == SQLite && strings.HasPrefix(table, "sqlite_") {
+			continue
+		}
+		t, err := i.inspectTable(table)
+		if err != nil {
+			return nil, fmt.Errorf("读取表 %s 结构失败: %w", table, err)
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// inspectTable 读取单个表的列、索引与外键
+func (i *SchemaInspector) inspectTable(table string) (TableSchema, error) {
+	t := TableSchema{Name: table}
+
+	columnTypes, err := i.db.Migrator().ColumnTypes(table)
+	if err != nil {
+		return t, err
+	}
+	for _, ct := range columnTypes {
+		column := ColumnSchema{Name: ct.Name()}
+		if typ, ok := ct.ColumnType(); ok && typ != "" {
+			column.Type = typ
+		} else {
+			column.Type = ct.DatabaseTypeName()
+		}
+		column.PrimaryKey, _ = ct.PrimaryKey()
+		column.Unique, _ = ct.Unique()
+		if nullable, ok := ct.Nullable(); ok {
+			column.Nullable = nullable && !column.PrimaryKey
+		}
+		if def, ok := ct.DefaultValue(); ok {
+			column.Default = def
+		}
+		t.Columns = append(t.Columns, column)
+	}
+
+	if t.Indexes, err = i.inspectIndexes(table); err != nil {
+		return t, err
+	}
+	if t.ForeignKeys, err = i.inspectForeignKeys(table); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// inspectIndexes 读取表的索引
+func (i *SchemaInspector) inspectIndexes(table string) ([]IndexSchema, error) {
+	var indexes []IndexSchema
+
+	if i.Dialect() == SQLite {
+		// sqlite 驱动的 GetIndexes 会输出调试日志，这里直接查询 PRAGMA
+		var list []struct {
+			Name   string
+			Unique bool
+			Origin string
+		}
+		if err := i.db.Raw("SELECT name, `unique`, origin FROM PRAGMA_index_list(?)", table).Scan(&list).Error; err != nil {
+			return nil, err
+		}
+		for _, index := range list {
+			// 跳过主键与唯一约束自动创建的索引
+			if index.Origin != "c" {
+				continue
+			}
+			var columns []string
+			if err := i.db.Raw("SELECT name FROM PRAGMA_index_info(?) ORDER BY seqno", index.Name).Scan(&columns).Error; err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, IndexSchema{Name: index.Name, Columns: columns, Unique: index.Unique})
+		}
+	} else {
+		list, err := i.db.Migrator().GetIndexes(table)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range list {
+			if primary, _ := index.PrimaryKey(); primary {
+				continue
+			}
+			unique, _ := index.Unique()
+			indexes = append(indexes, IndexSchema{Name: index.Name(), Columns: index.Columns(), Unique: unique})
+		}
+	}
+
+	sort.Slice(indexes, func(a, b int) bool { return indexes[a].Name < indexes[b].Name })
+	return indexes, nil
+}
+
+// foreignKeyRow 外键查询的单行结果，每行对应外键中的一列
+type foreignKeyRow struct {
+	Name      string
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+// inspectForeignKeys 读取表的外键，gorm 的 Migrator 不提供外键列表，按方言分别查询
+func (i *SchemaInspector) inspectForeignKeys(table string) ([]ForeignKeySchema, error) {
+	var rows []foreignKeyRow
+	var err error
+
+	switch i.Dialect() {
+	case SQLite:
+		err = i.db.Raw(`SELECT CAST(id AS TEXT) AS name, "from" AS "column", "table" AS ref_table, "to" AS ref_column
+			FROM PRAGMA_foreign_key_list(?) ORDER BY id, seq`, table).Scan(&rows).Error
+	case MySQL:
+		err = i.db.Raw(`SELECT CONSTRAINT_NAME AS name, COLUMN_NAME AS `+"`column`"+`,
+				REFERENCED_TABLE_NAME AS ref_table, REFERENCED_COLUMN_NAME AS ref_column
+			FROM information_schema.KEY_COLUMN_USAGE
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND REFERENCED_TABLE_NAME IS NOT NULL
+			ORDER BY CONSTRAINT_NAME, ORDINAL_POSITION`, table).Scan(&rows).Error
+	case PostgreSQL:
+		err = i.db.Raw(`SELECT tc.constraint_name AS name, kcu.column_name AS "column",
+				ccu.table_name AS ref_table, ccu.column_name AS ref_column
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.key_column_usage kcu
+				ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+			JOIN information_schema.constraint_column_usage ccu
+				ON tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema
+			WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = CURRENT_SCHEMA() AND tc.table_name = ?
+			ORDER BY tc.constraint_name, kcu.ordinal_position`, table).Scan(&rows).Error
+	default:
+		// 其他方言不检查外键
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fks []ForeignKeySchema
+	for _, row := range rows {
+		if n := len(fks); n > 0 && fks[n-1].Name == row.Name {
+			fks[n-1].Columns = append(fks[n-1].Columns, row.Column)
+			fks[n-1].RefColumns = append(fks[n-1].RefColumns, row.RefColumn)
+			continue
+		}
+		fks = append(fks, ForeignKeySchema{
+			Name:       row.Name,
+			Columns:    []string{row.Column},
+			RefTable:   row.RefTable,
+			RefColumns: []string{row.RefColumn},
+		})
+	}
+	if i.Dialect() == SQLite {
+		// SQLite 外键的 id 只是序号，不作为名称输出
+		for n := range fks {
+			fks[n].Name = ""
+		}
+	}
+	return fks, nil
+}
+
+// ModelSchema 根据模型推导期望的表结构，列类型由当前连接的方言决定
+func (i *SchemaInspector) ModelSchema(models ...interface{}) ([]TableSchema, error) {
+	result := make([]TableSchema, 0, len(models))
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: i.db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("解析模型 %T 失败: %w", model, err)
+		}
+		result = append(result, i.modelTable(stmt.Schema))
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].Name < result[b].Name })
+	return result, nil
+}
+
+// modelTable 将 gorm 的模型结构转换为表结构
+func (i *SchemaInspector) modelTable(s *schema.Schema) TableSchema {
+	t := TableSchema{Name: s.Table}
+
+	for _, dbName := range s.DBNames {
+		field := s.FieldsByDBName[dbName]
+		if field.IgnoreMigration {
+			continue
+		}
+		column := ColumnSchema{
+			Name:       dbName,
+			Type:       i.db.Dialector.DataTypeOf(field),
+			Nullable:   !field.NotNull && !field.PrimaryKey,
+			PrimaryKey: field.PrimaryKey,
+			Unique:     field.Unique,
+		}
+		if field.HasDefaultValue && field.DefaultValueInterface == nil {
+			column.Default = field.DefaultValue
+		} else if field.DefaultValueInterface != nil {
+			column.Default = fmt.Sprint(field.DefaultValueInterface)
+		}
+		t.Columns = append(t.Columns, column)
+	}
+
+	for _, index := range s.ParseIndexes() {
+		columns := make([]string, len(index.Fields))
+		for n, option := range index.Fields {
+			columns[n] = option.DBName
+		}
+		t.Indexes = append(t.Indexes, IndexSchema{
+			Name:    index.Name,
+			Columns: columns,
+			Unique:  strings.EqualFold(index.Class, "UNIQUE"),
+		})
+	}
+	sort.Slice(t.Indexes, func(a, b int) bool { return t.Indexes[a].Name < t.Indexes[b].Name })
+
+	for _, rel := range s.Relationships.Relations {
+		constraint := rel.ParseConstraint()
+		if constraint == nil || constraint.Schema != s {
+			continue
+		}
+		fk := ForeignKeySchema{Name: constraint.Name, RefTable: constraint.ReferenceSchema.Table}
+		for _, field := range constraint.ForeignKeys {
+			fk.Columns = append(fk.Columns, field.DBName)
+		}
+		for _, field := range constraint.References {
+			fk.RefColumns = append(fk.RefColumns, field.DBName)
+		}
+		t.ForeignKeys = append(t.ForeignKeys, fk)
+	}
+	sort.Slice(t.ForeignKeys, func(a, b int) bool { return t.ForeignKeys[a].Name < t.ForeignKeys[b].Name })
+	return t
+}
@@ -0,0 +1,516 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrSchemaDrift 数据库结构与模型不一致
+var ErrSchemaDrift = errors.New("数据库结构与模型不一致")
+
+// 结构差异类型
+const (
+	ChangeAddTable       = "add_table"
+	ChangeDropTable      = "drop_table"
+	ChangeAddColumn      = "add_column"
+	ChangeDropColumn     = "drop_column"
+	ChangeAlterColumn    = "alter_column"
+	ChangeAddIndex       = "add_index"
+	ChangeDropIndex      = "drop_index"
+	ChangeAddForeignKey  = "add_foreign_key"
+	ChangeDropForeignKey = "drop_foreign_key"
+)
+
+// SchemaChange 单项结构差异，Expected 为模型推导的定义，Actual 为数据库中的定义
+type SchemaChange struct {
+	Kind        string `json:"kind"`
+	Table       string `json:"table"`
+	Name        string `json:"name,omitempty"`
+	Expected    string `json:"expected,omitempty"`
+	Actual      string `json:"actual,omitempty"`
+	Destructive bool   `json:"destructive"`
+}
+
+// String 返回差异的可读描述
+func (c SchemaChange) String() string {
+	switch c.Kind {
+	case ChangeAddTable:
+		return fmt.Sprintf("+ 表 %s 缺失", c.Table)
+	case ChangeDropTable:
+		return fmt.Sprintf("- 表 %s 未在模型中定义", c.Table)
+	case ChangeAddColumn:
+		return fmt.Sprintf("+ %s.%s 缺失: %s", c.Table, c.Name, c.Expected)
+	case ChangeDropColumn:
+		return fmt.Sprintf("- %s.%s 未在模型中定义: %s", c.Table, c.Name, c.Actual)
+	case ChangeAlterColumn:
+		return fmt.Sprintf("~ %s.%s 定义不一致: 期望 %s，实际 %s", c.Table, c.Name, c.Expected, c.Actual)
+	case ChangeAddIndex:
+		return fmt.Sprintf("+ 索引 %s 缺失: %s", c.Name, c.Expected)
+	case ChangeDropIndex:
+		return fmt.Sprintf("- 索引 %s 未在模型中定义: %s", c.Name, c.Actual)
+	case ChangeAddForeignKey:
+		return fmt.Sprintf("+ %s 外键缺失: %s", c.Table, c.Expected)
+	case ChangeDropForeignKey:
+		return fmt.Sprintf("- %s 外键未在模型中定义: %s", c.Table, c.Actual)
+	}
+	return fmt.Sprintf("%s %s %s", c.Kind, c.Table, c.Name)
+}
+
+// SchemaDiff 模型与数据库之间的结构差异
+type SchemaDiff struct {
+	Dialect string         `json:"dialect"`
+	Changes []SchemaChange `json:"changes"`
+
+	expected map[string]TableSchema
+}
+
+// HasDrift 是否存在差异
+func (d *SchemaDiff) HasDrift() bool {
+	return len(d.Changes) > 0
+}
+
+// String 返回按行排列的差异描述
+func (d *SchemaDiff) String() string {
+	if !d.HasDrift() {
+		return "数据库结构与模型一致"
+	}
+	lines := make([]string, len(d.Changes))
+	for i, change := range d.Changes {
+		lines[i] = change.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiffOptions 结构对比选项
+type DiffOptions struct {
+	// IgnoreTables 不参与对比的数据库表，迁移记录表总是被忽略
+	IgnoreTables []string
+}
+
+// DiffSchemas 对比模型推导的结构与数据库中的结构
+// 列类型与默认值按方言规范化后再比较，避免 SQLite 类型亲和性、MySQL 显示宽度等造成的误报
+func DiffSchemas(dialect string, expected, actual []TableSchema, opts DiffOptions) *SchemaDiff {
+	ignored := map[string]bool{MigrationTable: true, (SeederRecord{}).TableName(): true}
+	for _, table := range opts.IgnoreTables {
+		ignored[table] = true
+	}
+
+	diff := &SchemaDiff{Dialect: dialect, Changes: []SchemaChange{}, expected: make(map[string]TableSchema)}
+	actualByName := make(map[string]TableSchema, len(actual))
+	for _, t := range actual {
+		actualByName[t.Name] = t
+	}
+
+	for _, want := range expected {
+		diff.expected[want.Name] = want
+		got, ok := actualByName[want.Name]
+		if !ok {
+			diff.Changes = append(diff.Changes, SchemaChange{Kind: ChangeAddTable, Table: want.Name})
+			continue
+		}
+		diff.Changes = append(diff.Changes, diffTable(dialect, want, got)...)
+	}
+
+	for _, got := range actual {
+		if _, ok := diff.expected[got.Name]; ok || ignored[got.Name] {
+			continue
+		}
+		diff.Changes = append(diff.Changes, SchemaChange{Kind: ChangeDropTable, Table: got.Name, Destructive: true})
+	}
+	return diff
+}
+
+// diffTable 对比单个表的列、索引与外键
+func diffTable(dialect string, want, got TableSchema) []SchemaChange {
+	var changes []SchemaChange
+
+	for _, column := range want.Columns {
+		actual, ok := got.Column(column.Name)
+		if !ok {
+			changes = append(changes, SchemaChange{
+				Kind: ChangeAddColumn, Table: want.Name, Name: column.Name,
+				Expected: columnDefinition(column),
+			})
+			continue
+		}
+		if !sameColumn(dialect, column, actual) {
+			changes = append(changes, SchemaChange{
+				Kind: ChangeAlterColumn, Table: want.Name, Name: column.Name,
+				Expected: columnDefinition(column), Actual: columnDefinition(actual),
+				Destructive: true,
+			})
+		}
+	}
+	for _, column := range got.Columns {
+		if _, ok := want.Column(column.Name); !ok {
+			changes = append(changes, SchemaChange{
+				Kind: ChangeDropColumn, Table: want.Name, Name: column.Name,
+				Actual: columnDefinition(column), Destructive: true,
+			})
+		}
+	}
+
+	gotIndexes := make(map[string]IndexSchema, len(got.Indexes))
+	for _, index := range got.Indexes {
+		gotIndexes[index.Name] = index
+	}
+	wantIndexes := make(map[string]bool, len(want.Indexes))
+	for _, index := range want.Indexes {
+		wantIndexes[index.Name] = true
+		actual, ok := gotIndexes[index.Name]
+		switch {
+		case !ok:
+			changes = append(changes, SchemaChange{
+				Kind: ChangeAddIndex, Table: want.Name, Name: index.Name, Expected: indexDefinition(index),
+			})
+		case indexDefinition(actual) != indexDefinition(index):
+			// 索引定义变化需先删除再重建
+			changes = append(changes,
+				SchemaChange{Kind: ChangeDropIndex, Table: want.Name, Name: index.Name, Actual: indexDefinition(actual), Destructive: true},
+				SchemaChange{Kind: ChangeAddIndex, Table: want.Name, Name: index.Name, Expected: indexDefinition(index)},
+			)
+		}
+	}
+	for _, index := range got.Indexes {
+		if !wantIndexes[index.Name] {
+			changes = append(changes, SchemaChange{
+				Kind: ChangeDropIndex, Table: want.Name, Name: index.Name, Actual: indexDefinition(index), Destructive: true,
+			})
+		}
+	}
+
+	gotFKs := make(map[string]ForeignKeySchema, len(got.ForeignKeys))
+	for _, fk := range got.ForeignKeys {
+		gotFKs[fk.signature()] = fk
+	}
+	wantFKs := make(map[string]bool, len(want.ForeignKeys))
+	for _, fk := range want.ForeignKeys {
+		wantFKs[fk.signature()] = true
+		if _, ok := gotFKs[fk.signature()]; !ok {
+			changes = append(changes, SchemaChange{
+				Kind: ChangeAddForeignKey, Table: want.Name, Name: fk.Name, Expected: fk.signature(),
+			})
+		}
+	}
+	for _, fk := range got.ForeignKeys {
+		if !wantFKs[fk.signature()] {
+			changes = append(changes, SchemaChange{
+				Kind: ChangeDropForeignKey, Table: want.Name, Name: fk.Name, Actual: fk.signature(), Destructive: true,
+			})
+		}
+	}
+	return changes
+}
+
+// sameColumn 规范化后比较列定义，主键列不比较默认值
+func sameColumn(dialect string, want, got ColumnSchema) bool {
+	if NormalizeColumnType(dialect, want.Type) != NormalizeColumnType(dialect, got.Type) {
+		return false
+	}
+	if want.Nullable != got.Nullable || want.Unique != got.Unique {
+		return false
+	}
+	if want.PrimaryKey || got.PrimaryKey {
+		return true
+	}
+	return normalizeDefault(want.Default) == normalizeDefault(got.Default)
+}
+
+// columnDefinition 列定义的可读形式
+func columnDefinition(c ColumnSchema) string {
+	parts := []string{c.Type}
+	if c.PrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+	} else if !c.Nullable {
+		parts = append(parts, "NOT NULL")
+	}
+	if c.Unique {
+		parts = append(parts, "UNIQUE")
+	}
+	if c.Default != "" {
+		parts = append(parts, "DEFAULT "+c.Default)
+	}
+	return strings.Join(parts, " ")
+}
+
+// indexDefinition 索引定义的可读形式
+func indexDefinition(index IndexSchema) string {
+	definition := "(" + strings.Join(index.Columns, ", ") + ")"
+	if index.Unique {
+		definition = "UNIQUE " + definition
+	}
+	return definition
+}
+
+var (
+	// displayWidthPattern MySQL 整数类型的显示宽度，例如 int(11)
+	displayWidthPattern = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|integer|bigint)\(\d+\)`)
+	// spacePattern 连续空白
+	spacePattern = regexp.MustCompile(`\s+`)
+)
+
+// postgresTypeAliases PostgreSQL 类型别名与 information_schema 名称到规范名称的映射
+var postgresTypeAliases = map[string]string{
+	"int":                         "integer",
+	"int4":                        "integer",
+	"serial":                      "integer",
+	"int8":                        "bigint",
+	"bigserial":                   "bigint",
+	"int2":                        "smallint",
+	"smallserial":                 "smallint",
+	"float8":                      "double precision",
+	"float4":                      "real",
+	"bool":                        "boolean",
+	"decimal":                     "numeric",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"time without time zone":      "time",
+	"character varying":           "varchar",
+	"character":                   "char",
+}
+
+// NormalizeColumnType 将列类型规范化为可比较的形式
+//
+//   - SQLite 按类型亲和性归类：varchar(255) 与 text 都视为 text，bigint 与 integer 都视为 integer
+//   - MySQL 去除整数类型的显示宽度：int(11) 视为 int，tinyint(1) 视为 boolean
+//   - PostgreSQL 统一类型别名：int8 视为 bigint，character varying(255) 视为 varchar(255)
+func NormalizeColumnType(dialect, typ string) string {
+	t := strings.ToLower(strings.TrimSpace(spacePattern.ReplaceAllString(typ, " ")))
+
+	switch dialect {
+	case SQLite:
+		return sqliteAffinity(t)
+	case MySQL:
+		if strings.HasPrefix(t, "tinyint(1)") || t == "bool" || t == "boolean" {
+			return "boolean"
+		}
+		t = displayWidthPattern.ReplaceAllString(t, "$1")
+		if t == "integer" || strings.HasPrefix(t, "integer ") {
+			t = "int" + strings.TrimPrefix(t, "integer")
+		}
+		return t
+	case PostgreSQL:
+		base, size := t, ""
+		if i := strings.IndexByte(t, '('); i >= 0 {
+			base, size = strings.TrimSpace(t[:i]), t[i:]
+			// timestamp(6) with time zone 等精度写在中间的类型
+			if j := strings.IndexByte(size, ')'); j >= 0 && j < len(size)-1 {
+				base, size = base+size[j+1:], size[:j+1]
+			}
+		}
+		if alias, ok := postgresTypeAliases[base]; ok {
+			base = alias
+		}
+		return base + size
+	}
+	return t
+}
+
+// sqliteAffinity 按 SQLite 的类型亲和性规则归类
+// 参见 https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+func sqliteAffinity(t string) string {
+	switch {
+	case strings.Contains(t, "int"):
+		return "integer"
+	case strings.Contains(t, "char"), strings.Contains(t, "clob"), strings.Contains(t, "text"):
+		return "text"
+	case strings.Contains(t, "blob"), t == "":
+		return "blob"
+	case strings.Contains(t, "real"), strings.Contains(t, "floa"), strings.Contains(t, "doub"):
+		return "real"
+	}
+	return "numeric"
+}
+
+// normalizeDefault 规范化默认值：去除括号、引号与 PostgreSQL 的类型转换，布尔值统一为 1/0
+func normalizeDefault(def string) string {
+	d := strings.TrimSpace(def)
+	for len(d) >= 2 && d[0] == '(' && d[len(d)-1] == ')' {
+		d = strings.TrimSpace(d[1 : len(d)-1])
+	}
+	if i := strings.Index(d, "::"); i > 0 {
+		d = d[:i]
+	}
+	if len(d) >= 2 && (d[0] == '\'' || d[0] == '"') && d[len(d)-1] == d[0] {
+		d = d[1 : len(d)-1]
+	}
+
+	switch lower := strings.ToLower(d); {
+	case lower == "null":
+		return ""
+	case lower == "true":
+		return "1"
+	case lower == "false":
+		return "0"
+	case strings.HasPrefix(lower, "nextval("):
+		// PostgreSQL 自增序列
+		return ""
+	case lower == "current_timestamp()" || lower == "now()":
+		return "current_timestamp"
+	case lower == "current_timestamp":
+		return lower
+	}
+	return d
+}
+
+// migrationStubTemplate 结构差异生成的迁移文件模板
+const migrationStubTemplate = `package migrations
+
+import (
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/gorm"
+)
+
+func init() {
+	db.RegisterMigration("%s", "%s", up%s, down%s)
+}
+
+// up%s 由 flow db diff 生成，执行前请检查语句
+func up%s(db *gorm.DB) error {
+%s	return nil
+}
+
+// down%s 回滚新增的结构
+func down%s(db *gorm.DB) error {
+%s	return nil
+}
+`
+
+// MigrationStub 根据差异生成迁移文件内容
+// 新增的表、列、索引与外键生成可执行语句，删除与修改等破坏性变更以注释形式输出，需人工确认
+func (d *SchemaDiff) MigrationStub(id, name string) string {
+	var up, down strings.Builder
+	for _, change := range d.Changes {
+		statements := d.changeSQL(change)
+		if change.Destructive {
+			fmt.Fprintf(&up, "\t// 警告: 破坏性变更，确认后取消注释: %s\n", change)
+			for _, statement := range statements {
+				fmt.Fprintf(&up, "\t// if err := db.Exec(%q).Error; err != nil {\n\t// \treturn err\n\t// }\n", statement)
+			}
+			continue
+		}
+		if len(statements) == 0 {
+			fmt.Fprintf(&up, "\t// TODO: %s 不支持自动生成，请手动处理: %s\n", d.Dialect, change)
+			continue
+		}
+		for _, statement := range statements {
+			fmt.Fprintf(&up, "\tif err := db.Exec(%q).Error; err != nil {\n\t\treturn err\n\t}\n", statement)
+		}
+		if rollback := rollbackCall(change); rollback != "" {
+			fmt.Fprintf(&down, "\tif err := %s; err != nil {\n\t\treturn err\n\t}\n", rollback)
+		}
+	}
+
+	pascalName := toPascalCase(name)
+	return fmt.Sprintf(migrationStubTemplate,
+		id, name, pascalName, pascalName,
+		pascalName, pascalName, up.String(),
+		pascalName, pascalName, down.String(),
+	)
+}
+
+// WriteMigrationStub 将差异生成的迁移文件写入目录，返回文件路径
+func (d *SchemaDiff) WriteMigrationStub(directory, name string) (string, error) {
+	id := time.Now().Format("20060102150405")
+	name = strings.ReplaceAll(strings.ToLower(name), " ", "_")
+
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return "", fmt.Errorf("创建迁移目录失败: %w", err)
+	}
+	path := fmt.Sprintf("%s/%s_%s.go", directory, id, name)
+	if err := os.WriteFile(path, []byte(d.MigrationStub(id, name)), 0644); err != nil {
+		return "", fmt.Errorf("写入迁移文件失败: %w", err)
+	}
+	return path, nil
+}
+
+// changeSQL 生成单项差异对应的SQL语句
+func (d *SchemaDiff) changeSQL(c SchemaChange) []string {
+	switch c.Kind {
+	case ChangeAddTable:
+		table := d.expected[c.Table]
+		definitions := make([]string, 0, len(table.Columns))
+		for _, column := range table.Columns {
+			definitions = append(definitions, column.Name+" "+columnDefinition(column))
+		}
+		statements := []string{fmt.Sprintf("CREATE TABLE %s (%s)", c.Table, strings.Join(definitions, ", "))}
+		for _, index := range table.Indexes {
+			statements = append(statements, createIndexSQL(c.Table, index))
+		}
+		return statements
+	case ChangeAddColumn:
+		return []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.Table, c.Name, c.Expected)}
+	case ChangeAddIndex:
+		for _, index := range d.expected[c.Table].Indexes {
+			if index.Name == c.Name {
+				return []string{createIndexSQL(c.Table, index)}
+			}
+		}
+	case ChangeAddForeignKey:
+		if d.Dialect == SQLite {
+			// SQLite 不支持为已有表添加外键
+			return nil
+		}
+		for _, fk := range d.expected[c.Table].ForeignKeys {
+			if fk.signature() == c.Expected {
+				return []string{fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+					c.Table, fk.Name, strings.Join(fk.Columns, ", "), fk.RefTable, strings.Join(fk.RefColumns, ", "))}
+			}
+		}
+	case ChangeDropTable:
+		return []string{"DROP TABLE " + c.Table}
+	case ChangeDropColumn:
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", c.Table, c.Name)}
+	case ChangeDropIndex:
+		if d.Dialect == MySQL {
+			return []string{fmt.Sprintf("DROP INDEX %s ON %s", c.Name, c.Table)}
+		}
+		return []string{"DROP INDEX " + c.Name}
+	case ChangeDropForeignKey:
+		if d.Dialect == SQLite || c.Name == "" {
+			return nil
+		}
+		if d.Dialect == MySQL {
+			return []string{fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", c.Table, c.Name)}
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", c.Table, c.Name)}
+	case ChangeAlterColumn:
+		switch d.Dialect {
+		case MySQL:
+			return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", c.Table, c.Name, c.Expected)}
+		case PostgreSQL:
+			return []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", c.Table, c.Name, strings.Fields(c.Expected)[0])}
+		}
+	}
+	return nil
+}
+
+// createIndexSQL 生成建索引语句
+func createIndexSQL(table string, index IndexSchema) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, index.Name, table, strings.Join(index.Columns, ", "))
+}
+
+// rollbackCall 新增结构的回滚调用
+func rollbackCall(c SchemaChange) string {
+	switch c.Kind {
+	case ChangeAddTable:
+		return fmt.Sprintf("db.Migrator().DropTable(%q)", c.Table)
+	case ChangeAddColumn:
+		return fmt.Sprintf("db.Migrator().DropColumn(%q, %q)", c.Table, c.Name)
+	case ChangeAddIndex:
+		return fmt.Sprintf("db.Migrator().DropIndex(%q, %q)", c.Table, c.Name)
+	case ChangeAddForeignKey:
+		if c.Name != "" {
+			return fmt.Sprintf("db.Migrator().DropConstraint(%q, %q)", c.Table, c.Name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type schemaUser struct {
+	ID     uint   `gorm:"primaryKey"`
+	Email  string `gorm:"size:191;not null;uniqueIndex:idx_users_email"`
+	Name   string `gorm:"size:100"`
+	Active bool   `gorm:"not null;default:true"`
+}
+
+func (schemaUser) TableName() string { return "users" }
+
+type schemaOrder struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null;index"`
+	User   schemaUser
+	Amount float64
+}
+
+func (schemaOrder) TableName() string { return "orders" }
+
+// newSchemaTestDB 创建已按模型迁移的内存数据库
+func newSchemaTestDB(t *testing.T) *gorm.DB {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&schemaUser{}, &schemaOrder{}, &MigrationRecord{}))
+	return conn
+}
+
+func diffTestDB(t *testing.T, conn *gorm.DB) *SchemaDiff {
+	inspector := NewSchemaInspector(conn)
+	expected, err := inspector.ModelSchema(&schemaUser{}, &schemaOrder{})
+	require.NoError(t, err)
+	actual, err := inspector.Inspect()
+	require.NoError(t, err)
+	return DiffSchemas(inspector.Dialect(), expected, actual, DiffOptions{})
+}
+
+func changeKinds(diff *SchemaDiff) []string {
+	kinds := make([]string, len(diff.Changes))
+	for i, change := range diff.Changes {
+		kinds[i] = change.Kind + " " + change.Table + "." + change.Name
+	}
+	return kinds
+}
+
+func TestSchemaInspector_Inspect(t *testing.T) {
+	conn := newSchemaTestDB(t)
+	tables, err := NewSchemaInspector(conn).Inspect("orders")
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+
+	orders := tables[0]
+	id, ok := orders.Column("id")
+	require.True(t, ok)
+	assert.True(t, id.PrimaryKey)
+	assert.False(t, id.Nullable)
+
+	assert.Equal(t, []IndexSchema{{Name: "idx_orders_user_id", Columns: []string{"user_id"}}}, orders.Indexes)
+	require.Len(t, orders.ForeignKeys, 1)
+	assert.Equal(t, "(user_id) -> users(id)", orders.ForeignKeys[0].signature())
+}
+
+func TestDiffSchemas_NoDrift(t *testing.T) {
+	diff := diffTestDB(t, newSchemaTestDB(t))
+	assert.False(t, diff.HasDrift(), diff.String())
+}
+
+func TestDiffSchemas_Drift(t *testing.T) {
+	conn := newSchemaTestDB(t)
+	require.NoError(t, conn.Exec("ALTER TABLE users ADD COLUMN legacy_code text").Error)
+	require.NoError(t, conn.Exec("ALTER TABLE orders DROP COLUMN amount").Error)
+	require.NoError(t, conn.Exec("DROP INDEX idx_users_email").Error)
+	require.NoError(t, conn.Exec("CREATE INDEX idx_users_name ON users (name)").Error)
+	require.NoError(t, conn.Exec("CREATE TABLE audit_logs (id integer PRIMARY KEY)").Error)
+
+	diff := diffTestDB(t, conn)
+	assert.ElementsMatch(t, []string{
+		"add_column orders.amount",
+		"add_index users.idx_users_email",
+		"drop_column users.legacy_code",
+		"drop_index users.idx_users_name",
+		"drop_table audit_logs.",
+	}, changeKinds(diff))
+
+	for _, change := range diff.Changes {
+		assert.Equal(t, change.Kind[:4] == "drop", change.Destructive, change.String())
+	}
+}
+
+func TestDiffSchemas_AlterColumn(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&schemaUser{}))
+	require.NoError(t, conn.Exec("DROP TABLE users").Error)
+	require.NoError(t, conn.Exec(`CREATE TABLE users (id integer PRIMARY KEY AUTOINCREMENT, email varchar(191) NOT NULL,
+		name varchar(100), active numeric DEFAULT false)`).Error)
+	require.NoError(t, conn.Exec("CREATE UNIQUE INDEX idx_users_email ON users (email)").Error)
+
+	inspector := NewSchemaInspector(conn)
+	expected, err := inspector.ModelSchema(&schemaUser{})
+	require.NoError(t, err)
+	actual, err := inspector.Inspect("users")
+	require.NoError(t, err)
+
+	diff := DiffSchemas(SQLite, expected, actual, DiffOptions{})
+	require.Len(t, diff.Changes, 1, diff.String())
+	change := diff.Changes[0]
+	assert.Equal(t, ChangeAlterColumn, change.Kind)
+	assert.Equal(t, "active", change.Name)
+	assert.True(t, change.Destructive)
+}
+
+func TestNormalizeColumnType(t *testing.T) {
+	cases := []struct {
+		dialect  string
+		a, b     string
+		expected bool
+	}{
+		{SQLite, "varchar(191)", "text", true},
+		{SQLite, "bigint", "integer", true},
+		{SQLite, "numeric", "boolean", true},
+		{SQLite, "datetime", "numeric", true},
+		{SQLite, "double", "real", true},
+		{SQLite, "text", "integer", false},
+		{MySQL, "int(11)", "int", true},
+		{MySQL, "bigint(20) unsigned", "bigint unsigned", true},
+		{MySQL, "tinyint(1)", "boolean", true},
+		{MySQL, "INTEGER", "int(10)", true},
+		{MySQL, "varchar(191)", "varchar(255)", false},
+		{MySQL, "int unsigned", "int", false},
+		{PostgreSQL, "character varying(255)", "varchar(255)", true},
+		{PostgreSQL, "int8", "bigint", true},
+		{PostgreSQL, "timestamp with time zone", "timestamptz", true},
+		{PostgreSQL, "bool", "boolean", true},
+		{PostgreSQL, "integer", "bigint", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, NormalizeColumnType(c.dialect, c.a) == NormalizeColumnType(c.dialect, c.b),
+			"%s: %s vs %s", c.dialect, c.a, c.b)
+	}
+
+	assert.Equal(t, normalizeDefault("'abc'::character varying"), normalizeDefault("abc"))
+	assert.Equal(t, normalizeDefault("(true)"), normalizeDefault("1"))
+	assert.Equal(t, "", normalizeDefault("nextval('users_id_seq'::regclass)"))
+}
+
+func TestSchemaDiff_MigrationStub(t *testing.T) {
+	conn := newSchemaTestDB(t)
+	require.NoError(t, conn.Exec("ALTER TABLE orders DROP COLUMN amount").Error)
+	require.NoError(t, conn.Exec("ALTER TABLE users ADD COLUMN legacy_code text").Error)
+
+	stub := diffTestDB(t, conn).MigrationStub("20260101000000", "sync_schema")
+	assert.Contains(t, stub, `db.RegisterMigration("20260101000000", "sync_schema", upSyncSchema, downSyncSchema)`)
+	assert.Contains(t, stub, "\tif err := db.Exec(\"ALTER TABLE orders ADD COLUMN amount real\").Error; err != nil {")
+	assert.Contains(t, stub, `db.Migrator().DropColumn("orders", "amount")`)
+	assert.Contains(t, stub, "// 警告: 破坏性变更")
+	assert.Contains(t, stub, "\t// if err := db.Exec(\"ALTER TABLE users DROP COLUMN legacy_code\").Error; err != nil {")
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/zzliekkas/flow/v2/config"
 	"gopkg.in/yaml.v3"
 	"gorm.io/gorm"
 )
@@ -230,20 +231,63 @@ func (SeederRecord) TableName() string {
 	return "seeders"
 }
 
+// EnvironmentSeeder 限定运行环境的种子数据
+type EnvironmentSeeder interface {
+	// Environments 允许执行的环境，为空表示所有环境
+	Environments() []string
+}
+
+// scopedSeeder 限定环境的种子数据包装
+type scopedSeeder struct {
+	Seeder
+	environments []string
+}
+
+// Environments 实现 EnvironmentSeeder 接口
+func (s *scopedSeeder) Environments() []string {
+	return s.environments
+}
+
+// OnlyInEnvironments 限定种子数据只在指定环境执行，支持别名，例如 OnlyInEnvironments(seeder, "dev", "test")
+func OnlyInEnvironments(seeder Seeder, envs ...string) Seeder {
+	return &scopedSeeder{Seeder: seeder, environments: envs}
+}
+
 // SeederManager 种子数据管理器
 type SeederManager struct {
-	db      *gorm.DB
-	seeders map[string]Seeder
+	db          *gorm.DB
+	seeders     map[string]Seeder
+	environment string
 }
 
-// NewSeederManager 创建种子数据管理器
+// NewSeederManager 创建种子数据管理器，当前环境取自 FLOW_ENV
 func NewSeederManager(db *gorm.DB) *SeederManager {
 	return &SeederManager{
-		db:      db,
-		seeders: make(map[string]Seeder),
+		db:          db,
+		seeders:     make(map[string]Seeder),
+		environment: config.NormalizeEnvironment(os.Getenv("FLOW_ENV")),
 	}
 }
 
+// SetEnvironment 设置当前环境，限定环境的种子数据只在匹配时执行
+func (m *SeederManager) SetEnvironment(env string) {
+	m.environment = config.NormalizeEnvironment(env)
+}
+
+// allowed 判断种子数据是否允许在当前环境执行
+func (m *SeederManager) allowed(seeder Seeder) bool {
+	scoped, ok := seeder.(EnvironmentSeeder)
+	if !ok || len(scoped.Environments()) == 0 {
+		return true
+	}
+	for _, env := range scoped.Environments() {
+		if config.NormalizeEnvironment(env) == m.environment {
+			return true
+		}
+	}
+	return false
+}
+
 // Register 注册种子数据
 func (m *SeederManager) Register(seeder Seeder) error {
 	name := seeder.Name()
@@ -317,10 +361,10 @@ func (m *SeederManager) GetPending() ([]Seeder, error) {
 	// 获取所有种子数据
 	all := m.GetSeeders()
 
-	// 过滤出未执行的种子数据
+	// 过滤出未执行且允许在当前环境执行的种子数据
 	pending := make([]Seeder, 0)
 	for _, seeder := range all {
-		if !ranSet[seeder.Name()] {
+		if !ranSet[seeder.Name()] && m.allowed(seeder) {
 			pending = append(pending, seeder)
 		}
 	}
@@ -462,6 +506,9 @@ func (m *SeederManager) RunSeeder(name string) error {
 	if !exists {
 		return ErrSeederNotFound
 	}
+	if !m.allowed(seeder) {
+		return fmt.Errorf("%w: %s 不允许在 %s 环境执行", ErrSeederFailed, name, m.environment)
+	}
 
 	// 获取当前批次号
 	var batch int
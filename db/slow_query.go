@@ -0,0 +1,95 @@
+package db
+
+import (
+	"time"
+
+	"github.com/zzliekkas/flow/v2/flowctx"
+	"gorm.io/gorm"
+)
+
+// slowQueryStartKey 语句开始时间在实例上的键
+const slowQueryStartKey = "flow:slow_query_start"
+
+// SlowQuery 慢查询信息
+type SlowQuery struct {
+	SQL      string        // 执行的SQL（不含参数值）
+	Duration time.Duration // 执行耗时
+	Conn     string        // 连接名称
+	Rows     int64         // 影响的行数
+
+	RequestID string // 发起查询的请求ID，查询使用 WithContext 传入请求 context 时可用
+	TenantID  string // 发起查询的租户ID
+}
+
+// OnSlowQuery 为连接注册慢查询回调，执行耗时达到阈值的语句会回调handler
+//
+// handler在查询所在的goroutine中同步执行，应避免耗时操作；
+// event 包的 WatchSlowQueries 基于它将慢查询发布为框架事件
+func OnSlowQuery(conn *gorm.DB, connName string, threshold time.Duration, handler func(SlowQuery)) error {
+	if threshold <= 0 {
+		threshold = 200 * time.Millisecond
+	}
+
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(slowQueryStartKey, time.Now())
+	}
+	after := func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(slowQueryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(start)
+		if elapsed < threshold {
+			return
+		}
+		values := flowctx.Import(tx.Statement.Context)
+		handler(SlowQuery{
+			SQL:       tx.Statement.SQL.String(),
+			Duration:  elapsed,
+			Conn:      connName,
+			Rows:      tx.RowsAffected,
+			RequestID: values.RequestID,
+			TenantID:  values.TenantID,
+		})
+	}
+
+	callback := conn.Callback()
+	if err := callback.Create().Before("gorm:create").Register("flow:slow_query_before", before); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("flow:slow_query_after", after); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("flow:slow_query_before", before); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register("flow:slow_query_after", after); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("flow:slow_query_before", before); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("flow:slow_query_after", after); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("flow:slow_query_before", before); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register("flow:slow_query_after", after); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("flow:slow_query_before", before); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register("flow:slow_query_after", after); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register("flow:slow_query_before", before); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register("flow:slow_query_after", after)
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSQLiteBusyTimeout 未配置时 SQLite 等待锁的时间
+const DefaultSQLiteBusyTimeout = 5 * time.Second
+
+// SQLiteConfig SQLite 连接选项，以 DSN 参数的形式应用到连接池中的每个连接
+//
+//	sqlite:
+//	  journal_mode: wal     # delete、truncate、persist、memory、wal、off
+//	  busy_timeout: 5s      # 等待锁的时间，默认5秒，小于0时不等待
+//	  foreign_keys: true    # 启用外键约束
+//	  synchronous: normal   # off、normal、full、extra
+//	  private_memory: false # :memory: 数据库默认在同一管理器内共享，为true时每个底层连接各自独立
+//
+// 未设置 max_open_conns 的非 WAL 文件数据库只使用一个连接，避免写锁争用
+type SQLiteConfig struct {
+	JournalMode   string        `yaml:"journal_mode" json:"journal_mode"`
+	BusyTimeout   time.Duration `yaml:"busy_timeout" json:"busy_timeout"`
+	ForeignKeys   bool          `yaml:"foreign_keys" json:"foreign_keys"`
+	Synchronous   string        `yaml:"synchronous" json:"synchronous"`
+	PrivateMemory bool          `yaml:"private_memory" json:"private_memory"`
+}
+
+// memoryDatabaseSeq 为每个管理器生成共享内存数据库的名称
+var memoryDatabaseSeq atomic.Int64
+
+// isSQLiteMemory 判断是否为内存数据库
+func isSQLiteMemory(database string) bool {
+	return database == "" || database == ":memory:" ||
+		strings.HasPrefix(database, "file::memory:") || strings.Contains(database, "mode=memory")
+}
+
+// isWAL 判断是否使用 WAL 日志模式
+func (c SQLiteConfig) isWAL() bool {
+	return strings.EqualFold(c.JournalMode, "wal")
+}
+
+// sqliteDSN 生成带连接选项的 DSN，DSN 中已有的参数优先
+// :memory: 数据库改写为以 memoryName 命名的共享缓存内存数据库，使连接池中的连接看到同一个数据库
+func sqliteDSN(database string, config SQLiteConfig, memoryName string) string {
+	memory := isSQLiteMemory(database)
+	if (database == "" || database == ":memory:") && !config.PrivateMemory {
+		database = "file:" + memoryName + "?mode=memory&cache=shared"
+	}
+
+	path, rawQuery, _ := strings.Cut(database, "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return database
+	}
+	set := func(key, value string) {
+		if value != "" && !params.Has(key) {
+			params.Set(key, value)
+		}
+	}
+
+	switch {
+	case config.BusyTimeout > 0:
+		set("_busy_timeout", fmt.Sprint(config.BusyTimeout.Milliseconds()))
+	case config.BusyTimeout == 0:
+		set("_busy_timeout", fmt.Sprint(DefaultSQLiteBusyTimeout.Milliseconds()))
+	}
+	if !memory {
+		// 内存数据库只支持 memory 日志模式
+		set("_journal_mode", strings.ToUpper(config.JournalMode))
+	}
+	if config.ForeignKeys {
+		set("_foreign_keys", "1")
+	}
+	set("_synchronous", strings.ToUpper(config.Synchronous))
+
+	if len(params) == 0 {
+		return path
+	}
+	return path + "?" + params.Encode()
+}
+
+// applySQLitePoolDefaults 按数据库类型调整连接池默认值，maxOpenSet 表示用户是否显式设置了最大连接数
+func applySQLitePoolDefaults(config *Config, maxOpenSet bool) {
+	if isSQLiteMemory(config.Database) {
+		// 共享内存数据库在最后一个连接关闭时销毁，连接不能因空闲或到期被回收
+		config.ConnMaxLifetime = 0
+		config.ConnMaxIdleTime = 0
+		if config.MaxIdleConns < 1 {
+			config.MaxIdleConns = 1
+		}
+		return
+	}
+	if !maxOpenSet && !config.SQLite.isWAL() {
+		config.MaxOpenConns = 1
+	}
+}
+
+// sqliteConfigFromMap 从配置映射读取 SQLite 选项
+func sqliteConfigFromMap(value interface{}) SQLiteConfig {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return SQLiteConfig{}
+	}
+	return SQLiteConfig{
+		JournalMode:   getString(m, "journal_mode", ""),
+		BusyTimeout:   getDuration(m, "busy_timeout", 0),
+		ForeignKeys:   getBool(m, "foreign_keys", false),
+		Synchronous:   getString(m, "synchronous", ""),
+		PrivateMemory: getBool(m, "private_memory", false),
+	}
+}
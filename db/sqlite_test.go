@@ -0,0 +1,150 @@
+package db_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type litAuthor struct {
+	ID   uint
+	Name string
+}
+
+type litBook struct {
+	ID       uint
+	AuthorID uint
+	Author   litAuthor
+}
+
+func connectSQLite(t *testing.T, manager *db.Manager, name string, config db.Config) *gorm.DB {
+	config.Driver = db.SQLite
+	config.LogLevel = logger.Silent
+	require.NoError(t, manager.Register(name, config))
+	conn, err := manager.Connect(name)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = manager.Close() })
+	return conn
+}
+
+func TestSQLite_SharedMemoryAcrossConnections(t *testing.T) {
+	manager := db.NewManager()
+	writer := connectSQLite(t, manager, "writer", db.Config{Database: ":memory:"})
+	reader := connectSQLite(t, manager, "reader", db.Config{Database: ":memory:"})
+
+	require.NoError(t, writer.AutoMigrate(&litAuthor{}))
+	require.NoError(t, writer.Create(&litAuthor{Name: "Ada"}).Error)
+
+	// 另一个连接名与连接池中的其他连接看到同一个数据库
+	var count int64
+	require.NoError(t, reader.Model(&litAuthor{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var n int64
+			assert.NoError(t, writer.Model(&litAuthor{}).Count(&n).Error)
+			assert.Equal(t, int64(1), n)
+		}()
+	}
+	wg.Wait()
+
+	// 不同管理器的内存数据库互不影响
+	other := connectSQLite(t, db.NewManager(), "main", db.Config{Database: ":memory:"})
+	assert.False(t, other.Migrator().HasTable(&litAuthor{}))
+}
+
+func TestSQLite_PragmasApplied(t *testing.T) {
+	conn := connectSQLite(t, db.NewManager(), "main", db.Config{
+		Database: filepath.Join(t.TempDir(), "app.db"),
+		SQLite: db.SQLiteConfig{
+			JournalMode: "wal",
+			BusyTimeout: 2 * time.Second,
+			ForeignKeys: true,
+			Synchronous: "normal",
+		},
+	})
+
+	pragma := func(name string) string {
+		var value string
+		require.NoError(t, conn.Raw("PRAGMA "+name).Scan(&value).Error)
+		return value
+	}
+	assert.Equal(t, "wal", pragma("journal_mode"))
+	assert.Equal(t, "2000", pragma("busy_timeout"))
+	assert.Equal(t, "1", pragma("foreign_keys"))
+	assert.Equal(t, "1", pragma("synchronous"))
+
+	sqlDB, err := conn.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 100, sqlDB.Stats().MaxOpenConnections, "WAL 模式不限制为单连接")
+}
+
+func TestSQLite_ForeignKeysRejectOrphans(t *testing.T) {
+	conn := connectSQLite(t, db.NewManager(), "main", db.Config{
+		Database: ":memory:",
+		SQLite:   db.SQLiteConfig{ForeignKeys: true},
+	})
+	require.NoError(t, conn.AutoMigrate(&litAuthor{}, &litBook{}))
+
+	err := conn.Exec("INSERT INTO lit_books (author_id) VALUES (?)", 42).Error
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "FOREIGN KEY")
+}
+
+func TestSQLite_ConcurrentWritersWithWAL(t *testing.T) {
+	conn := connectSQLite(t, db.NewManager(), "main", db.Config{
+		Database:     filepath.Join(t.TempDir(), "app.db"),
+		MaxOpenConns: 8,
+		SQLite:       db.SQLiteConfig{JournalMode: "wal", BusyTimeout: 5 * time.Second},
+	})
+	require.NoError(t, conn.AutoMigrate(&litAuthor{}))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8*20)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if err := conn.Create(&litAuthor{Name: fmt.Sprintf("%d-%d", w, i)}).Error; err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var count int64
+	require.NoError(t, conn.Model(&litAuthor{}).Count(&count).Error)
+	assert.Equal(t, int64(160), count)
+}
+
+func TestSQLite_SingleConnectionForRollbackJournal(t *testing.T) {
+	manager := db.NewManager()
+	conn := connectSQLite(t, manager, "main", db.Config{Database: filepath.Join(t.TempDir(), "app.db")})
+	sqlDB, err := conn.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections)
+
+	// 显式设置的最大连接数保留
+	conn = connectSQLite(t, manager, "tuned", db.Config{Database: filepath.Join(t.TempDir(), "tuned.db"), MaxOpenConns: 4})
+	sqlDB, err = conn.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 4, sqlDB.Stats().MaxOpenConnections)
+}
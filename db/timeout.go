@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrQueryTimeout 查询超时错误，可通过 errors.Is 判断
+var ErrQueryTimeout = errors.New("数据库查询超时")
+
+// QueryTimeoutError 查询超时的详细信息
+type QueryTimeoutError struct {
+	Timeout time.Duration // 生效的超时时间，来自调用方截止时间时为0
+	Elapsed time.Duration // 实际执行时间
+	SQL     string        // 已脱敏的SQL
+	Err     error         // 原始错误
+}
+
+// Error 实现error接口
+func (e *QueryTimeoutError) Error() string {
+	return fmt.Sprintf("数据库查询超时 (耗时 %s): %s", e.Elapsed.Round(time.Millisecond), e.SQL)
+}
+
+// Is 使 errors.Is(err, ErrQueryTimeout) 成立
+func (e *QueryTimeoutError) Is(target error) bool {
+	return target == ErrQueryTimeout
+}
+
+// Unwrap 返回原始错误，errors.Is(err, context.DeadlineExceeded) 同样成立
+func (e *QueryTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutConfig 连接级默认超时
+type TimeoutConfig struct {
+	Query time.Duration // 查询（SELECT、Row、Rows）的默认超时
+	Exec  time.Duration // 写入（INSERT、UPDATE、DELETE、Exec）的默认超时
+}
+
+// 超时相关的语句设置键
+const (
+	timeoutOverrideKey = "db:timeout"
+	timeoutStateKey    = "flow:timeout_state"
+)
+
+// noTimeoutKey 上下文中禁用默认超时的标记
+type noTimeoutKey struct{}
+
+// WithNoTimeout 返回不应用默认超时的上下文，用于有意长时间运行的操作，如数据迁移与报表
+func WithNoTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noTimeoutKey{}, true)
+}
+
+// Timeout 返回覆盖默认超时的作用域，用法：db.Scopes(db.Timeout(time.Minute)).Find(&rows)
+// 传入0表示该次操作不设置超时
+func Timeout(d time.Duration) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Set(timeoutOverrideKey, d)
+	}
+}
+
+// timeoutState 单次操作的超时状态
+type timeoutState struct {
+	start   time.Time
+	timeout time.Duration
+	cancel  context.CancelFunc
+}
+
+// EnableTimeouts 为连接注册默认超时回调
+//
+// 只有传入的上下文没有截止时间时才会应用默认超时；超过截止时间的错误会转换为 *QueryTimeoutError。
+// Row/Rows（以及 Raw(...).Scan）的结果在回调结束后才被读取，因此它们的上下文会保留到截止时间自动释放，
+// 读取过程中的超时错误也无法被转换，调用方可用 errors.Is(err, context.DeadlineExceeded) 判断
+func EnableTimeouts(conn *gorm.DB, config TimeoutConfig) error {
+	callback := conn.Callback()
+
+	if err := callback.Query().Before("*").Register("flow:timeout", timeoutBefore(config.Query)); err != nil {
+		return err
+	}
+	if err := callback.Query().After("*").Register("flow:timeout_after", timeoutAfter(true)); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("*").Register("flow:timeout", timeoutBefore(config.Query)); err != nil {
+		return err
+	}
+	if err := callback.Row().After("*").Register("flow:timeout_after", timeoutAfter(false)); err != nil {
+		return err
+	}
+	if err := callback.Create().Before("*").Register("flow:timeout", timeoutBefore(config.Exec)); err != nil {
+		return err
+	}
+	if err := callback.Create().After("*").Register("flow:timeout_after", timeoutAfter(true)); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("*").Register("flow:timeout", timeoutBefore(config.Exec)); err != nil {
+		return err
+	}
+	if err := callback.Update().After("*").Register("flow:timeout_after", timeoutAfter(true)); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("*").Register("flow:timeout", timeoutBefore(config.Exec)); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("*").Register("flow:timeout_after", timeoutAfter(true)); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("*").Register("flow:timeout", timeoutBefore(config.Exec)); err != nil {
+		return err
+	}
+	return callback.Raw().After("*").Register("flow:timeout_after", timeoutAfter(true))
+}
+
+// timeoutBefore 在操作开始前为上下文设置默认截止时间
+func timeoutBefore(defaultTimeout time.Duration) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		state := &timeoutState{start: time.Now()}
+		tx.InstanceSet(timeoutStateKey, state)
+
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if _, hasDeadline := ctx.Deadline(); hasDeadline {
+			return
+		}
+		if bypass, _ := ctx.Value(noTimeoutKey{}).(bool); bypass {
+			return
+		}
+
+		timeout := defaultTimeout
+		if v, ok := tx.Get(timeoutOverrideKey); ok {
+			if d, ok := v.(time.Duration); ok {
+				timeout = d
+			}
+		}
+		if timeout <= 0 {
+			return
+		}
+
+		state.timeout = timeout
+		tx.Statement.Context, state.cancel = context.WithTimeout(ctx, timeout)
+	}
+}
+
+// timeoutAfter 释放上下文并转换超时错误
+func timeoutAfter(release bool) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(timeoutStateKey)
+		if !ok {
+			return
+		}
+		state := value.(*timeoutState)
+		if release && state.cancel != nil {
+			state.cancel()
+		}
+
+		if tx.Error == nil || !errors.Is(tx.Error, context.DeadlineExceeded) || errors.Is(tx.Error, ErrQueryTimeout) {
+			return
+		}
+		tx.Error = &QueryTimeoutError{
+			Timeout: state.timeout,
+			Elapsed: time.Since(state.start),
+			SQL:     redactSQL(tx.Statement.SQL.String()),
+			Err:     tx.Error,
+		}
+	}
+}
+
+// sqlLiteralPattern 匹配SQL中的字符串字面量
+var sqlLiteralPattern = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// redactSQL 移除SQL中的字面量，参数本身以占位符形式存在不会被包含
+func redactSQL(sqlText string) string {
+	sqlText = sqlLiteralPattern.ReplaceAllString(sqlText, "'?'")
+	if len(sqlText) > 500 {
+		sqlText = sqlText[:500] + "..."
+	}
+	return strings.TrimSpace(sqlText)
+}
+
+// statementTimeoutDSN 在DSN中加入会话级语句超时参数
+//
+// 参数由驱动在连接池中每个新建的物理连接上执行：MySQL 为 max_execution_time（毫秒，仅限SELECT），
+// PostgreSQL 为 statement_timeout 启动参数。SQLite 不支持会话级超时，返回原DSN与false
+func statementTimeoutDSN(driver, dsn string, timeout time.Duration) (string, bool) {
+	if timeout <= 0 {
+		return dsn, false
+	}
+	ms := timeout.Milliseconds()
+
+	switch driver {
+	case MySQL:
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		return fmt.Sprintf("%s%smax_execution_time=%d", dsn, sep, ms), true
+	case PostgreSQL:
+		return fmt.Sprintf("%s statement_timeout=%d", dsn, ms), true
+	default:
+		return dsn, false
+	}
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowSQL 在SQLite中执行足够久的查询
+const slowSQL = `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c WHERE x < 500000000) SELECT count(*) FROM c WHERE x > 'a'`
+
+type timeoutRecord struct {
+	ID   uint
+	Name string
+}
+
+// newTimeoutDB 创建启用默认超时的连接，并记录每次查询实际使用的截止时间
+func newTimeoutDB(t *testing.T, config TimeoutConfig) (*gorm.DB, *[]time.Duration) {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&timeoutRecord{}))
+	require.NoError(t, EnableTimeouts(conn, config))
+
+	var deadlines []time.Duration
+	err = conn.Callback().Query().After("flow:timeout").Before("gorm:query").Register("test:deadline", func(tx *gorm.DB) {
+		if deadline, ok := tx.Statement.Context.Deadline(); ok {
+			deadlines = append(deadlines, time.Until(deadline).Round(time.Second))
+		} else {
+			deadlines = append(deadlines, 0)
+		}
+	})
+	require.NoError(t, err)
+	return conn, &deadlines
+}
+
+func TestTimeouts_DefaultOnlyWithoutDeadline(t *testing.T) {
+	conn, deadlines := newTimeoutDB(t, TimeoutConfig{Query: 10 * time.Second})
+
+	var records []timeoutRecord
+	require.NoError(t, conn.Find(&records).Error)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	require.NoError(t, conn.WithContext(ctx).Find(&records).Error)
+
+	require.NoError(t, conn.WithContext(WithNoTimeout(context.Background())).Find(&records).Error)
+
+	// 没有截止时间时使用默认值，调用方的截止时间与禁用标记优先
+	assert.Equal(t, []time.Duration{10 * time.Second, 60 * time.Second, 0}, *deadlines)
+}
+
+func TestTimeouts_ScopeOverride(t *testing.T) {
+	conn, deadlines := newTimeoutDB(t, TimeoutConfig{Query: 10 * time.Second})
+
+	var records []timeoutRecord
+	require.NoError(t, conn.Scopes(Timeout(2*time.Minute)).Find(&records).Error)
+	require.NoError(t, conn.Scopes(Timeout(0)).Find(&records).Error)
+
+	assert.Equal(t, []time.Duration{2 * time.Minute, 0}, *deadlines)
+}
+
+func TestTimeouts_ErrorTyping(t *testing.T) {
+	conn, _ := newTimeoutDB(t, TimeoutConfig{Query: 50 * time.Millisecond, Exec: 50 * time.Millisecond})
+
+	var count int64
+	err := conn.Raw(slowSQL).Find(&count).Error
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrQueryTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var timeoutErr *QueryTimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+	assert.GreaterOrEqual(t, timeoutErr.Elapsed, 50*time.Millisecond)
+	assert.Contains(t, timeoutErr.SQL, "WITH RECURSIVE")
+	assert.NotContains(t, timeoutErr.SQL, "'a'", "字面量应被脱敏")
+
+	// 调用方截止时间导致的超时同样被转换
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = conn.WithContext(ctx).Exec(slowSQL).Error
+	assert.ErrorIs(t, err, ErrQueryTimeout)
+}
+
+func TestStatementTimeoutDSN(t *testing.T) {
+	dsn, ok := statementTimeoutDSN(MySQL, "u:p@tcp(localhost:3306)/app?charset=utf8mb4", 5*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, "u:p@tcp(localhost:3306)/app?charset=utf8mb4&max_execution_time=5000", dsn)
+
+	dsn, ok = statementTimeoutDSN(PostgreSQL, "host=localhost dbname=app", 1500*time.Millisecond)
+	assert.True(t, ok)
+	assert.Equal(t, "host=localhost dbname=app statement_timeout=1500", dsn)
+
+	// SQLite 不支持会话级超时，保持DSN不变
+	dsn, ok = statementTimeoutDSN(SQLite, ":memory:", 5*time.Second)
+	assert.False(t, ok)
+	assert.Equal(t, ":memory:", dsn)
+
+	manager := NewManager()
+	require.NoError(t, manager.Register("lite", Config{
+		Driver:           SQLite,
+		Database:         ":memory:",
+		LogLevel:         logger.Silent,
+		StatementTimeout: 5 * time.Second,
+		QueryTimeout:     time.Second,
+	}))
+	conn, err := manager.Connect("lite")
+	require.NoError(t, err)
+	assert.NoError(t, conn.Exec("SELECT 1").Error)
+	require.NoError(t, manager.Close())
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"github.com/zzliekkas/flow/v2/timing"
+	"gorm.io/gorm"
+)
+
+// timingSpanKey 语句计时在实例上的键
+const timingSpanKey = "flow:timing_span"
+
+// EnableTiming 为连接注册耗时统计回调，使用 WithContext 传入请求 context 的语句计入 Server-Timing 的 db 层
+//
+// Manager 创建的连接自动注册；请求未启用 middleware.ServerTiming 时回调只做一次 context 查找
+func EnableTiming(conn *gorm.DB) error {
+	before := func(tx *gorm.DB) {
+		if span := timing.Start(tx.Statement.Context, timing.LayerDB); span != nil {
+			tx.InstanceSet(timingSpanKey, span)
+		}
+	}
+	after := func(tx *gorm.DB) {
+		if value, ok := tx.InstanceGet(timingSpanKey); ok {
+			value.(*timing.Span).Stop()
+		}
+	}
+
+	callback := conn.Callback()
+	if err := callback.Create().Before("gorm:create").Register("flow:timing_before", before); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("flow:timing_after", after); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("flow:timing_before", before); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register("flow:timing_after", after); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("flow:timing_before", before); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("flow:timing_after", after); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("flow:timing_before", before); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register("flow:timing_after", after); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("flow:timing_before", before); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register("flow:timing_after", after); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register("flow:timing_before", before); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register("flow:timing_after", after)
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// commitInvalidator 在事务提交后使暂存的缓存标签失效，由查询缓存与缓存失效插件实现
+type commitInvalidator interface {
+	invalidateCommitted(ctx context.Context, tags []string) error
+}
+
+// installTxHooks 包装连接池，使开启的事务在提交后触发暂存的失效；已包装时不重复包装
+func installTxHooks(db *gorm.DB) {
+	if _, ok := db.ConnPool.(*txHookPool); !ok {
+		db.ConnPool = &txHookPool{ConnPool: db.ConnPool}
+	}
+	db.Statement.ConnPool = db.ConnPool
+}
+
+// pendingTx 返回语句所在的可暂存失效的事务，不在事务中或事务未经过包装时返回false
+func pendingTx(tx *gorm.DB) (*txHooks, bool) {
+	pending, ok := tx.Statement.ConnPool.(*txHooks)
+	return pending, ok
+}
+
+// inTransaction 判断语句是否在事务中执行
+func inTransaction(tx *gorm.DB) bool {
+	_, ok := tx.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
+// txHookPool 包装连接池，开启的事务在提交后触发失效
+type txHookPool struct {
+	gorm.ConnPool
+}
+
+// BeginTx 开启事务
+func (c *txHookPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var tx gorm.ConnPool
+	var err error
+	switch beginner := c.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	default:
+		err = gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &txHooks{ConnPool: tx, ctx: ctx}, nil
+}
+
+// GetDBConn 返回底层数据库连接，供 gorm.DB.DB() 使用
+func (c *txHookPool) GetDBConn() (*sql.DB, error) {
+	switch pool := c.ConnPool.(type) {
+	case *sql.DB:
+		return pool, nil
+	case gorm.GetDBConnector:
+		return pool.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// txHooks 事务中按插件暂存待失效的标签
+type txHooks struct {
+	gorm.ConnPool
+	ctx context.Context
+
+	mu      sync.Mutex
+	owners  []commitInvalidator
+	pending map[commitInvalidator][]string
+}
+
+// add 暂存标签，提交后交给 owner 失效
+func (t *txHooks) add(owner commitInvalidator, tags []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending == nil {
+		t.pending = make(map[commitInvalidator][]string)
+	}
+	if _, ok := t.pending[owner]; !ok {
+		t.owners = append(t.owners, owner)
+	}
+	t.pending[owner] = append(t.pending[owner], tags...)
+}
+
+// take 取出并清空暂存的标签
+func (t *txHooks) take() ([]commitInvalidator, map[commitInvalidator][]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	owners, pending := t.owners, t.pending
+	t.owners, t.pending = nil, nil
+	return owners, pending
+}
+
+// Commit 提交事务，成功后统一失效暂存的标签
+func (t *txHooks) Commit() error {
+	committer, ok := t.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	if err := committer.Commit(); err != nil {
+		t.take()
+		return err
+	}
+	owners, pending := t.take()
+	var errs []error
+	for _, owner := range owners {
+		if err := owner.invalidateCommitted(t.ctx, pending[owner]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Rollback 回滚事务并丢弃暂存的标签
+func (t *txHooks) Rollback() error {
+	t.take()
+	committer, ok := t.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	return committer.Rollback()
+}
@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 
 	"go.uber.org/dig"
 )
@@ -11,23 +14,116 @@ import (
 // Container 是依赖注入容器的封装
 type Container struct {
 	container *dig.Container
+
+	mu         sync.RWMutex
+	provided   map[string]*providerInfo // 类型键到提供者，用于错误信息与启动检查
+	strict     bool                     // 重复注册时返回错误
+	duplicates []*DuplicateProviderError
+	onDup      func(*DuplicateProviderError)
 }
 
 // New 创建一个新的DI容器
 func New() *Container {
 	return &Container{
 		container: dig.New(),
+		provided:  make(map[string]*providerInfo),
 	}
 }
 
+// SetStrict 设置严格模式
+//
+// 同一类型注册多个提供者时先注册的生效。严格模式下后注册的 Provide 返回 *DuplicateProviderError；
+// 非严格模式下 Provide 返回 nil，冲突交给 OnDuplicate 设置的回调（通常输出警告）
+func (c *Container) SetStrict(strict bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.strict = strict
+}
+
+// OnDuplicate 设置非严格模式下发现重复注册时的回调
+func (c *Container) OnDuplicate(fn func(*DuplicateProviderError)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDup = fn
+}
+
+// Duplicates 返回已发现的重复注册，按发现顺序排列，严格模式下被拒绝的注册同样包含在内
+func (c *Container) Duplicates() []*DuplicateProviderError {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]*DuplicateProviderError(nil), c.duplicates...)
+}
+
 // Provide 向容器注册服务构造函数
+// 构造函数引入循环依赖时返回 *CycleError，类型已注册时的行为见 SetStrict
 func (c *Container) Provide(constructor interface{}, opts ...dig.ProvideOption) error {
-	return c.container.Provide(constructor, opts...)
+	return c.provide(constructor, "", Constructor{Name: funcName(constructor), Location: funcLocation(constructor)}, opts...)
 }
 
 // ProvideNamed 向容器注册命名服务
 func (c *Container) ProvideNamed(constructor interface{}, name string) error {
-	return c.container.Provide(constructor, dig.Name(name))
+	return c.provide(constructor, name, Constructor{Name: funcName(constructor), Location: funcLocation(constructor)}, dig.Name(name))
+}
+
+// provide 注册构造函数并记录其依赖与产出
+func (c *Container) provide(constructor interface{}, name string, source Constructor, opts ...dig.ProvideOption) error {
+	var info dig.ProvideInfo
+	opts = append(opts, dig.FillProvideInfo(&info))
+	if err := c.container.Provide(constructor, opts...); err != nil {
+		if dig.IsCycleDetected(err) {
+			return &CycleError{Path: c.cyclePath(constructor, name), Err: err}
+		}
+		if dup := c.duplicateOf(constructor, name, source, err); dup != nil {
+			return c.reportDuplicate(dup)
+		}
+		return err
+	}
+
+	outputs := make([]string, 0, len(info.Outputs))
+	for _, out := range info.Outputs {
+		if key := out.String(); !strings.Contains(key, "group = ") {
+			outputs = append(outputs, key)
+		}
+	}
+	c.record(source.Name, source.Location, inputsOf(reflect.TypeOf(constructor)), outputs)
+	return nil
+}
+
+// duplicateOf 判断注册失败是否因为类型已有提供者，不是时返回 nil
+func (c *Container) duplicateOf(constructor interface{}, name string, source Constructor, err error) *DuplicateProviderError {
+	ft := reflect.TypeOf(constructor)
+	if ft == nil || ft.Kind() != reflect.Func || !strings.Contains(err.Error(), "already provided") {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, key := range outputsOf(ft, name) {
+		if previous, ok := c.provided[key]; ok {
+			return &DuplicateProviderError{
+				Type:     key,
+				Previous: Constructor{Name: previous.name, Location: previous.location},
+				Current:  source,
+				Err:      err,
+			}
+		}
+	}
+	return nil
+}
+
+// reportDuplicate 记录重复注册，严格模式下返回错误，否则交给回调
+func (c *Container) reportDuplicate(dup *DuplicateProviderError) error {
+	c.mu.Lock()
+	c.duplicates = append(c.duplicates, dup)
+	strict, onDup := c.strict, c.onDup
+	c.mu.Unlock()
+
+	if strict {
+		return dup
+	}
+	if onDup != nil {
+		onDup(dup)
+	}
+	return nil
 }
 
 // ProvideValue 直接注册一个值到容器
@@ -45,12 +141,26 @@ func (c *Container) ProvideValue(value interface{}) error {
 		},
 	).Interface()
 
-	return c.container.Provide(constructor)
+	// 值没有构造函数，位置取调用方
+	source := Constructor{Name: fmt.Sprintf("value(%s)", valueType)}
+	if _, file, line, ok := runtime.Caller(1); ok {
+		source.Location = fmt.Sprintf("%s:%d", file, line)
+	}
+	return c.provide(constructor, "", source)
 }
 
 // Invoke 调用函数并注入其依赖
+// 依赖未注册时返回 *NotProvidedError，包含缺少的类型与名称相近的已注册类型
 func (c *Container) Invoke(function interface{}, opts ...dig.InvokeOption) error {
-	return c.container.Invoke(function, opts...)
+	err := c.container.Invoke(function, opts...)
+	if err == nil || !strings.Contains(err.Error(), "missing type") {
+		return err
+	}
+	if missing := c.missingFor(function); missing != nil {
+		missing.Err = err
+		return missing
+	}
+	return err
 }
 
 // Extract 从容器中提取特定类型的实例
@@ -0,0 +1,143 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotProvided 依赖的类型没有注册提供者
+	ErrNotProvided = errors.New("依赖未注册")
+
+	// ErrCycle 提供者之间存在循环依赖
+	ErrCycle = errors.New("循环依赖")
+
+	// ErrDuplicateProvider 同一类型注册了多个提供者
+	ErrDuplicateProvider = errors.New("重复注册依赖")
+)
+
+// NotProvidedError 缺少提供者的错误，可通过 errors.Is(err, ErrNotProvided) 判断
+type NotProvidedError struct {
+	// Type 缺少的类型，命名依赖形如 *pkg.T[name = "x"]
+	Type string
+	// Requester 依赖该类型的函数或类型
+	Requester string
+	// Suggestions 名称相近的已注册类型，按相似度排序
+	Suggestions []string
+	// Err 容器返回的原始错误，启动检查时为空
+	Err error
+}
+
+// Error 实现error接口
+func (e *NotProvidedError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "未注册类型 %s 的提供者", e.Type)
+	if e.Requester != "" {
+		fmt.Fprintf(&b, "（%s 依赖该类型）", e.Requester)
+	}
+	if len(e.Suggestions) > 0 {
+		fmt.Fprintf(&b, "，是否想要: %s", strings.Join(e.Suggestions, ", "))
+	}
+	return b.String()
+}
+
+// Is 判断是否为 ErrNotProvided
+func (e *NotProvidedError) Is(target error) bool {
+	return target == ErrNotProvided
+}
+
+// Unwrap 返回原始错误
+func (e *NotProvidedError) Unwrap() error {
+	return e.Err
+}
+
+// CycleError 循环依赖错误，可通过 errors.Is(err, ErrCycle) 判断
+type CycleError struct {
+	// Path 循环路径，首尾为同一类型，例如 [*A *B *A]
+	Path []string
+	// Err 容器返回的原始错误
+	Err error
+}
+
+// Error 实现error接口
+func (e *CycleError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("检测到循环依赖: %v", e.Err)
+	}
+	return "检测到循环依赖: " + strings.Join(e.Path, " -> ")
+}
+
+// Is 判断是否为 ErrCycle
+func (e *CycleError) Is(target error) bool {
+	return target == ErrCycle
+}
+
+// Unwrap 返回原始错误
+func (e *CycleError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyError 依赖检查发现的全部问题
+type VerifyError struct {
+	Errors []error
+}
+
+// Error 实现error接口，每个问题一行
+func (e *VerifyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "依赖检查发现 %d 个问题:", len(e.Errors))
+	for _, err := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap 返回全部问题，支持 errors.Is 与 errors.As
+func (e *VerifyError) Unwrap() []error {
+	return e.Errors
+}
+
+// Constructor 提供者的名称与源码位置
+type Constructor struct {
+	// Name 构造函数的完整名称，ProvideValue 注册的值形如 value(*pkg.T)
+	Name string
+	// Location 构造函数定义的位置，ProvideValue 为调用位置，形如 /path/file.go:42
+	Location string
+}
+
+// String 返回名称与位置
+func (c Constructor) String() string {
+	if c.Location == "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s (%s)", c.Name, c.Location)
+}
+
+// DuplicateProviderError 后注册的提供者产出了已注册的类型，可通过 errors.Is(err, ErrDuplicateProvider) 判断
+type DuplicateProviderError struct {
+	// Type 重复的类型，命名依赖形如 *pkg.T[name = "x"]
+	Type string
+	// Previous 先注册且生效的提供者
+	Previous Constructor
+	// Current 被拒绝的提供者
+	Current Constructor
+	// Err 容器返回的原始错误
+	Err error
+}
+
+// Error 实现error接口
+func (e *DuplicateProviderError) Error() string {
+	return fmt.Sprintf("类型 %s 重复注册: %s 与先注册的 %s 冲突", e.Type, e.Current, e.Previous)
+}
+
+// Is 判断是否为 ErrDuplicateProvider
+func (e *DuplicateProviderError) Is(target error) bool {
+	return target == ErrDuplicateProvider
+}
+
+// Unwrap 返回原始错误
+func (e *DuplicateProviderError) Unwrap() error {
+	return e.Err
+}
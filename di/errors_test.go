@@ -0,0 +1,176 @@
+package di_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/di"
+	"go.uber.org/dig"
+)
+
+type userRepository struct{}
+
+type userService struct{ repo *userRepository }
+
+type orderService struct{}
+
+type mailer interface{ Send() }
+
+func TestInvoke_NotProvidedWithSuggestions(t *testing.T) {
+	c := di.New()
+	require.NoError(t, c.Provide(func() *userService { return &userService{} }))
+	require.NoError(t, c.Provide(func() *orderService { return &orderService{} }))
+
+	err := c.Invoke(func(svc *userServices) {})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, di.ErrNotProvided)
+
+	var missing *di.NotProvidedError
+	require.True(t, errors.As(err, &missing))
+	assert.Equal(t, "*di_test.userServices", missing.Type)
+	assert.Equal(t, []string{"*di_test.userService"}, missing.Suggestions)
+	assert.Contains(t, err.Error(), "是否想要: *di_test.userService")
+}
+
+type userServices struct{}
+
+type smtpMailer struct{}
+
+func (*smtpMailer) Send() {}
+
+func TestInvoke_SuggestsImplementationForInterface(t *testing.T) {
+	c := di.New()
+	require.NoError(t, c.Provide(func() *smtpMailer { return &smtpMailer{} }))
+	require.NoError(t, c.Provide(func() *userService { return &userService{} }))
+
+	var missing *di.NotProvidedError
+	require.True(t, errors.As(c.Invoke(func(mailer) {}), &missing))
+	assert.Equal(t, []string{"*di_test.smtpMailer"}, missing.Suggestions)
+}
+
+func TestInvoke_TransitiveMissingNamesRequester(t *testing.T) {
+	c := di.New()
+	require.NoError(t, c.Provide(func(repo *userRepository) *userService { return &userService{repo: repo} }))
+
+	err := c.Invoke(func(svc *userService) {})
+	var missing *di.NotProvidedError
+	require.True(t, errors.As(err, &missing))
+	assert.Equal(t, "*di_test.userRepository", missing.Type)
+	assert.Contains(t, missing.Requester, "TestInvoke_TransitiveMissingNamesRequester")
+	assert.Empty(t, missing.Suggestions, "名称差距过大的类型不作为建议")
+}
+
+func TestInvoke_FunctionErrorUnchanged(t *testing.T) {
+	c := di.New()
+	sentinel := errors.New("missing type: 业务错误")
+	err := c.Invoke(func() error { return sentinel })
+	assert.Same(t, sentinel, err)
+}
+
+type cycleA struct{}
+type cycleB struct{}
+type cycleC struct{}
+
+func TestProvide_CyclePath(t *testing.T) {
+	c := di.New()
+	require.NoError(t, c.Provide(func(*cycleB) *cycleA { return &cycleA{} }))
+	require.NoError(t, c.Provide(func(*cycleC) *cycleB { return &cycleB{} }))
+
+	err := c.Provide(func(*cycleA) *cycleC { return &cycleC{} })
+	require.Error(t, err)
+	assert.ErrorIs(t, err, di.ErrCycle)
+	assert.True(t, dig.IsCycleDetected(err), "原始错误可继续交给 dig 判断")
+
+	var cycle *di.CycleError
+	require.True(t, errors.As(err, &cycle))
+	assert.Equal(t, []string{"*di_test.cycleC", "*di_test.cycleA", "*di_test.cycleB", "*di_test.cycleC"}, cycle.Path)
+	assert.Contains(t, err.Error(), "*di_test.cycleC -> *di_test.cycleA")
+}
+
+type verifyParams struct {
+	dig.In
+
+	Users  *userService
+	Mailer mailer        `optional:"true"`
+	Audit  *orderService `name:"audit"`
+}
+
+func TestVerify_ReportsAllMissing(t *testing.T) {
+	c := di.New()
+	require.NoError(t, c.Provide(func(repo *userRepository) *userService { return &userService{repo: repo} }))
+	require.NoError(t, c.ProvideNamed(func() *orderService { return &orderService{} }, "reports"))
+
+	constructed := false
+	require.NoError(t, c.Provide(func() *cycleA { constructed = true; return &cycleA{} }))
+
+	err := c.Verify(func(verifyParams) {}, func(*cycleA, *userRepository) {})
+	require.Error(t, err)
+	assert.False(t, constructed, "检查不调用构造函数")
+
+	var verify *di.VerifyError
+	require.True(t, errors.As(err, &verify))
+	require.Len(t, verify.Errors, 2, "可选依赖不报告，同一类型只报告一次")
+	assert.ErrorIs(t, err, di.ErrNotProvided)
+	assert.Contains(t, verify.Errors[0].Error(), "*di_test.userRepository")
+	assert.Contains(t, verify.Errors[1].Error(), `*di_test.orderService[name = "audit"]`)
+	assert.Contains(t, verify.Errors[1].Error(), `*di_test.orderService[name = "reports"]`)
+
+	require.NoError(t, c.Provide(func() *userRepository { return &userRepository{} }))
+	require.NoError(t, c.ProvideNamed(func() *orderService { return &orderService{} }, "audit"))
+	assert.NoError(t, c.Verify(func(verifyParams) {}))
+}
+
+// nextLine 返回调用位置下一行的 file:line
+func nextLine() string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("%s:%d", file, line+1)
+}
+
+func TestProvide_DuplicateWarns(t *testing.T) {
+	c := di.New()
+	var warned []*di.DuplicateProviderError
+	c.OnDuplicate(func(err *di.DuplicateProviderError) { warned = append(warned, err) })
+
+	first := nextLine()
+	require.NoError(t, c.Provide(func() *userService { return &userService{repo: &userRepository{}} }))
+	second := nextLine()
+	require.NoError(t, c.Provide(func() *userService { return &userService{} }), "非严格模式不返回错误")
+
+	require.Len(t, warned, 1)
+	dup := warned[0]
+	assert.Equal(t, "*di_test.userService", dup.Type)
+	assert.Equal(t, first, dup.Previous.Location)
+	assert.Equal(t, second, dup.Current.Location)
+	assert.Contains(t, dup.Previous.Name, "TestProvide_DuplicateWarns.func")
+	assert.Equal(t, warned, c.Duplicates())
+
+	// 先注册的提供者生效
+	require.NoError(t, c.Invoke(func(svc *userService) { assert.NotNil(t, svc.repo) }))
+}
+
+func TestProvide_DuplicateStrict(t *testing.T) {
+	c := di.New()
+	c.SetStrict(true)
+	require.NoError(t, c.ProvideNamed(func() *orderService { return &orderService{} }, "audit"))
+	require.NoError(t, c.ProvideNamed(func() *orderService { return &orderService{} }, "reports"), "名称不同不算重复")
+
+	err := c.ProvideNamed(func() *orderService { return &orderService{} }, "audit")
+	assert.ErrorIs(t, err, di.ErrDuplicateProvider)
+	var dup *di.DuplicateProviderError
+	require.True(t, errors.As(err, &dup))
+	assert.Equal(t, `*di_test.orderService[name = "audit"]`, dup.Type)
+
+	// 值的位置为调用位置
+	require.NoError(t, c.ProvideValue(&userRepository{}))
+	location := nextLine()
+	err = c.ProvideValue(&userRepository{})
+	require.True(t, errors.As(err, &dup))
+	assert.Equal(t, "value(*di_test.userRepository)", dup.Current.Name)
+	assert.Equal(t, location, dup.Current.Location)
+	assert.Contains(t, err.Error(), location)
+	assert.Len(t, c.Duplicates(), 2)
+}
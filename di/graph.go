@@ -0,0 +1,348 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"go.uber.org/dig"
+)
+
+// maxSuggestions 缺少类型时最多给出的相近类型数量
+const maxSuggestions = 3
+
+var (
+	inType    = reflect.TypeOf(dig.In{})
+	outType   = reflect.TypeOf(dig.Out{})
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// dependency 函数的一个依赖
+type dependency struct {
+	key      string
+	optional bool
+}
+
+// providerInfo 已注册的提供者，用于生成错误信息与启动检查
+type providerInfo struct {
+	name     string
+	location string
+	inputs   []dependency
+}
+
+// Requirement 一组需要检查的依赖，用于无法直接以函数表示的依赖方
+type Requirement struct {
+	// Requester 依赖方名称，出现在错误信息中
+	Requester string
+	// Types 依赖的类型
+	Types []reflect.Type
+}
+
+// record 记录提供者的依赖与产出
+func (c *Container) record(name, location string, inputs []dependency, outputs []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.provided == nil {
+		c.provided = make(map[string]*providerInfo)
+	}
+	info := &providerInfo{name: name, location: location, inputs: inputs}
+	for _, out := range outputs {
+		c.provided[out] = info
+	}
+}
+
+// Verify 检查函数或 Requirement 的全部依赖（包括间接依赖）是否都已注册，一次返回所有缺少的类型
+// 只检查类型是否有提供者，不会调用任何构造函数；通过 Dig() 直接注册的提供者不在检查范围内
+func (c *Container) Verify(targets ...interface{}) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var errs []error
+	reported := make(map[string]bool)
+	visited := make(map[string]bool)
+	for _, target := range targets {
+		var deps []dependency
+		var requester string
+		switch t := target.(type) {
+		case Requirement:
+			for _, typ := range t.Types {
+				deps = appendParam(deps, typ)
+			}
+			requester = t.Requester
+		default:
+			ft := reflect.TypeOf(target)
+			if ft == nil || ft.Kind() != reflect.Func {
+				continue
+			}
+			deps, requester = inputsOf(ft), funcName(target)
+		}
+		c.walk(deps, requester, visited, func(missing *NotProvidedError) bool {
+			if !reported[missing.Type] {
+				reported[missing.Type] = true
+				errs = append(errs, missing)
+			}
+			return true
+		})
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &VerifyError{Errors: errs}
+}
+
+// missingFor 查找函数依赖链上第一个缺少的类型，找不到时返回 nil
+func (c *Container) missingFor(function interface{}) *NotProvidedError {
+	ft := reflect.TypeOf(function)
+	if ft == nil || ft.Kind() != reflect.Func {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var found *NotProvidedError
+	c.walk(inputsOf(ft), funcName(function), make(map[string]bool), func(missing *NotProvidedError) bool {
+		found = missing
+		return false
+	})
+	return found
+}
+
+// walk 深度优先遍历依赖，对每个缺少的类型调用 report，report 返回 false 时停止
+func (c *Container) walk(deps []dependency, requester string, visited map[string]bool, report func(*NotProvidedError) bool) bool {
+	for _, dep := range deps {
+		provider, ok := c.provided[dep.key]
+		if !ok {
+			if dep.optional {
+				continue
+			}
+			missing := &NotProvidedError{Type: dep.key, Requester: requester, Suggestions: c.suggest(dep.key)}
+			if !report(missing) {
+				return false
+			}
+			continue
+		}
+		if visited[dep.key] {
+			continue
+		}
+		visited[dep.key] = true
+		if !c.walk(provider.inputs, provider.name, visited, report) {
+			return false
+		}
+	}
+	return true
+}
+
+// cyclePath 计算新构造函数引入的循环路径，找不到时返回 nil
+func (c *Container) cyclePath(constructor interface{}, name string) []string {
+	ft := reflect.TypeOf(constructor)
+	if ft == nil || ft.Kind() != reflect.Func {
+		return nil
+	}
+	targets := make(map[string]bool)
+	for _, out := range outputsOf(ft, name) {
+		targets[out] = true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	visited := make(map[string]bool)
+	var path []string
+	var search func(deps []dependency) bool
+	search = func(deps []dependency) bool {
+		for _, dep := range deps {
+			path = append(path, dep.key)
+			if targets[dep.key] {
+				return true
+			}
+			if provider, ok := c.provided[dep.key]; ok && !visited[dep.key] {
+				visited[dep.key] = true
+				if search(provider.inputs) {
+					return true
+				}
+			}
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	if !search(inputsOf(ft)) {
+		return nil
+	}
+	return append([]string{path[len(path)-1]}, path...)
+}
+
+// suggest 返回名称与缺少类型相近的已注册类型：编辑距离较小（拼写错误、单复数、指针与包名不同），
+// 或一方名称包含另一方（缩写、接口与实现）
+func (c *Container) suggest(missing string) []string {
+	want := baseName(missing)
+	limit := len(want) / 4
+	if limit < 2 {
+		limit = 2
+	}
+
+	type candidate struct {
+		key      string
+		distance int
+	}
+	var candidates []candidate
+	for key := range c.provided {
+		name := baseName(key)
+		distance := levenshtein(want, name)
+		if distance <= limit || contains(want, name) {
+			candidates = append(candidates, candidate{key: key, distance: distance})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].key < candidates[j].key
+	})
+
+	var result []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		result = append(result, candidates[i].key)
+	}
+	return result
+}
+
+// inputsOf 解析函数的依赖，展开 dig.In 参数对象，忽略值组与可变参数
+func inputsOf(ft reflect.Type) []dependency {
+	var deps []dependency
+	n := ft.NumIn()
+	if ft.IsVariadic() {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		deps = appendParam(deps, ft.In(i))
+	}
+	return deps
+}
+
+// appendParam 追加一个参数的依赖
+func appendParam(deps []dependency, t reflect.Type) []dependency {
+	if !dig.IsIn(t) {
+		return append(deps, dependency{key: keyOf(t, "")})
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type == inType || field.Tag.Get("group") != "" {
+			continue
+		}
+		if dig.IsIn(field.Type) {
+			deps = appendParam(deps, field.Type)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		deps = append(deps, dependency{
+			key:      keyOf(field.Type, field.Tag.Get("name")),
+			optional: field.Tag.Get("optional") == "true",
+		})
+	}
+	return deps
+}
+
+// outputsOf 解析构造函数的产出，展开 dig.Out 结果对象
+func outputsOf(ft reflect.Type, name string) []string {
+	var outputs []string
+	var appendResult func(t reflect.Type, name string)
+	appendResult = func(t reflect.Type, name string) {
+		if !dig.IsOut(t) {
+			outputs = append(outputs, keyOf(t, name))
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Type == outType || field.Tag.Get("group") != "" || field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
+			appendResult(field.Type, field.Tag.Get("name"))
+		}
+	}
+	for i := 0; i < ft.NumOut(); i++ {
+		if ft.Out(i) != errorType {
+			appendResult(ft.Out(i), name)
+		}
+	}
+	return outputs
+}
+
+// keyOf 生成类型在容器中的键，与 dig 的展示形式一致
+func keyOf(t reflect.Type, name string) string {
+	if name == "" {
+		return t.String()
+	}
+	return fmt.Sprintf("%v[name = %q]", t, name)
+}
+
+// baseName 去掉指针、切片、包名与名称，得到用于比较的小写类型名
+func baseName(key string) string {
+	if i := strings.Index(key, "[name = "); i > 0 {
+		key = key[:i]
+	}
+	key = strings.TrimLeft(key, "*[]")
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+	return strings.ToLower(key)
+}
+
+// contains 判断较短的名称是否为较长名称的一部分，过短的名称不参与比较
+func contains(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return len(a) >= 4 && strings.Contains(b, a)
+}
+
+// levenshtein 计算编辑距离
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// funcName 返回函数的完整名称
+func funcName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return fmt.Sprintf("%T", fn)
+	}
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		return f.Name()
+	}
+	return v.Type().String()
+}
+
+// funcLocation 返回函数定义的位置
+func funcLocation(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return ""
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return ""
+	}
+	file, line := f.FileLine(f.Entry())
+	return fmt.Sprintf("%s:%d", file, line)
+}
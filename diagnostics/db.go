@@ -0,0 +1,19 @@
+package diagnostics
+
+import (
+	"time"
+
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/gorm"
+)
+
+// WatchSlowQueries 将连接上的慢查询写入诊断收集器，c 为nil时使用全局收集器
+func WatchSlowQueries(conn *gorm.DB, connName string, threshold time.Duration, c *Collector) error {
+	return db.OnSlowQuery(conn, connName, threshold, func(q db.SlowQuery) {
+		collector := c
+		if collector == nil {
+			collector = Default()
+		}
+		collector.RecordSlowQuery(q.Conn, q.SQL, q.Duration)
+	})
+}
@@ -0,0 +1,268 @@
+// Package diagnostics 在进程内保留最近的错误与慢请求，便于没有日志聚合系统时快速排查问题
+package diagnostics
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/timing"
+)
+
+// Kind 记录类型
+type Kind string
+
+// 记录类型常量
+const (
+	// KindError 错误请求（5xx或处理中记录的错误）
+	KindError Kind = "error"
+	// KindPanic 恢复中间件捕获的panic
+	KindPanic Kind = "panic"
+	// KindSlowRequest 慢请求
+	KindSlowRequest Kind = "slow_request"
+	// KindSlowQuery 慢查询
+	KindSlowQuery Kind = "slow_query"
+)
+
+// Entry 一条诊断记录
+type Entry struct {
+	Seq       uint64        `json:"seq"`
+	Time      time.Time     `json:"time"`
+	Kind      Kind          `json:"kind"`
+	Method    string        `json:"method,omitempty"`
+	Route     string        `json:"route,omitempty"`
+	Path      string        `json:"path,omitempty"`
+	Status    int           `json:"status,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+
+	// Timing 按层的耗时，请求启用 middleware.ServerTiming 时由日志中间件写入
+	Timing []timing.Entry `json:"timing,omitempty"`
+}
+
+// Config 诊断收集器配置
+type Config struct {
+	// ErrorSize 保留的错误记录数
+	ErrorSize int
+
+	// SlowSize 保留的慢请求与慢查询记录数
+	SlowSize int
+
+	// SlowThreshold 慢请求阈值
+	SlowThreshold time.Duration
+
+	// MaxMessage 路径与错误信息的最大字节数，超出部分截断
+	MaxMessage int
+
+	// Redact 脱敏函数，应用于路径与错误信息，为nil时使用 Redact
+	Redact func(string) string
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		ErrorSize:     100,
+		SlowSize:      50,
+		SlowThreshold: time.Second,
+		MaxMessage:    256,
+		Redact:        Redact,
+	}
+}
+
+// LoadConfig 从配置加载诊断设置
+//
+//	diagnostics:
+//	  error_size: 100
+//	  slow_size: 50
+//	  slow_threshold: 1s
+//	  max_message: 256
+func LoadConfig(configManager *config.ConfigManager) Config {
+	cfg := DefaultConfig()
+	if size := configManager.GetInt("diagnostics.error_size"); size > 0 {
+		cfg.ErrorSize = size
+	}
+	if size := configManager.GetInt("diagnostics.slow_size"); size > 0 {
+		cfg.SlowSize = size
+	}
+	if threshold := configManager.GetDuration("diagnostics.slow_threshold"); threshold > 0 {
+		cfg.SlowThreshold = threshold
+	}
+	if size := configManager.GetInt("diagnostics.max_message"); size > 0 {
+		cfg.MaxMessage = size
+	}
+	return cfg
+}
+
+// Collector 诊断收集器，内存占用由缓冲区容量与 MaxMessage 决定
+type Collector struct {
+	config Config
+	errors *Ring
+	slow   *Ring
+}
+
+// New 创建诊断收集器
+func New(cfg Config) *Collector {
+	defaults := DefaultConfig()
+	if cfg.ErrorSize <= 0 {
+		cfg.ErrorSize = defaults.ErrorSize
+	}
+	if cfg.SlowSize <= 0 {
+		cfg.SlowSize = defaults.SlowSize
+	}
+	if cfg.SlowThreshold <= 0 {
+		cfg.SlowThreshold = defaults.SlowThreshold
+	}
+	if cfg.MaxMessage <= 0 {
+		cfg.MaxMessage = defaults.MaxMessage
+	}
+	if cfg.Redact == nil {
+		cfg.Redact = Redact
+	}
+
+	return &Collector{
+		config: cfg,
+		errors: NewRing(cfg.ErrorSize),
+		slow:   NewRing(cfg.SlowSize),
+	}
+}
+
+// RecordRequest 记录一次请求：5xx或带错误信息的请求进入错误缓冲区，超过阈值的请求进入慢请求缓冲区
+func (c *Collector) RecordRequest(entry Entry) {
+	isError := entry.Status >= 500 || entry.Error != ""
+	isSlow := entry.Duration >= c.config.SlowThreshold
+	if !isError && !isSlow {
+		return
+	}
+
+	entry = c.sanitize(entry)
+	if isError {
+		errEntry := entry
+		if errEntry.Kind == "" {
+			errEntry.Kind = KindError
+		}
+		c.errors.Push(errEntry)
+	}
+	if isSlow {
+		entry.Kind = KindSlowRequest
+		c.slow.Push(entry)
+	}
+}
+
+// RecordSlowRequest 只在请求超过慢请求阈值时记录到慢请求缓冲区
+func (c *Collector) RecordSlowRequest(entry Entry) {
+	if entry.Duration < c.config.SlowThreshold {
+		return
+	}
+	entry.Kind = KindSlowRequest
+	c.slow.Push(c.sanitize(entry))
+}
+
+// RecordPanic 记录恢复中间件捕获的panic
+func (c *Collector) RecordPanic(entry Entry) {
+	entry.Kind = KindPanic
+	c.errors.Push(c.sanitize(entry))
+}
+
+// RecordSlowQuery 记录慢查询，conn 为连接名称
+func (c *Collector) RecordSlowQuery(conn, sql string, duration time.Duration) {
+	c.slow.Push(c.sanitize(Entry{
+		Time:     time.Now(),
+		Kind:     KindSlowQuery,
+		Route:    conn,
+		Path:     sql,
+		Duration: duration,
+	}))
+}
+
+// RecentErrors 返回最近的错误记录，从旧到新
+func (c *Collector) RecentErrors() []Entry {
+	return c.errors.Snapshot()
+}
+
+// RecentSlowRequests 返回最近的慢请求与慢查询记录，从旧到新
+func (c *Collector) RecentSlowRequests() []Entry {
+	return c.slow.Snapshot()
+}
+
+// Errors 返回错误缓冲区
+func (c *Collector) Errors() *Ring {
+	return c.errors
+}
+
+// Slow 返回慢请求缓冲区
+func (c *Collector) Slow() *Ring {
+	return c.slow
+}
+
+// SlowThreshold 返回慢请求阈值
+func (c *Collector) SlowThreshold() time.Duration {
+	return c.config.SlowThreshold
+}
+
+// sanitize 脱敏并截断记录中的文本字段
+func (c *Collector) sanitize(entry Entry) Entry {
+	entry.Path = truncate(c.config.Redact(entry.Path), c.config.MaxMessage)
+	entry.Error = truncate(c.config.Redact(entry.Error), c.config.MaxMessage)
+	entry.Route = truncate(entry.Route, c.config.MaxMessage)
+	entry.RequestID = truncate(entry.RequestID, 64)
+	return entry
+}
+
+// truncate 按字节数截断并复制字符串，避免引用原始的大块内存
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return strings.Clone(s)
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return strings.Clone(s[:cut]) + "..."
+}
+
+// 脱敏规则，与请求记录中间件的敏感字段保持一致
+var (
+	sensitiveParamPattern = regexp.MustCompile(`(?i)([\w.-]*(?:password|token|secret|key|auth|credit|card)[\w.-]*)(=|:\s*|"\s*:\s*")([^&\s",]+)`)
+	bearerPattern         = regexp.MustCompile(`(?i)(bearer|basic)\s+[\w\-.~+/=]+`)
+)
+
+// Redact 遮盖文本中敏感参数的值，如 ?token=abc、password: xxx 与 Bearer 凭证
+func Redact(s string) string {
+	if s == "" {
+		return s
+	}
+	s = bearerPattern.ReplaceAllString(s, "$1 ***MASKED***")
+	return sensitiveParamPattern.ReplaceAllString(s, "$1$2***MASKED***")
+}
+
+var defaultCollector atomic.Pointer[Collector]
+
+func init() {
+	defaultCollector.Store(New(DefaultConfig()))
+}
+
+// Default 返回全局诊断收集器
+func Default() *Collector {
+	return defaultCollector.Load()
+}
+
+// SetDefault 替换全局诊断收集器
+func SetDefault(c *Collector) {
+	if c != nil {
+		defaultCollector.Store(c)
+	}
+}
+
+// RecentErrors 返回全局收集器中最近的错误记录
+func RecentErrors() []Entry {
+	return Default().RecentErrors()
+}
+
+// RecentSlowRequests 返回全局收集器中最近的慢请求记录
+func RecentSlowRequests() []Entry {
+	return Default().RecentSlowRequests()
+}
@@ -0,0 +1,137 @@
+package diagnostics
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRing_Wraparound(t *testing.T) {
+	ring := NewRing(3)
+	assert.Empty(t, ring.Snapshot())
+
+	for i := 1; i <= 5; i++ {
+		require.True(t, ring.Push(Entry{Status: i}))
+	}
+
+	// 只保留最近3条，按写入顺序排列
+	entries := ring.Snapshot()
+	require.Len(t, entries, 3)
+	assert.Equal(t, []int{3, 4, 5}, statuses(entries))
+	assert.Equal(t, []uint64{3, 4, 5}, seqs(entries))
+
+	assert.Equal(t, []int{5}, statuses(ring.Since(4)))
+	assert.Empty(t, ring.Since(5))
+	// 已被覆盖的序号从最旧的记录开始返回
+	assert.Equal(t, []int{3, 4, 5}, statuses(ring.Since(1)))
+}
+
+func TestRing_ConcurrentWriters(t *testing.T) {
+	ring := NewRing(64)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	pushed := 0
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := 0
+			for i := 0; i < 1000; i++ {
+				if ring.Push(Entry{Status: i}) {
+					n++
+				}
+				_ = ring.Snapshot()
+			}
+			mu.Lock()
+			pushed += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// 写入与丢弃的数量之和等于总写入次数
+	assert.Equal(t, uint64(8000), uint64(pushed)+ring.Dropped())
+	entries := ring.Snapshot()
+	assert.Len(t, entries, 64)
+	for i := 1; i < len(entries); i++ {
+		assert.Equal(t, entries[i-1].Seq+1, entries[i].Seq)
+	}
+}
+
+func TestCollector_TruncateAndRedact(t *testing.T) {
+	c := New(Config{MaxMessage: 16, SlowThreshold: time.Second})
+
+	c.RecordRequest(Entry{
+		Method: "GET",
+		Path:   "/login?token=abc123&page=2",
+		Status: 500,
+		Error:  "数据库连接失败：" + strings.Repeat("x", 100),
+	})
+	errs := c.RecentErrors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, KindError, errs[0].Kind)
+	assert.LessOrEqual(t, len(errs[0].Error), 16+len("..."))
+	assert.True(t, strings.HasPrefix(errs[0].Error, "数据库连接"), "应在字符边界截断")
+	assert.NotContains(t, errs[0].Path, "abc123")
+
+	assert.Equal(t, "/login?token=***MASKED***&page=2", Redact("/login?token=abc123&page=2"))
+	assert.NotContains(t, Redact("Authorization: Bearer eyJhbGciOi"), "eyJhbGciOi")
+	assert.Equal(t, "upstream said: Bearer ***MASKED***", Redact("upstream said: Bearer eyJhbGciOi"))
+	assert.Equal(t, `{"password":"***MASKED***"}`, Redact(`{"password":"hunter2"}`))
+}
+
+func TestCollector_RecordRequest(t *testing.T) {
+	c := New(Config{SlowThreshold: 100 * time.Millisecond})
+
+	c.RecordRequest(Entry{Path: "/ok", Status: 200, Duration: time.Millisecond})
+	c.RecordRequest(Entry{Path: "/slow", Status: 200, Duration: time.Second})
+	c.RecordRequest(Entry{Path: "/fail", Status: 502, Duration: time.Second})
+	c.RecordSlowQuery("default", "SELECT * FROM users WHERE name = ?", time.Second)
+
+	assert.Equal(t, []string{"/fail"}, paths(c.RecentErrors()))
+	slow := c.RecentSlowRequests()
+	assert.Equal(t, []string{"/slow", "/fail", "SELECT * FROM users WHERE name = ?"}, paths(slow))
+	assert.Equal(t, KindSlowQuery, slow[2].Kind)
+}
+
+func BenchmarkCollector_RecordRequest(b *testing.B) {
+	c := New(DefaultConfig())
+	entry := Entry{Method: "GET", Path: "/users/1", Status: 500, Error: fmt.Sprint("boom")}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.RecordRequest(entry)
+		}
+	})
+}
+
+func statuses(entries []Entry) []int {
+	out := make([]int, len(entries))
+	for i, e := range entries {
+		out[i] = e.Status
+	}
+	return out
+}
+
+func seqs(entries []Entry) []uint64 {
+	out := make([]uint64, len(entries))
+	for i, e := range entries {
+		out[i] = e.Seq
+	}
+	return out
+}
+
+func paths(entries []Entry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Path
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package diagnostics
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Ring 固定容量的环形缓冲区，槽位在创建时预分配，写满后覆盖最旧的记录
+//
+// 写入使用 TryLock，锁被占用时直接丢弃本条记录并计数，保证请求路径上不会阻塞
+type Ring struct {
+	mu      sync.Mutex
+	slots   []Entry
+	written uint64
+	dropped atomic.Uint64
+}
+
+// NewRing 创建指定容量的环形缓冲区，size 小于1时按1处理
+func NewRing(size int) *Ring {
+	if size < 1 {
+		size = 1
+	}
+	return &Ring{slots: make([]Entry, size)}
+}
+
+// Push 写入一条记录并分配序号，锁竞争时丢弃并返回false
+func (r *Ring) Push(entry Entry) bool {
+	if !r.mu.TryLock() {
+		r.dropped.Add(1)
+		return false
+	}
+	r.written++
+	entry.Seq = r.written
+	r.slots[(r.written-1)%uint64(len(r.slots))] = entry
+	r.mu.Unlock()
+	return true
+}
+
+// Since 返回序号大于 seq 的记录，按写入顺序从旧到新排列
+func (r *Ring) Since(seq uint64) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	size := uint64(len(r.slots))
+	first := uint64(1)
+	if r.written > size {
+		first = r.written - size + 1
+	}
+	if seq+1 > first {
+		first = seq + 1
+	}
+	if first > r.written {
+		return []Entry{}
+	}
+
+	entries := make([]Entry, 0, r.written-first+1)
+	for s := first; s <= r.written; s++ {
+		entries = append(entries, r.slots[(s-1)%size])
+	}
+	return entries
+}
+
+// Snapshot 返回缓冲区中的全部记录，按写入顺序从旧到新排列
+func (r *Ring) Snapshot() []Entry {
+	return r.Since(0)
+}
+
+// Cap 返回缓冲区容量
+func (r *Ring) Cap() int {
+	return len(r.slots)
+}
+
+// Dropped 返回因锁竞争丢弃的记录数
+func (r *Ring) Dropped() uint64 {
+	return r.dropped.Load()
+}
@@ -1,7 +1,6 @@
 package docs
 
 import (
-	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -635,7 +634,7 @@ func (g *APIDocGenerator) extractModels() (map[string]interface{}, error) {
 // writeDocumentation 将文档写入文件
 func (g *APIDocGenerator) writeDocumentation(doc APIDocumentation) error {
 	// JSON格式
-	jsonData, err := json.MarshalIndent(doc, "", "  ")
+	jsonData, err := marshalIndent(g.app, doc)
 	if err != nil {
 		return err
 	}
@@ -775,7 +774,7 @@ func (g *APIDocGenerator) generateMarkdown(doc APIDocumentation) (string, error)
 			if endpoint.RequestBody != nil {
 				content.WriteString("**请求体**:\n\n")
 				content.WriteString("```json\n")
-				requestJSON, _ := json.MarshalIndent(endpoint.RequestBody, "", "  ")
+				requestJSON, _ := marshalIndent(g.app, endpoint.RequestBody)
 				content.WriteString(string(requestJSON))
 				content.WriteString("\n```\n\n")
 			}
@@ -795,7 +794,7 @@ func (g *APIDocGenerator) generateMarkdown(doc APIDocumentation) (string, error)
 			if endpoint.ResponseBody != nil {
 				content.WriteString("**响应体**:\n\n")
 				content.WriteString("```json\n")
-				responseJSON, _ := json.MarshalIndent(endpoint.ResponseBody, "", "  ")
+				responseJSON, _ := marshalIndent(g.app, endpoint.ResponseBody)
 				content.WriteString(string(responseJSON))
 				content.WriteString("\n```\n\n")
 			}
@@ -812,14 +811,14 @@ func (g *APIDocGenerator) generateMarkdown(doc APIDocumentation) (string, error)
 					// 请求示例
 					content.WriteString("请求:\n\n")
 					content.WriteString("```json\n")
-					requestJSON, _ := json.MarshalIndent(example.Request, "", "  ")
+					requestJSON, _ := marshalIndent(g.app, example.Request)
 					content.WriteString(string(requestJSON))
 					content.WriteString("\n```\n\n")
 
 					// 响应示例
 					content.WriteString("响应:\n\n")
 					content.WriteString("```json\n")
-					responseJSON, _ := json.MarshalIndent(example.Response, "", "  ")
+					responseJSON, _ := marshalIndent(g.app, example.Response)
 					content.WriteString(string(responseJSON))
 					content.WriteString("\n```\n\n")
 				}
@@ -832,7 +831,7 @@ func (g *APIDocGenerator) generateMarkdown(doc APIDocumentation) (string, error)
 		content.WriteString("## 模型定义\n\n")
 		for name, model := range doc.Models {
 			content.WriteString(fmt.Sprintf("### %s\n\n", name))
-			modelJSON, _ := json.MarshalIndent(model, "", "  ")
+			modelJSON, _ := marshalIndent(g.app, model)
 			content.WriteString("```json\n")
 			content.WriteString(string(modelJSON))
 			content.WriteString("\n```\n\n")
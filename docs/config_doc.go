@@ -1,6 +1,11 @@
 package docs
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/zzliekkas/flow/v2/app"
 )
 
@@ -24,9 +29,28 @@ func (g *ConfigDocGenerator) SetOutputDir(dir string) *ConfigDocGenerator {
 	return g
 }
 
-// Generate 生成配置文档
+// Generate 生成配置文档，列出所有声明了配置段的服务提供者（app.ConfigProvider）
 func (g *ConfigDocGenerator) Generate() error {
-	// 这里是生成配置文档的实际逻辑
-	// 暂时返回nil，表示没有错误
-	return nil
+	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(g.outputDir, "providers.md"), []byte(g.Markdown()), 0644)
+}
+
+// Markdown 生成服务提供者配置段的 Markdown 文档
+func (g *ConfigDocGenerator) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# 服务提供者配置\n")
+	for _, section := range g.app.ConfigSections() {
+		fmt.Fprintf(&sb, "\n## %s\n\n服务提供者: `%s`\n\n", section.Section, section.Provider)
+		sb.WriteString("| 配置项 | 类型 | 必填 | 默认值 | 验证规则 |\n|---|---|---|---|---|\n")
+		for _, f := range section.Fields {
+			required := ""
+			if f.Required {
+				required = "是"
+			}
+			fmt.Fprintf(&sb, "| `%s.%s` | %s | %s | %s | %s |\n", section.Section, f.Key, f.Type, required, f.Default, f.Rules)
+		}
+	}
+	return sb.String()
 }
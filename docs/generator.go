@@ -2,9 +2,13 @@ package docs
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -34,9 +38,12 @@ type DocumentationGenerator struct {
 	// 文档标题
 	title string
 
-	// 生成器列表
+	// 通过 AddGenerator 添加的自定义生成器，内置生成器在每次 Generate 时重新创建
 	generators []Generator
 
+	// 生成前是否清空输出目录
+	clean bool
+
 	// 是否包含API文档
 	includeAPI bool
 
@@ -86,6 +93,42 @@ type Generator interface {
 	Generate() error
 }
 
+// GenerateReport 一次文档生成的结果
+type GenerateReport struct {
+	// Generators 按执行顺序排列的各生成器结果
+	Generators []GeneratorReport
+
+	// Cleaned 生成前删除的旧文件与目录数量
+	Cleaned int
+
+	// Duration 总耗时
+	Duration time.Duration
+}
+
+// GeneratorReport 单个生成器的结果
+type GeneratorReport struct {
+	// Name 生成器名称，实现了 Name() string 的生成器使用其返回值，否则为类型名
+	Name string
+
+	// Duration 耗时
+	Duration time.Duration
+
+	// Files 新写入或被修改的文件，为相对输出目录的路径
+	Files []string
+
+	// Err 生成错误
+	Err error
+}
+
+// Files 返回本次生成写入的全部文件
+func (r *GenerateReport) Files() []string {
+	var files []string
+	for _, gen := range r.Generators {
+		files = append(files, gen.Files...)
+	}
+	return files
+}
+
 // NewDocumentationGenerator 创建新的文档生成器
 func NewDocumentationGenerator(application *app.Application) *DocumentationGenerator {
 	return &DocumentationGenerator{
@@ -228,46 +271,177 @@ func (g *DocumentationGenerator) SetGoogleAnalyticsID(id string) *DocumentationG
 	return g
 }
 
-// AddGenerator 添加自定义生成器
+// SetClean 设置生成前是否清空输出目录，用于删除上次生成遗留的文件
+func (g *DocumentationGenerator) SetClean(clean bool) *DocumentationGenerator {
+	g.clean = clean
+	return g
+}
+
+// AddGenerator 添加自定义生成器，在内置生成器之后按添加顺序执行
 func (g *DocumentationGenerator) AddGenerator(generator Generator) *DocumentationGenerator {
 	g.generators = append(g.generators, generator)
 	return g
 }
 
-// Generate 执行文档生成
-func (g *DocumentationGenerator) Generate() error {
-	// 创建输出目录
-	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
+// Generate 执行文档生成，可重复调用，每次调用每个生成器只执行一次
+//
+// 某个生成器失败时其余生成器仍会执行，失败信息记录在报告中，此时不生成UI
+func (g *DocumentationGenerator) Generate() (*GenerateReport, error) {
+	start := time.Now()
+	report := &GenerateReport{}
+
+	if g.clean {
+		removed, err := cleanOutputDir(g.outputDir)
+		report.Cleaned = removed
+		if err != nil {
+			return report, err
+		}
 	}
 
-	// 初始化所有生成器
-	if err := g.initGenerators(); err != nil {
-		return fmt.Errorf("初始化生成器失败: %w", err)
+	// 创建输出目录
+	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
+		return report, fmt.Errorf("创建输出目录失败: %w", err)
 	}
 
 	// 生成各类文档
-	if err := g.generateDocs(); err != nil {
-		return fmt.Errorf("生成文档失败: %w", err)
+	var errs []error
+	for _, generator := range g.initGenerators() {
+		result := g.runGenerator(generator)
+		report.Generators = append(report.Generators, result)
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Name, result.Err))
+		}
+	}
+	if len(errs) > 0 {
+		report.Duration = time.Since(start)
+		return report, fmt.Errorf("生成文档失败: %w", errors.Join(errs...))
 	}
 
 	// 生成UI
 	if g.generateUI {
-		if err := g.generateDocUI(); err != nil {
-			return fmt.Errorf("生成文档UI失败: %w", err)
+		result := g.runGenerator(uiGenerator{g})
+		report.Generators = append(report.Generators, result)
+		if result.Err != nil {
+			report.Duration = time.Since(start)
+			return report, fmt.Errorf("生成文档UI失败: %w", result.Err)
 		}
 	}
 
-	fmt.Printf("文档生成完成，输出目录: %s\n", g.outputDir)
-	if g.generateUI {
-		fmt.Printf("文档UI生成完成，访问: %s\n", filepath.Join(g.outputDir, "index.html"))
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// runGenerator 执行生成器并记录耗时与写入的文件
+func (g *DocumentationGenerator) runGenerator(generator Generator) GeneratorReport {
+	before := snapshotDir(g.outputDir)
+	start := time.Now()
+	err := generator.Generate()
+	result := GeneratorReport{Name: generatorName(generator), Duration: time.Since(start), Err: err}
+
+	for path, info := range snapshotDir(g.outputDir) {
+		if old, ok := before[path]; !ok || old != info {
+			result.Files = append(result.Files, path)
+		}
 	}
+	sort.Strings(result.Files)
+	return result
+}
 
-	return nil
+// fileState 用于判断文件是否被修改
+type fileState struct {
+	size    int64
+	modTime time.Time
 }
 
-// initGenerators 初始化所有文档生成器
-func (g *DocumentationGenerator) initGenerators() error {
+// snapshotDir 记录目录下所有文件的大小与修改时间，键为相对路径
+func snapshotDir(dir string) map[string]fileState {
+	files := make(map[string]fileState)
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		files[filepath.ToSlash(rel)] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return files
+}
+
+// generatorName 返回生成器在报告中的名称
+func generatorName(generator Generator) string {
+	if named, ok := generator.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	t := reflect.TypeOf(generator)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// cleanOutputDir 删除输出目录中的全部内容，保留目录本身
+// 输出目录为根目录、当前工作目录或其上级目录时拒绝清理，避免误删项目文件
+func cleanOutputDir(dir string) (int, error) {
+	if strings.TrimSpace(dir) == "" {
+		return 0, errors.New("未设置输出目录，拒绝清理")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, fmt.Errorf("解析输出目录失败: %w", err)
+	}
+	if filepath.Dir(abs) == abs {
+		return 0, fmt.Errorf("输出目录 %s 是根目录，拒绝清理", dir)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(abs, wd); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return 0, fmt.Errorf("输出目录 %s 包含当前工作目录，拒绝清理", dir)
+		}
+	}
+
+	entries, err := os.ReadDir(abs)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("读取输出目录失败: %w", err)
+	}
+	removed := 0
+	for _, entry := range entries {
+		// 符号链接只删除链接本身，不会删除目录外的目标
+		if err := os.RemoveAll(filepath.Join(abs, entry.Name())); err != nil {
+			return removed, fmt.Errorf("清理输出目录失败: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// uiGenerator 以生成器的形式生成文档UI，使其出现在报告中
+type uiGenerator struct {
+	g *DocumentationGenerator
+}
+
+// Name 报告中的名称
+func (u uiGenerator) Name() string {
+	return "UI"
+}
+
+// Generate 生成文档UI
+func (u uiGenerator) Generate() error {
+	return u.g.generateDocUI()
+}
+
+// initGenerators 创建本次生成使用的生成器列表：内置生成器在前，自定义生成器按添加顺序在后
+func (g *DocumentationGenerator) initGenerators() []Generator {
+	var generators []Generator
+
 	// 添加API文档生成器
 	if g.includeAPI {
 		apiGen := NewAPIDocGenerator(g.app)
@@ -276,48 +450,38 @@ func (g *DocumentationGenerator) initGenerators() error {
 		apiGen.SetDescription(fmt.Sprintf("API documentation for %s", g.projectName))
 		apiGen.SetAPIVersion(g.version)
 		apiGen.UseMarkdown(true)
-		g.generators = append(g.generators, apiGen)
+		generators = append(generators, apiGen)
 	}
 
 	// 添加模块文档生成器
 	if g.includeModules {
 		moduleGen := NewModuleDocGenerator(g.app)
 		moduleGen.SetOutputDir(filepath.Join(g.outputDir, "modules"))
-		g.generators = append(g.generators, moduleGen)
+		generators = append(generators, moduleGen)
 	}
 
 	// 添加数据库文档生成器
 	if g.includeDatabase {
 		dbGen := NewDatabaseDocGenerator(g.app)
 		dbGen.SetOutputDir(filepath.Join(g.outputDir, "database"))
-		g.generators = append(g.generators, dbGen)
+		generators = append(generators, dbGen)
 	}
 
 	// 添加CLI文档生成器
 	if g.includeCLI {
 		cliGen := NewCLIDocGenerator(g.app)
 		cliGen.SetOutputDir(filepath.Join(g.outputDir, "cli"))
-		g.generators = append(g.generators, cliGen)
+		generators = append(generators, cliGen)
 	}
 
 	// 添加配置文档生成器
 	if g.includeConfig {
 		configGen := NewConfigDocGenerator(g.app)
 		configGen.SetOutputDir(filepath.Join(g.outputDir, "config"))
-		g.generators = append(g.generators, configGen)
+		generators = append(generators, configGen)
 	}
 
-	return nil
-}
-
-// generateDocs 生成所有文档
-func (g *DocumentationGenerator) generateDocs() error {
-	for _, generator := range g.generators {
-		if err := generator.Generate(); err != nil {
-			return err
-		}
-	}
-	return nil
+	return append(generators, g.generators...)
 }
 
 // generateDocUI 生成文档UI
@@ -0,0 +1,132 @@
+package docs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fileGenerator 写入固定文件并记录执行次数
+type fileGenerator struct {
+	dir   string
+	name  string
+	calls int
+	err   error
+}
+
+func (f *fileGenerator) Generate() error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	return os.WriteFile(filepath.Join(f.dir, f.name), []byte(f.name), 0644)
+}
+
+func newTestGenerator(outputDir string) *DocumentationGenerator {
+	return NewDocumentationGenerator(nil).
+		SetOutputDir(outputDir).
+		SetUIDir(filepath.Join(outputDir, "..", "ui")).
+		EnableAPI(false).
+		EnableModules(false).
+		EnableDatabase(false).
+		EnableCLI(false).
+		EnableConfig(false)
+}
+
+func readTree(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		require.NoError(t, err)
+		if !info.IsDir() {
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			rel, _ := filepath.Rel(dir, path)
+			files[filepath.ToSlash(rel)] = string(data)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestGenerateIsIdempotent(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	first := &fileGenerator{dir: out, name: "first.md"}
+	second := &fileGenerator{dir: out, name: "second.md"}
+	g := newTestGenerator(out).EnableCLI(true).AddGenerator(first).AddGenerator(second)
+
+	report, err := g.Generate()
+	require.NoError(t, err)
+	tree := readTree(t, out)
+
+	report2, err := g.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, tree, readTree(t, out))
+	assert.Equal(t, 2, first.calls)
+	assert.Equal(t, 2, second.calls)
+
+	// 每次生成的生成器列表相同，内置在前，自定义按添加顺序在后
+	names := func(r *GenerateReport) []string {
+		var list []string
+		for _, gen := range r.Generators {
+			list = append(list, gen.Name)
+		}
+		return list
+	}
+	assert.Equal(t, []string{"CLIDocGenerator", "fileGenerator", "fileGenerator", "UI"}, names(report))
+	assert.Equal(t, names(report), names(report2))
+}
+
+func TestGenerateReport(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	g := newTestGenerator(out).EnableUI(false).
+		AddGenerator(&fileGenerator{dir: out, name: "a.md"}).
+		AddGenerator(&fileGenerator{dir: out, name: "broken.md", err: errors.New("模板错误")}).
+		AddGenerator(&fileGenerator{dir: out, name: "b.md"})
+
+	report, err := g.Generate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "模板错误")
+
+	// 失败不影响其余生成器
+	require.Len(t, report.Generators, 3)
+	assert.Equal(t, []string{"a.md"}, report.Generators[0].Files)
+	assert.Error(t, report.Generators[1].Err)
+	assert.Empty(t, report.Generators[1].Files)
+	assert.Equal(t, []string{"b.md"}, report.Generators[2].Files)
+	assert.Equal(t, []string{"a.md", "b.md"}, report.Files())
+	assert.Positive(t, report.Duration)
+}
+
+func TestGenerateClean(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, os.MkdirAll(filepath.Join(out, "old"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(out, "old", "stale.md"), []byte("stale"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(out, "stale.md"), []byte("stale"), 0644))
+
+	g := newTestGenerator(out).EnableUI(false).SetClean(true).AddGenerator(&fileGenerator{dir: out, name: "fresh.md"})
+	report, err := g.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Cleaned)
+	assert.Equal(t, map[string]string{"fresh.md": "fresh.md"}, readTree(t, out))
+}
+
+func TestCleanRefusesUnsafeDirs(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	for _, dir := range []string{"", "/", ".", "..", wd, filepath.Dir(wd)} {
+		_, err := cleanOutputDir(dir)
+		assert.Error(t, err, dir)
+	}
+
+	// 拒绝清理时不删除任何文件
+	_, err = NewDocumentationGenerator(nil).SetOutputDir("..").SetClean(true).Generate()
+	require.Error(t, err)
+	_, err = os.Stat(filepath.Join(wd, "generator.go"))
+	assert.NoError(t, err)
+}
@@ -1,7 +1,6 @@
 package docs
 
 import (
-	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -260,7 +259,7 @@ func (g *ModelDocGenerator) Generate() error {
 
 	// 输出JSON文档
 	jsonPath := filepath.Join(g.outputDir, "models.json")
-	jsonData, err := json.MarshalIndent(doc, "", "  ")
+	jsonData, err := marshalIndent(g.app, doc)
 	if err != nil {
 		return fmt.Errorf("序列化模型文档失败: %w", err)
 	}
@@ -1,11 +1,31 @@
 package docs
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
+
+	"github.com/zzliekkas/flow/v2/app"
 )
 
+// marshalIndent 按应用引擎的JSON编码配置序列化并缩进，使文档示例与接口响应的格式一致
+func marshalIndent(application *app.Application, v interface{}) ([]byte, error) {
+	if application == nil || application.Engine() == nil {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	data, err := application.Engine().JSONOptions().Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
 // CopyDir 复制目录及其所有内容
 func CopyDir(src, dst string) error {
 	// 获取源目录信息
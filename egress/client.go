@@ -0,0 +1,59 @@
+package egress
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// transport 检查协议与主机名后交给使用 Guard.DialContext 的 http.Transport
+type transport struct {
+	guard *Guard
+	base  *http.Transport
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.guard.checkURL(req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// CloseIdleConnections 关闭空闲连接
+func (t *transport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
+
+// Transport 返回检查出站请求的 RoundTripper
+//
+// 基于 http.DefaultTransport 的设置，但不使用环境变量中的代理：经过代理时连接的是代理地址，无法检查实际目标
+func (g *Guard) Transport() http.RoundTripper {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = nil
+	base.DialContext = g.DialContext
+	return &transport{guard: g, base: base}
+}
+
+// CheckRedirect 用作 http.Client.CheckRedirect，限制重定向次数并检查每一跳的协议与主机名，
+// 地址在连接时由 Transport 检查
+func (g *Guard) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if g.maxRedirects < 0 || len(via) > g.maxRedirects {
+		max := g.maxRedirects
+		if max < 0 {
+			max = 0
+		}
+		return &BlockedError{Target: RedactURL(req.URL), Reason: fmt.Sprintf("重定向次数超过 %d 次", max)}
+	}
+	return g.checkURL(req.URL)
+}
+
+// Client 返回检查出站请求的 http.Client，未设置超时，调用方按需设置 Timeout
+func (g *Guard) Client() *http.Client {
+	return &http.Client{
+		Transport:     g.Transport(),
+		CheckRedirect: g.CheckRedirect,
+	}
+}
@@ -0,0 +1,417 @@
+// Package egress 防止服务端请求伪造（SSRF）：访问用户提供的URL（webhook 目标、头像导入、CDN 刷新等）时
+// 拒绝连接回环、链路本地（含云元数据服务 169.254.169.254）、私有网络与 IPv6 本地地址
+//
+// 检查在建立连接时进行：解析主机名，所有地址都通过检查后直接连接已检查的地址，不再重新解析，
+// 防止 DNS 重绑定；每次重定向都重新检查，非 http(s) 协议一律拒绝：
+//
+//	guard, err := egress.New(egress.Config{Deny: []string{"*.internal"}})
+//	client := guard.Client()
+//	client.Timeout = 10 * time.Second
+//	resp, err := client.Get(userURL)
+//	if errors.Is(err, egress.ErrEgressBlocked) { ... }
+//
+// 需要签名等其他 RoundTripper 时以 Transport 为底层，重定向检查仍需使用 CheckRedirect：
+//
+//	client := &http.Client{Transport: signer.Transport(guard.Transport()), CheckRedirect: guard.CheckRedirect}
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/redact"
+)
+
+// DefaultMaxRedirects 默认的最大重定向次数
+const DefaultMaxRedirects = 10
+
+// ErrEgressBlocked 目标被禁止访问，具体原因见 BlockedError
+var ErrEgressBlocked = errors.New("egress: 禁止访问的目标")
+
+// BlockedError 被拒绝的请求，errors.Is(err, ErrEgressBlocked) 为 true
+type BlockedError struct {
+	// Target 被拒绝的目标，URL 已去除用户信息并对敏感查询参数脱敏，可直接记录日志
+	Target string
+	// IP 被拒绝的地址，因协议、主机名或重定向次数被拒绝时无效
+	IP netip.Addr
+	// Reason 拒绝原因
+	Reason string
+}
+
+// Error 实现 error 接口
+func (e *BlockedError) Error() string {
+	if e.IP.IsValid() && e.IP.String() != e.Target {
+		return fmt.Sprintf("egress: 禁止访问 %s（%s）: %s", e.Target, e.IP, e.Reason)
+	}
+	return fmt.Sprintf("egress: 禁止访问 %s: %s", e.Target, e.Reason)
+}
+
+// Unwrap 返回 ErrEgressBlocked
+func (e *BlockedError) Unwrap() error {
+	return ErrEgressBlocked
+}
+
+// Resolver 域名解析，*net.Resolver 实现了该接口，测试中可替换
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// Config 出站请求检查配置
+type Config struct {
+	// Allow 允许访问的网络，CIDR 或 IP，优先于 Deny 与内置的禁止地址段，如内网中的某个服务 "10.1.2.3"
+	Allow []string
+	// Deny 额外禁止访问的网络（CIDR 或 IP）或主机名，主机名不区分大小写，"*.internal" 匹配所有子域名
+	Deny []string
+	// TrustedHosts 完全跳过检查的内部主机名，格式同 Deny；New 时逐个记录警告日志，只应配置确实需要访问的内部服务
+	TrustedHosts []string
+	// MaxRedirects 最大重定向次数，为0时使用 DefaultMaxRedirects，小于0时不跟随重定向
+	MaxRedirects int
+	// Resolver 域名解析，默认 net.DefaultResolver
+	Resolver Resolver
+	// DialTimeout 建立连接的超时时间，默认30秒
+	DialTimeout time.Duration
+	// Logger 记录启动时的信任配置，默认使用 logrus 标准日志
+	Logger logrus.FieldLogger
+}
+
+// LoadConfig 从配置加载出站请求检查设置
+//
+//	egress:
+//	  allow: ["10.1.2.3"]
+//	  deny: ["*.internal", "203.0.113.0/24"]
+//	  trusted_hosts: ["billing.svc.cluster.local"]
+//	  max_redirects: 5
+//	  dial_timeout: 10s
+func LoadConfig(configManager *config.ConfigManager) Config {
+	return Config{
+		Allow:        configManager.GetStringSlice("egress.allow"),
+		Deny:         configManager.GetStringSlice("egress.deny"),
+		TrustedHosts: configManager.GetStringSlice("egress.trusted_hosts"),
+		MaxRedirects: configManager.GetInt("egress.max_redirects"),
+		DialTimeout:  configManager.GetDuration("egress.dial_timeout"),
+	}
+}
+
+// blockedRange 内置的禁止地址段
+type blockedRange struct {
+	prefix netip.Prefix
+	reason string
+}
+
+// blockedRanges 内置的禁止地址段，IPv4 映射地址先转换为 IPv4 再检查
+var blockedRanges = func() []blockedRange {
+	ranges := []struct{ cidr, reason string }{
+		{"0.0.0.0/8", "本网络地址"},
+		{"127.0.0.0/8", "回环地址"},
+		{"10.0.0.0/8", "私有网络地址"},
+		{"172.16.0.0/12", "私有网络地址"},
+		{"192.168.0.0/16", "私有网络地址"},
+		{"169.254.0.0/16", "链路本地地址（含云元数据服务）"},
+		{"100.64.0.0/10", "运营商级NAT地址"},
+		{"192.0.0.0/24", "IETF协议保留地址"},
+		{"198.18.0.0/15", "基准测试地址"},
+		{"224.0.0.0/4", "组播地址"},
+		{"240.0.0.0/4", "保留地址"},
+		{"::/128", "未指定地址"},
+		{"::1/128", "回环地址"},
+		{"fe80::/10", "链路本地地址"},
+		{"fec0::/10", "站点本地地址"},
+		{"fc00::/7", "IPv6 唯一本地地址"},
+		{"ff00::/8", "组播地址"},
+	}
+	result := make([]blockedRange, len(ranges))
+	for i, r := range ranges {
+		result[i] = blockedRange{prefix: netip.MustParsePrefix(r.cidr), reason: r.reason}
+	}
+	return result
+}()
+
+// 内嵌 IPv4 地址的 IPv6 地址段，按内嵌的 IPv4 地址检查
+var (
+	nat64Prefix  = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFourNet = netip.MustParsePrefix("2002::/16")
+)
+
+// Guard 出站请求检查，创建后不可修改，并发安全
+type Guard struct {
+	allow        []netip.Prefix
+	deny         []netip.Prefix
+	denyHosts    []string
+	trustedHosts []string
+	maxRedirects int
+	resolver     Resolver
+	dial         func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// New 创建出站请求检查，网络或主机名格式无效时返回错误
+func New(cfg Config) (*Guard, error) {
+	g := &Guard{
+		maxRedirects: cfg.MaxRedirects,
+		resolver:     cfg.Resolver,
+	}
+	if g.maxRedirects == 0 {
+		g.maxRedirects = DefaultMaxRedirects
+	}
+	if g.resolver == nil {
+		g.resolver = net.DefaultResolver
+	}
+	timeout := cfg.DialTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	g.dial = dialer.DialContext
+
+	for _, entry := range cfg.Allow {
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("egress: 无效的允许网络 %q: %w", entry, err)
+		}
+		g.allow = append(g.allow, prefix)
+	}
+	for _, entry := range cfg.Deny {
+		if prefix, err := parsePrefix(entry); err == nil {
+			g.deny = append(g.deny, prefix)
+			continue
+		}
+		host, err := parseHostPattern(entry)
+		if err != nil {
+			return nil, fmt.Errorf("egress: 无效的禁止目标 %q: %w", entry, err)
+		}
+		g.denyHosts = append(g.denyHosts, host)
+	}
+	for _, entry := range cfg.TrustedHosts {
+		host, err := parseHostPattern(entry)
+		if err != nil {
+			return nil, fmt.Errorf("egress: 无效的信任主机 %q: %w", entry, err)
+		}
+		g.trustedHosts = append(g.trustedHosts, host)
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	for _, host := range g.trustedHosts {
+		logger.WithField("host", host).Warn("egress: 信任的内部主机，访问时不做 SSRF 检查")
+	}
+	for _, prefix := range g.allow {
+		logger.WithField("network", prefix.String()).Info("egress: 允许访问的网络，优先于禁止规则")
+	}
+	return g, nil
+}
+
+// parsePrefix 解析 CIDR 或单个 IP
+func parsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// parseHostPattern 规范化主机名模式
+func parseHostPattern(s string) (string, error) {
+	host := normalizeHost(s)
+	name := strings.TrimPrefix(host, "*.")
+	if name == "" || strings.ContainsAny(name, "*/:@ ") {
+		return "", errors.New("不是有效的主机名")
+	}
+	return host, nil
+}
+
+// normalizeHost 转为小写并去除末尾的点
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// matchHost 判断主机名是否匹配模式，"*.example.com" 只匹配子域名
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPrefix 判断地址是否属于任一网络
+func matchPrefix(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// trusted 判断主机名是否被信任
+func (g *Guard) trusted(host string) bool {
+	return matchHost(g.trustedHosts, normalizeHost(host))
+}
+
+// CheckIP 检查地址，返回拒绝原因，允许访问时返回空字符串
+//
+// 顺序为 Allow、Deny、内置禁止地址段；IPv4 映射地址与 NAT64、6to4 地址按内嵌的 IPv4 地址检查
+func (g *Guard) CheckIP(addr netip.Addr) string {
+	addr = addr.WithZone("").Unmap()
+	if matchPrefix(g.allow, addr) {
+		return ""
+	}
+	if matchPrefix(g.deny, addr) {
+		return "地址在禁止列表中"
+	}
+	for _, r := range blockedRanges {
+		if r.prefix.Contains(addr) {
+			return r.reason
+		}
+	}
+	if embedded, ok := embeddedIPv4(addr); ok {
+		if reason := g.CheckIP(embedded); reason != "" {
+			return "内嵌的IPv4地址为" + reason
+		}
+	}
+	return ""
+}
+
+// embeddedIPv4 取出 NAT64 与 6to4 地址内嵌的 IPv4 地址
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	b := addr.As16()
+	switch {
+	case !addr.Is6():
+		return netip.Addr{}, false
+	case nat64Prefix.Contains(addr):
+		return netip.AddrFrom4([4]byte{b[12], b[13], b[14], b[15]}), true
+	case sixToFourNet.Contains(addr):
+		return netip.AddrFrom4([4]byte{b[2], b[3], b[4], b[5]}), true
+	}
+	return netip.Addr{}, false
+}
+
+// checkURL 检查协议与主机名，不解析域名
+func (g *Guard) checkURL(u *url.URL) error {
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return &BlockedError{Target: RedactURL(u), Reason: fmt.Sprintf("不允许的协议 %q", u.Scheme)}
+	}
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return &BlockedError{Target: RedactURL(u), Reason: "缺少主机名"}
+	}
+	if !g.trusted(host) && matchHost(g.denyHosts, host) {
+		return &BlockedError{Target: RedactURL(u), Reason: "主机名在禁止列表中"}
+	}
+	return nil
+}
+
+// Validate 检查URL的协议、主机名与解析得到的全部地址，用于保存 webhook 地址等场景的提前校验
+//
+// 通过检查不代表之后的请求一定允许，域名可能解析到其他地址，实际请求仍须使用 Client 或 Transport
+func (g *Guard) Validate(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &BlockedError{Target: "<无效的URL>", Reason: "无法解析URL"}
+	}
+	if err := g.checkURL(u); err != nil {
+		return err
+	}
+	if g.trusted(u.Hostname()) {
+		return nil
+	}
+	_, err = g.resolve(ctx, "ip", u.Hostname(), RedactURL(u))
+	return err
+}
+
+// resolve 解析主机名并检查全部地址，任一地址被拒绝时整体拒绝
+func (g *Guard) resolve(ctx context.Context, network, host, target string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if reason := g.CheckIP(addr); reason != "" {
+			return nil, &BlockedError{Target: target, IP: addr, Reason: reason}
+		}
+		return []netip.Addr{addr}, nil
+	}
+	if matchHost(g.denyHosts, normalizeHost(host)) {
+		return nil, &BlockedError{Target: target, Reason: "主机名在禁止列表中"}
+	}
+
+	addrs, err := g.resolver.LookupNetIP(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "没有可用的地址", Name: host, IsNotFound: true}
+	}
+	for _, addr := range addrs {
+		if reason := g.CheckIP(addr); reason != "" {
+			return nil, &BlockedError{Target: target, IP: addr.WithZone("").Unmap(), Reason: reason}
+		}
+	}
+	return addrs, nil
+}
+
+// DialContext 解析并检查目标地址后连接，连接使用检查过的地址而不是再次解析主机名
+func (g *Guard) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if g.trusted(host) {
+		return g.dial(ctx, network, addr)
+	}
+
+	lookup := "ip"
+	switch network {
+	case "tcp4", "udp4":
+		lookup = "ip4"
+	case "tcp6", "udp6":
+		lookup = "ip6"
+	}
+	addrs, err := g.resolve(ctx, lookup, host, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range addrs {
+		conn, err := g.dial(ctx, network, net.JoinHostPort(ip.Unmap().String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// RedactURL 返回可记录日志的URL：去除用户信息与片段，对敏感查询参数脱敏
+func RedactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.Fragment = ""
+	redacted.RawFragment = ""
+	redacted.RawQuery = redact.Default().Query(u.RawQuery)
+	return redacted.String()
+}
@@ -0,0 +1,188 @@
+package event
+
+import (
+	"time"
+
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/gorm"
+)
+
+// 框架内部事件名称
+const (
+	EventCacheMiss       = "cache.miss"
+	EventCacheEviction   = "cache.eviction"
+	EventDBSlowQuery     = "db.slow_query"
+	EventStorageWrite    = "storage.write"
+	EventQueueJobFailed  = "queue.job_failed"
+	EventAuthLoginFailed = "auth.login_failed"
+)
+
+// CacheMiss 缓存未命中
+type CacheMiss struct {
+	BaseEvent
+	Key       string
+	Store     string
+	RequestID string
+}
+
+// NewCacheMiss 创建缓存未命中事件
+func NewCacheMiss(store, key string) *CacheMiss {
+	e := &CacheMiss{BaseEvent: *NewBaseEvent(EventCacheMiss), Key: key, Store: store}
+	e.payload["key"] = key
+	e.payload["store"] = store
+	return e
+}
+
+// WithRequestID 记录触发事件的请求ID，为空时不写入负载
+func (e *CacheMiss) WithRequestID(id string) *CacheMiss {
+	if id != "" {
+		e.RequestID = id
+		e.payload["request_id"] = id
+	}
+	return e
+}
+
+// CacheEviction 缓存被删除，Reason 为 delete 或 tag（按标签删除时Key为标签名）
+type CacheEviction struct {
+	BaseEvent
+	Key       string
+	Store     string
+	Reason    string
+	RequestID string
+}
+
+// NewCacheEviction 创建缓存删除事件
+func NewCacheEviction(store, key, reason string) *CacheEviction {
+	e := &CacheEviction{BaseEvent: *NewBaseEvent(EventCacheEviction), Key: key, Store: store, Reason: reason}
+	e.payload["key"] = key
+	e.payload["store"] = store
+	e.payload["reason"] = reason
+	return e
+}
+
+// WithRequestID 记录触发事件的请求ID，为空时不写入负载
+func (e *CacheEviction) WithRequestID(id string) *CacheEviction {
+	if id != "" {
+		e.RequestID = id
+		e.payload["request_id"] = id
+	}
+	return e
+}
+
+// DBSlowQuery 慢查询
+type DBSlowQuery struct {
+	BaseEvent
+	SQL       string
+	Duration  time.Duration
+	Conn      string
+	RequestID string
+}
+
+// NewDBSlowQuery 创建慢查询事件
+func NewDBSlowQuery(conn, sql string, duration time.Duration) *DBSlowQuery {
+	e := &DBSlowQuery{BaseEvent: *NewBaseEvent(EventDBSlowQuery), SQL: sql, Duration: duration, Conn: conn}
+	e.payload["sql"] = sql
+	e.payload["duration"] = duration
+	e.payload["conn"] = conn
+	return e
+}
+
+// WithRequestID 记录触发事件的请求ID，为空时不写入负载
+func (e *DBSlowQuery) WithRequestID(id string) *DBSlowQuery {
+	if id != "" {
+		e.RequestID = id
+		e.payload["request_id"] = id
+	}
+	return e
+}
+
+// StorageWrite 文件写入
+type StorageWrite struct {
+	BaseEvent
+	Disk string
+	Path string
+	Size int64
+}
+
+// NewStorageWrite 创建文件写入事件
+func NewStorageWrite(disk, path string, size int64) *StorageWrite {
+	e := &StorageWrite{BaseEvent: *NewBaseEvent(EventStorageWrite), Disk: disk, Path: path, Size: size}
+	e.payload["disk"] = disk
+	e.payload["path"] = path
+	e.payload["size"] = size
+	return e
+}
+
+// QueueJobFailed 队列任务执行失败
+type QueueJobFailed struct {
+	BaseEvent
+	Queue string
+	Type  string
+	Error string
+}
+
+// NewQueueJobFailed 创建任务失败事件
+func NewQueueJobFailed(queue, jobType string, err error) *QueueJobFailed {
+	e := &QueueJobFailed{BaseEvent: *NewBaseEvent(EventQueueJobFailed), Queue: queue, Type: jobType}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	e.payload["queue"] = queue
+	e.payload["type"] = jobType
+	e.payload["error"] = e.Error
+	return e
+}
+
+// AuthLoginFailed 登录失败
+type AuthLoginFailed struct {
+	BaseEvent
+	Provider string
+	Reason   string
+}
+
+// NewAuthLoginFailed 创建登录失败事件
+func NewAuthLoginFailed(provider, reason string) *AuthLoginFailed {
+	e := &AuthLoginFailed{BaseEvent: *NewBaseEvent(EventAuthLoginFailed), Provider: provider, Reason: reason}
+	e.payload["provider"] = provider
+	e.payload["reason"] = reason
+	return e
+}
+
+// Publisher 框架内部事件发布器，零值与nil均可安全使用（不发布任何事件）
+//
+// 发布方应先调用 Enabled 判断是否有监听器，再构造事件，
+// 这样在没有订阅者时热路径上不会产生任何分配：
+//
+//	if p.Enabled(event.EventCacheMiss) {
+//		p.Publish(event.NewCacheMiss(store, key))
+//	}
+type Publisher struct {
+	dispatcher Dispatcher
+}
+
+// NewPublisher 创建事件发布器，dispatcher为nil时不发布任何事件
+func NewPublisher(dispatcher Dispatcher) *Publisher {
+	return &Publisher{dispatcher: dispatcher}
+}
+
+// Enabled 检查事件是否有监听器
+func (p *Publisher) Enabled(name string) bool {
+	return p != nil && p.dispatcher != nil && p.dispatcher.HasListeners(name)
+}
+
+// Publish 通过分发器的异步通道发布事件，不会等待监听器执行
+func (p *Publisher) Publish(e Event) {
+	if !p.Enabled(e.GetName()) {
+		return
+	}
+	_ = p.dispatcher.DispatchAsync(e)
+}
+
+// WatchSlowQueries 为数据库连接注册慢查询回调，将超过阈值的语句发布为 DBSlowQuery 事件
+func WatchSlowQueries(conn *gorm.DB, connName string, threshold time.Duration, publisher *Publisher) error {
+	return db.OnSlowQuery(conn, connName, threshold, func(q db.SlowQuery) {
+		if publisher.Enabled(EventDBSlowQuery) {
+			publisher.Publish(NewDBSlowQuery(q.Conn, q.SQL, q.Duration).WithRequestID(q.RequestID))
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package event
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublisher_NilIsNoop(t *testing.T) {
+	var p *Publisher
+	assert.False(t, p.Enabled(EventCacheMiss))
+	p.Publish(NewCacheMiss("memory", "k"))
+
+	// 没有监听器时判断是否启用不应分配内存
+	d := NewDispatcher(10)
+	defer d.Stop()
+	p = NewPublisher(d)
+	allocs := testing.AllocsPerRun(100, func() {
+		if p.Enabled(EventCacheMiss) {
+			p.Publish(NewCacheMiss("memory", "k"))
+		}
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestPublisher_PerTypeSubscription(t *testing.T) {
+	d := NewDispatcher(10)
+	defer d.Stop()
+	p := NewPublisher(d)
+
+	var mu sync.Mutex
+	var received []Event
+	done := make(chan struct{}, 10)
+	require.NoError(t, d.AddListenerFunc(EventQueueJobFailed, func(e Event) error {
+		mu.Lock()
+		received = append(received, e)
+		mu.Unlock()
+		done <- struct{}{}
+		return nil
+	}))
+
+	p.Publish(NewCacheMiss("memory", "k"))
+	p.Publish(NewAuthLoginFailed("password", "密码错误"))
+	p.Publish(NewQueueJobFailed("default", "mail", errors.New("失败")))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("未收到事件")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 1)
+	failed, ok := received[0].(*QueueJobFailed)
+	require.True(t, ok, "监听器应收到具体的事件类型")
+	assert.Equal(t, "default", failed.Queue)
+	assert.Equal(t, "mail", failed.Type)
+	assert.Equal(t, "失败", failed.Error)
+	value, _ := failed.GetPayloadValue("type")
+	assert.Equal(t, "mail", value)
+}
+
+func TestPublisher_AsyncDelivery(t *testing.T) {
+	d := NewDispatcher(10)
+	defer d.Stop()
+	p := NewPublisher(d)
+
+	release := make(chan struct{})
+	delivered := make(chan struct{})
+	require.NoError(t, d.AddListenerFunc(EventDBSlowQuery, func(e Event) error {
+		<-release
+		close(delivered)
+		return nil
+	}))
+
+	// 监听器阻塞时发布方不应被阻塞
+	returned := make(chan struct{})
+	go func() {
+		p.Publish(NewDBSlowQuery("default", "SELECT 1", time.Second))
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Publish 被监听器阻塞")
+	}
+
+	close(release)
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("事件未被异步投递")
+	}
+}
+
+func BenchmarkPublisher_NoListeners(b *testing.B) {
+	d := NewDispatcher(10)
+	defer d.Stop()
+	p := NewPublisher(d)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if p.Enabled(EventCacheMiss) {
+			p.Publish(NewCacheMiss("memory", "k"))
+		}
+	}
+}
+
+func BenchmarkPublisher_Nil(b *testing.B) {
+	var p *Publisher
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if p.Enabled(EventCacheMiss) {
+			p.Publish(NewCacheMiss("memory", "k"))
+		}
+	}
+}
@@ -80,31 +80,24 @@ func setupRoutes(e *flow.Engine) {
 			return
 		}
 
-		// 尝试从缓存获取
+		// 未命中时从"数据库"获取并写入缓存，并发请求只查询一次
 		ctx := context.Background()
-		cacheKey := "products:all"
-		cached, err := manager.Get(ctx, cacheKey)
-
-		if err == nil {
-			// 缓存命中
-			c.JSON(http.StatusOK, flow.H{
-				"source":   "cache",
-				"products": cached,
-			})
+		source := "cache"
+		allProducts, err := manager.Remember(ctx, "products:all", 1*time.Minute, func() (interface{}, error) {
+			source = "database"
+			list := make([]Product, 0, len(products))
+			for _, product := range products {
+				list = append(list, product)
+			}
+			return list, nil
+		}, cache.WithTags("products"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, flow.H{"error": err.Error()})
 			return
 		}
 
-		// 缓存未命中，从"数据库"获取
-		allProducts := make([]Product, 0, len(products))
-		for _, product := range products {
-			allProducts = append(allProducts, product)
-		}
-
-		// 存入缓存
-		_ = manager.Set(ctx, cacheKey, allProducts, cache.WithExpiration(1*time.Minute), cache.WithTags("products"))
-
 		c.JSON(http.StatusOK, flow.H{
-			"source":   "database",
+			"source":   source,
 			"products": allProducts,
 		})
 	})
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/cache"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// User 用户模型
+type User struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// Order 订单，渲染列表时需要逐行查询所属用户
+type Order struct {
+	ID     uint    `json:"id"`
+	UserID uint    `json:"user_id"`
+	Amount float64 `json:"amount"`
+}
+
+func main() {
+	database, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("打开数据库失败: %v", err)
+	}
+	_ = database.AutoMigrate(&User{}, &Order{})
+	database.Create(&[]User{{ID: 1, Name: "张三"}, {ID: 2, Name: "李四"}})
+	database.Create(&[]Order{{UserID: 1, Amount: 99}, {UserID: 2, Amount: 199}, {UserID: 1, Amount: 59}, {UserID: 3, Amount: 9}})
+
+	manager := cache.NewManager()
+	manager.AddStore("memory", cache.NewMemoryStore())
+	manager.SetDefault("memory")
+
+	e := flow.New()
+
+	// 使用GORM的 WHERE id IN (?) 合并用户查询
+	e.GET("/orders", func(c *flow.Context) {
+		users := flow.LoaderFor(c, "users", func(ctx context.Context, ids []uint) (map[uint]User, error) {
+			var rows []User
+			if err := database.WithContext(ctx).Where("id IN (?)", ids).Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			result := make(map[uint]User, len(rows))
+			for _, u := range rows {
+				result[u.ID] = u
+			}
+			return result, nil
+		})
+
+		var orders []Order
+		database.Find(&orders)
+
+		// 每行调用Load，实际只执行一次查询
+		list := make([]flow.H, 0, len(orders))
+		for _, order := range orders {
+			user, err := users.Load(order.UserID)
+			item := flow.H{"order": order, "user": user}
+			if err != nil {
+				item["user"] = nil
+				item["error"] = err.Error()
+			}
+			list = append(list, item)
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	// 使用 cache.GetMultiple 合并缓存读取
+	e.GET("/prices", func(c *flow.Context) {
+		prices := flow.LoaderFor(c, "prices", func(ctx context.Context, ids []int) (map[int]interface{}, error) {
+			keys := make([]string, len(ids))
+			for i, id := range ids {
+				keys[i] = "price:" + strconv.Itoa(id)
+			}
+			// 个别缓存值损坏时仍使用其余结果
+			cached, err := manager.GetMultiple(ctx, keys)
+			var multiErr *cache.MultiError
+			if err != nil && !errors.As(err, &multiErr) {
+				return nil, err
+			}
+			result := make(map[int]interface{}, len(cached))
+			for i, id := range ids {
+				if v, ok := cached[keys[i]]; ok {
+					result[id] = v
+				}
+			}
+			return result, nil
+		}, flow.WithLoaderWait(time.Millisecond))
+
+		values, errs := prices.LoadMany([]int{1, 2, 3})
+		c.JSON(http.StatusOK, flow.H{"values": values, "errors": fmt.Sprint(errs)})
+	})
+
+	_ = manager.Set(context.Background(), "price:1", 10)
+	_ = manager.Set(context.Background(), "price:2", 20)
+
+	log.Println("数据加载器示例启动中，访问: http://localhost:8080/orders")
+	if err := e.Run(":8080"); err != nil {
+		log.Fatalf("启动失败: %v", err)
+	}
+}
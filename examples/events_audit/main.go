@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/event"
+	"github.com/zzliekkas/flow/v2/queue"
+	"github.com/zzliekkas/flow/v2/queue/memory"
+	"github.com/zzliekkas/flow/v2/security"
+)
+
+// auditListener 将登录失败与任务失败事件写入审计日志
+type auditListener struct {
+	audit security.AuditLogger
+}
+
+// Handle 实现 event.Listener 接口
+func (l *auditListener) Handle(e event.Event) error {
+	switch evt := e.(type) {
+	case *event.AuthLoginFailed:
+		return l.audit.LogAuthentication("", false, "", map[string]interface{}{
+			"provider": evt.Provider,
+			"reason":   evt.Reason,
+		})
+	case *event.QueueJobFailed:
+		return l.audit.LogEvent("queue", "system", "job_failed", evt.Queue+"/"+evt.Type, false, map[string]interface{}{
+			"error": evt.Error,
+		})
+	}
+	return nil
+}
+
+// ShouldHandle 实现 event.Listener 接口
+func (l *auditListener) ShouldHandle(e event.Event) bool {
+	return true
+}
+
+func main() {
+	dispatcher := event.NewDispatcher(100)
+	defer dispatcher.Stop()
+
+	audit := security.NewBasicAuditLogger(security.AuditConfig{Enabled: true})
+	listener := &auditListener{audit: audit}
+	_ = dispatcher.AddListener(event.EventAuthLoginFailed, listener)
+	_ = dispatcher.AddListener(event.EventQueueJobFailed, listener)
+
+	publisher := event.NewPublisher(dispatcher)
+
+	// 认证模块在登录失败时发布事件
+	publisher.Publish(event.NewAuthLoginFailed("password", "密码错误"))
+
+	// 队列任务失败时由中间件发布事件
+	q := memory.New(1)
+	q.Register("send-report", queue.ApplyMiddleware(func(ctx context.Context, job *queue.Job) error {
+		return errors.New("报表服务不可用")
+	}, queue.FailureEventsMiddleware(publisher)))
+
+	ctx := context.Background()
+	if _, err := q.Push(ctx, "default", "send-report", map[string]interface{}{"id": 1}); err != nil {
+		log.Fatal(err)
+	}
+	if err := q.ProcessNext(ctx, "default"); err != nil {
+		log.Printf("任务执行失败: %v", err)
+	}
+
+	// 事件为异步投递，稍等后查看审计日志
+	time.Sleep(50 * time.Millisecond)
+
+	for _, eventType := range []string{"authentication", "queue"} {
+		logs, _ := audit.GetLogs(eventType, 10)
+		for _, entry := range logs {
+			fmt.Printf("[%s] %s %s success=%v %v\n", entry.EventType, entry.Action, entry.Resource, entry.Success, entry.Details)
+		}
+	}
+}
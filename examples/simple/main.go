@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"log"
-	"strconv"
 	"time"
 
 	"github.com/zzliekkas/flow/v2"
@@ -53,18 +52,16 @@ func (c *UserController) GetUsers(ctx *flow.Context) {
 
 // GetUser 获取单个用户
 func (c *UserController) GetUser(ctx *flow.Context) {
-	// 获取URL参数
-	id := ctx.Param("id")
-
-	// 模拟查询数据库 - 使用id参数
-	var userId uint = 1
-	// 尝试将id转换为uint (实际项目中应该进行错误处理)
-	if parsedId, err := strconv.ParseUint(id, 10, 64); err == nil {
-		userId = uint(parsedId)
+	// 绑定URL参数，格式错误时自动返回400
+	var params struct {
+		ID uint `uri:"id"`
+	}
+	if err := ctx.BindUri(&params); err != nil {
+		return
 	}
 
 	user := User{
-		ID:        userId, // 使用转换后的id
+		ID:        params.ID,
 		Name:      "张三",
 		Email:     "zhangsan@example.com",
 		CreatedAt: time.Now(),
@@ -73,7 +70,7 @@ func (c *UserController) GetUser(ctx *flow.Context) {
 
 	ctx.JSON(200, flow.H{
 		"success": true,
-		"message": fmt.Sprintf("成功获取ID为%s的用户", id),
+		"message": fmt.Sprintf("成功获取ID为%d的用户", params.ID),
 		"data":    user,
 	})
 }
@@ -6,11 +6,13 @@ package flow
 // 3. 外部依赖
 // 4. 内部包
 import (
+	"html/template"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	// 确保在导入gin之前设置好gin模式
 	_ "github.com/zzliekkas/flow/v2/ginmode"
@@ -19,6 +21,7 @@ import (
 	"github.com/zzliekkas/flow/v2/config"
 	"github.com/zzliekkas/flow/v2/db"
 	"github.com/zzliekkas/flow/v2/di"
+	"github.com/zzliekkas/flow/v2/sanitize"
 	"go.uber.org/dig"
 )
 
@@ -50,18 +53,33 @@ type H map[string]interface{}
 // Engine 是Flow框架的主结构体，封装了Gin引擎和依赖注入容器
 type Engine struct {
 	*gin.Engine
-	container     *di.Container
-	config        *Config
-	server        *http.Server // HTTP服务器实例，用于优雅关闭
-	dbInitialized bool         // 数据库是否已初始化
+	container       *di.Container
+	config          *Config
+	server          *http.Server   // HTTP服务器实例，用于优雅关闭
+	listen          listenerConfig // 通过选项配置的监听器
+	restart         restartState   // 平滑重启与PID文件
+	shutdownTimeout time.Duration  // 优雅关闭时间，见 WithShutdownTimeout
+	dbInitialized   bool           // 数据库是否已初始化
 
 	// 数据库选项存储 - 每个Engine实例独立
 	databaseOptions []interface{}
 	dbOptionsMutex  sync.Mutex
 
 	// 生命周期钩子
-	startHooks    []hook // 启动钩子（Run之前执行）
-	shutdownHooks []hook // 关闭钩子（Shutdown时执行）
+	startHooks    []hook        // 启动钩子（Run监听端口后、开始处理请求前执行）
+	readyHooks    []hook        // 就绪钩子（启动任务全部完成、开始处理请求后执行）
+	shutdownHooks []hook        // 关闭钩子（Shutdown时执行）
+	readiness     readinessGate // 启动任务与就绪门控
+
+	// 路由与中间件
+	middleware []string         // 全局中间件名称（按Use顺序）
+	routeTable routeTable       // 已注册路由
+	hosts      hostRouter       // 通过 Host 注册的虚拟主机
+	groups     middlewareGroups // 通过 RegisterMiddlewareGroup 注册的命名中间件组
+
+	// H2 处理函数，供启动时检查依赖
+	injected []*injectTarget
+	injectMu sync.Mutex
 }
 
 // hook 带优先级的钩子函数
@@ -76,6 +94,12 @@ type Config struct {
 	JSONLib    string // JSON库: default, gojson
 	LogLevel   string // 日志级别: debug, info, warn, error
 	ConfigPath string // 配置文件路径
+
+	RawBodyLimit int64 // Context.RawBody 的默认大小限制，0 表示使用 DefaultRawBodyLimit
+
+	BaseURL string // 应用的外部访问地址，用于生成绝对链接，为空时按请求推断
+
+	JSON JSONOptions // 响应的JSON编码配置，见 WithJSONOptions
 }
 
 // HandlerFunc 定义Flow处理函数
@@ -173,6 +197,11 @@ func applyConfigToEngine(e *Engine, cfg *config.ConfigManager) {
 		e.config.LogLevel = logLevel
 	}
 
+	// 应用外部访问地址
+	if baseURL := cfg.GetString("app.url"); baseURL != "" {
+		e.config.BaseURL = baseURL
+	}
+
 	// 应用其它配置
 	if templates := cfg.GetString("app.templates"); templates != "" {
 		e.LoadHTMLGlob(templates)
@@ -227,6 +256,14 @@ func WithLogLevel(level string) Option {
 	}
 }
 
+// WithBaseURL 返回一个设置外部访问地址的选项，如 "https://api.example.com"，
+// 分页链接等绝对地址以此为前缀，未设置时按请求的协议与主机推断，也可通过配置 app.url 设置
+func WithBaseURL(baseURL string) Option {
+	return func(e *Engine) {
+		e.config.BaseURL = baseURL
+	}
+}
+
 // configureLogLevel 配置日志级别
 func configureLogLevel(level string) {
 	// 尝试将全局日志实例转换为 defaultLogger 以设置级别
@@ -272,6 +309,16 @@ func WithTemplates(pattern string) Option {
 	}
 }
 
+// WithTemplateFuncs 返回一个添加模板函数的选项，与默认的 sanitize、jsonInHTML 合并，
+// 需要在 WithTemplates 之前传入
+func WithTemplateFuncs(funcs template.FuncMap) Option {
+	return func(e *Engine) {
+		for name, fn := range funcs {
+			e.Engine.FuncMap[name] = fn
+		}
+	}
+}
+
 // WithStaticFiles 返回一个配置静态文件服务的选项
 func WithStaticFiles(urlPath, dirPath string) Option {
 	return func(e *Engine) {
@@ -286,6 +333,14 @@ func WithServiceProvider(constructor interface{}) Option {
 	}
 }
 
+// WithStrictDI 返回一个开启依赖注入严格模式的选项，同一类型重复注册时 Provide 返回 *di.DuplicateProviderError
+// 选项按顺序执行，需放在 WithConfig 等注册服务的选项之前
+func WithStrictDI() Option {
+	return func(e *Engine) {
+		e.container.SetStrict(true)
+	}
+}
+
 // WithConfigWatcher 返回一个监听配置变更的选项
 func WithConfigWatcher(callback func()) Option {
 	return func(e *Engine) {
@@ -299,8 +354,12 @@ func WithConfigWatcher(callback func()) Option {
 
 // New 创建一个新的Flow引擎实例，支持选项模式配置
 func New(options ...Option) *Engine {
-	// 创建依赖注入容器
+	// 创建依赖注入容器，重复注册默认输出警告，WithStrictDI 改为返回错误
 	container := di.New()
+	container.OnDuplicate(func(err *di.DuplicateProviderError) {
+		flog.Warnf("重复注册依赖: type=%s provider=%q location=%s previous=%q previous_location=%s",
+			err.Type, err.Current.Name, err.Current.Location, err.Previous.Name, err.Previous.Location)
+	})
 
 	// 默认配置
 	defaultMode := "debug"
@@ -329,6 +388,8 @@ func New(options ...Option) *Engine {
 
 	// 创建gin引擎
 	ginEngine := gin.New()
+	// HTML模板默认提供 sanitize 与 jsonInHTML 函数
+	ginEngine.SetFuncMap(sanitize.FuncMap())
 
 	// 创建Flow引擎
 	e := &Engine{
@@ -338,8 +399,8 @@ func New(options ...Option) *Engine {
 	}
 
 	// 添加默认中间件
-	e.Use(func(c *Context) {
-		ginRecovery := gin.Recovery()
+	ginRecovery := gin.Recovery()
+	e.UseNamed("recovery", func(c *Context) {
 		ginRecovery(c.Context)
 	})
 
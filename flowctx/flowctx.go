@@ -0,0 +1,234 @@
+// Package flowctx 定义在请求与下层调用（数据库、缓存、审计、队列）之间传递的请求级上下文值
+//
+// 所有值保存在同一个不导出的键下，各包通过这里的访问函数读写，不再各自定义键类型：
+//
+//	// 中间件中写入，同时更新 gin 上下文与 c.Request.Context()
+//	flowctx.SetRequestID(c.Context, id)
+//
+//	// 下层只需要 context.Context
+//	id := flowctx.RequestID(ctx)
+//
+// 值不存在时所有读取函数返回零值且不分配内存，ctx 为nil时同样安全
+package flowctx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin 上下文中的键，c.Get 与 Export 使用
+const (
+	KeyRequestID      = "RequestID"
+	KeyTenantID       = "tenant_id"
+	KeyUserID         = "user_id"
+	KeyLocale         = "app.locale"
+	KeyDeadlineSource = "deadline_source"
+	KeyGeo            = "geo"
+)
+
+// Values 请求级上下文值
+type Values struct {
+	RequestID      string
+	TenantID       string
+	UserID         string
+	Locale         string
+	DeadlineSource string // 设置 context 截止时间的来源，例如 "route" 或 "client"
+	Geo            GeoInfo
+}
+
+// GeoInfo 客户端IP解析出的地理位置，由 middleware.GeoIP 写入
+type GeoInfo struct {
+	Country string // ISO 3166-1 国家代码，如 "CN"
+	Region  string // 一级行政区代码，如 "GD"
+	City    string // 城市名称
+	ASN     uint32 // 自治系统编号
+	ASOrg   string // 自治系统所属组织
+}
+
+// IsZero 判断是否没有解析出任何信息
+func (g GeoInfo) IsZero() bool {
+	return g == GeoInfo{}
+}
+
+// IsZero 判断是否没有任何值
+func (v Values) IsZero() bool {
+	return v == Values{}
+}
+
+// valuesKey Values 在 context 中的键
+type valuesKey struct{}
+
+// With 将值保存到 context，覆盖已有的全部值
+func With(ctx context.Context, v Values) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, valuesKey{}, v)
+}
+
+// Import 读取 context 中的值，不存在时返回零值
+func Import(ctx context.Context) Values {
+	if ctx == nil {
+		return Values{}
+	}
+	v, _ := ctx.Value(valuesKey{}).(Values)
+	return v
+}
+
+// RequestID 读取请求ID
+func RequestID(ctx context.Context) string {
+	return Import(ctx).RequestID
+}
+
+// TenantID 读取租户ID
+func TenantID(ctx context.Context) string {
+	return Import(ctx).TenantID
+}
+
+// UserID 读取已认证用户的ID
+func UserID(ctx context.Context) string {
+	return Import(ctx).UserID
+}
+
+// Locale 读取请求语言
+func Locale(ctx context.Context) string {
+	return Import(ctx).Locale
+}
+
+// DeadlineSource 读取截止时间来源
+func DeadlineSource(ctx context.Context) string {
+	return Import(ctx).DeadlineSource
+}
+
+// Geo 读取客户端的地理位置
+func Geo(ctx context.Context) GeoInfo {
+	return Import(ctx).Geo
+}
+
+// WithRequestID 返回设置了请求ID的 context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return update(ctx, func(v *Values) { v.RequestID = id })
+}
+
+// WithTenantID 返回设置了租户ID的 context
+func WithTenantID(ctx context.Context, id string) context.Context {
+	return update(ctx, func(v *Values) { v.TenantID = id })
+}
+
+// WithUserID 返回设置了用户ID的 context
+func WithUserID(ctx context.Context, id string) context.Context {
+	return update(ctx, func(v *Values) { v.UserID = id })
+}
+
+// WithLocale 返回设置了语言的 context
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return update(ctx, func(v *Values) { v.Locale = locale })
+}
+
+// WithDeadlineSource 返回设置了截止时间来源的 context
+func WithDeadlineSource(ctx context.Context, source string) context.Context {
+	return update(ctx, func(v *Values) { v.DeadlineSource = source })
+}
+
+// WithGeo 返回设置了地理位置的 context
+func WithGeo(ctx context.Context, geo GeoInfo) context.Context {
+	return update(ctx, func(v *Values) { v.Geo = geo })
+}
+
+// update 修改 context 中的一个值
+func update(ctx context.Context, set func(*Values)) context.Context {
+	v := Import(ctx)
+	set(&v)
+	return With(ctx, v)
+}
+
+// SetRequestID 在 gin 上下文中设置请求ID，并同步到 c.Request.Context()
+func SetRequestID(c *gin.Context, id string) {
+	set(c, KeyRequestID, id, func(v *Values) { v.RequestID = id })
+}
+
+// SetTenantID 在 gin 上下文中设置租户ID，并同步到 c.Request.Context()
+func SetTenantID(c *gin.Context, id string) {
+	set(c, KeyTenantID, id, func(v *Values) { v.TenantID = id })
+}
+
+// SetUserID 在 gin 上下文中设置用户ID，并同步到 c.Request.Context()
+func SetUserID(c *gin.Context, id string) {
+	set(c, KeyUserID, id, func(v *Values) { v.UserID = id })
+}
+
+// SetLocale 在 gin 上下文中设置语言，并同步到 c.Request.Context()
+func SetLocale(c *gin.Context, locale string) {
+	set(c, KeyLocale, locale, func(v *Values) { v.Locale = locale })
+}
+
+// SetDeadlineSource 在 gin 上下文中设置截止时间来源，并同步到 c.Request.Context()
+func SetDeadlineSource(c *gin.Context, source string) {
+	set(c, KeyDeadlineSource, source, func(v *Values) { v.DeadlineSource = source })
+}
+
+// SetGeo 在 gin 上下文中设置地理位置，并同步到 c.Request.Context()
+func SetGeo(c *gin.Context, geo GeoInfo) {
+	c.Set(KeyGeo, geo)
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(WithGeo(c.Request.Context(), geo))
+	}
+}
+
+// set 写入 gin 上下文的键，并替换请求的 context，之后的 c.Request.Context() 都能读到
+func set(c *gin.Context, key, value string, apply func(*Values)) {
+	c.Set(key, value)
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(update(c.Request.Context(), apply))
+	}
+}
+
+// Export 返回携带请求级值的 context
+//
+// 以 c.Request.Context() 为基础，补充只通过 c.Set 写入 gin 上下文的值；通过 Set* 函数写入的值已在
+// c.Request.Context() 中，这种情况下直接返回它。flow.Context 嵌入了 *gin.Context，调用时传入 c.Context
+// （flowctx 不能依赖根包，否则数据库与队列包引用它时会形成循环依赖）
+func Export(c *gin.Context) context.Context {
+	ctx := context.Background()
+	if c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	current := Import(ctx)
+	v := current
+	fill := func(field *string, key string) {
+		if *field != "" {
+			return
+		}
+		if value, ok := c.Get(key); ok {
+			*field = stringOf(value)
+		}
+	}
+	fill(&v.RequestID, KeyRequestID)
+	fill(&v.TenantID, KeyTenantID)
+	fill(&v.UserID, KeyUserID)
+	fill(&v.Locale, KeyLocale)
+	fill(&v.DeadlineSource, KeyDeadlineSource)
+	if v.Geo.IsZero() {
+		if geo, ok := c.Get(KeyGeo); ok {
+			v.Geo, _ = geo.(GeoInfo)
+		}
+	}
+	if v == current {
+		return ctx
+	}
+	return With(ctx, v)
+}
+
+// stringOf 将 gin 上下文中的值转换为字符串，租户与用户ID可能是数字
+func stringOf(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
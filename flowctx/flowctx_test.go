@@ -0,0 +1,126 @@
+package flowctx_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/cache"
+	"github.com/zzliekkas/flow/v2/db"
+	"github.com/zzliekkas/flow/v2/event"
+	"github.com/zzliekkas/flow/v2/flowctx"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRequestIDReachesDBAndCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	queries := make(chan db.SlowQuery, 1)
+	require.NoError(t, db.OnSlowQuery(conn, "default", time.Nanosecond, func(q db.SlowQuery) {
+		queries <- q
+	}))
+
+	dispatcher := event.NewDispatcher(10)
+	defer dispatcher.Stop()
+	misses := make(chan *event.CacheMiss, 1)
+	require.NoError(t, dispatcher.AddListenerFunc(event.EventCacheMiss, func(e event.Event) error {
+		misses <- e.(*event.CacheMiss)
+		return nil
+	}))
+	manager := cache.NewManager()
+	manager.AddStore("memory", cache.NewMemoryStore())
+	manager.SetDefault("memory")
+	manager.SetEventPublisher(event.NewPublisher(dispatcher))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		flowctx.SetRequestID(c, "req-42")
+		flowctx.SetTenantID(c, "acme")
+		c.Next()
+	})
+	r.GET("/", func(c *gin.Context) {
+		// 下层只拿到请求的 context
+		ctx := c.Request.Context()
+		var n int
+		require.NoError(t, conn.WithContext(ctx).Raw("SELECT 1").Scan(&n).Error)
+		_, err := manager.Get(ctx, "missing")
+		assert.ErrorIs(t, err, cache.ErrCacheMiss)
+		c.Status(http.StatusNoContent)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	q := <-queries
+	assert.Equal(t, "req-42", q.RequestID)
+	assert.Equal(t, "acme", q.TenantID)
+	select {
+	case miss := <-misses:
+		assert.Equal(t, "req-42", miss.RequestID)
+		assert.Equal(t, "req-42", miss.GetPayload()["request_id"])
+	case <-time.After(time.Second):
+		t.Fatal("未收到缓存未命中事件")
+	}
+}
+
+func TestExportFillsValuesSetOnGinContext(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set(flowctx.KeyUserID, 7)
+	c.Set(flowctx.KeyLocale, "zh-CN")
+	c.Set(flowctx.KeyGeo, flowctx.GeoInfo{Country: "CN"})
+	flowctx.SetRequestID(c, "req-1")
+
+	ctx := flowctx.Export(c)
+	assert.Equal(t, flowctx.Values{RequestID: "req-1", UserID: "7", Locale: "zh-CN", Geo: flowctx.GeoInfo{Country: "CN"}}, flowctx.Import(ctx))
+	assert.Equal(t, "CN", flowctx.Geo(ctx).Country)
+	// 已通过 Set* 同步的值不需要新建 context
+	c.Keys = nil
+	flowctx.SetRequestID(c, "req-2")
+	assert.Equal(t, c.Request.Context(), flowctx.Export(c))
+}
+
+func TestAbsentValues(t *testing.T) {
+	var nilCtx context.Context
+	assert.True(t, flowctx.Import(nilCtx).IsZero())
+	ctx := context.Background()
+	assert.Empty(t, flowctx.RequestID(ctx))
+	assert.Empty(t, flowctx.UserID(ctx))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = flowctx.Import(ctx)
+		_ = flowctx.TenantID(ctx)
+	})
+	assert.Zero(t, allocs)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Equal(t, c.Request.Context(), flowctx.Export(c))
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	base := flowctx.WithRequestID(context.Background(), "req-1")
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := flowctx.WithUserID(base, "user")
+			if i%2 == 0 {
+				ctx = flowctx.WithLocale(ctx, "en")
+			}
+			assert.Equal(t, "req-1", flowctx.RequestID(ctx))
+			assert.Equal(t, "user", flowctx.UserID(ctx))
+		}(i)
+	}
+	wg.Wait()
+	// 派生 context 不影响原 context
+	assert.Empty(t, flowctx.UserID(base))
+}
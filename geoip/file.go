@@ -0,0 +1,231 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/zzliekkas/flow/v2/flowctx"
+)
+
+// defaultReloadDelay 文件变更后等待的时间，合并下载工具产生的多个事件
+const defaultReloadDelay = 200 * time.Millisecond
+
+// fileOptions FileResolver 的选项
+type fileOptions struct {
+	language    string
+	watch       bool
+	reloadDelay time.Duration
+	onReload    func(error)
+}
+
+// FileOption FileResolver 的配置函数
+type FileOption func(*fileOptions)
+
+// WithLanguage 设置城市名称的语言，默认 en，数据库中没有该语言时使用英文名称
+func WithLanguage(language string) FileOption {
+	return func(o *fileOptions) {
+		o.language = language
+	}
+}
+
+// WithoutWatch 不监视文件变更，只能通过 Reload 重新加载
+func WithoutWatch() FileOption {
+	return func(o *fileOptions) {
+		o.watch = false
+	}
+}
+
+// WithReloadDelay 设置文件变更后等待多久再重新加载
+func WithReloadDelay(d time.Duration) FileOption {
+	return func(o *fileOptions) {
+		o.reloadDelay = d
+	}
+}
+
+// WithOnReload 设置自动重新加载后的回调，err 为nil表示加载成功；默认记录失败日志
+func WithOnReload(fn func(err error)) FileOption {
+	return func(o *fileOptions) {
+		o.onReload = fn
+	}
+}
+
+// FileResolver 从 MaxMind DB 文件解析IP，文件以内存映射方式打开
+//
+// 默认监视文件所在目录，文件被替换后重新加载，加载失败时继续使用原来的数据库。
+// 更新数据库时应写入临时文件后重命名，直接覆盖正在映射的文件可能读到不完整的数据。
+// 文件不存在时 Lookup 返回 ErrNoDatabase，文件出现后自动加载
+type FileResolver struct {
+	path string
+	opts fileOptions
+
+	mu     sync.RWMutex
+	reader *Reader
+
+	watcher *fsnotify.Watcher
+	timer   *time.Timer
+	closed  bool
+}
+
+// NewFileResolver 打开数据库文件，文件不存在时不返回错误，文件无效时返回错误
+func NewFileResolver(path string, opts ...FileOption) (*FileResolver, error) {
+	options := fileOptions{language: "en", watch: true, reloadDelay: defaultReloadDelay}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.onReload == nil {
+		options.onReload = func(err error) {
+			if err != nil {
+				log.Printf("重新加载 GeoIP 数据库失败: %v\n", err)
+			}
+		}
+	}
+
+	f := &FileResolver{path: path, opts: options}
+	if err := f.Reload(); err != nil && !errors.Is(err, ErrNoDatabase) {
+		return nil, err
+	}
+	if options.watch {
+		if err := f.watch(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Open 打开 MaxMind DB 文件
+func Open(path string) (*Reader, error) {
+	buf, release, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newReader(buf, release)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Reload 重新打开数据库文件，失败时保留原来的数据库；文件不存在时返回 ErrNoDatabase
+func (f *FileResolver) Reload() error {
+	reader, err := Open(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s 不存在", ErrNoDatabase, f.path)
+		}
+		return err
+	}
+
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return reader.Close()
+	}
+	old := f.reader
+	f.reader = reader
+	f.mu.Unlock()
+
+	// 正在进行的查询持有读锁，替换后旧的映射不再被访问
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// Metadata 返回当前数据库的元数据，未加载时第二个返回值为false
+func (f *FileResolver) Metadata() (Metadata, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.reader == nil {
+		return Metadata{}, false
+	}
+	return f.reader.Metadata(), true
+}
+
+// Lookup 查询IP所在的位置
+func (f *FileResolver) Lookup(ip net.IP) (flowctx.GeoInfo, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.reader == nil {
+		return flowctx.GeoInfo{}, fmt.Errorf("%w: %s", ErrNoDatabase, f.path)
+	}
+	record, err := f.reader.Lookup(ip)
+	if err != nil {
+		return flowctx.GeoInfo{}, err
+	}
+	return geoFromRecord(record, f.opts.language), nil
+}
+
+// Close 停止监视并释放数据库
+func (f *FileResolver) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	if f.watcher != nil {
+		f.watcher.Close()
+	}
+	var err error
+	if f.reader != nil {
+		err = f.reader.Close()
+		f.reader = nil
+	}
+	return err
+}
+
+// watch 监视文件所在目录，重命名替换的文件不会触发原文件的事件
+func (f *FileResolver) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(f.path)); err != nil {
+		watcher.Close()
+		return err
+	}
+	f.watcher = watcher
+
+	name := filepath.Clean(f.path)
+	go func() {
+		for event := range watcher.Events {
+			if filepath.Clean(event.Name) != name || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			f.mu.Lock()
+			if !f.closed {
+				if f.timer != nil {
+					f.timer.Stop()
+				}
+				f.timer = time.AfterFunc(f.opts.reloadDelay, f.reloadFromWatch)
+			}
+			f.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// reloadFromWatch 文件变更后重新加载
+func (f *FileResolver) reloadFromWatch() {
+	f.mu.RLock()
+	closed := f.closed
+	f.mu.RUnlock()
+	if closed {
+		return
+	}
+	err := f.Reload()
+	if errors.Is(err, ErrNoDatabase) {
+		// 文件被移走（例如重命名替换的中间状态），保留当前数据库
+		return
+	}
+	f.opts.onReload(err)
+}
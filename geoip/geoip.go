@@ -0,0 +1,167 @@
+// Package geoip 将客户端IP解析为国家、地区、城市与自治系统，配合 middleware.GeoIP 使用
+//
+// FileResolver 读取 MaxMind DB（GeoIP2/GeoLite2 City、Country、ASN 等 .mmdb 文件），
+// 文件被替换后自动重新加载：
+//
+//	resolver, err := geoip.NewFileResolver("/var/lib/geoip/GeoLite2-City.mmdb")
+//	if err != nil {
+//		return err
+//	}
+//	defer resolver.Close()
+//	engine.Use(middleware.GeoIP(resolver))
+//
+// 测试中使用 Static 或 Nop
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/zzliekkas/flow/v2/flowctx"
+)
+
+// ErrNoDatabase 数据库文件不存在或尚未加载
+var ErrNoDatabase = errors.New("GeoIP 数据库不可用")
+
+// Resolver 解析IP的地理位置
+type Resolver interface {
+	// Lookup 返回IP所在的位置，数据库中没有收录时返回零值与nil
+	Lookup(ip net.IP) (flowctx.GeoInfo, error)
+}
+
+// ResolverFunc 函数形式的 Resolver
+type ResolverFunc func(ip net.IP) (flowctx.GeoInfo, error)
+
+// Lookup 调用函数本身
+func (f ResolverFunc) Lookup(ip net.IP) (flowctx.GeoInfo, error) {
+	return f(ip)
+}
+
+// Nop 返回不解析任何IP的 Resolver
+func Nop() Resolver {
+	return ResolverFunc(func(net.IP) (flowctx.GeoInfo, error) {
+		return flowctx.GeoInfo{}, nil
+	})
+}
+
+// staticEntry Static 的一个网段
+type staticEntry struct {
+	network *net.IPNet
+	geo     flowctx.GeoInfo
+}
+
+// Static 返回按固定表解析的 Resolver，键为IP或 CIDR 网段，多个网段匹配时使用前缀最长的
+func Static(table map[string]flowctx.GeoInfo) (Resolver, error) {
+	entries := make([]staticEntry, 0, len(table))
+	for key, geo := range table {
+		cidr := key
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("无效的IP地址: %s", key)
+			}
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("无效的网段: %s", key)
+		}
+		entries = append(entries, staticEntry{network: network, geo: geo})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, _ := entries[i].network.Mask.Size()
+		b, _ := entries[j].network.Mask.Size()
+		return a > b
+	})
+
+	return ResolverFunc(func(ip net.IP) (flowctx.GeoInfo, error) {
+		for _, entry := range entries {
+			if entry.network.Contains(ip) {
+				return entry.geo, nil
+			}
+		}
+		return flowctx.GeoInfo{}, nil
+	}), nil
+}
+
+// Chain 依次查询多个 Resolver 并合并结果，前面的 Resolver 已有的字段不会被覆盖，
+// 例如组合 City 与 ASN 两个数据库；全部查询出错时返回第一个错误
+func Chain(resolvers ...Resolver) Resolver {
+	return ResolverFunc(func(ip net.IP) (flowctx.GeoInfo, error) {
+		var geo flowctx.GeoInfo
+		var firstErr error
+		failed := 0
+		for _, resolver := range resolvers {
+			found, err := resolver.Lookup(ip)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				failed++
+				continue
+			}
+			merge(&geo, found)
+		}
+		if failed == len(resolvers) && firstErr != nil {
+			return flowctx.GeoInfo{}, firstErr
+		}
+		return geo, nil
+	})
+}
+
+// merge 用 src 填充 dst 中为空的字段
+func merge(dst *flowctx.GeoInfo, src flowctx.GeoInfo) {
+	if dst.Country == "" {
+		dst.Country = src.Country
+	}
+	if dst.Region == "" {
+		dst.Region = src.Region
+	}
+	if dst.City == "" {
+		dst.City = src.City
+	}
+	if dst.ASN == 0 {
+		dst.ASN = src.ASN
+		dst.ASOrg = src.ASOrg
+	}
+}
+
+// geoFromRecord 从 GeoIP2/GeoLite2 格式的记录中提取位置，language 为城市名称的语言
+func geoFromRecord(record interface{}, language string) flowctx.GeoInfo {
+	fields, ok := record.(map[string]interface{})
+	if !ok {
+		return flowctx.GeoInfo{}
+	}
+	var geo flowctx.GeoInfo
+	if country, ok := fields["country"].(map[string]interface{}); ok {
+		geo.Country = stringField(country, "iso_code")
+	}
+	if geo.Country == "" {
+		if country, ok := fields["registered_country"].(map[string]interface{}); ok {
+			geo.Country = stringField(country, "iso_code")
+		}
+	}
+	if subdivisions, ok := fields["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+		if subdivision, ok := subdivisions[0].(map[string]interface{}); ok {
+			geo.Region = stringField(subdivision, "iso_code")
+		}
+	}
+	if city, ok := fields["city"].(map[string]interface{}); ok {
+		if names, ok := city["names"].(map[string]interface{}); ok {
+			geo.City = stringField(names, language)
+			if geo.City == "" {
+				geo.City = stringField(names, "en")
+			}
+		}
+	}
+	geo.ASN = uint32(uintField(fields, "autonomous_system_number"))
+	geo.ASOrg = stringField(fields, "autonomous_system_organization")
+	return geo
+}
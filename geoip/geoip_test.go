@@ -0,0 +1,348 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/flowctx"
+)
+
+// testNetworks 测试数据库的内容，City 与 ASN 数据库格式的记录
+func testNetworks(city string) map[string]interface{} {
+	return map[string]interface{}{
+		"1.2.3.0/24": map[string]interface{}{
+			"country":      map[string]interface{}{"iso_code": "CN"},
+			"subdivisions": []interface{}{map[string]interface{}{"iso_code": "GD"}},
+			"city":         map[string]interface{}{"names": map[string]interface{}{"en": city, "zh-CN": "深圳"}},
+		},
+		"8.8.8.0/24": map[string]interface{}{
+			"registered_country":             map[string]interface{}{"iso_code": "US"},
+			"autonomous_system_number":       uint32(15169),
+			"autonomous_system_organization": "GOOGLE",
+		},
+		"2001:db8::/32": map[string]interface{}{
+			"country": map[string]interface{}{"iso_code": "DE"},
+		},
+	}
+}
+
+// writeTestDB 生成 MaxMind DB 文件，重复的字符串使用指针引用
+func writeTestDB(t *testing.T, path string, ipVersion, recordSize int, networks map[string]interface{}) {
+	t.Helper()
+	bitCount := 32
+	if ipVersion == 6 {
+		bitCount = 128
+	}
+
+	// 搜索树：每个节点两条记录，-1 为空，>=0 为子节点，<-1 为数据（-2-偏移量）
+	nodes := [][2]int{{-1, -1}}
+	var data bytes.Buffer
+	strings := make(map[string]int)
+
+	keys := make([]string, 0, len(networks))
+	for key := range networks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		_, network, err := net.ParseCIDR(key)
+		require.NoError(t, err)
+		ip := network.IP.To16()
+		ones, _ := network.Mask.Size()
+		if network.IP.To4() != nil {
+			// IPv6 数据库中 IPv4 地址位于 ::/96
+			ip = network.IP.To4()
+			if ipVersion == 6 {
+				ip = append(make(net.IP, 12), ip...)
+				ones += 96
+			}
+		}
+		require.LessOrEqual(t, len(ip)*8, bitCount)
+
+		offset := data.Len()
+		encodeValue(t, &data, strings, networks[key])
+
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := int(ip[i/8]>>(7-uint(i%8))) & 1
+			if i == ones-1 {
+				nodes[node][bit] = -2 - offset
+				break
+			}
+			if nodes[node][bit] < 0 {
+				nodes = append(nodes, [2]int{-1, -1})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	var out bytes.Buffer
+	nodeCount := len(nodes)
+	for _, node := range nodes {
+		var records [2]uint32
+		for i, record := range node {
+			switch {
+			case record == -1:
+				records[i] = uint32(nodeCount)
+			case record < -1:
+				records[i] = uint32(nodeCount + 16 + (-2 - record))
+			default:
+				records[i] = uint32(record)
+			}
+		}
+		switch recordSize {
+		case 24:
+			out.Write([]byte{byte(records[0] >> 16), byte(records[0] >> 8), byte(records[0])})
+			out.Write([]byte{byte(records[1] >> 16), byte(records[1] >> 8), byte(records[1])})
+		case 28:
+			out.Write([]byte{byte(records[0] >> 16), byte(records[0] >> 8), byte(records[0])})
+			out.WriteByte(byte(records[0]>>20)&0xf0 | byte(records[1]>>24)&0x0f)
+			out.Write([]byte{byte(records[1] >> 16), byte(records[1] >> 8), byte(records[1])})
+		default:
+			_ = binary.Write(&out, binary.BigEndian, records)
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+	out.Write(metadataMarker)
+	encodeValue(t, &out, nil, map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+		"ip_version":                  uint16(ipVersion),
+		"database_type":               "Flow-Test",
+		"languages":                   []interface{}{"en", "zh-CN"},
+		"binary_format_major_version": uint16(2),
+		"build_epoch":                 uint64(1700000000),
+	})
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0o644))
+}
+
+// encodeValue 按 MaxMind DB 数据段格式编码值，strings 不为nil时重复的字符串写入指针
+func encodeValue(t *testing.T, buf *bytes.Buffer, strings map[string]int, value interface{}) {
+	control := func(kind int, size int) {
+		first := byte(kind << 5)
+		if kind > 7 {
+			first = 0
+		}
+		var extra []byte
+		switch {
+		case size < 29:
+			first |= byte(size)
+		case size < 285:
+			first |= 29
+			extra = []byte{byte(size - 29)}
+		default:
+			first |= 30
+			extra = []byte{byte((size - 285) >> 8), byte(size - 285)}
+		}
+		buf.WriteByte(first)
+		if kind > 7 {
+			buf.WriteByte(byte(kind - 7))
+		}
+		buf.Write(extra)
+	}
+	uintBytes := func(v uint64) []byte {
+		var b []byte
+		for ; v > 0; v >>= 8 {
+			b = append([]byte{byte(v)}, b...)
+		}
+		return b
+	}
+
+	switch v := value.(type) {
+	case string:
+		if offset, ok := strings[v]; ok && offset < 2048 {
+			buf.WriteByte(byte(typePointer<<5) | byte(offset>>8))
+			buf.WriteByte(byte(offset))
+			return
+		}
+		if strings != nil {
+			strings[v] = buf.Len()
+		}
+		control(typeString, len(v))
+		buf.WriteString(v)
+	case uint16:
+		b := uintBytes(uint64(v))
+		control(typeUint16, len(b))
+		buf.Write(b)
+	case uint32:
+		b := uintBytes(uint64(v))
+		control(typeUint32, len(b))
+		buf.Write(b)
+	case uint64:
+		b := uintBytes(v)
+		control(typeUint64, len(b))
+		buf.Write(b)
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		control(typeBool, size)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		control(typeMap, len(v))
+		for _, key := range keys {
+			encodeValue(t, buf, strings, key)
+			encodeValue(t, buf, strings, v[key])
+		}
+	case []interface{}:
+		control(typeArray, len(v))
+		for _, item := range v {
+			encodeValue(t, buf, strings, item)
+		}
+	default:
+		t.Fatalf("不支持的类型 %T", value)
+	}
+}
+
+func TestReader_Lookup(t *testing.T) {
+	for _, tc := range []struct {
+		ipVersion, recordSize int
+	}{{4, 24}, {6, 24}, {6, 28}, {6, 32}} {
+		path := filepath.Join(t.TempDir(), "test.mmdb")
+		networks := testNetworks("Shenzhen")
+		if tc.ipVersion == 4 {
+			delete(networks, "2001:db8::/32")
+		}
+		writeTestDB(t, path, tc.ipVersion, tc.recordSize, networks)
+
+		reader, err := Open(path)
+		require.NoError(t, err)
+		assert.Equal(t, "Flow-Test", reader.Metadata().DatabaseType)
+		assert.Equal(t, uint(tc.recordSize), reader.Metadata().RecordSize)
+		assert.Equal(t, []string{"en", "zh-CN"}, reader.Metadata().Languages)
+
+		record, err := reader.Lookup(net.ParseIP("1.2.3.4"))
+		require.NoError(t, err)
+		assert.Equal(t, flowctx.GeoInfo{Country: "CN", Region: "GD", City: "Shenzhen"}, geoFromRecord(record, "en"), tc)
+		assert.Equal(t, "深圳", geoFromRecord(record, "zh-CN").City)
+
+		record, err = reader.Lookup(net.ParseIP("8.8.8.8"))
+		require.NoError(t, err)
+		assert.Equal(t, flowctx.GeoInfo{Country: "US", ASN: 15169, ASOrg: "GOOGLE"}, geoFromRecord(record, "en"), tc)
+
+		// 未收录的地址
+		record, err = reader.Lookup(net.ParseIP("1.2.4.1"))
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		record, err = reader.Lookup(net.ParseIP("2001:db8::1"))
+		require.NoError(t, err)
+		if tc.ipVersion == 6 {
+			assert.Equal(t, "DE", geoFromRecord(record, "en").Country)
+		} else {
+			assert.Nil(t, record, "IPv4 数据库不收录 IPv6 地址")
+		}
+		require.NoError(t, reader.Close())
+	}
+
+	// 不是 MaxMind DB 的文件
+	path := filepath.Join(t.TempDir(), "bad.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o644))
+	_, err := Open(path)
+	assert.ErrorIs(t, err, ErrInvalidDatabase)
+}
+
+func TestFileResolver_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "City.mmdb")
+	writeTestDB(t, path, 6, 28, testNetworks("Shenzhen"))
+
+	reloaded := make(chan error, 4)
+	resolver, err := NewFileResolver(path, WithReloadDelay(10*time.Millisecond), WithOnReload(func(err error) {
+		reloaded <- err
+	}))
+	require.NoError(t, err)
+	defer resolver.Close()
+
+	geo, err := resolver.Lookup(net.ParseIP("1.2.3.4"))
+	require.NoError(t, err)
+	assert.Equal(t, "Shenzhen", geo.City)
+
+	// 写入临时文件后重命名替换
+	tmp := filepath.Join(dir, "City.mmdb.tmp")
+	writeTestDB(t, tmp, 6, 28, testNetworks("Guangzhou"))
+	require.NoError(t, os.Rename(tmp, path))
+	select {
+	case err := <-reloaded:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待重新加载超时")
+	}
+	geo, err = resolver.Lookup(net.ParseIP("1.2.3.4"))
+	require.NoError(t, err)
+	assert.Equal(t, "Guangzhou", geo.City)
+
+	// 新文件无效时继续使用原来的数据库
+	require.NoError(t, os.WriteFile(tmp, []byte("broken"), 0o644))
+	require.NoError(t, os.Rename(tmp, path))
+	select {
+	case err := <-reloaded:
+		assert.ErrorIs(t, err, ErrInvalidDatabase)
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待重新加载超时")
+	}
+	geo, err = resolver.Lookup(net.ParseIP("1.2.3.4"))
+	require.NoError(t, err)
+	assert.Equal(t, "Guangzhou", geo.City)
+}
+
+func TestFileResolver_MissingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "City.mmdb")
+	resolver, err := NewFileResolver(path, WithoutWatch())
+	require.NoError(t, err)
+	defer resolver.Close()
+
+	_, err = resolver.Lookup(net.ParseIP("1.2.3.4"))
+	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, ok := resolver.Metadata()
+	assert.False(t, ok)
+
+	// 文件出现后可以加载
+	writeTestDB(t, path, 4, 24, map[string]interface{}{"1.2.3.0/24": testNetworks("")["1.2.3.0/24"]})
+	require.NoError(t, resolver.Reload())
+	geo, err := resolver.Lookup(net.ParseIP("1.2.3.4"))
+	require.NoError(t, err)
+	assert.Equal(t, "CN", geo.Country)
+}
+
+func TestStaticAndChain(t *testing.T) {
+	static, err := Static(map[string]flowctx.GeoInfo{
+		"10.0.0.0/8": {Country: "CN"},
+		"10.1.2.3":   {Country: "CN", City: "Beijing"},
+	})
+	require.NoError(t, err)
+	geo, _ := static.Lookup(net.ParseIP("10.1.2.3"))
+	assert.Equal(t, "Beijing", geo.City)
+	geo, _ = static.Lookup(net.ParseIP("10.9.9.9"))
+	assert.Equal(t, flowctx.GeoInfo{Country: "CN"}, geo)
+
+	_, err = Static(map[string]flowctx.GeoInfo{"bad": {}})
+	assert.Error(t, err)
+
+	asn, _ := Static(map[string]flowctx.GeoInfo{"10.0.0.0/8": {Country: "US", ASN: 4134, ASOrg: "CHINANET"}})
+	geo, err = Chain(static, asn).Lookup(net.ParseIP("10.1.2.3"))
+	require.NoError(t, err)
+	assert.Equal(t, flowctx.GeoInfo{Country: "CN", City: "Beijing", ASN: 4134, ASOrg: "CHINANET"}, geo)
+
+	missing := ResolverFunc(func(net.IP) (flowctx.GeoInfo, error) { return flowctx.GeoInfo{}, ErrNoDatabase })
+	geo, err = Chain(missing, static).Lookup(net.ParseIP("10.9.9.9"))
+	require.NoError(t, err)
+	assert.Equal(t, "CN", geo.Country)
+	_, err = Chain(missing).Lookup(net.ParseIP("10.9.9.9"))
+	assert.ErrorIs(t, err, ErrNoDatabase)
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package geoip
+
+import "os"
+
+// mapFile 不支持内存映射的系统上将文件读入内存
+func mapFile(path string) ([]byte, func() error, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf, func() error { return nil }, nil
+}
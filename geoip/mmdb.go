@@ -0,0 +1,381 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+)
+
+// metadataMarker 元数据段的起始标记，位于文件末尾128KB以内
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// metadataMaxSize 元数据段的最大长度
+const metadataMaxSize = 128 * 1024
+
+// ErrInvalidDatabase 文件不是有效的 MaxMind DB
+var ErrInvalidDatabase = errors.New("无效的 MaxMind DB 文件")
+
+// Metadata MaxMind DB 的元数据
+type Metadata struct {
+	DatabaseType string
+	IPVersion    uint
+	NodeCount    uint
+	RecordSize   uint
+	BuildEpoch   uint64
+	Languages    []string
+}
+
+// Reader MaxMind DB（.mmdb）格式的读取器，可并发使用
+//
+// 只实现了查询所需的部分：搜索树与数据段的解码，支持 24、28、32 位记录与 IPv4、IPv6 数据库
+type Reader struct {
+	buf       []byte
+	data      []byte // 数据段
+	metadata  Metadata
+	ipv4Start uint // IPv6 数据库中 IPv4 地址（::/96）子树的起始节点
+	release   func() error
+}
+
+// newReader 解析数据库内容，release 在 Close 时调用
+func newReader(buf []byte, release func() error) (*Reader, error) {
+	start := bytes.LastIndex(buf[max(0, len(buf)-metadataMaxSize):], metadataMarker)
+	if start < 0 {
+		return nil, fmt.Errorf("%w: 找不到元数据", ErrInvalidDatabase)
+	}
+	start += max(0, len(buf)-metadataMaxSize) + len(metadataMarker)
+
+	d := decoder{buf: buf[start:]}
+	raw, _, err := d.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 元数据: %v", ErrInvalidDatabase, err)
+	}
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: 元数据不是 map", ErrInvalidDatabase)
+	}
+	metadata := Metadata{
+		DatabaseType: stringField(fields, "database_type"),
+		IPVersion:    uint(uintField(fields, "ip_version")),
+		NodeCount:    uint(uintField(fields, "node_count")),
+		RecordSize:   uint(uintField(fields, "record_size")),
+		BuildEpoch:   uintField(fields, "build_epoch"),
+	}
+	if languages, ok := fields["languages"].([]interface{}); ok {
+		for _, language := range languages {
+			if s, ok := language.(string); ok {
+				metadata.Languages = append(metadata.Languages, s)
+			}
+		}
+	}
+	switch metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("%w: 不支持的记录长度 %d", ErrInvalidDatabase, metadata.RecordSize)
+	}
+	if metadata.IPVersion != 4 && metadata.IPVersion != 6 {
+		return nil, fmt.Errorf("%w: 不支持的IP版本 %d", ErrInvalidDatabase, metadata.IPVersion)
+	}
+
+	treeSize := metadata.NodeCount * metadata.RecordSize / 4
+	dataStart := treeSize + 16
+	metadataStart := uint(start - len(metadataMarker))
+	if dataStart > metadataStart {
+		return nil, fmt.Errorf("%w: 搜索树超出文件长度", ErrInvalidDatabase)
+	}
+
+	r := &Reader{
+		buf:      buf,
+		data:     buf[dataStart:metadataStart],
+		metadata: metadata,
+		release:  release,
+	}
+	if metadata.IPVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < metadata.NodeCount; i++ {
+			if node, err = r.record(node, 0); err != nil {
+				return nil, err
+			}
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// Metadata 返回数据库的元数据
+func (r *Reader) Metadata() Metadata {
+	return r.metadata
+}
+
+// Close 释放数据库占用的内存映射，关闭后不能再查询
+func (r *Reader) Close() error {
+	if r.release == nil {
+		return nil
+	}
+	release := r.release
+	r.release = nil
+	return release()
+}
+
+// Lookup 查询IP所在网段的记录，返回解码后的值（map[string]interface{} 等），未收录时返回nil
+func (r *Reader) Lookup(ip net.IP) (interface{}, error) {
+	offset, err := r.find(ip)
+	if err != nil || offset == 0 {
+		return nil, err
+	}
+	d := decoder{buf: r.data}
+	value, _, err := d.decode(offset-r.metadata.NodeCount-16, 0)
+	return value, err
+}
+
+// find 遍历搜索树，返回记录值；未收录时返回0
+func (r *Reader) find(ip net.IP) (uint, error) {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	} else if ip = ip.To16(); ip == nil {
+		return 0, fmt.Errorf("无效的IP地址")
+	} else if r.metadata.IPVersion == 4 {
+		return 0, nil
+	}
+
+	node := uint(0)
+	if len(ip) == net.IPv4len && r.metadata.IPVersion == 6 {
+		node = r.ipv4Start
+	}
+	nodeCount := r.metadata.NodeCount
+	for i := 0; i < len(ip)*8 && node < nodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-uint(i&7))) & 1
+		next, err := r.record(node, bit)
+		if err != nil {
+			return 0, err
+		}
+		node = next
+	}
+	switch {
+	case node == nodeCount:
+		return 0, nil
+	case node > nodeCount:
+		return node, nil
+	default:
+		return 0, fmt.Errorf("%w: 搜索树深度超出地址长度", ErrInvalidDatabase)
+	}
+}
+
+// record 读取节点的左（bit=0）或右（bit=1）记录
+func (r *Reader) record(node, bit uint) (uint, error) {
+	size := r.metadata.RecordSize
+	offset := node * size / 4
+	if offset+size/4 > uint(len(r.buf)) {
+		return 0, fmt.Errorf("%w: 节点 %d 超出文件长度", ErrInvalidDatabase, node)
+	}
+	b := r.buf[offset : offset+size/4]
+	switch size {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6]), nil
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:])), nil
+	}
+}
+
+// 数据段的类型编号
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// maxDecodeDepth 嵌套的最大深度，防止损坏的文件导致无限递归
+const maxDecodeDepth = 64
+
+// decoder 数据段解码器，指针的偏移量相对于 buf 的起始位置
+type decoder struct {
+	buf []byte
+}
+
+// decode 解码 offset 处的值，返回值与下一个值的偏移量
+func (d *decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, fmt.Errorf("%w: 数据嵌套过深", ErrInvalidDatabase)
+	}
+	kind, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if kind == typePointer {
+		target, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target, depth+1)
+		return value, next, err
+	}
+
+	switch kind {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var key, value interface{}
+			if key, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: map 的键不是字符串", ErrInvalidDatabase)
+			}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			m[name] = value
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]interface{}, 0, min(size, 64))
+		for i := uint(0); i < size; i++ {
+			var value interface{}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeEndMarker, typeContainer:
+		return nil, offset, nil
+	}
+
+	if offset+size > uint(len(d.buf)) {
+		return nil, 0, fmt.Errorf("%w: 数据超出数据段", ErrInvalidDatabase)
+	}
+	b := d.buf[offset : offset+size]
+	next := offset + size
+	switch kind {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte(nil), b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("%w: double 长度为 %d", ErrInvalidDatabase, size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("%w: float 长度为 %d", ErrInvalidDatabase, size)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), next, nil
+	case typeInt32:
+		return int32(unsigned(b)), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		return unsigned(b), next, nil
+	case typeUint128:
+		// 数据库中的 uint128 很少见，保留原始字节
+		return append([]byte(nil), b...), next, nil
+	default:
+		return nil, 0, fmt.Errorf("%w: 未知的数据类型 %d", ErrInvalidDatabase, kind)
+	}
+}
+
+// control 解析控制字节，返回类型、长度与数据的偏移量
+func (d *decoder) control(offset uint) (int, uint, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, fmt.Errorf("%w: 偏移量超出数据段", ErrInvalidDatabase)
+	}
+	ctrl := d.buf[offset]
+	offset++
+	kind := int(ctrl >> 5)
+	if kind == typePointer {
+		// 指针的长度位由 pointer 解析
+		return kind, uint(ctrl & 0x1f), offset, nil
+	}
+	if kind == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, fmt.Errorf("%w: 偏移量超出数据段", ErrInvalidDatabase)
+		}
+		kind = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(d.buf)) {
+			return 0, 0, 0, fmt.Errorf("%w: 偏移量超出数据段", ErrInvalidDatabase)
+		}
+		extra := uint(unsigned(d.buf[offset : offset+n]))
+		offset += n
+		switch n {
+		case 1:
+			size = 29 + extra
+		case 2:
+			size = 285 + extra
+		default:
+			size = 65821 + extra
+		}
+	}
+	return kind, size, offset, nil
+}
+
+// pointer 解析指针，bits 为控制字节的低5位
+func (d *decoder) pointer(bits uint, offset uint) (uint, uint, error) {
+	n := (bits >> 3) + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, fmt.Errorf("%w: 偏移量超出数据段", ErrInvalidDatabase)
+	}
+	b := d.buf[offset : offset+n]
+	var target uint
+	switch n {
+	case 1:
+		target = (bits&0x7)<<8 | uint(b[0])
+	case 2:
+		target = ((bits&0x7)<<16 | uint(unsigned(b))) + 2048
+	case 3:
+		target = ((bits&0x7)<<24 | uint(unsigned(b))) + 526336
+	default:
+		target = uint(unsigned(b))
+	}
+	return target, offset + n, nil
+}
+
+// unsigned 将大端字节解码为无符号整数
+func unsigned(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+// stringField 读取 map 中的字符串字段
+func stringField(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// uintField 读取 map 中的无符号整数字段
+func uintField(m map[string]interface{}, key string) uint64 {
+	v, _ := m[key].(uint64)
+	return v
+}
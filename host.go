@@ -0,0 +1,336 @@
+package flow
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hostKey 请求匹配的主机模式在上下文中的键，未绑定主机的路由为空
+const hostKey = "_flow/host"
+
+// hostRoute 一个虚拟主机，拥有独立的路由树，同一路径可以在不同主机上注册
+type hostRoute struct {
+	pattern    string // 规范化后的主机模式
+	name       string // 主机名，通配符模式为 "*.example.com"
+	port       string // 端口，为空时匹配任意端口
+	engine     *gin.Engine
+	middleware []string // 创建时的全局中间件名称
+}
+
+// hostRouter 虚拟主机注册表
+type hostRouter struct {
+	mu       sync.RWMutex
+	hosts    []*hostRoute
+	noRoute  []gin.HandlerFunc // 通过 Engine.NoRoute 设置，同时作用于每个主机
+	noMethod []gin.HandlerFunc // 通过 Engine.NoMethod 设置，同时作用于每个主机
+	enabled  atomic.Bool       // 是否注册了主机，未注册时请求直接交给默认路由
+	strict   bool              // 未匹配任何主机的请求响应404
+	proxies  []*net.IPNet
+	trustFwd bool
+}
+
+// WithStrictHosts 返回一个选项：请求的主机不匹配任何 Host 注册的主机时响应404，而不是交给默认路由处理
+func WithStrictHosts() Option {
+	return func(e *Engine) {
+		e.hosts.mu.Lock()
+		defer e.hosts.mu.Unlock()
+		e.hosts.strict = true
+	}
+}
+
+// TrustForwardedHost 信任来自指定代理（IP 或 CIDR）的 X-Forwarded-Host 请求头，按其中的主机匹配 Host 路由
+//
+// 未调用时只使用请求的 Host；不传参数时不再信任任何代理
+func (e *Engine) TrustForwardedHost(proxies ...string) error {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("flow: 无效的代理地址: %s", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("flow: 无效的代理地址: %s", proxy)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	e.hosts.mu.Lock()
+	defer e.hosts.mu.Unlock()
+	e.hosts.proxies = nets
+	e.hosts.trustFwd = len(nets) > 0
+	return nil
+}
+
+// Host 创建只匹配指定主机的路由组，同一路径可以在不同主机上分别注册
+//
+// 主机模式支持：
+//
+//	admin.example.com       精确匹配，忽略请求的端口
+//	admin.example.com:8443  同时要求端口一致
+//	*.example.com           匹配任意子域名，不匹配 example.com 本身
+//
+// 多个模式都匹配时精确模式优先，通配符模式按后缀长度优先。每个主机只继承创建时已添加的全局中间件，
+// 之后通过 Engine.Use 添加的中间件不作用于已创建的主机（与 Group 一致，并记录警告）；
+// 通过 Engine.NoRoute 与 Engine.NoMethod 设置的处理函数作用于所有主机。
+// 未匹配任何主机的请求由直接注册在 Engine 上的路由处理，设置 WithStrictHosts 时响应404
+func (e *Engine) Host(pattern string, handlers ...HandlerFunc) *RouterGroup {
+	handlers, attrs := splitAttrs(handlers)
+	handlers, groups := splitGroups(handlers)
+	h := e.hostRoute(pattern)
+	named := e.nameWithGroups(h.middleware, groups, handlers)
+	ginGroup := h.engine.Group("/", wrapNamedHandlers(e, named)...)
+	return &RouterGroup{
+		RouterGroup: *ginGroup,
+		engine:      e,
+		host:        h.pattern,
+		middleware:  append(append([]string(nil), h.middleware...), handlerNames(named)...),
+		attrs:       attrs,
+	}
+}
+
+// hostRoute 返回主机模式对应的虚拟主机，不存在时创建
+func (e *Engine) hostRoute(pattern string) *hostRoute {
+	name, port := splitHost(pattern)
+	if name == "" || strings.Contains(strings.TrimPrefix(name, "*."), "*") {
+		panic(fmt.Sprintf("flow: 无效的主机模式: %q", pattern))
+	}
+	normalized := name
+	if port != "" {
+		normalized = net.JoinHostPort(name, port)
+	}
+
+	e.hosts.mu.Lock()
+	defer e.hosts.mu.Unlock()
+	for _, h := range e.hosts.hosts {
+		if h.pattern == normalized {
+			return h
+		}
+	}
+
+	engine := gin.New()
+	engine.RedirectTrailingSlash = e.Engine.RedirectTrailingSlash
+	engine.RedirectFixedPath = e.Engine.RedirectFixedPath
+	engine.HandleMethodNotAllowed = e.Engine.HandleMethodNotAllowed
+	engine.UseRawPath = e.Engine.UseRawPath
+	engine.UnescapePathValues = e.Engine.UnescapePathValues
+	engine.RemoveExtraSlash = e.Engine.RemoveExtraSlash
+	engine.MaxMultipartMemory = e.Engine.MaxMultipartMemory
+	engine.ContextWithFallback = e.Engine.ContextWithFallback
+	engine.HTMLRender = e.Engine.HTMLRender
+	// 先记录匹配的主机，全局中间件检查路由跳过时需要它
+	engine.Use(func(c *gin.Context) {
+		c.Set(hostKey, normalized)
+	})
+	engine.Use(e.Engine.RouterGroup.Handlers...)
+	if e.hosts.noRoute != nil {
+		engine.NoRoute(e.hosts.noRoute...)
+	}
+	if e.hosts.noMethod != nil {
+		engine.NoMethod(e.hosts.noMethod...)
+	}
+
+	h := &hostRoute{
+		pattern:    normalized,
+		name:       name,
+		port:       port,
+		engine:     engine,
+		middleware: append([]string(nil), e.middleware...),
+	}
+	e.hosts.hosts = append(e.hosts.hosts, h)
+	e.hosts.enabled.Store(true)
+	return h
+}
+
+// NoRoute 设置未匹配任何路由时的处理函数，同时作用于默认路由与 Host 创建的每个主机
+func (e *Engine) NoRoute(handlers ...gin.HandlerFunc) {
+	e.hosts.mu.Lock()
+	defer e.hosts.mu.Unlock()
+	e.hosts.noRoute = handlers
+	e.Engine.NoRoute(handlers...)
+	for _, h := range e.hosts.hosts {
+		h.engine.NoRoute(handlers...)
+	}
+}
+
+// NoMethod 设置路径存在但方法不允许时的处理函数，同时作用于默认路由与 Host 创建的每个主机
+func (e *Engine) NoMethod(handlers ...gin.HandlerFunc) {
+	e.hosts.mu.Lock()
+	defer e.hosts.mu.Unlock()
+	e.hosts.noMethod = handlers
+	e.Engine.NoMethod(handlers...)
+	for _, h := range e.hosts.hosts {
+		h.engine.NoMethod(handlers...)
+	}
+}
+
+// warnLateMiddleware 已创建主机后添加全局中间件时发出警告，这些中间件不作用于已创建的主机
+func (e *Engine) warnLateMiddleware(names []string) {
+	e.hosts.mu.RLock()
+	defer e.hosts.mu.RUnlock()
+	if len(e.hosts.hosts) == 0 {
+		return
+	}
+	patterns := make([]string, len(e.hosts.hosts))
+	for i, h := range e.hosts.hosts {
+		patterns[i] = h.pattern
+	}
+	flog.Warnf("全局中间件 %s 在 Host 之后添加，不作用于已创建的主机: %s；请在调用 Host 之前调用 Use",
+		strings.Join(names, ", "), strings.Join(patterns, ", "))
+}
+
+// ServeHTTP 处理请求：启动完成前响应503，匹配 Host 注册的主机时交给该主机的路由，否则交给默认路由
+func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if e.serveGate(w, r) {
+		return
+	}
+	if !e.hosts.enabled.Load() {
+		e.Engine.ServeHTTP(w, r)
+		return
+	}
+	h, strict := e.hosts.match(r)
+	switch {
+	case h != nil:
+		h.engine.ServeHTTP(w, r)
+	case strict:
+		http.NotFound(w, r)
+	default:
+		e.Engine.ServeHTTP(w, r)
+	}
+}
+
+// match 返回请求匹配的虚拟主机
+func (hr *hostRouter) match(r *http.Request) (*hostRoute, bool) {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	name, port := splitHost(hr.requestHost(r))
+	var best *hostRoute
+	bestRank := -1
+	for _, h := range hr.hosts {
+		if h.port != "" && h.port != port {
+			continue
+		}
+		var rank int
+		if suffix, ok := strings.CutPrefix(h.name, "*"); ok {
+			if len(name) <= len(suffix) || !strings.HasSuffix(name, suffix) {
+				continue
+			}
+			rank = 2 * len(suffix)
+		} else {
+			if h.name != name {
+				continue
+			}
+			rank = 1 << 20
+		}
+		if h.port != "" {
+			rank++
+		}
+		if rank > bestRank {
+			best, bestRank = h, rank
+		}
+	}
+	return best, hr.strict
+}
+
+// requestHost 返回用于匹配的主机，请求来自受信任代理时使用 X-Forwarded-Host 中的第一个主机
+func (hr *hostRouter) requestHost(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" && hr.fromTrustedProxy(r) {
+		return firstForwarded(forwarded)
+	}
+	return r.Host
+}
+
+// requestScheme 返回请求的协议，请求来自受信任代理时使用 X-Forwarded-Proto
+func (hr *hostRouter) requestScheme(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-Proto"); forwarded != "" && hr.fromTrustedProxy(r) {
+		return strings.ToLower(firstForwarded(forwarded))
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// fromTrustedProxy 判断请求是否来自 TrustForwardedHost 配置的代理
+func (hr *hostRouter) fromTrustedProxy(r *http.Request) bool {
+	if !hr.trustFwd {
+		return false
+	}
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	ip := net.ParseIP(remote)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range hr.proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstForwarded 返回转发请求头中的第一个值
+func firstForwarded(value string) string {
+	first, _, _ := strings.Cut(value, ",")
+	return strings.TrimSpace(first)
+}
+
+// ExternalURL 返回请求的外部访问地址（协议、主机与路径），用于生成绝对链接
+//
+// 设置了 WithBaseURL 时使用该地址作为前缀；否则使用请求的协议与主机，
+// 请求来自 TrustForwardedHost 信任的代理时使用 X-Forwarded-Proto 与 X-Forwarded-Host
+func (e *Engine) ExternalURL(r *http.Request) *url.URL {
+	if base := e.config.BaseURL; base != "" {
+		if u, err := url.Parse(base); err == nil {
+			u.Path = strings.TrimSuffix(u.Path, "/") + r.URL.Path
+			u.RawPath = ""
+			u.RawQuery = r.URL.RawQuery
+			return u
+		}
+	}
+
+	e.hosts.mu.RLock()
+	scheme, host := e.hosts.requestScheme(r), e.hosts.requestHost(r)
+	e.hosts.mu.RUnlock()
+	return &url.URL{
+		Scheme:   scheme,
+		Host:     host,
+		Path:     r.URL.Path,
+		RawPath:  r.URL.RawPath,
+		RawQuery: r.URL.RawQuery,
+	}
+}
+
+// splitHost 拆分并规范化主机与端口，主机名转为小写并去掉末尾的点
+func splitHost(host string) (string, string) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = strings.Trim(host, "[]"), ""
+	}
+	return strings.TrimSuffix(name, "."), port
+}
+
+// requestHostKey 返回当前请求匹配的主机模式
+func requestHostKey(c *gin.Context) string {
+	return c.GetString(hostKey)
+}
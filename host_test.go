@@ -0,0 +1,198 @@
+package flow
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveHost 以指定主机发起请求
+func serveHost(e *Engine, host, path string, configure ...func(*http.Request)) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Host = host
+	for _, fn := range configure {
+		fn(req)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+// reply 返回响应固定内容的处理函数
+func reply(body string) HandlerFunc {
+	return func(c *Context) {
+		c.String(http.StatusOK, body)
+	}
+}
+
+func TestHost_ExactAndWildcard(t *testing.T) {
+	e := newRouteTestEngine()
+	e.GET("/", reply("default"))
+	e.Host("admin.example.com").GET("/", reply("admin"))
+	e.Host("*.example.com").GET("/", reply("tenant"))
+	e.Host("*.eu.example.com").GET("/", reply("eu"))
+	e.Host("api.example.com:8443").GET("/", reply("api-tls"))
+
+	cases := map[string]string{
+		"admin.example.com":      "admin",
+		"ADMIN.example.com:8080": "admin", // 模式不含端口时忽略端口，主机名不区分大小写
+		"acme.example.com":       "tenant",
+		"a.b.example.com":        "tenant",
+		"shop.eu.example.com":    "eu", // 更长的通配后缀优先
+		"api.example.com:8443":   "api-tls",
+		"api.example.com":        "tenant",
+		"example.com":            "default", // 通配符不匹配根域名
+		"other.test":             "default",
+	}
+	for host, want := range cases {
+		code, body := serveHost(e, host, "/")
+		assert.Equal(t, http.StatusOK, code, host)
+		assert.Equal(t, want, body, host)
+	}
+
+	// 主机内没有的路径不会回退到默认路由
+	e.GET("/only-default", reply("default"))
+	code, _ := serveHost(e, "admin.example.com", "/only-default")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestHost_MiddlewareIsolation(t *testing.T) {
+	e := newRouteTestEngine()
+	var trace []string
+	e.UseNamed("logger", recordMiddleware("logger", &trace))
+
+	admin := e.Host("admin.example.com", recordMiddleware("admin-auth", &trace))
+	admin.GET("/users", reply("admin users"))
+	api := e.Host("api.example.com")
+	api.UseNamed("throttle", recordMiddleware("throttle", &trace))
+	api.GET("/users", reply("api users")).Without("logger")
+
+	_, body := serveHost(e, "admin.example.com", "/users")
+	assert.Equal(t, "admin users", body)
+	assert.Equal(t, []string{"logger", "admin-auth"}, trace)
+
+	trace = nil
+	_, body = serveHost(e, "api.example.com", "/users")
+	assert.Equal(t, "api users", body)
+	assert.Equal(t, []string{"throttle"}, trace, "跳过的全局中间件只对该主机的路由生效")
+
+	assert.Equal(t, []string{"recovery", "logger", "record"}, e.HostMiddlewareChain("admin.example.com", http.MethodGet, "/users"))
+	assert.Equal(t, []string{"recovery", "throttle"}, e.HostMiddlewareChain("api.example.com", http.MethodGet, "/users"))
+	assert.Nil(t, e.MiddlewareChain(http.MethodGet, "/users"), "默认主机没有该路由")
+}
+
+func TestHost_StrictFallback(t *testing.T) {
+	e := New(WithStrictHosts())
+	e.GET("/", reply("default"))
+
+	// 没有注册主机时不影响默认路由
+	code, _ := serveHost(e, "anything.test", "/")
+	assert.Equal(t, http.StatusOK, code)
+
+	e.Host("admin.example.com").GET("/", reply("admin"))
+	code, _ = serveHost(e, "anything.test", "/")
+	assert.Equal(t, http.StatusNotFound, code)
+	_, body := serveHost(e, "admin.example.com", "/")
+	assert.Equal(t, "admin", body)
+}
+
+func TestHost_RouteList(t *testing.T) {
+	e := newRouteTestEngine()
+	e.GET("/status", reply("default"))
+	admin := e.Host("Admin.Example.com").Group("/v1", WithAttr(testLimit(5)))
+	admin.GET("/status", reply("admin"))
+
+	routes := e.RouteList()
+	require.Len(t, routes, 2)
+	assert.Equal(t, "", routes[0].Host)
+	assert.Equal(t, "/status", routes[0].Path)
+	assert.Equal(t, "admin.example.com", routes[1].Host)
+	assert.Equal(t, "/v1/status", routes[1].Path)
+	assert.Contains(t, routes[1].Attrs, "flow.testLimit")
+
+	// 路由属性按主机查找
+	e.Host("admin.example.com").GET("/attr", WithAttr(testLimit(5)), func(c *Context) {
+		_, ok := AttrFrom[testLimit](c)
+		c.String(http.StatusOK, "%v", ok)
+	})
+	_, body := serveHost(e, "admin.example.com", "/attr")
+	assert.Equal(t, "true", body)
+}
+
+func TestHost_ForwardedHost(t *testing.T) {
+	e := newRouteTestEngine()
+	e.GET("/", reply("default"))
+	e.Host("admin.example.com").GET("/", reply("admin"))
+	forwarded := func(remote string) func(*http.Request) {
+		return func(r *http.Request) {
+			r.RemoteAddr = remote
+			r.Header.Set("X-Forwarded-Host", "admin.example.com, proxy.internal")
+		}
+	}
+
+	// 未配置时忽略 X-Forwarded-Host
+	_, body := serveHost(e, "lb.internal", "/", forwarded("10.0.0.5:1234"))
+	assert.Equal(t, "default", body)
+
+	require.NoError(t, e.TrustForwardedHost("10.0.0.0/8", "192.168.1.1"))
+	_, body = serveHost(e, "lb.internal", "/", forwarded("10.0.0.5:1234"))
+	assert.Equal(t, "admin", body)
+	_, body = serveHost(e, "lb.internal", "/", forwarded("192.168.1.1:80"))
+	assert.Equal(t, "admin", body)
+
+	// 不受信任的来源不能伪造主机
+	_, body = serveHost(e, "lb.internal", "/", forwarded("203.0.113.9:1234"))
+	assert.Equal(t, "default", body)
+
+	assert.Error(t, e.TrustForwardedHost("not-an-ip"))
+}
+
+func TestHost_InvalidPattern(t *testing.T) {
+	e := newRouteTestEngine()
+	assert.Panics(t, func() { e.Host("") })
+	assert.Panics(t, func() { e.Host("admin.*.com") })
+}
+
+func TestHost_NoRouteAndNoMethod(t *testing.T) {
+	e := newRouteTestEngine()
+	e.HandleMethodNotAllowed = true
+	e.Host("admin.example.com").POST("/users", reply("admin"))
+
+	// 设置在 Host 之前或之后都作用于所有主机
+	e.NoRoute(func(c *gin.Context) { c.String(http.StatusNotFound, "custom 404") })
+	e.NoMethod(func(c *gin.Context) { c.String(http.StatusMethodNotAllowed, "custom 405") })
+	e.Host("api.example.com").GET("/", reply("api"))
+
+	for _, host := range []string{"admin.example.com", "api.example.com", "other.test"} {
+		code, body := serveHost(e, host, "/missing")
+		assert.Equal(t, http.StatusNotFound, code, host)
+		assert.Equal(t, "custom 404", body, host)
+	}
+	code, body := serveHost(e, "admin.example.com", "/users")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+	assert.Equal(t, "custom 405", body)
+}
+
+func TestHost_UseAfterHostWarns(t *testing.T) {
+	var warnings []string
+	warnf := flog.Warnf
+	flog.Warnf = func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+	defer func() { flog.Warnf = warnf }()
+
+	e := newRouteTestEngine()
+	e.UseNamed("before", func(c *Context) { c.Next() })
+	assert.Empty(t, warnings)
+
+	e.Host("admin.example.com")
+	e.UseNamed("late", func(c *Context) { c.Next() })
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "late")
+	assert.Contains(t, warnings[0], "admin.example.com")
+}
@@ -0,0 +1,181 @@
+// Package id 提供按时间排序的 ULID 与 UUIDv7 生成器
+//
+// 同一毫秒内生成的ID单调递增，字符串形式的字典序与生成顺序一致，适合作为数据库主键与对外暴露的资源ID
+package id
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrMonotonicOverflow 同一毫秒内的随机部分已用尽
+var ErrMonotonicOverflow = errors.New("同一毫秒内生成的ID数量超过上限")
+
+// Generator ID生成器，并发安全
+type Generator struct {
+	mu      sync.Mutex
+	entropy io.Reader
+	now     func() time.Time
+
+	// ULID 状态：上次的毫秒时间戳与80位随机部分
+	ulidMs   uint64
+	ulidHigh uint16
+	ulidLow  uint64
+
+	// UUIDv7 状态：上次的毫秒时间戳与74位随机部分（rand_a 12位 + rand_b 62位）
+	uuidMs uint64
+	uuidA  uint16
+	uuidB  uint64
+}
+
+// Option 生成器配置选项
+type Option func(*Generator)
+
+// WithEntropy 设置随机源，默认为 crypto/rand；测试中可传入固定种子的随机源以得到可复现的ID
+func WithEntropy(r io.Reader) Option {
+	return func(g *Generator) {
+		g.entropy = r
+	}
+}
+
+// WithClock 设置时间源
+func WithClock(now func() time.Time) Option {
+	return func(g *Generator) {
+		g.now = now
+	}
+}
+
+// NewGenerator 创建ID生成器
+func NewGenerator(opts ...Option) *Generator {
+	g := &Generator{
+		entropy: rand.Reader,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// ULID 生成单调递增的ULID
+//
+// 同一毫秒内在上一个ID的随机部分上加一；时钟回拨时沿用上一个时间戳，保证不会生成更小的ID
+func (g *Generator) ULID() (ULID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms > g.ulidMs {
+		var buf [10]byte
+		if _, err := io.ReadFull(g.entropy, buf[:]); err != nil {
+			return ULID{}, err
+		}
+		g.ulidMs = ms
+		g.ulidHigh = binary.BigEndian.Uint16(buf[:2])
+		g.ulidLow = binary.BigEndian.Uint64(buf[2:])
+	} else {
+		g.ulidLow++
+		if g.ulidLow == 0 {
+			g.ulidHigh++
+			if g.ulidHigh == 0 {
+				return ULID{}, ErrMonotonicOverflow
+			}
+		}
+	}
+
+	var u ULID
+	putUint48(u[:6], g.ulidMs)
+	binary.BigEndian.PutUint16(u[6:8], g.ulidHigh)
+	binary.BigEndian.PutUint64(u[8:], g.ulidLow)
+	return u, nil
+}
+
+// UUID 生成单调递增的UUIDv7（RFC 9562）
+//
+// 同一毫秒内把 rand_a 与 rand_b 视为一个74位计数器加一，溢出时借用下一毫秒的时间戳
+func (g *Generator) UUID() (UUID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms > g.uuidMs {
+		var buf [10]byte
+		if _, err := io.ReadFull(g.entropy, buf[:]); err != nil {
+			return UUID{}, err
+		}
+		g.uuidMs = ms
+		// rand_a 最高位保持为0，为同一毫秒内的递增预留空间
+		g.uuidA = binary.BigEndian.Uint16(buf[:2]) & 0x07ff
+		g.uuidB = binary.BigEndian.Uint64(buf[2:]) & (1<<62 - 1)
+	} else {
+		g.uuidB = (g.uuidB + 1) & (1<<62 - 1)
+		if g.uuidB == 0 {
+			g.uuidA = (g.uuidA + 1) & 0x0fff
+			if g.uuidA == 0 {
+				g.uuidMs++
+			}
+		}
+	}
+
+	var u UUID
+	putUint48(u[:6], g.uuidMs)
+	binary.BigEndian.PutUint16(u[6:8], 0x7000|g.uuidA)
+	binary.BigEndian.PutUint64(u[8:], 0x8000000000000000|g.uuidB)
+	return u, nil
+}
+
+// putUint48 以大端序写入48位整数
+func putUint48(b []byte, v uint64) {
+	b[0] = byte(v >> 40)
+	b[1] = byte(v >> 32)
+	b[2] = byte(v >> 24)
+	b[3] = byte(v >> 16)
+	b[4] = byte(v >> 8)
+	b[5] = byte(v)
+}
+
+// uint48 以大端序读取48位整数
+func uint48(b []byte) uint64 {
+	return uint64(b[0])<<40 | uint64(b[1])<<32 | uint64(b[2])<<24 |
+		uint64(b[3])<<16 | uint64(b[4])<<8 | uint64(b[5])
+}
+
+var defaultGenerator = NewGenerator()
+
+// SetDefault 替换包级函数使用的生成器，通常只在测试中使用
+func SetDefault(g *Generator) {
+	defaultGenerator = g
+}
+
+// New 使用默认生成器生成ULID，随机源读取失败时panic
+func New() ULID {
+	u, err := defaultGenerator.ULID()
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// NewUUID 使用默认生成器生成UUIDv7，随机源读取失败时panic
+func NewUUID() UUID {
+	u, err := defaultGenerator.UUID()
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// NewString 生成ULID的字符串形式
+func NewString() string {
+	return New().String()
+}
+
+// NewBinary 生成ULID的16字节二进制形式
+func NewBinary() []byte {
+	u := New()
+	return u[:]
+}
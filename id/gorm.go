@@ -0,0 +1,111 @@
+package id
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/gorm"
+)
+
+// Model 以ULID为主键的基础模型，创建时自动生成ID
+type Model struct {
+	ID        ULID      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate 未设置ID时生成ULID
+func (m *Model) BeforeCreate(tx *gorm.DB) error {
+	if m.ID.IsZero() {
+		u, err := defaultGenerator.ULID()
+		if err != nil {
+			return err
+		}
+		m.ID = u
+	}
+	return nil
+}
+
+// UUIDModel 以UUIDv7为主键的基础模型，创建时自动生成ID
+type UUIDModel struct {
+	ID        UUID      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate 未设置ID时生成UUIDv7
+func (m *UUIDModel) BeforeCreate(tx *gorm.DB) error {
+	if m.ID.IsZero() {
+		u, err := defaultGenerator.UUID()
+		if err != nil {
+			return err
+		}
+		m.ID = u
+	}
+	return nil
+}
+
+// PublicID 为仍使用自增主键的表增加对外暴露的ULID列，嵌入到模型中使用
+//
+//	type User struct {
+//		db.Model
+//		id.PublicID
+//		Name string
+//	}
+type PublicID struct {
+	PublicID ULID `gorm:"column:public_id;uniqueIndex" json:"public_id"`
+}
+
+// BeforeCreate 未设置时生成公开ID
+func (p *PublicID) BeforeCreate(tx *gorm.DB) error {
+	if p.PublicID.IsZero() {
+		u, err := defaultGenerator.ULID()
+		if err != nil {
+			return err
+		}
+		p.PublicID = u
+	}
+	return nil
+}
+
+// PublicIDMigration 创建为已有表增加 public_id 列的迁移：添加列、为已有记录回填ID并创建唯一索引
+//
+// 回填按主键顺序进行，已有记录的ID按主键顺序递增
+func PublicIDMigration(migrationID, table string) db.Migration {
+	index := fmt.Sprintf("idx_%s_public_id", table)
+
+	up := func(tx *gorm.DB) error {
+		if !tx.Migrator().HasColumn(table, "public_id") {
+			if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN public_id char(26)", tx.Statement.Quote(table))).Error; err != nil {
+				return err
+			}
+		}
+
+		var ids []uint64
+		if err := tx.Table(table).Where("public_id IS NULL").Order("id").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		for _, pk := range ids {
+			u, err := defaultGenerator.ULID()
+			if err != nil {
+				return err
+			}
+			if err := tx.Table(table).Where("id = ?", pk).Update("public_id", u.String()).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (public_id)",
+			tx.Statement.Quote(index), tx.Statement.Quote(table))).Error
+	}
+
+	down := func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(table, index); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(table, "public_id")
+	}
+
+	return db.NewMigration(migrationID, "add_public_id_to_"+table, up, down)
+}
@@ -0,0 +1,249 @@
+package id
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestULID_OrderingAndUniqueness(t *testing.T) {
+	n := 1_000_000
+	if testing.Short() {
+		n = 10_000
+	}
+
+	g := NewGenerator()
+	ids := make([]string, n)
+	seen := make(map[ULID]struct{}, n)
+	for i := range ids {
+		u, err := g.ULID()
+		require.NoError(t, err)
+		seen[u] = struct{}{}
+		ids[i] = u.String()
+	}
+
+	assert.Len(t, seen, n)
+	// 字典序与生成顺序一致
+	assert.True(t, sort.StringsAreSorted(ids))
+}
+
+func TestUUID_OrderingAndFormat(t *testing.T) {
+	n := 1_000_000
+	if testing.Short() {
+		n = 10_000
+	}
+
+	g := NewGenerator()
+	ids := make([]string, n)
+	for i := range ids {
+		u, err := g.UUID()
+		require.NoError(t, err)
+		ids[i] = u.String()
+	}
+	assert.True(t, sort.StringsAreSorted(ids))
+
+	parsed, err := uuid.Parse(ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), parsed.Version())
+	assert.Equal(t, uuid.RFC4122, parsed.Variant())
+}
+
+func TestGenerator_Concurrent(t *testing.T) {
+	g := NewGenerator()
+	var mu sync.Mutex
+	seen := make(map[ULID]struct{})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local := make([]ULID, 0, 1000)
+			for i := 0; i < 1000; i++ {
+				u, err := g.ULID()
+				assert.NoError(t, err)
+				local = append(local, u)
+			}
+			mu.Lock()
+			for _, u := range local {
+				seen[u] = struct{}{}
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, 8000)
+}
+
+func TestGenerator_DeterministicAndClockSkew(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	newGen := func() *Generator {
+		return NewGenerator(
+			WithEntropy(rand.New(rand.NewSource(42))),
+			WithClock(func() time.Time { return now }),
+		)
+	}
+
+	a, b := newGen(), newGen()
+	first, _ := a.ULID()
+	same, _ := b.ULID()
+	assert.Equal(t, first, same, "相同的随机源应生成相同的ID")
+	assert.Equal(t, now, first.Time())
+
+	// 时钟回拨时仍然递增
+	now = now.Add(-time.Second)
+	next, err := a.ULID()
+	require.NoError(t, err)
+	assert.Greater(t, next.String(), first.String())
+}
+
+func TestParse(t *testing.T) {
+	u := New()
+	parsed, err := ParseULID(u.String())
+	require.NoError(t, err)
+	assert.Equal(t, u, parsed)
+
+	lower, err := ParseULID(string(bytes.ToLower([]byte(u.String()))))
+	require.NoError(t, err)
+	assert.Equal(t, u, lower)
+
+	for _, bad := range []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FA", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "81ARZ3NDEKTSV4RRFFQ69G5FAV"} {
+		_, err := ParseULID(bad)
+		assert.ErrorIs(t, err, ErrInvalidID, bad)
+	}
+
+	v := NewUUID()
+	parsedUUID, err := ParseUUID(v.String())
+	require.NoError(t, err)
+	assert.Equal(t, v, parsedUUID)
+	_, err = ParseUUID("not-a-uuid")
+	assert.ErrorIs(t, err, ErrInvalidID)
+}
+
+func TestJSON(t *testing.T) {
+	type resource struct {
+		ID   ULID `json:"id"`
+		Ref  UUID `json:"ref"`
+		Name string
+	}
+
+	in := resource{ID: New(), Ref: NewUUID(), Name: "a"}
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"id":"`+in.ID.String()+`"`)
+	assert.Contains(t, string(data), `"ref":"`+in.Ref.String()+`"`)
+
+	var out resource
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"id":"bad"}`), &out))
+}
+
+type idUser struct {
+	Model
+	Name string
+}
+
+type legacyUser struct {
+	db.Model
+	Name string
+}
+
+func TestGorm(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&idUser{}, &legacyUser{}))
+
+	// BeforeCreate 生成主键
+	user := idUser{Name: "alice"}
+	require.NoError(t, conn.Create(&user).Error)
+	assert.False(t, user.ID.IsZero())
+
+	var loaded idUser
+	require.NoError(t, conn.First(&loaded, "id = ?", user.ID).Error)
+	assert.Equal(t, user.ID, loaded.ID)
+
+	// 自增主键的旧表通过迁移增加 public_id
+	require.NoError(t, conn.Create(&[]legacyUser{{Name: "a"}, {Name: "b"}}).Error)
+	migrator := db.NewMigrator(conn, "")
+	require.NoError(t, migrator.Register(PublicIDMigration("20240101000000", "legacy_users")))
+	require.NoError(t, migrator.Migrate())
+
+	var publicIDs []string
+	require.NoError(t, conn.Table("legacy_users").Order("id").Pluck("public_id", &publicIDs).Error)
+	require.Len(t, publicIDs, 2)
+	assert.Less(t, publicIDs[0], publicIDs[1])
+	assert.True(t, conn.Migrator().HasIndex("legacy_users", "idx_legacy_users_public_id"))
+
+	// 唯一索引生效
+	err = conn.Exec("UPDATE legacy_users SET public_id = ? WHERE id = 2", publicIDs[0]).Error
+	assert.Error(t, err)
+}
+
+func TestParam(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := flow.New()
+	e.GET("/users/:id", func(c *flow.Context) {
+		u, ok := Param(c, "id")
+		if !ok {
+			return
+		}
+		c.String(http.StatusOK, u.String())
+	})
+	e.GET("/orders/:id", func(c *flow.Context) {
+		if _, ok := ParamUUID(c, "id", NotFoundOnMalformed()); !ok {
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	valid := New().String()
+	w := serve("/users/" + valid)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, valid, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, serve("/users/42").Code)
+	assert.Equal(t, http.StatusNotFound, serve("/orders/42").Code)
+	assert.Equal(t, http.StatusOK, serve("/orders/"+NewUUID().String()).Code)
+}
+
+func BenchmarkNewULID(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = New()
+	}
+}
+
+func BenchmarkNewUUIDv7(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = NewUUID()
+	}
+}
+
+func BenchmarkGoogleUUIDv4(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = uuid.New()
+	}
+}
@@ -0,0 +1,61 @@
+package id
+
+import (
+	"net/http"
+
+	"github.com/zzliekkas/flow/v2"
+)
+
+// paramOptions 路由参数解析选项
+type paramOptions struct {
+	notFound bool
+}
+
+// ParamOption 路由参数解析选项
+type ParamOption func(*paramOptions)
+
+// NotFoundOnMalformed 格式错误时响应404而不是400，避免暴露资源ID的格式
+func NotFoundOnMalformed() ParamOption {
+	return func(o *paramOptions) {
+		o.notFound = true
+	}
+}
+
+// Param 从路由参数解析ULID，格式错误时中止请求并返回false
+//
+//	userID, ok := id.Param(c, "id")
+//	if !ok {
+//		return
+//	}
+func Param(c *flow.Context, name string, opts ...ParamOption) (ULID, bool) {
+	u, err := ParseULID(c.Param(name))
+	if err != nil {
+		abortMalformed(c, name, opts)
+		return ULID{}, false
+	}
+	return u, true
+}
+
+// ParamUUID 从路由参数解析UUID，格式错误时中止请求并返回false
+func ParamUUID(c *flow.Context, name string, opts ...ParamOption) (UUID, bool) {
+	u, err := ParseUUID(c.Param(name))
+	if err != nil {
+		abortMalformed(c, name, opts)
+		return UUID{}, false
+	}
+	return u, true
+}
+
+// abortMalformed 按选项响应格式错误
+func abortMalformed(c *flow.Context, name string, opts []ParamOption) {
+	options := paramOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.notFound {
+		c.AbortWithStatusJSON(http.StatusNotFound, flow.H{"error": "资源不存在"})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusBadRequest, flow.H{"error": "无效的ID参数: " + name})
+}
@@ -0,0 +1,149 @@
+package id
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidID ID格式无效
+var ErrInvalidID = errors.New("无效的ID")
+
+// crockford Crockford Base32 字母表
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// crockfordDecode 解码表，兼容小写与易混淆字符（I、L→1，O→0）
+var crockfordDecode [256]byte
+
+func init() {
+	for i := range crockfordDecode {
+		crockfordDecode[i] = 0xff
+	}
+	for i := 0; i < len(crockford); i++ {
+		c := crockford[i]
+		crockfordDecode[c] = byte(i)
+		if c >= 'A' && c <= 'Z' {
+			crockfordDecode[c+'a'-'A'] = byte(i)
+		}
+	}
+	for _, c := range []byte{'I', 'i', 'L', 'l'} {
+		crockfordDecode[c] = 1
+	}
+	crockfordDecode['O'], crockfordDecode['o'] = 0, 0
+}
+
+// ULID 128位按时间排序的标识符，前48位为毫秒时间戳，后80位为随机数
+type ULID [16]byte
+
+// ParseULID 解析26位 Crockford Base32 字符串
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 {
+		return u, fmt.Errorf("%w: ULID 长度应为26，实际为%d", ErrInvalidID, len(s))
+	}
+	// 首字符最大为7，否则超出128位
+	if crockfordDecode[s[0]] > 7 {
+		return u, fmt.Errorf("%w: ULID 超出范围", ErrInvalidID)
+	}
+
+	var hi, lo uint64
+	for i := 0; i < 26; i++ {
+		v := crockfordDecode[s[i]]
+		if v == 0xff {
+			return u, fmt.Errorf("%w: ULID 包含非法字符 %q", ErrInvalidID, s[i])
+		}
+		// 128位左移5位
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	for i := 0; i < 8; i++ {
+		u[i] = byte(hi >> (56 - 8*i))
+		u[8+i] = byte(lo >> (56 - 8*i))
+	}
+	return u, nil
+}
+
+// MustParseULID 解析ULID，失败时panic
+func MustParseULID(s string) ULID {
+	u, err := ParseULID(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// String 返回26位 Crockford Base32 字符串
+func (u ULID) String() string {
+	var hi, lo uint64
+	for i := 0; i < 8; i++ {
+		hi = hi<<8 | uint64(u[i])
+		lo = lo<<8 | uint64(u[8+i])
+	}
+
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		// 128位右移5位
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// Time 返回ULID中的时间戳
+func (u ULID) Time() time.Time {
+	return time.UnixMilli(int64(uint48(u[:6])))
+}
+
+// IsZero 判断是否为零值
+func (u ULID) IsZero() bool {
+	return u == ULID{}
+}
+
+// MarshalText 以字符串形式编码，JSON中同样输出字符串
+func (u ULID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText 从字符串解码
+func (u *ULID) UnmarshalText(data []byte) error {
+	parsed, err := ParseULID(string(data))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// Value 以字符串形式写入数据库，零值写入NULL
+func (u ULID) Value() (driver.Value, error) {
+	if u.IsZero() {
+		return nil, nil
+	}
+	return u.String(), nil
+}
+
+// Scan 从数据库读取，支持字符串与16字节二进制
+func (u *ULID) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*u = ULID{}
+		return nil
+	case string:
+		return u.UnmarshalText([]byte(v))
+	case []byte:
+		if len(v) == 16 {
+			copy(u[:], v)
+			return nil
+		}
+		return u.UnmarshalText(v)
+	default:
+		return fmt.Errorf("%w: 无法将 %T 转换为ULID", ErrInvalidID, value)
+	}
+}
+
+// GormDataType gorm 建表时使用的列类型
+func (ULID) GormDataType() string {
+	return "char(26)"
+}
@@ -0,0 +1,114 @@
+package id
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// UUID RFC 9562 UUID，由生成器产生的为按时间排序的第7版
+type UUID [16]byte
+
+// ParseUUID 解析 8-4-4-4-12 格式的UUID字符串
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("%w: UUID 格式应为 8-4-4-4-12", ErrInvalidID)
+	}
+
+	src := []byte(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36])
+	if _, err := hex.Decode(u[:], src); err != nil {
+		return UUID{}, fmt.Errorf("%w: UUID 包含非法字符", ErrInvalidID)
+	}
+	return u, nil
+}
+
+// MustParseUUID 解析UUID，失败时panic
+func MustParseUUID(s string) UUID {
+	u, err := ParseUUID(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// String 返回小写的 8-4-4-4-12 格式字符串
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// Version 返回UUID版本号
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time 返回UUIDv7中的时间戳，其他版本返回零值
+func (u UUID) Time() time.Time {
+	if u.Version() != 7 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(uint48(u[:6])))
+}
+
+// IsZero 判断是否为零值
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+// MarshalText 以字符串形式编码，JSON中同样输出字符串
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText 从字符串解码
+func (u *UUID) UnmarshalText(data []byte) error {
+	parsed, err := ParseUUID(string(data))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// Value 以字符串形式写入数据库，零值写入NULL
+func (u UUID) Value() (driver.Value, error) {
+	if u.IsZero() {
+		return nil, nil
+	}
+	return u.String(), nil
+}
+
+// Scan 从数据库读取，支持字符串与16字节二进制
+func (u *UUID) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*u = UUID{}
+		return nil
+	case string:
+		return u.UnmarshalText([]byte(v))
+	case []byte:
+		if len(v) == 16 {
+			copy(u[:], v)
+			return nil
+		}
+		return u.UnmarshalText(v)
+	default:
+		return fmt.Errorf("%w: 无法将 %T 转换为UUID", ErrInvalidID, value)
+	}
+}
+
+// GormDataType gorm 建表时使用的列类型
+func (UUID) GormDataType() string {
+	return "char(36)"
+}
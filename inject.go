@@ -0,0 +1,143 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/zzliekkas/flow/v2/di"
+)
+
+var (
+	contextType = reflect.TypeOf((*Context)(nil))
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// injectTarget H2 包装的处理函数
+type injectTarget struct {
+	fn       interface{}
+	value    reflect.Value
+	deps     []reflect.Type
+	resolved sync.Map // *Engine -> []reflect.Value，依赖为单例，解析一次后复用
+}
+
+// H2 包装带依赖参数的处理函数，第一个参数为 *Context，其余参数从容器注入：
+//
+//	e.GET("/users", flow.H2(func(c *flow.Context, svc *UserService) {
+//		c.JSON(http.StatusOK, svc.List())
+//	}))
+//
+// 函数可以返回 error，非空时交给 DefaultHTTPErrorHandler。依赖在第一次请求时解析并缓存，
+// 缺少依赖时请求返回 500；调用 Engine.VerifyDependencies 可在启动时一次检查所有 H2 路由的依赖
+func H2(fn interface{}) HandlerFunc {
+	value := reflect.ValueOf(fn)
+	ft := value.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() == 0 || ft.In(0) != contextType || ft.IsVariadic() ||
+		ft.NumOut() > 1 || ft.NumOut() == 1 && ft.Out(0) != errorType {
+		panic(fmt.Sprintf("H2 需要形如 func(*flow.Context, 依赖...) [error] 的函数，实际为 %T", fn))
+	}
+
+	target := &injectTarget{fn: fn, value: value}
+	for i := 1; i < ft.NumIn(); i++ {
+		target.deps = append(target.deps, ft.In(i))
+	}
+	return target.serve
+}
+
+// serve 注入依赖后调用处理函数，注册路由时被识别以登记待检查的函数
+func (t *injectTarget) serve(c *Context) {
+	if c.injectSink != nil {
+		*c.injectSink = append(*c.injectSink, t)
+		return
+	}
+
+	args, err := t.resolve(c.engine)
+	if err == nil {
+		results := t.value.Call(append([]reflect.Value{reflect.ValueOf(c)}, args...))
+		if len(results) == 1 && !results[0].IsNil() {
+			err = results[0].Interface().(error)
+		}
+	}
+	if err != nil {
+		_ = c.Error(err)
+		DefaultHTTPErrorHandler(err, c)
+		c.Abort()
+	}
+}
+
+// injectHandlerPC serve 方法值的代码地址，所有 H2 处理函数共用，用于识别
+var injectHandlerPC = reflect.ValueOf((&injectTarget{}).serve).Pointer()
+
+// resolve 从引擎容器解析依赖，成功后缓存
+func (t *injectTarget) resolve(e *Engine) ([]reflect.Value, error) {
+	if len(t.deps) == 0 {
+		return nil, nil
+	}
+	if args, ok := t.resolved.Load(e); ok {
+		return args.([]reflect.Value), nil
+	}
+
+	var args []reflect.Value
+	collector := reflect.MakeFunc(reflect.FuncOf(t.deps, nil, false), func(in []reflect.Value) []reflect.Value {
+		args = in
+		return nil
+	})
+	if err := e.Invoke(collector.Interface()); err != nil {
+		// 直接依赖缺失时错误指向用户的处理函数，而不是内部的收集函数
+		var missing *di.NotProvidedError
+		if errors.As(err, &missing) && missing.Requester == funcName(collector.Interface()) {
+			missing.Requester = funcName(t.fn)
+		}
+		return nil, err
+	}
+	t.resolved.Store(e, args)
+	return args, nil
+}
+
+// requirement 返回待检查的依赖，以原函数名称作为依赖方
+func (t *injectTarget) requirement() di.Requirement {
+	return di.Requirement{Requester: funcName(t.fn), Types: t.deps}
+}
+
+// injectTargets 取出处理函数中的 H2 函数
+func injectTargets(handlers []HandlerFunc) []*injectTarget {
+	var targets []*injectTarget
+	for _, h := range handlers {
+		if h != nil && reflect.ValueOf(h).Pointer() == injectHandlerPC {
+			h(&Context{injectSink: &targets})
+		}
+	}
+	return targets
+}
+
+// injectedFunc 返回 H2 包装的原函数，其他处理函数原样返回，用于展示名称
+func injectedFunc(h HandlerFunc) interface{} {
+	if targets := injectTargets([]HandlerFunc{h}); len(targets) == 1 {
+		return targets[0].fn
+	}
+	return h
+}
+
+// VerifyDependencies 检查通过 H2 注册的处理函数以及 functions 的依赖（包括间接依赖）是否都已注册，
+// 一次返回所有缺少的类型，错误为 *di.VerifyError。只检查类型，不调用构造函数
+func (e *Engine) VerifyDependencies(functions ...interface{}) error {
+	e.injectMu.Lock()
+	targets := make([]interface{}, 0, len(e.injected)+len(functions))
+	for _, target := range e.injected {
+		targets = append(targets, target.requirement())
+	}
+	e.injectMu.Unlock()
+	return e.container.Verify(append(targets, functions...)...)
+}
+
+// registerInjected 登记路由中的 H2 处理函数
+func (e *Engine) registerInjected(handlers []HandlerFunc) {
+	targets := injectTargets(handlers)
+	if len(targets) == 0 {
+		return
+	}
+	e.injectMu.Lock()
+	e.injected = append(e.injected, targets...)
+	e.injectMu.Unlock()
+}
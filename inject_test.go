@@ -0,0 +1,83 @@
+package flow
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/di"
+)
+
+type injectUserService struct{ calls int }
+
+type injectAuditLog struct{}
+
+func TestH2_InjectsDependencies(t *testing.T) {
+	e := newRouteTestEngine()
+	constructed := 0
+	require.NoError(t, e.Provide(func() *injectUserService {
+		constructed++
+		return &injectUserService{}
+	}))
+	e.GET("/users", H2(func(c *Context, svc *injectUserService) {
+		svc.calls++
+		c.String(http.StatusOK, "%d", svc.calls)
+	}))
+	e.GET("/fail", H2(func(c *Context, svc *injectUserService) error {
+		return NewHTTPError(http.StatusConflict, "冲突")
+	}))
+
+	for i := 1; i <= 2; i++ {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, string(rune('0'+i)), w.Body.String())
+	}
+	assert.Equal(t, 1, constructed)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 路由列表展示原处理函数而不是包装函数
+	for _, r := range e.RouteList() {
+		assert.Contains(t, r.Handler, "TestH2_InjectsDependencies")
+	}
+}
+
+func TestH2_MissingDependency(t *testing.T) {
+	e := newRouteTestEngine()
+	handler := func(c *Context, log *injectAuditLog) {}
+	e.GET("/audit", H2(handler))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "flow.injectAuditLog")
+	assert.Contains(t, w.Body.String(), "TestH2_MissingDependency")
+}
+
+func TestEngine_VerifyDependencies(t *testing.T) {
+	e := newRouteTestEngine()
+	e.GET("/users", H2(func(c *Context, svc *injectUserService) {}))
+	e.GET("/audit", H2(func(c *Context, log *injectAuditLog, svc *injectUserService) {}))
+	e.GET("/plain", func(c *Context) {})
+
+	err := e.VerifyDependencies()
+	var verify *di.VerifyError
+	require.True(t, errors.As(err, &verify))
+	assert.Len(t, verify.Errors, 2, "所有缺少的类型一次报告")
+	assert.ErrorIs(t, err, di.ErrNotProvided)
+
+	require.NoError(t, e.Provide(func() *injectUserService { return &injectUserService{} }))
+	require.NoError(t, e.Provide(func() *injectAuditLog { return &injectAuditLog{} }))
+	assert.NoError(t, e.VerifyDependencies())
+}
+
+func TestH2_RejectsInvalidSignature(t *testing.T) {
+	assert.Panics(t, func() { H2(func(svc *injectUserService) {}) })
+	assert.Panics(t, func() { H2(func(c *Context) string { return "" }) })
+}
@@ -0,0 +1,759 @@
+package flow
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RFC3339Milli 精确到毫秒的 RFC3339 时间格式，可用于 JSONOptions.TimeFormat
+const RFC3339Milli = "2006-01-02T15:04:05.000Z07:00"
+
+// maxJSONDepth 转换编码时的最大嵌套深度，超过时视为循环引用
+const maxJSONDepth = 1000
+
+// FieldNaming 未设置 json 标签名的字段的命名策略，标签名始终优先
+type FieldNaming int
+
+const (
+	// FieldNamingNone 使用Go字段名，与 encoding/json 一致
+	FieldNamingNone FieldNaming = iota
+	// FieldNamingCamel 小驼峰，如 UserID -> userId
+	FieldNamingCamel
+	// FieldNamingSnake 蛇形，如 UserID -> user_id
+	FieldNamingSnake
+)
+
+// NaNPolicy 浮点数 NaN 与 ±Inf 的处理策略
+type NaNPolicy int
+
+const (
+	// NaNError 返回错误，与 encoding/json 一致
+	NaNError NaNPolicy = iota
+	// NaNNull 输出为 null
+	NaNNull
+)
+
+// JSONEncoder JSON编码器，可用于接入 jsoniter、segmentio 等实现：
+//
+//	flow.JSONEncoderFunc(func(v interface{}, escapeHTML bool) ([]byte, error) {
+//		return jsoniter.Config{EscapeHTML: escapeHTML, SortMapKeys: true}.Froze().Marshal(v)
+//	})
+type JSONEncoder interface {
+	Marshal(v interface{}, escapeHTML bool) ([]byte, error)
+}
+
+// JSONEncoderFunc 函数形式的 JSONEncoder
+type JSONEncoderFunc func(v interface{}, escapeHTML bool) ([]byte, error)
+
+// Marshal 实现 JSONEncoder
+func (f JSONEncoderFunc) Marshal(v interface{}, escapeHTML bool) ([]byte, error) {
+	return f(v, escapeHTML)
+}
+
+// StdJSONEncoder 基于 encoding/json 的默认编码器
+var StdJSONEncoder JSONEncoder = JSONEncoderFunc(func(v interface{}, escapeHTML bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+})
+
+// JSONOptions 响应的JSON编码配置，零值与 encoding/json 的行为一致
+//
+// 设置了 TimeFormat、FieldNaming、OmitEmpty 或 NaNNull 时由内置的转换编码器输出，
+// 它与 encoding/json 一样处理标签、嵌入字段与 Marshaler；Encoder 用于无需转换的情况
+type JSONOptions struct {
+	Encoder           JSONEncoder // 编码器，默认 StdJSONEncoder
+	TimeFormat        string      // time.Time 的格式，如 RFC3339Milli，为空时使用 RFC3339Nano；自定义了 MarshalJSON 的类型不受影响
+	FieldNaming       FieldNaming // 未设置标签名的字段的命名策略
+	OmitEmpty         bool        // 未设置 json 标签的字段省略零值，设置了标签的字段按标签处理
+	DisableHTMLEscape bool        // 不转义字符串中的 <、> 与 &
+	NaN               NaNPolicy   // NaN 与 ±Inf 的处理策略
+}
+
+// WithJSONOptions 返回一个设置JSON编码配置的选项，c.JSON、JSONStream、NDJSON、Paginated
+// 与错误响应都按此编码；单个路由可通过 flow.WithAttr(flow.JSONOptions{...}) 覆盖，
+// 传入零值即恢复 encoding/json 的默认行为
+func WithJSONOptions(opts JSONOptions) Option {
+	return func(e *Engine) {
+		e.config.JSON = opts
+	}
+}
+
+// JSONOptions 返回引擎的JSON编码配置
+func (e *Engine) JSONOptions() JSONOptions {
+	return e.config.JSON
+}
+
+// isDefault 是否与 encoding/json 的默认行为一致
+func (o JSONOptions) isDefault() bool {
+	return o.Encoder == nil && !o.DisableHTMLEscape && !o.transforms()
+}
+
+// transforms 是否需要转换编码
+func (o JSONOptions) transforms() bool {
+	return o.TimeFormat != "" || o.FieldNaming != FieldNamingNone || o.OmitEmpty || o.NaN == NaNNull
+}
+
+// Marshal 按配置序列化 v
+func (o JSONOptions) Marshal(v interface{}) ([]byte, error) {
+	if !o.transforms() {
+		encoder := o.Encoder
+		if encoder == nil {
+			encoder = StdJSONEncoder
+		}
+		return encoder.Marshal(v, !o.DisableHTMLEscape)
+	}
+	w := &jsonWriter{opts: &o}
+	if err := w.value(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// jsonOptions 返回当前请求生效的JSON编码配置，路由属性优先
+func (c *Context) jsonOptions() JSONOptions {
+	if opts, ok := AttrFrom[JSONOptions](c); ok {
+		return opts
+	}
+	if c.engine != nil {
+		return c.engine.config.JSON
+	}
+	return JSONOptions{}
+}
+
+// JSON 按引擎与路由的JSON编码配置输出响应，见 WithJSONOptions
+func (c *Context) JSON(code int, obj interface{}) {
+	opts := c.jsonOptions()
+	if opts.isDefault() {
+		c.Context.JSON(code, obj)
+		return
+	}
+	c.Render(code, jsonRender{opts: opts, data: obj})
+}
+
+// jsonRender 按配置编码的 render.Render
+type jsonRender struct {
+	opts JSONOptions
+	data interface{}
+}
+
+// Render 实现 render.Render
+func (r jsonRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	data, err := r.opts.Marshal(r.data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// WriteContentType 实现 render.Render
+func (r jsonRender) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+}
+
+var (
+	numberType        = reflect.TypeOf(json.Number(""))
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// jsonWriter 按配置转换编码的写入器
+type jsonWriter struct {
+	buf   []byte
+	opts  *JSONOptions
+	depth int
+}
+
+// value 写入一个值
+func (w *jsonWriter) value(v reflect.Value) error {
+	if !v.IsValid() {
+		w.buf = append(w.buf, "null"...)
+		return nil
+	}
+	t := v.Type()
+
+	if w.opts.TimeFormat != "" {
+		if t == timeType {
+			w.buf = append(w.buf, '"')
+			w.buf = v.Interface().(time.Time).AppendFormat(w.buf, w.opts.TimeFormat)
+			w.buf = append(w.buf, '"')
+			return nil
+		}
+		if t.Kind() == reflect.Pointer && t.Elem() == timeType {
+			if v.IsNil() {
+				w.buf = append(w.buf, "null"...)
+				return nil
+			}
+			return w.value(v.Elem())
+		}
+	}
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) {
+		return w.marshaler(v)
+	}
+	if t.Kind() != reflect.Pointer && v.CanAddr() {
+		if pt := reflect.PointerTo(t); pt.Implements(marshalerType) || pt.Implements(textMarshalerType) {
+			return w.marshaler(v.Addr())
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		w.buf = strconv.AppendBool(w.buf, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.buf = strconv.AppendInt(w.buf, v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.buf = strconv.AppendUint(w.buf, v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return w.float(v)
+	case reflect.String:
+		if t == numberType {
+			number := v.String()
+			if number == "" {
+				number = "0"
+			}
+			w.buf = append(w.buf, number...)
+			return nil
+		}
+		w.string(v.String())
+	case reflect.Interface:
+		if v.IsNil() {
+			w.buf = append(w.buf, "null"...)
+			return nil
+		}
+		return w.value(v.Elem())
+	case reflect.Pointer:
+		if v.IsNil() {
+			w.buf = append(w.buf, "null"...)
+			return nil
+		}
+		return w.nested(func() error { return w.value(v.Elem()) })
+	case reflect.Struct:
+		return w.nested(func() error { return w.object(v) })
+	case reflect.Map:
+		if v.IsNil() {
+			w.buf = append(w.buf, "null"...)
+			return nil
+		}
+		return w.nested(func() error { return w.mapValue(v) })
+	case reflect.Slice:
+		if v.IsNil() {
+			w.buf = append(w.buf, "null"...)
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 && !reflect.PointerTo(t.Elem()).Implements(marshalerType) &&
+			!reflect.PointerTo(t.Elem()).Implements(textMarshalerType) {
+			w.buf = append(w.buf, '"')
+			w.buf = base64.StdEncoding.AppendEncode(w.buf, v.Bytes())
+			w.buf = append(w.buf, '"')
+			return nil
+		}
+		return w.nested(func() error { return w.array(v) })
+	case reflect.Array:
+		return w.nested(func() error { return w.array(v) })
+	default:
+		return &json.UnsupportedTypeError{Type: t}
+	}
+	return nil
+}
+
+// nested 写入嵌套值并限制深度
+func (w *jsonWriter) nested(fn func() error) error {
+	w.depth++
+	defer func() { w.depth-- }()
+	if w.depth > maxJSONDepth {
+		return fmt.Errorf("JSON编码嵌套超过 %d 层，可能存在循环引用", maxJSONDepth)
+	}
+	return fn()
+}
+
+// marshaler 写入实现了 json.Marshaler 或 encoding.TextMarshaler 的值
+func (w *jsonWriter) marshaler(v reflect.Value) error {
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		w.buf = append(w.buf, "null"...)
+		return nil
+	}
+	if m, ok := v.Interface().(json.Marshaler); ok {
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return &json.MarshalerError{Type: v.Type(), Err: err}
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, data); err != nil {
+			return &json.MarshalerError{Type: v.Type(), Err: err}
+		}
+		if w.opts.DisableHTMLEscape {
+			w.buf = append(w.buf, compact.Bytes()...)
+			return nil
+		}
+		var escaped bytes.Buffer
+		json.HTMLEscape(&escaped, compact.Bytes())
+		w.buf = append(w.buf, escaped.Bytes()...)
+		return nil
+	}
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return &json.MarshalerError{Type: v.Type(), Err: err}
+	}
+	w.string(string(text))
+	return nil
+}
+
+// float 按 encoding/json 的格式写入浮点数
+func (w *jsonWriter) float(v reflect.Value) error {
+	f := v.Float()
+	bits := 64
+	if v.Kind() == reflect.Float32 {
+		bits = 32
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		if w.opts.NaN == NaNNull {
+			w.buf = append(w.buf, "null"...)
+			return nil
+		}
+		return &json.UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	w.buf = strconv.AppendFloat(w.buf, f, format, -1, bits)
+	if format == 'e' {
+		// 与 encoding/json 一致，将 e-09 简化为 e-9
+		n := len(w.buf)
+		if n >= 4 && w.buf[n-4] == 'e' && w.buf[n-3] == '-' && w.buf[n-2] == '0' {
+			w.buf[n-2] = w.buf[n-1]
+			w.buf = w.buf[:n-1]
+		}
+	}
+	return nil
+}
+
+// array 写入切片或数组
+func (w *jsonWriter) array(v reflect.Value) error {
+	w.buf = append(w.buf, '[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			w.buf = append(w.buf, ',')
+		}
+		if err := w.value(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	w.buf = append(w.buf, ']')
+	return nil
+}
+
+// mapValue 写入映射，键按字典序排列
+func (w *jsonWriter) mapValue(v reflect.Value) error {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: key, value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	w.buf = append(w.buf, '{')
+	for i, e := range entries {
+		if i > 0 {
+			w.buf = append(w.buf, ',')
+		}
+		w.string(e.key)
+		w.buf = append(w.buf, ':')
+		if err := w.value(e.value); err != nil {
+			return err
+		}
+	}
+	w.buf = append(w.buf, '}')
+	return nil
+}
+
+// mapKey 返回映射键的字符串形式
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: k.Type()}
+}
+
+// object 写入结构体
+func (w *jsonWriter) object(v reflect.Value) error {
+	w.buf = append(w.buf, '{')
+	first := true
+fields:
+	for _, f := range cachedJSONFields(v.Type(), w.opts.FieldNaming, w.opts.OmitEmpty) {
+		fv := v
+		for _, i := range f.index {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue fields
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(i)
+		}
+		if f.omitEmpty && isEmptyJSONValue(fv) {
+			continue
+		}
+
+		if !first {
+			w.buf = append(w.buf, ',')
+		}
+		first = false
+		w.string(f.name)
+		w.buf = append(w.buf, ':')
+
+		if !f.quoted {
+			if err := w.value(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		// ",string" 选项：将标量值编码后作为字符串输出
+		inner := &jsonWriter{opts: w.opts, depth: w.depth}
+		if err := inner.value(fv); err != nil {
+			return err
+		}
+		if fv.Kind() == reflect.Pointer && fv.IsNil() || fv.Kind() == reflect.Interface && fv.IsNil() {
+			w.buf = append(w.buf, inner.buf...)
+		} else {
+			w.string(string(inner.buf))
+		}
+	}
+	w.buf = append(w.buf, '}')
+	return nil
+}
+
+// string 写入JSON字符串，转义规则与 encoding/json 一致
+func (w *jsonWriter) string(s string) {
+	const hex = "0123456789abcdef"
+	escapeHTML := !w.opts.DisableHTMLEscape
+	w.buf = append(w.buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && (!escapeHTML || b != '<' && b != '>' && b != '&') {
+				i++
+				continue
+			}
+			w.buf = append(w.buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				w.buf = append(w.buf, '\\', b)
+			case '\n':
+				w.buf = append(w.buf, '\\', 'n')
+			case '\r':
+				w.buf = append(w.buf, '\\', 'r')
+			case '\t':
+				w.buf = append(w.buf, '\\', 't')
+			default:
+				w.buf = append(w.buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			w.buf = append(w.buf, s[start:i]...)
+			w.buf = append(w.buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 与 U+2029 在 JavaScript 中是换行符
+		if r == '\u2028' || r == '\u2029' {
+			w.buf = append(w.buf, s[start:i]...)
+			w.buf = append(w.buf, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	w.buf = append(w.buf, s[start:]...)
+	w.buf = append(w.buf, '"')
+}
+
+// isEmptyJSONValue 是否为 omitempty 意义上的空值
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// jsonField 结构体的一个输出字段
+type jsonField struct {
+	name      string
+	tagged    bool
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	quoted    bool
+}
+
+// jsonFieldsKey 字段缓存的键，命名策略与省略策略不同时字段不同
+type jsonFieldsKey struct {
+	typ       reflect.Type
+	naming    FieldNaming
+	omitEmpty bool
+}
+
+// jsonFieldCache 结构体字段缓存
+var jsonFieldCache sync.Map
+
+// cachedJSONFields 返回结构体的输出字段
+func cachedJSONFields(t reflect.Type, naming FieldNaming, omitEmpty bool) []jsonField {
+	key := jsonFieldsKey{typ: t, naming: naming, omitEmpty: omitEmpty}
+	if fields, ok := jsonFieldCache.Load(key); ok {
+		return fields.([]jsonField)
+	}
+	fields, _ := jsonFieldCache.LoadOrStore(key, typeJSONFields(t, naming, omitEmpty))
+	return fields.([]jsonField)
+}
+
+// typeJSONFields 按 encoding/json 的规则展开嵌入字段并处理同名冲突
+func typeJSONFields(t reflect.Type, naming FieldNaming, omitAll bool) []jsonField {
+	var fields []jsonField
+	current := []jsonField{}
+	next := []jsonField{{typ: t}}
+	var count, nextCount map[reflect.Type]int
+	visited := map[reflect.Type]bool{}
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag, hasTag := sf.Tag.Lookup("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					field := jsonField{
+						name:      name,
+						tagged:    name != "",
+						index:     index,
+						typ:       ft,
+						omitEmpty: hasTagOption(opts, "omitempty") || omitAll && !hasTag,
+					}
+					if field.name == "" {
+						field.name = applyFieldNaming(sf.Name, naming)
+					}
+					if hasTagOption(opts, "string") {
+						switch ft.Kind() {
+						case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+							reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+							reflect.Float32, reflect.Float64, reflect.String:
+							field.quoted = true
+						}
+					}
+					fields = append(fields, field)
+					if count[f.typ] > 1 {
+						// 同一层级多次嵌入同一类型时互相抵消
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, jsonField{name: ft.Name(), index: index, typ: ft})
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		x := fields
+		if x[i].name != x[j].name {
+			return x[i].name < x[j].name
+		}
+		if len(x[i].index) != len(x[j].index) {
+			return len(x[i].index) < len(x[j].index)
+		}
+		if x[i].tagged != x[j].tagged {
+			return x[i].tagged
+		}
+		return lessIndex(x[i].index, x[j].index)
+	})
+
+	// 同名字段中只保留层级最浅且唯一（或唯一设置了标签）的字段
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		name := fields[i].name
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != name {
+				break
+			}
+		}
+		if advance == 1 {
+			out = append(out, fields[i])
+			continue
+		}
+		dominants := fields[i : i+advance]
+		if len(dominants) > 1 && len(dominants[0].index) == len(dominants[1].index) &&
+			dominants[0].tagged == dominants[1].tagged {
+			continue
+		}
+		out = append(out, dominants[0])
+	}
+
+	fields = out
+	sort.Slice(fields, func(i, j int) bool { return lessIndex(fields[i].index, fields[j].index) })
+	return fields
+}
+
+// lessIndex 按字段声明顺序比较
+func lessIndex(a, b []int) bool {
+	for k, xk := range a {
+		if k >= len(b) {
+			return false
+		}
+		if xk != b[k] {
+			return xk < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// hasTagOption 标签选项中是否包含 option
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var name string
+		name, opts, _ = strings.Cut(opts, ",")
+		if name == option {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFieldNaming 按命名策略转换字段名
+func applyFieldNaming(name string, naming FieldNaming) string {
+	switch naming {
+	case FieldNamingCamel:
+		words := splitFieldName(name)
+		for i, word := range words {
+			word = strings.ToLower(word)
+			if i > 0 {
+				word = strings.ToUpper(word[:1]) + word[1:]
+			}
+			words[i] = word
+		}
+		return strings.Join(words, "")
+	case FieldNamingSnake:
+		words := splitFieldName(name)
+		for i, word := range words {
+			words[i] = strings.ToLower(word)
+		}
+		return strings.Join(words, "_")
+	}
+	return name
+}
+
+// splitFieldName 将Go字段名拆分为单词，连续的大写视为一个缩写词，如 HTTPServerID -> HTTP Server ID
+func splitFieldName(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		boundary := unicode.IsUpper(cur) && !unicode.IsUpper(prev) ||
+			unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) ||
+			cur == '_'
+		if !boundary {
+			continue
+		}
+		if i > start {
+			words = append(words, string(runes[start:i]))
+		}
+		start = i
+		if cur == '_' {
+			start = i + 1
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,208 @@
+package flow
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonAudit struct {
+	CreatedBy string
+	UpdatedAt time.Time
+}
+
+type jsonUser struct {
+	ID        int `json:"id"`
+	UserName  string
+	HTTPProxy string
+	Email     string `json:"email_address"`
+	Score     float64
+	CreatedAt time.Time
+	DeletedAt *time.Time
+	Tags      []string `json:"tags,omitempty"`
+	jsonAudit
+}
+
+var jsonTestTime = time.Date(2024, 3, 1, 8, 30, 15, 123456789, time.UTC)
+
+// marshalString 按配置序列化并返回字符串
+func marshalString(t *testing.T, opts JSONOptions, v interface{}) string {
+	t.Helper()
+	data, err := opts.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestJSONOptions_MatchesEncodingJSON(t *testing.T) {
+	deleted := jsonTestTime.Add(time.Hour)
+	values := []interface{}{
+		jsonUser{ID: 1, UserName: "<a&b>", Score: 1e-7, CreatedAt: jsonTestTime, DeletedAt: &deleted, jsonAudit: jsonAudit{CreatedBy: "line\u2028sep\xff\x01"}},
+		map[int]interface{}{2: []byte("raw"), 1: json.Number("3.14"), 3: nil},
+		[]float32{1.5, 1e21, 0},
+	}
+	// 只开启 NaN 转换时，其余输出应与 encoding/json 完全一致
+	for _, v := range values {
+		expected, err := json.Marshal(v)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), marshalString(t, JSONOptions{NaN: NaNNull}, v))
+	}
+}
+
+func TestJSONOptions_TimeFormat(t *testing.T) {
+	user := jsonUser{CreatedAt: jsonTestTime, DeletedAt: &jsonTestTime}
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(marshalString(t, JSONOptions{TimeFormat: RFC3339Milli}, user)), &got))
+
+	assert.Equal(t, "2024-03-01T08:30:15.123Z", got["CreatedAt"])
+	assert.Equal(t, "2024-03-01T08:30:15.123Z", got["DeletedAt"])
+	assert.Equal(t, "0001-01-01T00:00:00.000Z", got["UpdatedAt"], "嵌入字段同样生效")
+	assert.Equal(t, `{"at":"2024-03-01"}`, marshalString(t, JSONOptions{TimeFormat: time.DateOnly}, map[string]time.Time{"at": jsonTestTime}))
+}
+
+func TestJSONOptions_FieldNaming(t *testing.T) {
+	user := jsonUser{ID: 7, UserName: "alice", HTTPProxy: "p", Email: "a@example.com", jsonAudit: jsonAudit{CreatedBy: "admin"}}
+
+	var camel map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(marshalString(t, JSONOptions{FieldNaming: FieldNamingCamel}, user)), &camel))
+	assert.Equal(t, "alice", camel["userName"])
+	assert.Equal(t, "p", camel["httpProxy"])
+	assert.Equal(t, "admin", camel["createdBy"])
+	// 标签优先
+	assert.Equal(t, 7.0, camel["id"])
+	assert.Equal(t, "a@example.com", camel["email_address"])
+	assert.NotContains(t, camel, "tags")
+
+	data := marshalString(t, JSONOptions{FieldNaming: FieldNamingSnake}, user)
+	assert.Contains(t, data, `"user_name":"alice","http_proxy":"p","email_address":"a@example.com"`, "保持字段顺序")
+	assert.Contains(t, data, `"created_by":"admin"`)
+}
+
+func TestJSONOptions_OmitEmpty(t *testing.T) {
+	data := marshalString(t, JSONOptions{OmitEmpty: true, TimeFormat: time.RFC3339}, jsonUser{UserName: "bob"})
+	// 未设置标签的零值被省略，设置了标签但没有 omitempty 的字段保留
+	assert.Equal(t, `{"id":0,"UserName":"bob","email_address":"","CreatedAt":"0001-01-01T00:00:00Z","UpdatedAt":"0001-01-01T00:00:00Z"}`, data)
+}
+
+func TestJSONOptions_HTMLEscape(t *testing.T) {
+	v := map[string]string{"html": "<b>Tom & Jerry</b>"}
+	assert.Equal(t, `{"html":"\u003cb\u003eTom \u0026 Jerry\u003c/b\u003e"}`, marshalString(t, JSONOptions{}, v))
+	assert.Equal(t, `{"html":"\u003cb\u003eTom \u0026 Jerry\u003c/b\u003e"}`, marshalString(t, JSONOptions{OmitEmpty: true}, v))
+	assert.Equal(t, `{"html":"<b>Tom & Jerry</b>"}`, marshalString(t, JSONOptions{DisableHTMLEscape: true}, v))
+	assert.Equal(t, `{"html":"<b>Tom & Jerry</b>"}`, marshalString(t, JSONOptions{DisableHTMLEscape: true, FieldNaming: FieldNamingCamel}, v))
+	assert.Equal(t, `{"Raw":{"a":"<"}}`, marshalString(t, JSONOptions{DisableHTMLEscape: true, OmitEmpty: true},
+		struct{ Raw json.RawMessage }{json.RawMessage(`{ "a": "<" }`)}), "Marshaler 的输出被压缩")
+}
+
+func TestJSONOptions_NaNPolicy(t *testing.T) {
+	v := map[string]float64{"nan": math.NaN(), "inf": math.Inf(-1), "ok": 1.5}
+
+	_, err := JSONOptions{}.Marshal(v)
+	var unsupported *json.UnsupportedValueError
+	assert.ErrorAs(t, err, &unsupported)
+	_, err = JSONOptions{FieldNaming: FieldNamingCamel}.Marshal(v)
+	assert.ErrorAs(t, err, &unsupported)
+
+	assert.Equal(t, `{"inf":null,"nan":null,"ok":1.5}`, marshalString(t, JSONOptions{NaN: NaNNull}, v))
+}
+
+func TestContextJSON_EngineAndRouteOptions(t *testing.T) {
+	e := New(WithMode("test"), WithJSONOptions(JSONOptions{FieldNaming: FieldNamingCamel, TimeFormat: RFC3339Milli}))
+	user := jsonUser{ID: 1, UserName: "alice", CreatedAt: jsonTestTime}
+	handler := func(c *Context) { c.JSON(http.StatusOK, user) }
+	e.GET("/users", handler)
+	// 旧接口保持 encoding/json 的默认行为
+	e.GET("/legacy/users", handler, WithAttr(JSONOptions{}))
+	e.GET("/export", func(c *Context) {
+		_ = c.NDJSON(http.StatusOK, func(enc *StreamEncoder) error { return enc.Encode(user) })
+	})
+	e.GET("/fail", func(c *Context) {
+		c.JSON(http.StatusOK, math.NaN())
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/users")
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"userName":"alice"`)
+	assert.Contains(t, w.Body.String(), `"createdAt":"2024-03-01T08:30:15.123Z"`)
+
+	w = get("/legacy/users")
+	assert.Contains(t, w.Body.String(), `"UserName":"alice"`)
+	assert.Contains(t, w.Body.String(), `"CreatedAt":"2024-03-01T08:30:15.123456789Z"`)
+
+	assert.Contains(t, get("/export").Body.String(), `"userName":"alice"`)
+
+	// 编码失败时与 gin 一致，记录错误并中止
+	assert.Empty(t, get("/fail").Body.String())
+}
+
+func TestSplitFieldName(t *testing.T) {
+	cases := map[string]string{
+		"ID":            "id",
+		"UserID":        "userId",
+		"HTTPServer":    "httpServer",
+		"Address2":      "address2",
+		"already_snake": "alreadySnake",
+	}
+	for name, expected := range cases {
+		assert.Equal(t, expected, applyFieldNaming(name, FieldNamingCamel), name)
+	}
+	assert.Equal(t, "http_server_id", applyFieldNaming("HTTPServerID", FieldNamingSnake))
+}
+
+// benchmarkPayload 典型的列表接口响应
+func benchmarkPayload() interface{} {
+	users := make([]jsonUser, 100)
+	for i := range users {
+		users[i] = jsonUser{
+			ID:        i,
+			UserName:  "user",
+			Email:     "user@example.com",
+			Score:     float64(i) * 1.5,
+			CreatedAt: jsonTestTime,
+			Tags:      []string{"a", "b"},
+			jsonAudit: jsonAudit{CreatedBy: "admin", UpdatedAt: jsonTestTime},
+		}
+	}
+	return map[string]interface{}{"data": users, "meta": map[string]int{"page": 1, "per_page": 100}}
+}
+
+func benchmarkJSONOptions(b *testing.B, opts JSONOptions) {
+	payload := benchmarkPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := opts.Marshal(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSON_EncodingJSON(b *testing.B) {
+	payload := benchmarkPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSON_StdEncoder(b *testing.B) {
+	benchmarkJSONOptions(b, JSONOptions{DisableHTMLEscape: true})
+}
+
+func BenchmarkJSON_Transform(b *testing.B) {
+	benchmarkJSONOptions(b, JSONOptions{FieldNaming: FieldNamingCamel, TimeFormat: RFC3339Milli, NaN: NaNNull})
+}
@@ -2,6 +2,7 @@ package flow
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -9,7 +10,6 @@ import (
 	"os/signal"
 	"sort"
 	"syscall"
-	"time"
 )
 
 // resolveAddr 解析监听地址，与gin.resolveAddress逻辑保持一致
@@ -38,31 +38,103 @@ func executeHooks(hooks []hook) {
 }
 
 // Run 启动HTTP服务器
+//
+// 先监听端口（或 WithListener、WithUnixSocket、WithSystemdSocket 配置的监听器），再执行启动钩子与 AddStartupTask 注册的启动任务，期间请求响应503，全部完成后开始处理请求。
+// 启动失败或超过 WithStartupTimeout 设置的时间时关闭服务器并返回错误；Validate 返回错误时不监听端口，直接返回该错误。
+// 启用 WithGracefulRestart 时，平滑重启交接完成、进行中的请求处理完毕后返回 http.ErrServerClosed
 func (e *Engine) Run(addr ...string) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
 	// 显示Flow框架Banner
 	if os.Getenv("FLOW_HIDE_BANNER") != "true" {
 		fmt.Printf(FlowBanner, Version)
 	}
 
-	// 执行启动钩子
-	executeHooks(e.startHooks)
-
 	address := resolveAddr(addr)
 
 	// 创建并持有http.Server引用，支持优雅关闭
 	e.server = &http.Server{
 		Addr:    address,
-		Handler: e.Engine,
+		Handler: e,
 	}
 
-	// 使用自定义listener以便在启动前打印地址
-	listener, err := net.Listen("tcp", address)
+	// 先监听端口，负载均衡器可以看到端口，启动完成前的请求响应503
+	e.readiness.closed.Store(true)
+	listeners, err := e.openListeners(address)
 	if err != nil {
+		e.readiness.closed.Store(false)
 		return err
 	}
+	e.restart.listeners = listeners
+
+	// 尽早监听重启信号，启动完成前收到的 SIGUSR2 不会按默认行为终止进程
+	stopRestartSignal := e.watchRestartSignal()
+	defer stopRestartSignal()
+
+	// 多个监听器共用同一个 http.Server，Shutdown 时一起关闭
+	served := make(chan error, len(listeners))
+	serve := func() {
+		for _, listener := range listeners {
+			go func(listener net.Listener) {
+				served <- e.server.Serve(listener)
+			}(listener)
+		}
+	}
+	for _, listener := range listeners {
+		addr := listenerAddr(listener)
+		e.readiness.tasksMu.Lock()
+		e.readiness.addrs = append(e.readiness.addrs, addr)
+		e.readiness.tasksMu.Unlock()
+		flog.Infof("Flow 服务器监听地址: %s", addr)
+	}
+	// 任一监听器出错时关闭服务器，等待全部监听器退出
+	wait := func() error {
+		err := <-served
+		if !errors.Is(err, http.ErrServerClosed) {
+			_ = e.server.Close()
+		}
+		for i := 1; i < len(listeners); i++ {
+			<-served
+		}
+		return err
+	}
+
+	// 平滑重启的新进程在就绪后才开始接受连接，此前由父进程继续处理请求；
+	// 启动失败时监听器与套接字文件仍由父进程使用，只关闭自己持有的副本
+	inherited := e.restart.ready != nil
+	if !inherited {
+		serve()
+		defer e.removeUnixSockets()
+	}
 
-	flog.Infof("Flow 服务器监听地址: %s", address)
-	return e.server.Serve(listener)
+	if err := e.startup(); err != nil {
+		if inherited {
+			closeListeners(listeners)
+			return err
+		}
+		_ = e.server.Close()
+		wait()
+		return err
+	}
+	e.readiness.closed.Store(false)
+	if inherited {
+		serve()
+		defer e.removeUnixSockets()
+	}
+	flog.Infof("Flow 服务器已就绪")
+	e.notifyRestartReady()
+	e.writePIDFile()
+	defer e.removePIDFile()
+	executeHooks(e.readyHooks)
+
+	err = wait()
+	if e.restart.handedOff.Load() {
+		// Shutdown 开始时 Serve 即返回，等待进行中的请求处理完毕，调用方随后退出进程
+		<-e.Restarted()
+	}
+	return err
 }
 
 // OnStart 注册启动钩子函数，priority 越小越先执行
@@ -74,6 +146,15 @@ func (e *Engine) OnStart(fn func(), priority ...int) {
 	e.startHooks = append(e.startHooks, hook{fn: fn, priority: p})
 }
 
+// OnReady 注册就绪钩子函数，在启动任务全部完成、开始处理请求后执行，priority 越小越先执行
+func (e *Engine) OnReady(fn func(), priority ...int) {
+	p := 100
+	if len(priority) > 0 {
+		p = priority[0]
+	}
+	e.readyHooks = append(e.readyHooks, hook{fn: fn, priority: p})
+}
+
 // OnShutdown 注册关闭钩子函数，priority 越小越先执行
 func (e *Engine) OnShutdown(fn func(), priority ...int) {
 	p := 100
@@ -83,7 +164,7 @@ func (e *Engine) OnShutdown(fn func(), priority ...int) {
 	e.shutdownHooks = append(e.shutdownHooks, hook{fn: fn, priority: p})
 }
 
-// Shutdown 优雅关闭HTTP服务器
+// Shutdown 优雅关闭HTTP服务器，关闭全部监听器并删除 WithUnixSocket 创建的套接字文件
 func (e *Engine) Shutdown(ctx context.Context) error {
 	// 执行关闭钩子
 	executeHooks(e.shutdownHooks)
@@ -95,13 +176,18 @@ func (e *Engine) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-// WaitForTermination 等待终止信号
+// WaitForTermination 等待终止信号并优雅关闭，最长等待 WithShutdownTimeout 设置的时间；平滑重启完成后直接返回
 func (e *Engine) WaitForTermination() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	defer signal.Stop(quit)
+	select {
+	case <-quit:
+	case <-e.Restarted():
+		return
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), e.shutdownTimeoutOrDefault())
 	defer cancel()
 
 	if err := e.Shutdown(ctx); err != nil {
@@ -0,0 +1,190 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listenFDsStart systemd 传递的第一个文件描述符（SD_LISTEN_FDS_START）
+const listenFDsStart = 3
+
+// newListenFile 由文件描述符创建文件，测试时替换
+var newListenFile = os.NewFile
+
+// listenerConfig 通过选项配置的监听器，为空时 Run 监听 TCP 地址
+type listenerConfig struct {
+	listeners   []net.Listener
+	unixSockets []unixSocket
+	systemd     bool
+}
+
+// unixSocket Unix 域套接字配置
+type unixSocket struct {
+	path string
+	mode os.FileMode
+}
+
+// WithListener 返回一个使用已创建监听器的选项，可重复使用以同时监听多个地址
+//
+// 配置了 WithListener、WithUnixSocket 或 WithSystemdSocket 时 Run 只在这些监听器上处理请求，忽略传入的地址
+func WithListener(listener net.Listener) Option {
+	return func(e *Engine) {
+		e.listen.listeners = append(e.listen.listeners, listener)
+	}
+}
+
+// WithUnixSocket 返回一个监听 Unix 域套接字的选项，mode 为套接字文件的权限，为0时不修改
+//
+// Run 时删除无人监听的残留套接字文件，路径上存在其他文件或仍有进程监听时返回错误；
+// 服务器关闭后删除套接字文件。常用于部署在本机 nginx 之后：
+//
+//	e := flow.New(flow.WithUnixSocket("/run/app/app.sock", 0660))
+//	e.Run()
+func WithUnixSocket(path string, mode os.FileMode) Option {
+	return func(e *Engine) {
+		e.listen.unixSockets = append(e.listen.unixSockets, unixSocket{path: path, mode: mode})
+	}
+}
+
+// WithSystemdSocket 返回一个使用 systemd 套接字激活的选项
+//
+// Run 时检查 LISTEN_PID 与 LISTEN_FDS 环境变量，接管 systemd 传递的全部套接字，多个套接字共用同一个引擎；
+// 未通过套接字激活启动时照常监听传入的 TCP 地址，便于同一个二进制在本地直接运行
+func WithSystemdSocket() Option {
+	return func(e *Engine) {
+		e.listen.systemd = true
+	}
+}
+
+// openListeners 按配置创建监听器，未配置时监听 address；由平滑重启启动时使用父进程传递的监听器
+func (e *Engine) openListeners(address string) ([]net.Listener, error) {
+	inherited, err := e.inheritedListeners()
+	if err != nil || len(inherited) > 0 {
+		return inherited, err
+	}
+
+	listeners := append([]net.Listener(nil), e.listen.listeners...)
+	if e.listen.systemd {
+		inherited, err := systemdListeners()
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, inherited...)
+	}
+	for _, socket := range e.listen.unixSockets {
+		listener, err := listenUnix(socket)
+		if err != nil {
+			// 已创建的套接字文件在关闭时删除
+			closeListeners(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) > 0 {
+		return listeners, nil
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return []net.Listener{listener}, nil
+}
+
+// listenUnix 删除残留的套接字文件后监听，并设置文件权限
+func listenUnix(socket unixSocket) (net.Listener, error) {
+	if info, err := os.Lstat(socket.path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("flow: %s 已存在且不是套接字文件", socket.path)
+		}
+		// 仍能连接说明有其他进程在监听
+		if conn, err := net.DialTimeout("unix", socket.path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("flow: 套接字 %s 正在被其他进程使用", socket.path)
+		}
+		if err := os.Remove(socket.path); err != nil {
+			return nil, fmt.Errorf("flow: 删除残留的套接字 %s 失败: %w", socket.path, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", socket.path)
+	if err != nil {
+		return nil, err
+	}
+	if socket.mode != 0 {
+		if err := os.Chmod(socket.path, socket.mode); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("flow: 设置套接字 %s 的权限失败: %w", socket.path, err)
+		}
+	}
+	return listener, nil
+}
+
+// systemdListeners 接管 systemd 套接字激活传递的文件描述符，未通过套接字激活启动时返回空
+func systemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	// 与 sd_listen_fds 一致，接管后清除环境变量，避免子进程重复接管
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(listenFDsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := newListenFile(uintptr(listenFDsStart+i), name)
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("flow: 接管 systemd 套接字 %s 失败: %w", name, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// closeListeners 关闭监听器
+func closeListeners(listeners []net.Listener) {
+	for _, listener := range listeners {
+		_ = listener.Close()
+	}
+}
+
+// removeUnixSockets 删除 WithUnixSocket 创建的套接字文件，systemd 传递的套接字由 systemd 管理，不删除；
+// 平滑重启后套接字由新进程使用，同样不删除
+func (e *Engine) removeUnixSockets() {
+	if e.restart.handedOff.Load() {
+		return
+	}
+	for _, socket := range e.listen.unixSockets {
+		if err := os.Remove(socket.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			flog.Warnf("删除套接字文件 %s 失败: %v", socket.path, err)
+		}
+	}
+}
+
+// listenerAddr 监听地址的显示形式，非 TCP 监听器带网络类型前缀，如 unix:/run/app.sock
+func listenerAddr(listener net.Listener) string {
+	addr := listener.Addr()
+	if addr.Network() == "tcp" {
+		return addr.String()
+	}
+	return addr.Network() + ":" + addr.String()
+}
@@ -0,0 +1,194 @@
+package flow
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socketPath 返回一个较短的套接字路径，避免超过 sun_path 的长度限制
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "flow")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "app.sock")
+}
+
+// unixClient 返回经由套接字发起请求的客户端
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+// runUntilReady 在后台运行引擎并等待就绪，返回 Run 的结果通道
+func runUntilReady(t *testing.T, e *Engine, addr ...string) chan error {
+	t.Helper()
+	ready := make(chan struct{})
+	e.OnReady(func() { close(ready) })
+	done := make(chan error, 1)
+	go func() { done <- e.Run(addr...) }()
+	select {
+	case <-ready:
+	case err := <-done:
+		t.Fatalf("Run 提前返回: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待就绪超时")
+	}
+	return done
+}
+
+func TestRun_UnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不支持套接字文件权限")
+	}
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	path := socketPath(t)
+
+	e := newRouteTestEngine()
+	WithUnixSocket(path, 0o600)(e)
+	e.GET("/ping", reply("pong"))
+	done := runUntilReady(t, e)
+
+	resp, err := unixClient(path).Get("http://localhost/ping")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "pong", string(body))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	assert.Equal(t, []string{"unix:" + path}, e.ListenAddrs())
+
+	// 关闭后删除套接字文件
+	require.NoError(t, e.Shutdown(context.Background()))
+	assert.ErrorIs(t, <-done, http.ErrServerClosed)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRun_UnixSocketStaleFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不支持套接字文件")
+	}
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	path := socketPath(t)
+
+	// 上次异常退出残留的套接字文件
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	e := newRouteTestEngine()
+	WithUnixSocket(path, 0)(e)
+	e.GET("/ping", reply("pong"))
+	done := runUntilReady(t, e)
+
+	resp, err := unixClient(path).Get("http://localhost/ping")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// 仍有进程监听的套接字不删除
+	other := newRouteTestEngine()
+	WithUnixSocket(path, 0)(other)
+	assert.ErrorContains(t, other.Run(), "正在被其他进程使用")
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	require.NoError(t, e.Shutdown(context.Background()))
+	<-done
+
+	// 不是套接字的文件不删除
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+	other = newRouteTestEngine()
+	WithUnixSocket(path, 0)(other)
+	assert.ErrorContains(t, other.Run(), "不是套接字文件")
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "data", string(data))
+}
+
+func TestRun_WithListener(t *testing.T) {
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	first, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	second, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	e := New(WithMode("test"), WithListener(first), WithListener(second))
+	e.GET("/ping", reply("pong"))
+	done := runUntilReady(t, e, "127.0.0.1:1")
+
+	// 多个监听器共用同一个引擎，忽略传入的地址
+	for _, listener := range []net.Listener{first, second} {
+		code, _, body := getURL(t, "http://"+listener.Addr().String()+"/ping")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "pong", body)
+	}
+	assert.Equal(t, []string{first.Addr().String(), second.Addr().String()}, e.ListenAddrs())
+
+	require.NoError(t, e.Shutdown(context.Background()))
+	assert.ErrorIs(t, <-done, http.ErrServerClosed)
+}
+
+func TestRun_SystemdSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("systemd 套接字激活只支持 Unix 系统")
+	}
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+
+	// 用普通的 TCP 监听器模拟 systemd 传递的文件描述符
+	inherited, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	file, err := inherited.(*net.TCPListener).File()
+	require.NoError(t, err)
+	addr := inherited.Addr().String()
+	require.NoError(t, inherited.Close())
+
+	var fds []uintptr
+	newListenFile = func(fd uintptr, name string) *os.File {
+		fds = append(fds, fd)
+		return file
+	}
+	t.Cleanup(func() { newListenFile = os.NewFile })
+
+	// LISTEN_PID 不是当前进程时不接管，照常监听传入的地址
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	listeners, err := systemdListeners()
+	require.NoError(t, err)
+	assert.Empty(t, listeners)
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	e := New(WithMode("test"), WithSystemdSocket())
+	e.GET("/ping", reply("pong"))
+	done := runUntilReady(t, e, freeAddr(t))
+
+	assert.Equal(t, []uintptr{listenFDsStart}, fds)
+	assert.Equal(t, []string{addr}, e.ListenAddrs())
+	code, _, body := getURL(t, "http://"+addr+"/ping")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "pong", body)
+	assert.Empty(t, os.Getenv("LISTEN_FDS"), "接管后清除环境变量")
+
+	require.NoError(t, e.Shutdown(context.Background()))
+	<-done
+}
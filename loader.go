@@ -0,0 +1,262 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotFound 批量加载结果中缺少某个键时返回的错误
+var ErrNotFound = errors.New("flow: 未找到数据")
+
+// NotFoundError 表示数据加载器中缺失的键
+type NotFoundError struct {
+	Loader string
+	Key    interface{}
+}
+
+// Error 实现error接口
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("flow: 数据加载器 %s 未找到键 %v", e.Loader, e.Key)
+}
+
+// Is 使 errors.Is(err, ErrNotFound) 成立
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// BatchFunc 批量加载函数，返回结果中不存在的键视为未找到
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// loaderConfig 数据加载器配置
+type loaderConfig struct {
+	wait     time.Duration
+	maxBatch int
+}
+
+// LoaderOption 数据加载器选项
+type LoaderOption func(*loaderConfig)
+
+// WithLoaderWait 设置批次收集窗口，默认2ms
+func WithLoaderWait(wait time.Duration) LoaderOption {
+	return func(c *loaderConfig) {
+		c.wait = wait
+	}
+}
+
+// WithLoaderMaxBatch 设置单个批次的最大键数，达到后立即执行，0表示不限制
+func WithLoaderMaxBatch(n int) LoaderOption {
+	return func(c *loaderConfig) {
+		c.maxBatch = n
+	}
+}
+
+// loaderResult 单个键的加载结果
+type loaderResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// loaderBatch 等待执行的批次
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	results []*loaderResult[V]
+	full    chan struct{}
+}
+
+// Loader 请求级数据加载器，合并同一请求内的多次Load调用为批量查询
+// 结果在请求生命周期内缓存，可以在处理器启动的goroutine中并发使用
+type Loader[K comparable, V any] struct {
+	name   string
+	ctx    context.Context
+	fetch  BatchFunc[K, V]
+	config loaderConfig
+
+	mu    sync.Mutex
+	cache map[K]*loaderResult[V]
+	batch *loaderBatch[K, V]
+}
+
+// loadersKey 数据加载器在上下文中的存储键
+const loadersKey = "flow.loaders"
+
+// loadersMu 保护上下文中加载器表的创建
+var loadersMu sync.Mutex
+
+// LoaderFor 获取或创建当前请求中名为name的数据加载器
+// 同一请求内以相同名称多次调用返回同一个加载器，选项只在首次创建时生效
+func LoaderFor[K comparable, V any](c *Context, name string, fetch BatchFunc[K, V], opts ...LoaderOption) *Loader[K, V] {
+	loadersMu.Lock()
+	defer loadersMu.Unlock()
+
+	var loaders map[string]interface{}
+	if v, ok := c.Get(loadersKey); ok {
+		loaders = v.(map[string]interface{})
+	} else {
+		loaders = make(map[string]interface{})
+		c.Set(loadersKey, loaders)
+	}
+
+	if existing, ok := loaders[name]; ok {
+		loader, ok := existing.(*Loader[K, V])
+		if !ok {
+			panic(fmt.Sprintf("flow: 数据加载器 %s 已使用其他类型注册", name))
+		}
+		return loader
+	}
+
+	ctx := context.Background()
+	if c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	loader := NewLoader(ctx, name, fetch, opts...)
+	loaders[name] = loader
+	return loader
+}
+
+// NewLoader 创建独立于请求上下文的数据加载器
+func NewLoader[K comparable, V any](ctx context.Context, name string, fetch BatchFunc[K, V], opts ...LoaderOption) *Loader[K, V] {
+	config := loaderConfig{wait: 2 * time.Millisecond}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return &Loader[K, V]{
+		name:   name,
+		ctx:    ctx,
+		fetch:  fetch,
+		config: config,
+		cache:  make(map[K]*loaderResult[V]),
+	}
+}
+
+// Load 加载单个键，调用会阻塞到所在批次执行完成或请求上下文结束
+func (l *Loader[K, V]) Load(key K) (V, error) {
+	return l.wait(l.enqueue(key))
+}
+
+// LoadMany 加载多个键，返回与keys一一对应的值和错误
+func (l *Loader[K, V]) LoadMany(keys []K) ([]V, []error) {
+	results := make([]*loaderResult[V], len(keys))
+	for i, key := range keys {
+		results[i] = l.enqueue(key)
+	}
+
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+	for i, r := range results {
+		values[i], errs[i] = l.wait(r)
+	}
+	return values, errs
+}
+
+// Prime 预先写入缓存，已存在的键不会被覆盖
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+	r := &loaderResult[V]{done: make(chan struct{}), value: value}
+	close(r.done)
+	l.cache[key] = r
+}
+
+// Clear 从缓存中移除键，下一次Load会重新查询
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.cache, key)
+}
+
+// enqueue 将键加入当前批次，已缓存或正在加载的键直接复用结果
+func (l *Loader[K, V]) enqueue(key K) *loaderResult[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r, ok := l.cache[key]; ok {
+		return r
+	}
+
+	r := &loaderResult[V]{done: make(chan struct{})}
+	l.cache[key] = r
+
+	if l.batch == nil {
+		l.batch = &loaderBatch[K, V]{full: make(chan struct{})}
+		go l.dispatch(l.batch)
+	}
+
+	b := l.batch
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+
+	if l.config.maxBatch > 0 && len(b.keys) >= l.config.maxBatch {
+		l.batch = nil
+		close(b.full)
+	}
+	return r
+}
+
+// wait 等待结果，请求上下文结束时提前返回
+func (l *Loader[K, V]) wait(r *loaderResult[V]) (V, error) {
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-l.ctx.Done():
+		var zero V
+		return zero, l.ctx.Err()
+	}
+}
+
+// dispatch 等待收集窗口结束或批次已满后执行批量加载
+func (l *Loader[K, V]) dispatch(b *loaderBatch[K, V]) {
+	timer := time.NewTimer(l.config.wait)
+	defer timer.Stop()
+
+	select {
+	case <-b.full:
+	case <-timer.C:
+	case <-l.ctx.Done():
+	}
+
+	l.mu.Lock()
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	var values map[K]V
+	err := l.ctx.Err()
+	if err == nil {
+		values, err = l.fetch(l.ctx, b.keys)
+	}
+
+	if err != nil {
+		// 整批失败的结果不缓存，便于后续重试
+		l.mu.Lock()
+		for i, key := range b.keys {
+			if l.cache[key] == b.results[i] {
+				delete(l.cache, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+
+	for i, key := range b.keys {
+		r := b.results[i]
+		switch value, ok := values[key]; {
+		case err != nil:
+			r.err = err
+		case ok:
+			r.value = value
+		default:
+			r.err = &NotFoundError{Loader: l.name, Key: key}
+		}
+		close(r.done)
+	}
+}
@@ -0,0 +1,137 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingFetch 记录每个批次的键
+type recordingFetch struct {
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (f *recordingFetch) fetch(ctx context.Context, keys []int) (map[int]string, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, append([]int(nil), keys...))
+	f.mu.Unlock()
+
+	result := make(map[int]string)
+	for _, k := range keys {
+		// 负数键模拟不存在的数据
+		if k >= 0 {
+			result[k] = "user" + string(rune('0'+k))
+		}
+	}
+	return result, nil
+}
+
+func newLoaderTestContext() *Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	return &Context{Context: c}
+}
+
+func TestLoader_BatchesAndDeduplicates(t *testing.T) {
+	c := newLoaderTestContext()
+	f := &recordingFetch{}
+	loader := LoaderFor(c, "users", f.fetch, WithLoaderWait(20*time.Millisecond))
+
+	var wg sync.WaitGroup
+	for _, k := range []int{1, 2, 1, 3, 2} {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			v, err := loader.Load(k)
+			assert.NoError(t, err)
+			assert.Equal(t, "user"+string(rune('0'+k)), v)
+		}(k)
+	}
+	wg.Wait()
+
+	require.Len(t, f.batches, 1)
+	assert.ElementsMatch(t, []int{1, 2, 3}, f.batches[0])
+
+	// 同一请求内再次获取返回同一加载器，且结果已缓存
+	assert.Same(t, loader, LoaderFor(c, "users", f.fetch))
+	_, _ = loader.Load(2)
+	assert.Len(t, f.batches, 1)
+}
+
+func TestLoader_MaxBatchAndWindow(t *testing.T) {
+	c := newLoaderTestContext()
+	f := &recordingFetch{}
+	loader := LoaderFor(c, "users", f.fetch, WithLoaderWait(time.Hour), WithLoaderMaxBatch(2))
+
+	// 达到批次上限立即执行，不等待窗口
+	values, errs := loader.LoadMany([]int{1, 2})
+	assert.Equal(t, []string{"user1", "user2"}, values)
+	assert.Equal(t, []error{nil, nil}, errs)
+
+	short := NewLoader(context.Background(), "short", f.fetch, WithLoaderWait(5*time.Millisecond))
+	_, err := short.Load(3)
+	assert.NoError(t, err)
+	_, err = short.Load(4)
+	assert.NoError(t, err)
+
+	assert.Equal(t, [][]int{{1, 2}, {3}, {4}}, f.batches)
+}
+
+func TestLoader_PartialAndBatchErrors(t *testing.T) {
+	c := newLoaderTestContext()
+	f := &recordingFetch{}
+	loader := LoaderFor(c, "users", f.fetch)
+
+	values, errs := loader.LoadMany([]int{1, -1})
+	assert.Equal(t, "user1", values[0])
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], ErrNotFound)
+
+	var notFound *NotFoundError
+	require.ErrorAs(t, errs[1], &notFound)
+	assert.Equal(t, -1, notFound.Key)
+
+	// 整批失败不缓存，之后可以重试
+	var calls atomic.Int32
+	failing := LoaderFor(c, "failing", func(ctx context.Context, keys []int) (map[int]string, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("db down")
+		}
+		return map[int]string{1: "ok"}, nil
+	})
+	_, err := failing.Load(1)
+	assert.EqualError(t, err, "db down")
+	v, err := failing.Load(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", v)
+}
+
+func TestLoader_RespectsContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var called atomic.Bool
+	loader := NewLoader(ctx, "slow", func(ctx context.Context, keys []int) (map[int]int, error) {
+		called.Store(true)
+		return nil, nil
+	}, WithLoaderWait(time.Hour))
+
+	_, err := loader.Load(1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Eventually(t, func() bool {
+		loader.mu.Lock()
+		defer loader.mu.Unlock()
+		return loader.batch == nil
+	}, time.Second, time.Millisecond)
+	assert.False(t, called.Load())
+}
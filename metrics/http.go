@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/middleware"
+)
+
+// HTTPMetrics 请求耗时指标，实现 middleware.LatencyObserver 接口
+type HTTPMetrics struct {
+	requests *CounterVec
+	duration *HistogramVec
+}
+
+var _ middleware.LatencyObserver = (*HTTPMetrics)(nil)
+
+// NewHTTPMetrics 在注册表中创建HTTP请求指标，buckets为空时使用DefaultBuckets
+func NewHTTPMetrics(registry *Registry, buckets []float64) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: registry.NewCounterVec("http_requests_total", "HTTP请求数", "method", "route", "status"),
+		duration: registry.NewHistogramVec("http_request_duration_seconds", "HTTP请求耗时", buckets, "method", "route"),
+	}
+}
+
+// ObserveRequest 记录一次请求，route为路由模板，保证标签基数有限
+func (m *HTTPMetrics) ObserveRequest(method, route string, status int, latency time.Duration) {
+	m.requests.Inc(method, route, strconv.Itoa(status))
+	m.duration.Observe(latency.Seconds(), method, route)
+}
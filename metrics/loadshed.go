@@ -0,0 +1,22 @@
+package metrics
+
+import "github.com/zzliekkas/flow/v2/middleware"
+
+// LoadShedMetrics 负载保护指标，实现 middleware.ShedObserver 接口
+type LoadShedMetrics struct {
+	shed *CounterVec
+}
+
+var _ middleware.ShedObserver = (*LoadShedMetrics)(nil)
+
+// NewLoadShedMetrics 在注册表中创建负载保护指标
+func NewLoadShedMetrics(registry *Registry) *LoadShedMetrics {
+	return &LoadShedMetrics{
+		shed: registry.NewCounterVec("shed_total", "负载保护拒绝的请求数", "reason"),
+	}
+}
+
+// ObserveShed 记录一次被拒绝的请求
+func (m *LoadShedMetrics) ObserveShed(reason string) {
+	m.shed.Inc(reason)
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/queue"
+)
+
+func TestQueueMetrics_UnknownJobType(t *testing.T) {
+	reg := NewTestRegistry()
+	m := NewQueueMetrics(reg.Registry, WithJobTypes("send_email"))
+
+	handler := queue.ApplyMiddleware(func(ctx context.Context, job *queue.Job) error {
+		if job.Name == "resize" {
+			return errors.New("失败")
+		}
+		return nil
+	}, queue.MetricsMiddleware(m))
+
+	_ = handler(context.Background(), &queue.Job{Queue: "default", Name: "send_email"})
+	_ = handler(context.Background(), &queue.Job{Queue: "default", Name: "resize"})
+	_ = handler(context.Background(), &queue.Job{Queue: "default", Name: "user-123-export"})
+
+	assert.Equal(t, 1.0, reg.CounterValue("jobs_processed_total", "default", "send_email", "success"))
+	assert.Equal(t, 1.0, reg.CounterValue("jobs_processed_total", "default", "unknown", "failed"))
+	assert.Equal(t, 1.0, reg.CounterValue("jobs_processed_total", "default", "unknown", "success"))
+	assert.Equal(t, uint64(2), reg.HistogramCount("job_duration_seconds", "default", "unknown"))
+	assert.NotContains(t, reg.Scrape(), "user-123-export")
+}
+
+func TestHistogram_ConfigurableBuckets(t *testing.T) {
+	reg := NewTestRegistry()
+	h := reg.NewHistogramVec("latency_seconds", "", []float64{1, 0.5}, "op")
+
+	h.Observe(0.2, "read")
+	h.Observe(0.5, "read")
+	h.Observe(3, "read")
+
+	assert.Equal(t, uint64(3), h.Count("read"))
+	assert.Equal(t, 3.7, h.Sum("read"))
+	assert.Contains(t, reg.Scrape(), `latency_seconds_bucket{op="read",le="0.5"} 2`)
+	assert.Contains(t, reg.Scrape(), `latency_seconds_bucket{op="read",le="1"} 2`)
+	assert.Contains(t, reg.Scrape(), `latency_seconds_bucket{op="read",le="+Inf"} 3`)
+}
+
+func TestRegistry_ScrapeGolden(t *testing.T) {
+	reg := NewTestRegistry()
+	q := NewQueueMetrics(reg.Registry, WithJobTypes("mail"), WithDurationBuckets([]float64{0.1, 1}))
+	s := NewSchedulerMetrics(reg.Registry, "cleanup")
+
+	q.JobProcessed("critical", "mail", queue.MetricStatusSuccess, 50*time.Millisecond)
+	q.QueueDepth("critical", 7)
+	q.WorkerConcurrency("critical", 2)
+	s.TaskRun("cleanup", nil, time.Unix(1700000000, 0))
+	s.TaskRun("cleanup", errors.New("失败"), time.Unix(1700000100, 0))
+
+	golden := `# HELP job_duration_seconds 队列任务处理耗时
+# TYPE job_duration_seconds histogram
+job_duration_seconds_bucket{queue="critical",type="mail",le="0.1"} 1
+job_duration_seconds_bucket{queue="critical",type="mail",le="1"} 1
+job_duration_seconds_bucket{queue="critical",type="mail",le="+Inf"} 1
+job_duration_seconds_sum{queue="critical",type="mail"} 0.05
+job_duration_seconds_count{queue="critical",type="mail"} 1
+# HELP jobs_processed_total 已处理的队列任务数
+# TYPE jobs_processed_total counter
+jobs_processed_total{queue="critical",type="mail",status="success"} 1
+# HELP queue_depth 采样得到的队列长度
+# TYPE queue_depth gauge
+queue_depth{queue="critical"} 7
+# HELP scheduler_task_last_success_timestamp 定时任务最近一次成功的Unix时间戳
+# TYPE scheduler_task_last_success_timestamp gauge
+scheduler_task_last_success_timestamp{task="cleanup"} 1.7e+09
+# HELP scheduler_task_runs_total 定时任务执行次数
+# TYPE scheduler_task_runs_total counter
+scheduler_task_runs_total{task="cleanup",status="failed"} 1
+scheduler_task_runs_total{task="cleanup",status="success"} 1
+# HELP worker_concurrency 队列当前正在执行的任务数
+# TYPE worker_concurrency gauge
+worker_concurrency{queue="critical"} 2
+`
+	assert.Equal(t, golden, reg.Scrape())
+
+	w := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, golden, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "version=0.0.4")
+}
+
+func TestRegistry_ConflictingRegistrationPanics(t *testing.T) {
+	reg := NewTestRegistry()
+	c := reg.NewCounterVec("requests_total", "", "code")
+	assert.Same(t, c.vec, reg.NewCounterVec("requests_total", "", "code").vec)
+	assert.Panics(t, func() { reg.NewGaugeVec("requests_total", "", "code") })
+	assert.Panics(t, func() { c.Inc() })
+
+	// 测试注册表之间互不影响
+	require.Zero(t, NewTestRegistry().CounterValue("requests_total", "200"))
+}
+
+func TestLoadShedMetrics(t *testing.T) {
+	reg := NewTestRegistry()
+	m := NewLoadShedMetrics(reg.Registry)
+
+	m.ObserveShed("queue_full")
+	m.ObserveShed("queue_full")
+	m.ObserveShed("heap")
+
+	assert.Equal(t, 2.0, reg.CounterValue("shed_total", "queue_full"))
+	assert.Contains(t, reg.Scrape(), `shed_total{reason="heap"} 1`)
+}
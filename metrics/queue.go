@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/queue"
+)
+
+// UnknownLabel 未注册的标签值统一映射为该值，避免标签基数失控
+const UnknownLabel = "unknown"
+
+// QueueOption 队列指标选项
+type QueueOption func(*QueueMetrics)
+
+// WithJobTypes 预先登记允许作为标签的任务类型
+func WithJobTypes(types ...string) QueueOption {
+	return func(m *QueueMetrics) {
+		m.RegisterJobType(types...)
+	}
+}
+
+// WithDurationBuckets 设置任务耗时直方图的分桶（秒）
+func WithDurationBuckets(buckets []float64) QueueOption {
+	return func(m *QueueMetrics) {
+		m.buckets = buckets
+	}
+}
+
+// QueueMetrics 实现 queue.Metrics 接口的队列指标收集器
+type QueueMetrics struct {
+	processed   *CounterVec
+	duration    *HistogramVec
+	depth       *GaugeVec
+	concurrency *GaugeVec
+	buckets     []float64
+
+	mu       sync.RWMutex
+	jobTypes map[string]bool
+}
+
+var _ queue.Metrics = (*QueueMetrics)(nil)
+
+// NewQueueMetrics 在注册表中创建队列指标
+func NewQueueMetrics(registry *Registry, opts ...QueueOption) *QueueMetrics {
+	m := &QueueMetrics{
+		jobTypes: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.processed = registry.NewCounterVec("jobs_processed_total", "已处理的队列任务数", "queue", "type", "status")
+	m.duration = registry.NewHistogramVec("job_duration_seconds", "队列任务处理耗时", m.buckets, "queue", "type")
+	m.depth = registry.NewGaugeVec("queue_depth", "采样得到的队列长度", "queue")
+	m.concurrency = registry.NewGaugeVec("worker_concurrency", "队列当前正在执行的任务数", "queue")
+	return m
+}
+
+// RegisterJobType 登记任务类型，通常与处理器注册同时进行
+func (m *QueueMetrics) RegisterJobType(types ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range types {
+		m.jobTypes[t] = true
+	}
+}
+
+// jobType 返回受约束的任务类型标签
+func (m *QueueMetrics) jobType(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.jobTypes[name] {
+		return name
+	}
+	return UnknownLabel
+}
+
+// JobProcessed 记录任务处理结果与耗时
+func (m *QueueMetrics) JobProcessed(queueName, jobType, status string, duration time.Duration) {
+	jobType = m.jobType(jobType)
+	if status != queue.MetricStatusSuccess && status != queue.MetricStatusFailed {
+		status = UnknownLabel
+	}
+
+	m.processed.Inc(queueName, jobType, status)
+	m.duration.Observe(duration.Seconds(), queueName, jobType)
+}
+
+// QueueDepth 记录队列长度
+func (m *QueueMetrics) QueueDepth(queueName string, depth int) {
+	m.depth.Set(float64(depth), queueName)
+}
+
+// WorkerConcurrency 记录当前并发数
+func (m *QueueMetrics) WorkerConcurrency(queueName string, running int) {
+	m.concurrency.Set(float64(running), queueName)
+}
@@ -0,0 +1,329 @@
+// Package metrics 提供兼容Prometheus文本格式的轻量指标收集器
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets 默认的耗时直方图分桶（秒）
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// 指标类型
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// Registry 指标注册表
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]*metricVec
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]*metricVec),
+	}
+}
+
+// defaultRegistry 全局默认注册表
+var defaultRegistry = NewRegistry()
+
+// Default 返回全局默认注册表
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// metricVec 带标签的一组指标
+type metricVec struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series 一组标签值对应的数据
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64 // 直方图各分桶计数（不累计）
+	count       uint64
+	sum         float64
+}
+
+// register 注册指标，同名同类型的指标返回已有实例
+func (r *Registry) register(name, help, kind string, labels []string, buckets []float64) *metricVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[name]; ok {
+		if existing.kind != kind || strings.Join(existing.labels, ",") != strings.Join(labels, ",") {
+			panic(fmt.Sprintf("metrics: 指标 %s 已使用不同的类型或标签注册", name))
+		}
+		return existing
+	}
+
+	m := &metricVec{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  append([]string(nil), labels...),
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.metrics[name] = m
+	return m
+}
+
+// with 获取或创建标签值对应的数据，调用方需持有锁
+func (m *metricVec) with(labelValues []string) *series {
+	if len(labelValues) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: 指标 %s 需要 %d 个标签值，实际为 %d", m.name, len(m.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if m.kind == typeHistogram {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// lookup 查找标签值对应的数据，不存在时返回nil
+func (m *metricVec) lookup(labelValues []string) *series {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.series[strings.Join(labelValues, "\xff")]
+}
+
+// CounterVec 只增计数器
+type CounterVec struct {
+	vec *metricVec
+}
+
+// NewCounterVec 注册计数器
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{vec: r.register(name, help, typeCounter, labels, nil)}
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 增加计数，负数会被忽略
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.vec.mu.Lock()
+	c.vec.with(labelValues).value += v
+	c.vec.mu.Unlock()
+}
+
+// Value 返回当前计数
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	if s := c.vec.lookup(labelValues); s != nil {
+		c.vec.mu.Lock()
+		defer c.vec.mu.Unlock()
+		return s.value
+	}
+	return 0
+}
+
+// GaugeVec 可增可减的仪表
+type GaugeVec struct {
+	vec *metricVec
+}
+
+// NewGaugeVec 注册仪表
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{vec: r.register(name, help, typeGauge, labels, nil)}
+}
+
+// Set 设置当前值
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.vec.mu.Lock()
+	g.vec.with(labelValues).value = v
+	g.vec.mu.Unlock()
+}
+
+// Add 增加（或减少）当前值
+func (g *GaugeVec) Add(v float64, labelValues ...string) {
+	g.vec.mu.Lock()
+	g.vec.with(labelValues).value += v
+	g.vec.mu.Unlock()
+}
+
+// Value 返回当前值
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	if s := g.vec.lookup(labelValues); s != nil {
+		g.vec.mu.Lock()
+		defer g.vec.mu.Unlock()
+		return s.value
+	}
+	return 0
+}
+
+// HistogramVec 直方图
+type HistogramVec struct {
+	vec *metricVec
+}
+
+// NewHistogramVec 注册直方图，buckets为空时使用DefaultBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{vec: r.register(name, help, typeHistogram, labels, buckets)}
+}
+
+// Observe 记录一个观测值
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.vec.mu.Lock()
+	defer h.vec.mu.Unlock()
+
+	s := h.vec.with(labelValues)
+	s.count++
+	s.sum += v
+	if i := sort.SearchFloat64s(h.vec.buckets, v); i < len(s.counts) {
+		s.counts[i]++
+	}
+}
+
+// Count 返回观测次数
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	if s := h.vec.lookup(labelValues); s != nil {
+		h.vec.mu.Lock()
+		defer h.vec.mu.Unlock()
+		return s.count
+	}
+	return 0
+}
+
+// Sum 返回观测值之和
+func (h *HistogramVec) Sum(labelValues ...string) float64 {
+	if s := h.vec.lookup(labelValues); s != nil {
+		h.vec.mu.Lock()
+		defer h.vec.mu.Unlock()
+		return s.sum
+	}
+	return 0
+}
+
+// WriteText 以Prometheus文本格式输出全部指标，指标与标签按字典序排列
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := make([]*metricVec, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.mu.RUnlock()
+
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		m.writeText(&buf)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeText 输出单个指标
+func (m *metricVec) writeText(buf *bytes.Buffer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.help != "" {
+		fmt.Fprintf(buf, "# HELP %s %s\n", m.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(m.help))
+	}
+	fmt.Fprintf(buf, "# TYPE %s %s\n", m.name, m.kind)
+
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := m.series[key]
+		if m.kind != typeHistogram {
+			fmt.Fprintf(buf, "%s%s %s\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), formatFloat(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, upper := range m.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(buf, "%s_bucket%s %d\n", m.name, formatLabels(m.labels, s.labelValues, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", m.name, formatLabels(m.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(buf, "%s_sum%s %s\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(buf, "%s_count%s %d\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), s.count)
+	}
+}
+
+// Handler 返回输出指标的HTTP处理器，可挂载到 /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WriteText(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// formatLabels 格式化标签，extraName非空时追加额外标签（如直方图的le）
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, name, escaper.Replace(values[i])))
+	}
+	if extraName != "" {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatFloat 格式化浮点数
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
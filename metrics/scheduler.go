@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// SchedulerMetrics 定时任务指标收集器
+type SchedulerMetrics struct {
+	runs        *CounterVec
+	lastSuccess *GaugeVec
+
+	mu    sync.RWMutex
+	tasks map[string]bool
+}
+
+// NewSchedulerMetrics 在注册表中创建定时任务指标，tasks为允许作为标签的任务名
+func NewSchedulerMetrics(registry *Registry, tasks ...string) *SchedulerMetrics {
+	m := &SchedulerMetrics{
+		runs:        registry.NewCounterVec("scheduler_task_runs_total", "定时任务执行次数", "task", "status"),
+		lastSuccess: registry.NewGaugeVec("scheduler_task_last_success_timestamp", "定时任务最近一次成功的Unix时间戳", "task"),
+		tasks:       make(map[string]bool),
+	}
+	m.RegisterTask(tasks...)
+	return m
+}
+
+// RegisterTask 登记任务名
+func (m *SchedulerMetrics) RegisterTask(tasks ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range tasks {
+		m.tasks[t] = true
+	}
+}
+
+// TaskRun 记录一次任务执行，err为nil时更新最近成功时间
+func (m *SchedulerMetrics) TaskRun(task string, err error, finishedAt time.Time) {
+	m.mu.RLock()
+	if !m.tasks[task] {
+		task = UnknownLabel
+	}
+	m.mu.RUnlock()
+
+	if err != nil {
+		m.runs.Inc(task, "failed")
+		return
+	}
+	m.runs.Inc(task, "success")
+	m.lastSuccess.Set(float64(finishedAt.Unix()), task)
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"strings"
+)
+
+// TestRegistry 供测试使用的独立注册表，避免污染全局注册表
+type TestRegistry struct {
+	*Registry
+}
+
+// NewTestRegistry 创建测试注册表
+func NewTestRegistry() *TestRegistry {
+	return &TestRegistry{Registry: NewRegistry()}
+}
+
+// CounterValue 返回计数器的值，指标不存在时返回0
+func (r *TestRegistry) CounterValue(name string, labelValues ...string) float64 {
+	return r.value(name, typeCounter, labelValues)
+}
+
+// GaugeValue 返回仪表的值，指标不存在时返回0
+func (r *TestRegistry) GaugeValue(name string, labelValues ...string) float64 {
+	return r.value(name, typeGauge, labelValues)
+}
+
+// HistogramCount 返回直方图的观测次数，指标不存在时返回0
+func (r *TestRegistry) HistogramCount(name string, labelValues ...string) uint64 {
+	m := r.metric(name, typeHistogram)
+	if m == nil {
+		return 0
+	}
+	return (&HistogramVec{vec: m}).Count(labelValues...)
+}
+
+// Scrape 返回文本格式的全部指标
+func (r *TestRegistry) Scrape() string {
+	var sb strings.Builder
+	_ = r.WriteText(&sb)
+	return sb.String()
+}
+
+// value 读取计数器或仪表的值
+func (r *TestRegistry) value(name, kind string, labelValues []string) float64 {
+	m := r.metric(name, kind)
+	if m == nil {
+		return 0
+	}
+	if s := m.lookup(labelValues); s != nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return s.value
+	}
+	return 0
+}
+
+// metric 按名称与类型查找指标
+func (r *TestRegistry) metric(name, kind string) *metricVec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if m, ok := r.metrics[name]; ok && m.kind == kind {
+		return m
+	}
+	return nil
+}
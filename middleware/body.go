@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/zzliekkas/flow/v2"
+)
+
+// BodyLimit 路由级请求体大小限制属性，通过 flow.WithAttr(middleware.MaxBody(n)) 附加到路由或路由组
+type BodyLimit int64
+
+// MaxBody 创建请求体大小限制属性
+func MaxBody(limit int64) BodyLimit {
+	return BodyLimit(limit)
+}
+
+// CaptureBody 返回预先缓存原始请求体的中间件，处理函数中可通过 c.RawBody() 读取且绑定仍然可用
+//
+// 路由设置了 BodyLimit 属性时优先使用该限制；limit <= 0 时使用引擎的默认限制；routes 为空时作用于所有请求，
+// 否则只作用于匹配的路由（路由模式如 "/webhooks/:provider" 或路径，以*结尾表示前缀匹配）。
+// 超过限制返回413；multipart 请求不会被缓存，以免把上传的文件读入内存
+func CaptureBody(limit int64, routes ...string) flow.HandlerFunc {
+	return func(c *flow.Context) {
+		if len(routes) > 0 && !matchCaptureRoute(c, routes) {
+			c.Next()
+			return
+		}
+
+		if routeLimit := flow.AttrOr(c, BodyLimit(limit)); routeLimit > 0 {
+			c.SetRawBodyLimit(int64(routeLimit))
+		}
+		if _, err := c.RawBody(); err != nil {
+			switch {
+			case errors.Is(err, flow.ErrMultipartBody):
+				// 不缓存文件上传
+			case errors.Is(err, flow.ErrBodyTooLarge):
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, flow.H{"error": err.Error()})
+				return
+			default:
+				c.AbortWithStatusJSON(http.StatusBadRequest, flow.H{"error": err.Error()})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// matchCaptureRoute 判断请求是否命中需要缓存请求体的路由
+func matchCaptureRoute(c *flow.Context, routes []string) bool {
+	fullPath := c.FullPath()
+	for _, route := range routes {
+		if strings.HasSuffix(route, "*") {
+			if strings.HasPrefix(c.Request.URL.Path, strings.TrimSuffix(route, "*")) {
+				return true
+			}
+		} else if route == fullPath || route == c.Request.URL.Path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zzliekkas/flow/v2"
+)
+
+func TestCaptureBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := flow.New()
+	e.Use(CaptureBody(16, "/hooks/:provider"))
+	handler := func(c *flow.Context) {
+		var payload map[string]interface{}
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		raw, err := c.RawBody()
+		if err != nil {
+			c.String(http.StatusConflict, err.Error())
+			return
+		}
+		c.String(http.StatusOK, string(raw))
+	}
+	e.POST("/hooks/:provider", handler)
+	e.POST("/api/items", handler)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	// 命中的路由先缓存请求体，绑定后仍可读取
+	w := post("/hooks/github", `{"a":1}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"a":1}`, w.Body.String())
+
+	w = post("/hooks/github", `{"a":"0123456789abcdef"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// 未命中的路由不缓存，绑定之后读取会得到明确的错误
+	w = post("/api/items", `{"a":1}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "CaptureBody")
+}
+
+func TestCaptureBody_RouteLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := flow.New()
+	e.Use(CaptureBody(8))
+	handler := func(c *flow.Context) {
+		raw, _ := c.RawBody()
+		c.String(http.StatusOK, string(raw))
+	}
+	e.POST("/small", handler)
+	e.POST("/upload", handler, flow.WithAttr(MaxBody(64)))
+
+	post := func(path string) int {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"a":"0123456789"}`)))
+		return w.Code
+	}
+
+	// 路由属性优先于中间件参数
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/small"))
+	assert.Equal(t, http.StatusOK, post("/upload"))
+}
+
+func TestCaptureBody_SkipsMultipart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := flow.New()
+	e.Use(CaptureBody(8))
+	e.POST("/upload", func(c *flow.Context) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, file.Filename)
+	})
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, _ := form.CreateFormFile("file", "large.bin")
+	_, _ = part.Write(bytes.Repeat([]byte("x"), 1024))
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+
+	// 文件上传超过限制也不会被拒绝或读入内存
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "large.bin", w.Body.String())
+}
@@ -0,0 +1,316 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/flowctx"
+	"github.com/zzliekkas/flow/v2/redact"
+)
+
+// CaptureFormatVersion 捕获文件的格式版本，字段只增不改，不兼容的修改需要增加版本
+const CaptureFormatVersion = 1
+
+// DefaultCaptureHeader 按请求开启捕获的调试请求头
+const DefaultCaptureHeader = "X-Flow-Capture"
+
+// 捕获原因
+const (
+	CaptureReasonRoute  = "route"  // 命中配置的路由
+	CaptureReasonSample = "sample" // 按采样率选中
+	CaptureReasonHeader = "header" // 携带调试请求头且通过授权
+)
+
+// CaptureRecord 一次捕获的请求与响应摘要，以 JSON 文件保存，可用 flow replay 重放
+type CaptureRecord struct {
+	Version    int              `json:"version"`
+	ID         string           `json:"id"`
+	CapturedAt time.Time        `json:"captured_at"`
+	Reason     string           `json:"reason"`
+	Route      string           `json:"route,omitempty"`
+	Request    CapturedRequest  `json:"request"`
+	Response   CapturedResponse `json:"response"`
+}
+
+// CapturedRequest 捕获的请求，请求头、查询参数与请求体已脱敏
+type CapturedRequest struct {
+	Method       string      `json:"method"`
+	Host         string      `json:"host,omitempty"`
+	Path         string      `json:"path"`
+	Query        string      `json:"query,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // 为 "base64" 时请求体不是 UTF-8 文本
+	BodySize     int64       `json:"body_size"`
+	Truncated    bool        `json:"truncated,omitempty"`    // 请求体超过上限，只保存了开头部分
+	BodyOmitted  bool        `json:"body_omitted,omitempty"` // 请求体无法可靠脱敏，没有保存
+}
+
+// CapturedResponse 捕获的响应摘要
+type CapturedResponse struct {
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+	BodySize     int         `json:"body_size"`
+	Truncated    bool        `json:"truncated,omitempty"`
+	BodyOmitted  bool        `json:"body_omitted,omitempty"`
+	LatencyMS    float64     `json:"latency_ms"`
+}
+
+// CaptureSink 捕获文件的保存位置
+//
+// 默认使用 DirCaptureSink 写入本地目录，保存到存储磁盘时实现该接口即可
+type CaptureSink interface {
+	WriteCapture(ctx context.Context, record *CaptureRecord) error
+}
+
+// DirCaptureSink 将每次捕获写入目录中的一个 JSON 文件
+type DirCaptureSink struct {
+	Dir string
+}
+
+// NewDirCaptureSink 创建目录捕获位置，目录不存在时在首次写入时创建
+func NewDirCaptureSink(dir string) *DirCaptureSink {
+	return &DirCaptureSink{Dir: dir}
+}
+
+// WriteCapture 写入 <时间>-<ID>.json，先写临时文件再重命名，不会留下不完整的文件
+func (s *DirCaptureSink) WriteCapture(ctx context.Context, record *CaptureRecord) error {
+	if err := os.MkdirAll(s.Dir, 0o750); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := record.CapturedAt.UTC().Format("20060102T150405.000Z") + "-" + sanitizeFileName(record.ID) + ".json"
+	tmp, err := os.CreateTemp(s.Dir, ".capture-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.Dir, name))
+}
+
+// sanitizeFileName 只保留文件名中安全的字符
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// ReadCaptureFile 读取捕获文件
+func ReadCaptureFile(path string) (*CaptureRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var record CaptureRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("解析捕获文件失败: %w", err)
+	}
+	if record.Version == 0 || record.Version > CaptureFormatVersion {
+		return nil, fmt.Errorf("不支持的捕获文件版本: %d", record.Version)
+	}
+	return &record, nil
+}
+
+// CaptureConfig 请求捕获中间件的配置，Routes、SampleRate 与 Authorize 都未设置时不捕获任何请求
+type CaptureConfig struct {
+	// Sink 捕获文件的保存位置，必填
+	Sink CaptureSink
+
+	// Routes 始终捕获的路由，匹配路由模板（如 /orders/:id）或请求路径
+	Routes []string
+
+	// SampleRate 其他请求的采样率（0~1），为0时不采样
+	SampleRate float64
+
+	// SampleSource 采样使用的随机源，为nil时使用按时间播种的随机源
+	SampleSource rand.Source
+
+	// Header 按请求开启捕获的请求头，默认 DefaultCaptureHeader
+	Header string
+
+	// Authorize 判断携带 Header 的请求是否允许捕获，为nil时忽略该请求头，
+	// 避免任何人都能让服务保存自己的请求
+	Authorize func(c *flow.Context) bool
+
+	// MaxBodySize 请求体与响应体保存的最大字节数，默认64KB
+	MaxBodySize int
+
+	// Redaction 脱敏策略，为nil时使用 redact.Default()
+	Redaction *redact.Policy
+
+	// ErrorLogWriter 写入失败时的处理函数，为nil时输出到标准错误
+	ErrorLogWriter ErrorLogWriterFunc
+}
+
+// Capture 返回请求捕获中间件，将选中的请求连同响应摘要保存为可移植的 JSON 文件，
+// 用于在本地用 flow replay 复现线上问题
+//
+// 请求头、查询参数与 JSON/表单请求体按 redact 策略脱敏；由 flow replay 重放的请求不会再次被捕获
+func Capture(config CaptureConfig) flow.HandlerFunc {
+	if config.Sink == nil {
+		panic("middleware: Capture 需要设置 Sink")
+	}
+	if config.Header == "" {
+		config.Header = DefaultCaptureHeader
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 64 << 10
+	}
+	if config.Redaction == nil {
+		config.Redaction = redact.Default()
+	}
+	if config.ErrorLogWriter == nil {
+		config.ErrorLogWriter = func(err error) {
+			fmt.Fprintf(os.Stderr, "Capture middleware error: %v\n", err)
+		}
+	}
+	routes := make(map[string]struct{}, len(config.Routes))
+	for _, route := range config.Routes {
+		routes[route] = struct{}{}
+	}
+	var sampler *logSampler
+	if config.SampleRate > 0 {
+		sampler = newLogSampler(config.SampleRate, config.SampleSource)
+	}
+
+	return func(c *flow.Context) {
+		if IsReplay(c.Request) {
+			c.Next()
+			return
+		}
+
+		reason := ""
+		_, routeMatched := routes[c.FullPath()]
+		if _, pathMatched := routes[c.Request.URL.Path]; routeMatched || pathMatched {
+			reason = CaptureReasonRoute
+		} else if c.GetHeader(config.Header) != "" && config.Authorize != nil && config.Authorize(c) {
+			reason = CaptureReasonHeader
+		} else if sampler != nil && sampler.sample() {
+			reason = CaptureReasonSample
+		}
+		if reason == "" {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		record := &CaptureRecord{
+			Version:    CaptureFormatVersion,
+			ID:         c.GetString(flowctx.KeyRequestID),
+			CapturedAt: start,
+			Reason:     reason,
+			Route:      c.FullPath(),
+			Request:    captureRequest(c.Request, config.Redaction, config.MaxBodySize),
+		}
+		if record.ID == "" {
+			record.ID = fmt.Sprintf("%d", start.UnixNano())
+		}
+
+		writer := &ResponseBodyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+
+		body := writer.body.Bytes()
+		record.Response = CapturedResponse{
+			Status:    c.Writer.Status(),
+			Header:    config.Redaction.Header(c.Writer.Header()),
+			BodySize:  len(body),
+			LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if len(body) > config.MaxBodySize {
+			body, record.Response.Truncated = body[:config.MaxBodySize], true
+		}
+		record.Response.Body, record.Response.BodyEncoding, record.Response.BodyOmitted = captureBody(config.Redaction, body, c.Writer.Header().Get("Content-Type"), record.Response.Truncated)
+
+		if err := config.Sink.WriteCapture(c.Request.Context(), record); err != nil {
+			config.ErrorLogWriter(fmt.Errorf("failed to write capture: %w", err))
+		}
+	}
+}
+
+// captureRequest 读取并还原请求体，返回脱敏后的请求
+func captureRequest(r *http.Request, policy *redact.Policy, maxBody int) CapturedRequest {
+	captured := CapturedRequest{
+		Method: r.Method,
+		Host:   r.Host,
+		Path:   r.URL.Path,
+		Query:  policy.Query(r.URL.RawQuery),
+		Header: policy.Header(r.Header),
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return captured
+	}
+
+	// 只读取上限内的部分，其余内容原样留给处理函数
+	head, _ := io.ReadAll(io.LimitReader(r.Body, int64(maxBody)+1))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), r.Body), Closer: r.Body}
+	captured.BodySize = int64(len(head))
+	if r.ContentLength > captured.BodySize {
+		captured.BodySize = r.ContentLength
+	}
+	if len(head) > maxBody {
+		head, captured.Truncated = head[:maxBody], true
+	}
+	captured.Body, captured.BodyEncoding, captured.BodyOmitted = captureBody(policy, head, r.Header.Get("Content-Type"), captured.Truncated)
+	return captured
+}
+
+// readCloser 组合读取与关闭
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureBody 返回保存的请求体或响应体、编码以及是否省略
+//
+// JSON 与表单按策略脱敏；被截断或无法解析的 JSON 无法可靠脱敏，不保存原文
+func captureBody(policy *redact.Policy, body []byte, contentType string, truncated bool) (string, string, bool) {
+	if len(body) == 0 {
+		return "", "", false
+	}
+	trimmed := bytes.TrimSpace(body)
+	looksJSON := len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+	switch {
+	case looksJSON || strings.Contains(contentType, "json"):
+		if !truncated {
+			if masked, err := policy.JSON(body); err == nil {
+				return string(masked), "", false
+			}
+		}
+		return "", "", true
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		return policy.Query(string(body)), "", false
+	case utf8.Valid(body):
+		return string(body), "", false
+	default:
+		return base64.StdEncoding.EncodeToString(body), "base64", false
+	}
+}
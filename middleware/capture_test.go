@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+)
+
+// memoryCaptureSink 将捕获记录保存在内存中
+type memoryCaptureSink struct {
+	mu      sync.Mutex
+	records []*CaptureRecord
+}
+
+func (s *memoryCaptureSink) WriteCapture(ctx context.Context, record *CaptureRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+// newCaptureEngine 创建挂载捕获中间件的引擎，/orders/:id 返回固定的 JSON
+func newCaptureEngine(config CaptureConfig, total *int) *flow.Engine {
+	gin.SetMode(gin.TestMode)
+	e := flow.New()
+	e.Use(Capture(config))
+	e.POST("/orders/:id", func(c *flow.Context) {
+		var body map[string]interface{}
+		_ = c.ShouldBindJSON(&body)
+		*total++
+		c.JSON(http.StatusOK, flow.H{"id": c.Param("id"), "total": *total, "token": "tok_secret"})
+	})
+	e.GET("/ping", func(c *flow.Context) { c.String(http.StatusOK, "pong") })
+	return e
+}
+
+func newOrderRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/orders/42?sig=abc&page=2", strings.NewReader(`{"item":"book","password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer prod-token")
+	return req
+}
+
+func TestCapture_FileFormat(t *testing.T) {
+	dir := t.TempDir()
+	total := 0
+	e := newCaptureEngine(CaptureConfig{Sink: NewDirCaptureSink(dir), Routes: []string{"/orders/:id"}}, &total)
+
+	e.ServeHTTP(httptest.NewRecorder(), newOrderRequest())
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1, "只捕获配置的路由")
+
+	// 文件格式的字段名是兼容性承诺
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	var raw map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &raw))
+	for _, key := range []string{"version", "id", "captured_at", "reason", "route", "request", "response"} {
+		assert.Contains(t, raw, key)
+	}
+	var request, response map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw["request"], &request))
+	require.NoError(t, json.Unmarshal(raw["response"], &response))
+	for _, key := range []string{"method", "path", "query", "header", "body", "body_size"} {
+		assert.Contains(t, request, key)
+	}
+	for _, key := range []string{"status", "header", "body", "body_size", "latency_ms"} {
+		assert.Contains(t, response, key)
+	}
+
+	record, err := ReadCaptureFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, CaptureFormatVersion, record.Version)
+	assert.Equal(t, CaptureReasonRoute, record.Reason)
+	assert.Equal(t, "/orders/:id", record.Route)
+	assert.Equal(t, "/orders/42", record.Request.Path)
+	assert.Equal(t, http.StatusOK, record.Response.Status)
+
+	// 未来版本的文件无法读取
+	require.NoError(t, os.WriteFile(files[0], []byte(`{"version": 99}`), 0o600))
+	_, err = ReadCaptureFile(files[0])
+	assert.Error(t, err)
+}
+
+func TestCapture_Redaction(t *testing.T) {
+	sink := &memoryCaptureSink{}
+	total := 0
+	e := newCaptureEngine(CaptureConfig{Sink: sink, Routes: []string{"/orders/:id"}, MaxBodySize: 1024}, &total)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, newOrderRequest())
+	assert.Equal(t, http.StatusOK, w.Code, "处理函数仍能读取完整的请求体")
+	require.Len(t, sink.records, 1)
+	record := sink.records[0]
+
+	assert.Equal(t, []string{"***MASKED***"}, record.Request.Header["Authorization"])
+	assert.JSONEq(t, `{"item":"book","password":"***MASKED***"}`, record.Request.Body)
+	assert.JSONEq(t, `{"id":"42","total":1,"token":"***MASKED***"}`, record.Response.Body)
+	data, _ := json.Marshal(record)
+	for _, secret := range []string{"prod-token", "hunter2", "tok_secret"} {
+		assert.NotContains(t, string(data), secret)
+	}
+
+	// 被截断的 JSON 无法可靠脱敏，不保存
+	sink.records = nil
+	e = newCaptureEngine(CaptureConfig{Sink: sink, Routes: []string{"/orders/:id"}, MaxBodySize: 10}, &total)
+	e.ServeHTTP(httptest.NewRecorder(), newOrderRequest())
+	record = sink.records[0]
+	assert.True(t, record.Request.Truncated)
+	assert.True(t, record.Request.BodyOmitted)
+	assert.Empty(t, record.Request.Body)
+	assert.NotContains(t, record.Response.Body, "tok_secret")
+}
+
+func TestCapture_Arming(t *testing.T) {
+	sink := &memoryCaptureSink{}
+	total := 0
+	e := newCaptureEngine(CaptureConfig{
+		Sink:      sink,
+		Authorize: func(c *flow.Context) bool { return c.GetHeader("X-Admin") == "yes" },
+	}, &total)
+
+	// 调试请求头需要授权
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(DefaultCaptureHeader, "1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Empty(t, sink.records)
+
+	req.Header.Set("X-Admin", "yes")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.Len(t, sink.records, 1)
+	assert.Equal(t, CaptureReasonHeader, sink.records[0].Reason)
+	assert.Equal(t, "pong", sink.records[0].Response.Body)
+
+	// 重放的请求不再捕获
+	req.Header.Set(ReplayHeader, "x")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Len(t, sink.records, 1)
+
+	// 采样
+	sink.records = nil
+	e = newCaptureEngine(CaptureConfig{Sink: sink, SampleRate: 0.5, SampleSource: rand.NewSource(1)}, &total)
+	for i := 0; i < 100; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	}
+	assert.InDelta(t, 50, len(sink.records), 20)
+	assert.Equal(t, CaptureReasonSample, sink.records[0].Reason)
+}
+
+func TestReplay_InProcessDiff(t *testing.T) {
+	sink := &memoryCaptureSink{}
+	total := 0
+	e := newCaptureEngine(CaptureConfig{Sink: sink, Routes: []string{"/orders/:id"}}, &total)
+	e.ServeHTTP(httptest.NewRecorder(), newOrderRequest())
+	require.Len(t, sink.records, 1)
+	record := sink.records[0]
+
+	total = 0
+	result, err := Replay(context.Background(), record, ReplayOptions{Handler: e})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, result.Status)
+	assert.Empty(t, result.Diff, "相同的响应没有差异")
+
+	result, err = Replay(context.Background(), record, ReplayOptions{Handler: e, Headers: http.Header{"authorization": {"Bearer local"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"$.total: 记录 1，重放 2"}, result.Diff)
+
+	// 状态码、Content-Type 与响应体都不同
+	e2 := flow.New()
+	e2.POST("/orders/:id", func(c *flow.Context) { c.String(http.StatusInternalServerError, "boom") })
+	result, err = Replay(context.Background(), record, ReplayOptions{Handler: e2})
+	require.NoError(t, err)
+	assert.Contains(t, result.Diff, "状态码: 记录 200，重放 500")
+	assert.Len(t, result.Diff, 3)
+
+	// 请求体未保存时无法重放
+	record.Request.BodyOmitted = true
+	_, err = Replay(context.Background(), record, ReplayOptions{Handler: e})
+	assert.ErrorIs(t, err, ErrReplayBodyOmitted)
+}
+
+// 已脱敏的请求头不发送，其他请求头与重放标记发送
+func TestReplay_OverHTTP(t *testing.T) {
+	var got http.Header
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	record := &CaptureRecord{
+		Version: CaptureFormatVersion, ID: "req-1", CapturedAt: time.Now(),
+		Request: CapturedRequest{
+			Method: http.MethodPost, Path: "/hook", Query: "a=1", Body: "payload",
+			Header: http.Header{"Authorization": {"***MASKED***"}, "X-Trace": {"t1"}},
+		},
+		Response: CapturedResponse{Status: http.StatusOK, Header: http.Header{"Content-Type": {"text/plain"}}, Body: "ok"},
+	}
+	result, err := Replay(context.Background(), record, ReplayOptions{Target: server.URL + "/"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Diff)
+	assert.Equal(t, "payload", body)
+	assert.Equal(t, "req-1", got.Get(ReplayHeader))
+	assert.Equal(t, "t1", got.Get("X-Trace"))
+	assert.Empty(t, got.Get("Authorization"))
+}
+
+func TestRejectReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := flow.New()
+	e.POST("/payments", RejectReplay(), func(c *flow.Context) { c.Status(http.StatusCreated) })
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/payments", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+	req.Header.Set(ReplayHeader, "req-1")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
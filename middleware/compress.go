@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zzliekkas/flow/v2"
+)
+
+// GzipConfig 是压缩中间件的配置选项
+type GzipConfig struct {
+	// Level 压缩级别，默认 gzip.DefaultCompression
+	Level int
+
+	// ExcludedPaths 不压缩的路径，以*结尾表示前缀匹配
+	ExcludedPaths []string
+}
+
+// DefaultGzipConfig 返回压缩中间件的默认配置
+func DefaultGzipConfig() GzipConfig {
+	return GzipConfig{
+		Level: gzip.DefaultCompression,
+	}
+}
+
+// Gzip 返回一个使用默认配置的gzip压缩中间件
+func Gzip() flow.HandlerFunc {
+	return GzipWithConfig(DefaultGzipConfig())
+}
+
+// GzipWithConfig 返回一个使用指定配置的gzip压缩中间件
+//
+// 响应写入器实现了 http.Flusher：Flush 会先刷新gzip块再刷新连接，
+// 因此 JSONStream/NDJSON 等流式响应的刷新边界在压缩后依然保留
+func GzipWithConfig(config GzipConfig) flow.HandlerFunc {
+	if config.Level == 0 {
+		config.Level = gzip.DefaultCompression
+	}
+
+	pool := sync.Pool{
+		New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(nil, config.Level)
+			return w
+		},
+	}
+
+	return func(c *flow.Context) {
+		if !shouldGzip(c.Request, config.ExcludedPaths) {
+			c.Next()
+			return
+		}
+
+		gz := pool.Get().(*gzip.Writer)
+		gz.Reset(c.Writer)
+
+		header := c.Writer.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+
+		writer := &gzipResponseWriter{ResponseWriter: c.Writer, gz: gz}
+		c.Writer = writer
+
+		defer func() {
+			if writer.Size() <= 0 && !writer.Written() {
+				// 没有响应体时不输出gzip头
+				header.Del("Content-Encoding")
+			} else {
+				_ = gz.Close()
+			}
+			header.Del("Content-Length")
+			gz.Reset(nil)
+			pool.Put(gz)
+		}()
+
+		c.Next()
+	}
+}
+
+// shouldGzip 判断请求是否需要压缩
+func shouldGzip(r *http.Request, excluded []string) bool {
+	if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		return false
+	}
+	if r.Header.Get("Upgrade") != "" || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return false
+	}
+	for _, path := range excluded {
+		if strings.HasSuffix(path, "*") {
+			if strings.HasPrefix(r.URL.Path, strings.TrimSuffix(path, "*")) {
+				return false
+			}
+		} else if r.URL.Path == path {
+			return false
+		}
+	}
+	return true
+}
+
+// gzipResponseWriter 压缩响应写入器
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	gz *gzip.Writer
+}
+
+// WriteHeader 写入状态码，压缩后的长度未知，因此移除Content-Length
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write 压缩写入响应体
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	w.Header().Del("Content-Length")
+	return w.gz.Write(data)
+}
+
+// WriteString 压缩写入字符串
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 刷新gzip缓冲并刷新底层连接
+func (w *gzipResponseWriter) Flush() {
+	_ = w.gz.Flush()
+	w.ResponseWriter.Flush()
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+)
+
+func TestGzip_StreamingKeepsFlushBoundaries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	release := make(chan struct{})
+
+	e := flow.New()
+	e.Use(Gzip())
+	e.GET("/export.ndjson", func(c *flow.Context) {
+		_ = c.NDJSON(http.StatusOK, func(enc *flow.StreamEncoder) error {
+			for i := 0; i < 20; i++ {
+				if i == 10 {
+					// 前10条已刷新，等待客户端读取后再继续
+					<-release
+				}
+				if err := enc.Encode(map[string]int{"id": i}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, flow.WithStreamFlushItems(10))
+	})
+	e.GET("/export.json", func(c *flow.Context) {
+		_ = c.JSONStream(http.StatusOK, func(enc *flow.StreamEncoder) error {
+			for i := 0; i < 1000; i++ {
+				if err := enc.Encode(i); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/export.ndjson", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(reader)
+
+	// 处理器阻塞期间客户端应已能解压出前10条
+	received := make(chan int, 20)
+	go func() {
+		for scanner.Scan() {
+			var row map[string]int
+			if json.Unmarshal(scanner.Bytes(), &row) == nil {
+				received <- row["id"]
+			}
+		}
+		close(received)
+	}()
+	for i := 0; i < 10; i++ {
+		select {
+		case id := <-received:
+			assert.Equal(t, i, id)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("第 %d 条数据未在刷新后到达", i)
+		}
+	}
+
+	close(release)
+	rest := 0
+	for range received {
+		rest++
+	}
+	assert.Equal(t, 10, rest)
+
+	// 数组模式压缩后仍是完整的JSON
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/export.json", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err = http.DefaultTransport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	reader, err = gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	var items []int
+	require.NoError(t, json.NewDecoder(reader).Decode(&items))
+	assert.Len(t, items, 1000)
+	assert.Equal(t, 999, items[999])
+}
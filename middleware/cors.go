@@ -1,25 +1,38 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/zzliekkas/flow/v2"
 )
 
+// ErrCORSCredentialsWildcard 允许凭证时不能使用通配源
+var ErrCORSCredentialsWildcard = errors.New("CORS配置错误: AllowCredentials 不能与 AllowOrigins \"*\" 同时使用")
+
 // CORSConfig 是CORS中间件的配置选项
 type CORSConfig struct {
 	// AllowOrigins 是允许的源列表，例如 ["https://example.com"]
-	// 特殊的 "*" 表示允许所有源
+	// 特殊的 "*" 表示允许所有源，"https://*.example.com" 匹配任意子域名（不含 example.com 本身）且不带端口的源；
+	// "https://*.example.com:8443" 只匹配该端口，"https://*.example.com:*" 匹配任意端口
 	AllowOrigins []string
 
+	// AllowOriginFunc 动态判断源是否允许，例如多租户的自定义域名
+	// 在 AllowOrigins 未匹配时调用
+	AllowOriginFunc func(origin string) bool
+
 	// AllowMethods 是允许的HTTP方法列表
 	// 默认是 ["GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"]
 	AllowMethods []string
 
 	// AllowHeaders 是允许的HTTP头部列表
 	// 默认是 ["Origin", "Content-Type", "Content-Length", "Accept", "Authorization"]
+	// 预检请求只回显在此列表中的请求头，"*" 表示回显所有请求头
 	AllowHeaders []string
 
 	// ExposeHeaders 是客户端可以访问的自定义头部列表
@@ -33,6 +46,19 @@ type CORSConfig struct {
 
 	// AllowPrivateNetwork 指示是否允许私有网络请求
 	AllowPrivateNetwork bool
+
+	// Policies 按路径覆盖的策略，例如管理后台使用更严格的源列表
+	// 精确路径优先于前缀（以*结尾），前缀越长优先级越高，未匹配时使用本配置
+	Policies []CORSPolicy
+}
+
+// CORSPolicy 路径级CORS策略
+type CORSPolicy struct {
+	// Path 路由路径，支持 :param 参数与以*结尾的前缀匹配，例如 "/admin/*"
+	Path string
+
+	// Config 该路径使用的完整配置（不会与全局配置合并）
+	Config CORSConfig
 }
 
 // DefaultCORSConfig 返回CORS中间件的默认配置
@@ -48,15 +74,145 @@ func DefaultCORSConfig() CORSConfig {
 	}
 }
 
+// Validate 检查配置是否合法，包括所有路径策略
+//
+// 检查的是补齐默认值之后的配置：未配置任何源时默认允许所有源，因此允许凭证时必须显式列出源
+func (config CORSConfig) Validate() error {
+	config = config.withDefaults()
+	if config.AllowCredentials {
+		for _, origin := range config.AllowOrigins {
+			if origin == "*" {
+				return ErrCORSCredentialsWildcard
+			}
+		}
+	}
+	for _, origin := range config.AllowOrigins {
+		if origin == "*" || !strings.Contains(origin, "*") {
+			continue
+		}
+		if _, ok := parseWildcardOrigin(origin); !ok {
+			return fmt.Errorf("CORS配置错误: 无效的通配源 %q，只支持 scheme://*.domain[:port] 形式", origin)
+		}
+	}
+	for _, policy := range config.Policies {
+		if policy.Path == "" {
+			return errors.New("CORS配置错误: 策略路径不能为空")
+		}
+		if len(policy.Config.Policies) > 0 {
+			return fmt.Errorf("CORS配置错误: 策略 %s 不能嵌套 Policies", policy.Path)
+		}
+		if err := policy.Config.Validate(); err != nil {
+			return fmt.Errorf("%w (策略 %s)", err, policy.Path)
+		}
+	}
+	return nil
+}
+
 // CORS 返回一个CORS中间件，允许所有源访问
 func CORS() flow.HandlerFunc {
 	return CORSWithConfig(DefaultCORSConfig())
 }
 
 // CORSWithConfig 返回一个使用指定配置的CORS中间件
+//
+// 配置不合法时（例如允许凭证同时使用通配源）会在注册时panic，而不是在运行时静默失效。
+// 预检请求在此中间件内直接响应并中止后续处理，因此应将其注册在认证中间件之前
 func CORSWithConfig(config CORSConfig) flow.HandlerFunc {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+
+	global := compileCORSPolicy(config)
+	policies := make([]compiledCORSPath, 0, len(config.Policies))
+	for _, p := range config.Policies {
+		policies = append(policies, compiledCORSPath{
+			path:   p.Path,
+			prefix: strings.HasSuffix(p.Path, "*"),
+			policy: compileCORSPolicy(p.Config),
+		})
+	}
+	// 精确路径优先，其次是更长的前缀
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].prefix != policies[j].prefix {
+			return !policies[i].prefix
+		}
+		return len(policies[i].path) > len(policies[j].path)
+	})
+
+	return func(c *flow.Context) {
+		// 无论是否跨域，响应都随Origin变化，避免缓存串用
+		c.Writer.Header().Add("Vary", "Origin")
+
+		origin := c.Request.Header.Get("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		policy := global
+		for _, p := range policies {
+			if p.match(c.Request.URL.Path) {
+				policy = p.policy
+				break
+			}
+		}
+
+		// 预检请求：直接响应，不进入认证等后续中间件
+		if c.Request.Method == http.MethodOptions && c.Request.Header.Get("Access-Control-Request-Method") != "" {
+			header := c.Writer.Header()
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			policy.handlePreflight(c, origin)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		policy.handleActual(c, origin)
+		c.Next()
+	}
+}
+
+// compiledCORSPolicy 预处理后的CORS策略
+type compiledCORSPolicy struct {
+	config        CORSConfig
+	allowAll      bool
+	origins       map[string]bool
+	wildcards     []wildcardOrigin
+	methods       []string
+	methodSet     map[string]bool
+	headers       []string
+	headerSet     map[string]bool
+	allowAnyHdr   bool
+	exposeHeaders string
+	maxAge        string
+}
+
+// wildcardOrigin 子域名通配源
+type wildcardOrigin struct {
+	scheme string
+	suffix string // 形如 ".example.com"
+	port   string // 为空时只匹配不带端口的源，"*" 匹配任意端口
+}
+
+// compiledCORSPath 路径策略
+type compiledCORSPath struct {
+	path   string
+	prefix bool
+	policy *compiledCORSPolicy
+}
+
+// match 判断请求路径是否命中策略
+func (p compiledCORSPath) match(requestPath string) bool {
+	if p.prefix {
+		return strings.HasPrefix(requestPath, strings.TrimSuffix(p.path, "*"))
+	}
+	return matchCORSPath(p.path, requestPath)
+}
+
+// withDefaults 返回补齐默认值后的配置
+func (config CORSConfig) withDefaults() CORSConfig {
 	// 如果没有配置来源，使用默认的所有来源
-	if len(config.AllowOrigins) == 0 {
+	if len(config.AllowOrigins) == 0 && config.AllowOriginFunc == nil {
 		config.AllowOrigins = []string{"*"}
 	}
 
@@ -69,140 +225,199 @@ func CORSWithConfig(config CORSConfig) flow.HandlerFunc {
 	if len(config.AllowHeaders) == 0 {
 		config.AllowHeaders = []string{"Origin", "Content-Type", "Content-Length", "Accept", "Authorization"}
 	}
+	return config
+}
 
-	// 方法和头部转换为大写
-	allowMethods := normalizeHeaders(config.AllowMethods)
-	allowHeaders := normalizeHeaders(config.AllowHeaders)
-	exposeHeaders := normalizeHeaders(config.ExposeHeaders)
+// compileCORSPolicy 预处理配置，补齐默认值
+func compileCORSPolicy(config CORSConfig) *compiledCORSPolicy {
+	config = config.withDefaults()
+	p := &compiledCORSPolicy{
+		config:    config,
+		origins:   make(map[string]bool),
+		methods:   normalizeHeaders(config.AllowMethods),
+		methodSet: make(map[string]bool),
+		headerSet: make(map[string]bool),
+	}
 
-	// 处理所有源的情况
-	allowAllOrigins := false
-	allowOrigins := []string{}
 	for _, origin := range config.AllowOrigins {
-		if origin == "*" {
-			allowAllOrigins = true
-			break
+		switch {
+		case origin == "*":
+			p.allowAll = true
+		case strings.Contains(origin, "*"):
+			w, _ := parseWildcardOrigin(origin)
+			p.wildcards = append(p.wildcards, w)
+		default:
+			p.origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
 		}
-		allowOrigins = append(allowOrigins, strings.ToLower(origin))
 	}
 
-	return func(c *flow.Context) {
-		// 如果这不是CORS请求，跳过
-		origin := c.Request.Header.Get("Origin")
-		if origin == "" {
-			c.Next()
-			return
+	for _, m := range p.methods {
+		p.methodSet[m] = true
+	}
+	for _, h := range config.AllowHeaders {
+		if h == "*" {
+			p.allowAnyHdr = true
+			continue
 		}
+		canonical := http.CanonicalHeaderKey(strings.TrimSpace(h))
+		if !p.headerSet[canonical] {
+			p.headerSet[canonical] = true
+			p.headers = append(p.headers, canonical)
+		}
+	}
 
-		// 始终设置Vary头部
-		c.Header("Vary", "Origin")
+	if len(config.ExposeHeaders) > 0 {
+		p.exposeHeaders = strings.Join(normalizeHeaders(config.ExposeHeaders), ", ")
+	}
+	if config.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(config.MaxAge)
+	}
+	return p
+}
 
-		// 如果这是一个预检请求，处理预检
-		if c.Request.Method == "OPTIONS" {
-			// 检查请求方法
-			reqMethod := c.Request.Header.Get("Access-Control-Request-Method")
-			if reqMethod == "" {
-				c.Next()
-				return
+// parseWildcardOrigin 解析 scheme://*.domain[:port] 形式的通配源，port 可以是数字或 "*"
+func parseWildcardOrigin(origin string) (wildcardOrigin, bool) {
+	scheme, host, found := strings.Cut(strings.ToLower(origin), "://")
+	if !found || !strings.HasPrefix(host, "*.") {
+		return wildcardOrigin{}, false
+	}
+	var port string
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host, port = host[:i], host[i+1:]
+		if port != "*" {
+			if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 || strconv.Itoa(n) != port {
+				return wildcardOrigin{}, false
 			}
-
-			// 处理预检请求
-			handlePreflightRequest(c, config, allowAllOrigins, allowOrigins, allowMethods, allowHeaders)
-			c.Status(http.StatusNoContent)
-			return
 		}
+	}
+	suffix := host[1:]
+	if strings.ContainsAny(suffix, "*:/") || len(suffix) < 2 {
+		return wildcardOrigin{}, false
+	}
+	return wildcardOrigin{scheme: scheme, suffix: suffix, port: port}, true
+}
 
-		// 简单请求或实际请求
-		handleActualRequest(c, config, allowAllOrigins, allowOrigins, exposeHeaders)
-
-		c.Next()
+// match 判断解析后的源是否命中通配源，按主机名匹配子域名，端口单独比较
+func (w wildcardOrigin) match(u *url.URL) bool {
+	host := u.Hostname()
+	if u.Scheme != w.scheme || !strings.HasSuffix(host, w.suffix) || len(host) <= len(w.suffix) {
+		return false
 	}
+	return w.port == "*" || u.Port() == w.port
 }
 
-// handlePreflightRequest 处理预检请求
-func handlePreflightRequest(c *flow.Context, config CORSConfig, allowAllOrigins bool, allowOrigins, allowMethods, allowHeaders []string) {
-	origin := c.Request.Header.Get("Origin")
+// allowOrigin 判断源是否允许，返回应写入 Access-Control-Allow-Origin 的值
+func (p *compiledCORSPolicy) allowOrigin(origin string) (string, bool) {
+	// 通配源只返回字面量 "*"，从不回显请求的 Origin；允许凭证时通配源在 Validate 中已被拒绝
+	if p.allowAll && !p.config.AllowCredentials {
+		return "*", true
+	}
 
-	// 设置允许的来源
-	if allowAllOrigins {
-		c.Header("Access-Control-Allow-Origin", "*")
-	} else {
-		originLower := strings.ToLower(origin)
-		allowed := false
-		for _, allowOrigin := range allowOrigins {
-			if allowOrigin == originLower {
-				allowed = true
-				break
+	lower := strings.ToLower(origin)
+	if p.origins[lower] {
+		return origin, true
+	}
+
+	if len(p.wildcards) > 0 {
+		if u, err := url.Parse(lower); err == nil && u.Host != "" {
+			for _, w := range p.wildcards {
+				if w.match(u) {
+					return origin, true
+				}
 			}
 		}
+	}
 
-		if allowed {
-			c.Header("Access-Control-Allow-Origin", origin)
-		} else {
-			c.Header("Access-Control-Allow-Origin", "")
-		}
+	if p.config.AllowOriginFunc != nil && p.config.AllowOriginFunc(origin) {
+		return origin, true
 	}
+	return "", false
+}
 
-	// 设置允许的方法
-	c.Header("Access-Control-Allow-Methods", strings.Join(allowMethods, ", "))
+// handlePreflight 处理预检请求，源或方法不被允许时不写入任何CORS头
+func (p *compiledCORSPolicy) handlePreflight(c *flow.Context, origin string) {
+	allowed, ok := p.allowOrigin(origin)
+	if !ok {
+		return
+	}
 
-	// 设置允许的头部
-	reqHeaders := c.Request.Header.Get("Access-Control-Request-Headers")
-	if reqHeaders != "" {
-		c.Header("Access-Control-Allow-Headers", reqHeaders)
-	} else {
-		c.Header("Access-Control-Allow-Headers", strings.Join(allowHeaders, ", "))
+	method := strings.ToUpper(c.Request.Header.Get("Access-Control-Request-Method"))
+	if !p.methodSet[method] {
+		return
 	}
 
-	// 设置允许凭证
-	if config.AllowCredentials {
-		c.Header("Access-Control-Allow-Credentials", "true")
+	header := c.Writer.Header()
+	header.Set("Access-Control-Allow-Origin", allowed)
+	header.Set("Access-Control-Allow-Methods", strings.Join(p.methods, ", "))
+
+	// 只回显在允许列表中的请求头
+	if requested := c.Request.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		var headers []string
+		for _, h := range strings.Split(requested, ",") {
+			h = strings.TrimSpace(h)
+			if h == "" {
+				continue
+			}
+			if p.allowAnyHdr || p.headerSet[http.CanonicalHeaderKey(h)] {
+				headers = append(headers, h)
+			}
+		}
+		if len(headers) > 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+		}
 	}
 
-	// 设置缓存时间
-	if config.MaxAge > 0 {
-		c.Header("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+	if p.config.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if p.maxAge != "" {
+		header.Set("Access-Control-Max-Age", p.maxAge)
 	}
 
-	// 设置允许私有网络
-	if config.AllowPrivateNetwork {
-		c.Header("Access-Control-Allow-Private-Network", "true")
+	if p.config.AllowPrivateNetwork && c.Request.Header.Get("Access-Control-Request-Private-Network") == "true" {
+		header.Set("Access-Control-Allow-Private-Network", "true")
 	}
 }
 
-// handleActualRequest 处理实际请求
-func handleActualRequest(c *flow.Context, config CORSConfig, allowAllOrigins bool, allowOrigins []string, exposeHeaders []string) {
-	origin := c.Request.Header.Get("Origin")
+// handleActual 处理实际请求
+func (p *compiledCORSPolicy) handleActual(c *flow.Context, origin string) {
+	allowed, ok := p.allowOrigin(origin)
+	if !ok {
+		return
+	}
 
-	// 设置允许的来源
-	if allowAllOrigins {
-		c.Header("Access-Control-Allow-Origin", "*")
-	} else {
-		originLower := strings.ToLower(origin)
-		allowed := false
-		for _, allowOrigin := range allowOrigins {
-			if allowOrigin == originLower {
-				allowed = true
-				break
-			}
-		}
+	header := c.Writer.Header()
+	header.Set("Access-Control-Allow-Origin", allowed)
 
-		if allowed {
-			c.Header("Access-Control-Allow-Origin", origin)
-		} else {
-			c.Header("Access-Control-Allow-Origin", "")
-		}
+	if p.config.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
 	}
 
-	// 设置允许凭证
-	if config.AllowCredentials {
-		c.Header("Access-Control-Allow-Credentials", "true")
+	if p.exposeHeaders != "" {
+		header.Set("Access-Control-Expose-Headers", p.exposeHeaders)
 	}
+}
 
-	// 设置允许访问的头部
-	if len(exposeHeaders) > 0 {
-		c.Header("Access-Control-Expose-Headers", strings.Join(exposeHeaders, ", "))
+// matchCORSPath 判断请求路径是否匹配路由模式，支持 :param
+func matchCORSPath(pattern, requestPath string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(requestPath, "/"), "/")
+	if len(patternParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
 	}
+	return true
 }
 
 // normalizeHeaders 将头部名称转换为大写，并移除重复的
@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+)
+
+// newCORSTestEngine 创建在CORS之后挂载认证中间件的引擎
+func newCORSTestEngine(config CORSConfig) *flow.Engine {
+	gin.SetMode(gin.TestMode)
+
+	e := flow.New()
+	e.Use(CORSWithConfig(config))
+	e.Use(func(c *flow.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	})
+	ok := func(c *flow.Context) { c.String(http.StatusOK, "ok") }
+	e.GET("/api/items", ok)
+	e.GET("/admin/users/:id", ok)
+	e.GET("/admin/public", ok)
+	return e
+}
+
+func TestCORS_Origins(t *testing.T) {
+	e := newCORSTestEngine(CORSConfig{
+		AllowOrigins:  []string{"https://app.example.com", "https://*.tenant.io"},
+		ExposeHeaders: []string{"X-Total"},
+		AllowOriginFunc: func(origin string) bool {
+			return strings.HasSuffix(origin, ".custom-domain.net")
+		},
+	})
+
+	tests := []struct {
+		name   string
+		origin string
+		want   string
+	}{
+		{"精确匹配", "https://app.example.com", "https://app.example.com"},
+		{"大小写不敏感", "https://APP.example.com", "https://APP.example.com"},
+		{"子域名通配", "https://acme.tenant.io", "https://acme.tenant.io"},
+		{"多级子域名", "https://eu.acme.tenant.io", "https://eu.acme.tenant.io"},
+		{"通配不匹配裸域名", "https://tenant.io", ""},
+		{"通配要求协议一致", "http://acme.tenant.io", ""},
+		{"后缀欺骗", "https://eviltenant.io", ""},
+		{"回调允许", "https://shop.custom-domain.net", "https://shop.custom-domain.net"},
+		{"未允许", "https://evil.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Authorization", "Bearer x")
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.want, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Contains(t, w.Header().Values("Vary"), "Origin")
+			if tt.want != "" {
+				assert.Equal(t, "X-TOTAL", w.Header().Get("Access-Control-Expose-Headers"))
+			}
+		})
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	e := newCORSTestEngine(CORSConfig{
+		AllowOrigins:        []string{"https://app.example.com"},
+		AllowMethods:        []string{"GET", "POST"},
+		AllowHeaders:        []string{"Content-Type", "Authorization"},
+		AllowCredentials:    true,
+		MaxAge:              600,
+		AllowPrivateNetwork: true,
+	})
+
+	tests := []struct {
+		name           string
+		origin         string
+		method         string
+		requestHeaders string
+		privateNetwork bool
+		wantOrigin     string
+		wantHeaders    string
+		wantPrivate    string
+	}{
+		{"允许的预检", "https://app.example.com", "POST", "content-type, authorization", false, "https://app.example.com", "content-type, authorization", ""},
+		{"只回显允许的请求头", "https://app.example.com", "POST", "Content-Type, X-Debug", false, "https://app.example.com", "Content-Type", ""},
+		{"方法不允许", "https://app.example.com", "DELETE", "", false, "", "", ""},
+		{"源不允许", "https://evil.com", "GET", "", false, "", "", ""},
+		{"私有网络预检", "https://app.example.com", "GET", "", true, "https://app.example.com", "", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/api/items", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.requestHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.requestHeaders)
+			}
+			if tt.privateNetwork {
+				req.Header.Set("Access-Control-Request-Private-Network", "true")
+			}
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+
+			// 预检请求不进入认证中间件
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, tt.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.wantHeaders, w.Header().Get("Access-Control-Allow-Headers"))
+			assert.Equal(t, tt.wantPrivate, w.Header().Get("Access-Control-Allow-Private-Network"))
+			assert.Contains(t, w.Header().Values("Vary"), "Origin")
+			if tt.wantOrigin != "" {
+				assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+				assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+				assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+			}
+		})
+	}
+}
+
+func TestCORS_PolicyPrecedence(t *testing.T) {
+	e := newCORSTestEngine(CORSConfig{
+		AllowOrigins: []string{"*"},
+		Policies: []CORSPolicy{
+			{Path: "/admin/*", Config: CORSConfig{
+				AllowOrigins:     []string{"https://admin.example.com"},
+				AllowCredentials: true,
+			}},
+			{Path: "/admin/public", Config: CORSConfig{
+				AllowOrigins: []string{"https://partner.example.com"},
+			}},
+		},
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		origin string
+		want   string
+	}{
+		{"全局策略允许所有源", "/api/items", "https://anyone.com", "*"},
+		{"前缀策略拒绝其他源", "/admin/users/1", "https://anyone.com", ""},
+		{"前缀策略允许管理源", "/admin/users/1", "https://admin.example.com", "https://admin.example.com"},
+		{"精确路径优先于前缀", "/admin/public", "https://partner.example.com", "https://partner.example.com"},
+		{"精确路径不继承前缀策略", "/admin/public", "https://admin.example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", "GET")
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, tt.want, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestCORS_InvalidConfigFailsAtBoot(t *testing.T) {
+	assert.PanicsWithValue(t, ErrCORSCredentialsWildcard, func() {
+		CORSWithConfig(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+	})
+
+	// 路径策略中的错误配置同样在注册时发现
+	assert.Panics(t, func() {
+		CORSWithConfig(CORSConfig{Policies: []CORSPolicy{{
+			Path:   "/admin/*",
+			Config: CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true},
+		}}})
+	})
+	assert.Error(t, CORSConfig{AllowOrigins: []string{"https://api.*.com"}}.Validate())
+
+	// 未配置源时默认允许所有源，同样不能允许凭证
+	assert.ErrorIs(t, CORSConfig{AllowCredentials: true}.Validate(), ErrCORSCredentialsWildcard)
+	assert.PanicsWithValue(t, ErrCORSCredentialsWildcard, func() {
+		CORSWithConfig(CORSConfig{AllowCredentials: true})
+	})
+	assert.NoError(t, CORSConfig{AllowCredentials: true, AllowOriginFunc: func(string) bool { return false }}.Validate())
+}
+
+func TestCORS_WildcardNeverReflectsOrigin(t *testing.T) {
+	// 绕过 Validate 直接编译，确认通配源在允许凭证时也不会回显请求的 Origin
+	policy := compileCORSPolicy(CORSConfig{AllowCredentials: true})
+	_, ok := policy.allowOrigin("https://evil.example")
+	assert.False(t, ok)
+
+	e := newCORSTestEngine(CORSConfig{})
+	req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	req.Header.Set("Authorization", "Bearer x")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_WildcardOriginPorts(t *testing.T) {
+	for _, tc := range []struct {
+		spec, origin string
+		allowed      bool
+	}{
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://app.example.com:8443", false},
+		{"https://*.example.com:8443", "https://app.example.com:8443", true},
+		{"https://*.example.com:8443", "https://app.example.com", false},
+		{"https://*.example.com:8443", "https://app.example.com:9443", false},
+		{"https://*.example.com:*", "https://app.example.com:9443", true},
+		{"https://*.example.com:*", "https://app.example.com", true},
+		// 端口不能被当作主机名的一部分绕过后缀匹配
+		{"https://*.example.com:*", "https://app.example.com.evil.io:443", false},
+		{"https://*.example.com:*", "https://example.com:8443", false},
+		{"http://*.example.com:*", "https://app.example.com:8443", false},
+	} {
+		require.NoError(t, CORSConfig{AllowOrigins: []string{tc.spec}}.Validate(), tc.spec)
+		_, ok := compileCORSPolicy(CORSConfig{AllowOrigins: []string{tc.spec}}).allowOrigin(tc.origin)
+		assert.Equal(t, tc.allowed, ok, "%s %s", tc.spec, tc.origin)
+	}
+
+	for _, spec := range []string{"https://*.example.com:", "https://*.example.com:0", "https://*.example.com:http", "https://*.example.com:8443/"} {
+		assert.Error(t, CORSConfig{AllowOrigins: []string{spec}}.Validate(), spec)
+	}
+}
+
+func TestCORS_NoOriginStillVaries(t *testing.T) {
+	e := newCORSTestEngine(DefaultCORSConfig())
+	req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+	req.Header.Set("Authorization", "Bearer x")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"))
+}
@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/app"
+	"github.com/zzliekkas/flow/v2/crypto"
 )
 
 // CSRF错误常量
@@ -83,9 +85,12 @@ type CSRFConfig struct {
 	// ContextKey 指定存储在上下文中的键名
 	ContextKey string
 
-	// Secret 用于签名CSRF令牌的密钥
+	// Secret 用于签名CSRF令牌的密钥，设置后 Cookie 与表单中的令牌都带签名
 	Secret string
 
+	// Signer 签名CSRF令牌的签名器，可传入由 app.key 创建的签名器以支持密钥轮换，优先于 Secret
+	Signer *crypto.Signer
+
 	// ErrorFunc 自定义错误处理函数
 	ErrorFunc func(*flow.Context, error)
 
@@ -112,6 +117,7 @@ func DefaultCSRFConfig() CSRFConfig {
 		TokenExpiry:    DefaultCSRFTokenExpiry,
 		CookieName:     DefaultCSRFCookieName,
 		CookiePath:     "/",
+		CookieSecure:   app.CurrentDefaults().SecureCookies,
 		CookieHTTPOnly: true,
 		CookieSameSite: http.SameSiteLaxMode,
 		HeaderName:     DefaultCSRFHeaderName,
@@ -274,6 +280,7 @@ func CSRFWithConfig(config CSRFConfig) flow.HandlerFunc {
 	if len(config.AllowedMethods) == 0 {
 		config.AllowedMethods = []string{"GET", "HEAD", "OPTIONS"}
 	}
+	config.Signer = config.signer()
 
 	return func(c *flow.Context) {
 		// 检查是否跳过此URL
@@ -303,9 +310,9 @@ func CSRFWithConfig(config CSRFConfig) flow.HandlerFunc {
 			return
 		}
 
-		// 验证令牌
-		valid := config.TokenStore.ValidateToken(c, token)
-		if !valid {
+		// 验证签名与令牌
+		token, valid := verifyCSRFToken(config, token)
+		if !valid || !config.TokenStore.ValidateToken(c, token) {
 			config.ErrorFunc(c, ErrCSRFTokenInvalid)
 			c.Abort()
 			return
@@ -331,7 +338,7 @@ func setCSRFToken(c *flow.Context, config CSRFConfig) {
 
 	if err == nil && cookie != "" {
 		// 验证现有令牌
-		if config.TokenStore.ValidateToken(c, cookie) {
+		if raw, ok := verifyCSRFToken(config, cookie); ok && config.TokenStore.ValidateToken(c, raw) {
 			token = cookie
 		}
 	}
@@ -343,6 +350,9 @@ func setCSRFToken(c *flow.Context, config CSRFConfig) {
 		if err != nil {
 			return
 		}
+		if config.Signer != nil {
+			token = config.Signer.Sign(token)
+		}
 
 		// 设置Cookie
 		c.SetCookie(
@@ -402,6 +412,7 @@ func GetCSRFToken(c *flow.Context) string {
 
 // CSRFRequired 提供更细粒度的验证，可在特定路由上启用
 func CSRFRequired(config CSRFConfig) flow.HandlerFunc {
+	config.Signer = config.signer()
 	return func(c *flow.Context) {
 		token := extractCSRFToken(c, config)
 		if token == "" {
@@ -410,9 +421,9 @@ func CSRFRequired(config CSRFConfig) flow.HandlerFunc {
 			return
 		}
 
-		// 验证令牌
-		valid := config.TokenStore.ValidateToken(c, token)
-		if !valid {
+		// 验证签名与令牌
+		token, valid := verifyCSRFToken(config, token)
+		if !valid || !config.TokenStore.ValidateToken(c, token) {
 			config.ErrorFunc(c, ErrCSRFTokenInvalid)
 			c.Abort()
 			return
@@ -443,6 +454,7 @@ func ProtectForm(formHTML string, c *flow.Context) string {
 
 // GenerateCSRFToken 生成CSRF令牌并返回
 func GenerateCSRFToken(config CSRFConfig) flow.HandlerFunc {
+	config.Signer = config.signer()
 	return func(c *flow.Context) {
 		setCSRFToken(c, config)
 		token := GetCSRFToken(c)
@@ -453,9 +465,41 @@ func GenerateCSRFToken(config CSRFConfig) flow.HandlerFunc {
 	}
 }
 
+// signer 返回签名令牌使用的签名器，未设置 Signer 与 Secret 时返回 nil
+func (config CSRFConfig) signer() *crypto.Signer {
+	if config.Signer != nil || config.Secret == "" {
+		return config.Signer
+	}
+	signer, err := crypto.NewSigner([][]byte{[]byte(config.Secret)})
+	if err != nil {
+		return nil
+	}
+	return signer
+}
+
+// verifyCSRFToken 验证令牌签名并返回存储中的原始令牌，未配置签名器时原样返回
+func verifyCSRFToken(config CSRFConfig, token string) (string, bool) {
+	if config.Signer == nil {
+		return token, true
+	}
+	raw, err := config.Signer.Verify(token)
+	return raw, err == nil
+}
+
 // GenerateCSRFTokenHash 根据密钥生成令牌哈希
+//
+// Deprecated: 哈希未使用 HMAC，请改用 SignCSRFToken
 func GenerateCSRFTokenHash(token, secret string) string {
 	h := sha256.New()
 	h.Write([]byte(token + secret))
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
+
+// SignCSRFToken 使用密钥生成令牌的 HMAC 签名，密钥为空时返回错误
+func SignCSRFToken(token, secret string) (string, error) {
+	signer, err := crypto.NewSigner([][]byte{[]byte(secret)})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", signer.MAC([]byte(token))), nil
+}
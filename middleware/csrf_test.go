@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+)
+
+func TestGenerateCSRFTokenHash_Compatible(t *testing.T) {
+	sum := sha256.Sum256([]byte("token" + "secret"))
+	assert.Equal(t, fmt.Sprintf("%x", sum), GenerateCSRFTokenHash("token", "secret"))
+	assert.NotEmpty(t, GenerateCSRFTokenHash("token", ""), "空密钥保持原有行为")
+
+	signed, err := SignCSRFToken("token", "secret")
+	require.NoError(t, err)
+	assert.Len(t, signed, 64)
+	_, err = SignCSRFToken("token", "")
+	assert.Error(t, err)
+}
+
+func TestCSRF_SignedTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := DefaultCSRFConfig()
+	config.Secret = "csrf-secret"
+	e := flow.New()
+	e.Use(CSRFWithConfig(config))
+	e.GET("/form", func(c *flow.Context) { c.String(http.StatusOK, GetCSRFToken(c)) })
+	e.POST("/submit", func(c *flow.Context) { c.String(http.StatusOK, "ok") })
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/form", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	token := w.Body.String()
+	require.Contains(t, token, ".", "令牌带签名")
+
+	submit := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/submit", nil)
+		req.Header.Set(DefaultCSRFHeaderName, token)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, submit(token))
+
+	// 篡改签名或去掉签名都被拒绝
+	raw := token[:strings.LastIndexByte(token, '.')]
+	assert.Equal(t, http.StatusForbidden, submit(raw))
+	assert.Equal(t, http.StatusForbidden, submit(raw+".AAAA"))
+}
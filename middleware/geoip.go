@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"container/list"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/flowctx"
+	"github.com/zzliekkas/flow/v2/geoip"
+)
+
+// GeoIPOptions GeoIP 中间件的选项
+type GeoIPOptions struct {
+	// CacheSize 缓存最近查询结果的条数，默认4096，小于0时不缓存
+	CacheSize int
+
+	// Header 调试用的响应头名称，设置后输出解析结果，如 X-Geo: CN/GD/Shenzhen/AS4134；默认不输出
+	Header string
+
+	// TruncateIP 查询、缓存与日志前截断IP，不保留完整的客户端地址
+	TruncateIP bool
+
+	// IPv4PrefixLength 截断后保留的 IPv4 前缀长度，默认24
+	IPv4PrefixLength int
+
+	// IPv6PrefixLength 截断后保留的 IPv6 前缀长度，默认48
+	IPv6PrefixLength int
+
+	// Logger 数据库不可用时记录日志，默认使用 logrus 标准日志
+	Logger logrus.FieldLogger
+}
+
+// DefaultGeoIPOptions 返回默认的 GeoIP 选项
+func DefaultGeoIPOptions() *GeoIPOptions {
+	return &GeoIPOptions{
+		CacheSize:        4096,
+		IPv4PrefixLength: 24,
+		IPv6PrefixLength: 48,
+	}
+}
+
+// GeoIP 解析客户端IP的地理位置，写入 flowctx.GeoInfo，通过 flowctx.Geo(ctx) 读取
+//
+// 客户端IP取自 Context.ClientIP()，需要配合引擎的可信代理设置使用。查询出错（例如数据库文件缺失）时
+// 只记录一次日志，请求照常处理但不带地理位置，查询恢复正常后再次出错时重新记录。
+// 在 Locale 之前注册时，LocaleOptions.CountryLocales 可以按国家选择默认语言
+func GeoIP(resolver geoip.Resolver, opts ...*GeoIPOptions) flow.HandlerFunc {
+	options := DefaultGeoIPOptions()
+	if len(opts) > 0 && opts[0] != nil {
+		custom := *opts[0]
+		options = &custom
+	}
+	if options.IPv4PrefixLength <= 0 || options.IPv4PrefixLength > 32 {
+		options.IPv4PrefixLength = 24
+	}
+	if options.IPv6PrefixLength <= 0 || options.IPv6PrefixLength > 128 {
+		options.IPv6PrefixLength = 48
+	}
+	logger := options.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	var cache *geoCache
+	if options.CacheSize >= 0 {
+		size := options.CacheSize
+		if size == 0 {
+			size = 4096
+		}
+		cache = newGeoCache(size)
+	}
+
+	var degraded atomic.Bool
+	return func(c *flow.Context) {
+		ip := net.ParseIP(c.ClientIP())
+		if ip == nil {
+			c.Next()
+			return
+		}
+		if options.TruncateIP {
+			ip = truncateIP(ip, options.IPv4PrefixLength, options.IPv6PrefixLength)
+		}
+
+		key := ip.String()
+		geo, ok := cache.get(key)
+		if !ok {
+			var err error
+			geo, err = resolver.Lookup(ip)
+			if err != nil {
+				if degraded.CompareAndSwap(false, true) {
+					logger.WithError(err).WithField("ip", key).Warn("GeoIP 查询失败，请求将不带地理位置")
+				}
+				c.Next()
+				return
+			}
+			degraded.Store(false)
+			cache.add(key, geo)
+		}
+
+		if !geo.IsZero() {
+			flowctx.SetGeo(c.Context, geo)
+			if options.Header != "" {
+				c.Header(options.Header, formatGeo(geo))
+			}
+		}
+		c.Next()
+	}
+}
+
+// truncateIP 保留IP的前缀，其余位清零
+func truncateIP(ip net.IP, v4Bits, v6Bits int) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(v4Bits, 32))
+	}
+	return ip.Mask(net.CIDRMask(v6Bits, 128))
+}
+
+// formatGeo 格式化调试响应头，响应头只能包含 ASCII，城市名称中的其他字符替换为问号
+func formatGeo(geo flowctx.GeoInfo) string {
+	parts := []string{geo.Country, geo.Region, geo.City}
+	if geo.ASN != 0 {
+		parts = append(parts, "AS"+strconv.FormatUint(uint64(geo.ASN), 10))
+	}
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r >= 0x7f {
+			return '?'
+		}
+		return r
+	}, strings.Join(parts, "/"))
+}
+
+// geoCache 最近查询结果的 LRU 缓存，nil 缓存不保存任何结果
+type geoCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // 最近使用的在前
+	items map[string]*list.Element
+}
+
+// geoCacheEntry 缓存项
+type geoCacheEntry struct {
+	key string
+	geo flowctx.GeoInfo
+}
+
+// newGeoCache 创建最多保存 size 条的缓存
+func newGeoCache(size int) *geoCache {
+	return &geoCache{size: size, order: list.New(), items: make(map[string]*list.Element, size)}
+}
+
+// get 读取缓存的结果
+func (g *geoCache) get(key string) (flowctx.GeoInfo, bool) {
+	if g == nil {
+		return flowctx.GeoInfo{}, false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	elem, ok := g.items[key]
+	if !ok {
+		return flowctx.GeoInfo{}, false
+	}
+	g.order.MoveToFront(elem)
+	return elem.Value.(*geoCacheEntry).geo, true
+}
+
+// add 保存结果，超出容量时淘汰最久未使用的
+func (g *geoCache) add(key string, geo flowctx.GeoInfo) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if elem, ok := g.items[key]; ok {
+		elem.Value.(*geoCacheEntry).geo = geo
+		g.order.MoveToFront(elem)
+		return
+	}
+	g.items[key] = g.order.PushFront(&geoCacheEntry{key: key, geo: geo})
+	if g.order.Len() > g.size {
+		oldest := g.order.Back()
+		g.order.Remove(oldest)
+		delete(g.items, oldest.Value.(*geoCacheEntry).key)
+	}
+}
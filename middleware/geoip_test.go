@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/flowctx"
+	"github.com/zzliekkas/flow/v2/geoip"
+	"github.com/zzliekkas/flow/v2/i18n"
+)
+
+// countingResolver 统计查询次数，err 不为nil时返回错误
+type countingResolver struct {
+	resolver geoip.Resolver
+	calls    atomic.Int64
+	ips      []string
+	err      error
+}
+
+func (r *countingResolver) Lookup(ip net.IP) (flowctx.GeoInfo, error) {
+	r.calls.Add(1)
+	r.ips = append(r.ips, ip.String())
+	if r.err != nil {
+		return flowctx.GeoInfo{}, r.err
+	}
+	return r.resolver.Lookup(ip)
+}
+
+func newGeoTestEngine(t *testing.T, resolver geoip.Resolver, opts *GeoIPOptions) *flow.Engine {
+	gin.SetMode(gin.TestMode)
+	e := flow.New()
+	require.NoError(t, e.SetTrustedProxies([]string{"10.0.0.1"}))
+	e.Use(GeoIP(resolver, opts))
+	e.GET("/", func(c *flow.Context) {
+		geo := flowctx.Geo(c.Request.Context())
+		c.String(http.StatusOK, geo.Country+"|"+geo.City)
+	})
+	return e
+}
+
+func geoRequest(e *flow.Engine, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func testGeoResolver(t *testing.T) *countingResolver {
+	static, err := geoip.Static(map[string]flowctx.GeoInfo{
+		"1.2.3.0/24":    {Country: "CN", Region: "GD", City: "深圳"},
+		"8.8.8.0/24":    {Country: "US", ASN: 15169},
+		"2001:db8::/32": {Country: "DE"},
+	})
+	require.NoError(t, err)
+	return &countingResolver{resolver: static}
+}
+
+func TestGeoIP_ProxyAwareClientIP(t *testing.T) {
+	resolver := testGeoResolver(t)
+	e := newGeoTestEngine(t, resolver, &GeoIPOptions{Header: "X-Geo"})
+
+	// 可信代理转发的客户端地址
+	w := geoRequest(e, "10.0.0.1:1234", "1.2.3.4")
+	assert.Equal(t, "CN|深圳", w.Body.String())
+	assert.Equal(t, "CN/GD/??", w.Header().Get("X-Geo"))
+
+	// 不可信来源的 X-Forwarded-For 被忽略
+	w = geoRequest(e, "8.8.8.8:1234", "1.2.3.4")
+	assert.Equal(t, "US|", w.Body.String())
+	assert.Equal(t, "US///AS15169", w.Header().Get("X-Geo"))
+
+	// 未收录的地址不设置地理位置
+	w = geoRequest(e, "9.9.9.9:1234", "")
+	assert.Equal(t, "|", w.Body.String())
+	assert.Empty(t, w.Header().Get("X-Geo"))
+}
+
+func TestGeoIP_CacheAndTruncate(t *testing.T) {
+	resolver := testGeoResolver(t)
+	e := newGeoTestEngine(t, resolver, &GeoIPOptions{CacheSize: 2})
+
+	for i := 0; i < 3; i++ {
+		geoRequest(e, "1.2.3.4:1", "")
+	}
+	assert.Equal(t, int64(1), resolver.calls.Load(), "重复的地址使用缓存")
+
+	// 超出容量时淘汰最久未使用的
+	geoRequest(e, "8.8.8.8:1", "")
+	geoRequest(e, "1.2.3.4:1", "")
+	geoRequest(e, "9.9.9.9:1", "")
+	assert.Equal(t, int64(3), resolver.calls.Load())
+	geoRequest(e, "1.2.3.4:1", "")
+	assert.Equal(t, int64(3), resolver.calls.Load())
+	geoRequest(e, "8.8.8.8:1", "")
+	assert.Equal(t, int64(4), resolver.calls.Load())
+
+	// 截断后同一网段共用缓存，查询时不使用完整地址
+	resolver = testGeoResolver(t)
+	e = newGeoTestEngine(t, resolver, &GeoIPOptions{TruncateIP: true})
+	assert.Equal(t, "CN|深圳", geoRequest(e, "1.2.3.4:1", "").Body.String())
+	assert.Equal(t, "CN|深圳", geoRequest(e, "1.2.3.200:1", "").Body.String())
+	assert.Equal(t, "DE|", geoRequest(e, "[2001:db8:1:2:3::4]:1", "").Body.String())
+	assert.Equal(t, []string{"1.2.3.0", "2001:db8:1::"}, resolver.ips)
+}
+
+func TestGeoIP_Degradation(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	resolver := &countingResolver{err: geoip.ErrNoDatabase}
+	e := newGeoTestEngine(t, resolver, &GeoIPOptions{Logger: logger, TruncateIP: true})
+
+	// 数据库缺失时请求照常处理，只记录一次日志
+	for i := 0; i < 3; i++ {
+		w := geoRequest(e, "1.2.3.4:1", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "|", w.Body.String())
+	}
+	require.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, "1.2.3.0", hook.LastEntry().Data["ip"])
+	assert.Equal(t, int64(3), resolver.calls.Load(), "错误结果不缓存")
+
+	// 恢复后再次出错时重新记录
+	resolver.err = nil
+	resolver.resolver = geoip.Nop()
+	geoRequest(e, "1.2.3.4:1", "")
+	resolver.err = geoip.ErrNoDatabase
+	geoRequest(e, "5.6.7.8:1", "")
+	assert.Len(t, hook.AllEntries(), 2)
+}
+
+func TestLocale_CountryFallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	static, err := geoip.Static(map[string]flowctx.GeoInfo{"1.2.3.0/24": {Country: "CN"}})
+	require.NoError(t, err)
+
+	options := DefaultLocaleOptions()
+	options.CountryLocales = map[string]string{"CN": "zh"}
+	e := flow.New()
+	e.Use(GeoIP(static))
+	e.Engine.Use(Locale(i18n.NewManager("en", "en"), options))
+	e.GET("/", func(c *flow.Context) { c.String(http.StatusOK, GetLocale(c.Context)) })
+
+	assert.Equal(t, "zh", geoRequest(e, "1.2.3.4:1", "").Body.String())
+	assert.Equal(t, "en", geoRequest(e, "9.9.9.9:1", "").Body.String())
+
+	// 国家的优先级低于浏览器语言
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "1.2.3.4:1"
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Equal(t, "en", w.Body.String())
+}
@@ -0,0 +1,304 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+)
+
+// 负载保护拒绝请求的原因，用作 shed_total 指标的 reason 标签
+const (
+	ShedReasonQueueFull    = "queue_full"    // 并发已满且等待队列已满
+	ShedReasonQueueTimeout = "queue_timeout" // 在等待队列中超时
+	ShedReasonHeap         = "heap"          // 堆内存超过阈值
+	ShedReasonGCPause      = "gc_pause"      // GC暂停时间的滑动平均超过阈值
+)
+
+// ShedObserver 负载保护观察者，用于导出被拒绝请求的指标
+type ShedObserver interface {
+	// ObserveShed 记录一次被拒绝的请求
+	ObserveShed(reason string)
+}
+
+// ShedExempt 免于负载保护的路由属性，用于健康检查、管理后台等路由
+//
+//	e.GET("/health", h, flow.WithAttr(middleware.ShedExempt(true)))
+type ShedExempt bool
+
+// LoadSignals 自适应模式采样的运行时信号
+type LoadSignals struct {
+	HeapBytes uint64        // 当前堆内存占用
+	GCPause   time.Duration // 上次采样以来最长的GC暂停时间，期间没有GC时为0
+}
+
+// LoadShedOptions 负载保护配置
+type LoadShedOptions struct {
+	// MaxInFlight 最大并发处理请求数，为0时不限制并发
+	MaxInFlight int
+
+	// MaxQueue 并发已满时最多等待的请求数，为0时直接拒绝
+	MaxQueue int
+
+	// QueueTimeout 请求在等待队列中的最长时间，默认1秒
+	QueueTimeout time.Duration
+
+	// RetryAfter 拒绝时 Retry-After 响应头的时间，默认1秒
+	RetryAfter time.Duration
+
+	// MaxHeapBytes 堆内存阈值，超过后拒绝新请求，为0时不检查
+	MaxHeapBytes uint64
+
+	// MaxGCPause GC暂停时间滑动平均的阈值，超过后拒绝新请求，为0时不检查
+	MaxGCPause time.Duration
+
+	// SampleInterval 自适应模式的采样间隔，默认1秒；小于0时不启动定时采样，需手动调用 Sample
+	SampleInterval time.Duration
+
+	// Signals 运行时信号来源，为nil时读取 runtime.MemStats，测试中可注入固定的信号
+	Signals func() LoadSignals
+
+	// Lifecycle 应用生命周期，通常为 *flow.Engine，设置后在应用关闭时停止后台采样
+	Lifecycle interface {
+		OnShutdown(fn func(), priority ...int)
+	}
+
+	// Metrics 负载保护观察者，为nil时不导出指标
+	Metrics ShedObserver
+}
+
+// LoadShedder 负载保护器，限制并发处理的请求数，并在内存或GC压力过大时拒绝新请求
+type LoadShedder struct {
+	opts     LoadShedOptions
+	slots    chan struct{}
+	queued   atomic.Int64
+	inFlight atomic.Int64
+	overload atomic.Value // 当前拒绝原因，空字符串表示未过载
+
+	mu       sync.Mutex
+	gcPause  float64 // GC暂停时间的滑动平均（纳秒）
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// gcPauseAlpha GC暂停滑动平均的平滑系数
+const gcPauseAlpha = 0.3
+
+// LoadShed 返回负载保护中间件
+// 自适应模式会启动后台采样，需设置 Lifecycle 以便在应用关闭时停止，
+// 否则应使用 NewLoadShedder 并自行调用 Stop
+func LoadShed(opts LoadShedOptions) flow.HandlerFunc {
+	adaptive := opts.MaxHeapBytes > 0 || opts.MaxGCPause > 0
+	if adaptive && opts.SampleInterval >= 0 && opts.Lifecycle == nil {
+		panic("middleware: 自适应负载保护需要设置 Lifecycle，或使用 NewLoadShedder 并在关闭时调用 Stop")
+	}
+	return NewLoadShedder(opts).Handler()
+}
+
+// NewLoadShedder 创建负载保护器，配置了内存或GC阈值时启动后台采样
+func NewLoadShedder(opts LoadShedOptions) *LoadShedder {
+	if opts.QueueTimeout <= 0 {
+		opts.QueueTimeout = time.Second
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = time.Second
+	}
+	if opts.SampleInterval == 0 {
+		opts.SampleInterval = time.Second
+	}
+	if opts.Signals == nil {
+		opts.Signals = newRuntimeSignals().read
+	}
+
+	s := &LoadShedder{opts: opts, stop: make(chan struct{})}
+	s.overload.Store("")
+	if opts.MaxInFlight > 0 {
+		s.slots = make(chan struct{}, opts.MaxInFlight)
+	}
+	if s.adaptive() && opts.SampleInterval > 0 {
+		go s.sampleLoop()
+		if opts.Lifecycle != nil {
+			opts.Lifecycle.OnShutdown(s.Stop)
+		}
+	}
+	return s
+}
+
+// Handler 返回中间件处理函数
+func (s *LoadShedder) Handler() flow.HandlerFunc {
+	return func(c *flow.Context) {
+		if flow.AttrOr(c, ShedExempt(false)) {
+			c.Next()
+			return
+		}
+		if reason := s.overload.Load().(string); reason != "" {
+			s.shed(c, reason)
+			return
+		}
+		if s.slots == nil {
+			c.Next()
+			return
+		}
+
+		if !s.acquire(c) {
+			return
+		}
+		// 处理函数panic时同样释放并发名额
+		defer s.release()
+		c.Next()
+	}
+}
+
+// acquire 获取并发名额，并发已满时进入等待队列，失败时已写入拒绝响应
+func (s *LoadShedder) acquire(c *flow.Context) bool {
+	select {
+	case s.slots <- struct{}{}:
+		s.inFlight.Add(1)
+		return true
+	default:
+	}
+
+	if s.queued.Add(1) > int64(s.opts.MaxQueue) {
+		s.queued.Add(-1)
+		s.shed(c, ShedReasonQueueFull)
+		return false
+	}
+	defer s.queued.Add(-1)
+
+	timer := time.NewTimer(s.opts.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case s.slots <- struct{}{}:
+		s.inFlight.Add(1)
+		return true
+	case <-timer.C:
+		s.shed(c, ShedReasonQueueTimeout)
+		return false
+	case <-c.Request.Context().Done():
+		// 客户端已断开
+		c.Abort()
+		return false
+	}
+}
+
+// release 释放并发名额
+func (s *LoadShedder) release() {
+	s.inFlight.Add(-1)
+	<-s.slots
+}
+
+// shed 拒绝请求并记录原因
+func (s *LoadShedder) shed(c *flow.Context, reason string) {
+	if s.opts.Metrics != nil {
+		s.opts.Metrics.ObserveShed(reason)
+	}
+	seconds := int(math.Ceil(s.opts.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, flow.H{"error": "服务繁忙，请稍后重试"})
+}
+
+// InFlight 当前正在处理的请求数
+func (s *LoadShedder) InFlight() int {
+	return int(s.inFlight.Load())
+}
+
+// Queued 当前在等待队列中的请求数
+func (s *LoadShedder) Queued() int {
+	return int(s.queued.Load())
+}
+
+// Overloaded 返回当前是否因内存或GC压力拒绝请求，以及拒绝原因
+func (s *LoadShedder) Overloaded() (string, bool) {
+	reason := s.overload.Load().(string)
+	return reason, reason != ""
+}
+
+// Sample 采样一次运行时信号并更新过载状态，由后台定时调用，也可在测试中手动调用
+func (s *LoadShedder) Sample() {
+	signals := s.opts.Signals()
+
+	s.mu.Lock()
+	s.gcPause = gcPauseAlpha*float64(signals.GCPause) + (1-gcPauseAlpha)*s.gcPause
+	gcPause := time.Duration(s.gcPause)
+	s.mu.Unlock()
+
+	reason := ""
+	switch {
+	case s.opts.MaxHeapBytes > 0 && signals.HeapBytes > s.opts.MaxHeapBytes:
+		reason = ShedReasonHeap
+	case s.opts.MaxGCPause > 0 && gcPause > s.opts.MaxGCPause:
+		reason = ShedReasonGCPause
+	}
+	s.overload.Store(reason)
+}
+
+// Stop 停止后台采样
+func (s *LoadShedder) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// adaptive 是否配置了内存或GC阈值
+func (s *LoadShedder) adaptive() bool {
+	return s.opts.MaxHeapBytes > 0 || s.opts.MaxGCPause > 0
+}
+
+// sampleLoop 定时采样运行时信号
+func (s *LoadShedder) sampleLoop() {
+	ticker := time.NewTicker(s.opts.SampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Sample()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// runtimeSignals 读取运行时信号，记录上次采样时的GC次数，只上报此后新发生的GC暂停
+type runtimeSignals struct {
+	mu    sync.Mutex
+	numGC uint32
+}
+
+// newRuntimeSignals 以当前GC次数为起点创建运行时信号来源
+func newRuntimeSignals() *runtimeSignals {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return &runtimeSignals{numGC: stats.NumGC}
+}
+
+// read 读取堆内存占用与上次采样以来最长的GC暂停时间
+func (r *runtimeSignals) read() LoadSignals {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	signals := LoadSignals{HeapBytes: stats.HeapAlloc, GCPause: newGCPause(&stats, r.numGC)}
+	r.numGC = stats.NumGC
+	return signals
+}
+
+// newGCPause 返回第 last 次之后发生的GC中最长的暂停时间，没有新的GC时返回0
+func newGCPause(stats *runtime.MemStats, last uint32) time.Duration {
+	n := stats.NumGC - last
+	if n > uint32(len(stats.PauseNs)) {
+		// 环形缓冲只保留最近256次暂停
+		n = uint32(len(stats.PauseNs))
+	}
+
+	var longest uint64
+	for i := uint32(0); i < n; i++ {
+		if pause := stats.PauseNs[(stats.NumGC-1-i)%uint32(len(stats.PauseNs))]; pause > longest {
+			longest = pause
+		}
+	}
+	return time.Duration(longest)
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+)
+
+// shedCounter 记录拒绝原因的观察者
+type shedCounter struct {
+	mu      sync.Mutex
+	reasons map[string]int
+}
+
+func (s *shedCounter) ObserveShed(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reasons == nil {
+		s.reasons = make(map[string]int)
+	}
+	s.reasons[reason]++
+}
+
+func (s *shedCounter) count(reason string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reasons[reason]
+}
+
+func newShedEngine(shedder *LoadShedder, release <-chan struct{}) *flow.Engine {
+	gin.SetMode(gin.TestMode)
+	e := flow.New()
+	e.Use(shedder.Handler())
+	e.GET("/slow", func(c *flow.Context) {
+		<-release
+		c.Status(http.StatusOK)
+	})
+	e.GET("/health", func(c *flow.Context) {
+		c.Status(http.StatusOK)
+	}, flow.WithAttr(ShedExempt(true)))
+	e.GET("/panic", func(c *flow.Context) {
+		panic("boom")
+	})
+	return e
+}
+
+func serveAsync(e *flow.Engine, path string) <-chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		done <- w
+	}()
+	return done
+}
+
+func TestLoadShed_ConcurrencyCap(t *testing.T) {
+	observer := &shedCounter{}
+	shedder := NewLoadShedder(LoadShedOptions{MaxInFlight: 2, RetryAfter: 3 * time.Second, Metrics: observer})
+	release := make(chan struct{})
+	e := newShedEngine(shedder, release)
+
+	first, second := serveAsync(e, "/slow"), serveAsync(e, "/slow")
+	assert.Eventually(t, func() bool { return shedder.InFlight() == 2 }, time.Second, time.Millisecond)
+
+	// 并发已满且不排队时直接拒绝
+	w := <-serveAsync(e, "/slow")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Retry-After"))
+	assert.Equal(t, 1, observer.count(ShedReasonQueueFull))
+
+	// 豁免的路由不受限制
+	w = <-serveAsync(e, "/health")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, http.StatusOK, (<-second).Code)
+	assert.Equal(t, 0, shedder.InFlight())
+}
+
+func TestLoadShed_Queue(t *testing.T) {
+	observer := &shedCounter{}
+	shedder := NewLoadShedder(LoadShedOptions{MaxInFlight: 1, MaxQueue: 1, QueueTimeout: 30 * time.Millisecond, Metrics: observer})
+	release := make(chan struct{})
+	e := newShedEngine(shedder, release)
+
+	first := serveAsync(e, "/slow")
+	assert.Eventually(t, func() bool { return shedder.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	// 排队超时
+	w := <-serveAsync(e, "/slow")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, 1, observer.count(ShedReasonQueueTimeout))
+	assert.Equal(t, 0, shedder.Queued())
+
+	// 排队期间名额释放后继续处理
+	queued := serveAsync(e, "/slow")
+	assert.Eventually(t, func() bool { return shedder.Queued() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, http.StatusOK, (<-queued).Code)
+}
+
+func TestLoadShed_Adaptive(t *testing.T) {
+	observer := &shedCounter{}
+	var mu sync.Mutex
+	signals := LoadSignals{HeapBytes: 100}
+	shedder := NewLoadShedder(LoadShedOptions{
+		MaxHeapBytes:   1000,
+		MaxGCPause:     10 * time.Millisecond,
+		SampleInterval: -1,
+		Metrics:        observer,
+		Signals: func() LoadSignals {
+			mu.Lock()
+			defer mu.Unlock()
+			return signals
+		},
+	})
+	release := make(chan struct{})
+	close(release)
+	e := newShedEngine(shedder, release)
+	setSignals := func(s LoadSignals) {
+		mu.Lock()
+		signals = s
+		mu.Unlock()
+		shedder.Sample()
+	}
+
+	setSignals(LoadSignals{HeapBytes: 100})
+	assert.Equal(t, http.StatusOK, (<-serveAsync(e, "/slow")).Code)
+
+	setSignals(LoadSignals{HeapBytes: 2000})
+	assert.Equal(t, http.StatusServiceUnavailable, (<-serveAsync(e, "/slow")).Code)
+	assert.Equal(t, http.StatusOK, (<-serveAsync(e, "/health")).Code)
+	assert.Equal(t, 1, observer.count(ShedReasonHeap))
+
+	// 单次较长的GC暂停不足以触发，持续偏高后触发
+	setSignals(LoadSignals{HeapBytes: 100, GCPause: 20 * time.Millisecond})
+	_, overloaded := shedder.Overloaded()
+	assert.False(t, overloaded)
+	for i := 0; i < 5; i++ {
+		setSignals(LoadSignals{HeapBytes: 100, GCPause: 20 * time.Millisecond})
+	}
+	reason, overloaded := shedder.Overloaded()
+	assert.True(t, overloaded)
+	assert.Equal(t, ShedReasonGCPause, reason)
+}
+
+func TestLoadShed_PanicReleasesSlot(t *testing.T) {
+	shedder := NewLoadShedder(LoadShedOptions{MaxInFlight: 4, MaxQueue: 100})
+	e := newShedEngine(shedder, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, shedder.InFlight())
+	assert.Equal(t, 0, shedder.Queued())
+	assert.Len(t, shedder.slots, 0)
+}
+
+func TestLoadShed_NewGCPausesOnly(t *testing.T) {
+	var stats runtime.MemStats
+	stats.NumGC = 3
+	stats.PauseNs[0] = uint64(5 * time.Millisecond)
+	stats.PauseNs[1] = uint64(30 * time.Millisecond)
+	stats.PauseNs[2] = uint64(10 * time.Millisecond)
+
+	assert.Equal(t, 30*time.Millisecond, newGCPause(&stats, 0))
+	assert.Equal(t, 10*time.Millisecond, newGCPause(&stats, 2))
+	assert.Zero(t, newGCPause(&stats, 3), "没有新的GC时不重复上报")
+
+	// 环形缓冲回绕
+	stats.NumGC = 257
+	stats.PauseNs[0] = uint64(50 * time.Millisecond)
+	assert.Equal(t, 50*time.Millisecond, newGCPause(&stats, 256))
+}
+
+// shutdownHooks 记录注册的关闭钩子
+type shutdownHooks struct {
+	hooks []func()
+}
+
+func (h *shutdownHooks) OnShutdown(fn func(), priority ...int) {
+	h.hooks = append(h.hooks, fn)
+}
+
+func TestLoadShed_SamplerLifecycle(t *testing.T) {
+	assert.Panics(t, func() { LoadShed(LoadShedOptions{MaxHeapBytes: 1000}) }, "自适应模式必须能停止采样")
+	assert.NotPanics(t, func() { LoadShed(LoadShedOptions{MaxInFlight: 1}) })
+
+	hooks := &shutdownHooks{}
+	LoadShed(LoadShedOptions{MaxHeapBytes: 1000, SampleInterval: time.Millisecond, Lifecycle: hooks})
+	require.Len(t, hooks.hooks, 1)
+	hooks.hooks[0]()
+}
@@ -5,11 +5,13 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zzliekkas/flow/v2/app"
+	"github.com/zzliekkas/flow/v2/flowctx"
 	"github.com/zzliekkas/flow/v2/i18n"
 )
 
 // 定义上下文中保存语言的键
-const localeContextKey = "app.locale"
+const localeContextKey = flowctx.KeyLocale
 
 // LocaleOptions 本地化中间件的选项
 type LocaleOptions struct {
@@ -51,6 +53,10 @@ type LocaleOptions struct {
 
 	// 是否自动检测浏览器语言
 	DetectBrowserLocale bool
+
+	// 国家代码到语言的映射，如 {"CN": "zh"}，其他方式都没有确定语言时按客户端所在国家选择
+	// 需要在 GeoIP 中间件之后注册
+	CountryLocales map[string]string
 }
 
 // DefaultLocaleOptions 返回默认的本地化选项
@@ -63,7 +69,7 @@ func DefaultLocaleOptions() *LocaleOptions {
 		SaveToCookie:        true,
 		CookieMaxAge:        60 * 60 * 24 * 30, // 30天
 		CookiePath:          "/",
-		CookieSecure:        false,
+		CookieSecure:        app.CurrentDefaults().SecureCookies,
 		CookieHTTPOnly:      false,
 		CookieSession:       false,
 		DetectBrowserLocale: true,
@@ -85,14 +91,17 @@ func Locale(translator i18n.Translator, opts ...*LocaleOptions) gin.HandlerFunc
 	options.Translator = translator
 
 	return func(c *gin.Context) {
-		// 尝试获取语言，按优先级：查询参数 > Cookie > Header > 默认值
+		// 尝试获取语言，按优先级：查询参数 > Cookie > Header > 客户端所在国家 > 默认值
 		locale := options.DefaultLocale
+		// 是否由请求明确指定了语言，明确指定为默认语言时不再参考国家
+		explicit := false
 
 		// 尝试从查询参数获取
 		if options.QueryParameterName != "" {
 			if qlocale := c.Query(options.QueryParameterName); qlocale != "" {
 				if isSupported(qlocale, options.SupportedLocales) {
 					locale = qlocale
+					explicit = true
 				}
 			}
 		}
@@ -102,6 +111,7 @@ func Locale(translator i18n.Translator, opts ...*LocaleOptions) gin.HandlerFunc
 			if clocale, err := c.Cookie(options.CookieName); err == nil && clocale != "" {
 				if isSupported(clocale, options.SupportedLocales) {
 					locale = clocale
+					explicit = true
 				}
 			}
 		}
@@ -111,6 +121,15 @@ func Locale(translator i18n.Translator, opts ...*LocaleOptions) gin.HandlerFunc
 			headerLocale := extractLocaleFromHeader(c.GetHeader(options.HeaderName), options.SupportedLocales)
 			if headerLocale != "" {
 				locale = headerLocale
+				explicit = true
+			}
+		}
+
+		// 最后参考 GeoIP 解析出的国家
+		if len(options.CountryLocales) > 0 && !explicit {
+			country := flowctx.Geo(c.Request.Context()).Country
+			if glocale := options.CountryLocales[country]; glocale != "" && isSupported(glocale, options.SupportedLocales) {
+				locale = glocale
 			}
 		}
 
@@ -135,7 +154,7 @@ func Locale(translator i18n.Translator, opts ...*LocaleOptions) gin.HandlerFunc
 		}
 
 		// 将语言保存到上下文
-		c.Set(localeContextKey, locale)
+		flowctx.SetLocale(c, locale)
 
 		// 将语言保存到翻译器上下文
 		transCtx := c.Request.Context()
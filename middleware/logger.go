@@ -1,12 +1,29 @@
 package middleware
 
 import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/diagnostics"
+	"github.com/zzliekkas/flow/v2/timing"
 )
 
+// ErrBodyTooLarge 请求体超过大小限制
+var ErrBodyTooLarge = errors.New("请求体超过大小限制")
+
+// LatencyObserver 请求耗时观察者，用于导出按路由模板统计的延迟直方图
+type LatencyObserver interface {
+	// ObserveRequest 记录一次请求，route 为路由模板（如 /users/:id），未匹配路由时为 "unmatched"
+	ObserveRequest(method, route string, status int, latency time.Duration)
+}
+
 // LoggerConfig 是日志中间件的配置选项
 type LoggerConfig struct {
 	// SkipPaths 是不需要记录日志的路径
@@ -20,6 +37,31 @@ type LoggerConfig struct {
 
 	// Output 是日志输出目标
 	Output logrus.FieldLogger
+
+	// SlowThreshold 慢请求阈值，超过时以Warn级别记录并附加耗时拆分与大小信息，为0时不检测
+	SlowThreshold time.Duration
+
+	// SuccessSampleRate 2xx请求日志的采样率（0~1），错误与慢请求始终记录
+	// 小于等于0或大于等于1时记录全部请求
+	SuccessSampleRate float64
+
+	// SampleSource 采样使用的随机源，为nil时使用按时间播种的随机源
+	SampleSource rand.Source
+
+	// Metrics 请求耗时观察者，为nil时不导出指标
+	Metrics LatencyObserver
+
+	// MaxBodySize 请求体大小上限（字节），为0时不限制
+	MaxBodySize int64
+
+	// EnforceBodyLimit 是否拒绝超限请求，为false时仅记录警告，便于灰度上线
+	EnforceBodyLimit bool
+
+	// Diagnostics 记录错误与慢请求的诊断收集器，为nil时使用 diagnostics.Default()
+	Diagnostics *diagnostics.Collector
+
+	// DisableDiagnostics 不向诊断收集器写入记录
+	DisableDiagnostics bool
 }
 
 // LoggerDefaultConfig 返回日志中间件的默认配置
@@ -60,6 +102,8 @@ func LoggerWithConfig(config LoggerConfig) flow.HandlerFunc {
 		config.Output = logrus.StandardLogger()
 	}
 
+	sampler := newLogSampler(config.SuccessSampleRate, config.SampleSource)
+
 	return func(c *flow.Context) {
 		// 处理请求开始时间
 		start := time.Now()
@@ -74,8 +118,29 @@ func LoggerWithConfig(config LoggerConfig) flow.HandlerFunc {
 			}
 		}
 
+		// 请求体大小限制，声明的长度已超限时直接处理
+		if config.MaxBodySize > 0 && c.Request.ContentLength > config.MaxBodySize {
+			if config.EnforceBodyLimit {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge,
+					flow.NewHTTPError(http.StatusRequestEntityTooLarge, ErrBodyTooLarge.Error()))
+			} else {
+				config.Output.Warnf("[Flow] 请求体超过限制(未拦截): %s %s %d > %d",
+					c.Request.Method, path, c.Request.ContentLength, config.MaxBodySize)
+			}
+		}
+
+		// 统计请求与响应大小，不缓冲内容
+		body := &countingBody{ReadCloser: c.Request.Body, limit: config.MaxBodySize, enforce: config.EnforceBodyLimit}
+		if c.Request.Body != nil {
+			c.Request.Body = body
+		}
+		writer := &timingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
 		// 处理请求
-		c.Next()
+		if !c.IsAborted() {
+			c.Next()
+		}
 
 		// 请求结束后记录日志
 		end := time.Now()
@@ -85,6 +150,30 @@ func LoggerWithConfig(config LoggerConfig) flow.HandlerFunc {
 		clientIP := c.ClientIP()
 		method := c.Request.Method
 		statusCode := c.Writer.Status()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		if config.Metrics != nil {
+			config.Metrics.ObserveRequest(method, route, statusCode, latency)
+		}
+
+		if !config.DisableDiagnostics {
+			recordDiagnostics(c, config.Diagnostics, start, route, raw, latency)
+		}
+
+		// 未声明长度（如分块传输）的超限请求在读取完成后告警
+		if body.exceeded && !config.EnforceBodyLimit && c.Request.ContentLength <= config.MaxBodySize {
+			config.Output.Warnf("[Flow] 请求体超过限制(未拦截): %s %s 已读取 %d 字节", method, path, body.n)
+		}
+
+		slow := config.SlowThreshold > 0 && latency >= config.SlowThreshold
+
+		// 成功请求按采样率记录
+		if statusCode < 300 && !slow && len(c.Errors) == 0 && !sampler.sample() {
+			return
+		}
 
 		if raw != "" {
 			path = path + "?" + raw
@@ -103,8 +192,32 @@ func LoggerWithConfig(config LoggerConfig) flow.HandlerFunc {
 			logFunc = config.Output.Infof
 		}
 
+		format := "[Flow] %s | %3d | %13v | %15s | %-7s %s"
+		if slow {
+			// 慢请求提升到Warn级别并附加详细信息
+			fields := logrus.Fields{
+				"route":          route,
+				"request_bytes":  body.n,
+				"response_bytes": writer.Size(),
+			}
+			if handlerStart, ok := flow.HandlerStartTime(c); ok {
+				fields["middleware_time"] = handlerStart.Sub(start)
+				if !writer.firstByte.IsZero() {
+					fields["handler_time"] = writer.firstByte.Sub(handlerStart)
+				} else {
+					fields["handler_time"] = end.Sub(handlerStart)
+				}
+			}
+			entry := config.Output.WithFields(fields)
+			logFunc = entry.Warnf
+			if statusCode >= 500 {
+				logFunc = entry.Errorf
+			}
+			format += " | SLOW"
+		}
+
 		// 记录日志
-		logFunc("[Flow] %s | %3d | %13v | %15s | %-7s %s",
+		logFunc(format,
 			end.Format("2006/01/02 - 15:04:05"),
 			statusCode,
 			latency,
@@ -121,3 +234,114 @@ func LoggerWithConfig(config LoggerConfig) flow.HandlerFunc {
 		}
 	}
 }
+
+// recordDiagnostics 将错误与慢请求写入诊断收集器，恢复中间件已记录的panic不重复记录
+func recordDiagnostics(c *flow.Context, collector *diagnostics.Collector, start time.Time, route, rawQuery string, latency time.Duration) {
+	if collector == nil {
+		collector = diagnostics.Default()
+	}
+
+	entry := diagnostics.Entry{
+		Time:      start,
+		Method:    c.Request.Method,
+		Route:     route,
+		Path:      c.Request.URL.Path,
+		Status:    c.Writer.Status(),
+		Duration:  latency,
+		RequestID: c.GetString("RequestID"),
+		Timing:    timing.FromContext(c.Request.Context()).Entries(),
+	}
+	if rawQuery != "" {
+		entry.Path += "?" + rawQuery
+	}
+	if _, recorded := c.Get(diagnosticsRecordedKey); recorded {
+		// panic 已由恢复中间件记录，这里只补充慢请求
+		collector.RecordSlowRequest(entry)
+		return
+	}
+	if last := c.Errors.Last(); last != nil {
+		entry.Error = last.Error()
+	}
+	collector.RecordRequest(entry)
+}
+
+// logSampler 成功请求日志采样器
+type logSampler struct {
+	rate float64
+	rnd  *rand.Rand
+	mu   sync.Mutex
+}
+
+// newLogSampler 创建采样器
+func newLogSampler(rate float64, source rand.Source) *logSampler {
+	if source == nil {
+		source = rand.NewSource(time.Now().UnixNano())
+	}
+	return &logSampler{rate: rate, rnd: rand.New(source)}
+}
+
+// sample 判断本次是否记录
+func (s *logSampler) sample() bool {
+	if s.rate <= 0 || s.rate >= 1 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rnd.Float64() < s.rate
+}
+
+// countingBody 统计读取字节数的请求体包装，强制限制时超限返回错误而不是panic
+type countingBody struct {
+	io.ReadCloser
+	n        int64
+	limit    int64
+	enforce  bool
+	exceeded bool
+}
+
+// Read 读取并计数
+func (b *countingBody) Read(p []byte) (int, error) {
+	if b.ReadCloser == nil {
+		return 0, io.EOF
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.limit > 0 && b.n > b.limit {
+		b.exceeded = true
+		if b.enforce {
+			return n, ErrBodyTooLarge
+		}
+	}
+	return n, err
+}
+
+// timingWriter 记录首字节写出时间的响应包装
+type timingWriter struct {
+	gin.ResponseWriter
+	firstByte time.Time
+}
+
+// WriteHeaderNow 记录响应头写出时间
+func (w *timingWriter) WriteHeaderNow() {
+	w.mark()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write 写入响应内容
+func (w *timingWriter) Write(data []byte) (int, error) {
+	w.mark()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写入字符串响应内容
+func (w *timingWriter) WriteString(s string) (int, error) {
+	w.mark()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// mark 记录首字节时间
+func (w *timingWriter) mark() {
+	if w.firstByte.IsZero() {
+		w.firstByte = time.Now()
+	}
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/diagnostics"
+)
+
+// fakeObserver 记录观察到的请求
+type fakeObserver struct {
+	mu     sync.Mutex
+	routes []string
+}
+
+func (o *fakeObserver) ObserveRequest(method, route string, status int, latency time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.routes = append(o.routes, method+" "+route)
+}
+
+func newLoggerTestEngine(config LoggerConfig) (*flow.Engine, *test.Hook) {
+	gin.SetMode(gin.TestMode)
+	logger, hook := test.NewNullLogger()
+	config.Output = logger
+
+	e := flow.New()
+	e.Use(LoggerWithConfig(config))
+	return e, hook
+}
+
+func doRequest(e *flow.Engine, method, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestLogger_SlowThresholdEscalation(t *testing.T) {
+	e, hook := newLoggerTestEngine(LoggerConfig{SlowThreshold: 10 * time.Millisecond})
+	e.GET("/fast", func(c *flow.Context) { c.String(http.StatusOK, "ok") })
+	e.GET("/slow/:id", func(c *flow.Context) {
+		time.Sleep(20 * time.Millisecond)
+		c.String(http.StatusOK, "ok")
+	})
+
+	doRequest(e, http.MethodGet, "/fast", "")
+	require.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, logrus.InfoLevel, hook.LastEntry().Level)
+
+	hook.Reset()
+	doRequest(e, http.MethodGet, "/slow/7", "")
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, logrus.WarnLevel, entry.Level, "慢请求应提升为Warn")
+	assert.Contains(t, entry.Message, "SLOW")
+	assert.Equal(t, "/slow/:id", entry.Data["route"])
+	assert.Equal(t, 2, entry.Data["response_bytes"])
+	assert.Contains(t, entry.Data, "middleware_time")
+	assert.Contains(t, entry.Data, "handler_time")
+}
+
+func TestLogger_SamplingDeterminism(t *testing.T) {
+	run := func() []bool {
+		e, hook := newLoggerTestEngine(LoggerConfig{
+			SuccessSampleRate: 0.3,
+			SampleSource:      rand.NewSource(42),
+		})
+		e.GET("/ok", func(c *flow.Context) { c.Status(http.StatusOK) })
+		e.GET("/fail", func(c *flow.Context) { c.Status(http.StatusInternalServerError) })
+
+		logged := make([]bool, 0, 50)
+		for i := 0; i < 50; i++ {
+			hook.Reset()
+			doRequest(e, http.MethodGet, "/ok", "")
+			logged = append(logged, len(hook.AllEntries()) > 0)
+		}
+
+		// 错误请求始终记录
+		for i := 0; i < 10; i++ {
+			hook.Reset()
+			doRequest(e, http.MethodGet, "/fail", "")
+			assert.Len(t, hook.AllEntries(), 1)
+		}
+		return logged
+	}
+
+	first := run()
+	assert.Equal(t, first, run(), "相同种子应得到相同的采样结果")
+
+	count := 0
+	for _, v := range first {
+		if v {
+			count++
+		}
+	}
+	assert.Greater(t, count, 0)
+	assert.Less(t, count, 50)
+}
+
+func TestLogger_MetricsUseRouteTemplates(t *testing.T) {
+	observer := &fakeObserver{}
+	e, _ := newLoggerTestEngine(LoggerConfig{Metrics: observer})
+	e.GET("/users/:id", func(c *flow.Context) { c.Status(http.StatusOK) })
+
+	doRequest(e, http.MethodGet, "/users/1", "")
+	doRequest(e, http.MethodGet, "/users/2", "")
+	doRequest(e, http.MethodGet, "/missing", "")
+
+	assert.Equal(t, []string{"GET /users/:id", "GET /users/:id", "GET unmatched"}, observer.routes)
+}
+
+func TestLogger_BodyLimit(t *testing.T) {
+	// 灰度模式只告警不拦截
+	e, hook := newLoggerTestEngine(LoggerConfig{MaxBodySize: 4})
+	e.POST("/upload", func(c *flow.Context) { c.Status(http.StatusOK) })
+	w := doRequest(e, http.MethodPost, "/upload", "0123456789")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, hook.AllEntries()[0].Message, "未拦截")
+
+	// 强制模式返回413
+	e, _ = newLoggerTestEngine(LoggerConfig{MaxBodySize: 4, EnforceBodyLimit: true})
+	called := false
+	e.POST("/upload", func(c *flow.Context) { called = true })
+	w = doRequest(e, http.MethodPost, "/upload", "0123456789")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.False(t, called)
+}
+
+func TestLogger_RecordsDiagnostics(t *testing.T) {
+	collector := diagnostics.New(diagnostics.Config{SlowThreshold: 20 * time.Millisecond})
+	e, _ := newLoggerTestEngine(LoggerConfig{Diagnostics: collector})
+	e.Use(RecoveryWithConfig(RecoveryConfig{DisablePrintStack: true, MaxStackSize: 1024, Diagnostics: collector}))
+
+	e.GET("/ok", func(c *flow.Context) { c.String(http.StatusOK, "ok") })
+	e.GET("/slow", func(c *flow.Context) {
+		time.Sleep(25 * time.Millisecond)
+		c.String(http.StatusOK, "ok")
+	})
+	e.GET("/users/:id", func(c *flow.Context) {
+		c.Set("RequestID", "req-1")
+		_ = c.Error(errors.New("查询失败"))
+		c.String(http.StatusBadGateway, "bad gateway")
+	})
+	e.GET("/panic", func(c *flow.Context) { panic("boom") })
+
+	for _, path := range []string{"/ok", "/slow", "/users/7?token=secret", "/panic"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	errs := collector.RecentErrors()
+	require.Len(t, errs, 2)
+	assert.Equal(t, "/users/:id", errs[0].Route)
+	assert.Equal(t, "/users/7?token=***MASKED***", errs[0].Path)
+	assert.Equal(t, "req-1", errs[0].RequestID)
+	assert.Equal(t, "查询失败", errs[0].Error)
+	// panic 只由恢复中间件记录一次
+	assert.Equal(t, diagnostics.KindPanic, errs[1].Kind)
+	assert.Equal(t, "panic: boom", errs[1].Error)
+
+	slow := collector.RecentSlowRequests()
+	require.Len(t, slow, 1)
+	assert.Equal(t, "/slow", slow[0].Route)
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"html/template"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/app"
+)
+
+// defaultMaintenanceTemplate 默认的维护页面
+var defaultMaintenanceTemplate = template.Must(template.New("maintenance").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>服务维护中</title></head>
+<body><h1>服务维护中</h1><p>{{if .Message}}{{.Message}}{{else}}我们正在进行系统维护，请稍后再试。{{end}}</p></body></html>`))
+
+// MaintenanceConfig 维护模式中间件配置
+type MaintenanceConfig struct {
+	// Store 维护模式状态存储，多实例部署时应使用共享的缓存存储
+	Store app.MaintenanceStore
+
+	// ExcludedPaths 维护期间仍可访问的路径，以 * 结尾表示前缀匹配
+	// 默认为健康检查路径
+	ExcludedPaths []string
+
+	// AllowCIDRs 始终允许访问的网段，与状态中保存的网段合并生效
+	AllowCIDRs []string
+
+	// BypassHeader 携带绕过密钥的请求头，默认 X-Maintenance-Bypass
+	BypassHeader string
+
+	// BypassCookie 绕过维护模式的Cookie名称，默认 flow_maintenance_bypass
+	// 访问 /<secret> 会写入该Cookie并重定向到首页
+	BypassCookie string
+
+	// Template HTML响应模板，模板数据为 *app.MaintenanceState
+	Template *template.Template
+
+	// RefreshInterval 状态缓存时间，避免每个请求都读取存储，默认1秒
+	RefreshInterval time.Duration
+}
+
+// DefaultMaintenanceConfig 返回默认配置
+func DefaultMaintenanceConfig() MaintenanceConfig {
+	return MaintenanceConfig{
+		ExcludedPaths:   []string{"/health", "/healthz", "/ready"},
+		BypassHeader:    "X-Maintenance-Bypass",
+		BypassCookie:    "flow_maintenance_bypass",
+		Template:        defaultMaintenanceTemplate,
+		RefreshInterval: time.Second,
+	}
+}
+
+// MaintenanceMode 创建维护模式中间件，store为空时使用默认文件存储
+// 应尽量放在中间件链的前部注册
+func MaintenanceMode(store app.MaintenanceStore) flow.HandlerFunc {
+	config := DefaultMaintenanceConfig()
+	config.Store = store
+	return MaintenanceModeWithConfig(config)
+}
+
+// MaintenanceModeWithConfig 使用自定义配置创建维护模式中间件
+func MaintenanceModeWithConfig(config MaintenanceConfig) flow.HandlerFunc {
+	defaults := DefaultMaintenanceConfig()
+	if config.Store == nil {
+		config.Store = app.NewFileMaintenanceStore("")
+	}
+	if config.ExcludedPaths == nil {
+		config.ExcludedPaths = defaults.ExcludedPaths
+	}
+	if config.BypassHeader == "" {
+		config.BypassHeader = defaults.BypassHeader
+	}
+	if config.BypassCookie == "" {
+		config.BypassCookie = defaults.BypassCookie
+	}
+	if config.Template == nil {
+		config.Template = defaults.Template
+	}
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = defaults.RefreshInterval
+	}
+
+	staticNets := parseCIDRs(config.AllowCIDRs)
+	state := &maintenanceStateCache{store: config.Store, interval: config.RefreshInterval}
+
+	return func(c *flow.Context) {
+		current := state.get(c.Request.Context())
+		if current == nil || isExcludedPath(c.Request.URL.Path, config.ExcludedPaths) {
+			c.Next()
+			return
+		}
+
+		if current.Secret != "" {
+			// 访问 /<secret> 时写入绕过Cookie
+			if c.Request.URL.Path == "/"+current.Secret {
+				c.SetCookie(config.BypassCookie, hashSecret(current.Secret), 12*3600, "/", "", false, true)
+				c.Redirect(http.StatusFound, "/")
+				c.Abort()
+				return
+			}
+			if secretEqual(c.GetHeader(config.BypassHeader), current.Secret) {
+				c.Next()
+				return
+			}
+			if cookie, err := c.Cookie(config.BypassCookie); err == nil && secretEqual(cookie, hashSecret(current.Secret)) {
+				c.Next()
+				return
+			}
+		}
+
+		if ip := net.ParseIP(c.ClientIP()); ip != nil {
+			if containsIP(staticNets, ip) || containsIP(parseCIDRs(current.AllowCIDRs), ip) {
+				c.Next()
+				return
+			}
+		}
+
+		if current.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(current.RetryAfter))
+		}
+
+		if strings.Contains(c.GetHeader("Accept"), "text/html") {
+			var buf bytes.Buffer
+			if err := config.Template.Execute(&buf, current); err == nil {
+				c.Data(http.StatusServiceUnavailable, "text/html; charset=utf-8", buf.Bytes())
+				c.Abort()
+				return
+			}
+		}
+
+		message := current.Message
+		if message == "" {
+			message = "服务维护中，请稍后再试"
+		}
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, flow.H{
+			"error":       "service_unavailable",
+			"message":     message,
+			"retry_after": current.RetryAfter,
+		})
+	}
+}
+
+// maintenanceStateCache 按刷新间隔缓存维护模式状态
+type maintenanceStateCache struct {
+	store    app.MaintenanceStore
+	interval time.Duration
+
+	mu        sync.Mutex
+	state     *app.MaintenanceState
+	fetchedAt time.Time
+}
+
+// get 返回当前状态，读取失败时沿用上一次的结果
+func (s *maintenanceStateCache) get(ctx context.Context) *app.MaintenanceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < s.interval {
+		return s.state
+	}
+
+	if state, err := s.store.Load(ctx); err == nil {
+		s.state = state
+	}
+	s.fetchedAt = time.Now()
+	return s.state
+}
+
+// isExcludedPath 检查路径是否在排除列表中
+func isExcludedPath(path string, excluded []string) bool {
+	for _, p := range excluded {
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDRs 解析网段列表，单个IP视为/32或/128，无效项被忽略
+func parseCIDRs(cidrs []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil {
+				bits := 128
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 32
+				}
+				nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			}
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// containsIP 检查IP是否属于任一网段
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashSecret 计算写入Cookie的密钥摘要，避免明文密钥出现在Cookie中
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte("flow-maintenance:" + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// secretEqual 常量时间比较
+func secretEqual(a, b string) bool {
+	return a != "" && subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
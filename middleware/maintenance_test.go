@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/app"
+	"github.com/zzliekkas/flow/v2/cache"
+)
+
+func newMaintenanceTestEngine(config MaintenanceConfig) *flow.Engine {
+	gin.SetMode(gin.TestMode)
+	config.RefreshInterval = time.Nanosecond
+
+	e := flow.New()
+	e.Use(MaintenanceModeWithConfig(config))
+	e.GET("/", func(c *flow.Context) { c.String(http.StatusOK, "home") })
+	e.GET("/healthz", func(c *flow.Context) { c.String(http.StatusOK, "ok") })
+	e.POST("/webhooks/pay", func(c *flow.Context) { c.String(http.StatusOK, "hook") })
+	return e
+}
+
+func TestMaintenance_BlocksWithRetryAfter(t *testing.T) {
+	store := app.NewFileMaintenanceStore(filepath.Join(t.TempDir(), "down"))
+	e := newMaintenanceTestEngine(MaintenanceConfig{Store: store})
+
+	assert.Equal(t, http.StatusOK, doRequest(e, "GET", "/", "").Code)
+
+	require.NoError(t, store.Save(context.Background(), &app.MaintenanceState{RetryAfter: 120, Message: "升级中"}))
+	w := doRequest(e, "GET", "/", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "120", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "升级中")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "<h1>服务维护中</h1>")
+
+	// 健康检查保持可用
+	assert.Equal(t, http.StatusOK, doRequest(e, "GET", "/healthz", "").Code)
+
+	require.NoError(t, store.Clear(context.Background()))
+	assert.Equal(t, http.StatusOK, doRequest(e, "GET", "/", "").Code)
+}
+
+func TestMaintenance_Bypass(t *testing.T) {
+	store := app.NewFileMaintenanceStore(filepath.Join(t.TempDir(), "down"))
+	require.NoError(t, store.Save(context.Background(), &app.MaintenanceState{Secret: "s3cret"}))
+	e := newMaintenanceTestEngine(MaintenanceConfig{
+		Store:         store,
+		ExcludedPaths: []string{"/healthz", "/webhooks/*"},
+	})
+
+	// 请求头绕过
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Maintenance-Bypass", "s3cret")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 访问密钥路径写入Cookie，之后携带Cookie即可访问
+	w = doRequest(e, "GET", "/s3cret", "")
+	assert.Equal(t, http.StatusFound, w.Code)
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.NotContains(t, cookies[0].Value, "s3cret")
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 错误的密钥与排除路径
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Maintenance-Bypass", "wrong")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, http.StatusOK, doRequest(e, "POST", "/webhooks/pay", "").Code)
+}
+
+func TestMaintenance_AllowCIDRs(t *testing.T) {
+	store := app.NewFileMaintenanceStore(filepath.Join(t.TempDir(), "down"))
+	require.NoError(t, store.Save(context.Background(), &app.MaintenanceState{AllowCIDRs: []string{"192.0.2.0/24"}}))
+	e := newMaintenanceTestEngine(MaintenanceConfig{Store: store})
+
+	// httptest 的默认来源地址为 192.0.2.1
+	assert.Equal(t, http.StatusOK, doRequest(e, "GET", "/", "").Code)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.9:1234"
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestMaintenance_SharedCacheStore(t *testing.T) {
+	shared := cache.NewMemoryStore()
+	nodeA := newMaintenanceTestEngine(MaintenanceConfig{Store: cache.NewMaintenanceStore(shared, "")})
+	nodeB := newMaintenanceTestEngine(MaintenanceConfig{Store: cache.NewMaintenanceStore(shared, "")})
+
+	application := app.New(flow.New())
+	application.SetMaintenanceStore(cache.NewMaintenanceStore(shared, ""))
+	require.NoError(t, application.Down(app.DownOptions{RetryAfter: 30}))
+	assert.True(t, application.IsDown())
+
+	assert.Equal(t, http.StatusServiceUnavailable, doRequest(nodeA, "GET", "/", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, doRequest(nodeB, "GET", "/", "").Code)
+
+	require.NoError(t, application.Up())
+	assert.Equal(t, http.StatusOK, doRequest(nodeA, "GET", "/", "").Code)
+	assert.Equal(t, http.StatusOK, doRequest(nodeB, "GET", "/", "").Code)
+}
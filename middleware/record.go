@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zzliekkas/flow/v2/flowctx"
+	"github.com/zzliekkas/flow/v2/redact"
 )
 
 // 日志记录输出位置
@@ -28,9 +30,6 @@ const (
 	LogLevelFull
 )
 
-// 敏感字段正则表达式
-var sensitiveFieldsRegex = regexp.MustCompile(`(?i)(password|token|secret|key|auth|credit|card)`)
-
 // ErrorLogWriterFunc 是错误日志写入函数
 type ErrorLogWriterFunc func(error)
 
@@ -71,8 +70,10 @@ type RecordConfig struct {
 	LogLevel int
 	// 是否屏蔽敏感信息
 	MaskSensitiveData bool
-	// 自定义敏感字段列表，补充默认的正则表达式
+	// 自定义敏感字段列表，补充默认的脱敏规则，设置 Redaction 时忽略
 	SensitiveFields []string
+	// 脱敏策略，为空时使用 redact.DefaultConfig 并追加 SensitiveFields
+	Redaction *redact.Policy
 	// 请求路径排除列表，这些路径不会被记录
 	SkipPaths []string
 	// 记录请求体的最大大小(字节)
@@ -150,41 +151,40 @@ func (w *FileLogWriter) Close() error {
 	return nil
 }
 
-// maskSensitiveData 遮盖敏感数据
-func maskSensitiveData(data map[string]interface{}, sensitiveFields []string) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	// 创建自定义敏感字段映射，便于快速查询
-	customSensitiveMap := make(map[string]bool)
-	for _, field := range sensitiveFields {
-		customSensitiveMap[strings.ToLower(field)] = true
+// headerMap 将请求头转换为日志字段，单个值记录为字符串
+func headerMap(h http.Header) map[string]interface{} {
+	headers := make(map[string]interface{}, len(h))
+	for k, v := range h {
+		if len(v) == 1 {
+			headers[k] = v[0]
+		} else {
+			headers[k] = v
+		}
 	}
+	return headers
+}
 
-	for k, v := range data {
-		// 检查是否为敏感字段
-		if customSensitiveMap[strings.ToLower(k)] || sensitiveFieldsRegex.MatchString(k) {
-			// 对不同类型进行不同处理
-			switch val := v.(type) {
-			case string:
-				if len(val) > 0 {
-					result[k] = "***MASKED***"
-				} else {
-					result[k] = val
-				}
-			case map[string]interface{}:
-				result[k] = maskSensitiveData(val, sensitiveFields)
-			default:
-				result[k] = "***MASKED***"
-			}
-		} else if nestedMap, ok := v.(map[string]interface{}); ok {
-			// 递归处理嵌套结构
-			result[k] = maskSensitiveData(nestedMap, sensitiveFields)
-		} else {
-			result[k] = v
+// logBody 返回记录的请求体或响应体，JSON 原样嵌入日志
+//
+// 脱敏时 JSON 经过流式重写，表单按查询参数规则处理；看起来是 JSON 却无法解析的内容不记录原文
+func logBody(policy *redact.Policy, body []byte, contentType string) interface{} {
+	if policy == nil {
+		if json.Valid(body) {
+			return json.RawMessage(body)
 		}
+		return string(body)
 	}
 
-	return result
+	if masked, err := policy.JSON(body); err == nil {
+		return json.RawMessage(masked)
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		return policy.Query(string(body))
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return fmt.Sprintf("[Invalid JSON body: %d bytes]", len(body))
+	}
+	return string(body)
 }
 
 // ResponseBodyWriter 自定义响应体写入器，用于捕获响应
@@ -225,6 +225,17 @@ func Record(config RecordConfig) gin.HandlerFunc {
 		}
 	}
 
+	// 脱敏策略只编译一次，为空表示不脱敏
+	var policy *redact.Policy
+	if config.MaskSensitiveData {
+		policy = config.Redaction
+		if policy == nil {
+			redactConfig := redact.DefaultConfig()
+			redactConfig.Fields = append(redactConfig.Fields, config.SensitiveFields...)
+			policy = redact.MustCompile(redactConfig)
+		}
+	}
+
 	return func(c *gin.Context) {
 		// 检查是否跳过此路径
 		path := c.Request.URL.Path
@@ -249,7 +260,7 @@ func Record(config RecordConfig) gin.HandlerFunc {
 		// 准备日志条目
 		entry := &RequestLogEntry{
 			Timestamp: startTime,
-			RequestID: c.GetString("RequestID"),
+			RequestID: c.GetString(flowctx.KeyRequestID),
 			ClientIP:  c.ClientIP(),
 			Method:    c.Request.Method,
 			Path:      path,
@@ -259,24 +270,18 @@ func Record(config RecordConfig) gin.HandlerFunc {
 		// 记录查询参数
 		if len(c.Request.URL.RawQuery) > 0 {
 			entry.Query = c.Request.URL.RawQuery
+			if policy != nil {
+				entry.Query = policy.Query(entry.Query)
+			}
 		}
 
 		// 记录请求头
 		if config.LogLevel >= LogLevelStandard {
-			headers := make(map[string]interface{})
-			for k, v := range c.Request.Header {
-				if len(v) == 1 {
-					headers[k] = v[0]
-				} else {
-					headers[k] = v
-				}
-			}
-
-			if config.MaskSensitiveData {
-				entry.RequestHeaders = maskSensitiveData(headers, config.SensitiveFields)
-			} else {
-				entry.RequestHeaders = headers
+			headers := c.Request.Header
+			if policy != nil {
+				headers = policy.Header(headers)
 			}
+			entry.RequestHeaders = headerMap(headers)
 		}
 
 		// 记录请求体
@@ -286,19 +291,7 @@ func Record(config RecordConfig) gin.HandlerFunc {
 				bodyBytes, _ := io.ReadAll(c.Request.Body)
 				c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes)) // 重置请求体供后续中间件使用
 
-				// 尝试解析JSON
-				var bodyData map[string]interface{}
-				if err := json.Unmarshal(bodyBytes, &bodyData); err == nil {
-					// 成功解析为JSON
-					if config.MaskSensitiveData {
-						entry.RequestBody = maskSensitiveData(bodyData, config.SensitiveFields)
-					} else {
-						entry.RequestBody = bodyData
-					}
-				} else {
-					// 不是JSON，使用字符串
-					entry.RequestBody = string(bodyBytes)
-				}
+				entry.RequestBody = logBody(policy, bodyBytes, c.ContentType())
 			} else {
 				entry.RequestBody = fmt.Sprintf("[Content too large: %d bytes]", c.Request.ContentLength)
 			}
@@ -322,39 +315,16 @@ func Record(config RecordConfig) gin.HandlerFunc {
 
 			// 记录响应头
 			if config.LogLevel >= LogLevelDetailed {
-				headers := make(map[string]interface{})
-				for k, v := range c.Writer.Header() {
-					if len(v) == 1 {
-						headers[k] = v[0]
-					} else {
-						headers[k] = v
-					}
-				}
-
-				if config.MaskSensitiveData {
-					entry.ResponseHeaders = maskSensitiveData(headers, config.SensitiveFields)
-				} else {
-					entry.ResponseHeaders = headers
+				headers := c.Writer.Header()
+				if policy != nil {
+					headers = policy.Header(headers)
 				}
+				entry.ResponseHeaders = headerMap(headers)
 			}
 
 			// 记录响应体
 			if config.LogLevel >= LogLevelFull && respBodyWriter.body.Len() > 0 && respBodyWriter.body.Len() <= config.MaxBodySize {
-				bodyBytes := respBodyWriter.body.Bytes()
-
-				// 尝试解析JSON
-				var bodyData map[string]interface{}
-				if err := json.Unmarshal(bodyBytes, &bodyData); err == nil {
-					// 成功解析为JSON
-					if config.MaskSensitiveData {
-						entry.ResponseBody = maskSensitiveData(bodyData, config.SensitiveFields)
-					} else {
-						entry.ResponseBody = bodyData
-					}
-				} else {
-					// 不是JSON，使用字符串
-					entry.ResponseBody = string(bodyBytes)
-				}
+				entry.ResponseBody = logBody(policy, respBodyWriter.body.Bytes(), c.Writer.Header().Get("Content-Type"))
 			} else if respBodyWriter.body.Len() > config.MaxBodySize {
 				entry.ResponseBody = fmt.Sprintf("[Content too large: %d bytes]", respBodyWriter.body.Len())
 			}
@@ -388,8 +358,8 @@ func WithRequestID() gin.HandlerFunc {
 			requestID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), time.Now().Unix())
 		}
 
-		// 设置请求ID到上下文
-		c.Set("RequestID", requestID)
+		// 设置请求ID到上下文，同时写入请求的 context 供数据库、缓存等下层读取
+		flowctx.SetRequestID(c, requestID)
 
 		// 添加请求ID到响应头
 		c.Header("X-Request-ID", requestID)
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/redact"
+)
+
+// memoryLogWriter 将日志条目保存在内存中
+type memoryLogWriter struct {
+	entries []*RequestLogEntry
+}
+
+func (w *memoryLogWriter) WriteLog(entry *RequestLogEntry) error {
+	w.entries = append(w.entries, entry)
+	return nil
+}
+
+func (w *memoryLogWriter) Close() error {
+	return nil
+}
+
+func TestRecord_Redaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	writer := &memoryLogWriter{}
+	config := DefaultRecordConfig()
+	config.LogWriter = writer
+	config.LogLevel = LogLevelFull
+	config.SensitiveFields = []string{"ssn"}
+
+	r := gin.New()
+	r.Use(Record(config))
+	r.POST("/login", func(c *gin.Context) {
+		c.Header("Set-Cookie", "session=abc")
+		c.JSON(http.StatusOK, gin.H{"access_token": "t1", "user": gin.H{"name": "alice"}})
+	})
+
+	send := func(body, contentType string) *RequestLogEntry {
+		req := httptest.NewRequest(http.MethodPost, "/login?api_key=k1&page=2", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer abc")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		require.NotEmpty(t, writer.entries)
+		return writer.entries[len(writer.entries)-1]
+	}
+
+	entry := send(`{"username":"alice","password":"hunter2","profile":{"ssn":"123"}}`, "application/json")
+	assert.Equal(t, "api_key=***MASKED***&page=2", entry.Query)
+	assert.Equal(t, "***MASKED***", entry.RequestHeaders.(map[string]interface{})["Authorization"])
+	assert.JSONEq(t, `{"username":"alice","password":"***MASKED***","profile":{"ssn":"***MASKED***"}}`, string(entry.RequestBody.(json.RawMessage)))
+	assert.Equal(t, "***MASKED***", entry.ResponseHeaders.(map[string]interface{})["Set-Cookie"])
+	assert.JSONEq(t, `{"access_token":"***MASKED***","user":{"name":"alice"}}`, string(entry.ResponseBody.(json.RawMessage)))
+
+	entry = send("username=alice&password=hunter2", "application/x-www-form-urlencoded")
+	assert.Equal(t, "username=alice&password=***MASKED***", entry.RequestBody)
+
+	// 无法解析的 JSON 不记录原文
+	entry = send(`{"password":"hunter2"`, "application/json")
+	assert.NotContains(t, entry.RequestBody, "hunter2")
+
+	// 自定义策略
+	config.Redaction = redact.MustCompile(redact.Config{Paths: []string{"$.username"}, Mask: "[x]"})
+	r = gin.New()
+	r.Use(Record(config))
+	r.POST("/login", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	entry = send(`{"username":"alice","password":"hunter2"}`, "application/json")
+	assert.JSONEq(t, `{"username":"[x]","password":"hunter2"}`, string(entry.RequestBody.(json.RawMessage)))
+}
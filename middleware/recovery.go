@@ -4,10 +4,16 @@ import (
 	"fmt"
 	"net/http"
 	"runtime"
+	"time"
 
 	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/app"
+	"github.com/zzliekkas/flow/v2/diagnostics"
 )
 
+// diagnosticsRecordedKey 标记panic已写入诊断收集器，日志中间件不再重复记录
+const diagnosticsRecordedKey = "flow.diagnostics_recorded"
+
 // RecoveryConfig 是恢复中间件的配置选项
 type RecoveryConfig struct {
 	// DisableStackAll 禁用完整堆栈跟踪
@@ -18,6 +24,12 @@ type RecoveryConfig struct {
 
 	// MaxStackSize 最大堆栈大小
 	MaxStackSize int
+
+	// HideErrorDetails 响应中不包含panic信息，生产环境默认开启
+	HideErrorDetails bool
+
+	// Diagnostics 记录panic的诊断收集器，为nil时使用 diagnostics.Default()
+	Diagnostics *diagnostics.Collector
 }
 
 // RecoveryDefaultConfig 返回恢复中间件的默认配置
@@ -26,6 +38,7 @@ func RecoveryDefaultConfig() RecoveryConfig {
 		DisableStackAll:   false,
 		DisablePrintStack: false,
 		MaxStackSize:      2048,
+		HideErrorDetails:  !app.CurrentDefaults().DetailedErrors,
 	}
 }
 
@@ -37,6 +50,7 @@ func Recovery() flow.HandlerFunc {
 // RecoveryWithConfig 返回一个使用指定配置的恢复中间件
 func RecoveryWithConfig(config RecoveryConfig) flow.HandlerFunc {
 	return func(c *flow.Context) {
+		start := time.Now()
 		defer func() {
 			if err := recover(); err != nil {
 				// 检查是否已经写入响应头
@@ -55,6 +69,9 @@ func RecoveryWithConfig(config RecoveryConfig) flow.HandlerFunc {
 
 				// 创建错误响应
 				errMsg := fmt.Sprintf("%v", err)
+				if config.HideErrorDetails {
+					errMsg = "内部服务器错误"
+				}
 				httpErr := &flow.HTTPError{
 					Code:    http.StatusInternalServerError,
 					Message: errMsg,
@@ -63,6 +80,22 @@ func RecoveryWithConfig(config RecoveryConfig) flow.HandlerFunc {
 				// 添加错误到上下文
 				c.Error(fmt.Errorf("%v", err))
 
+				collector := config.Diagnostics
+				if collector == nil {
+					collector = diagnostics.Default()
+				}
+				collector.RecordPanic(diagnostics.Entry{
+					Time:      start,
+					Method:    c.Request.Method,
+					Route:     c.FullPath(),
+					Path:      c.Request.URL.RequestURI(),
+					Status:    http.StatusInternalServerError,
+					Duration:  time.Since(start),
+					Error:     fmt.Sprintf("panic: %v", err),
+					RequestID: c.GetString("RequestID"),
+				})
+				c.Set(diagnosticsRecordedKey, true)
+
 				// 返回JSON错误响应
 				c.JSON(httpErr.Code, flow.H{
 					"error": httpErr.Message,
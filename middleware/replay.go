@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/redact"
+)
+
+// ReplayHeader flow replay 重放的请求携带该请求头，值为捕获记录的ID
+//
+// 有副作用的处理函数（扣款、发送通知、webhook）应检查 IsReplay 或使用 RejectReplay
+const ReplayHeader = "X-Flow-Replay"
+
+// ErrReplayBodyOmitted 捕获时请求体无法可靠脱敏而没有保存，无法重放
+var ErrReplayBodyOmitted = errors.New("捕获记录没有保存请求体，无法重放")
+
+// maxReplayDiffs 差异最多列出的条数
+const maxReplayDiffs = 20
+
+// IsReplay 判断请求是否由 flow replay 重放
+func IsReplay(r *http.Request) bool {
+	return r.Header.Get(ReplayHeader) != ""
+}
+
+// RejectReplay 返回拒绝重放请求的中间件，用于支付、webhook 等有外部副作用的路由
+func RejectReplay() flow.HandlerFunc {
+	return func(c *flow.Context) {
+		if IsReplay(c.Request) {
+			c.AbortWithStatusJSON(http.StatusForbidden, flow.H{"error": "该路由不接受重放的请求"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ReplayOptions 重放选项
+type ReplayOptions struct {
+	// Target 目标地址，如 http://localhost:8080，设置 Handler 时忽略
+	Target string
+
+	// Handler 进程内重放的处理器（通常是 *flow.Engine），不经过网络
+	Handler http.Handler
+
+	// Headers 覆盖的请求头，例如用本地令牌替换已脱敏的 Authorization
+	Headers http.Header
+
+	// Client 发送请求的客户端，为nil时使用超时30秒的客户端
+	Client *http.Client
+
+	// Redaction 与捕获时相同的脱敏策略，为nil时使用 redact.Default()
+	//
+	// 匹配策略的请求头在捕获时已替换为掩码，重放时不发送；比较响应前对重放的响应体做同样的脱敏
+	Redaction *redact.Policy
+}
+
+// ReplayResult 重放的响应与差异
+type ReplayResult struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Latency time.Duration
+	// Diff 与捕获的响应之间的差异，为空表示一致
+	Diff []string
+}
+
+// NewReplayRequest 根据捕获记录构造请求，target 为空时构造进程内请求
+func NewReplayRequest(ctx context.Context, record *CaptureRecord, target string, opts ReplayOptions) (*http.Request, error) {
+	captured := record.Request
+	if captured.BodyOmitted {
+		return nil, ErrReplayBodyOmitted
+	}
+	body := []byte(captured.Body)
+	if captured.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(captured.Body)
+		if err != nil {
+			return nil, fmt.Errorf("解码请求体失败: %w", err)
+		}
+		body = decoded
+	}
+
+	url := strings.TrimRight(target, "/") + captured.Path
+	if captured.Query != "" {
+		url += "?" + captured.Query
+	}
+	if target == "" && captured.Host != "" {
+		url = "http://" + captured.Host + url
+	}
+	req, err := http.NewRequestWithContext(ctx, captured.Method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	policy := opts.Redaction
+	if policy == nil {
+		policy = redact.Default()
+	}
+	for name, values := range captured.Header {
+		// 已脱敏的请求头与由传输层决定的请求头不发送
+		if policy.MatchHeader(name) || name == "Content-Length" || name == "Connection" || name == "Accept-Encoding" {
+			continue
+		}
+		req.Header[name] = append([]string(nil), values...)
+	}
+	for name, values := range opts.Headers {
+		req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+	req.Header.Set(ReplayHeader, record.ID)
+	return req, nil
+}
+
+// Replay 重放捕获的请求并与捕获的响应比较
+func Replay(ctx context.Context, record *CaptureRecord, opts ReplayOptions) (*ReplayResult, error) {
+	target := opts.Target
+	if opts.Handler != nil {
+		target = ""
+	} else if target == "" {
+		return nil, errors.New("重放需要设置目标地址或处理器")
+	}
+	req, err := NewReplayRequest(ctx, record, target, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result := &ReplayResult{}
+	if opts.Handler != nil {
+		recorder := httptest.NewRecorder()
+		opts.Handler.ServeHTTP(recorder, req)
+		result.Status, result.Header, result.Body = recorder.Code, recorder.Header(), recorder.Body.Bytes()
+	} else {
+		client := opts.Client
+		if client == nil {
+			client = &http.Client{Timeout: 30 * time.Second}
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		result.Status, result.Header, result.Body = resp.StatusCode, resp.Header, body
+	}
+	result.Latency = time.Since(start)
+	result.Diff = DiffResponse(record.Response, result, opts.Redaction)
+	return result, nil
+}
+
+// DiffResponse 比较捕获的响应与重放的响应：状态码、Content-Type 与响应体
+//
+// JSON 响应体按值比较并列出不同的路径；捕获时被截断或省略的响应体不比较
+func DiffResponse(recorded CapturedResponse, replayed *ReplayResult, policy *redact.Policy) []string {
+	if policy == nil {
+		policy = redact.Default()
+	}
+	var diffs []string
+	if recorded.Status != replayed.Status {
+		diffs = append(diffs, fmt.Sprintf("状态码: 记录 %d，重放 %d", recorded.Status, replayed.Status))
+	}
+	recordedType, replayedType := recorded.Header.Get("Content-Type"), replayed.Header.Get("Content-Type")
+	if recordedType != replayedType {
+		diffs = append(diffs, fmt.Sprintf("Content-Type: 记录 %q，重放 %q", recordedType, replayedType))
+	}
+	if recorded.Truncated || recorded.BodyOmitted {
+		return diffs
+	}
+
+	body, encoding, omitted := captureBody(policy, replayed.Body, replayedType, false)
+	if omitted {
+		return append(diffs, "响应体: 重放的响应体无法解析为 JSON")
+	}
+	if body == recorded.Body && encoding == recorded.BodyEncoding {
+		return diffs
+	}
+	var a, b interface{}
+	if json.Unmarshal([]byte(recorded.Body), &a) == nil && json.Unmarshal([]byte(body), &b) == nil {
+		diffJSON("$", a, b, &diffs)
+		return diffs
+	}
+	return append(diffs, fmt.Sprintf("响应体: 记录 %d 字节，重放 %d 字节，内容不同", len(recorded.Body), len(body)))
+}
+
+// diffJSON 递归比较两个 JSON 值，记录不同的路径
+func diffJSON(path string, a, b interface{}, diffs *[]string) {
+	if len(*diffs) >= maxReplayDiffs || reflect.DeepEqual(a, b) {
+		return
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			keys := make(map[string]struct{}, len(av)+len(bv))
+			for k := range av {
+				keys[k] = struct{}{}
+			}
+			for k := range bv {
+				keys[k] = struct{}{}
+			}
+			sorted := make([]string, 0, len(keys))
+			for k := range keys {
+				sorted = append(sorted, k)
+			}
+			sort.Strings(sorted)
+			for _, k := range sorted {
+				diffJSON(path+"."+k, av[k], bv[k], diffs)
+			}
+			return
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok && len(av) == len(bv) {
+			for i := range av {
+				diffJSON(fmt.Sprintf("%s[%d]", path, i), av[i], bv[i], diffs)
+			}
+			return
+		}
+	}
+	*diffs = append(*diffs, fmt.Sprintf("%s: 记录 %s，重放 %s", path, compactJSON(a), compactJSON(b)))
+}
+
+// compactJSON 输出差异中的值，不存在时为 <缺失>
+func compactJSON(v interface{}) string {
+	if v == nil {
+		return "<缺失>"
+	}
+	data, _ := json.Marshal(v)
+	if len(data) > 80 {
+		return string(data[:77]) + "..."
+	}
+	return string(data)
+}
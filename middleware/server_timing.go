@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/timing"
+)
+
+// DefaultServerTimingEntries Server-Timing 响应头默认最多输出的条数，不含 total
+const DefaultServerTimingEntries = 10
+
+// ServerTimingConfig Server-Timing 中间件配置
+type ServerTimingConfig struct {
+	// Enabled 判断请求是否启用，例如检查调试权限；为nil时只在 debug 模式下启用
+	Enabled func(c *flow.Context) bool
+
+	// SampleRate Enabled 返回false的请求按此比例（0~1）采样启用，为0时不采样
+	SampleRate float64
+
+	// SampleSource 采样使用的随机源，为nil时使用按时间播种的随机源
+	SampleSource rand.Source
+
+	// MaxEntries 响应头最多输出的条数，超出时保留耗时最长的几条，默认为 DefaultServerTimingEntries
+	MaxEntries int
+}
+
+// DefaultServerTimingConfig 返回默认配置，只在 debug 模式下启用
+func DefaultServerTimingConfig() ServerTimingConfig {
+	return ServerTimingConfig{MaxEntries: DefaultServerTimingEntries}
+}
+
+// ServerTiming 返回 debug 模式下输出 Server-Timing 响应头的中间件
+func ServerTiming() flow.HandlerFunc {
+	return ServerTimingWithConfig(DefaultServerTimingConfig())
+}
+
+// ServerTimingWithConfig 返回输出 Server-Timing 响应头的中间件
+//
+// 启用的请求在 c.Request.Context() 中带有 timing.Collector，数据库查询与缓存管理器的操作自动计入
+// db 与 cache 层，其他层用 timing.Measure 记录。响应头在首次写出时生成，包含写出前记录的各层与 total。
+// 同时使用 Logger 时，慢请求的各层耗时一并写入诊断缓冲区。未启用的请求不分配收集器
+func ServerTimingWithConfig(config ServerTimingConfig) flow.HandlerFunc {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultServerTimingEntries
+	}
+	var sampler *logSampler
+	if config.SampleRate > 0 {
+		sampler = newLogSampler(config.SampleRate, config.SampleSource)
+	}
+
+	return func(c *flow.Context) {
+		enabled := false
+		if config.Enabled != nil {
+			enabled = config.Enabled(c)
+		} else {
+			enabled = gin.IsDebugging()
+		}
+		if !enabled && (sampler == nil || !sampler.sample()) {
+			c.Next()
+			return
+		}
+
+		collector := timing.New()
+		c.Request = c.Request.WithContext(timing.WithCollector(c.Request.Context(), collector))
+		writer := &serverTimingWriter{
+			ResponseWriter: c.Writer,
+			collector:      collector,
+			start:          time.Now(),
+			max:            config.MaxEntries,
+		}
+		c.Writer = writer
+
+		c.Next()
+
+		// 没有写出响应体时 gin 在中间件返回后才写出响应头
+		writer.setHeader()
+	}
+}
+
+// serverTimingWriter 在响应头写出前设置 Server-Timing 的响应包装
+type serverTimingWriter struct {
+	gin.ResponseWriter
+	collector *timing.Collector
+	start     time.Time
+	max       int
+	done      bool
+}
+
+// setHeader 设置 Server-Timing 响应头，响应头已写出时不再设置
+func (w *serverTimingWriter) setHeader() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	w.done = true
+	if header := timing.Header(w.collector.Entries(), time.Since(w.start), w.max); header != "" {
+		w.Header().Set("Server-Timing", header)
+	}
+}
+
+// WriteHeaderNow 写出响应头
+func (w *serverTimingWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write 写入响应内容
+func (w *serverTimingWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写入字符串响应内容
+func (w *serverTimingWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Flush 刷新响应，流式响应的响应头在首次刷新时写出
+func (w *serverTimingWriter) Flush() {
+	w.setHeader()
+	w.ResponseWriter.Flush()
+}
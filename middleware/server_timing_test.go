@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/cache"
+	"github.com/zzliekkas/flow/v2/db"
+	"github.com/zzliekkas/flow/v2/diagnostics"
+	"github.com/zzliekkas/flow/v2/timing"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 处理函数不做任何改动，数据库与缓存的耗时自动出现在响应头与诊断记录中
+func TestServerTiming_CapturesLayers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.EnableTiming(conn))
+	manager := cache.NewManager()
+	manager.AddStore("memory", cache.NewMemoryStore())
+	manager.SetDefault("memory")
+
+	collector := diagnostics.New(diagnostics.Config{SlowThreshold: time.Nanosecond})
+	e, _ := newLoggerTestEngine(LoggerConfig{Diagnostics: collector})
+	e.Use(ServerTimingWithConfig(ServerTimingConfig{
+		Enabled: func(c *flow.Context) bool { return c.GetHeader("X-Debug") == "1" },
+	}))
+	e.GET("/report", func(c *flow.Context) {
+		ctx := c.Request.Context()
+		var n int
+		require.NoError(t, conn.WithContext(ctx).Raw("SELECT 1").Scan(&n).Error)
+		require.NoError(t, manager.Set(ctx, "report", n))
+		_, _ = manager.Get(ctx, "report")
+		func() {
+			defer timing.Measure(ctx, "render")()
+		}()
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/report", nil)
+	req.Header.Set("X-Debug", "1")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Regexp(t, `^db;dur=[0-9.]+, cache;dur=[0-9.]+, render;dur=[0-9.]+, total;dur=[0-9.]+$`, w.Header().Get("Server-Timing"))
+
+	// 慢请求的各层耗时写入诊断缓冲区
+	slow := collector.RecentSlowRequests()
+	require.Len(t, slow, 1)
+	require.Len(t, slow[0].Timing, 3)
+	assert.Equal(t, timing.LayerCache, slow[0].Timing[1].Name)
+	assert.Equal(t, 2, slow[0].Timing[1].Count)
+
+	// 未启用的请求没有响应头与收集器
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil))
+	assert.Empty(t, w.Header().Get("Server-Timing"))
+	assert.Empty(t, collector.RecentSlowRequests()[1].Timing)
+}
+
+func TestServerTiming_HeaderWrittenOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := flow.New()
+	e.Use(ServerTimingWithConfig(ServerTimingConfig{
+		Enabled:    func(c *flow.Context) bool { return true },
+		MaxEntries: 1,
+	}))
+	e.GET("/empty", func(c *flow.Context) {
+		timing.FromContext(c.Request.Context()).Add("a", time.Millisecond)
+		timing.FromContext(c.Request.Context()).Add("b", 2*time.Millisecond)
+		c.Status(http.StatusNoContent)
+	})
+	e.GET("/late", func(c *flow.Context) {
+		c.String(http.StatusOK, "ok")
+		// 响应头写出后的记录不再出现
+		timing.FromContext(c.Request.Context()).Add("late", time.Second)
+	})
+
+	// 没有响应体时同样输出，超出条数时保留耗时最长的
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/empty", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Regexp(t, `^b;dur=2, total;dur=[0-9.]+$`, w.Header().Get("Server-Timing"))
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/late", nil))
+	assert.NotContains(t, w.Header().Get("Server-Timing"), "late")
+	assert.Contains(t, w.Header().Get("Server-Timing"), "total;dur=")
+}
+
+func BenchmarkServerTiming_Disabled(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	manager := cache.NewManager()
+	manager.AddStore("memory", cache.NewMemoryStore())
+	manager.SetDefault("memory")
+	_ = manager.Set(context.Background(), "key", 1)
+
+	e := flow.New()
+	e.Use(ServerTiming())
+	e.GET("/", func(c *flow.Context) {
+		_, _ = manager.Get(c.Request.Context(), "key")
+		c.Status(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/signing"
+)
+
+// 请求签名错误
+var (
+	ErrSignatureMissing  = errors.New("请求签名缺失")
+	ErrSignatureInvalid  = errors.New("请求签名无效")
+	ErrSignatureExpired  = errors.New("请求签名时间戳超出允许范围")
+	ErrSignatureReplayed = errors.New("请求随机数已被使用")
+	ErrSignatureKey      = errors.New("未知的签名密钥")
+)
+
+// signedServiceKey 已认证的调用方服务在上下文中的键
+const signedServiceKey = "signed_service"
+
+// ServiceKey 调用方服务及其当前有效的密钥，轮换期间可同时配置新旧密钥
+type ServiceKey struct {
+	Service string
+	Secrets [][]byte
+}
+
+// KeyResolver 按请求中的密钥ID查找调用方，未知的密钥ID返回 nil
+type KeyResolver interface {
+	ResolveKey(ctx context.Context, keyID string) (*ServiceKey, error)
+}
+
+// StaticKeys 固定的密钥表，键为密钥ID
+type StaticKeys map[string]ServiceKey
+
+// ResolveKey 实现 KeyResolver
+func (k StaticKeys) ResolveKey(_ context.Context, keyID string) (*ServiceKey, error) {
+	if key, ok := k[keyID]; ok {
+		return &key, nil
+	}
+	return nil, nil
+}
+
+// NonceStore 记录已使用的随机数，cache.NonceStore 实现了该接口
+type NonceStore interface {
+	// Claim 首次使用时返回 true，ttl 内再次使用返回 false
+	Claim(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// SignatureOptions 请求签名验证配置
+type SignatureOptions struct {
+	// Tolerance 时间戳与服务器时间的最大偏差，默认5分钟
+	Tolerance time.Duration
+
+	// Nonces 已使用随机数的存储，为nil时使用进程内存储；多实例部署应使用 cache.NewNonceStore
+	Nonces NonceStore
+
+	// Now 时钟，为nil时使用 time.Now，测试中可注入固定时间
+	Now func() time.Time
+
+	// ErrorHandler 验证失败时的处理函数，默认返回401
+	ErrorHandler func(*flow.Context, error)
+}
+
+// VerifySignature 返回验证服务间请求签名的中间件，签名由 signing.Signer 生成
+//
+// 请求体通过 c.RawBody() 读取后计算摘要，处理函数中绑定仍然可用。验证通过后调用方服务名
+// 保存在上下文中，可通过 SignedService 读取用于权限判断
+//
+//	internal := e.Group("/internal", middleware.VerifySignature(middleware.StaticKeys{
+//		"orders": {Service: "orders", Secrets: [][]byte{current, previous}},
+//	}, middleware.SignatureOptions{}))
+func VerifySignature(keys KeyResolver, opts SignatureOptions) flow.HandlerFunc {
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 5 * time.Minute
+	}
+	if opts.Nonces == nil {
+		opts.Nonces = NewMemoryNonceStore()
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = func(c *flow.Context, err error) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, flow.H{"error": err.Error()})
+		}
+	}
+
+	return func(c *flow.Context) {
+		service, err := verifySignature(c, keys, opts)
+		if err != nil {
+			opts.ErrorHandler(c, err)
+			c.Abort()
+			return
+		}
+		c.Set(signedServiceKey, service)
+		c.Next()
+	}
+}
+
+// verifySignature 验证请求签名并返回调用方服务名
+func verifySignature(c *flow.Context, keys KeyResolver, opts SignatureOptions) (string, error) {
+	keyID := c.GetHeader(signing.HeaderKeyID)
+	timestamp := c.GetHeader(signing.HeaderTimestamp)
+	nonce := c.GetHeader(signing.HeaderNonce)
+	signature := c.GetHeader(signing.HeaderSignature)
+	if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return "", ErrSignatureMissing
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", ErrSignatureInvalid
+	}
+	skew := opts.Now().Sub(time.Unix(seconds, 0))
+	if skew > opts.Tolerance || skew < -opts.Tolerance {
+		return "", ErrSignatureExpired
+	}
+
+	ctx := c.Request.Context()
+	key, err := keys.ResolveKey(ctx, keyID)
+	if err != nil {
+		return "", err
+	}
+	if key == nil || len(key.Secrets) == 0 {
+		return "", ErrSignatureKey
+	}
+
+	body, err := c.RawBody()
+	if err != nil {
+		return "", err
+	}
+	stringToSign := signing.StringToSign(c.Request.Method, c.Request.URL.EscapedPath(), c.Request.URL.RawQuery,
+		signing.BodyHash(body), timestamp, nonce)
+	if !signing.Verify(key.Secrets, stringToSign, signature) {
+		return "", ErrSignatureInvalid
+	}
+
+	// 签名通过后才记录随机数，伪造的请求无法占用合法调用方的随机数
+	// 时间戳在前后各 Tolerance 内都有效，随机数需要保留两倍的时长
+	fresh, err := opts.Nonces.Claim(ctx, keyID+":"+nonce, 2*opts.Tolerance)
+	if err != nil {
+		return "", err
+	}
+	if !fresh {
+		return "", ErrSignatureReplayed
+	}
+	return key.Service, nil
+}
+
+// SignedService 返回通过签名认证的调用方服务名
+func SignedService(c *flow.Context) (string, bool) {
+	service := c.GetString(signedServiceKey)
+	return service, service != ""
+}
+
+// MemoryNonceStore 进程内的随机数存储，只适用于单实例部署
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	sweep  time.Time
+}
+
+// NewMemoryNonceStore 创建进程内随机数存储
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+// Claim 实现 NonceStore，顺带清理过期的随机数
+func (s *MemoryNonceStore) Claim(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.sweep) {
+		for key, expiry := range s.nonces {
+			if now.After(expiry) {
+				delete(s.nonces, key)
+			}
+		}
+		s.sweep = now.Add(ttl)
+	}
+
+	if expiry, ok := s.nonces[nonce]; ok && now.Before(expiry) {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
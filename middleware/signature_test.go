@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/cache"
+	"github.com/zzliekkas/flow/v2/signing"
+)
+
+var (
+	signingNow      = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	currentSecret   = []byte("current-secret")
+	previousSecret  = []byte("previous-secret")
+	signatureKeySet = StaticKeys{
+		"orders": {Service: "orders", Secrets: [][]byte{currentSecret, previousSecret}},
+	}
+)
+
+func newSignatureEngine(opts SignatureOptions) *flow.Engine {
+	gin.SetMode(gin.TestMode)
+	if opts.Now == nil {
+		opts.Now = func() time.Time { return signingNow }
+	}
+	e := flow.New()
+	e.Use(VerifySignature(signatureKeySet, opts))
+	e.POST("/internal/charge", func(c *flow.Context) {
+		var payload struct {
+			Amount int `json:"amount"`
+		}
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		service, _ := SignedService(c)
+		c.String(http.StatusOK, "%s:%d", service, payload.Amount)
+	})
+	return e
+}
+
+// httpBody 创建请求体
+func httpBody(body string) io.ReadCloser {
+	return io.NopCloser(strings.NewReader(body))
+}
+
+// signedRequest 使用指定密钥与时间签名的请求
+func signedRequest(t *testing.T, secret []byte, at time.Time, target, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(body))
+	signer := signing.NewSigner("orders", secret, signing.WithClock(func() time.Time { return at }))
+	require.NoError(t, signer.Sign(req))
+	return req
+}
+
+func serve(e *flow.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestVerifySignature_AcceptsSignedRequest(t *testing.T) {
+	e := newSignatureEngine(SignatureOptions{})
+
+	w := serve(e, signedRequest(t, currentSecret, signingNow, "/internal/charge?b=2&a=1", `{"amount": 42}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "orders:42", w.Body.String(), "请求体在验证后仍可绑定")
+
+	// 轮换期间旧密钥仍然有效
+	w = serve(e, signedRequest(t, previousSecret, signingNow, "/internal/charge", `{"amount": 7}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 查询参数顺序变化不影响签名
+	req := signedRequest(t, currentSecret, signingNow, "/internal/charge?b=2&a=1", `{"amount": 1}`)
+	req.URL.RawQuery = "a=1&b=2"
+	assert.Equal(t, http.StatusOK, serve(e, req).Code)
+}
+
+func TestVerifySignature_DetectsTampering(t *testing.T) {
+	e := newSignatureEngine(SignatureOptions{})
+
+	tamper := map[string]func(req *http.Request){
+		"body": func(req *http.Request) {
+			req.Body = httpBody(`{"amount": 4200}`)
+		},
+		"path": func(req *http.Request) {
+			req.URL.Path = "/internal/refund"
+		},
+		"query": func(req *http.Request) {
+			req.URL.RawQuery = "a=2"
+		},
+		"unknown key": func(req *http.Request) {
+			req.Header.Set(signing.HeaderKeyID, "billing")
+		},
+		"missing signature": func(req *http.Request) {
+			req.Header.Del(signing.HeaderSignature)
+		},
+	}
+	for name, fn := range tamper {
+		t.Run(name, func(t *testing.T) {
+			req := signedRequest(t, currentSecret, signingNow, "/internal/charge?a=1", `{"amount": 42}`)
+			fn(req)
+			w := serve(e, req)
+			// 修改路径后路由不匹配，但签名中间件已先行拒绝
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+
+	w := serve(e, signedRequest(t, []byte("wrong"), signingNow, "/internal/charge", `{}`))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), ErrSignatureInvalid.Error())
+}
+
+func TestVerifySignature_TimestampWindow(t *testing.T) {
+	e := newSignatureEngine(SignatureOptions{Tolerance: time.Minute})
+
+	cases := []struct {
+		name   string
+		at     time.Time
+		status int
+	}{
+		{"恰好在窗口下界", signingNow.Add(-time.Minute), http.StatusOK},
+		{"恰好在窗口上界", signingNow.Add(time.Minute), http.StatusOK},
+		{"过期", signingNow.Add(-time.Minute - time.Second), http.StatusUnauthorized},
+		{"时钟超前", signingNow.Add(time.Minute + time.Second), http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serve(e, signedRequest(t, currentSecret, tc.at, "/internal/charge", `{"amount": 1}`))
+			assert.Equal(t, tc.status, w.Code)
+			if tc.status != http.StatusOK {
+				assert.Contains(t, w.Body.String(), ErrSignatureExpired.Error())
+			}
+		})
+	}
+}
+
+func TestVerifySignature_RejectsReplay(t *testing.T) {
+	// 两个实例共享缓存，重放到另一个实例同样被拒绝
+	shared := cache.NewNonceStore(cache.NewMemoryStore(), "")
+	nodeA := newSignatureEngine(SignatureOptions{Nonces: shared})
+	nodeB := newSignatureEngine(SignatureOptions{Nonces: shared})
+
+	req := signedRequest(t, currentSecret, signingNow, "/internal/charge", `{"amount": 42}`)
+	replay := req.Clone(req.Context())
+	replay.Body = httpBody(`{"amount": 42}`)
+
+	assert.Equal(t, http.StatusOK, serve(nodeA, req).Code)
+	w := serve(nodeB, replay)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), ErrSignatureReplayed.Error())
+}
+
+func TestMemoryNonceStore_Expiry(t *testing.T) {
+	store := NewMemoryNonceStore()
+	fresh, err := store.Claim(context.Background(), "n1", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+	fresh, _ = store.Claim(context.Background(), "n1", 20*time.Millisecond)
+	assert.False(t, fresh)
+
+	time.Sleep(30 * time.Millisecond)
+	fresh, _ = store.Claim(context.Background(), "n1", 20*time.Millisecond)
+	assert.True(t, fresh, "过期后可以再次使用")
+}
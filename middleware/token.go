@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/auth/tokens"
+	"github.com/zzliekkas/flow/v2/flowctx"
+)
+
+// 访问令牌在上下文中的键，权限检查可直接读取用户ID与权限范围
+const (
+	tokenContextKey = "access_token"
+	TokenUserKey    = flowctx.KeyUserID
+	TokenScopesKey  = "scopes"
+)
+
+// TokenAuth 返回验证个人访问令牌的中间件，令牌通过 Authorization: Bearer flow_pat_... 传递
+//
+// 验证通过后令牌记录（用户ID与权限范围）保存在上下文中，可通过 AccessToken、TokenUserKey、TokenScopesKey 读取或使用 RequireScopes 检查权限；
+// 缺失、无效、过期或已撤销的令牌返回401；存储错误记录日志并返回不含细节的500
+func TokenAuth(manager *tokens.Manager) flow.HandlerFunc {
+	return func(c *flow.Context) {
+		header := c.GetHeader("Authorization")
+		plaintext, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || !strings.HasPrefix(plaintext, tokens.TokenPrefix) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, flow.H{"error": tokens.ErrTokenInvalid.Error()})
+			return
+		}
+
+		token, err := manager.Authenticate(c.Request.Context(), plaintext)
+		switch {
+		case errors.Is(err, tokens.ErrTokenInvalid), errors.Is(err, tokens.ErrTokenExpired), errors.Is(err, tokens.ErrTokenRevoked):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, flow.H{"error": err.Error()})
+			return
+		case err != nil:
+			// 存储错误可能包含SQL等内部信息，只写入日志
+			logrus.WithError(err).Error("验证访问令牌失败")
+			_ = c.Error(err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, flow.H{"error": "验证访问令牌失败"})
+			return
+		}
+
+		c.Set(tokenContextKey, token)
+		flowctx.SetUserID(c.Context, token.UserID)
+		c.Set(TokenScopesKey, token.Scopes)
+		c.Next()
+	}
+}
+
+// AccessToken 返回当前请求通过 TokenAuth 验证的令牌
+func AccessToken(c *flow.Context) (*tokens.Token, bool) {
+	value, ok := c.Get(tokenContextKey)
+	if !ok {
+		return nil, false
+	}
+	token, ok := value.(*tokens.Token)
+	return token, ok
+}
+
+// RequireScopes 要求访问令牌拥有全部权限范围，缺少时返回403，需放在 TokenAuth 之后
+func RequireScopes(scopes ...string) flow.HandlerFunc {
+	return func(c *flow.Context) {
+		token, ok := AccessToken(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, flow.H{"error": tokens.ErrTokenInvalid.Error()})
+			return
+		}
+		for _, scope := range scopes {
+			if !token.HasScope(scope) {
+				c.AbortWithStatusJSON(http.StatusForbidden, flow.H{"error": "访问令牌缺少权限: " + scope})
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/auth/tokens"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTokenAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := conn.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+
+	manager := tokens.NewManager(conn)
+	ctx := context.Background()
+	require.NoError(t, manager.Migrate(ctx))
+	reader, _, err := manager.IssueToken(ctx, "42", "reader", []string{"read:users"}, time.Time{})
+	require.NoError(t, err)
+	revoked, token, err := manager.IssueToken(ctx, "42", "old", []string{"read:users"}, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, manager.RevokeToken(ctx, token.ID))
+
+	e := flow.New()
+	api := e.Group("/api", TokenAuth(manager))
+	api.GET("/users", RequireScopes("read:users"), func(c *flow.Context) {
+		c.JSON(http.StatusOK, flow.H{"user": c.GetString(TokenUserKey), "scopes": c.GetStringSlice(TokenScopesKey)})
+	})
+	api.DELETE("/users", RequireScopes("write:users"), func(c *flow.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(method, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/users", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "Bearer "+reader)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user":"42","scopes":["read:users"]}`, w.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, "Bearer "+reader).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "Bearer "+revoked).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "Bearer eyJhbGciOiJIUzI1NiJ9").Code)
+
+	// 存储错误不向客户端暴露细节
+	require.NoError(t, sqlDB.Close())
+	w = request(http.MethodGet, "Bearer "+reader)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":"验证访问令牌失败"}`, w.Body.String())
+}
@@ -0,0 +1,245 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownMiddlewareGroup 引用了未注册的中间件组
+var ErrUnknownMiddlewareGroup = errors.New("未注册的中间件组")
+
+// MiddlewareGroupInfo 已注册中间件组的描述，供路由列表与文档生成使用
+type MiddlewareGroupInfo struct {
+	Name       string   // 中间件组名称
+	Middleware []string // 按执行顺序排列的中间件名称
+}
+
+// middlewareGroup 一个命名中间件组
+type middlewareGroup struct {
+	name     string
+	handlers []namedHandler
+}
+
+// middlewareGroups 中间件组注册表，按注册顺序保存
+type middlewareGroups struct {
+	mu     sync.RWMutex
+	groups []*middlewareGroup
+	errs   []error // UseGroup 引用未注册的组时记录的错误，由 Validate 与 Run 返回
+}
+
+// find 按名称查找中间件组
+func (r *middlewareGroups) find(name string) *middlewareGroup {
+	for _, g := range r.groups {
+		if g.name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// RegisterMiddlewareGroup 以名称注册一组中间件，之后可通过 UseGroup、UseGroupE 或 WithMiddlewareGroup 引用：
+//
+//	e.RegisterMiddlewareGroup("admin", auth.Required(), auth.Role("admin"))
+//	admin := e.Group("/admin").UseGroup("admin")
+//	e.GET("/reports", reports.Index, flow.WithMiddlewareGroup("admin"))
+//
+// 组内中间件的名称为“组名.推导名称”，如 admin.required，可用于 Route.Without 跳过。
+// 名称在引用时解析，重复注册同名的组会替换之前的定义，但不影响已注册的路由
+func (e *Engine) RegisterMiddlewareGroup(name string, handlers ...HandlerFunc) *Engine {
+	if name == "" {
+		panic("中间件组名称不能为空")
+	}
+
+	group := &middlewareGroup{name: name, handlers: nameHandlers(nil, handlers)}
+	for i := range group.handlers {
+		group.handlers[i].name = name + "." + group.handlers[i].name
+	}
+
+	e.groups.mu.Lock()
+	defer e.groups.mu.Unlock()
+	for i, g := range e.groups.groups {
+		if g.name == name {
+			e.groups.groups[i] = group
+			return e
+		}
+	}
+	e.groups.groups = append(e.groups.groups, group)
+	return e
+}
+
+// MiddlewareGroups 按注册顺序返回全部中间件组
+func (e *Engine) MiddlewareGroups() []MiddlewareGroupInfo {
+	e.groups.mu.RLock()
+	defer e.groups.mu.RUnlock()
+
+	infos := make([]MiddlewareGroupInfo, len(e.groups.groups))
+	for i, g := range e.groups.groups {
+		infos[i] = MiddlewareGroupInfo{Name: g.name, Middleware: handlerNames(g.handlers)}
+	}
+	return infos
+}
+
+// resolveMiddlewareGroups 按引用顺序展开中间件组，名称在中间件链中去重
+func (e *Engine) resolveMiddlewareGroups(chain []string, names []string) ([]namedHandler, error) {
+	e.groups.mu.RLock()
+	defer e.groups.mu.RUnlock()
+
+	var resolved []namedHandler
+	current := append([]string(nil), chain...)
+	for _, name := range names {
+		group := e.groups.find(name)
+		if group == nil {
+			registered := make([]string, len(e.groups.groups))
+			for i, g := range e.groups.groups {
+				registered[i] = g.name
+			}
+			return nil, fmt.Errorf("%w %q（已注册: %s）", ErrUnknownMiddlewareGroup, name, strings.Join(registered, ", "))
+		}
+		for _, h := range group.handlers {
+			h.name = uniqueMiddlewareName(current, h.name)
+			current = append(current, h.name)
+			resolved = append(resolved, h)
+		}
+	}
+	return resolved, nil
+}
+
+// mustResolveMiddlewareGroups 与 resolveMiddlewareGroups 相同，引用未注册的组时 panic，与路由冲突的处理方式一致
+func (e *Engine) mustResolveMiddlewareGroups(chain []string, names []string) []namedHandler {
+	resolved, err := e.resolveMiddlewareGroups(chain, names)
+	if err != nil {
+		panic(err)
+	}
+	return resolved
+}
+
+// nameWithGroups 展开引用的中间件组并为其余中间件分配名称，中间件组在前
+func (e *Engine) nameWithGroups(chain []string, groups []string, handlers []HandlerFunc) []namedHandler {
+	named := e.mustResolveMiddlewareGroups(chain, groups)
+	current := append(append([]string(nil), chain...), handlerNames(named)...)
+	return append(named, nameHandlers(current, handlers)...)
+}
+
+// UseGroup 按名称添加中间件组作为全局中间件，组按参数顺序执行
+//
+// 引用未注册的组时不添加任何中间件，错误由 Validate 与 Run 返回；需要立即处理错误时使用 UseGroupE
+func (e *Engine) UseGroup(names ...string) *Engine {
+	e.recordGroupError(e.UseGroupE(names...))
+	return e
+}
+
+// UseGroupE 与 UseGroup 相同，引用未注册的组时不添加任何中间件并返回 ErrUnknownMiddlewareGroup
+func (e *Engine) UseGroupE(names ...string) error {
+	named, err := e.resolveMiddlewareGroups(e.middleware, names)
+	if err != nil {
+		return err
+	}
+	e.middleware = append(e.middleware, handlerNames(named)...)
+	e.Engine.Use(wrapNamedHandlers(e, named)...)
+	return nil
+}
+
+// MustUseGroup 与 UseGroup 相同，引用未注册的组时 panic
+func (e *Engine) MustUseGroup(names ...string) *Engine {
+	if err := e.UseGroupE(names...); err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// UseGroup 按名称添加中间件组作为路由组中间件，组按参数顺序执行
+//
+// 引用未注册的组时不添加任何中间件，错误由 Engine.Validate 与 Run 返回；需要立即处理错误时使用 UseGroupE
+func (g *RouterGroup) UseGroup(names ...string) *RouterGroup {
+	g.engine.recordGroupError(g.UseGroupE(names...))
+	return g
+}
+
+// UseGroupE 与 UseGroup 相同，引用未注册的组时不添加任何中间件并返回 ErrUnknownMiddlewareGroup
+func (g *RouterGroup) UseGroupE(names ...string) error {
+	named, err := g.engine.resolveMiddlewareGroups(g.middleware, names)
+	if err != nil {
+		return err
+	}
+	g.middleware = append(g.middleware, handlerNames(named)...)
+	g.RouterGroup.Use(wrapNamedHandlers(g.engine, named)...)
+	return nil
+}
+
+// MustUseGroup 与 UseGroup 相同，引用未注册的组时 panic
+func (g *RouterGroup) MustUseGroup(names ...string) *RouterGroup {
+	if err := g.UseGroupE(names...); err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// recordGroupError 记录 UseGroup 的错误
+func (e *Engine) recordGroupError(err error) {
+	if err == nil {
+		return
+	}
+	e.groups.mu.Lock()
+	defer e.groups.mu.Unlock()
+	e.groups.errs = append(e.groups.errs, err)
+}
+
+// Validate 返回路由配置中记录的错误，如 UseGroup 引用了未注册的中间件组；没有错误时返回 nil
+//
+// Run 在监听端口前调用 Validate，也可以在测试或启动脚本中单独调用
+func (e *Engine) Validate() error {
+	e.groups.mu.RLock()
+	defer e.groups.mu.RUnlock()
+	return errors.Join(e.groups.errs...)
+}
+
+// WithMiddlewareGroup 在注册路由或路由组时按名称引用中间件组，与处理函数一起传入：
+//
+//	e.GET("/reports", reports.Index, flow.WithMiddlewareGroup("auth", "api-throttle"))
+//
+// 无论传入位置，引用的组都在路由级中间件之前按引用顺序执行
+func WithMiddlewareGroup(names ...string) HandlerFunc {
+	return groupHandler(names)
+}
+
+// groupHandler 返回引用中间件组的标记处理函数，注册路由时被识别并移出处理链
+func groupHandler(names []string) HandlerFunc {
+	return func(c *Context) {
+		if c.groupSink != nil {
+			*c.groupSink = append(*c.groupSink, names...)
+			return
+		}
+		// 误用在 Use 等位置时不影响请求
+		c.Next()
+	}
+}
+
+// groupHandlerPC groupHandler 返回的处理函数的代码地址，用于识别中间件组标记
+var groupHandlerPC = reflect.ValueOf(groupHandler(nil)).Pointer()
+
+// splitGroups 从处理函数中分离出中间件组标记，返回其余处理函数与引用的组名
+func splitGroups(handlers []HandlerFunc) ([]HandlerFunc, []string) {
+	var rest []HandlerFunc
+	var names []string
+	found := false
+	for i, h := range handlers {
+		if h == nil || reflect.ValueOf(h).Pointer() != groupHandlerPC {
+			if found {
+				rest = append(rest, h)
+			}
+			continue
+		}
+		if !found {
+			rest = append([]HandlerFunc(nil), handlers[:i]...)
+			found = true
+		}
+		h(&Context{groupSink: &names})
+	}
+	if !found {
+		return handlers, nil
+	}
+	return rest, names
+}
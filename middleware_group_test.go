@@ -0,0 +1,106 @@
+package flow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareGroup_Order(t *testing.T) {
+	e := newRouteTestEngine()
+	var trace []string
+
+	e.RegisterMiddlewareGroup("auth", recordMiddleware("auth", &trace))
+	e.RegisterMiddlewareGroup("admin", recordMiddleware("role", &trace), recordMiddleware("audit", &trace))
+	e.RegisterMiddlewareGroup("throttle", recordMiddleware("throttle", &trace))
+
+	admin := e.Group("/admin", WithMiddlewareGroup("auth")).UseGroup("admin")
+	// 中间件组在路由级中间件之前执行，与传入位置无关
+	admin.GET("/reports", recordMiddleware("route", &trace), WithMiddlewareGroup("throttle"), func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/reports", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"auth", "role", "audit", "throttle", "route"}, trace)
+	assert.Equal(t, []string{"recovery", "auth.record", "admin.record", "admin.record#2", "throttle.record", "record"},
+		e.MiddlewareChain(http.MethodGet, "/admin/reports"))
+}
+
+func TestMiddlewareGroup_Without(t *testing.T) {
+	e := newRouteTestEngine()
+	var trace []string
+
+	e.RegisterMiddlewareGroup("auth", recordMiddleware("auth", &trace))
+	e.UseGroup("auth")
+	e.GET("/health", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}).Without("auth.record")
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, trace)
+}
+
+func TestMiddlewareGroup_Unknown(t *testing.T) {
+	e := newRouteTestEngine()
+	e.RegisterMiddlewareGroup("auth", func(c *Context) { c.Next() })
+
+	_, err := e.resolveMiddlewareGroups(nil, []string{"auth", "admin"})
+	assert.ErrorIs(t, err, ErrUnknownMiddlewareGroup)
+	assert.Contains(t, err.Error(), `"admin"`)
+	assert.Contains(t, err.Error(), "已注册: auth")
+
+	assert.Panics(t, func() { e.Group("/api").MustUseGroup("admin") })
+	assert.Panics(t, func() { e.MustUseGroup("admin") })
+	defer func() {
+		r := recover()
+		require.NotNil(t, r)
+		// 路由注册的 panic 附带注册位置
+		assert.Contains(t, r, "admin")
+		assert.Contains(t, r, "middleware_group_test.go")
+	}()
+	e.GET("/reports", func(c *Context) {}, WithMiddlewareGroup("admin"))
+}
+
+func TestMiddlewareGroup_UnknownReturnsError(t *testing.T) {
+	e := newRouteTestEngine()
+	e.RegisterMiddlewareGroup("auth", func(c *Context) { c.Next() })
+	require.NoError(t, e.Validate())
+
+	api := e.Group("/api")
+	err := api.UseGroupE("auth", "admin")
+	assert.ErrorIs(t, err, ErrUnknownMiddlewareGroup)
+	assert.ErrorIs(t, e.UseGroupE("admin"), ErrUnknownMiddlewareGroup)
+	// 出错时不添加任何中间件，包括已注册的组
+	api.GET("/ping", func(c *Context) {})
+	assert.Equal(t, []string{"recovery"}, e.MiddlewareChain(http.MethodGet, "/api/ping"))
+	require.NoError(t, e.Validate(), "UseGroupE 的错误由调用方处理")
+
+	// UseGroup 记录错误，由 Validate 与 Run 返回
+	assert.NotPanics(t, func() { e.Group("/admin").UseGroup("admin") })
+	err = e.Validate()
+	assert.ErrorIs(t, err, ErrUnknownMiddlewareGroup)
+	assert.Contains(t, err.Error(), `"admin"`)
+	assert.ErrorIs(t, e.Run("127.0.0.1:0"), ErrUnknownMiddlewareGroup)
+}
+
+func TestMiddlewareGroups_List(t *testing.T) {
+	e := newRouteTestEngine()
+	e.RegisterMiddlewareGroup("web", recordMiddleware("session", nil))
+	e.RegisterMiddlewareGroup("api", recordMiddleware("token", nil), recordMiddleware("throttle", nil))
+	// 重复注册替换之前的定义，保留原来的顺序
+	e.RegisterMiddlewareGroup("web", recordMiddleware("session", nil), recordMiddleware("csrf", nil))
+
+	assert.Equal(t, []MiddlewareGroupInfo{
+		{Name: "web", Middleware: []string{"web.record", "web.record#2"}},
+		{Name: "api", Middleware: []string{"api.record", "api.record#2"}},
+	}, e.MiddlewareGroups())
+
+	assert.Panics(t, func() { e.RegisterMiddlewareGroup("") })
+}
@@ -0,0 +1,609 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gin-gonic/gin"
+	"github.com/zzliekkas/flow/v2/db"
+	"gopkg.in/yaml.v3"
+)
+
+// mockReloadDelay 文件变更后等待的时间
+const mockReloadDelay = 50 * time.Millisecond
+
+// mockOptions 模拟服务配置
+type mockOptions struct {
+	seed     int64
+	locale   string
+	override bool
+	watch    *bool
+}
+
+// MockOption 模拟服务选项
+type MockOption func(*mockOptions)
+
+// WithMockSeed 设置随机种子，相同种子下假数据与故障注入的结果可复现
+func WithMockSeed(seed int64) MockOption {
+	return func(o *mockOptions) {
+		o.seed = seed
+	}
+}
+
+// WithMockLocale 设置假数据的语言，默认 en_US
+func WithMockLocale(locale string) MockOption {
+	return func(o *mockOptions) {
+		o.locale = locale
+	}
+}
+
+// WithMockOverride 模拟路由优先于真实路由，需在注册真实路由之前挂载
+// 默认只有没有真实路由匹配的请求才由模拟路由处理
+func WithMockOverride() MockOption {
+	return func(o *mockOptions) {
+		o.override = true
+	}
+}
+
+// WithMockReload 设置是否监视模拟文件并自动重新加载，默认在调试模式下开启
+func WithMockReload(enabled bool) MockOption {
+	return func(o *mockOptions) {
+		o.watch = &enabled
+	}
+}
+
+// mockRouteSpec 模拟文件中的路由定义
+//
+//	routes:
+//	  - method: GET
+//	    path: /users/:id
+//	    status: 200
+//	    headers: {X-Mock: "true"}
+//	    latency: 150ms
+//	    failure_rate: 0.1
+//	    body:
+//	      id: "{{.Params.id}}"
+//	      name: "{{name}}"
+//	      email: "{{email}}"
+//	  - path: /users
+//	    repeat: 10
+//	    body: {id: "{{seq \"users\"}}", name: "{{name}}"}
+type mockRouteSpec struct {
+	Method        string            `yaml:"method"`
+	Path          string            `yaml:"path"`
+	Status        int               `yaml:"status"`
+	Headers       map[string]string `yaml:"headers"`
+	Body          interface{}       `yaml:"body"`
+	Repeat        int               `yaml:"repeat"`
+	Latency       string            `yaml:"latency"`
+	FailureRate   float64           `yaml:"failure_rate"`
+	FailureStatus int               `yaml:"failure_status"`
+}
+
+// mockRoute 编译后的模拟路由
+type mockRoute struct {
+	source        string // 文件与行号，例如 mocks/users.yaml:12
+	method        string
+	path          string
+	segments      []string
+	static        int // 静态路径段数量，用于匹配优先级
+	status        int
+	headers       map[string]string
+	body          interface{} // 字面量、*mockTemplate 或由它们组成的 map/slice
+	rawBody       bool        // body 为字符串模板，原样输出
+	repeat        int
+	latency       time.Duration
+	failureRate   float64
+	failureStatus int
+}
+
+// mockTemplate 字段值模板
+type mockTemplate struct {
+	tmpl *template.Template
+	// typed 整个值只有一个函数调用动作，输出为数字或布尔值时保留JSON类型
+	typed bool
+}
+
+// mockData 模板可访问的请求数据
+type mockData struct {
+	Params map[string]string
+	Query  map[string]string
+}
+
+// MockServer 模拟服务，从目录中的 YAML/JSON 文件加载路由定义
+type MockServer struct {
+	dir    string
+	opts   mockOptions
+	routes atomic.Pointer[[]*mockRoute]
+
+	mu       sync.Mutex // 保护假数据生成器、故障注入随机数与计数器
+	faker    *db.Faker
+	rnd      *rand.Rand
+	counters map[string]int
+
+	watcher *fsnotify.Watcher
+	sleep   func(time.Duration)
+}
+
+// MountMocks 从目录加载模拟路由并挂载到引擎，用于前端在没有真实后端时开发
+//
+// 默认只处理没有真实路由匹配的请求；调试模式下监视文件变更并自动重新加载
+func (e *Engine) MountMocks(dir string, opts ...MockOption) (*MockServer, error) {
+	options := mockOptions{seed: time.Now().UnixNano(), locale: db.LocaleEnUS}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	m := &MockServer{
+		dir:      dir,
+		opts:     options,
+		faker:    db.NewFaker(options.seed, options.locale),
+		rnd:      rand.New(rand.NewSource(options.seed)),
+		counters: make(map[string]int),
+		sleep:    time.Sleep,
+	}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+
+	if options.override {
+		e.UseNamed("mocks", func(c *Context) {
+			if !m.serve(c) {
+				c.Next()
+			}
+		})
+	} else {
+		e.NoRoute(func(c *gin.Context) {
+			m.serve(e.NewContext(c))
+		})
+	}
+
+	watch := e.IsDebug()
+	if options.watch != nil {
+		watch = *options.watch
+	}
+	if watch {
+		if err := m.watch(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Reload 重新加载模拟文件，加载失败时保留原有路由
+func (m *MockServer) Reload() error {
+	routes, err := loadMockRoutes(m.dir, m.funcs())
+	if err != nil {
+		return err
+	}
+	m.routes.Store(&routes)
+	return nil
+}
+
+// Close 停止监视文件
+func (m *MockServer) Close() error {
+	if m.watcher != nil {
+		return m.watcher.Close()
+	}
+	return nil
+}
+
+// serve 处理匹配的模拟路由，返回是否已处理
+func (m *MockServer) serve(c *Context) bool {
+	route, params := m.match(c.Request.Method, c.Request.URL.Path)
+	if route == nil {
+		return false
+	}
+
+	if route.latency > 0 {
+		m.sleep(route.latency)
+	}
+
+	m.mu.Lock()
+	failed := route.failureRate > 0 && m.rnd.Float64() < route.failureRate
+	m.mu.Unlock()
+	if failed {
+		c.AbortWithStatusJSON(route.failureStatus, H{"error": "模拟故障", "mock": route.source})
+		return true
+	}
+
+	data := mockData{Params: params, Query: make(map[string]string)}
+	for key, values := range c.Request.URL.Query() {
+		data.Query[key] = values[0]
+	}
+
+	m.mu.Lock()
+	body, err := route.render(data)
+	m.mu.Unlock()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, H{"error": err.Error(), "mock": route.source})
+		return true
+	}
+
+	for key, value := range route.headers {
+		c.Header(key, value)
+	}
+	if route.rawBody {
+		contentType := route.headers["Content-Type"]
+		if contentType == "" {
+			contentType = "application/json; charset=utf-8"
+		}
+		c.Data(route.status, contentType, []byte(body.(string)))
+	} else if body == nil {
+		c.Status(route.status)
+	} else {
+		c.JSON(route.status, body)
+	}
+	c.Abort()
+	return true
+}
+
+// match 查找匹配的模拟路由
+func (m *MockServer) match(method, path string) (*mockRoute, map[string]string) {
+	segments := splitMockPath(path)
+	for _, route := range *m.routes.Load() {
+		if route.method != method {
+			continue
+		}
+		if params, ok := route.match(segments); ok {
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+// match 按路径段匹配，支持 :name 参数与 *name 通配
+func (r *mockRoute) match(segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, pattern := range r.segments {
+		if strings.HasPrefix(pattern, "*") {
+			params[pattern[1:]] = "/" + strings.Join(segments[i:], "/")
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		switch {
+		case strings.HasPrefix(pattern, ":"):
+			params[pattern[1:]] = segments[i]
+		case pattern != segments[i]:
+			return nil, false
+		}
+	}
+	return params, len(segments) == len(r.segments)
+}
+
+// render 渲染响应体，调用方需持有 MockServer.mu
+func (r *mockRoute) render(data mockData) (interface{}, error) {
+	if r.repeat <= 0 {
+		return renderMockValue(r.body, data)
+	}
+	items := make([]interface{}, r.repeat)
+	for i := range items {
+		item, err := renderMockValue(r.body, data)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// renderMockValue 递归渲染模板
+func renderMockValue(value interface{}, data mockData) (interface{}, error) {
+	switch v := value.(type) {
+	case *mockTemplate:
+		var buf bytes.Buffer
+		if err := v.tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		out := buf.String()
+		if v.typed {
+			var scalar interface{}
+			if err := json.Unmarshal(buf.Bytes(), &scalar); err == nil {
+				switch scalar.(type) {
+				case float64, bool, nil:
+					return scalar, nil
+				}
+			}
+		}
+		return out, nil
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			r, err := renderMockValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = r
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := renderMockValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+	}
+	return value, nil
+}
+
+// funcs 模板函数，调用时已持有 MockServer.mu
+func (m *MockServer) funcs() template.FuncMap {
+	return template.FuncMap{
+		"name":     func() string { return m.faker.Name() },
+		"username": func() string { return m.faker.Username() },
+		"email":    func() string { return m.faker.Email() },
+		"phone":    func() string { return m.faker.Phone() },
+		"word":     func() string { return m.faker.Word() },
+		"sentence": func(words int) string { return m.faker.Sentence(words) },
+		"int":      func(min, max int) int { return m.faker.Int(min, max) },
+		"float":    func(min, max float64) float64 { return m.faker.Float(min, max) },
+		"bool":     func() bool { return m.faker.Bool(0.5) },
+		"pick":     func(values ...string) string { return m.faker.Pick(values...) },
+		"past":     func() string { return m.faker.Past(30 * 24 * time.Hour).Format(time.RFC3339) },
+		"now":      func() string { return time.Now().Format(time.RFC3339) },
+		// seq 返回指定名称的递增序号，跨请求保持，用于列表接口生成连续ID
+		"seq": func(name string) int {
+			m.counters[name]++
+			return m.counters[name]
+		},
+		// loop 返回 0..n-1，用于在字符串模板中 range 生成列表
+		"loop": func(n int) []int {
+			items := make([]int, n)
+			for i := range items {
+				items[i] = i
+			}
+			return items
+		},
+	}
+}
+
+// watch 监视模拟目录，文件变更后重新加载
+func (m *MockServer) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	err = filepath.WalkDir(m.dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			err = watcher.Add(path)
+		}
+		return err
+	})
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+	m.watcher = watcher
+
+	// 编辑器保存时通常触发多个事件，合并短时间内的变更后再重新加载
+	var timer *time.Timer
+	go func() {
+		for event := range watcher.Events {
+			if !isMockFile(event.Name) {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			name := event.Name
+			timer = time.AfterFunc(mockReloadDelay, func() {
+				if err := m.Reload(); err != nil {
+					log.Printf("重新加载模拟路由失败: %v\n", err)
+					return
+				}
+				log.Printf("模拟路由已重新加载: %s\n", name)
+			})
+		}
+	}()
+	return nil
+}
+
+// isMockFile 是否为模拟定义文件
+func isMockFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// splitMockPath 拆分路径段
+func splitMockPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// loadMockRoutes 加载目录下的所有模拟文件，路由按静态路径段数量排序，静态路径优先于参数
+func loadMockRoutes(dir string, funcs template.FuncMap) ([]*mockRoute, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && isMockFile(path) {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取模拟目录失败: %w", err)
+	}
+	sort.Strings(files)
+
+	var routes []*mockRoute
+	for _, file := range files {
+		fileRoutes, err := loadMockFile(file, funcs)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, fileRoutes...)
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].static > routes[j].static
+	})
+	return routes, nil
+}
+
+// loadMockFile 解析单个模拟文件，JSON 作为 YAML 的子集解析，以便错误信息带有行号
+func loadMockFile(file string, funcs template.FuncMap) ([]*mockRoute, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Routes []yaml.Node `yaml:"routes"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	routes := make([]*mockRoute, 0, len(doc.Routes))
+	for i := range doc.Routes {
+		node := &doc.Routes[i]
+		source := fmt.Sprintf("%s:%d", file, node.Line)
+		var spec mockRouteSpec
+		if err := node.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("%s: %v", source, err)
+		}
+		route, err := compileMockRoute(source, spec, funcs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// compileMockRoute 验证路由定义并编译模板
+func compileMockRoute(source string, spec mockRouteSpec, funcs template.FuncMap) (*mockRoute, error) {
+	route := &mockRoute{
+		source:        source,
+		method:        strings.ToUpper(spec.Method),
+		path:          spec.Path,
+		status:        spec.Status,
+		headers:       spec.Headers,
+		repeat:        spec.Repeat,
+		failureRate:   spec.FailureRate,
+		failureStatus: spec.FailureStatus,
+	}
+	if route.method == "" {
+		route.method = http.MethodGet
+	}
+	switch route.method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead, http.MethodOptions:
+	default:
+		return nil, fmt.Errorf("不支持的请求方法 %q", spec.Method)
+	}
+	if !strings.HasPrefix(spec.Path, "/") {
+		return nil, errors.New("path 必须以 / 开头")
+	}
+	route.segments = splitMockPath(spec.Path)
+	for i, segment := range route.segments {
+		switch {
+		case strings.HasPrefix(segment, "*") && i != len(route.segments)-1:
+			return nil, fmt.Errorf("通配参数 %s 必须位于路径末尾", segment)
+		case !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*"):
+			route.static++
+		}
+	}
+
+	if route.status == 0 {
+		route.status = http.StatusOK
+	}
+	if route.status < 100 || route.status > 599 {
+		return nil, fmt.Errorf("无效的状态码 %d", route.status)
+	}
+	if route.failureRate < 0 || route.failureRate > 1 {
+		return nil, fmt.Errorf("failure_rate 需在 0 到 1 之间，当前为 %v", route.failureRate)
+	}
+	if route.failureStatus == 0 {
+		route.failureStatus = http.StatusInternalServerError
+	}
+	if spec.Latency != "" {
+		latency, err := time.ParseDuration(spec.Latency)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 latency %q", spec.Latency)
+		}
+		route.latency = latency
+	}
+
+	if raw, ok := spec.Body.(string); ok {
+		if route.repeat > 0 {
+			return nil, errors.New("repeat 只能用于结构化的 body")
+		}
+		tmpl, err := template.New(source).Funcs(funcs).Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("body 模板错误: %v", err)
+		}
+		route.body, route.rawBody = &mockTemplate{tmpl: tmpl}, true
+		return route, nil
+	}
+
+	body, err := compileMockValue(source, spec.Body, funcs)
+	if err != nil {
+		return nil, err
+	}
+	route.body = body
+	return route, nil
+}
+
+// compileMockValue 编译结构化 body 中包含模板的字符串
+func compileMockValue(source string, value interface{}, funcs template.FuncMap) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		tmpl, err := template.New(source).Funcs(funcs).Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("body 模板错误: %v", err)
+		}
+		trimmed := strings.TrimSpace(v)
+		// 请求参数 {{.Params.id}} 始终作为字符串输出，函数调用 {{int 1 9}} 保留数字与布尔类型
+		typed := strings.HasPrefix(trimmed, "{{") && strings.HasSuffix(trimmed, "}}") && strings.Count(trimmed, "{{") == 1 &&
+			!strings.HasPrefix(strings.TrimLeft(trimmed[2:], "- "), ".")
+		return &mockTemplate{tmpl: tmpl, typed: typed}, nil
+	case map[string]interface{}:
+		compiled := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			c, err := compileMockValue(source, item, funcs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			compiled[key] = c
+		}
+		return compiled, nil
+	case []interface{}:
+		compiled := make([]interface{}, len(v))
+		for i, item := range v {
+			c, err := compileMockValue(source, item, funcs)
+			if err != nil {
+				return nil, err
+			}
+			compiled[i] = c
+		}
+		return compiled, nil
+	}
+	return value, nil
+}
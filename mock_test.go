@@ -0,0 +1,140 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mockUsersSpec = `routes:
+  - path: /users/:id
+    headers: {X-Mock: "true"}
+    body:
+      id: "{{.Params.id}}"
+      age: "{{int 18 60}}"
+      email: "{{email}}"
+      tab: "{{.Query.tab}}"
+  - path: /users/me
+    body: {id: "me"}
+  - path: /users
+    repeat: 3
+    body: {id: "{{seq \"users\"}}", name: "{{name}}"}
+  - method: POST
+    path: /orders
+    status: 201
+    latency: 200ms
+    failure_rate: 0.5
+    failure_status: 503
+    body: '{"ok":true}'
+`
+
+// writeMockSpec 写入模拟定义文件并返回目录
+func writeMockSpec(t *testing.T, spec string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.yaml"), []byte(spec), 0o644))
+	return dir
+}
+
+func serveMock(e *Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestMountMocks_Templates(t *testing.T) {
+	e := newRouteTestEngine()
+	_, err := e.MountMocks(writeMockSpec(t, mockUsersSpec), WithMockSeed(1), WithMockReload(false))
+	require.NoError(t, err)
+
+	w := serveMock(e, http.MethodGet, "/users/42?tab=orders")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Mock"))
+	var user map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, "42", user["id"])
+	assert.Equal(t, "orders", user["tab"])
+	assert.IsType(t, float64(0), user["age"], "单个动作输出数字时保留JSON类型")
+	assert.Contains(t, user["email"], "@")
+
+	// 静态路径优先于参数路径
+	assert.JSONEq(t, `{"id":"me"}`, serveMock(e, http.MethodGet, "/users/me").Body.String())
+
+	var users []map[string]interface{}
+	require.NoError(t, json.Unmarshal(serveMock(e, http.MethodGet, "/users").Body.Bytes(), &users))
+	require.Len(t, users, 3)
+	assert.Equal(t, []interface{}{float64(1), float64(2), float64(3)}, []interface{}{users[0]["id"], users[1]["id"], users[2]["id"]})
+
+	assert.Equal(t, http.StatusNotFound, serveMock(e, http.MethodGet, "/missing").Code)
+}
+
+func TestMountMocks_SeededFailuresAndLatency(t *testing.T) {
+	run := func() ([]int, []time.Duration) {
+		e := newRouteTestEngine()
+		m, err := e.MountMocks(writeMockSpec(t, mockUsersSpec), WithMockSeed(7), WithMockReload(false))
+		require.NoError(t, err)
+		var slept []time.Duration
+		m.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+		var codes []int
+		for i := 0; i < 20; i++ {
+			codes = append(codes, serveMock(e, http.MethodPost, "/orders").Code)
+		}
+		return codes, slept
+	}
+
+	codes, slept := run()
+	again, _ := run()
+	assert.Equal(t, codes, again, "相同种子下故障序列一致")
+	assert.Contains(t, codes, http.StatusCreated)
+	assert.Contains(t, codes, http.StatusServiceUnavailable)
+	require.Len(t, slept, 20)
+	assert.Equal(t, 200*time.Millisecond, slept[0])
+}
+
+func TestMountMocks_Precedence(t *testing.T) {
+	spec := "routes:\n  - path: /users/me\n    body: {source: mock}\n"
+
+	e := newRouteTestEngine()
+	e.GET("/users/me", func(c *Context) { c.JSON(http.StatusOK, H{"source": "real"}) })
+	_, err := e.MountMocks(writeMockSpec(t, spec), WithMockReload(false))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"source":"real"}`, serveMock(e, http.MethodGet, "/users/me").Body.String(), "默认真实路由优先")
+
+	e = newRouteTestEngine()
+	_, err = e.MountMocks(writeMockSpec(t, spec), WithMockOverride(), WithMockReload(false))
+	require.NoError(t, err)
+	e.GET("/users/me", func(c *Context) { c.JSON(http.StatusOK, H{"source": "real"}) })
+	assert.JSONEq(t, `{"source":"mock"}`, serveMock(e, http.MethodGet, "/users/me").Body.String(), "覆盖模式下模拟路由优先")
+}
+
+func TestMountMocks_HotReload(t *testing.T) {
+	dir := writeMockSpec(t, "routes:\n  - path: /ping\n    body: {v: 1}\n")
+	e := newRouteTestEngine()
+	m, err := e.MountMocks(dir, WithMockReload(true))
+	require.NoError(t, err)
+	defer m.Close()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.yaml"), []byte("routes:\n  - path: /ping\n    body: {v: 2}\n"), 0o644))
+	assert.Eventually(t, func() bool {
+		return serveMock(e, http.MethodGet, "/ping").Body.String() == `{"v":2}`
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// 无效定义不替换已加载的路由
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.yaml"), []byte("routes:\n  - path: ping\n"), 0o644))
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, `{"v":2}`, serveMock(e, http.MethodGet, "/ping").Body.String())
+}
+
+func TestMountMocks_ValidationErrorHasLine(t *testing.T) {
+	dir := writeMockSpec(t, "routes:\n  - path: /ok\n  - path: /bad\n    failure_rate: 2\n")
+	_, err := newRouteTestEngine().MountMocks(dir, WithMockReload(false))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "users.yaml:3: failure_rate")
+}
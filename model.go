@@ -0,0 +1,220 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// modelKeyPrefix 路由绑定的模型在上下文中的键前缀，后接模型类型
+const modelKeyPrefix = "_flow/model/"
+
+// modelConfig 路由模型绑定配置
+type modelConfig struct {
+	conn        *gorm.DB
+	preloads    []string
+	withTrashed bool
+	tenantCol   string
+	tenant      func(*Context) (interface{}, bool)
+}
+
+// ModelOption 路由模型绑定选项
+type ModelOption func(*modelConfig)
+
+// WithModelDB 指定查询使用的数据库连接，默认使用 c.DB()
+func WithModelDB(conn *gorm.DB) ModelOption {
+	return func(c *modelConfig) {
+		c.conn = conn
+	}
+}
+
+// WithModelPreload 查询时预加载关联
+func WithModelPreload(associations ...string) ModelOption {
+	return func(c *modelConfig) {
+		c.preloads = append(c.preloads, associations...)
+	}
+}
+
+// WithModelTrashed 包含已软删除的记录
+func WithModelTrashed() ModelOption {
+	return func(c *modelConfig) {
+		c.withTrashed = true
+	}
+}
+
+// WithModelTenant 按租户限定查询范围，tenant 返回当前请求的租户值
+//
+// 其他租户的记录与不存在的记录一样响应404，无法通过猜测ID访问；无法确定租户时同样响应404
+func WithModelTenant(column string, tenant func(*Context) (interface{}, bool)) ModelOption {
+	return func(c *modelConfig) {
+		c.tenantCol = column
+		c.tenant = tenant
+	}
+}
+
+// ParamModel 按路由参数查询模型，记录不存在时响应404并中止请求
+//
+// 默认按主键查询；模型字段带有与参数同名的 `route` 标签时按该字段的列查询：
+//
+//	type Post struct {
+//		ID   uint
+//		Slug string `route:"slug"`
+//	}
+//
+//	e.GET("/posts/:slug", func(c *flow.Context) {
+//		post, err := flow.ParamModel[Post](c, "slug", flow.WithModelPreload("Comments"))
+//		if err != nil {
+//			return
+//		}
+//		c.JSON(http.StatusOK, post)
+//	})
+//
+// 查询使用请求的 context，请求 context 中存在事务（db.NewTransactionContext）时在事务中查询。
+// 未找到时返回的错误满足 errors.Is(err, ErrNotFound)，查询失败时响应500
+func ParamModel[T any](c *Context, param string, opts ...ModelOption) (*T, error) {
+	record, err := findModel[T](c, param, opts)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, H{"error": "记录不存在"})
+		} else {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, H{"error": err.Error()})
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// BindModel 返回按路由参数加载模型的中间件，处理函数通过 ModelFrom 读取：
+//
+//	e.GET("/users/:id", flow.BindModel[User]("id"), func(c *flow.Context) {
+//		user, _ := flow.ModelFrom[User](c)
+//		c.JSON(http.StatusOK, user)
+//	})
+func BindModel[T any](param string, opts ...ModelOption) HandlerFunc {
+	return func(c *Context) {
+		record, err := ParamModel[T](c, param, opts...)
+		if err != nil {
+			return
+		}
+		c.Set(modelKey[T](), record)
+		c.Next()
+	}
+}
+
+// ModelFrom 读取 BindModel 加载的模型
+func ModelFrom[T any](c *Context) (*T, bool) {
+	v, ok := c.Get(modelKey[T]())
+	if !ok {
+		return nil, false
+	}
+	record, ok := v.(*T)
+	return record, ok
+}
+
+// modelKey 模型在上下文中的键
+func modelKey[T any]() string {
+	return modelKeyPrefix + reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+// findModel 查询路由参数对应的记录
+func findModel[T any](c *Context, param string, opts []ModelOption) (*T, error) {
+	cfg := modelConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx := c.Request.Context()
+	conn := cfg.conn
+	if tx, ok := db.GetTransaction(ctx); ok {
+		conn = tx
+	}
+	if conn == nil {
+		conn = c.DB()
+	}
+	if conn == nil {
+		return nil, errors.New("flow: 路由模型绑定没有可用的数据库连接")
+	}
+
+	record := new(T)
+	stmt := &gorm.Statement{DB: conn}
+	if err := stmt.Parse(record); err != nil {
+		return nil, fmt.Errorf("flow: 解析模型 %T 失败: %w", record, err)
+	}
+	field, err := routeField(stmt.Schema, param)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := c.Param(param)
+	value, ok := routeValue(field, raw)
+	if !ok {
+		// 无法转换为列类型的值不可能匹配任何记录
+		return nil, fmt.Errorf("%w: %s %s=%s", ErrNotFound, stmt.Schema.Name, param, raw)
+	}
+
+	query := conn.WithContext(ctx).Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+		Value:  value,
+	})
+	if cfg.tenant != nil {
+		tenant, ok := cfg.tenant(c)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s=%s", ErrNotFound, stmt.Schema.Name, param, raw)
+		}
+		query = query.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: cfg.tenantCol},
+			Value:  tenant,
+		})
+	}
+	if cfg.withTrashed {
+		query = query.Unscoped()
+	}
+	for _, association := range cfg.preloads {
+		query = query.Preload(association)
+	}
+
+	if err := query.Take(record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s %s=%s", ErrNotFound, stmt.Schema.Name, param, raw)
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// routeField 返回路由参数对应的字段：带有同名 route 标签的字段，否则为主键
+func routeField(s *schema.Schema, param string) (*schema.Field, error) {
+	for _, field := range s.Fields {
+		if field.Tag.Get("route") == param && field.DBName != "" {
+			return field, nil
+		}
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("flow: 模型 %s 没有单一主键，请用 route 标签指定参数 %s 对应的字段", s.Name, param)
+	}
+	return s.PrioritizedPrimaryField, nil
+}
+
+// routeValue 按字段类型转换参数值，整数列只接受整数
+func routeValue(field *schema.Field, raw string) (interface{}, bool) {
+	if raw == "" {
+		return nil, false
+	}
+	switch field.FieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		return n, err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		return n, err == nil
+	default:
+		return raw, true
+	}
+}
@@ -0,0 +1,162 @@
+package flow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type bindAccount struct {
+	ID        uint
+	TenantID  uint
+	Slug      string `route:"slug"`
+	Name      string
+	Orders    []bindOrder
+	DeletedAt gorm.DeletedAt
+}
+
+type bindOrder struct {
+	ID            uint
+	BindAccountID uint
+	Total         int
+}
+
+func newModelTestDB(t *testing.T) *gorm.DB {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := conn.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, conn.AutoMigrate(&bindAccount{}, &bindOrder{}))
+	require.NoError(t, conn.Create(&[]bindAccount{
+		{ID: 1, TenantID: 1, Slug: "acme", Name: "Acme", Orders: []bindOrder{{Total: 10}, {Total: 20}}},
+		{ID: 2, TenantID: 2, Slug: "globex", Name: "Globex"},
+		{ID: 3, TenantID: 1, Slug: "closed", Name: "Closed"},
+	}).Error)
+	require.NoError(t, conn.Delete(&bindAccount{}, 3).Error)
+	return conn
+}
+
+func serveModel(e *Engine, path string, header http.Header) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+func TestBindModel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := newModelTestDB(t)
+	e := New()
+	show := func(c *Context) {
+		account, ok := ModelFrom[bindAccount](c)
+		require.True(t, ok)
+		c.JSON(http.StatusOK, H{"name": account.Name, "orders": len(account.Orders)})
+	}
+	e.GET("/accounts/:id", BindModel[bindAccount]("id", WithModelDB(conn)), show)
+	e.GET("/by-slug/:slug", BindModel[bindAccount]("slug", WithModelDB(conn)), show)
+	e.GET("/with-orders/:id", BindModel[bindAccount]("id", WithModelDB(conn), WithModelPreload("Orders")), show)
+	e.GET("/trashed/:id", BindModel[bindAccount]("id", WithModelDB(conn), WithModelTrashed()), show)
+
+	code, body := serveModel(e, "/accounts/1", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"name":"Acme","orders":0}`, body)
+
+	code, _ = serveModel(e, "/accounts/99", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = serveModel(e, "/accounts/abc", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// route 标签指定的列
+	code, body = serveModel(e, "/by-slug/globex", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"name":"Globex","orders":0}`, body)
+
+	_, body = serveModel(e, "/with-orders/1", nil)
+	assert.JSONEq(t, `{"name":"Acme","orders":2}`, body)
+
+	// 软删除的记录默认不可见
+	code, _ = serveModel(e, "/accounts/3", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, body = serveModel(e, "/trashed/3", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"name":"Closed","orders":0}`, body)
+}
+
+func TestBindModelTenantIsolation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := newModelTestDB(t)
+	tenant := func(c *Context) (interface{}, bool) {
+		id := c.GetHeader("X-Tenant")
+		return id, id != ""
+	}
+	e := New()
+	e.GET("/accounts/:id", BindModel[bindAccount]("id", WithModelDB(conn), WithModelTenant("tenant_id", tenant)), func(c *Context) {
+		account, _ := ModelFrom[bindAccount](c)
+		c.String(http.StatusOK, account.Name)
+	})
+
+	code, body := serveModel(e, "/accounts/1", http.Header{"X-Tenant": {"1"}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Acme", body)
+
+	// 其他租户的记录与不存在一样
+	code, _ = serveModel(e, "/accounts/2", http.Header{"X-Tenant": {"1"}})
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = serveModel(e, "/accounts/1", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestParamModelUsesRequestTransaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := newModelTestDB(t)
+	e := New()
+	e.GET("/accounts/:id", func(c *Context) {
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			require.NoError(t, tx.Create(&bindAccount{ID: 10, TenantID: 1, Slug: "pending", Name: "Pending"}).Error)
+			c.Request = c.Request.WithContext(db.NewTransactionContext(c.Request.Context(), tx))
+
+			account, err := ParamModel[bindAccount](c, "id", WithModelDB(conn))
+			if err != nil {
+				return err
+			}
+			c.String(http.StatusOK, account.Name)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	code, body := serveModel(e, "/accounts/10", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Pending", body)
+}
+
+func TestParamModelRespectsRequestContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := newModelTestDB(t)
+	e := New()
+	e.GET("/accounts/:id", func(c *Context) {
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		cancel()
+		c.Request = c.Request.WithContext(ctx)
+		_, err := ParamModel[bindAccount](c, "id", WithModelDB(conn))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	code, _ := serveModel(e, "/accounts/1", nil)
+	assert.Equal(t, http.StatusInternalServerError, code)
+}
@@ -0,0 +1,143 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// moneyJSON JSON表示，amount 可以是字符串或数字
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON 编码为 {"amount":"39.99","currency":"CNY"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.currency})
+}
+
+// UnmarshalJSON 解码 {"amount":"39.99","currency":"CNY"}
+//
+// 为兼容旧的 float64 接口，amount 也可以是数字（如 39.99），按原始文本严格解析，不经过 float64
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	amount, err := decodeAmount(raw.Amount)
+	if err != nil {
+		return err
+	}
+	parsed, err := FromDecimalString(amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Compact 紧凑的JSON表示，amount 为最小货币单位的整数：{"amount":3999,"currency":"CNY"}
+// 用于与按分计价的支付接口交互
+type Compact struct {
+	Money
+}
+
+// MarshalJSON 编码为 {"amount":3999,"currency":"CNY"}
+func (c Compact) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+	}{c.amount, c.currency})
+}
+
+// UnmarshalJSON 解码 {"amount":3999,"currency":"CNY"}
+func (c *Compact) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := validateCurrency(raw.Currency); err != nil {
+		return err
+	}
+	c.Money = FromMinorUnits(raw.Amount, raw.Currency)
+	return nil
+}
+
+// decodeAmount 取出金额文本，数字保留原始写法
+func decodeAmount(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "", fmt.Errorf("%w: 缺少 amount", ErrInvalidAmount)
+	}
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidAmount, raw)
+	}
+	return trimTrailingZeros(number.String()), nil
+}
+
+// trimTrailingZeros 去掉数字小数部分末尾的0，使 3999.000 这类旧数据也能解析
+func trimTrailingZeros(s string) string {
+	if !strings.Contains(s, ".") || strings.ContainsAny(s, "eE") {
+		return s
+	}
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// GormDataType 数据库列类型
+func (Money) GormDataType() string {
+	return "string"
+}
+
+// Value 实现 driver.Valuer，存储为 "CNY 39.99"
+func (m Money) Value() (driver.Value, error) {
+	if m.currency == "" {
+		return nil, nil
+	}
+	return m.String(), nil
+}
+
+// Scan 实现 sql.Scanner
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("money: 无法从 %T 读取金额", src)
+	}
+
+	currency, amount, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	parsed, err := FromDecimalString(amount, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
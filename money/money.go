@@ -0,0 +1,285 @@
+// Package money 提供以最小货币单位（分）存储的金额类型，避免 float64 带来的舍入误差
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 金额相关错误
+var (
+	ErrCurrencyMismatch = errors.New("money: 币种不一致")
+	ErrInvalidAmount    = errors.New("money: 无效的金额")
+	ErrInvalidCurrency  = errors.New("money: 无效的币种代码")
+	ErrOverflow         = errors.New("money: 金额溢出")
+)
+
+// exponents 小数位数不为2的币种（ISO 4217）
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Exponent 返回币种的小数位数，例如 CNY 为2，JPY 为0，BHD 为3
+func Exponent(currency string) int {
+	if exp, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Money 金额，amount 为最小货币单位的整数
+type Money struct {
+	amount   int64
+	currency string
+}
+
+// FromMinorUnits 以最小货币单位创建金额，例如 FromMinorUnits(3999, "CNY") 表示 39.99 元
+func FromMinorUnits(amount int64, currency string) Money {
+	return Money{amount: amount, currency: strings.ToUpper(currency)}
+}
+
+// FromDecimalString 解析十进制字符串，例如 "39.99"
+//
+// 解析是严格的：不接受科学计数法、正号、空白、千位分隔符，
+// 小数位数不能超过币种的小数位数（"1.999" 对 CNY 返回错误而不是舍入）
+func FromDecimalString(value, currency string) (Money, error) {
+	if err := validateCurrency(currency); err != nil {
+		return Money{}, err
+	}
+	currency = strings.ToUpper(currency)
+	exp := Exponent(currency)
+
+	s := value
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+	whole, frac, hasDot := strings.Cut(s, ".")
+	if whole == "" || (hasDot && frac == "") || len(frac) > exp || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	frac += strings.Repeat("0", exp-len(frac))
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{amount: amount, currency: currency}, nil
+}
+
+// FromFloat 兼容旧的 float64 金额，按币种小数位数四舍五入
+// 仅用于迁移已有数据与接口，新代码应使用 FromMinorUnits 或 FromDecimalString
+func FromFloat(value float64, currency string) (Money, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Money{}, fmt.Errorf("%w: %v", ErrInvalidAmount, value)
+	}
+	return FromDecimalString(strconv.FormatFloat(value, 'f', Exponent(currency), 64), currency)
+}
+
+// MustParse 解析金额，失败时panic，用于常量与测试
+func MustParse(value, currency string) Money {
+	m, err := FromDecimalString(value, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// MinorUnits 返回最小货币单位的金额
+func (m Money) MinorUnits() int64 {
+	return m.amount
+}
+
+// Currency 返回币种代码
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// IsZero 判断金额是否为0
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsNegative 判断金额是否为负
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Decimal 按币种小数位数格式化金额，例如 "39.99"、"1000"（JPY）、"1.500"（BHD）
+func (m Money) Decimal() string {
+	exp := Exponent(m.currency)
+	amount := m.amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUint(amount), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String 返回带币种的金额，例如 "CNY 39.99"
+func (m Money) String() string {
+	return m.currency + " " + m.Decimal()
+}
+
+// Add 相加，币种不一致时返回 ErrCurrencyMismatch
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	sum := m.amount + other.amount
+	if (other.amount > 0 && sum < m.amount) || (other.amount < 0 && sum > m.amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: sum, currency: m.currency}, nil
+}
+
+// Sub 相减，币种不一致时返回 ErrCurrencyMismatch
+func (m Money) Sub(other Money) (Money, error) {
+	if other.amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(other.Negate())
+}
+
+// Multiply 乘以整数，例如单价乘以数量
+func (m Money) Multiply(n int64) (Money, error) {
+	if n != 0 && m.amount != 0 {
+		product := m.amount * n
+		if product/n != m.amount || (m.amount == -1 && n == math.MinInt64) {
+			return Money{}, ErrOverflow
+		}
+		return Money{amount: product, currency: m.currency}, nil
+	}
+	return Money{currency: m.currency}, nil
+}
+
+// Negate 返回相反数
+func (m Money) Negate() Money {
+	return Money{amount: -m.amount, currency: m.currency}
+}
+
+// Cmp 比较金额，返回 -1、0、1，币种不一致时返回 ErrCurrencyMismatch
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+	case m.amount > other.amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Equal 判断金额与币种是否都相同
+func (m Money) Equal(other Money) bool {
+	return m.amount == other.amount && m.currency == other.currency
+}
+
+// Allocate 按比例分配金额，各部分之和严格等于原金额
+// 无法整除的余数按顺序每份分配1个最小单位，例如 0.05 按 [1, 1] 分配为 0.03 与 0.02
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("money: 分配比例不能为空")
+	}
+	var total int64
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, errors.New("money: 分配比例不能为负")
+		}
+		total += int64(r)
+	}
+	if total == 0 {
+		return nil, errors.New("money: 分配比例之和不能为0")
+	}
+
+	parts := make([]Money, len(ratios))
+	remainder := m.amount
+	for i, r := range ratios {
+		share := m.amount / total * int64(r)
+		share += m.amount % total * int64(r) / total
+		parts[i] = Money{amount: share, currency: m.currency}
+		remainder -= share
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(parts) {
+		if ratios[i] == 0 {
+			continue
+		}
+		parts[i].amount += step
+		remainder -= step
+	}
+	return parts, nil
+}
+
+// Split 平均分成n份，余数分配给前面的部分
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, errors.New("money: 份数必须大于0")
+	}
+	ratios := make([]int, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// sameCurrency 检查币种是否一致
+func (m Money) sameCurrency(other Money) error {
+	if m.currency != other.currency {
+		return fmt.Errorf("%w: %s 与 %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return nil
+}
+
+// validateCurrency 币种代码必须为三个字母
+func validateCurrency(currency string) error {
+	if len(currency) != 3 {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, currency)
+	}
+	for _, c := range currency {
+		if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+			return fmt.Errorf("%w: %q", ErrInvalidCurrency, currency)
+		}
+	}
+	return nil
+}
+
+// isDigits 判断字符串是否只包含数字，空字符串返回true
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// absUint 返回绝对值，支持 math.MinInt64
+func absUint(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
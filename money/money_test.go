@@ -0,0 +1,147 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestFromDecimalString(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     int64
+		wantErr  error
+	}{
+		{"39.99", "CNY", 3999, nil},
+		{"0.1", "usd", 10, nil},
+		{"-5.05", "EUR", -505, nil},
+		{"1000", "JPY", 1000, nil},
+		{"1.5", "BHD", 1500, nil},
+		{"1.999", "CNY", 0, ErrInvalidAmount},
+		{"1.5", "JPY", 0, ErrInvalidAmount},
+		{".5", "CNY", 0, ErrInvalidAmount},
+		{"1.", "CNY", 0, ErrInvalidAmount},
+		{"+1", "CNY", 0, ErrInvalidAmount},
+		{"1e2", "CNY", 0, ErrInvalidAmount},
+		{" 1", "CNY", 0, ErrInvalidAmount},
+		{"1,000", "CNY", 0, ErrInvalidAmount},
+		{"99999999999999999999", "CNY", 0, ErrOverflow},
+		{"1", "YUAN", 0, ErrInvalidCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.currency, func(t *testing.T) {
+			m, err := FromDecimalString(tt.value, tt.currency)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.MinorUnits())
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	// 0.1 + 0.2 精确等于 0.3
+	sum, err := MustParse("0.1", "CNY").Add(MustParse("0.2", "CNY"))
+	require.NoError(t, err)
+	assert.True(t, sum.Equal(MustParse("0.3", "CNY")))
+	assert.Equal(t, "0.30", sum.Decimal())
+
+	diff, err := MustParse("1.00", "CNY").Sub(MustParse("1.01", "CNY"))
+	require.NoError(t, err)
+	assert.Equal(t, "-0.01", diff.Decimal())
+
+	total, err := MustParse("39.99", "CNY").Multiply(3)
+	require.NoError(t, err)
+	assert.Equal(t, "CNY 119.97", total.String())
+
+	_, err = MustParse("1", "CNY").Add(MustParse("1", "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = MustParse("1", "CNY").Cmp(MustParse("1", "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	assert.Equal(t, "1000", FromMinorUnits(1000, "JPY").Decimal())
+	assert.Equal(t, "0.005", FromMinorUnits(5, "KWD").Decimal())
+}
+
+func TestAllocate(t *testing.T) {
+	parts, err := MustParse("0.05", "CNY").Allocate(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 2}, minorUnits(parts))
+
+	// 100元按 1:1:1 分配，余数给第一份
+	parts, err = MustParse("100", "CNY").Split(3)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3334, 3333, 3333}, minorUnits(parts))
+
+	parts, err = FromMinorUnits(-100, "CNY").Allocate(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-34, -66}, minorUnits(parts))
+
+	// 比例为0的部分不分配余数
+	parts, err = FromMinorUnits(7, "JPY").Allocate(0, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 4, 3}, minorUnits(parts))
+
+	_, err = MustParse("1", "CNY").Allocate(0, 0)
+	assert.Error(t, err)
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(MustParse("39.99", "CNY"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"39.99","currency":"CNY"}`, string(data))
+
+	data, err = json.Marshal(Compact{MustParse("39.99", "CNY")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":3999,"currency":"CNY"}`, string(data))
+
+	var compact Compact
+	require.NoError(t, json.Unmarshal(data, &compact))
+	assert.Equal(t, int64(3999), compact.MinorUnits())
+
+	// 兼容旧的数字金额，按原始文本解析
+	var m Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":3999.00,"currency":"CNY"}`), &m))
+	assert.Equal(t, int64(399900), m.MinorUnits())
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":0.3,"currency":"USD"}`), &m))
+	assert.Equal(t, int64(30), m.MinorUnits())
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":0.333,"currency":"USD"}`), &m), ErrInvalidAmount)
+
+	legacy, err := FromFloat(0.1+0.2, "CNY")
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), legacy.MinorUnits())
+}
+
+func TestGormRoundTrip(t *testing.T) {
+	type order struct {
+		ID    uint
+		Total Money
+	}
+
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&order{}))
+
+	require.NoError(t, conn.Create(&order{Total: MustParse("1.500", "BHD")}).Error)
+
+	var loaded order
+	require.NoError(t, conn.First(&loaded).Error)
+	assert.True(t, loaded.Total.Equal(MustParse("1.5", "BHD")))
+}
+
+func minorUnits(parts []Money) []int64 {
+	out := make([]int64, len(parts))
+	for i, p := range parts {
+		out[i] = p.MinorUnits()
+	}
+	return out
+}
@@ -0,0 +1,202 @@
+package flow
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zzliekkas/flow/v2/db"
+)
+
+// PageKeys 分页响应信封的键名，为空的键使用默认名称
+type PageKeys struct {
+	Data       string // 默认 "data"
+	Meta       string // 默认 "meta"
+	Links      string // 默认 "links"
+	Page       string // 默认 "page"
+	PerPage    string // 默认 "per_page"
+	Total      string // 默认 "total"
+	TotalPages string // 默认 "total_pages"
+	NextCursor string // 默认 "next_cursor"
+	PrevCursor string // 默认 "prev_cursor"
+}
+
+// DefaultPageKeys 返回默认的信封键名
+func DefaultPageKeys() PageKeys {
+	return PageKeys{
+		Data:       "data",
+		Meta:       "meta",
+		Links:      "links",
+		Page:       "page",
+		PerPage:    "per_page",
+		Total:      "total",
+		TotalPages: "total_pages",
+		NextCursor: "next_cursor",
+		PrevCursor: "prev_cursor",
+	}
+}
+
+// PageOption 分页响应选项
+type PageOption func(*pageOptions)
+
+// pageOptions 分页响应配置
+type pageOptions struct {
+	keys         PageKeys
+	pageParam    string
+	perPageParam string
+	cursorParam  string
+}
+
+// WithPageKeys 重命名信封的键，用于兼容旧客户端，未设置的键保持默认
+func WithPageKeys(keys PageKeys) PageOption {
+	return func(o *pageOptions) {
+		defaults := DefaultPageKeys()
+		for _, pair := range []struct{ target, value *string }{
+			{&defaults.Data, &keys.Data},
+			{&defaults.Meta, &keys.Meta},
+			{&defaults.Links, &keys.Links},
+			{&defaults.Page, &keys.Page},
+			{&defaults.PerPage, &keys.PerPage},
+			{&defaults.Total, &keys.Total},
+			{&defaults.TotalPages, &keys.TotalPages},
+			{&defaults.NextCursor, &keys.NextCursor},
+			{&defaults.PrevCursor, &keys.PrevCursor},
+		} {
+			if *pair.value != "" {
+				*pair.target = *pair.value
+			}
+		}
+		o.keys = defaults
+	}
+}
+
+// WithPageParams 设置链接中页码、每页数量与游标的查询参数名，默认为 page、per_page 与 cursor，为空的保持默认
+func WithPageParams(page, perPage, cursor string) PageOption {
+	return func(o *pageOptions) {
+		if page != "" {
+			o.pageParam = page
+		}
+		if perPage != "" {
+			o.perPageParam = perPage
+		}
+		if cursor != "" {
+			o.cursorParam = cursor
+		}
+	}
+}
+
+// Paginated 以标准信封响应一页数据，并设置 RFC 5988 Link 与 X-Total-Count 响应头
+//
+//	{
+//	  "data": [...],
+//	  "meta": {"page": 2, "per_page": 20, "total": 95, "total_pages": 5},
+//	  "links": {"self": "...", "first": "...", "prev": "...", "next": "...", "last": "..."}
+//	}
+//
+// 游标分页的 meta 为 per_page、next_cursor 与 prev_cursor，没有 last 链接；跳过总数统计时省略 total、
+// total_pages 与 last。链接是保留请求中其他查询参数（过滤、排序等）的绝对地址，见 Engine.ExternalURL；
+// 没有上一页或下一页时对应的链接为 null
+func (c *Context) Paginated(page db.Paged, opts ...PageOption) {
+	o := &pageOptions{
+		keys:         DefaultPageKeys(),
+		pageParam:    "page",
+		perPageParam: "per_page",
+		cursorParam:  "cursor",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	info := page.Info()
+	self := c.engine.ExternalURL(c.Request)
+	query := self.Query()
+	link := func(set func(url.Values)) string {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set(o.perPageParam, strconv.Itoa(info.PerPage))
+		set(q)
+		u := *self
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	meta := map[string]interface{}{o.keys.PerPage: info.PerPage}
+	if info.HasTotal() {
+		meta[o.keys.Total] = info.Total
+		c.Header("X-Total-Count", strconv.FormatInt(info.Total, 10))
+	}
+	links := map[string]interface{}{"self": self.String(), "prev": nil, "next": nil}
+
+	if info.Cursor {
+		meta[o.keys.NextCursor] = nullable(info.NextCursor)
+		meta[o.keys.PrevCursor] = nullable(info.PrevCursor)
+		cursor := func(value string) string {
+			return link(func(q url.Values) {
+				q.Del(o.pageParam)
+				if value == "" {
+					q.Del(o.cursorParam)
+				} else {
+					q.Set(o.cursorParam, value)
+				}
+			})
+		}
+		links["first"] = cursor("")
+		if info.HasPrev() {
+			links["prev"] = cursor(info.PrevCursor)
+		}
+		if info.HasNext() {
+			links["next"] = cursor(info.NextCursor)
+		}
+	} else {
+		meta[o.keys.Page] = info.Page
+		numbered := func(n int) string {
+			return link(func(q url.Values) {
+				q.Del(o.cursorParam)
+				q.Set(o.pageParam, strconv.Itoa(n))
+			})
+		}
+		links["first"] = numbered(1)
+		if info.HasPrev() {
+			links["prev"] = numbered(info.Page - 1)
+		}
+		if info.HasNext() {
+			links["next"] = numbered(info.Page + 1)
+		}
+		if info.HasTotal() {
+			last := info.TotalPages()
+			if last < 1 {
+				last = 1
+			}
+			meta[o.keys.TotalPages] = info.TotalPages()
+			links["last"] = numbered(last)
+		}
+	}
+
+	// RFC 5988 Link 响应头，不包含 self
+	var header []string
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		if href, ok := links[rel].(string); ok {
+			header = append(header, "<"+href+`>; rel="`+rel+`"`)
+		}
+	}
+	if len(header) > 0 {
+		c.Writer.Header().Add("Link", strings.Join(header, ", "))
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		o.keys.Data:  page.Data(),
+		o.keys.Meta:  meta,
+		o.keys.Links: links,
+	})
+}
+
+// nullable 空字符串返回 nil，在 JSON 中为 null
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,142 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/db"
+)
+
+type pageItem struct {
+	ID int `json:"id"`
+}
+
+// servePage 以指定请求调用返回分页结果的路由
+func servePage(e *Engine, page db.Paged, target string, configure func(*http.Request), opts ...PageOption) (*httptest.ResponseRecorder, map[string]interface{}) {
+	e.GET("/items", func(c *Context) {
+		c.Paginated(page, opts...)
+	})
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if configure != nil {
+		configure(req)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	var body map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func TestPaginated_Offset(t *testing.T) {
+	page := db.Page[pageItem]{
+		Items:    []pageItem{{ID: 21}, {ID: 22}},
+		PageInfo: db.PageInfo{Page: 2, PerPage: 20, Total: 95},
+	}
+	w, body := servePage(newRouteTestEngine(), page, "/items?page=2&status=%E5%B7%B2%E6%94%AF%E4%BB%98&q=a%2Bb&tag=x+y&sort=-created_at", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, "95", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, map[string]interface{}{"page": 2.0, "per_page": 20.0, "total": 95.0, "total_pages": 5.0}, body["meta"])
+	assert.Len(t, body["data"], 2)
+
+	// 保留过滤与排序参数，中文与加号正确编码
+	links := body["links"].(map[string]interface{})
+	assert.Equal(t, "http://example.com/items?page=3&per_page=20&q=a%2Bb&sort=-created_at&status=%E5%B7%B2%E6%94%AF%E4%BB%98&tag=x+y", links["next"])
+	assert.Equal(t, "http://example.com/items?page=1&per_page=20&q=a%2Bb&sort=-created_at&status=%E5%B7%B2%E6%94%AF%E4%BB%98&tag=x+y", links["prev"])
+	assert.Equal(t, links["prev"], links["first"])
+	assert.Equal(t, "http://example.com/items?page=5&per_page=20&q=a%2Bb&sort=-created_at&status=%E5%B7%B2%E6%94%AF%E4%BB%98&tag=x+y", links["last"])
+
+	next, err := url.Parse(links["next"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "已支付", next.Query().Get("status"))
+	assert.Equal(t, "a+b", next.Query().Get("q"))
+	assert.Equal(t, "x y", next.Query().Get("tag"))
+
+	assert.Equal(t, `<`+links["first"].(string)+`>; rel="first", `+
+		`<`+links["prev"].(string)+`>; rel="prev", `+
+		`<`+links["next"].(string)+`>; rel="next", `+
+		`<`+links["last"].(string)+`>; rel="last"`, w.Header().Get("Link"))
+}
+
+func TestPaginated_FirstAndLastPage(t *testing.T) {
+	page := db.Page[pageItem]{PageInfo: db.PageInfo{Page: 1, PerPage: 10, Total: 0}}
+	w, body := servePage(newRouteTestEngine(), page, "/items", nil)
+	links := body["links"].(map[string]interface{})
+	assert.Nil(t, links["prev"])
+	assert.Nil(t, links["next"])
+	assert.Equal(t, "http://example.com/items?page=1&per_page=10", links["last"])
+	assert.Equal(t, []interface{}{}, body["data"], "没有数据时为空数组")
+	assert.Equal(t, "0", w.Header().Get("X-Total-Count"))
+	assert.NotContains(t, w.Header().Get("Link"), `rel="next"`)
+}
+
+func TestPaginated_SkippedTotal(t *testing.T) {
+	page := db.Page[pageItem]{PageInfo: db.PageInfo{Page: 3, PerPage: 10, Total: db.TotalUnknown, HasMore: true}}
+	w, body := servePage(newRouteTestEngine(), page, "/items?page=3", nil)
+	assert.Equal(t, map[string]interface{}{"page": 3.0, "per_page": 10.0}, body["meta"])
+	links := body["links"].(map[string]interface{})
+	assert.Equal(t, "http://example.com/items?page=4&per_page=10", links["next"])
+	assert.NotContains(t, links, "last")
+	assert.Empty(t, w.Header().Get("X-Total-Count"))
+}
+
+func TestPaginated_Cursor(t *testing.T) {
+	page := db.NewCursorPage([]pageItem{{ID: 1}}, 50, "eyJpZCI6MTB9", "", db.TotalUnknown)
+	w, body := servePage(newRouteTestEngine(), page, "/items?cursor=abc&page=9&filter=%E4%B8%AD", nil)
+
+	assert.Equal(t, map[string]interface{}{"per_page": 50.0, "next_cursor": "eyJpZCI6MTB9", "prev_cursor": nil}, body["meta"])
+	links := body["links"].(map[string]interface{})
+	assert.Equal(t, "http://example.com/items?cursor=eyJpZCI6MTB9&filter=%E4%B8%AD&per_page=50", links["next"])
+	assert.Equal(t, "http://example.com/items?filter=%E4%B8%AD&per_page=50", links["first"])
+	assert.Nil(t, links["prev"])
+	assert.NotContains(t, links, "last")
+	assert.Equal(t, "http://example.com/items?cursor=abc&page=9&filter=%E4%B8%AD", links["self"])
+	assert.Empty(t, w.Header().Get("X-Total-Count"))
+
+	// 统计了总数的游标分页也返回总数
+	page = db.NewCursorPage([]pageItem{{ID: 1}}, 50, "", "prev", 120)
+	w, body = servePage(newRouteTestEngine(), page, "/items", nil)
+	assert.Equal(t, 120.0, body["meta"].(map[string]interface{})["total"])
+	assert.Equal(t, "120", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, `<http://example.com/items?per_page=50>; rel="first", <http://example.com/items?cursor=prev&per_page=50>; rel="prev"`, w.Header().Get("Link"))
+}
+
+func TestPaginated_ExternalURL(t *testing.T) {
+	page := db.Page[pageItem]{PageInfo: db.PageInfo{Page: 1, PerPage: 10, Total: 30}}
+	forwarded := func(r *http.Request) {
+		r.RemoteAddr = "10.0.0.5:1234"
+		r.Header.Set("X-Forwarded-Host", "api.example.com")
+		r.Header.Set("X-Forwarded-Proto", "https")
+	}
+
+	// 未信任代理时忽略转发请求头
+	_, body := servePage(newRouteTestEngine(), page, "/items", forwarded)
+	assert.Equal(t, "http://example.com/items?page=2&per_page=10", body["links"].(map[string]interface{})["next"])
+
+	e := newRouteTestEngine()
+	require.NoError(t, e.TrustForwardedHost("10.0.0.0/8"))
+	_, body = servePage(e, page, "/items", forwarded)
+	assert.Equal(t, "https://api.example.com/items?page=2&per_page=10", body["links"].(map[string]interface{})["next"])
+
+	// 配置的外部地址优先
+	e = New(WithBaseURL("https://gateway.example.com/shop/"))
+	_, body = servePage(e, page, "/items", forwarded)
+	assert.Equal(t, "https://gateway.example.com/shop/items?page=2&per_page=10", body["links"].(map[string]interface{})["next"])
+}
+
+func TestPaginated_LegacyKeys(t *testing.T) {
+	page := db.Page[pageItem]{Items: []pageItem{{ID: 1}}, PageInfo: db.PageInfo{Page: 1, PerPage: 10, Total: 1}}
+	_, body := servePage(newRouteTestEngine(), page, "/items?p=1", nil,
+		WithPageKeys(PageKeys{Data: "list", Total: "count", PerPage: "page_size"}),
+		WithPageParams("p", "size", ""))
+
+	assert.Contains(t, body, "list")
+	assert.Contains(t, body, "links")
+	assert.Equal(t, map[string]interface{}{"page": 1.0, "page_size": 10.0, "count": 1.0, "total_pages": 1.0}, body["meta"])
+	assert.Equal(t, "http://example.com/items?p=1&size=10", body["links"].(map[string]interface{})["first"])
+}
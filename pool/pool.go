@@ -0,0 +1,256 @@
+// Package pool 提供固定大小的工作池，用于框架内部的并发任务
+//
+// 任务在有界队列中排队，panic 被转换为错误而不会终止工作协程，Drain 停止接收新任务并在截止时间内等待已提交的任务完成
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 工作池错误
+var (
+	ErrQueueFull  = errors.New("pool: 任务队列已满")
+	ErrPoolClosed = errors.New("pool: 工作池已关闭")
+)
+
+// Task 任务函数
+type Task func(ctx context.Context) error
+
+// PanicError 任务panic时转换得到的错误
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error 实现error接口
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("pool: 任务panic: %v", e.Value)
+}
+
+// Stats 工作池统计
+type Stats struct {
+	Submitted  int64 // 已提交的任务数
+	Succeeded  int64 // 成功完成的任务数
+	Failed     int64 // 返回错误的任务数，不含panic
+	Panicked   int64 // panic的任务数
+	Running    int64 // 正在执行的任务数
+	QueueDepth int   // 排队中的任务数
+}
+
+// options 工作池配置
+type options struct {
+	queueSize   int
+	nonBlocking bool
+	taskTimeout time.Duration
+	onError     func(error)
+}
+
+// Option 工作池选项
+type Option func(*options)
+
+// WithQueueSize 设置队列容量，默认与工作协程数相同
+func WithQueueSize(n int) Option {
+	return func(o *options) {
+		o.queueSize = n
+	}
+}
+
+// WithNonBlocking 队列满时 Submit 立即返回 ErrQueueFull，默认阻塞等待
+func WithNonBlocking() Option {
+	return func(o *options) {
+		o.nonBlocking = true
+	}
+}
+
+// WithTaskTimeout 设置单个任务的超时时间，超时后任务的 ctx 被取消
+func WithTaskTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.taskTimeout = timeout
+	}
+}
+
+// WithErrorHook 设置任务失败时的回调，panic 以 *PanicError 传入
+// 回调在工作协程中同步执行，需自行保证并发安全
+func WithErrorHook(hook func(error)) Option {
+	return func(o *options) {
+		o.onError = hook
+	}
+}
+
+// Pool 固定大小的工作池
+type Pool struct {
+	opts  options
+	queue chan Task
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	closed    bool
+	closing   chan struct{}
+	stopped   chan struct{}
+	pending   sync.WaitGroup
+	drainOnce sync.Once
+
+	submitted atomic.Int64
+	succeeded atomic.Int64
+	failed    atomic.Int64
+	panicked  atomic.Int64
+	running   atomic.Int64
+}
+
+// New 创建工作池并启动 size 个工作协程
+func New(size int, opts ...Option) *Pool {
+	if size <= 0 {
+		size = 1
+	}
+	o := options{queueSize: size}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.queueSize < 0 {
+		o.queueSize = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		opts:    o,
+		queue:   make(chan Task, o.queueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		closing: make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for i := 0; i < size; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit 提交任务，队列满时阻塞直到有空位或 ctx 结束；非阻塞模式下返回 ErrQueueFull
+// ctx 只用于等待入队，任务执行时使用工作池自己的 context，在 Drain 超时后被取消
+func (p *Pool) Submit(ctx context.Context, task Task) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	p.pending.Add(1)
+	p.mu.Unlock()
+
+	if p.opts.nonBlocking {
+		select {
+		case p.queue <- task:
+			p.submitted.Add(1)
+			return nil
+		default:
+			p.pending.Done()
+			return ErrQueueFull
+		}
+	}
+
+	select {
+	case p.queue <- task:
+		p.submitted.Add(1)
+		return nil
+	case <-ctx.Done():
+		p.pending.Done()
+		return ctx.Err()
+	case <-p.closing:
+		p.pending.Done()
+		return ErrPoolClosed
+	}
+}
+
+// Drain 停止接收新任务，等待排队与执行中的任务完成
+// ctx 结束时取消正在执行的任务的 context 并返回 ctx.Err()，工作协程在任务返回后退出
+func (p *Pool) Drain(ctx context.Context) error {
+	p.drainOnce.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		close(p.closing)
+		p.mu.Unlock()
+
+		go func() {
+			p.pending.Wait()
+			close(p.stopped)
+			p.cancel()
+		}()
+	})
+
+	select {
+	case <-p.stopped:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// Stats 返回统计信息
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Submitted:  p.submitted.Load(),
+		Succeeded:  p.succeeded.Load(),
+		Failed:     p.failed.Load(),
+		Panicked:   p.panicked.Load(),
+		Running:    p.running.Load(),
+		QueueDepth: len(p.queue),
+	}
+}
+
+// work 工作协程
+func (p *Pool) work() {
+	for {
+		select {
+		case task := <-p.queue:
+			p.run(task)
+			p.pending.Done()
+		case <-p.stopped:
+			return
+		}
+	}
+}
+
+// run 执行单个任务并记录结果
+func (p *Pool) run(task Task) {
+	p.running.Add(1)
+	defer p.running.Add(-1)
+
+	err := p.call(task)
+	var panicErr *PanicError
+	switch {
+	case err == nil:
+		p.succeeded.Add(1)
+		return
+	case errors.As(err, &panicErr):
+		p.panicked.Add(1)
+	default:
+		p.failed.Add(1)
+	}
+	if p.opts.onError != nil {
+		p.opts.onError(err)
+	}
+}
+
+// call 调用任务，将panic转换为错误
+func (p *Pool) call(task Task) (err error) {
+	ctx := p.ctx
+	if p.opts.taskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.taskTimeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return task(ctx)
+}
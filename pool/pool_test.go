@@ -0,0 +1,130 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_Backpressure(t *testing.T) {
+	release := make(chan struct{})
+	block := func(ctx context.Context) error {
+		<-release
+		return nil
+	}
+
+	p := New(1, WithQueueSize(1), WithNonBlocking())
+	require.NoError(t, p.Submit(context.Background(), block))
+	// 等待第一个任务被工作协程取走
+	require.Eventually(t, func() bool { return p.Stats().Running == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, p.Submit(context.Background(), block))
+	assert.ErrorIs(t, p.Submit(context.Background(), block), ErrQueueFull)
+	assert.Equal(t, 1, p.Stats().QueueDepth)
+
+	// 阻塞模式下等待空位，ctx 结束时返回
+	blocking := New(1, WithQueueSize(1))
+	require.NoError(t, blocking.Submit(context.Background(), block))
+	require.Eventually(t, func() bool { return blocking.Stats().Running == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, blocking.Submit(context.Background(), block))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, blocking.Submit(ctx, block), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, p.Drain(context.Background()))
+	require.NoError(t, blocking.Drain(context.Background()))
+	assert.Equal(t, int64(2), p.Stats().Succeeded)
+	assert.ErrorIs(t, p.Submit(context.Background(), block), ErrPoolClosed)
+}
+
+func TestPool_PanicIsolation(t *testing.T) {
+	var mu sync.Mutex
+	var reported []error
+	p := New(2, WithErrorHook(func(err error) {
+		mu.Lock()
+		reported = append(reported, err)
+		mu.Unlock()
+	}))
+
+	require.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error { panic("boom") }))
+	require.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error { return errors.New("failed") }))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error { return nil }))
+	}
+	require.NoError(t, p.Drain(context.Background()))
+
+	stats := p.Stats()
+	assert.Equal(t, Stats{Submitted: 12, Succeeded: 10, Failed: 1, Panicked: 1}, stats)
+	require.Len(t, reported, 2)
+	var panicErr *PanicError
+	for _, err := range reported {
+		if errors.As(err, &panicErr) {
+			assert.Equal(t, "boom", panicErr.Value)
+			assert.NotEmpty(t, panicErr.Stack)
+		}
+	}
+	require.NotNil(t, panicErr)
+}
+
+func TestPool_DrainDeadline(t *testing.T) {
+	p := New(1)
+	cancelled := make(chan struct{})
+	require.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Drain(ctx), context.DeadlineExceeded)
+
+	// 超时后任务的 context 被取消，再次 Drain 可等待其返回
+	<-cancelled
+	require.NoError(t, p.Drain(context.Background()))
+	assert.Equal(t, int64(1), p.Stats().Failed)
+}
+
+func TestPool_TaskTimeout(t *testing.T) {
+	p := New(1, WithTaskTimeout(10*time.Millisecond))
+	var got error
+	require.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		got = ctx.Err()
+		return got
+	}))
+	require.NoError(t, p.Drain(context.Background()))
+	assert.ErrorIs(t, got, context.DeadlineExceeded)
+}
+
+func TestPool_StatsUnderConcurrency(t *testing.T) {
+	p := New(8, WithQueueSize(4))
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				n := i*20 + j
+				_ = p.Submit(context.Background(), func(ctx context.Context) error {
+					switch n % 10 {
+					case 0:
+						panic(n)
+					case 1, 2:
+						return errors.New("failed")
+					}
+					return nil
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, p.Drain(context.Background()))
+
+	assert.Equal(t, Stats{Submitted: 1000, Succeeded: 700, Failed: 200, Panicked: 100}, p.Stats())
+}
@@ -0,0 +1,94 @@
+package flow
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 条件请求错误
+var (
+	// ErrPreconditionRequired 修改资源的请求缺少 If-Match 或 If-Unmodified-Since，应响应428
+	ErrPreconditionRequired = errors.New("请求缺少前置条件: 请携带 If-Match 请求头")
+
+	// ErrPreconditionFailed 资源已被其他请求修改，应响应412
+	ErrPreconditionFailed = errors.New("资源已被修改: 请重新获取后再提交")
+)
+
+// RequireIfMatch 检查 If-Match 请求头与资源当前的 ETag 是否一致，用于乐观并发控制
+//
+// 缺少请求头时响应428，不一致时响应412并中止请求。比较使用强比较，弱 ETag（W/"..."）不会匹配；
+// If-Match: * 匹配任何已存在的资源，currentETag 为空表示资源不存在。
+// 写入成功后应通过 SetETag 返回新的 ETag，客户端以此发起下一次修改：
+//
+//	if err := c.RequireIfMatch(file.Checksum); err != nil {
+//		return
+//	}
+func (c *Context) RequireIfMatch(currentETag string) error {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.AbortWithStatusJSON(http.StatusPreconditionRequired, H{"error": ErrPreconditionRequired.Error()})
+		return ErrPreconditionRequired
+	}
+	if !etagMatches(header, currentETag) {
+		c.Header("ETag", quoteETag(currentETag))
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, H{"error": ErrPreconditionFailed.Error()})
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// RequireUnmodifiedSince 检查 If-Unmodified-Since 请求头，资源在该时间之后被修改时响应412
+//
+// 适用于只能提供修改时间的资源；同时携带 If-Match 时以 RequireIfMatch 为准。HTTP 日期只精确到秒
+func (c *Context) RequireUnmodifiedSince(lastModified time.Time) error {
+	header := c.GetHeader("If-Unmodified-Since")
+	if header == "" {
+		c.AbortWithStatusJSON(http.StatusPreconditionRequired, H{"error": ErrPreconditionRequired.Error()})
+		return ErrPreconditionRequired
+	}
+	since, err := http.ParseTime(header)
+	if err != nil || lastModified.Truncate(time.Second).After(since) {
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, H{"error": ErrPreconditionFailed.Error()})
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// SetETag 设置响应的 ETag，未加引号的值会自动加上引号
+func (c *Context) SetETag(etag string) {
+	if etag != "" {
+		c.Header("ETag", quoteETag(etag))
+	}
+}
+
+// SetLastModified 设置响应的 Last-Modified
+func (c *Context) SetLastModified(t time.Time) {
+	if !t.IsZero() {
+		c.Header("Last-Modified", t.UTC().Format(http.TimeFormat))
+	}
+}
+
+// etagMatches 按强比较判断 If-Match 列表中是否有与当前 ETag 相同的值
+func etagMatches(header, current string) bool {
+	if current == "" {
+		return false
+	}
+	current = quoteETag(current)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == current && !strings.HasPrefix(candidate, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteETag 为 ETag 加上引号，已有引号或弱标记的值原样返回
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return `"` + etag + `"`
+}
@@ -0,0 +1,77 @@
+package flow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	etag := "v1"
+	e := New()
+	e.PUT("/files/report", func(c *Context) {
+		if err := c.RequireIfMatch(etag); err != nil {
+			return
+		}
+		etag = "v2"
+		c.SetETag(etag)
+		c.Status(http.StatusNoContent)
+	})
+
+	put := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/files/report", nil)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusPreconditionRequired, put("").Code)
+	assert.Equal(t, http.StatusPreconditionFailed, put(`W/"v1"`).Code)
+
+	w := put(`"v0", "v1"`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, `"v2"`, w.Header().Get("ETag"))
+
+	// 第二个使用旧 ETag 的请求被拒绝，并得到当前 ETag
+	w = put(`"v1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, `"v2"`, w.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusNoContent, put("*").Code)
+}
+
+func TestRequireUnmodifiedSince(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modified := time.Date(2026, 5, 1, 12, 0, 0, 500, time.UTC)
+	e := New()
+	e.PUT("/files/report", func(c *Context) {
+		if err := c.RequireUnmodifiedSince(modified); err != nil {
+			return
+		}
+		c.SetLastModified(modified)
+		c.Status(http.StatusNoContent)
+	})
+
+	put := func(since string) int {
+		req := httptest.NewRequest(http.MethodPut, "/files/report", nil)
+		if since != "" {
+			req.Header.Set("If-Unmodified-Since", since)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusPreconditionRequired, put(""))
+	assert.Equal(t, http.StatusNoContent, put(modified.Format(http.TimeFormat)))
+	assert.Equal(t, http.StatusPreconditionFailed, put(modified.Add(-time.Second).Format(http.TimeFormat)))
+	assert.Equal(t, http.StatusPreconditionFailed, put("yesterday"))
+}
@@ -0,0 +1,68 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// subjectPlaceholder 前缀模板中主体ID的占位符
+const subjectPlaceholder = "{id}"
+
+// PrefixStore 支持按前缀查找与删除键的缓存，*cache.Manager 与 cache.PrefixStore 的实现都满足该接口
+type PrefixStore interface {
+	KeysByPrefix(ctx context.Context, prefix string) ([]string, error)
+	DeleteByPrefix(ctx context.Context, prefix string) (int64, error)
+}
+
+// CacheHandler 删除主体命名空间下的缓存，默认名称为 cache
+type CacheHandler struct {
+	store    PrefixStore
+	prefixes []string
+	opts     handlerOptions
+}
+
+// NewCacheHandler 创建缓存处理器，prefixes 为包含 {id} 的键前缀模板，如 "user:{id}:"
+//
+// 模板末尾应带分隔符，否则 "user:{id}" 会同时删除 user:42 与 user:420 的缓存
+func NewCacheHandler(store PrefixStore, prefixes []string, opts ...HandlerOption) *CacheHandler {
+	return &CacheHandler{store: store, prefixes: prefixes, opts: applyHandlerOptions("cache", opts)}
+}
+
+// Name 实现 ErasureHandler
+func (h *CacheHandler) Name() string {
+	return h.opts.name
+}
+
+// Erase 实现 ErasureHandler，报告按前缀模板统计删除的键数
+func (h *CacheHandler) Erase(ctx context.Context, subjectID string) (ErasureReport, error) {
+	var report ErasureReport
+	for _, template := range h.prefixes {
+		prefix, err := expandPrefix(template, subjectID)
+		if err != nil {
+			return report, err
+		}
+		if IsDryRun(ctx) {
+			keys, err := h.store.KeysByPrefix(ctx, prefix)
+			if err != nil {
+				return report, err
+			}
+			report.Add(template, int64(len(keys)))
+			continue
+		}
+		n, err := h.store.DeleteByPrefix(ctx, prefix)
+		if err != nil {
+			return report, err
+		}
+		report.Add(template, n)
+	}
+	return report, nil
+}
+
+// expandPrefix 将模板中的 {id} 替换为主体ID，模板不含占位符时返回错误，避免删除所有用户的数据
+func expandPrefix(template, subjectID string) (string, error) {
+	if !strings.Contains(template, subjectPlaceholder) {
+		return "", errors.New("privacy: 前缀模板 " + template + " 中没有 {id}")
+	}
+	return strings.ReplaceAll(template, subjectPlaceholder, subjectID), nil
+}
@@ -0,0 +1,281 @@
+package privacy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zzliekkas/flow/v2/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Strategy 数据库中主体数据的处理方式
+type Strategy string
+
+const (
+	// StrategyDelete 删除主体的行
+	StrategyDelete Strategy = "delete"
+	// StrategyNull 将指定的列置为 NULL，保留行（如订单金额等统计需要的数据）
+	StrategyNull Strategy = "null"
+	// StrategyPseudonymize 将指定的列替换为确定性的假名，相同的原值得到相同的假名，关联统计仍然可用
+	StrategyPseudonymize Strategy = "pseudonymize"
+)
+
+// TableRule 一张表的处理规则
+type TableRule struct {
+	// Table 表名
+	Table string `mapstructure:"table"`
+	// Column 保存主体ID的列
+	Column string `mapstructure:"column"`
+	// Strategy 处理方式
+	Strategy Strategy `mapstructure:"strategy"`
+	// Columns StrategyNull 与 StrategyPseudonymize 处理的列；假名化可以包含字符串类型的 Column 本身
+	Columns []string `mapstructure:"columns"`
+}
+
+// LoadTableRules 从配置加载数据库处理规则
+//
+//	privacy:
+//	  tables:
+//	    - table: orders
+//	      column: user_id
+//	      strategy: pseudonymize
+//	      columns: [email, phone]
+//	    - table: users
+//	      column: id
+//	      strategy: delete
+func LoadTableRules(configManager *config.ConfigManager) ([]TableRule, error) {
+	var rules []TableRule
+	if err := configManager.Unmarshal("privacy.tables", &rules); err != nil {
+		return nil, fmt.Errorf("privacy: 加载数据库处理规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// Pseudonymizer 使用 HMAC-SHA256 生成确定性的假名，没有密钥无法由假名反推原值
+type Pseudonymizer struct {
+	key []byte
+}
+
+// NewPseudonymizer 创建假名生成器，密钥通常为 app.key；假名密钥由它派生，与加密、签名使用的密钥互不相同
+func NewPseudonymizer(key []byte) (*Pseudonymizer, error) {
+	if len(key) == 0 {
+		return nil, errors.New("privacy: 假名密钥不能为空")
+	}
+	return &Pseudonymizer{key: hmacSHA256(key, []byte("flow.privacy.pseudonym"))}, nil
+}
+
+// Pseudonymize 返回值的假名，形如 anon_ 加32位十六进制
+func (p *Pseudonymizer) Pseudonymize(value string) string {
+	return pseudonymPrefix + hex.EncodeToString(hmacSHA256(p.key, []byte(value))[:16])
+}
+
+// pseudonymPrefix 假名的前缀
+const pseudonymPrefix = "anon_"
+
+// isPseudonym 判断值是否已经是假名
+func isPseudonym(value string) bool {
+	if len(value) != len(pseudonymPrefix)+32 || !strings.HasPrefix(value, pseudonymPrefix) {
+		return false
+	}
+	_, err := hex.DecodeString(value[len(pseudonymPrefix):])
+	return err == nil
+}
+
+// hmacSHA256 计算 HMAC-SHA256
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// HandlerOption 内置处理器的选项
+type HandlerOption func(*handlerOptions)
+
+// handlerOptions 内置处理器的选项
+type handlerOptions struct {
+	name          string
+	pseudonymizer *Pseudonymizer
+	shredder      KeyShredder
+}
+
+// WithName 设置处理器名称，同一类处理器注册多个时（如多个数据库连接）需要不同的名称
+func WithName(name string) HandlerOption {
+	return func(o *handlerOptions) {
+		o.name = name
+	}
+}
+
+// WithPseudonymizer 设置数据库处理器的假名生成器，使用 StrategyPseudonymize 时必需
+func WithPseudonymizer(p *Pseudonymizer) HandlerOption {
+	return func(o *handlerOptions) {
+		o.pseudonymizer = p
+	}
+}
+
+// applyHandlerOptions 应用选项
+func applyHandlerOptions(name string, opts []HandlerOption) handlerOptions {
+	options := handlerOptions{name: name}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// DBHandler 按表规则清除数据库中主体的数据，默认名称为 db
+//
+// 所有规则在同一个事务中按顺序执行，有外键约束时应先列出子表
+type DBHandler struct {
+	db    *gorm.DB
+	rules []TableRule
+	opts  handlerOptions
+}
+
+// NewDBHandler 创建数据库处理器，规则无效时返回错误
+func NewDBHandler(db *gorm.DB, rules []TableRule, opts ...HandlerOption) (*DBHandler, error) {
+	options := applyHandlerOptions("db", opts)
+	for i, rule := range rules {
+		if rule.Table == "" || rule.Column == "" {
+			return nil, fmt.Errorf("privacy: 第 %d 条规则缺少表名或主体列", i+1)
+		}
+		switch rule.Strategy {
+		case StrategyDelete:
+		case StrategyNull, StrategyPseudonymize:
+			if len(rule.Columns) == 0 {
+				return nil, fmt.Errorf("privacy: 表 %s 的 %s 规则需要指定列", rule.Table, rule.Strategy)
+			}
+			if rule.Strategy == StrategyPseudonymize && options.pseudonymizer == nil {
+				return nil, fmt.Errorf("privacy: 表 %s 使用假名化，需要通过 WithPseudonymizer 设置假名生成器", rule.Table)
+			}
+		default:
+			return nil, fmt.Errorf("privacy: 表 %s 的处理方式 %q 无效", rule.Table, rule.Strategy)
+		}
+	}
+	return &DBHandler{db: db, rules: rules, opts: options}, nil
+}
+
+// MustDBHandler 创建数据库处理器，规则无效时 panic
+func MustDBHandler(db *gorm.DB, rules []TableRule, opts ...HandlerOption) *DBHandler {
+	h, err := NewDBHandler(db, rules, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// Name 实现 ErasureHandler
+func (h *DBHandler) Name() string {
+	return h.opts.name
+}
+
+// Erase 实现 ErasureHandler，报告按表统计处理的行数
+func (h *DBHandler) Erase(ctx context.Context, subjectID string) (ErasureReport, error) {
+	var report ErasureReport
+	if IsDryRun(ctx) {
+		for _, rule := range h.rules {
+			var n int64
+			if err := h.db.WithContext(ctx).Table(rule.Table).Where(subjectClause(rule, subjectID)).Count(&n).Error; err != nil {
+				return report, fmt.Errorf("统计表 %s 失败: %w", rule.Table, err)
+			}
+			report.Add(rule.Table, n)
+		}
+		return report, nil
+	}
+
+	// 事务回滚时报告不应包含未生效的数量
+	var counts ErasureReport
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rule := range h.rules {
+			n, err := h.apply(tx, rule, subjectID)
+			if err != nil {
+				return fmt.Errorf("处理表 %s 失败: %w", rule.Table, err)
+			}
+			counts.Add(rule.Table, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	return counts, nil
+}
+
+// subjectClause 返回匹配主体的条件
+func subjectClause(rule TableRule, subjectID string) clause.Expression {
+	return clause.Eq{Column: clause.Column{Name: rule.Column}, Value: subjectID}
+}
+
+// apply 在事务中处理一张表，返回处理的行数
+func (h *DBHandler) apply(tx *gorm.DB, rule TableRule, subjectID string) (int64, error) {
+	table := tx.Table(rule.Table).Where(subjectClause(rule, subjectID))
+	switch rule.Strategy {
+	case StrategyDelete:
+		result := tx.Exec("DELETE FROM ? WHERE ?", clause.Table{Name: rule.Table}, subjectClause(rule, subjectID))
+		return result.RowsAffected, result.Error
+	case StrategyNull:
+		values := make(map[string]interface{}, len(rule.Columns))
+		for _, column := range rule.Columns {
+			values[column] = nil
+		}
+		result := table.Session(&gorm.Session{}).Updates(values)
+		return result.RowsAffected, result.Error
+	}
+
+	var rows int64
+	if err := table.Session(&gorm.Session{}).Count(&rows).Error; err != nil {
+		return 0, err
+	}
+	// 主体列最后处理，之前的更新仍能按原主体ID匹配
+	columns := make([]string, 0, len(rule.Columns))
+	subjectColumn := false
+	for _, column := range rule.Columns {
+		if column == rule.Column {
+			subjectColumn = true
+			continue
+		}
+		columns = append(columns, column)
+	}
+	for _, column := range columns {
+		if err := h.pseudonymizeColumn(tx, rule, column, subjectID); err != nil {
+			return 0, err
+		}
+	}
+	if subjectColumn {
+		err := tx.Table(rule.Table).Where(subjectClause(rule, subjectID)).
+			Update(rule.Column, h.opts.pseudonymizer.Pseudonymize(subjectID)).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	return rows, nil
+}
+
+// pseudonymizeColumn 将主体各行中该列的每个不同取值替换为假名，NULL 保持不变
+func (h *DBHandler) pseudonymizeColumn(tx *gorm.DB, rule TableRule, column, subjectID string) error {
+	var values []sql.NullString
+	err := tx.Table(rule.Table).Where(subjectClause(rule, subjectID)).
+		Distinct(column).Pluck(column, &values).Error
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		// 重新执行时跳过已经替换过的值，保证假名确定
+		if !value.Valid || isPseudonym(value.String) {
+			continue
+		}
+		err := tx.Table(rule.Table).
+			Where(subjectClause(rule, subjectID)).
+			Where(clause.Eq{Column: clause.Column{Name: column}, Value: value.String}).
+			Update(column, h.opts.pseudonymizer.Pseudonymize(value.String)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package privacy
+
+import (
+	"context"
+	"fmt"
+)
+
+// FileStore 文件存储的最小接口，文件存储（flow-storage）的磁盘通过简单的适配即可实现
+type FileStore interface {
+	// Files 返回以 prefix 开头的全部文件路径，包括子目录中的文件
+	Files(ctx context.Context, prefix string) ([]string, error)
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(ctx context.Context, path string) error
+}
+
+// KeyShredder 销毁主体的文件加密密钥
+//
+// 文件使用按主体生成的密钥加密时，销毁密钥即可使全部文件（包括备份中的副本）无法解密，即加密擦除
+type KeyShredder interface {
+	ShredKey(ctx context.Context, subjectID string) error
+}
+
+// WithCryptoShred 文件处理器销毁主体的加密密钥而不是逐个删除文件，报告中的数量为受影响的文件数
+func WithCryptoShred(shredder KeyShredder) HandlerOption {
+	return func(o *handlerOptions) {
+		o.shredder = shredder
+	}
+}
+
+// FileHandler 删除或加密擦除主体目录下的文件，默认名称为 storage
+type FileHandler struct {
+	store    FileStore
+	prefixes []string
+	opts     handlerOptions
+}
+
+// NewFileHandler 创建文件处理器，prefixes 为包含 {id} 的路径前缀模板，如 "avatars/{id}/"
+func NewFileHandler(store FileStore, prefixes []string, opts ...HandlerOption) *FileHandler {
+	return &FileHandler{store: store, prefixes: prefixes, opts: applyHandlerOptions("storage", opts)}
+}
+
+// Name 实现 ErasureHandler
+func (h *FileHandler) Name() string {
+	return h.opts.name
+}
+
+// Erase 实现 ErasureHandler，报告按前缀模板统计文件数
+func (h *FileHandler) Erase(ctx context.Context, subjectID string) (ErasureReport, error) {
+	var report ErasureReport
+	for _, template := range h.prefixes {
+		prefix, err := expandPrefix(template, subjectID)
+		if err != nil {
+			return report, err
+		}
+		files, err := h.store.Files(ctx, prefix)
+		if err != nil {
+			return report, fmt.Errorf("列出 %s 失败: %w", prefix, err)
+		}
+		if IsDryRun(ctx) || h.opts.shredder != nil {
+			report.Add(template, int64(len(files)))
+			continue
+		}
+		for _, file := range files {
+			if err := h.store.Delete(ctx, file); err != nil {
+				return report, fmt.Errorf("删除 %s 失败: %w", file, err)
+			}
+			report.Add(template, 1)
+		}
+	}
+
+	if h.opts.shredder != nil && !IsDryRun(ctx) {
+		if err := h.opts.shredder.ShredKey(ctx, subjectID); err != nil {
+			return ErasureReport{}, fmt.Errorf("销毁加密密钥失败: %w", err)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,44 @@
+package privacy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zzliekkas/flow/v2/queue"
+)
+
+// JobName 异步清除任务的名称
+const JobName = "privacy.erase"
+
+// jobPayload 清除任务的负载
+type jobPayload struct {
+	Subject string `json:"subject"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+// Dispatch 分发异步清除任务，任务由注册了 HandleJob 的工作进程执行
+func Dispatch(ctx context.Context, queues *queue.QueueManager, subjectID string, dryRun bool, opts ...queue.DispatchOption) (string, error) {
+	if subjectID == "" {
+		return "", ErrEmptySubject
+	}
+	payload := map[string]interface{}{"subject": subjectID, "dry_run": dryRun}
+	return queues.Dispatch(ctx, JobName, payload, opts...)
+}
+
+// RegisterJob 在队列中注册默认执行器的清除任务
+func RegisterJob(queues *queue.QueueManager) {
+	queues.Register(JobName, defaultEraser.HandleJob)
+}
+
+// HandleJob 处理清除任务，部分处理器失败时返回错误，任务按队列的重试策略重新执行全部处理器
+func (e *Eraser) HandleJob(ctx context.Context, job *queue.Job) error {
+	var payload jobPayload
+	if err := job.GetPayload(&payload); err != nil {
+		return fmt.Errorf("privacy: 解析清除任务失败: %w", err)
+	}
+	if payload.DryRun {
+		ctx = WithDryRun(ctx)
+	}
+	_, err := e.Erase(ctx, payload.Subject)
+	return err
+}
@@ -0,0 +1,382 @@
+// Package privacy 提供数据保留与个人数据删除（GDPR 删除权）：在数据库、文件存储与缓存中清除某个用户的数据，
+// 生成可审计的签名报告
+//
+// 各模块注册 ErasureHandler，Erase 按注册顺序依次执行，单个处理器失败或超时不影响后续处理器：
+//
+//	privacy.Register(privacy.MustDBHandler(conn, []privacy.TableRule{
+//		{Table: "orders", Column: "user_id", Strategy: privacy.StrategyPseudonymize, Columns: []string{"email", "phone"}},
+//		{Table: "users", Column: "id", Strategy: privacy.StrategyDelete},
+//	}, privacy.WithPseudonymizer(pseudonymizer)))
+//	privacy.Register(privacy.NewCacheHandler(cacheManager, []string{"user:{id}:"}))
+//	privacy.Configure(privacy.WithSigner(signer), privacy.WithAudit(auditLogger))
+//
+//	report, err := privacy.Erase(ctx, "42")
+//
+// 使用 WithDryRun 包装 context 时只统计将要清除的数据，不做任何修改
+package privacy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/crypto"
+	"github.com/zzliekkas/flow/v2/security"
+)
+
+// DefaultTimeout 每个处理器默认的最长执行时间
+const DefaultTimeout = time.Minute
+
+// 清除相关错误
+var (
+	// ErrEmptySubject 主体ID为空
+	ErrEmptySubject = errors.New("privacy: 主体ID不能为空")
+	// ErrNoHandlers 没有注册任何清除处理器
+	ErrNoHandlers = errors.New("privacy: 没有注册清除处理器，请在应用中通过 privacy.Register 注册")
+	// ErrIncomplete 部分处理器执行失败，报告中记录了失败原因
+	ErrIncomplete = errors.New("privacy: 清除未全部完成")
+	// ErrUnsigned 报告没有签名
+	ErrUnsigned = errors.New("privacy: 报告没有签名")
+)
+
+// ErasureHandler 清除某个模块中属于主体的数据
+//
+// Erase 应当是幂等的：失败后重新执行不会出错；IsDryRun(ctx) 为 true 时只统计将要清除的数据。
+// 出错时也应返回已处理部分的报告
+type ErasureHandler interface {
+	// Name 处理器名称，在同一个 Eraser 中唯一
+	Name() string
+	// Erase 清除主体的数据
+	Erase(ctx context.Context, subjectID string) (ErasureReport, error)
+}
+
+// ErasureReport 单个处理器的执行结果
+type ErasureReport struct {
+	// Handler 处理器名称
+	Handler string `json:"handler"`
+	// Counts 按目标（表、键前缀、文件目录）统计的处理数量，试运行时为将要处理的数量
+	Counts map[string]int64 `json:"counts,omitempty"`
+	// Duration 执行耗时
+	Duration time.Duration `json:"duration"`
+	// Error 失败原因，成功时为空
+	Error string `json:"error,omitempty"`
+}
+
+// Add 累加目标的处理数量
+func (r *ErasureReport) Add(target string, n int64) {
+	if r.Counts == nil {
+		r.Counts = make(map[string]int64)
+	}
+	r.Counts[target] += n
+}
+
+// Total 返回各目标处理数量之和
+func (r ErasureReport) Total() int64 {
+	var total int64
+	for _, n := range r.Counts {
+		total += n
+	}
+	return total
+}
+
+// Report 一次清除的汇总报告
+type Report struct {
+	Subject    string          `json:"subject"`
+	DryRun     bool            `json:"dry_run"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Handlers   []ErasureReport `json:"handlers"`
+	// Signature 报告内容的 HMAC 签名，未配置签名器时为空，使用 VerifyReport 验证
+	Signature string `json:"signature,omitempty"`
+}
+
+// Failed 返回失败的处理器名称
+func (r *Report) Failed() []string {
+	var failed []string
+	for _, h := range r.Handlers {
+		if h.Error != "" {
+			failed = append(failed, h.Handler)
+		}
+	}
+	return failed
+}
+
+// Complete 判断所有处理器是否都执行成功
+func (r *Report) Complete() bool {
+	return len(r.Failed()) == 0
+}
+
+// Total 返回所有处理器处理数量之和
+func (r *Report) Total() int64 {
+	var total int64
+	for _, h := range r.Handlers {
+		total += h.Total()
+	}
+	return total
+}
+
+// payload 返回参与签名的内容
+func (r *Report) payload() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = ""
+	return json.Marshal(unsigned)
+}
+
+// VerifyReport 验证报告签名，报告内容被修改时返回 crypto.ErrInvalidSignature
+func VerifyReport(signer *crypto.Signer, report *Report) error {
+	if report.Signature == "" {
+		return ErrUnsigned
+	}
+	data, err := report.payload()
+	if err != nil {
+		return err
+	}
+	_, err = signer.Verify(string(data) + "." + report.Signature)
+	return err
+}
+
+// dryRunKey 试运行标记在 context 中的键
+type dryRunKey struct{}
+
+// WithDryRun 返回标记为试运行的 context，处理器只统计将要清除的数据
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun 判断是否为试运行
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// Option Eraser 的配置函数
+type Option func(*Eraser)
+
+// WithTimeout 设置处理器默认的最长执行时间，默认 DefaultTimeout
+func WithTimeout(d time.Duration) Option {
+	return func(e *Eraser) {
+		e.timeout = d
+	}
+}
+
+// WithSigner 设置报告签名器，通常由 app.key 创建
+func WithSigner(signer *crypto.Signer) Option {
+	return func(e *Eraser) {
+		e.signer = signer
+	}
+}
+
+// WithAudit 设置保存报告的审计日志
+func WithAudit(audit security.AuditLogger) Option {
+	return func(e *Eraser) {
+		e.audit = audit
+	}
+}
+
+// WithClock 设置时钟，用于测试
+func WithClock(now func() time.Time) Option {
+	return func(e *Eraser) {
+		e.now = now
+	}
+}
+
+// registration 已注册的处理器
+type registration struct {
+	handler ErasureHandler
+	timeout time.Duration
+}
+
+// Eraser 按注册顺序执行清除处理器
+type Eraser struct {
+	mu       sync.RWMutex
+	handlers []registration
+	timeout  time.Duration
+	signer   *crypto.Signer
+	audit    security.AuditLogger
+	now      func() time.Time
+}
+
+// NewEraser 创建清除执行器
+func NewEraser(opts ...Option) *Eraser {
+	e := &Eraser{timeout: DefaultTimeout, now: time.Now}
+	e.Configure(opts...)
+	return e
+}
+
+// Configure 修改配置
+func (e *Eraser) Configure(opts ...Option) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, opt := range opts {
+		opt(e)
+	}
+}
+
+// Register 注册处理器，使用默认的最长执行时间；名称重复时 panic
+func (e *Eraser) Register(handler ErasureHandler) {
+	e.RegisterWithTimeout(handler, 0)
+}
+
+// RegisterWithTimeout 注册处理器并设置它的最长执行时间，为0时使用默认值；名称重复时 panic
+func (e *Eraser) RegisterWithTimeout(handler ErasureHandler, timeout time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range e.handlers {
+		if r.handler.Name() == handler.Name() {
+			panic("privacy: 重复注册清除处理器 " + handler.Name())
+		}
+	}
+	e.handlers = append(e.handlers, registration{handler: handler, timeout: timeout})
+}
+
+// Handlers 按执行顺序返回已注册的处理器名称
+func (e *Eraser) Handlers() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	names := make([]string, len(e.handlers))
+	for i, r := range e.handlers {
+		names[i] = r.handler.Name()
+	}
+	return names
+}
+
+// Signed 判断是否配置了报告签名器
+func (e *Eraser) Signed() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.signer != nil
+}
+
+// Erase 依次执行所有处理器清除主体的数据，返回签名后的报告
+//
+// 处理器失败、panic 或超时时记录在报告中并继续执行后续处理器，最后返回包装了 ErrIncomplete 的错误；
+// 配置了审计日志时报告写入审计日志，写入失败同样返回错误。超时后不再等待处理器返回，
+// 处理器应当响应 ctx 的取消
+func (e *Eraser) Erase(ctx context.Context, subjectID string) (*Report, error) {
+	if strings.TrimSpace(subjectID) == "" {
+		return nil, ErrEmptySubject
+	}
+	e.mu.RLock()
+	handlers := append([]registration(nil), e.handlers...)
+	defaultTimeout, signer, audit, now := e.timeout, e.signer, e.audit, e.now
+	e.mu.RUnlock()
+	if len(handlers) == 0 {
+		return nil, ErrNoHandlers
+	}
+
+	report := &Report{Subject: subjectID, DryRun: IsDryRun(ctx), StartedAt: now().UTC()}
+	for _, r := range handlers {
+		timeout := r.timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		report.Handlers = append(report.Handlers, runHandler(ctx, r.handler, subjectID, timeout, now))
+	}
+	report.FinishedAt = now().UTC()
+
+	var errs []error
+	if failed := report.Failed(); len(failed) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrIncomplete, strings.Join(failed, ", ")))
+	}
+	data, err := report.payload()
+	if err != nil {
+		return report, err
+	}
+	if signer != nil {
+		signed := signer.Sign(string(data))
+		report.Signature = signed[len(data)+1:]
+	}
+	if audit != nil {
+		if err := saveReport(audit, report, data); err != nil {
+			errs = append(errs, fmt.Errorf("privacy: 保存报告到审计日志失败: %w", err))
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// runHandler 在超时时间内执行处理器，捕获 panic
+func runHandler(ctx context.Context, handler ErasureHandler, subjectID string, timeout time.Duration, now func() time.Time) ErasureReport {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		report ErasureReport
+		err    error
+	}
+	done := make(chan result, 1)
+	start := now()
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- result{err: fmt.Errorf("panic: %v", p)}
+			}
+		}()
+		report, err := handler.Erase(ctx, subjectID)
+		done <- result{report: report, err: err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("%s 内未完成: %w", timeout, ctx.Err())
+	}
+	report := res.report
+	report.Handler = handler.Name()
+	report.Duration = now().Sub(start)
+	if res.err != nil {
+		report.Error = res.err.Error()
+	}
+	return report
+}
+
+// saveReport 将报告写入审计日志
+//
+// 报告以签名时的 JSON 原文保存，审计日志的脱敏规则不会改动其中的内容，
+// 可通过 VerifyReport 或 crypto.Signer.Verify(report+"."+signature) 验证
+func saveReport(audit security.AuditLogger, report *Report, payload []byte) error {
+	action := "privacy.erase"
+	if report.DryRun {
+		action = "privacy.erase.dry_run"
+	}
+	details := map[string]interface{}{
+		"report":    string(payload),
+		"signature": report.Signature,
+		"total":     report.Total(),
+	}
+	if failed := report.Failed(); len(failed) > 0 {
+		details["failed"] = failed
+	}
+	return audit.LogSensitiveAction("system", action, "subject:"+report.Subject, report.Complete(), details)
+}
+
+var defaultEraser = NewEraser()
+
+// Default 返回包级的默认清除执行器，Register、Configure 与 Erase 都作用于它
+func Default() *Eraser {
+	return defaultEraser
+}
+
+// Register 向默认执行器注册处理器
+func Register(handler ErasureHandler) {
+	defaultEraser.Register(handler)
+}
+
+// RegisterWithTimeout 向默认执行器注册处理器并设置最长执行时间
+func RegisterWithTimeout(handler ErasureHandler, timeout time.Duration) {
+	defaultEraser.RegisterWithTimeout(handler, timeout)
+}
+
+// Configure 修改默认执行器的配置
+func Configure(opts ...Option) {
+	defaultEraser.Configure(opts...)
+}
+
+// Erase 使用默认执行器清除主体的数据
+func Erase(ctx context.Context, subjectID string) (*Report, error) {
+	return defaultEraser.Erase(ctx, subjectID)
+}
@@ -0,0 +1,364 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/cache"
+	"github.com/zzliekkas/flow/v2/crypto"
+	"github.com/zzliekkas/flow/v2/queue"
+	"github.com/zzliekkas/flow/v2/security"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// funcHandler 测试用的处理器
+type funcHandler struct {
+	name string
+	fn   func(ctx context.Context, subjectID string) (ErasureReport, error)
+}
+
+func (h *funcHandler) Name() string { return h.name }
+
+func (h *funcHandler) Erase(ctx context.Context, subjectID string) (ErasureReport, error) {
+	return h.fn(ctx, subjectID)
+}
+
+// recorder 记录处理器的执行顺序
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) handler(name string, n int64, err error) *funcHandler {
+	return &funcHandler{name: name, fn: func(ctx context.Context, subjectID string) (ErasureReport, error) {
+		r.mu.Lock()
+		r.calls = append(r.calls, name+":"+subjectID)
+		r.mu.Unlock()
+		var report ErasureReport
+		report.Add(name+"_items", n)
+		return report, err
+	}}
+}
+
+func TestEraser_RegistrationAndOrder(t *testing.T) {
+	rec := &recorder{}
+	e := NewEraser()
+	e.Register(rec.handler("db", 3, nil))
+	e.Register(rec.handler("storage", 2, nil))
+	e.Register(rec.handler("cache", 1, nil))
+	assert.Equal(t, []string{"db", "storage", "cache"}, e.Handlers())
+	assert.Panics(t, func() { e.Register(rec.handler("db", 0, nil)) }, "名称重复")
+
+	report, err := e.Erase(context.Background(), "42")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db:42", "storage:42", "cache:42"}, rec.calls)
+	assert.True(t, report.Complete())
+	assert.Equal(t, int64(6), report.Total())
+	assert.Equal(t, "storage", report.Handlers[1].Handler)
+
+	_, err = e.Erase(context.Background(), " ")
+	assert.ErrorIs(t, err, ErrEmptySubject)
+	_, err = NewEraser().Erase(context.Background(), "42")
+	assert.ErrorIs(t, err, ErrNoHandlers)
+}
+
+func TestEraser_PartialFailureContinues(t *testing.T) {
+	rec := &recorder{}
+	e := NewEraser(WithTimeout(time.Second))
+	e.Register(rec.handler("first", 1, nil))
+	e.Register(&funcHandler{name: "failing", fn: func(ctx context.Context, subjectID string) (ErasureReport, error) {
+		var report ErasureReport
+		report.Add("partial", 2)
+		return report, errors.New("连接断开")
+	}})
+	e.Register(&funcHandler{name: "panicking", fn: func(ctx context.Context, subjectID string) (ErasureReport, error) {
+		panic("boom")
+	}})
+	e.RegisterWithTimeout(&funcHandler{name: "slow", fn: func(ctx context.Context, subjectID string) (ErasureReport, error) {
+		<-ctx.Done()
+		time.Sleep(100 * time.Millisecond)
+		return ErasureReport{}, ctx.Err()
+	}}, 20*time.Millisecond)
+	e.Register(rec.handler("last", 1, nil))
+
+	report, err := e.Erase(context.Background(), "42")
+	assert.ErrorIs(t, err, ErrIncomplete)
+	assert.ErrorContains(t, err, "failing, panicking, slow")
+	assert.Equal(t, []string{"first:42", "last:42"}, rec.calls, "失败的处理器不影响后续处理器")
+
+	assert.Equal(t, []string{"failing", "panicking", "slow"}, report.Failed())
+	assert.Equal(t, "连接断开", report.Handlers[1].Error)
+	assert.Equal(t, int64(2), report.Handlers[1].Counts["partial"], "保留失败前已处理的数量")
+	assert.Contains(t, report.Handlers[2].Error, "panic: boom")
+	assert.Contains(t, report.Handlers[3].Error, "20ms 内未完成")
+	assert.Less(t, report.Handlers[3].Duration, 100*time.Millisecond, "超时后不等待处理器返回")
+	assert.Empty(t, report.Handlers[4].Error)
+}
+
+func TestEraser_ReportPersistence(t *testing.T) {
+	signer, err := crypto.NewSigner([][]byte{[]byte("app-key")})
+	require.NoError(t, err)
+	audit := security.NewBasicAuditLogger(security.AuditConfig{Enabled: true})
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	e := NewEraser(WithSigner(signer), WithAudit(audit), WithClock(func() time.Time { return now }))
+	rec := &recorder{}
+	e.Register(rec.handler("db", 3, nil))
+	e.Register(rec.handler("cache", 0, errors.New("不可用")))
+
+	report, err := e.Erase(context.Background(), "42")
+	assert.ErrorIs(t, err, ErrIncomplete)
+	require.NotEmpty(t, report.Signature)
+	assert.NoError(t, VerifyReport(signer, report))
+
+	logs, err := audit.GetLogs("sensitive_action", 0)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	entry := logs[0]
+	assert.Equal(t, "privacy.erase", entry.Action)
+	assert.Equal(t, "subject:42", entry.Resource)
+	assert.False(t, entry.Success)
+	assert.Equal(t, []interface{}{"cache"}, entry.Details["failed"])
+	assert.Equal(t, int64(3), entry.Details["total"])
+
+	// 审计日志中保存的是签名时的原文
+	saved := entry.Details["report"].(string)
+	value, err := signer.Verify(saved + "." + entry.Details["signature"].(string))
+	require.NoError(t, err)
+	assert.Contains(t, value, `"subject":"42"`)
+
+	// 修改后的报告无法通过验证
+	report.Handlers[0].Counts["db_items"] = 0
+	assert.ErrorIs(t, VerifyReport(signer, report), crypto.ErrInvalidSignature)
+	report.Signature = ""
+	assert.ErrorIs(t, VerifyReport(signer, report), ErrUnsigned)
+
+	// 试运行使用单独的审计动作
+	_, _ = e.Erase(WithDryRun(context.Background()), "42")
+	logs, err = audit.GetLogs("sensitive_action", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "privacy.erase.dry_run", logs[0].Action)
+}
+
+// newTestDB 创建包含两个用户数据的数据库
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.Exec(`CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT, name TEXT)`).Error)
+	require.NoError(t, conn.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id TEXT, email TEXT, phone TEXT, amount INTEGER)`).Error)
+	require.NoError(t, conn.Exec(`CREATE TABLE sessions (id INTEGER PRIMARY KEY, user_id TEXT, ip TEXT, agent TEXT)`).Error)
+	require.NoError(t, conn.Exec(`INSERT INTO users VALUES ('42', 'a@example.com', 'Alice'), ('7', 'b@example.com', 'Bob')`).Error)
+	require.NoError(t, conn.Exec(`INSERT INTO orders (user_id, email, phone, amount) VALUES
+		('42', 'a@example.com', '555-1', 100), ('42', 'a@example.com', NULL, 200), ('42', 'old@example.com', '555-2', 50),
+		('7', 'b@example.com', '555-9', 80)`).Error)
+	require.NoError(t, conn.Exec(`INSERT INTO sessions (user_id, ip, agent) VALUES ('42', '10.0.0.1', 'ua'), ('7', '10.0.0.2', 'ua')`).Error)
+	return conn
+}
+
+func newTestPseudonymizer(t *testing.T) *Pseudonymizer {
+	t.Helper()
+	p, err := NewPseudonymizer([]byte("app-key"))
+	require.NoError(t, err)
+	return p
+}
+
+var testRules = []TableRule{
+	{Table: "orders", Column: "user_id", Strategy: StrategyPseudonymize, Columns: []string{"email", "phone", "user_id"}},
+	{Table: "sessions", Column: "user_id", Strategy: StrategyNull, Columns: []string{"ip", "agent"}},
+	{Table: "users", Column: "id", Strategy: StrategyDelete},
+}
+
+func TestDBHandler_Strategies(t *testing.T) {
+	conn := newTestDB(t)
+	p := newTestPseudonymizer(t)
+	h, err := NewDBHandler(conn, testRules, WithPseudonymizer(p))
+	require.NoError(t, err)
+
+	report, err := h.Erase(context.Background(), "42")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"orders": 3, "sessions": 1, "users": 1}, report.Counts)
+
+	var users int64
+	conn.Table("users").Count(&users)
+	assert.Equal(t, int64(1), users)
+
+	type order struct {
+		UserID string
+		Email  string
+		Phone  *string
+		Amount int
+	}
+	var orders []order
+	require.NoError(t, conn.Table("orders").Order("id").Find(&orders).Error)
+	pseudonymousID := p.Pseudonymize("42")
+	for _, o := range orders[:3] {
+		assert.Equal(t, pseudonymousID, o.UserID)
+		assert.True(t, strings.HasPrefix(o.Email, "anon_"))
+	}
+	// 相同的原值得到相同的假名，金额等其他数据保留
+	assert.Equal(t, orders[0].Email, orders[1].Email)
+	assert.NotEqual(t, orders[0].Email, orders[2].Email)
+	assert.Equal(t, p.Pseudonymize("a@example.com"), orders[0].Email)
+	assert.Nil(t, orders[1].Phone, "NULL 保持不变")
+	assert.Equal(t, 200, orders[1].Amount)
+	assert.Equal(t, order{UserID: "7", Email: "b@example.com", Phone: orders[3].Phone, Amount: 80}, orders[3])
+
+	var ip *string
+	require.NoError(t, conn.Raw(`SELECT ip FROM sessions WHERE user_id = '42'`).Scan(&ip).Error)
+	assert.Nil(t, ip)
+
+	// 重新执行不会再次替换已生成的假名
+	_, err = h.Erase(context.Background(), "42")
+	require.NoError(t, err)
+	var email string
+	require.NoError(t, conn.Raw(`SELECT email FROM orders WHERE id = 1`).Scan(&email).Error)
+	assert.Equal(t, p.Pseudonymize("a@example.com"), email)
+
+	// 无效规则
+	_, err = NewDBHandler(conn, []TableRule{{Table: "orders", Column: "user_id", Strategy: StrategyPseudonymize, Columns: []string{"email"}}})
+	assert.ErrorContains(t, err, "WithPseudonymizer")
+	_, err = NewDBHandler(conn, []TableRule{{Table: "orders", Column: "user_id", Strategy: "truncate"}})
+	assert.ErrorContains(t, err, "无效")
+}
+
+func TestPseudonymizer_Deterministic(t *testing.T) {
+	p1 := newTestPseudonymizer(t)
+	p2 := newTestPseudonymizer(t)
+	assert.Equal(t, p1.Pseudonymize("a@example.com"), p2.Pseudonymize("a@example.com"))
+	assert.NotEqual(t, p1.Pseudonymize("a@example.com"), p1.Pseudonymize("b@example.com"))
+	assert.Len(t, p1.Pseudonymize("x"), 37)
+	assert.True(t, isPseudonym(p1.Pseudonymize("x")))
+	assert.False(t, isPseudonym("anon_user"))
+
+	other, err := NewPseudonymizer([]byte("other-key"))
+	require.NoError(t, err)
+	assert.NotEqual(t, p1.Pseudonymize("a@example.com"), other.Pseudonymize("a@example.com"), "不同密钥得到不同假名")
+	_, err = NewPseudonymizer(nil)
+	assert.Error(t, err)
+}
+
+// memoryFiles 内存中的文件存储
+type memoryFiles struct {
+	files map[string]bool
+}
+
+func (m *memoryFiles) Files(ctx context.Context, prefix string) ([]string, error) {
+	var result []string
+	for path := range m.files {
+		if strings.HasPrefix(path, prefix) {
+			result = append(result, path)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (m *memoryFiles) Delete(ctx context.Context, path string) error {
+	delete(m.files, path)
+	return nil
+}
+
+type shredderFunc func(ctx context.Context, subjectID string) error
+
+func (f shredderFunc) ShredKey(ctx context.Context, subjectID string) error { return f(ctx, subjectID) }
+
+func TestEraser_DryRunAccuracy(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	store := cache.NewMemoryStore()
+	require.NoError(t, store.Set(ctx, "user:42:profile", "p"))
+	require.NoError(t, store.Set(ctx, "user:42:prefs", "p"))
+	require.NoError(t, store.Set(ctx, "user:420:profile", "p"))
+	files := &memoryFiles{files: map[string]bool{
+		"avatars/42/a.png": true, "avatars/42/b.png": true, "exports/42/data.zip": true, "avatars/7/a.png": true,
+	}}
+
+	e := NewEraser()
+	e.Register(MustDBHandler(conn, testRules, WithPseudonymizer(newTestPseudonymizer(t))))
+	e.Register(NewFileHandler(files, []string{"avatars/{id}/", "exports/{id}/"}))
+	e.Register(NewCacheHandler(store, []string{"user:{id}:"}))
+
+	dry, err := e.Erase(WithDryRun(ctx), "42")
+	require.NoError(t, err)
+	assert.True(t, dry.DryRun)
+
+	// 试运行不修改任何数据
+	var users int64
+	conn.Table("users").Count(&users)
+	assert.Equal(t, int64(2), users)
+	assert.Len(t, files.files, 4)
+	assert.True(t, store.Has(ctx, "user:42:profile"))
+
+	erased, err := e.Erase(ctx, "42")
+	require.NoError(t, err)
+	require.Len(t, erased.Handlers, len(dry.Handlers))
+	for i := range erased.Handlers {
+		assert.Equal(t, dry.Handlers[i].Counts, erased.Handlers[i].Counts, erased.Handlers[i].Handler)
+	}
+	assert.Equal(t, map[string]int64{"avatars/{id}/": 2, "exports/{id}/": 1}, erased.Handlers[1].Counts)
+	assert.Equal(t, map[string]int64{"user:{id}:": 2}, erased.Handlers[2].Counts)
+	assert.Equal(t, []string{"avatars/7/a.png"}, keys(files.files))
+	assert.True(t, store.Has(ctx, "user:420:profile"))
+
+	// 前缀模板必须包含 {id}
+	_, err = NewCacheHandler(store, []string{"user:"}).Erase(ctx, "42")
+	assert.ErrorContains(t, err, "{id}")
+}
+
+func TestFileHandler_CryptoShred(t *testing.T) {
+	files := &memoryFiles{files: map[string]bool{"docs/42/a.pdf": true, "docs/42/b.pdf": true}}
+	var shredded []string
+	h := NewFileHandler(files, []string{"docs/{id}/"}, WithName("documents"), WithCryptoShred(shredderFunc(func(ctx context.Context, subjectID string) error {
+		shredded = append(shredded, subjectID)
+		return nil
+	})))
+	assert.Equal(t, "documents", h.Name())
+
+	report, err := h.Erase(WithDryRun(context.Background()), "42")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Total())
+	assert.Empty(t, shredded)
+
+	report, err = h.Erase(context.Background(), "42")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Total())
+	assert.Equal(t, []string{"42"}, shredded)
+	assert.Len(t, files.files, 2, "加密擦除不删除文件")
+}
+
+func TestEraser_HandleJob(t *testing.T) {
+	rec := &recorder{}
+	e := NewEraser()
+	var dryRun bool
+	e.Register(&funcHandler{name: "db", fn: func(ctx context.Context, subjectID string) (ErasureReport, error) {
+		dryRun = IsDryRun(ctx)
+		return rec.handler("db", 1, nil).Erase(ctx, subjectID)
+	}})
+
+	job := &queue.Job{Name: JobName, Payload: map[string]interface{}{"subject": "42", "dry_run": true}}
+	require.NoError(t, e.HandleJob(context.Background(), job))
+	assert.True(t, dryRun)
+	assert.Equal(t, []string{"db:42"}, rec.calls)
+
+	job.Payload = map[string]interface{}{}
+	assert.ErrorIs(t, e.HandleJob(context.Background(), job), ErrEmptySubject)
+}
+
+func keys(m map[string]bool) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
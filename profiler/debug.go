@@ -0,0 +1,344 @@
+package profiler
+
+import (
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	httppprof "net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/debug"
+	rtpprof "runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/diagnostics"
+)
+
+// DebugConfig 运行时调试端点配置
+type DebugConfig struct {
+	// 是否启用，为nil时仅在debug模式下启用
+	Enabled *bool
+
+	// 路由前缀，默认 /_debug
+	Prefix string
+
+	// 允许访问的CIDR列表，为空时不限制来源
+	AllowCIDRs []string
+
+	// 额外的访问控制中间件（如认证中间件）
+	Middleware []flow.HandlerFunc
+
+	// 内存采样间隔，为0时不进行后台采样
+	SampleInterval time.Duration
+
+	// 保留的采样数量
+	SampleSize int
+
+	// Diagnostics 诊断端点读取的收集器，为nil时使用 diagnostics.Default()
+	Diagnostics *diagnostics.Collector
+}
+
+// DefaultDebugConfig 返回默认调试端点配置
+func DefaultDebugConfig() DebugConfig {
+	return DebugConfig{
+		Prefix:     "/_debug",
+		SampleSize: 60,
+	}
+}
+
+// IsEnabled 判断调试端点是否应在指定引擎上启用
+func (c DebugConfig) IsEnabled(engine *flow.Engine) bool {
+	if c.Enabled != nil {
+		return *c.Enabled
+	}
+	return engine.IsDebug()
+}
+
+// DebugEndpoints 已挂载的调试端点
+type DebugEndpoints struct {
+	config  DebugConfig
+	sampler *MemSampler
+}
+
+// MountDebugEndpoints 在引擎上挂载pprof、expvar及运行时统计端点
+// 未启用时返回nil，不注册任何路由
+func MountDebugEndpoints(engine *flow.Engine, config DebugConfig) (*DebugEndpoints, error) {
+	if !config.IsEnabled(engine) {
+		return nil, nil
+	}
+	if config.Prefix == "" {
+		config.Prefix = "/_debug"
+	}
+
+	guard, err := CIDRGuard(config.AllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DebugEndpoints{config: config}
+	if config.SampleInterval > 0 {
+		d.sampler = NewMemSampler(config.SampleInterval, config.SampleSize)
+		d.sampler.Start()
+	}
+
+	handlers := append([]flow.HandlerFunc{guard}, config.Middleware...)
+	group := engine.Group(config.Prefix, handlers...)
+
+	group.GET("/pprof/", wrapHTTP(httppprof.Index))
+	group.GET("/pprof/:name", func(c *flow.Context) {
+		switch name := c.Param("name"); name {
+		case "cmdline":
+			httppprof.Cmdline(c.Writer, c.Request)
+		case "profile":
+			httppprof.Profile(c.Writer, c.Request)
+		case "symbol":
+			httppprof.Symbol(c.Writer, c.Request)
+		case "trace":
+			httppprof.Trace(c.Writer, c.Request)
+		default:
+			httppprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+		}
+	})
+	group.POST("/pprof/symbol", wrapHTTP(httppprof.Symbol))
+	group.GET("/vars", func(c *flow.Context) {
+		expvar.Handler().ServeHTTP(c.Writer, c.Request)
+	})
+	group.GET("/stack", stackHandler)
+	group.GET("/memstats", d.memStatsHandler)
+	group.GET("/buildinfo", buildInfoHandler)
+	group.GET("/diagnostics/:kind", d.diagnosticsHandler)
+
+	return d, nil
+}
+
+// Stop 停止后台采样
+func (d *DebugEndpoints) Stop() {
+	if d != nil && d.sampler != nil {
+		d.sampler.Stop()
+	}
+}
+
+// CIDRGuard 创建按来源IP限制访问的中间件，cidrs为空时放行所有请求
+// 来源IP取自 Context.ClientIP()，需要配合引擎的可信代理设置使用
+func CIDRGuard(cidrs []string) (flow.HandlerFunc, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("无效的CIDR: %s: %w", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+
+	return func(c *flow.Context) {
+		if len(networks) == 0 {
+			c.Next()
+			return
+		}
+		ip := net.ParseIP(c.ClientIP())
+		for _, network := range networks {
+			if ip != nil && network.Contains(ip) {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, flow.H{"error": "禁止访问调试端点"})
+	}, nil
+}
+
+// wrapHTTP 将标准库处理函数包装为Flow处理函数
+func wrapHTTP(fn http.HandlerFunc) flow.HandlerFunc {
+	return func(c *flow.Context) {
+		fn(c.Writer, c.Request)
+	}
+}
+
+// stackHandler 输出所有协程的调用栈
+func stackHandler(c *flow.Context) {
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	_ = rtpprof.Lookup("goroutine").WriteTo(c.Writer, 2)
+}
+
+// memStatsHandler 输出GC与堆内存统计
+func (d *DebugEndpoints) memStatsHandler(c *flow.Context) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	gogc := os.Getenv("GOGC")
+	if gogc == "" {
+		gogc = "100"
+	}
+
+	result := flow.H{
+		"goroutines":      runtime.NumGoroutine(),
+		"heap_alloc":      m.HeapAlloc,
+		"heap_sys":        m.HeapSys,
+		"heap_idle":       m.HeapIdle,
+		"heap_inuse":      m.HeapInuse,
+		"heap_released":   m.HeapReleased,
+		"heap_objects":    m.HeapObjects,
+		"total_alloc":     m.TotalAlloc,
+		"sys":             m.Sys,
+		"num_gc":          m.NumGC,
+		"pause_total_ns":  m.PauseTotalNs,
+		"last_gc":         time.Unix(0, int64(m.LastGC)),
+		"next_gc":         m.NextGC,
+		"gc_cpu_fraction": m.GCCPUFraction,
+		"gogc":            gogc,
+		"gomemlimit":      debug.SetMemoryLimit(-1), // 传入负数仅读取当前值
+	}
+	if d.sampler != nil {
+		result["samples"] = d.sampler.Samples()
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// diagnosticsHandler 输出最近的错误（errors）或慢请求（slow），since 参数只返回更新的记录
+func (d *DebugEndpoints) diagnosticsHandler(c *flow.Context) {
+	collector := d.config.Diagnostics
+	if collector == nil {
+		collector = diagnostics.Default()
+	}
+
+	var ring *diagnostics.Ring
+	switch c.Param("kind") {
+	case "errors":
+		ring = collector.Errors()
+	case "slow":
+		ring = collector.Slow()
+	default:
+		c.JSON(http.StatusNotFound, flow.H{"error": "未知的诊断类型，可选 errors 或 slow"})
+		return
+	}
+
+	since, _ := strconv.ParseUint(c.Query("since"), 10, 64)
+	c.JSON(http.StatusOK, flow.H{
+		"entries":  ring.Since(since),
+		"capacity": ring.Cap(),
+		"dropped":  ring.Dropped(),
+	})
+}
+
+// buildInfoHandler 输出模块版本与VCS信息
+func buildInfoHandler(c *flow.Context) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		c.JSON(http.StatusNotFound, flow.H{"error": "构建信息不可用"})
+		return
+	}
+
+	settings := make(map[string]string, len(info.Settings))
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	deps := make(map[string]string, len(info.Deps))
+	for _, dep := range info.Deps {
+		deps[dep.Path] = dep.Version
+	}
+
+	c.JSON(http.StatusOK, flow.H{
+		"go_version":   info.GoVersion,
+		"path":         info.Path,
+		"main":         info.Main.Path,
+		"version":      info.Main.Version,
+		"vcs_revision": settings["vcs.revision"],
+		"vcs_time":     settings["vcs.time"],
+		"vcs_modified": settings["vcs.modified"],
+		"flow_version": flow.Version,
+		"settings":     settings,
+		"deps":         deps,
+	})
+}
+
+// MemSample 内存采样点
+type MemSample struct {
+	Time       time.Time `json:"time"`
+	HeapAlloc  uint64    `json:"heap_alloc"`
+	HeapInuse  uint64    `json:"heap_inuse"`
+	NumGC      uint32    `json:"num_gc"`
+	Goroutines int       `json:"goroutines"`
+}
+
+// MemSampler 后台内存采样器，保留最近的若干采样点
+type MemSampler struct {
+	interval time.Duration
+	size     int
+	samples  []MemSample
+	mu       sync.Mutex
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewMemSampler 创建内存采样器
+func NewMemSampler(interval time.Duration, size int) *MemSampler {
+	if size <= 0 {
+		size = 60
+	}
+	return &MemSampler{
+		interval: interval,
+		size:     size,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动后台采样
+func (s *MemSampler) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.sample()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台采样，可重复调用
+func (s *MemSampler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+// Samples 返回采样点副本
+func (s *MemSampler) Samples() []MemSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]MemSample(nil), s.samples...)
+}
+
+// sample 采集一次内存数据
+func (s *MemSampler) sample() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, MemSample{
+		Time:       time.Now(),
+		HeapAlloc:  m.HeapAlloc,
+		HeapInuse:  m.HeapInuse,
+		NumGC:      m.NumGC,
+		Goroutines: runtime.NumGoroutine(),
+	})
+	if len(s.samples) > s.size {
+		s.samples = s.samples[len(s.samples)-s.size:]
+	}
+}
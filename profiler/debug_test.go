@@ -0,0 +1,82 @@
+package profiler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/config"
+)
+
+func newDebugTestEngine(mode string) *flow.Engine {
+	gin.SetMode(gin.TestMode)
+	return flow.New(flow.WithMode(mode))
+}
+
+func serve(e *flow.Engine, path, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestMountDebugEndpoints_GatedByConfig(t *testing.T) {
+	// 非debug模式默认不挂载
+	e := newDebugTestEngine("release")
+	endpoints, err := MountDebugEndpoints(e, DefaultDebugConfig())
+	require.NoError(t, err)
+	assert.Nil(t, endpoints)
+	assert.Equal(t, http.StatusNotFound, serve(e, "/_debug/memstats", "127.0.0.1:1").Code)
+
+	// 配置显式启用
+	cm := config.NewConfigManager()
+	cm.Set("debug.enabled", true)
+	cm.Set("debug.prefix", "/ops")
+	e = newDebugTestEngine("release")
+	endpoints, err = MountDebugEndpoints(e, LoadDebugConfig(cm))
+	require.NoError(t, err)
+	require.NotNil(t, endpoints)
+	defer endpoints.Stop()
+
+	w := serve(e, "/ops/memstats", "127.0.0.1:1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stats map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Contains(t, stats, "heap_alloc")
+	assert.Contains(t, stats, "gomemlimit")
+
+	assert.Equal(t, http.StatusOK, serve(e, "/ops/pprof/", "127.0.0.1:1").Code)
+	assert.Equal(t, http.StatusOK, serve(e, "/ops/pprof/heap", "127.0.0.1:1").Code)
+	assert.Equal(t, http.StatusOK, serve(e, "/ops/vars", "127.0.0.1:1").Code)
+	assert.Equal(t, http.StatusOK, serve(e, "/ops/stack", "127.0.0.1:1").Code)
+	assert.Equal(t, http.StatusOK, serve(e, "/ops/buildinfo", "127.0.0.1:1").Code)
+
+	// debug模式下默认启用
+	e = newDebugTestEngine("debug")
+	endpoints, err = MountDebugEndpoints(e, DefaultDebugConfig())
+	require.NoError(t, err)
+	assert.NotNil(t, endpoints)
+}
+
+func TestMountDebugEndpoints_CIDRDenial(t *testing.T) {
+	e := newDebugTestEngine("debug")
+	cfg := DefaultDebugConfig()
+	cfg.AllowCIDRs = []string{"10.0.0.0/8", "127.0.0.1"}
+	_, err := MountDebugEndpoints(e, cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, serve(e, "/_debug/memstats", "10.1.2.3:5000").Code)
+	assert.Equal(t, http.StatusOK, serve(e, "/_debug/memstats", "127.0.0.1:5000").Code)
+	assert.Equal(t, http.StatusForbidden, serve(e, "/_debug/memstats", "192.168.1.10:5000").Code)
+}
+
+func TestCIDRGuard_InvalidCIDR(t *testing.T) {
+	_, err := CIDRGuard([]string{"not-a-cidr"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package profiler
+
+import (
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/app"
+	"github.com/zzliekkas/flow/v2/config"
+	"github.com/zzliekkas/flow/v2/diagnostics"
+)
+
+// DebugProvider 运行时调试端点服务提供者
+type DebugProvider struct {
+	*app.BaseProvider
+	middleware []flow.HandlerFunc
+	endpoints  *DebugEndpoints
+}
+
+// NewDebugProvider 创建调试端点服务提供者
+// middleware 用于保护调试端点，例如认证中间件
+func NewDebugProvider(middleware ...flow.HandlerFunc) *DebugProvider {
+	return &DebugProvider{
+		BaseProvider: app.NewBaseProvider("debug", 90),
+		middleware:   middleware,
+	}
+}
+
+// Register 注册调试服务
+func (p *DebugProvider) Register(application *app.Application) error {
+	return nil
+}
+
+// Boot 根据配置挂载调试端点
+func (p *DebugProvider) Boot(application *app.Application) error {
+	cfg := DefaultDebugConfig()
+
+	var configManager *config.ConfigManager
+	if err := application.Engine().Invoke(func(cm *config.ConfigManager) {
+		configManager = cm
+	}); err == nil && configManager != nil {
+		cfg = LoadDebugConfig(configManager)
+		if configManager.Has("diagnostics") {
+			diagnostics.SetDefault(diagnostics.New(diagnostics.LoadConfig(configManager)))
+		}
+	}
+	cfg.Middleware = append(cfg.Middleware, p.middleware...)
+
+	// 未显式配置时按环境默认值决定，生产环境默认不挂载
+	env := application.Environment()
+	if cfg.Enabled == nil && !env.Defaults.DebugEndpoints {
+		disabled := false
+		cfg.Enabled = &disabled
+	}
+	if cfg.IsEnabled(application.Engine()) && !env.Defaults.DebugEndpoints {
+		application.ReportDevFeature("调试端点 (" + cfg.Prefix + ")")
+	}
+
+	endpoints, err := MountDebugEndpoints(application.Engine(), cfg)
+	if err != nil {
+		return err
+	}
+	if endpoints == nil {
+		return nil
+	}
+	p.endpoints = endpoints
+
+	application.Logger().Infof("调试端点已挂载: %s", cfg.Prefix)
+	if len(cfg.AllowCIDRs) == 0 && len(cfg.Middleware) == 0 {
+		application.Logger().Warn("调试端点未设置访问限制，请配置 debug.allow_cidrs 或认证中间件")
+	}
+
+	// 关闭时停止后台采样
+	application.OnBeforeShutdown("debug_sampler", func() {
+		p.endpoints.Stop()
+	}, 100)
+
+	return nil
+}
+
+// LoadDebugConfig 从配置加载调试端点设置
+//
+//	debug:
+//	  enabled: true
+//	  prefix: /_debug
+//	  allow_cidrs: ["10.0.0.0/8", "127.0.0.1"]
+//	  sample_interval: 10s
+//	  sample_size: 60
+func LoadDebugConfig(configManager *config.ConfigManager) DebugConfig {
+	cfg := DefaultDebugConfig()
+
+	if configManager.Has("debug.enabled") {
+		enabled := configManager.GetBool("debug.enabled")
+		cfg.Enabled = &enabled
+	}
+	if prefix := configManager.GetString("debug.prefix"); prefix != "" {
+		cfg.Prefix = prefix
+	}
+	cfg.AllowCIDRs = configManager.GetStringSlice("debug.allow_cidrs")
+	cfg.SampleInterval = configManager.GetDuration("debug.sample_interval")
+	if size := configManager.GetInt("debug.sample_size"); size > 0 {
+		cfg.SampleSize = size
+	}
+
+	return cfg
+}
@@ -0,0 +1,268 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 请求/响应调用的错误定义
+var (
+	ErrCallTimeout     = errors.New("queue: 等待任务回复超时")
+	ErrCallFailed      = errors.New("queue: 任务返回错误")
+	ErrNotCallJob      = errors.New("queue: 任务不是通过 Call 分发的，无法回复")
+	ErrAlreadyReplied  = errors.New("queue: 任务已回复")
+	ErrNoReplyReceiver = errors.New("queue: 未设置回复通道")
+)
+
+// CorrelationIDKey 调用任务负载中保存关联ID的键
+const CorrelationIDKey = "_correlation_id"
+
+// Result 任务回复
+type Result struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// Decode 将回复数据解析为指定类型
+func (r Result) Decode(v interface{}) error {
+	if len(r.Data) == 0 {
+		return errors.New("queue: 回复没有数据")
+	}
+	return json.Unmarshal(r.Data, v)
+}
+
+// ReplyBroker 回复通道，在调用方与工作进程之间按关联ID传递回复
+// 同一进程内使用 NewMemoryReplyBroker；调用方与工作进程在不同进程时使用 redis.NewReplyBroker
+type ReplyBroker interface {
+	// Subscribe 在分发任务之前登记等待，保证不会错过回复
+	Subscribe(ctx context.Context, correlationID string) (ReplySubscription, error)
+
+	// Publish 发送回复，没有调用方等待时回复被丢弃
+	Publish(ctx context.Context, correlationID string, result Result) error
+}
+
+// ReplySubscription 单次调用的回复等待
+type ReplySubscription interface {
+	// Receive 等待回复，ctx 结束时返回 ctx.Err()
+	Receive(ctx context.Context) (Result, error)
+
+	// Close 取消等待，之后到达的回复被丢弃
+	Close() error
+}
+
+// ReplyWaiters 按关联ID登记的回复等待者，供 ReplyBroker 实现使用
+type ReplyWaiters struct {
+	mu      sync.Mutex
+	waiters map[string]chan Result
+}
+
+// NewReplyWaiters 创建回复等待者表
+func NewReplyWaiters() *ReplyWaiters {
+	return &ReplyWaiters{waiters: make(map[string]chan Result)}
+}
+
+// Add 登记等待者
+func (w *ReplyWaiters) Add(correlationID string) ReplySubscription {
+	ch := make(chan Result, 1)
+	w.mu.Lock()
+	w.waiters[correlationID] = ch
+	w.mu.Unlock()
+	return &waiterSubscription{waiters: w, id: correlationID, ch: ch}
+}
+
+// Deliver 将回复交给等待者，没有等待者或已回复时丢弃并返回false
+func (w *ReplyWaiters) Deliver(correlationID string, result Result) bool {
+	w.mu.Lock()
+	ch, ok := w.waiters[correlationID]
+	w.mu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case ch <- result:
+		return true
+	default:
+		return false
+	}
+}
+
+// remove 取消登记
+func (w *ReplyWaiters) remove(correlationID string) {
+	w.mu.Lock()
+	delete(w.waiters, correlationID)
+	w.mu.Unlock()
+}
+
+// waiterSubscription 基于 ReplyWaiters 的回复等待
+type waiterSubscription struct {
+	waiters *ReplyWaiters
+	id      string
+	ch      chan Result
+}
+
+// Receive 等待回复
+func (s *waiterSubscription) Receive(ctx context.Context) (Result, error) {
+	select {
+	case result := <-s.ch:
+		return result, nil
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+// Close 取消等待
+func (s *waiterSubscription) Close() error {
+	s.waiters.remove(s.id)
+	return nil
+}
+
+// MemoryReplyBroker 进程内的回复通道
+type MemoryReplyBroker struct {
+	waiters *ReplyWaiters
+}
+
+// NewMemoryReplyBroker 创建进程内的回复通道
+func NewMemoryReplyBroker() *MemoryReplyBroker {
+	return &MemoryReplyBroker{waiters: NewReplyWaiters()}
+}
+
+// Subscribe 登记等待
+func (b *MemoryReplyBroker) Subscribe(ctx context.Context, correlationID string) (ReplySubscription, error) {
+	return b.waiters.Add(correlationID), nil
+}
+
+// Publish 发送回复
+func (b *MemoryReplyBroker) Publish(ctx context.Context, correlationID string, result Result) error {
+	b.waiters.Deliver(correlationID, result)
+	return nil
+}
+
+// SetReplyBroker 设置 Call 使用的回复通道，默认使用进程内通道
+func (m *QueueManager) SetReplyBroker(broker ReplyBroker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replies = broker
+}
+
+// replyBroker 返回当前回复通道
+func (m *QueueManager) replyBroker() ReplyBroker {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.replies
+}
+
+// Call 分发任务并等待处理器通过 JobContext.Reply 回复
+//
+// 超时后返回 ErrCallTimeout，任务仍会继续执行，之后的回复被丢弃；
+// 处理器未回复就失败或进程崩溃时，调用方同样在超时后返回。处理器需通过 QueueManager.Register 注册
+//
+//	result, err := queues.Call(ctx, "report:generate", payload, 30*time.Second)
+//	var out struct{ URL string }
+//	err = result.Decode(&out)
+func (m *QueueManager) Call(ctx context.Context, jobName string, payload map[string]interface{}, timeout time.Duration, opts ...DispatchOption) (Result, error) {
+	broker := m.replyBroker()
+	if broker == nil {
+		return Result{}, ErrNoReplyReceiver
+	}
+
+	id := uuid.NewString()
+	sub, err := broker.Subscribe(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+	defer sub.Close()
+
+	callPayload := make(map[string]interface{}, len(payload)+1)
+	for k, v := range payload {
+		callPayload[k] = v
+	}
+	callPayload[CorrelationIDKey] = id
+	if _, err := m.Dispatch(ctx, jobName, callPayload, opts...); err != nil {
+		return Result{}, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := sub.Receive(callCtx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return Result{}, fmt.Errorf("%w: %s (%s)", ErrCallTimeout, jobName, timeout)
+		}
+		return Result{}, err
+	}
+	if result.Error != "" {
+		return result, fmt.Errorf("%w: %s", ErrCallFailed, result.Error)
+	}
+	return result, nil
+}
+
+// jobContextKey JobContext 在 context 中的键
+type jobContextKey struct{}
+
+// JobContext 任务执行上下文，通过 JobContextFrom 在处理器中获取
+type JobContext struct {
+	Job *Job
+
+	// Metadata 分发任务时写入的元数据
+	Metadata Metadata
+
+	broker  ReplyBroker
+	replied atomic.Bool
+}
+
+// JobContextFrom 获取任务执行上下文，处理器未通过 QueueManager.Register 注册时返回false
+func JobContextFrom(ctx context.Context) (*JobContext, bool) {
+	jc, ok := ctx.Value(jobContextKey{}).(*JobContext)
+	return jc, ok
+}
+
+// IsCall 任务是否通过 Call 分发，需要回复
+func (jc *JobContext) IsCall() bool {
+	_, ok := jc.Job.Payload[CorrelationIDKey].(string)
+	return ok
+}
+
+// Reply 回复调用方，v 编码为JSON；每个任务只能回复一次
+func (jc *JobContext) Reply(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("编码任务回复失败: %w", err)
+	}
+	return jc.publish(Result{Data: data})
+}
+
+// ReplyError 以错误回复调用方，Call 返回包装了 ErrCallFailed 的错误
+func (jc *JobContext) ReplyError(err error) error {
+	return jc.publish(Result{Error: err.Error()})
+}
+
+// publish 发送回复
+func (jc *JobContext) publish(result Result) error {
+	id, ok := jc.Job.Payload[CorrelationIDKey].(string)
+	if !ok {
+		return ErrNotCallJob
+	}
+	if jc.broker == nil {
+		return ErrNoReplyReceiver
+	}
+	if !jc.replied.CompareAndSwap(false, true) {
+		return ErrAlreadyReplied
+	}
+	return jc.broker.Publish(context.Background(), id, result)
+}
+
+// withJobContext 包装处理器，在 context 中注入 JobContext，已由 Execute 创建时复用
+func (m *QueueManager) withJobContext(handler Handler) Handler {
+	return func(ctx context.Context, job *Job) error {
+		jc := jobContext(ctx, job)
+		jc.broker = m.replyBroker()
+		return handler(context.WithValue(ctx, jobContextKey{}, jc), job)
+	}
+}
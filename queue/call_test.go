@@ -0,0 +1,154 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/queue"
+	"github.com/zzliekkas/flow/v2/queue/memory"
+)
+
+// newCallTestManager 创建使用内存队列的管理器，并在后台持续处理任务
+func newCallTestManager(t *testing.T, workers int) (*queue.QueueManager, *memory.MemoryQueue) {
+	mq := memory.New(0)
+	manager := queue.NewQueueManager()
+	require.NoError(t, manager.AddQueue("default", mq))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if ok, _ := mq.TryProcessNext(ctx, "default"); !ok {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}()
+	}
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	return manager, mq
+}
+
+type report struct {
+	URL   string `json:"url"`
+	Pages int    `json:"pages"`
+}
+
+func TestCall_TypedReply(t *testing.T) {
+	manager, _ := newCallTestManager(t, 1)
+	manager.Register("report", func(ctx context.Context, job *queue.Job) error {
+		jc, ok := queue.JobContextFrom(ctx)
+		require.True(t, ok)
+		require.True(t, jc.IsCall())
+		require.NoError(t, jc.Reply(report{URL: fmt.Sprintf("/reports/%v.pdf", job.Payload["id"]), Pages: 3}))
+		assert.ErrorIs(t, jc.Reply("again"), queue.ErrAlreadyReplied)
+		return nil
+	})
+
+	result, err := manager.Call(context.Background(), "report", map[string]interface{}{"id": 42}, time.Second)
+	require.NoError(t, err)
+	var out report
+	require.NoError(t, result.Decode(&out))
+	assert.Equal(t, report{URL: "/reports/42.pdf", Pages: 3}, out)
+}
+
+func TestCall_ReplyError(t *testing.T) {
+	manager, _ := newCallTestManager(t, 1)
+	manager.Register("report", func(ctx context.Context, job *queue.Job) error {
+		jc, _ := queue.JobContextFrom(ctx)
+		return jc.ReplyError(errors.New("数据源不可用"))
+	})
+
+	_, err := manager.Call(context.Background(), "report", nil, time.Second)
+	assert.ErrorIs(t, err, queue.ErrCallFailed)
+	assert.Contains(t, err.Error(), "数据源不可用")
+}
+
+func TestCall_TimeoutWhileJobRuns(t *testing.T) {
+	manager, _ := newCallTestManager(t, 1)
+	release := make(chan struct{})
+	lateReply := make(chan error, 1)
+	manager.Register("slow", func(ctx context.Context, job *queue.Job) error {
+		<-release
+		jc, _ := queue.JobContextFrom(ctx)
+		lateReply <- jc.Reply("done")
+		return nil
+	})
+
+	_, err := manager.Call(context.Background(), "slow", nil, 50*time.Millisecond)
+	assert.ErrorIs(t, err, queue.ErrCallTimeout)
+
+	// 任务继续执行，超时后的回复被丢弃而不报错
+	close(release)
+	assert.NoError(t, <-lateReply)
+}
+
+func TestCall_WorkerFailsBeforeReply(t *testing.T) {
+	manager, _ := newCallTestManager(t, 1)
+	manager.Register("crash", func(ctx context.Context, job *queue.Job) error {
+		return errors.New("worker crashed")
+	})
+
+	start := time.Now()
+	_, err := manager.Call(context.Background(), "crash", nil, 100*time.Millisecond)
+	assert.ErrorIs(t, err, queue.ErrCallTimeout)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestCall_NonCallJobCannotReply(t *testing.T) {
+	manager, mq := newCallTestManager(t, 0)
+	replyErr := make(chan error, 1)
+	manager.Register("plain", func(ctx context.Context, job *queue.Job) error {
+		jc, _ := queue.JobContextFrom(ctx)
+		replyErr <- jc.Reply("x")
+		return nil
+	})
+
+	_, err := manager.Push(context.Background(), "plain", map[string]interface{}{})
+	require.NoError(t, err)
+	require.NoError(t, mq.ProcessNext(context.Background(), "default"))
+	assert.ErrorIs(t, <-replyErr, queue.ErrNotCallJob)
+}
+
+func TestCall_ConcurrentCallsDoNotCrosstalk(t *testing.T) {
+	manager, _ := newCallTestManager(t, 16)
+	manager.Register("echo", func(ctx context.Context, job *queue.Job) error {
+		jc, _ := queue.JobContextFrom(ctx)
+		return jc.Reply(job.Payload["n"])
+	})
+
+	const calls = 1000
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			result, err := manager.Call(context.Background(), "echo", map[string]interface{}{"n": n}, 10*time.Second)
+			if err != nil {
+				errs <- err
+				return
+			}
+			var got int
+			if err := result.Decode(&got); err != nil || got != n {
+				errs <- fmt.Errorf("调用 %d 收到 %d (%v)", n, got, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
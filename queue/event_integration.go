@@ -173,3 +173,17 @@ func (m *QueueManager) RegisterWithMiddleware(jobName string, handler Handler, m
 	// 注册包装后的处理器
 	m.Register(jobName, wrappedHandler)
 }
+
+// FailureEventsMiddleware 创建一个在任务失败时发布 event.QueueJobFailed 的中间件
+// publisher为nil或没有监听器时不产生任何开销
+func FailureEventsMiddleware(publisher *event.Publisher) QueueMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) error {
+			err := next(ctx, job)
+			if err != nil && publisher.Enabled(event.EventQueueJobFailed) {
+				publisher.Publish(event.NewQueueJobFailed(job.Queue, job.Name, err))
+			}
+			return err
+		}
+	}
+}
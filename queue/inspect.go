@@ -0,0 +1,123 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRevealNotAuthorized 没有查看受保护负载的权限
+var ErrRevealNotAuthorized = errors.New("queue: 没有查看任务负载的权限")
+
+// JobView 失败任务浏览器中展示的任务
+type JobView struct {
+	ID         string                 `json:"id"`
+	Queue      string                 `json:"queue"`
+	Name       string                 `json:"name"`
+	Status     JobStatus              `json:"status"`
+	Attempts   int                    `json:"attempts"`
+	Error      string                 `json:"error,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+	Metadata   Metadata               `json:"metadata"`
+	Protection string                 `json:"protection"`       // 负载保护策略：none、sign 或 encrypt
+	KeyID      string                 `json:"key_id,omitempty"` // 保护负载使用的密钥ID
+	Payload    map[string]interface{} `json:"payload"`          // 受保护且未揭示的负载为 nil
+	Revealed   bool                   `json:"revealed"`         // 是否为揭示后的负载
+}
+
+// Masked 负载是否被隐藏
+func (v JobView) Masked() bool {
+	return v.Payload == nil && v.Protection != Unprotected.String()
+}
+
+// RevealAuthorizer 揭示受保护负载前的第二道权限检查，返回错误时拒绝
+type RevealAuthorizer func(ctx context.Context, job *Job) error
+
+// Inspector 查看队列中的任务，默认只展示元数据，受保护的负载需通过 Reveal 揭示
+type Inspector struct {
+	keys      *PayloadKeys
+	authorize RevealAuthorizer
+}
+
+// NewInspector 创建任务查看器，authorize 为 nil 时禁止揭示受保护的负载
+func NewInspector(keys *PayloadKeys, authorize RevealAuthorizer) *Inspector {
+	return &Inspector{keys: keys, authorize: authorize}
+}
+
+// View 返回任务的展示信息，受保护的负载不解密，只显示保护策略与密钥ID
+func (i *Inspector) View(job *Job) JobView {
+	view := JobView{
+		ID:         job.ID,
+		Queue:      job.Queue,
+		Name:       job.Name,
+		Status:     job.Status,
+		Attempts:   job.Attempts,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		UpdatedAt:  job.UpdatedAt,
+		Metadata:   metadataOf(job),
+		Protection: Unprotected.String(),
+	}
+
+	env, err := parseEnvelope(job.Payload)
+	switch {
+	case errors.Is(err, ErrPayloadNotProtected):
+		view.Payload = job.Payload
+	case err != nil:
+		view.Protection = "unknown"
+	default:
+		view.Protection = env.protection.String()
+		view.KeyID = env.keyID
+	}
+	return view
+}
+
+// Reveal 通过权限检查后返回包含还原负载的展示信息
+func (i *Inspector) Reveal(ctx context.Context, job *Job) (JobView, error) {
+	view := i.View(job)
+	if !view.Masked() {
+		return view, nil
+	}
+	if i.authorize == nil {
+		return view, ErrRevealNotAuthorized
+	}
+	if err := i.authorize(ctx, job); err != nil {
+		return view, fmt.Errorf("%w: %v", ErrRevealNotAuthorized, err)
+	}
+
+	payload, err := i.keys.open(job.Name, job.Payload)
+	if err != nil {
+		return view, err
+	}
+	view.Payload = payload
+	view.Revealed = true
+	return view, nil
+}
+
+// Stats 返回各队列的长度，worker 不为 nil 时合并其运行中、已处理与吞吐量统计
+// 吞吐量只能由运行中的工作进程统计，未传入 worker 时为0
+func (i *Inspector) Stats(ctx context.Context, q Queue, names []string, worker *Worker) ([]QueueStats, error) {
+	byName := make(map[string]QueueStats)
+	if worker != nil {
+		for _, s := range worker.Stats(ctx) {
+			byName[s.Name] = s
+		}
+	}
+
+	stats := make([]QueueStats, 0, len(names))
+	for _, name := range names {
+		s, ok := byName[name]
+		if !ok {
+			s = QueueStats{Name: name}
+		}
+		depth, err := q.Size(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("queue: 获取队列 %s 的长度失败: %w", name, err)
+		}
+		s.Depth = depth
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
@@ -0,0 +1,456 @@
+// Package jetstream 提供基于 NATS JetStream 的队列驱动
+//
+// 任务记录保存在 JetStream 键值存储中，流中的消息只携带任务ID，每个队列对应一个主题与一个持久消费者。
+// 延迟与重试通过 NakWithDelay 让 JetStream 延后重新投递，重试次数用尽的消息以 Term 结束，
+// 因此工作进程崩溃时未确认的消息会在 AckWait 之后重新投递，AckWait 应大于最长任务的执行时间。
+//
+// 连接 NATS 的 New 依赖 github.com/nats-io/nats.go，只在使用 jetstream 构建标签时编译：
+//
+//	go get github.com/nats-io/nats.go
+//	go build -tags jetstream ./...
+//
+// 不使用构建标签时可以通过 NewWithStream 接入自行实现的 Stream 与 JobStore
+package jetstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zzliekkas/flow/v2/queue"
+)
+
+// ErrNoMessages 等待时间内队列中没有可投递的消息
+var ErrNoMessages = errors.New("jetstream: 队列中没有消息")
+
+// Stream 驱动使用的 JetStream 流操作，New 返回基于 nats.go 的实现
+type Stream interface {
+	// Publish 向队列的主题发布消息，msgID 用于 JetStream 的重复消息检测
+	Publish(ctx context.Context, queueName string, data []byte, msgID string) error
+
+	// Next 从队列的持久消费者取下一条消息，wait 内没有消息时返回 ErrNoMessages
+	Next(ctx context.Context, queueName string, wait time.Duration) (Message, error)
+
+	// Purge 删除队列主题中的全部消息
+	Purge(ctx context.Context, queueName string) error
+
+	// Pending 返回队列主题中的消息数，包括等待中、延迟中与未确认的消息
+	Pending(ctx context.Context, queueName string) (int, error)
+}
+
+// Message 从消费者取出的消息，方法与 nats.go 的 jetstream.Msg 一致
+type Message interface {
+	// Data 返回消息内容
+	Data() []byte
+
+	// Ack 确认消息，消息从工作队列中移除
+	Ack() error
+
+	// NakWithDelay 否认消息，delay 之后重新投递
+	NakWithDelay(delay time.Duration) error
+
+	// Term 终止消息，不再投递
+	Term() error
+}
+
+// JobStore 任务记录存储，New 使用 JetStream 键值存储
+type JobStore interface {
+	// Get 读取任务记录，不存在时返回 queue.ErrJobNotFound
+	Get(ctx context.Context, jobID string) ([]byte, error)
+
+	// Put 写入任务记录
+	Put(ctx context.Context, jobID string, data []byte) error
+
+	// Delete 删除任务记录
+	Delete(ctx context.Context, jobID string) error
+}
+
+// Options JetStream 队列配置选项
+type Options struct {
+	// NATS 服务器地址
+	URL string
+	// 任务流名称
+	Stream string
+	// 任务主题前缀，队列 default 的主题为 "<Subject>.default"
+	Subject string
+	// 任务记录的键值存储名称
+	Bucket string
+	// 任务记录的保存时间
+	RecordTTL time.Duration
+	// 消息的确认等待时间，超过后未确认的消息重新投递
+	AckWait time.Duration
+	// TryProcessNext 等待消息的最长时间
+	FetchWait time.Duration
+	// 最大重试次数
+	MaxRetries int
+}
+
+// DefaultOptions 返回默认配置选项
+func DefaultOptions() Options {
+	return Options{
+		URL:        "nats://localhost:4222",
+		Stream:     "FLOW_JOBS",
+		Subject:    "flow.jobs",
+		Bucket:     "flow_jobs",
+		RecordTTL:  7 * 24 * time.Hour,
+		AckWait:    5 * time.Minute,
+		FetchWait:  200 * time.Millisecond,
+		MaxRetries: 3,
+	}
+}
+
+// JetStreamQueue 是基于 NATS JetStream 的队列实现
+type JetStreamQueue struct {
+	// 任务消息流
+	stream Stream
+	// 任务记录存储
+	store JobStore
+	// 配置选项
+	options Options
+	// 任务处理器映射
+	handlers map[string]queue.Handler
+	// 工作进程上下文和取消函数
+	workerContexts map[string]context.CancelFunc
+	// 关闭底层连接，由 New 设置
+	closer func() error
+	// 互斥锁，保证并发安全
+	mu sync.RWMutex
+}
+
+// NewWithStream 使用指定的流与任务记录存储创建队列，options 中未设置的字段使用默认值
+func NewWithStream(stream Stream, store JobStore, options Options) *JetStreamQueue {
+	defaults := DefaultOptions()
+	if options.FetchWait <= 0 {
+		options.FetchWait = defaults.FetchWait
+	}
+	if options.MaxRetries <= 0 {
+		options.MaxRetries = defaults.MaxRetries
+	}
+	return &JetStreamQueue{
+		stream:         stream,
+		store:          store,
+		options:        options,
+		handlers:       make(map[string]queue.Handler),
+		workerContexts: make(map[string]context.CancelFunc),
+	}
+}
+
+// Push 将任务推送到队列
+func (q *JetStreamQueue) Push(ctx context.Context, queueName string, jobName string, payload map[string]interface{}) (string, error) {
+	return q.enqueue(ctx, queueName, jobName, payload, nil)
+}
+
+// PushWithDelay 延迟执行任务
+func (q *JetStreamQueue) PushWithDelay(ctx context.Context, queueName string, jobName string, payload map[string]interface{}, delay time.Duration) (string, error) {
+	return q.Schedule(ctx, queueName, jobName, payload, time.Now().Add(delay))
+}
+
+// Schedule 计划在特定时间执行任务，消息立即发布，取出时未到执行时间则延后重新投递
+func (q *JetStreamQueue) Schedule(ctx context.Context, queueName string, jobName string, payload map[string]interface{}, scheduledAt time.Time) (string, error) {
+	return q.enqueue(ctx, queueName, jobName, payload, &scheduledAt)
+}
+
+// enqueue 保存任务记录并发布携带任务ID的消息
+func (q *JetStreamQueue) enqueue(ctx context.Context, queueName string, jobName string, payload map[string]interface{}, scheduledAt *time.Time) (string, error) {
+	now := time.Now()
+	job := &queue.Job{
+		ID:          uuid.New().String(),
+		Queue:       queueName,
+		Name:        jobName,
+		Payload:     payload,
+		MaxRetries:  q.options.MaxRetries,
+		Status:      queue.JobStatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ScheduledAt: scheduledAt,
+	}
+	if scheduledAt != nil {
+		job.Status = queue.JobStatusScheduled
+	}
+
+	if err := q.save(ctx, job); err != nil {
+		return "", err
+	}
+	if err := q.stream.Publish(ctx, queueName, []byte(job.ID), job.ID); err != nil {
+		return "", fmt.Errorf("发布任务到JetStream失败: %w", err)
+	}
+	return job.ID, nil
+}
+
+// Get 获取任务信息
+func (q *JetStreamQueue) Get(ctx context.Context, queueName string, jobID string) (*queue.Job, error) {
+	data, err := q.store.Get(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, queue.ErrJobNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("获取任务记录失败: %w", err)
+	}
+
+	var job queue.Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("解析任务数据失败: %w", err)
+	}
+	return &job, nil
+}
+
+// Delete 删除任务，流中对应的消息在下次取出时被丢弃
+func (q *JetStreamQueue) Delete(ctx context.Context, queueName string, jobID string) error {
+	if _, err := q.Get(ctx, queueName, jobID); err != nil {
+		return err
+	}
+	if err := q.store.Delete(ctx, jobID); err != nil {
+		return fmt.Errorf("删除任务记录失败: %w", err)
+	}
+	return nil
+}
+
+// Clear 清空队列中的消息，任务记录在 RecordTTL 后过期
+func (q *JetStreamQueue) Clear(ctx context.Context, queueName string) error {
+	if err := q.stream.Purge(ctx, queueName); err != nil {
+		return fmt.Errorf("清空队列失败: %w", err)
+	}
+	return nil
+}
+
+// Size 获取队列大小，包括等待中、计划中与处理中的任务
+func (q *JetStreamQueue) Size(ctx context.Context, queueName string) (int, error) {
+	n, err := q.stream.Pending(ctx, queueName)
+	if err != nil {
+		return 0, fmt.Errorf("获取队列大小失败: %w", err)
+	}
+	return n, nil
+}
+
+// Register 注册任务处理器
+func (q *JetStreamQueue) Register(jobName string, handler queue.Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.handlers[jobName] = handler
+}
+
+// ProcessNext 处理队列中的下一个任务
+func (q *JetStreamQueue) ProcessNext(ctx context.Context, queueName string) error {
+	_, err := q.TryProcessNext(ctx, queueName)
+	return err
+}
+
+// TryProcessNext 处理队列中的下一个任务，返回是否取到了任务
+//
+// 已删除的任务与未到执行时间的任务不算取到，继续取下一条消息，直到 FetchWait 内没有消息
+func (q *JetStreamQueue) TryProcessNext(ctx context.Context, queueName string) (bool, error) {
+	for {
+		msg, err := q.stream.Next(ctx, queueName, q.options.FetchWait)
+		if errors.Is(err, ErrNoMessages) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("从JetStream获取任务失败: %w", err)
+		}
+
+		job, err := q.Get(ctx, queueName, string(msg.Data()))
+		if errors.Is(err, queue.ErrJobNotFound) {
+			// 任务已删除或记录已过期
+			_ = msg.Term()
+			continue
+		}
+		if err != nil {
+			_ = msg.NakWithDelay(time.Second)
+			return true, err
+		}
+
+		if job.ScheduledAt != nil {
+			if delay := time.Until(*job.ScheduledAt); delay > 0 {
+				if err := msg.NakWithDelay(delay); err != nil {
+					return false, fmt.Errorf("延后任务失败: %w", err)
+				}
+				continue
+			}
+		}
+
+		return true, q.process(ctx, msg, job)
+	}
+}
+
+// process 执行任务并按结果确认、延后重新投递或终止消息
+func (q *JetStreamQueue) process(ctx context.Context, msg Message, job *queue.Job) error {
+	q.mu.RLock()
+	handler, exists := q.handlers[job.Name]
+	q.mu.RUnlock()
+
+	if !exists {
+		// 任务处理器不存在，将任务标记为失败
+		job.Status = queue.JobStatusFailed
+		job.Error = "任务处理器不存在"
+		job.UpdatedAt = time.Now()
+		_ = q.save(ctx, job)
+		_ = msg.Term()
+		return errors.New("任务处理器不存在")
+	}
+
+	// 更新任务状态为处理中
+	now := time.Now()
+	job.Status = queue.JobStatusRunning
+	job.Attempts++
+	job.StartedAt = &now
+	job.UpdatedAt = now
+	if err := q.save(ctx, job); err != nil {
+		_ = msg.NakWithDelay(time.Second)
+		return err
+	}
+
+	// 执行任务
+	err := queue.Execute(ctx, handler, job)
+
+	finishTime := time.Now()
+	job.UpdatedAt = finishTime
+	job.FinishedAt = &finishTime
+
+	if err == nil {
+		job.Status = queue.JobStatusCompleted
+		job.ScheduledAt = nil
+		if saveErr := q.save(ctx, job); saveErr != nil {
+			log.Printf("保存已完成任务 %s 失败: %v", job.ID, saveErr)
+		}
+		if ackErr := msg.Ack(); ackErr != nil {
+			return fmt.Errorf("确认任务消息失败: %w", ackErr)
+		}
+		return nil
+	}
+
+	job.Error = err.Error()
+	if job.Attempts < job.MaxRetries {
+		// 还可以重试，延迟重试时间随尝试次数增加
+		delay := time.Duration(job.Attempts*5) * time.Second
+		retryAt := finishTime.Add(delay)
+		job.Status = queue.JobStatusRetrying
+		job.ScheduledAt = &retryAt
+		if saveErr := q.save(ctx, job); saveErr != nil {
+			log.Printf("保存重试任务 %s 失败: %v", job.ID, saveErr)
+		}
+		if nakErr := msg.NakWithDelay(delay); nakErr != nil {
+			return fmt.Errorf("安排任务重试失败: %w", nakErr)
+		}
+		return err
+	}
+
+	// 不再重试，将任务标记为失败
+	job.Status = queue.JobStatusFailed
+	if saveErr := q.save(ctx, job); saveErr != nil {
+		log.Printf("保存失败任务 %s 失败: %v", job.ID, saveErr)
+	}
+	if termErr := msg.Term(); termErr != nil {
+		return fmt.Errorf("终止任务消息失败: %w", termErr)
+	}
+	return err
+}
+
+// StartWorker 启动工作进程
+func (q *JetStreamQueue) StartWorker(ctx context.Context, queueName string, concurrency int) error {
+	q.mu.Lock()
+
+	// 如果已有工作进程在运行，先停止
+	if cancel, exists := q.workerContexts[queueName]; exists {
+		cancel()
+	}
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	q.workerContexts[queueName] = cancel
+
+	q.mu.Unlock()
+
+	for i := 0; i < concurrency; i++ {
+		go func(workerID int) {
+			for workerCtx.Err() == nil {
+				processed, err := q.TryProcessNext(workerCtx, queueName)
+				if err != nil {
+					log.Printf("工作进程 %d 处理任务失败: %v", workerID, err)
+				}
+				if !processed {
+					// TryProcessNext 已等待 FetchWait，失败时稍作等待避免空转
+					select {
+					case <-workerCtx.Done():
+					case <-time.After(q.options.FetchWait):
+					}
+				}
+			}
+		}(i)
+	}
+
+	return nil
+}
+
+// StopWorker 停止工作进程
+func (q *JetStreamQueue) StopWorker(ctx context.Context, queueName string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if cancel, exists := q.workerContexts[queueName]; exists {
+		cancel()
+		delete(q.workerContexts, queueName)
+		return nil
+	}
+
+	return queue.ErrQueueNotFound
+}
+
+// Retry 重试失败的任务
+func (q *JetStreamQueue) Retry(ctx context.Context, queueName string, jobID string) error {
+	job, err := q.Get(ctx, queueName, jobID)
+	if err != nil {
+		return err
+	}
+
+	// 只能重试失败的任务
+	if job.Status != queue.JobStatusFailed {
+		return errors.New("只能重试失败的任务")
+	}
+
+	job.Status = queue.JobStatusPending
+	job.Error = ""
+	job.ScheduledAt = nil
+	job.UpdatedAt = time.Now()
+	if err := q.save(ctx, job); err != nil {
+		return err
+	}
+
+	// 之前的消息已终止，以新的消息ID重新发布，避免被重复消息检测丢弃
+	if err := q.stream.Publish(ctx, queueName, []byte(job.ID), job.ID+":"+uuid.New().String()); err != nil {
+		return fmt.Errorf("重试任务失败: %w", err)
+	}
+	return nil
+}
+
+// Close 停止所有工作进程并关闭连接
+func (q *JetStreamQueue) Close() error {
+	q.mu.Lock()
+	for _, cancel := range q.workerContexts {
+		cancel()
+	}
+	q.workerContexts = make(map[string]context.CancelFunc)
+	closer := q.closer
+	q.mu.Unlock()
+
+	if closer != nil {
+		return closer()
+	}
+	return nil
+}
+
+// save 序列化并写入任务记录
+func (q *JetStreamQueue) save(ctx context.Context, job *queue.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("序列化任务失败: %w", err)
+	}
+	if err := q.store.Put(ctx, job.ID, data); err != nil {
+		return fmt.Errorf("保存任务记录失败: %w", err)
+	}
+	return nil
+}
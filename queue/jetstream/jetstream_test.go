@@ -0,0 +1,355 @@
+package jetstream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/queue"
+)
+
+// 编译期检查接口实现
+var (
+	_ queue.Queue     = (*JetStreamQueue)(nil)
+	_ queue.Processor = (*JetStreamQueue)(nil)
+)
+
+// fakeStream 模拟工作队列保留策略的流：确认或终止后删除消息，否认后延迟重新投递
+type fakeStream struct {
+	mu       sync.Mutex
+	messages map[string][]*fakeMessage
+	seen     map[string]bool
+}
+
+type fakeMessage struct {
+	stream      *fakeStream
+	queueName   string
+	data        []byte
+	availableAt time.Time
+	inFlight    bool
+	delays      []time.Duration
+}
+
+func newFakeStream() *fakeStream {
+	return &fakeStream{messages: make(map[string][]*fakeMessage), seen: make(map[string]bool)}
+}
+
+func (s *fakeStream) Publish(ctx context.Context, queueName string, data []byte, msgID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[msgID] {
+		return nil
+	}
+	s.seen[msgID] = true
+	s.messages[queueName] = append(s.messages[queueName], &fakeMessage{stream: s, queueName: queueName, data: data})
+	return nil
+}
+
+func (s *fakeStream) Next(ctx context.Context, queueName string, wait time.Duration) (Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, msg := range s.messages[queueName] {
+		if !msg.inFlight && !msg.availableAt.After(now) {
+			msg.inFlight = true
+			return msg, nil
+		}
+	}
+	return nil, ErrNoMessages
+}
+
+func (s *fakeStream) Purge(ctx context.Context, queueName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.messages, queueName)
+	return nil
+}
+
+func (s *fakeStream) Pending(ctx context.Context, queueName string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.messages[queueName]), nil
+}
+
+func (s *fakeStream) remove(target *fakeMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := s.messages[target.queueName]
+	for i, msg := range messages {
+		if msg == target {
+			s.messages[target.queueName] = append(messages[:i], messages[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m *fakeMessage) Data() []byte { return m.data }
+
+func (m *fakeMessage) Ack() error {
+	m.stream.remove(m)
+	return nil
+}
+
+func (m *fakeMessage) Term() error {
+	m.stream.remove(m)
+	return nil
+}
+
+func (m *fakeMessage) NakWithDelay(delay time.Duration) error {
+	m.stream.mu.Lock()
+	defer m.stream.mu.Unlock()
+	m.inFlight = false
+	m.availableAt = time.Now().Add(delay)
+	m.delays = append(m.delays, delay)
+	return nil
+}
+
+// fakeStore 内存任务记录存储
+type fakeStore struct {
+	mu      sync.Mutex
+	records map[string][]byte
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{records: make(map[string][]byte)}
+}
+
+func (s *fakeStore) Get(ctx context.Context, jobID string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.records[jobID]
+	if !ok {
+		return nil, queue.ErrJobNotFound
+	}
+	return data, nil
+}
+
+func (s *fakeStore) Put(ctx context.Context, jobID string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[jobID] = data
+	return nil
+}
+
+func (s *fakeStore) Delete(ctx context.Context, jobID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, jobID)
+	return nil
+}
+
+func newTestQueue() (*JetStreamQueue, *fakeStream) {
+	stream := newFakeStream()
+	return NewWithStream(stream, newFakeStore(), Options{MaxRetries: 2}), stream
+}
+
+func TestJetStreamQueue_PushAndProcess(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestQueue()
+
+	var got map[string]interface{}
+	q.Register("email", func(ctx context.Context, job *queue.Job) error {
+		got = job.Payload
+		return nil
+	})
+
+	id, err := q.Push(ctx, "default", "email", map[string]interface{}{"to": "a@example.com"})
+	require.NoError(t, err)
+
+	size, err := q.Size(ctx, "default")
+	require.NoError(t, err)
+	assert.Equal(t, 1, size)
+
+	processed, err := q.TryProcessNext(ctx, "default")
+	require.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, "a@example.com", got["to"])
+
+	job, err := q.Get(ctx, "default", id)
+	require.NoError(t, err)
+	assert.Equal(t, queue.JobStatusCompleted, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+
+	size, err = q.Size(ctx, "default")
+	require.NoError(t, err)
+	assert.Equal(t, 0, size)
+
+	processed, err = q.TryProcessNext(ctx, "default")
+	require.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestJetStreamQueue_RetryThenFail(t *testing.T) {
+	ctx := context.Background()
+	q, stream := newTestQueue()
+
+	handlerErr := errors.New("boom")
+	q.Register("flaky", func(ctx context.Context, job *queue.Job) error {
+		return handlerErr
+	})
+
+	id, err := q.Push(ctx, "default", "flaky", nil)
+	require.NoError(t, err)
+
+	processed, err := q.TryProcessNext(ctx, "default")
+	assert.True(t, processed)
+	assert.ErrorIs(t, err, handlerErr)
+
+	job, err := q.Get(ctx, "default", id)
+	require.NoError(t, err)
+	assert.Equal(t, queue.JobStatusRetrying, job.Status)
+	require.NotNil(t, job.ScheduledAt)
+
+	// 否认的消息延迟重新投递，延迟时间随尝试次数增加
+	msg := stream.messages["default"][0]
+	assert.Equal(t, []time.Duration{5 * time.Second}, msg.delays)
+
+	processed, err = q.TryProcessNext(ctx, "default")
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	// 模拟重新投递时间已到
+	msg.availableAt = time.Time{}
+	past := time.Now().Add(-time.Second)
+	job.ScheduledAt = &past
+	require.NoError(t, q.save(ctx, job))
+
+	processed, err = q.TryProcessNext(ctx, "default")
+	assert.True(t, processed)
+	assert.ErrorIs(t, err, handlerErr)
+
+	job, err = q.Get(ctx, "default", id)
+	require.NoError(t, err)
+	assert.Equal(t, queue.JobStatusFailed, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "boom", job.Error)
+
+	size, err := q.Size(ctx, "default")
+	require.NoError(t, err)
+	assert.Equal(t, 0, size, "重试用尽的消息应被终止")
+
+	// 重试失败的任务会以新的消息ID重新发布
+	require.NoError(t, q.Retry(ctx, "default", id))
+	size, err = q.Size(ctx, "default")
+	require.NoError(t, err)
+	assert.Equal(t, 1, size)
+
+	job, err = q.Get(ctx, "default", id)
+	require.NoError(t, err)
+	assert.Equal(t, queue.JobStatusPending, job.Status)
+
+	assert.Error(t, q.Retry(ctx, "default", id), "只能重试失败的任务")
+}
+
+func TestJetStreamQueue_MissingHandler(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestQueue()
+
+	id, err := q.Push(ctx, "default", "unknown", nil)
+	require.NoError(t, err)
+
+	processed, err := q.TryProcessNext(ctx, "default")
+	assert.True(t, processed)
+	assert.Error(t, err)
+
+	job, err := q.Get(ctx, "default", id)
+	require.NoError(t, err)
+	assert.Equal(t, queue.JobStatusFailed, job.Status)
+
+	size, err := q.Size(ctx, "default")
+	require.NoError(t, err)
+	assert.Equal(t, 0, size)
+}
+
+func TestJetStreamQueue_ScheduledJobIsDelayed(t *testing.T) {
+	ctx := context.Background()
+	q, stream := newTestQueue()
+
+	ran := false
+	q.Register("report", func(ctx context.Context, job *queue.Job) error {
+		ran = true
+		return nil
+	})
+
+	id, err := q.PushWithDelay(ctx, "default", "report", nil, time.Hour)
+	require.NoError(t, err)
+
+	job, err := q.Get(ctx, "default", id)
+	require.NoError(t, err)
+	assert.Equal(t, queue.JobStatusScheduled, job.Status)
+
+	processed, err := q.TryProcessNext(ctx, "default")
+	require.NoError(t, err)
+	assert.False(t, processed)
+	assert.False(t, ran)
+
+	msg := stream.messages["default"][0]
+	require.Len(t, msg.delays, 1)
+	assert.InDelta(t, time.Hour, msg.delays[0], float64(time.Second))
+}
+
+func TestJetStreamQueue_DeleteAndClear(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestQueue()
+
+	ran := 0
+	q.Register("job", func(ctx context.Context, job *queue.Job) error {
+		ran++
+		return nil
+	})
+
+	id, err := q.Push(ctx, "default", "job", nil)
+	require.NoError(t, err)
+	require.NoError(t, q.Delete(ctx, "default", id))
+	assert.ErrorIs(t, q.Delete(ctx, "default", id), queue.ErrJobNotFound)
+
+	// 已删除任务的消息被终止，不会执行
+	processed, err := q.TryProcessNext(ctx, "default")
+	require.NoError(t, err)
+	assert.False(t, processed)
+	assert.Equal(t, 0, ran)
+
+	size, err := q.Size(ctx, "default")
+	require.NoError(t, err)
+	assert.Equal(t, 0, size)
+
+	_, err = q.Push(ctx, "default", "job", nil)
+	require.NoError(t, err)
+	_, err = q.Push(ctx, "default", "job", nil)
+	require.NoError(t, err)
+	require.NoError(t, q.Clear(ctx, "default"))
+
+	size, err = q.Size(ctx, "default")
+	require.NoError(t, err)
+	assert.Equal(t, 0, size)
+}
+
+func TestJetStreamQueue_Worker(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestQueue()
+	defer q.Close()
+
+	done := make(chan string, 1)
+	q.Register("job", func(ctx context.Context, job *queue.Job) error {
+		done <- job.ID
+		return nil
+	})
+
+	id, err := q.Push(ctx, "default", "job", nil)
+	require.NoError(t, err)
+	require.NoError(t, q.StartWorker(ctx, "default", 1))
+
+	select {
+	case got := <-done:
+		assert.Equal(t, id, got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("工作进程未处理任务")
+	}
+
+	require.NoError(t, q.StopWorker(ctx, "default"))
+	assert.ErrorIs(t, q.StopWorker(ctx, "default"), queue.ErrQueueNotFound)
+}
@@ -0,0 +1,186 @@
+//go:build jetstream
+
+package jetstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/zzliekkas/flow/v2/queue"
+)
+
+// New 连接 NATS，创建（或更新）任务流与任务记录的键值存储，返回基于 JetStream 的队列
+//
+// 任务流使用工作队列保留策略，消息确认后即从流中删除；队列名称需要是合法的 NATS 主题片段
+func New(options Options) (*JetStreamQueue, error) {
+	defaults := DefaultOptions()
+	if options.URL == "" {
+		options.URL = defaults.URL
+	}
+	if options.Stream == "" {
+		options.Stream = defaults.Stream
+	}
+	if options.Subject == "" {
+		options.Subject = defaults.Subject
+	}
+	if options.Bucket == "" {
+		options.Bucket = defaults.Bucket
+	}
+	if options.RecordTTL <= 0 {
+		options.RecordTTL = defaults.RecordTTL
+	}
+	if options.AckWait <= 0 {
+		options.AckWait = defaults.AckWait
+	}
+
+	nc, err := nats.Connect(options.URL)
+	if err != nil {
+		return nil, fmt.Errorf("连接NATS失败: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("创建JetStream上下文失败: %w", err)
+	}
+
+	ctx := context.Background()
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      options.Stream,
+		Subjects:  []string{options.Subject + ".>"},
+		Retention: jetstream.WorkQueuePolicy,
+		Storage:   jetstream.FileStorage,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("创建任务流失败: %w", err)
+	}
+
+	kv, err := js.CreateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: options.Bucket, TTL: options.RecordTTL})
+	if errors.Is(err, jetstream.ErrBucketExists) {
+		kv, err = js.KeyValue(ctx, options.Bucket)
+	}
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("创建任务记录存储失败: %w", err)
+	}
+
+	q := NewWithStream(&natsStream{
+		js:        js,
+		stream:    stream,
+		subject:   options.Subject,
+		ackWait:   options.AckWait,
+		consumers: make(map[string]jetstream.Consumer),
+	}, natsStore{kv: kv}, options)
+	q.closer = func() error {
+		return nc.Drain()
+	}
+	return q, nil
+}
+
+// natsStream 基于 nats.go 的 Stream 实现，每个队列一个持久消费者
+type natsStream struct {
+	js      jetstream.JetStream
+	stream  jetstream.Stream
+	subject string
+	ackWait time.Duration
+
+	mu        sync.Mutex
+	consumers map[string]jetstream.Consumer
+}
+
+// subjectFor 返回队列的主题
+func (s *natsStream) subjectFor(queueName string) string {
+	return s.subject + "." + queueName
+}
+
+// Publish 实现 Stream
+func (s *natsStream) Publish(ctx context.Context, queueName string, data []byte, msgID string) error {
+	_, err := s.js.Publish(ctx, s.subjectFor(queueName), data, jetstream.WithMsgID(msgID))
+	return err
+}
+
+// Next 实现 Stream
+func (s *natsStream) Next(ctx context.Context, queueName string, wait time.Duration) (Message, error) {
+	consumer, err := s.consumer(ctx, queueName)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := consumer.Next(jetstream.FetchMaxWait(wait))
+	if errors.Is(err, nats.ErrTimeout) {
+		return nil, ErrNoMessages
+	}
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// consumer 返回队列的持久消费者，首次使用时创建；重试由驱动控制，消费者不限制投递次数
+func (s *natsStream) consumer(ctx context.Context, queueName string) (jetstream.Consumer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if consumer, ok := s.consumers[queueName]; ok {
+		return consumer, nil
+	}
+	consumer, err := s.stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       "flow_" + queueName,
+		FilterSubject: s.subjectFor(queueName),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       s.ackWait,
+		MaxDeliver:    -1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建队列 %s 的消费者失败: %w", queueName, err)
+	}
+	s.consumers[queueName] = consumer
+	return consumer, nil
+}
+
+// Purge 实现 Stream
+func (s *natsStream) Purge(ctx context.Context, queueName string) error {
+	return s.stream.Purge(ctx, jetstream.WithPurgeSubject(s.subjectFor(queueName)))
+}
+
+// Pending 实现 Stream
+func (s *natsStream) Pending(ctx context.Context, queueName string) (int, error) {
+	subject := s.subjectFor(queueName)
+	info, err := s.stream.Info(ctx, jetstream.WithSubjectFilter(subject))
+	if err != nil {
+		return 0, err
+	}
+	return int(info.State.Subjects[subject]), nil
+}
+
+// natsStore 基于 JetStream 键值存储的 JobStore 实现
+type natsStore struct {
+	kv jetstream.KeyValue
+}
+
+// Get 实现 JobStore
+func (s natsStore) Get(ctx context.Context, jobID string) ([]byte, error) {
+	entry, err := s.kv.Get(ctx, jobID)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, queue.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry.Value(), nil
+}
+
+// Put 实现 JobStore
+func (s natsStore) Put(ctx context.Context, jobID string, data []byte) error {
+	_, err := s.kv.Put(ctx, jobID, data)
+	return err
+}
+
+// Delete 实现 JobStore
+func (s natsStore) Delete(ctx context.Context, jobID string) error {
+	return s.kv.Delete(ctx, jobID)
+}
@@ -0,0 +1,302 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zzliekkas/flow/v2/flowctx"
+)
+
+// ErrJobPanicked 任务处理器发生 panic，由 RecoveryMiddleware 转换为任务失败
+var ErrJobPanicked = errors.New("queue: 任务处理器 panic")
+
+// MetadataKey 任务负载中保存分发元数据的键
+const MetadataKey = "_meta"
+
+// Metadata 分发任务时写入负载、执行任务时可恢复到 context 的元数据
+type Metadata struct {
+	RequestID   string `json:"request_id,omitempty"`
+	Tenant      string `json:"tenant,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+	TraceParent string `json:"traceparent,omitempty"` // W3C traceparent，用于把任务的 span 关联到分发时的追踪
+}
+
+// IsZero 判断元数据是否为空
+func (m Metadata) IsZero() bool {
+	return m == Metadata{}
+}
+
+// toMap 转换为负载中的表示，与经过 JSON 序列化后的形式一致
+func (m Metadata) toMap() map[string]interface{} {
+	values := make(map[string]interface{})
+	set := func(key, value string) {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	set("request_id", m.RequestID)
+	set("tenant", m.Tenant)
+	set("locale", m.Locale)
+	set("traceparent", m.TraceParent)
+	return values
+}
+
+// metadataOf 从任务负载中读取元数据
+func metadataOf(job *Job) Metadata {
+	values, ok := job.Payload[MetadataKey].(map[string]interface{})
+	if !ok {
+		return Metadata{}
+	}
+	get := func(key string) string {
+		s, _ := values[key].(string)
+		return s
+	}
+	return Metadata{
+		RequestID:   get("request_id"),
+		Tenant:      get("tenant"),
+		Locale:      get("locale"),
+		TraceParent: get("traceparent"),
+	}
+}
+
+// metadataKey Metadata 在 context 中的键
+type metadataKey struct{}
+
+// WithMetadata 将元数据保存到 context，之后通过 QueueManager 分发的任务会携带这些元数据
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// MetadataFrom 读取 context 中的元数据
+func MetadataFrom(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	return md
+}
+
+// MetadataProvider 分发任务时补充元数据，例如从 context 读取当前 span 写入 TraceParent
+type MetadataProvider func(ctx context.Context, md *Metadata)
+
+// AddMetadataProvider 添加分发任务时补充元数据的函数，按添加顺序执行
+func (m *QueueManager) AddMetadataProvider(provider MetadataProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metadataProviders = append(m.metadataProviders, provider)
+}
+
+// envelope 将元数据写入负载副本，没有元数据时原样返回
+//
+// WithMetadata 未设置的请求ID、租户与语言从 flowctx 读取，在请求中分发的任务自动携带请求的值
+func (m *QueueManager) envelope(ctx context.Context, payload map[string]interface{}) map[string]interface{} {
+	md := MetadataFrom(ctx)
+	values := flowctx.Import(ctx)
+	if md.RequestID == "" {
+		md.RequestID = values.RequestID
+	}
+	if md.Tenant == "" {
+		md.Tenant = values.TenantID
+	}
+	if md.Locale == "" {
+		md.Locale = values.Locale
+	}
+	m.mu.RLock()
+	providers := m.metadataProviders
+	m.mu.RUnlock()
+	for _, provider := range providers {
+		provider(ctx, &md)
+	}
+	if md.IsZero() {
+		return payload
+	}
+
+	wrapped := make(map[string]interface{}, len(payload)+1)
+	for k, v := range payload {
+		wrapped[k] = v
+	}
+	wrapped[MetadataKey] = md.toMap()
+	return wrapped
+}
+
+// Queue 任务所属队列
+func (jc *JobContext) Queue() string {
+	return jc.Job.Queue
+}
+
+// Attempt 当前是第几次执行，从1开始
+func (jc *JobContext) Attempt() int {
+	return jc.Job.Attempts
+}
+
+// EnqueuedAt 任务写入队列的时间
+func (jc *JobContext) EnqueuedAt() time.Time {
+	return jc.Job.CreatedAt
+}
+
+// CorrelationID 通过 Call 分发的任务的关联ID
+func (jc *JobContext) CorrelationID() string {
+	id, _ := jc.Job.Payload[CorrelationIDKey].(string)
+	return id
+}
+
+// middlewareChain 工作进程的任务中间件
+type middlewareChain struct {
+	global []QueueMiddleware
+	perJob map[string][]QueueMiddleware
+}
+
+// apply 按 全局中间件 → 任务中间件 → 处理器 的顺序包装处理器
+func (c *middlewareChain) apply(jobName string, handler Handler) Handler {
+	handler = ApplyMiddleware(handler, c.perJob[jobName]...)
+	return ApplyMiddleware(handler, c.global...)
+}
+
+// chainKey 中间件链在 context 中的键
+type chainKey struct{}
+
+// Use 添加作用于该工作进程处理的所有任务的中间件，先添加的位于外层
+func (w *Worker) Use(middlewares ...QueueMiddleware) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	chain := w.cloneChainLocked()
+	chain.global = append(chain.global, middlewares...)
+	w.chain = chain
+}
+
+// UseFor 添加只作用于指定任务的中间件，位于 Use 添加的中间件之内
+func (w *Worker) UseFor(jobName string, middlewares ...QueueMiddleware) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	chain := w.cloneChainLocked()
+	chain.perJob[jobName] = append(chain.perJob[jobName], middlewares...)
+	w.chain = chain
+}
+
+// cloneChainLocked 复制中间件链，执行中的任务继续使用旧链
+func (w *Worker) cloneChainLocked() *middlewareChain {
+	chain := &middlewareChain{perJob: make(map[string][]QueueMiddleware)}
+	if w.chain != nil {
+		chain.global = append(chain.global, w.chain.global...)
+		for name, list := range w.chain.perJob {
+			chain.perJob[name] = append([]QueueMiddleware(nil), list...)
+		}
+	}
+	return chain
+}
+
+// Execute 执行任务处理器，供队列驱动在取出任务后调用
+//
+// 在 context 中创建 JobContext，并应用 Worker.Use 与 Worker.UseFor 添加的中间件；
+// 任务不经过 Worker 调度时只创建 JobContext。驱动直接调用处理器时这些中间件不会生效
+func Execute(ctx context.Context, handler Handler, job *Job) error {
+	ctx = context.WithValue(ctx, jobContextKey{}, jobContext(ctx, job))
+	if chain, ok := ctx.Value(chainKey{}).(*middlewareChain); ok {
+		handler = chain.apply(job.Name, handler)
+	}
+	return handler(ctx, job)
+}
+
+// jobContext 返回当前任务的上下文，不存在时按任务创建
+func jobContext(ctx context.Context, job *Job) *JobContext {
+	if jc, ok := JobContextFrom(ctx); ok && jc.Job == job {
+		return jc
+	}
+	return &JobContext{Job: job, Metadata: metadataOf(job)}
+}
+
+// RecoveryMiddleware 创建将处理器 panic 转换为任务失败的中间件，任务按驱动的重试策略重新执行
+func RecoveryMiddleware() QueueMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("任务 %s (ID: %s) panic: %v\n%s", job.Name, job.ID, r, debug.Stack())
+					err = fmt.Errorf("%w: %v", ErrJobPanicked, r)
+				}
+			}()
+			return next(ctx, job)
+		}
+	}
+}
+
+// StructuredLoggingMiddleware 创建以结构化字段记录任务结果与耗时的中间件，logger 为nil时使用 logrus 默认实例
+func StructuredLoggingMiddleware(logger logrus.FieldLogger) QueueMiddleware {
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) error {
+			start := time.Now()
+			err := next(ctx, job)
+
+			jc := jobContext(ctx, job)
+			fields := logrus.Fields{
+				"queue":    job.Queue,
+				"job":      job.Name,
+				"job_id":   job.ID,
+				"attempt":  job.Attempts,
+				"duration": time.Since(start).String(),
+			}
+			if jc.Metadata.RequestID != "" {
+				fields["request_id"] = jc.Metadata.RequestID
+			}
+			if jc.Metadata.Tenant != "" {
+				fields["tenant"] = jc.Metadata.Tenant
+			}
+			if id := jc.CorrelationID(); id != "" {
+				fields["correlation_id"] = id
+			}
+
+			entry := logger.WithFields(fields)
+			if err != nil {
+				entry.WithError(err).Error("任务处理失败")
+			} else {
+				entry.Info("任务处理完成")
+			}
+			return err
+		}
+	}
+}
+
+// MetadataMiddleware 创建将分发时的元数据（请求ID、租户、语言等）恢复到任务 context 的中间件，
+// 处理器可通过 MetadataFrom 或 flowctx 读取，处理器中继续分发的任务也会携带这些元数据
+func MetadataMiddleware() QueueMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) error {
+			if md := jobContext(ctx, job).Metadata; !md.IsZero() {
+				ctx = WithMetadata(ctx, md)
+				values := flowctx.Import(ctx)
+				values.RequestID = md.RequestID
+				values.TenantID = md.Tenant
+				values.Locale = md.Locale
+				ctx = flowctx.With(ctx, values)
+			}
+			return next(ctx, job)
+		}
+	}
+}
+
+// Tracer 为任务执行创建追踪 span
+//
+// 框架暂未内置 OpenTelemetry 实现，应用可自行适配：用 propagation.TraceContext 从
+// propagation.MapCarrier{"traceparent": jc.Metadata.TraceParent} 提取分发时的 span 上下文，
+// 作为 tracer.Start 的父 span 或 link，返回的函数中按 err 设置状态后调用 span.End。
+// 返回的函数在任务结束时调用，err 为任务结果
+type Tracer interface {
+	StartJob(ctx context.Context, jc *JobContext) (context.Context, func(err error))
+}
+
+// TracingMiddleware 创建为每次任务执行创建 span 的中间件
+func TracingMiddleware(tracer Tracer) QueueMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) error {
+			ctx, end := tracer.StartJob(ctx, jobContext(ctx, job))
+			err := next(ctx, job)
+			end(err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/flowctx"
+	"github.com/zzliekkas/flow/v2/queue"
+	"github.com/zzliekkas/flow/v2/queue/memory"
+)
+
+func TestMetadataEnvelopeRoundTrip(t *testing.T) {
+	mq := memory.New(0)
+	manager := queue.NewQueueManager()
+	require.NoError(t, manager.AddQueue("default", mq))
+	manager.AddMetadataProvider(func(ctx context.Context, md *queue.Metadata) {
+		md.TraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	})
+
+	ctx := queue.WithMetadata(context.Background(), queue.Metadata{RequestID: "req-1", Tenant: "acme", Locale: "zh-CN"})
+	payload := map[string]interface{}{"to": "a@example.com"}
+	id, err := manager.Dispatch(ctx, "SendEmail", payload)
+	require.NoError(t, err)
+	assert.NotContains(t, payload, queue.MetadataKey, "调用方的负载不应被修改")
+
+	// 按 Redis 驱动的方式序列化后再执行
+	job, err := mq.Get(ctx, "default", id)
+	require.NoError(t, err)
+	data, err := json.Marshal(job)
+	require.NoError(t, err)
+	var stored queue.Job
+	require.NoError(t, json.Unmarshal(data, &stored))
+
+	var got queue.Metadata
+	var restored queue.Metadata
+	handler := queue.ApplyMiddleware(func(ctx context.Context, job *queue.Job) error {
+		jc, ok := queue.JobContextFrom(ctx)
+		require.True(t, ok)
+		got = jc.Metadata
+		restored = queue.MetadataFrom(ctx)
+		return nil
+	}, queue.MetadataMiddleware())
+	require.NoError(t, queue.Execute(context.Background(), handler, &stored))
+
+	want := queue.Metadata{
+		RequestID:   "req-1",
+		Tenant:      "acme",
+		Locale:      "zh-CN",
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, want, restored)
+}
+
+func TestMetadataFromRequestContext(t *testing.T) {
+	mq := memory.New(0)
+	manager := queue.NewQueueManager()
+	require.NoError(t, manager.AddQueue("default", mq))
+
+	// 请求中分发的任务自动携带请求ID与租户，WithMetadata 设置的值优先
+	ctx := flowctx.With(context.Background(), flowctx.Values{RequestID: "req-9", TenantID: "acme", Locale: "en"})
+	ctx = queue.WithMetadata(ctx, queue.Metadata{Locale: "zh-CN"})
+	id, err := manager.Dispatch(ctx, "SendEmail", nil)
+	require.NoError(t, err)
+	job, err := mq.Get(ctx, "default", id)
+	require.NoError(t, err)
+
+	var values flowctx.Values
+	handler := queue.ApplyMiddleware(func(ctx context.Context, job *queue.Job) error {
+		values = flowctx.Import(ctx)
+		return nil
+	}, queue.MetadataMiddleware())
+	require.NoError(t, queue.Execute(context.Background(), handler, job))
+	assert.Equal(t, flowctx.Values{RequestID: "req-9", TenantID: "acme", Locale: "zh-CN"}, values)
+}
+
+func TestMetadataNotAddedWhenEmpty(t *testing.T) {
+	mq := memory.New(0)
+	manager := queue.NewQueueManager()
+	require.NoError(t, manager.AddQueue("default", mq))
+
+	id, err := manager.Dispatch(context.Background(), "SendEmail", map[string]interface{}{"to": "a@example.com"})
+	require.NoError(t, err)
+	job, err := mq.Get(context.Background(), "default", id)
+	require.NoError(t, err)
+	assert.NotContains(t, job.Payload, queue.MetadataKey)
+}
+
+// runWorker 启动工作进程直到 done 关闭
+func runWorker(t *testing.T, w *queue.Worker, done <-chan struct{}) {
+	require.NoError(t, w.Start(context.Background()))
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("任务未在预期时间内完成")
+	}
+	w.Stop()
+}
+
+func TestWorkerMiddlewareOrder(t *testing.T) {
+	mq := memory.New(0)
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string) queue.QueueMiddleware {
+		return func(next queue.Handler) queue.Handler {
+			return func(ctx context.Context, job *queue.Job) error {
+				mu.Lock()
+				calls = append(calls, name+">"+job.Name)
+				mu.Unlock()
+				return next(ctx, job)
+			}
+		}
+	}
+
+	done := make(chan struct{})
+	var processed int
+	handler := func(ctx context.Context, job *queue.Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, "handler>"+job.Name)
+		if processed++; processed == 2 {
+			close(done)
+		}
+		return nil
+	}
+	mq.Register("SendEmail", handler)
+	mq.Register("Resize", handler)
+
+	w, err := queue.NewWorker(mq, []queue.QueueSpec{{Name: "default", Weight: 1}}, queue.WithPollInterval(time.Millisecond))
+	require.NoError(t, err)
+	w.Use(record("a"), record("b"))
+	w.UseFor("SendEmail", record("email"))
+	w.Use(record("c"))
+
+	_, err = mq.Push(context.Background(), "default", "SendEmail", nil)
+	require.NoError(t, err)
+	_, err = mq.Push(context.Background(), "default", "Resize", nil)
+	require.NoError(t, err)
+	runWorker(t, w, done)
+
+	assert.Equal(t, []string{
+		"a>SendEmail", "b>SendEmail", "c>SendEmail", "email>SendEmail", "handler>SendEmail",
+		"a>Resize", "b>Resize", "c>Resize", "handler>Resize",
+	}, calls)
+}
+
+func TestRecoveryMiddlewareRetriesPanics(t *testing.T) {
+	mq := memory.New(3)
+	done := make(chan struct{})
+	var retried queue.Job
+	mq.Register("Flaky", func(ctx context.Context, job *queue.Job) error {
+		if job.Attempts == 1 {
+			panic("boom")
+		}
+		retried = *job
+		close(done)
+		return nil
+	})
+
+	w, err := queue.NewWorker(mq, []queue.QueueSpec{{Name: "default", Weight: 1}}, queue.WithPollInterval(time.Millisecond))
+	require.NoError(t, err)
+	w.Use(queue.RecoveryMiddleware())
+
+	_, err = mq.Push(context.Background(), "default", "Flaky", nil)
+	require.NoError(t, err)
+	runWorker(t, w, done)
+
+	// panic 被当作普通失败，任务重新入队并再次执行
+	assert.Equal(t, 2, retried.Attempts)
+	assert.Contains(t, retried.Error, "boom")
+}
+
+func TestRecoveryMiddlewareReturnsError(t *testing.T) {
+	handler := queue.ApplyMiddleware(func(ctx context.Context, job *queue.Job) error {
+		panic("boom")
+	}, queue.RecoveryMiddleware())
+
+	err := handler(context.Background(), &queue.Job{Name: "Flaky"})
+	assert.True(t, errors.Is(err, queue.ErrJobPanicked))
+}
+
+// recordingTracer 记录每个 span 的父追踪与结果
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []recordedSpan
+}
+
+type recordedSpan struct {
+	job    string
+	parent string
+	err    error
+}
+
+type spanKey struct{}
+
+func (r *recordingTracer) StartJob(ctx context.Context, jc *queue.JobContext) (context.Context, func(error)) {
+	return context.WithValue(ctx, spanKey{}, jc.Job.Name), func(err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.spans = append(r.spans, recordedSpan{job: jc.Job.Name, parent: jc.Metadata.TraceParent, err: err})
+	}
+}
+
+func TestTracingMiddlewareLinksDispatchTrace(t *testing.T) {
+	mq := memory.New(0)
+	manager := queue.NewQueueManager()
+	require.NoError(t, manager.AddQueue("default", mq))
+
+	done := make(chan struct{})
+	manager.Register("Report", func(ctx context.Context, job *queue.Job) error {
+		assert.Equal(t, "Report", ctx.Value(spanKey{}), "处理器应运行在任务 span 中")
+		close(done)
+		return nil
+	})
+
+	tracer := &recordingTracer{}
+	w, err := queue.NewWorker(mq, []queue.QueueSpec{{Name: "default", Weight: 1}}, queue.WithPollInterval(time.Millisecond))
+	require.NoError(t, err)
+	w.Use(queue.TracingMiddleware(tracer))
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := queue.WithMetadata(context.Background(), queue.Metadata{TraceParent: traceParent})
+	_, err = manager.Dispatch(ctx, "Report", nil)
+	require.NoError(t, err)
+	runWorker(t, w, done)
+
+	require.Len(t, tracer.spans, 1)
+	assert.Equal(t, recordedSpan{job: "Report", parent: traceParent}, tracer.spans[0])
+}
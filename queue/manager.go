@@ -17,12 +17,18 @@ type QueueManager struct {
 	mu           sync.RWMutex
 	queues       map[string]Queue
 	defaultQueue string
+	replies      ReplyBroker
+
+	metadataProviders []MetadataProvider
+	payloadKeys       *PayloadKeys
+	strictProtection  bool // 受保护的任务类型拒绝没有信封的负载
 }
 
 // NewQueueManager 创建一个新的队列管理器
 func NewQueueManager() *QueueManager {
 	return &QueueManager{
-		queues: make(map[string]Queue),
+		queues:  make(map[string]Queue),
+		replies: NewMemoryReplyBroker(),
 	}
 }
 
@@ -154,6 +160,10 @@ func (m *QueueManager) Push(ctx context.Context, jobName string, payload map[str
 		return "", err
 	}
 
+	payload, err = m.protect(jobName, m.envelope(ctx, payload))
+	if err != nil {
+		return "", err
+	}
 	return queue.Push(ctx, m.defaultQueue, jobName, payload)
 }
 
@@ -164,6 +174,10 @@ func (m *QueueManager) PushWithDelay(ctx context.Context, jobName string, payloa
 		return "", err
 	}
 
+	payload, err = m.protect(jobName, m.envelope(ctx, payload))
+	if err != nil {
+		return "", err
+	}
 	return queue.PushWithDelay(ctx, m.defaultQueue, jobName, payload, delay)
 }
 
@@ -174,11 +188,18 @@ func (m *QueueManager) Schedule(ctx context.Context, jobName string, payload map
 		return "", err
 	}
 
+	payload, err = m.protect(jobName, m.envelope(ctx, payload))
+	if err != nil {
+		return "", err
+	}
 	return queue.Schedule(ctx, m.defaultQueue, jobName, payload, scheduledAt)
 }
 
-// Register 为所有队列注册同一个处理器
+// Register 为所有队列注册同一个处理器，处理器可通过 JobContextFrom 获取任务上下文，
+// 带信封的负载在调用处理器前验证并还原，见 Protect
 func (m *QueueManager) Register(jobName string, handler Handler) {
+	handler = m.withJobContext(m.withUnprotect(handler))
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -186,3 +207,80 @@ func (m *QueueManager) Register(jobName string, handler Handler) {
 		queue.Register(jobName, handler)
 	}
 }
+
+// Priority 任务优先级，每个优先级对应一个独立的队列
+type Priority int
+
+const (
+	PriorityLow    Priority = iota // 低优先级，对应bulk队列
+	PriorityNormal                 // 普通优先级，对应default队列
+	PriorityHigh                   // 高优先级，对应critical队列
+)
+
+// QueueName 返回优先级对应的队列名称
+func (p Priority) QueueName() string {
+	switch {
+	case p >= PriorityHigh:
+		return "critical"
+	case p <= PriorityLow:
+		return "bulk"
+	default:
+		return "default"
+	}
+}
+
+// dispatchOptions 分发选项
+type dispatchOptions struct {
+	queue string
+	delay time.Duration
+}
+
+// DispatchOption 分发任务的选项
+type DispatchOption func(*dispatchOptions)
+
+// WithQueue 指定任务写入的队列名称
+func WithQueue(name string) DispatchOption {
+	return func(o *dispatchOptions) {
+		o.queue = name
+	}
+}
+
+// WithPriority 按优先级选择队列，与WithQueue同时使用时以后设置的为准
+func WithPriority(p Priority) DispatchOption {
+	return func(o *dispatchOptions) {
+		o.queue = p.QueueName()
+	}
+}
+
+// WithDelay 延迟指定时间后执行
+func WithDelay(delay time.Duration) DispatchOption {
+	return func(o *dispatchOptions) {
+		o.delay = delay
+	}
+}
+
+// Dispatch 通过默认队列驱动分发任务，可通过选项指定目标队列与优先级
+// context 中的 Metadata 与 AddMetadataProvider 补充的元数据写入负载的 MetadataKey 键
+func (m *QueueManager) Dispatch(ctx context.Context, jobName string, payload map[string]interface{}, opts ...DispatchOption) (string, error) {
+	queue, err := m.GetDefaultQueue()
+	if err != nil {
+		return "", err
+	}
+
+	options := dispatchOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.queue == "" {
+		options.queue, _ = m.GetDefaultQueueName()
+	}
+	payload, err = m.protect(jobName, m.envelope(ctx, payload))
+	if err != nil {
+		return "", err
+	}
+
+	if options.delay > 0 {
+		return queue.PushWithDelay(ctx, options.queue, jobName, payload, options.delay)
+	}
+	return queue.Push(ctx, options.queue, jobName, payload)
+}
@@ -176,6 +176,12 @@ func (m *MemoryQueue) Register(jobName string, handler queue.Handler) {
 
 // ProcessNext 处理队列中的下一个任务
 func (m *MemoryQueue) ProcessNext(ctx context.Context, queueName string) error {
+	_, err := m.TryProcessNext(ctx, queueName)
+	return err
+}
+
+// TryProcessNext 处理队列中的下一个任务，返回是否取到了任务
+func (m *MemoryQueue) TryProcessNext(ctx context.Context, queueName string) (bool, error) {
 	m.mu.Lock()
 	// 首先检查是否有到期的计划任务
 	now := time.Now()
@@ -213,7 +219,8 @@ func (m *MemoryQueue) ProcessNext(ctx context.Context, queueName string) error {
 			job.Status = queue.JobStatusFailed
 			job.Error = "没有注册对应的任务处理器"
 			job.UpdatedAt = time.Now()
-			return errors.New("没有注册对应的任务处理器")
+			m.mu.Unlock()
+			return true, errors.New("没有注册对应的任务处理器")
 		}
 
 		// 更新任务状态
@@ -227,7 +234,7 @@ func (m *MemoryQueue) ProcessNext(ctx context.Context, queueName string) error {
 		m.mu.Unlock()
 
 		// 执行任务
-		err := handler(ctx, job)
+		err := queue.Execute(ctx, handler, job)
 
 		// 重新获取锁更新任务状态
 		m.mu.Lock()
@@ -253,11 +260,11 @@ func (m *MemoryQueue) ProcessNext(ctx context.Context, queueName string) error {
 		}
 
 		job.UpdatedAt = time.Now()
-		return err
+		return true, err
 	}
 
 	m.mu.Unlock()
-	return nil
+	return false, nil
 }
 
 // StartWorker 启动工作进程处理任务
@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// 任务处理状态标签
+const (
+	MetricStatusSuccess = "success"
+	MetricStatusFailed  = "failed"
+)
+
+// Metrics 队列指标接口，由metrics包实现，未设置时不产生任何开销
+type Metrics interface {
+	// JobProcessed 记录任务处理结果与耗时
+	JobProcessed(queue, jobType, status string, duration time.Duration)
+
+	// QueueDepth 记录采样得到的队列长度
+	QueueDepth(queue string, depth int)
+
+	// WorkerConcurrency 记录队列当前正在执行的任务数
+	WorkerConcurrency(queue string, running int)
+}
+
+// noopMetrics 空实现
+type noopMetrics struct{}
+
+func (noopMetrics) JobProcessed(string, string, string, time.Duration) {}
+func (noopMetrics) QueueDepth(string, int)                             {}
+func (noopMetrics) WorkerConcurrency(string, int)                      {}
+
+// MetricsMiddleware 创建记录任务处理指标的中间件
+func MetricsMiddleware(metrics Metrics) QueueMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) error {
+			start := time.Now()
+			err := next(ctx, job)
+
+			status := MetricStatusSuccess
+			if err != nil {
+				status = MetricStatusFailed
+			}
+			metrics.JobProcessed(job.Queue, job.Name, status, time.Since(start))
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package queue
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/zzliekkas/flow/v2/crypto"
+)
+
+// EnvelopeKey 受保护任务的负载中保存信封的键
+//
+// 受保护的任务负载只包含信封与元数据（MetadataKey、CorrelationIDKey），业务数据在信封中加密或签名，
+// 明文的元数据同样参与认证；没有信封的负载视为未保护的旧任务照常处理，启用严格模式后以 ErrPayloadNotProtected 失败
+const EnvelopeKey = "_envelope"
+
+// envelopeVersion 信封格式版本，同时认证业务数据与明文元数据；其他版本的信封以 ErrUnsupportedEnvelope 拒绝
+const envelopeVersion = 2
+
+// 负载保护相关错误，验证失败的任务直接标记为失败，不再重试
+var (
+	ErrPayloadTampered     = errors.New("queue: 任务负载签名无效")
+	ErrUnknownPayloadKey   = errors.New("queue: 任务负载使用了未知的密钥")
+	ErrUnsupportedEnvelope = errors.New("queue: 不支持的任务负载信封")
+	ErrPayloadKeysMissing  = errors.New("queue: 未配置任务负载密钥")
+	// ErrPayloadNotProtected 严格模式下受保护的任务类型收到没有信封的负载
+	ErrPayloadNotProtected = errors.New("queue: 任务负载未受保护")
+)
+
+// 各任务类型的保护策略
+var (
+	protectionsMu sync.RWMutex
+	protections   = make(map[string]Protection)
+	strictJobs    = make(map[string]bool)
+)
+
+// Protection 任务负载的保护策略
+type Protection int
+
+const (
+	// Unprotected 负载以明文保存
+	Unprotected Protection = iota
+	// Sign 负载以明文保存并签名，防止被篡改
+	Sign
+	// EncryptAndSign 负载使用 AES-256-GCM 加密并认证，适用于包含个人信息的任务
+	EncryptAndSign
+)
+
+// String 返回策略在信封中的名称
+func (p Protection) String() string {
+	switch p {
+	case Sign:
+		return "sign"
+	case EncryptAndSign:
+		return "encrypt"
+	}
+	return "none"
+}
+
+// parseProtection 解析信封中的策略名称
+func parseProtection(s string) (Protection, bool) {
+	switch s {
+	case "sign":
+		return Sign, true
+	case "encrypt":
+		return EncryptAndSign, true
+	}
+	return Unprotected, false
+}
+
+// Protect 设置任务类型的负载保护策略，分发与处理任务的进程需要设置相同的策略：
+//
+//	queue.Protect("SendPasswordReset", queue.EncryptAndSign)
+//
+// 受保护的任务通过 QueueManager 分发时写入信封，需要先调用 QueueManager.SetPayloadKeys；
+// 通过 QueueManager.Register 注册的处理器收到的是还原后的负载
+func Protect(jobName string, protection Protection) {
+	protectionsMu.Lock()
+	defer protectionsMu.Unlock()
+	delete(strictJobs, jobName)
+	if protection == Unprotected {
+		delete(protections, jobName)
+		return
+	}
+	protections[jobName] = protection
+}
+
+// ProtectStrict 与 Protect 相同，并对该任务类型启用严格模式：没有信封的负载不再处理，
+// 任务以 ErrPayloadNotProtected 失败。应在启用保护前写入的旧任务处理完之后再切换，
+// 所有任务类型统一切换时使用 QueueManager.SetStrictProtection
+func ProtectStrict(jobName string, protection Protection) {
+	Protect(jobName, protection)
+	if protection == Unprotected {
+		return
+	}
+	protectionsMu.Lock()
+	defer protectionsMu.Unlock()
+	strictJobs[jobName] = true
+}
+
+// ProtectionOf 返回任务类型的负载保护策略
+func ProtectionOf(jobName string) Protection {
+	protectionsMu.RLock()
+	defer protectionsMu.RUnlock()
+	return protections[jobName]
+}
+
+// payloadKey 一个负载密钥
+type payloadKey struct {
+	id        string
+	encrypter *crypto.Encrypter
+	signer    *crypto.Signer
+}
+
+// PayloadKeys 任务负载的密钥，第一个密钥保护新任务，其余密钥用于读取轮换前写入的任务
+//
+// 每个密钥的ID由密钥派生并写入信封，处理任务时按ID选择密钥，旧密钥移除后对应的任务以 ErrUnknownPayloadKey 失败
+type PayloadKeys struct {
+	current *payloadKey
+	byID    map[string]*payloadKey
+}
+
+// NewPayloadKeys 创建负载密钥，通常传入 crypto.LoadKeys 加载的应用密钥
+func NewPayloadKeys(keys [][]byte) (*PayloadKeys, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个密钥", crypto.ErrInvalidKey)
+	}
+	pk := &PayloadKeys{byID: make(map[string]*payloadKey, len(keys))}
+	for _, key := range keys {
+		encrypter, err := crypto.NewEncrypter([][]byte{key})
+		if err != nil {
+			return nil, err
+		}
+		signer, err := crypto.NewSigner([][]byte{key})
+		if err != nil {
+			return nil, err
+		}
+		k := &payloadKey{id: payloadKeyID(key), encrypter: encrypter, signer: signer}
+		if pk.current == nil {
+			pk.current = k
+		}
+		pk.byID[k.id] = k
+	}
+	return pk, nil
+}
+
+// CurrentKeyID 返回保护新任务使用的密钥ID
+func (pk *PayloadKeys) CurrentKeyID() string {
+	return pk.current.id
+}
+
+// payloadKeyID 由密钥派生密钥ID，不泄露密钥本身
+func payloadKeyID(key []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("flow.queue.key-id"))
+	return hex.EncodeToString(h.Sum(nil)[:4])
+}
+
+// envelopeAAD 参与认证的信封字段，密文与签名绑定到任务名称，不能挪用到其他任务类型；
+// 同时绑定负载中的明文元数据，租户、语言、追踪信息与关联ID被改写时验证失败
+func envelopeAAD(protection Protection, keyID, jobName string, payload map[string]interface{}) ([]byte, error) {
+	aad := fmt.Sprintf("flow.queue.v%d|%s|%s|%s", envelopeVersion, protection, keyID, jobName)
+	plain := make(map[string]interface{}, 2)
+	for _, k := range []string{MetadataKey, CorrelationIDKey} {
+		if v, ok := payload[k]; ok {
+			plain[k] = v
+		}
+	}
+	// encoding/json 按键排序输出，JSON 往返前后的编码一致
+	data, err := json.Marshal(plain)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return append([]byte(aad+"|"), data...), nil
+}
+
+// seal 将负载写入信封，元数据保持明文
+func (pk *PayloadKeys) seal(jobName string, protection Protection, payload map[string]interface{}) (map[string]interface{}, error) {
+	body := make(map[string]interface{}, len(payload))
+	sealed := make(map[string]interface{}, 3)
+	for k, v := range payload {
+		if k == MetadataKey || k == CorrelationIDKey {
+			sealed[k] = v
+			continue
+		}
+		body[k] = v
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	key := pk.current
+	aad, err := envelopeAAD(protection, key.id, jobName, sealed)
+	if err != nil {
+		return nil, err
+	}
+	env := map[string]interface{}{
+		"v":          envelopeVersion,
+		"protection": protection.String(),
+		"kid":        key.id,
+	}
+	if protection == EncryptAndSign {
+		ciphertext, err := key.encrypter.Seal(data, aad)
+		if err != nil {
+			return nil, err
+		}
+		env["body"] = base64.RawStdEncoding.EncodeToString(ciphertext)
+	} else {
+		env["body"] = string(data)
+		env["sig"] = base64.RawStdEncoding.EncodeToString(key.signer.MAC(append(aad, data...)))
+	}
+	sealed[EnvelopeKey] = env
+	return sealed, nil
+}
+
+// open 验证并还原信封中的负载，没有信封时返回 ErrPayloadNotProtected
+func (pk *PayloadKeys) open(jobName string, payload map[string]interface{}) (map[string]interface{}, error) {
+	env, err := parseEnvelope(payload)
+	if err != nil {
+		return nil, err
+	}
+	if pk == nil {
+		return nil, ErrPayloadKeysMissing
+	}
+	key, ok := pk.byID[env.keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPayloadKey, env.keyID)
+	}
+
+	aad, err := envelopeAAD(env.protection, env.keyID, jobName, payload)
+	if err != nil {
+		return nil, ErrPayloadTampered
+	}
+	var data []byte
+	if env.protection == EncryptAndSign {
+		ciphertext, err := base64.RawStdEncoding.DecodeString(env.body)
+		if err != nil {
+			return nil, ErrPayloadTampered
+		}
+		if data, err = key.encrypter.Open(ciphertext, aad); err != nil {
+			return nil, ErrPayloadTampered
+		}
+	} else {
+		signature, err := base64.RawStdEncoding.DecodeString(env.signature)
+		data = []byte(env.body)
+		if err != nil || !hmac.Equal(signature, key.signer.MAC(append(aad, data...))) {
+			return nil, ErrPayloadTampered
+		}
+	}
+
+	restored := make(map[string]interface{})
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	for k, v := range payload {
+		if k != EnvelopeKey {
+			restored[k] = v
+		}
+	}
+	return restored, nil
+}
+
+// envelope 解析后的信封
+type envelope struct {
+	protection Protection
+	keyID      string
+	body       string
+	signature  string
+}
+
+// parseEnvelope 读取负载中的信封，兼容内存队列中的原始值与经过 JSON 序列化后的值
+func parseEnvelope(payload map[string]interface{}) (envelope, error) {
+	raw, exists := payload[EnvelopeKey]
+	if !exists {
+		return envelope{}, ErrPayloadNotProtected
+	}
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return envelope{}, ErrUnsupportedEnvelope
+	}
+	get := func(key string) string {
+		s, _ := values[key].(string)
+		return s
+	}
+
+	var e envelope
+	var version int
+	switch v := values["v"].(type) {
+	case int:
+		version = v
+	case float64:
+		version = int(v)
+	case json.Number:
+		version, _ = strconv.Atoi(v.String())
+	}
+	if version != envelopeVersion {
+		return envelope{}, fmt.Errorf("%w: 版本 %v", ErrUnsupportedEnvelope, values["v"])
+	}
+	if e.protection, ok = parseProtection(get("protection")); !ok {
+		return envelope{}, fmt.Errorf("%w: 保护策略 %q", ErrUnsupportedEnvelope, get("protection"))
+	}
+	e.keyID, e.body, e.signature = get("kid"), get("body"), get("sig")
+	return e, nil
+}
+
+// SetPayloadKeys 设置任务负载的密钥，分发受保护的任务与处理带信封的任务时使用
+func (m *QueueManager) SetPayloadKeys(keys *PayloadKeys) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payloadKeys = keys
+}
+
+// SetStrictProtection 对所有设置了保护策略的任务类型启用严格模式，见 ProtectStrict
+//
+// 严格模式用于滚动升级完成之后：此前没有信封的负载按旧任务处理，任何能写入队列存储的人都可以借此绕过签名
+func (m *QueueManager) SetStrictProtection(strict bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.strictProtection = strict
+}
+
+// strictFor 判断任务类型是否处于严格模式
+func (m *QueueManager) strictFor(jobName string) bool {
+	if ProtectionOf(jobName) == Unprotected {
+		return false
+	}
+	m.mu.RLock()
+	strict := m.strictProtection
+	m.mu.RUnlock()
+	if strict {
+		return true
+	}
+	protectionsMu.RLock()
+	defer protectionsMu.RUnlock()
+	return strictJobs[jobName]
+}
+
+// payloadKeyring 返回任务负载的密钥
+func (m *QueueManager) payloadKeyring() *PayloadKeys {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.payloadKeys
+}
+
+// protect 按任务类型的保护策略写入信封，未设置策略时原样返回
+func (m *QueueManager) protect(jobName string, payload map[string]interface{}) (map[string]interface{}, error) {
+	protection := ProtectionOf(jobName)
+	if protection == Unprotected {
+		return payload, nil
+	}
+	keys := m.payloadKeyring()
+	if keys == nil {
+		return nil, fmt.Errorf("%w: 任务 %s 要求保护负载", ErrPayloadKeysMissing, jobName)
+	}
+	return keys.seal(jobName, protection, payload)
+}
+
+// withUnprotect 包装处理器，验证并还原带信封的负载
+//
+// 处理器收到的是任务副本，队列驱动保存任务时负载仍是信封，明文不会写回存储；
+// 验证失败时不调用处理器，任务以对应的错误失败且不再重试
+func (m *QueueManager) withUnprotect(handler Handler) Handler {
+	return func(ctx context.Context, job *Job) error {
+		strict := m.strictFor(job.Name)
+		payload, err := m.payloadKeyring().open(job.Name, job.Payload)
+		if errors.Is(err, ErrPayloadNotProtected) && !strict {
+			// 未保护的旧任务照常处理
+			return handler(ctx, job)
+		}
+		if err != nil {
+			job.MaxRetries = job.Attempts
+			return err
+		}
+
+		clone := *job
+		clone.Payload = payload
+		err = handler(ctx, &clone)
+		clone.Payload = job.Payload
+		*job = clone
+		return err
+	}
+}
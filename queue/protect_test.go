@@ -0,0 +1,268 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/crypto"
+	"github.com/zzliekkas/flow/v2/queue"
+	"github.com/zzliekkas/flow/v2/queue/memory"
+)
+
+// newPayloadKeys 按顺序创建负载密钥，第一个为当前密钥
+func newPayloadKeys(t *testing.T, keys ...[]byte) *queue.PayloadKeys {
+	t.Helper()
+	pk, err := queue.NewPayloadKeys(keys)
+	require.NoError(t, err)
+	return pk
+}
+
+func generateKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return key
+}
+
+// newProtectedManager 创建使用内存队列的管理器，处理器收到的负载写入 received
+func newProtectedManager(t *testing.T, jobName string, keys *queue.PayloadKeys, received *map[string]interface{}) (*queue.QueueManager, *memory.MemoryQueue) {
+	t.Helper()
+	mq := memory.New(3)
+	manager := queue.NewQueueManager()
+	require.NoError(t, manager.AddQueue("default", mq))
+	manager.SetPayloadKeys(keys)
+	manager.Register(jobName, func(ctx context.Context, job *queue.Job) error {
+		*received = job.Payload
+		return nil
+	})
+	return manager, mq
+}
+
+// dispatch 分发任务并返回队列中保存的任务，保存的负载经过 JSON 往返以模拟 Redis
+func dispatch(t *testing.T, manager *queue.QueueManager, mq *memory.MemoryQueue, ctx context.Context, jobName string, payload map[string]interface{}) *queue.Job {
+	t.Helper()
+	id, err := manager.Dispatch(ctx, jobName, payload)
+	require.NoError(t, err)
+	job, err := mq.Get(ctx, "default", id)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(job.Payload)
+	require.NoError(t, err)
+	job.Payload = nil
+	require.NoError(t, json.Unmarshal(data, &job.Payload))
+	return job
+}
+
+func TestProtect_EncryptRoundTrip(t *testing.T) {
+	queue.Protect("protect:reset", queue.EncryptAndSign)
+	var received map[string]interface{}
+	manager, mq := newProtectedManager(t, "protect:reset", newPayloadKeys(t, generateKey(t)), &received)
+
+	ctx := queue.WithMetadata(context.Background(), queue.Metadata{RequestID: "req-1"})
+	job := dispatch(t, manager, mq, ctx, "protect:reset", map[string]interface{}{"email": "alice@example.com", "token": "t-123"})
+
+	// 存储中只有信封与明文元数据
+	stored, _ := json.Marshal(job.Payload)
+	assert.NotContains(t, string(stored), "alice@example.com")
+	assert.NotContains(t, job.Payload, "email")
+	assert.Equal(t, map[string]interface{}{"request_id": "req-1"}, job.Payload[queue.MetadataKey])
+
+	processed, err := mq.TryProcessNext(context.Background(), "default")
+	require.True(t, processed)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", received["email"])
+	assert.Equal(t, "t-123", received["token"])
+	assert.Contains(t, job.Payload, queue.EnvelopeKey, "明文不会写回存储")
+}
+
+func TestProtect_RejectsTampering(t *testing.T) {
+	queue.Protect("protect:signed", queue.Sign)
+	queue.Protect("protect:encrypted", queue.EncryptAndSign)
+	keys := newPayloadKeys(t, generateKey(t))
+
+	tamper := map[string]func(env map[string]interface{}){
+		"protect:signed": func(env map[string]interface{}) {
+			env["body"] = `{"amount":1000000}`
+		},
+		"protect:encrypted": func(env map[string]interface{}) {
+			body := []byte(env["body"].(string))
+			body[len(body)-2] ^= 1
+			env["body"] = string(body)
+		},
+	}
+	for name, modify := range tamper {
+		called := false
+		var received map[string]interface{}
+		manager, mq := newProtectedManager(t, name, keys, &received)
+		manager.Register(name, func(ctx context.Context, job *queue.Job) error {
+			called = true
+			return nil
+		})
+
+		job := dispatch(t, manager, mq, context.Background(), name, map[string]interface{}{"amount": 1})
+		modify(job.Payload[queue.EnvelopeKey].(map[string]interface{}))
+
+		_, err := mq.TryProcessNext(context.Background(), "default")
+		assert.ErrorIs(t, err, queue.ErrPayloadTampered, name)
+		assert.False(t, called, "验证失败时不调用处理器")
+		assert.Equal(t, queue.JobStatusFailed, job.Status, "验证失败的任务不再重试")
+	}
+
+	// 信封不能挪用到其他任务类型
+	queue.Protect("protect:other", queue.EncryptAndSign)
+	var received map[string]interface{}
+	manager, mq := newProtectedManager(t, "protect:other", keys, &received)
+	sealed := dispatch(t, manager, mq, context.Background(), "protect:encrypted", map[string]interface{}{"amount": 1})
+	_, err := mq.Push(context.Background(), "default", "protect:other", sealed.Payload)
+	require.NoError(t, err)
+	_, _ = mq.TryProcessNext(context.Background(), "default")
+	_, err = mq.TryProcessNext(context.Background(), "default")
+	assert.ErrorIs(t, err, queue.ErrPayloadTampered)
+	assert.Nil(t, received)
+}
+
+func TestProtect_RotatedKeys(t *testing.T) {
+	queue.Protect("protect:rotate", queue.EncryptAndSign)
+	oldKey, newKey := generateKey(t), generateKey(t)
+
+	var received map[string]interface{}
+	producer, mq := newProtectedManager(t, "protect:rotate", newPayloadKeys(t, oldKey), &received)
+	job := dispatch(t, producer, mq, context.Background(), "protect:rotate", map[string]interface{}{"user": "bob"})
+	assert.Equal(t, newPayloadKeys(t, oldKey).CurrentKeyID(), job.Payload[queue.EnvelopeKey].(map[string]interface{})["kid"])
+
+	// 轮换后旧密钥仍可解密
+	producer.SetPayloadKeys(newPayloadKeys(t, newKey, oldKey))
+	_, err := mq.TryProcessNext(context.Background(), "default")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", received["user"])
+
+	// 旧密钥移除后以独立的错误失败
+	job = dispatch(t, producer, mq, context.Background(), "protect:rotate", map[string]interface{}{"user": "carol"})
+	producer.SetPayloadKeys(newPayloadKeys(t, generateKey(t)))
+	_, err = mq.TryProcessNext(context.Background(), "default")
+	assert.ErrorIs(t, err, queue.ErrUnknownPayloadKey)
+	assert.Equal(t, queue.JobStatusFailed, job.Status)
+}
+
+func TestProtect_LegacyAndMissingKeys(t *testing.T) {
+	queue.Protect("protect:legacy", queue.EncryptAndSign)
+	var received map[string]interface{}
+	manager, mq := newProtectedManager(t, "protect:legacy", newPayloadKeys(t, generateKey(t)), &received)
+
+	// 启用保护前写入的旧任务照常处理
+	_, err := mq.Push(context.Background(), "default", "protect:legacy", map[string]interface{}{"email": "old@example.com"})
+	require.NoError(t, err)
+	_, err = mq.TryProcessNext(context.Background(), "default")
+	require.NoError(t, err)
+	assert.Equal(t, "old@example.com", received["email"])
+
+	// 未知版本的信封被拒绝，包括从未发布的 v1
+	for _, version := range []float64{1, 99} {
+		_, err = mq.Push(context.Background(), "default", "protect:legacy", map[string]interface{}{
+			queue.EnvelopeKey: map[string]interface{}{"v": version, "protection": "encrypt"},
+		})
+		require.NoError(t, err)
+		_, err = mq.TryProcessNext(context.Background(), "default")
+		assert.ErrorIs(t, err, queue.ErrUnsupportedEnvelope)
+	}
+
+	// 没有密钥时拒绝以明文分发受保护的任务
+	manager.SetPayloadKeys(nil)
+	_, err = manager.Dispatch(context.Background(), "protect:legacy", map[string]interface{}{"email": "x"})
+	assert.ErrorIs(t, err, queue.ErrPayloadKeysMissing)
+}
+
+func TestInspector_MasksProtectedPayload(t *testing.T) {
+	queue.Protect("protect:inspect", queue.EncryptAndSign)
+	keys := newPayloadKeys(t, generateKey(t))
+	var received map[string]interface{}
+	manager, mq := newProtectedManager(t, "protect:inspect", keys, &received)
+	ctx := queue.WithMetadata(context.Background(), queue.Metadata{Tenant: "acme"})
+	job := dispatch(t, manager, mq, ctx, "protect:inspect", map[string]interface{}{"email": "alice@example.com"})
+
+	view := queue.NewInspector(keys, nil).View(job)
+	assert.True(t, view.Masked())
+	assert.Nil(t, view.Payload)
+	assert.Equal(t, "encrypt", view.Protection)
+	assert.Equal(t, keys.CurrentKeyID(), view.KeyID)
+	assert.Equal(t, "acme", view.Metadata.Tenant, "元数据正常显示")
+	shown, _ := json.Marshal(view)
+	assert.NotContains(t, string(shown), "alice@example.com")
+
+	// 没有第二道权限时拒绝揭示
+	_, err := queue.NewInspector(keys, nil).Reveal(context.Background(), job)
+	assert.ErrorIs(t, err, queue.ErrRevealNotAuthorized)
+	denied := func(ctx context.Context, job *queue.Job) error { return errors.New("缺少 queue:reveal 权限") }
+	_, err = queue.NewInspector(keys, denied).Reveal(context.Background(), job)
+	assert.ErrorIs(t, err, queue.ErrRevealNotAuthorized)
+
+	allowed := func(ctx context.Context, job *queue.Job) error { return nil }
+	view, err = queue.NewInspector(keys, allowed).Reveal(context.Background(), job)
+	require.NoError(t, err)
+	assert.True(t, view.Revealed)
+	assert.Equal(t, "alice@example.com", view.Payload["email"])
+
+	// 未保护的任务直接显示负载
+	legacy := &queue.Job{Name: "legacy", Payload: map[string]interface{}{"id": 1}}
+	assert.Equal(t, legacy.Payload, queue.NewInspector(nil, nil).View(legacy).Payload)
+}
+
+func TestProtect_MetadataAuthenticated(t *testing.T) {
+	queue.Protect("protect:tenant", queue.Sign)
+	var received map[string]interface{}
+	manager, mq := newProtectedManager(t, "protect:tenant", newPayloadKeys(t, generateKey(t)), &received)
+
+	ctx := queue.WithMetadata(context.Background(), queue.Metadata{Tenant: "acme"})
+	job := dispatch(t, manager, mq, ctx, "protect:tenant", map[string]interface{}{"amount": 1})
+
+	// 改写明文的租户后验证失败
+	job.Payload[queue.MetadataKey].(map[string]interface{})["tenant"] = "victim"
+	_, err := mq.TryProcessNext(context.Background(), "default")
+	assert.ErrorIs(t, err, queue.ErrPayloadTampered)
+	assert.Nil(t, received)
+}
+
+func TestProtect_Strict(t *testing.T) {
+	plaintext := map[string]interface{}{"email": "forged@example.com"}
+
+	// 按任务类型启用严格模式
+	queue.ProtectStrict("protect:strict", queue.EncryptAndSign)
+	var received map[string]interface{}
+	manager, mq := newProtectedManager(t, "protect:strict", newPayloadKeys(t, generateKey(t)), &received)
+	_, err := mq.Push(context.Background(), "default", "protect:strict", plaintext)
+	require.NoError(t, err)
+	_, err = mq.TryProcessNext(context.Background(), "default")
+	assert.ErrorIs(t, err, queue.ErrPayloadNotProtected)
+	assert.Nil(t, received)
+
+	// 带信封的任务照常处理
+	dispatch(t, manager, mq, context.Background(), "protect:strict", map[string]interface{}{"email": "a@example.com"})
+	_, err = mq.TryProcessNext(context.Background(), "default")
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com", received["email"])
+
+	// 全局启用严格模式，未设置保护策略的任务类型不受影响
+	queue.Protect("protect:global", queue.Sign)
+	received = nil
+	manager, mq = newProtectedManager(t, "protect:global", newPayloadKeys(t, generateKey(t)), &received)
+	manager.SetStrictProtection(true)
+	_, err = mq.Push(context.Background(), "default", "protect:global", plaintext)
+	require.NoError(t, err)
+	_, err = mq.TryProcessNext(context.Background(), "default")
+	assert.ErrorIs(t, err, queue.ErrPayloadNotProtected)
+	assert.Nil(t, received)
+
+	manager.Register("plain", func(ctx context.Context, job *queue.Job) error {
+		received = job.Payload
+		return nil
+	})
+	_, err = mq.Push(context.Background(), "default", "plain", plaintext)
+	require.NoError(t, err)
+	_, err = mq.TryProcessNext(context.Background(), "default")
+	require.NoError(t, err)
+	assert.Equal(t, "forged@example.com", received["email"])
+}
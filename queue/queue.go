@@ -89,6 +89,12 @@ type Queue interface {
 	Retry(ctx context.Context, queueName string, jobID string) error
 }
 
+// Processor 是可选接口，实现后工作进程可以区分"队列为空"与"已处理任务"
+type Processor interface {
+	// TryProcessNext 处理队列中的下一个任务，队列为空时返回false
+	TryProcessNext(ctx context.Context, queueName string) (bool, error)
+}
+
 // GetPayload 将任务负载解析为指定类型
 func (j *Job) GetPayload(v interface{}) error {
 	data, err := json.Marshal(j.Payload)
@@ -351,6 +351,12 @@ func (r *RedisQueue) Register(jobName string, handler queue.Handler) {
 
 // ProcessNext 处理队列中的下一个任务
 func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
+	_, err := r.TryProcessNext(ctx, queueName)
+	return err
+}
+
+// TryProcessNext 处理队列中的下一个任务，返回是否取到了任务
+func (r *RedisQueue) TryProcessNext(ctx context.Context, queueName string) (bool, error) {
 	// 1. 将到期的计划任务移动到主队列
 	now := float64(time.Now().Unix())
 
@@ -361,7 +367,7 @@ func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
 	}).Result()
 
 	if err != nil && err != redis.Nil {
-		return fmt.Errorf("获取到期计划任务失败: %w", err)
+		return false, fmt.Errorf("获取到期计划任务失败: %w", err)
 	}
 
 	// 将到期任务移动到主队列
@@ -397,7 +403,7 @@ func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
 		// 执行管道
 		_, err = pipe.Exec(ctx)
 		if err != nil {
-			return fmt.Errorf("处理到期计划任务失败: %w", err)
+			return false, fmt.Errorf("处理到期计划任务失败: %w", err)
 		}
 	}
 
@@ -406,15 +412,15 @@ func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
 	if err != nil {
 		if err == redis.Nil {
 			// 队列为空
-			return nil
+			return false, nil
 		}
-		return fmt.Errorf("从队列获取任务失败: %w", err)
+		return false, fmt.Errorf("从队列获取任务失败: %w", err)
 	}
 
 	// 获取任务数据
 	job, err := r.Get(ctx, queueName, jobID)
 	if err != nil {
-		return fmt.Errorf("获取任务数据失败: %w", err)
+		return true, fmt.Errorf("获取任务数据失败: %w", err)
 	}
 
 	// 查找任务处理器
@@ -440,7 +446,7 @@ func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
 		})
 		_, err = pipe.Exec(ctx)
 
-		return errors.New("任务处理器不存在")
+		return true, errors.New("任务处理器不存在")
 	}
 
 	// 更新任务状态为处理中
@@ -461,11 +467,11 @@ func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
 	})
 	_, err = pipe.Exec(ctx)
 	if err != nil {
-		return fmt.Errorf("更新任务状态失败: %w", err)
+		return true, fmt.Errorf("更新任务状态失败: %w", err)
 	}
 
 	// 执行任务
-	err = handler(ctx, job)
+	err = queue.Execute(ctx, handler, job)
 
 	// 更新任务状态
 	finishTime := time.Now()
@@ -503,7 +509,7 @@ func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
 
 			_, err = pipe.Exec(ctx)
 			if err != nil {
-				return fmt.Errorf("安排任务重试失败: %w", err)
+				return true, fmt.Errorf("安排任务重试失败: %w", err)
 			}
 		} else {
 			// 不再重试，将任务标记为失败
@@ -524,7 +530,7 @@ func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
 
 			_, err = pipe.Exec(ctx)
 			if err != nil {
-				return fmt.Errorf("更新失败任务状态失败: %w", err)
+				return true, fmt.Errorf("更新失败任务状态失败: %w", err)
 			}
 		}
 
@@ -536,7 +542,7 @@ func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
 
 		_, err = pipe.Exec(ctx)
 		if err != nil {
-			return fmt.Errorf("更新已完成任务状态失败: %w", err)
+			return true, fmt.Errorf("更新已完成任务状态失败: %w", err)
 		}
 	} else {
 		// 任务执行成功
@@ -559,11 +565,11 @@ func (r *RedisQueue) ProcessNext(ctx context.Context, queueName string) error {
 
 		_, err = pipe.Exec(ctx)
 		if err != nil {
-			return fmt.Errorf("更新已完成任务状态失败: %w", err)
+			return true, fmt.Errorf("更新已完成任务状态失败: %w", err)
 		}
 	}
 
-	return nil
+	return true, nil
 }
 
 // StartWorker 启动工作进程
@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zzliekkas/flow/v2/queue"
+)
+
+// replyChannelPrefix 回复频道前缀，完整频道名为前缀加关联ID
+const replyChannelPrefix = "flow:reply:"
+
+// ReplyBroker 基于 Redis 发布订阅的回复通道，用于调用方与工作进程不在同一进程的场景
+// 每个调用方进程只建立一个模式订阅，按关联ID分发给本地等待者；没有订阅者时回复自然丢弃，无需清理
+type ReplyBroker struct {
+	client  *redis.Client
+	waiters *queue.ReplyWaiters
+
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+}
+
+// NewReplyBroker 创建 Redis 回复通道
+func NewReplyBroker(client *redis.Client) *ReplyBroker {
+	return &ReplyBroker{client: client, waiters: queue.NewReplyWaiters()}
+}
+
+// ReplyBroker 使用队列的 Redis 连接创建回复通道
+func (r *RedisQueue) ReplyBroker() *ReplyBroker {
+	return NewReplyBroker(r.client)
+}
+
+// Subscribe 登记等待，首次调用时建立模式订阅
+func (b *ReplyBroker) Subscribe(ctx context.Context, correlationID string) (queue.ReplySubscription, error) {
+	if err := b.listen(ctx); err != nil {
+		return nil, err
+	}
+	return b.waiters.Add(correlationID), nil
+}
+
+// Publish 发布回复
+func (b *ReplyBroker) Publish(ctx context.Context, correlationID string, result queue.Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, replyChannelPrefix+correlationID, data).Err()
+}
+
+// Close 关闭订阅
+func (b *ReplyBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pubsub == nil {
+		return nil
+	}
+	err := b.pubsub.Close()
+	b.pubsub = nil
+	return err
+}
+
+// listen 建立模式订阅并在后台分发回复
+func (b *ReplyBroker) listen(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pubsub != nil {
+		return nil
+	}
+
+	pubsub := b.client.PSubscribe(ctx, replyChannelPrefix+"*")
+	// 等待订阅确认，保证之后分发的任务的回复不会丢失
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+	b.pubsub = pubsub
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			var result queue.Result
+			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
+				continue
+			}
+			b.waiters.Deliver(strings.TrimPrefix(msg.Channel, replyChannelPrefix), result)
+		}
+	}()
+	return nil
+}
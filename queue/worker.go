@@ -0,0 +1,409 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueueSpec 队列规格：Weight 同时表示轮询权重与最大并发数
+type QueueSpec struct {
+	Name   string
+	Weight int
+}
+
+// ParseQueueSpec 解析形如 "critical:3,default:2,bulk:1" 的队列规格，省略权重时为1
+func ParseQueueSpec(spec string) ([]QueueSpec, error) {
+	var specs []QueueSpec
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, weight := part, 1
+		if i := strings.LastIndex(part, ":"); i >= 0 {
+			name = strings.TrimSpace(part[:i])
+			w, err := strconv.Atoi(strings.TrimSpace(part[i+1:]))
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("queue: 无效的队列权重: %s", part)
+			}
+			weight = w
+		}
+		if name == "" {
+			return nil, fmt.Errorf("queue: 队列名称不能为空: %s", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("queue: 队列重复: %s", name)
+		}
+		seen[name] = true
+		specs = append(specs, QueueSpec{Name: name, Weight: weight})
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("queue: 队列规格为空")
+	}
+	return specs, nil
+}
+
+// QueueStats 单个队列的运行统计
+type QueueStats struct {
+	Name       string  `json:"name"`
+	Weight     int     `json:"weight"`
+	Depth      int     `json:"depth"`      // 当前队列长度（来自Queue.Size）
+	Running    int     `json:"running"`    // 正在执行的任务数
+	Processed  int64   `json:"processed"`  // 已处理任务数（含失败）
+	Failed     int64   `json:"failed"`     // 失败任务数
+	Throughput float64 `json:"throughput"` // 每秒处理任务数
+}
+
+// WorkerOption 工作进程选项
+type WorkerOption func(*Worker)
+
+// WithWorkStealing 允许队列借用其他空闲队列的并发额度
+// 关闭时（默认）每个队列的并发数严格不超过其权重
+func WithWorkStealing(enabled bool) WorkerOption {
+	return func(w *Worker) {
+		w.stealing = enabled
+	}
+}
+
+// WithAging 启用饥饿保护：队列超过threshold未被调度时优先调度
+func WithAging(threshold time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.aging = threshold
+	}
+}
+
+// WithPollInterval 设置队列为空时的重新轮询间隔
+func WithPollInterval(interval time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.pollInterval = interval
+	}
+}
+
+// WithMetrics 设置指标收集器，用于上报并发数与采样的队列长度
+func WithMetrics(metrics Metrics) WorkerOption {
+	return func(w *Worker) {
+		w.metrics = metrics
+	}
+}
+
+// WithDepthSampleInterval 设置队列长度的采样间隔，默认15秒
+// 每个间隔内每个队列最多调用一次Size，避免对后端产生额外压力
+func WithDepthSampleInterval(interval time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.sampleInterval = interval
+	}
+}
+
+// WithDrainTimeout 设置 Stop 等待正在执行的任务完成的最长时间，超时后取消任务的上下文并等待其返回；
+// 默认为0，一直等待任务完成
+func WithDrainTimeout(timeout time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.drainTimeout = timeout
+	}
+}
+
+// queueState 队列调度状态
+type queueState struct {
+	spec       QueueSpec
+	current    int // 平滑加权轮询的当前权重
+	running    int
+	processed  int64
+	failed     int64
+	lastServed time.Time
+	emptyUntil time.Time
+}
+
+// Worker 按权重轮询多个队列的工作进程，并对每个队列限制并发
+type Worker struct {
+	queue        Queue
+	stealing     bool
+	aging        time.Duration
+	pollInterval time.Duration
+
+	metrics        Metrics
+	sampleInterval time.Duration
+	drainTimeout   time.Duration
+
+	chain *middlewareChain
+
+	mu         sync.Mutex
+	states     []*queueState
+	capacity   int
+	running    int
+	startedAt  time.Time
+	release    chan struct{}
+	cancel     context.CancelFunc // 停止调度循环
+	cancelJobs context.CancelFunc // 取消正在执行的任务，只在排空超时后调用
+	wg         sync.WaitGroup
+	now        func() time.Time
+}
+
+// NewWorker 创建工作进程
+func NewWorker(q Queue, specs []QueueSpec, options ...WorkerOption) (*Worker, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("queue: 至少需要一个队列")
+	}
+
+	w := &Worker{
+		queue:          q,
+		pollInterval:   time.Second,
+		metrics:        noopMetrics{},
+		sampleInterval: 15 * time.Second,
+		release:        make(chan struct{}, 1),
+		now:            time.Now,
+	}
+	for _, option := range options {
+		option(w)
+	}
+
+	for _, spec := range specs {
+		if spec.Weight <= 0 {
+			return nil, fmt.Errorf("queue: 队列 %s 的权重必须大于0", spec.Name)
+		}
+		w.states = append(w.states, &queueState{spec: spec})
+		w.capacity += spec.Weight
+	}
+	return w, nil
+}
+
+// Start 启动调度循环，直到ctx取消或调用Stop
+//
+// 任务的上下文保留ctx中的值，但不随ctx取消或Stop结束，正在执行的任务由 Stop 排空
+func (w *Worker) Start(ctx context.Context) error {
+	w.mu.Lock()
+	if w.cancel != nil {
+		w.mu.Unlock()
+		return fmt.Errorf("queue: 工作进程已启动")
+	}
+	var jobCtx context.Context
+	jobCtx, w.cancelJobs = context.WithCancel(context.WithoutCancel(ctx))
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.startedAt = w.now()
+	for _, st := range w.states {
+		st.lastServed = w.startedAt
+	}
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go w.loop(ctx, jobCtx)
+
+	if _, ok := w.metrics.(noopMetrics); !ok && w.sampleInterval > 0 {
+		w.wg.Add(1)
+		go w.sampleDepth(ctx)
+	}
+	return nil
+}
+
+// Stop 停止调度并等待正在执行的任务完成
+//
+// 只停止调度循环，不取消正在执行的任务；设置了 WithDrainTimeout 时，超时后取消任务的上下文并等待其返回
+func (w *Worker) Stop() {
+	w.mu.Lock()
+	cancel, cancelJobs := w.cancel, w.cancelJobs
+	w.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	defer cancelJobs()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	if w.drainTimeout <= 0 {
+		<-done
+		return
+	}
+
+	timer := time.NewTimer(w.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		cancelJobs()
+		<-done
+	}
+}
+
+// Stats 返回各队列的统计信息，队列长度通过Queue.Size实时获取
+func (w *Worker) Stats(ctx context.Context) []QueueStats {
+	w.mu.Lock()
+	elapsed := w.now().Sub(w.startedAt).Seconds()
+	stats := make([]QueueStats, len(w.states))
+	for i, st := range w.states {
+		stats[i] = QueueStats{
+			Name:      st.spec.Name,
+			Weight:    st.spec.Weight,
+			Running:   st.running,
+			Processed: st.processed,
+			Failed:    st.failed,
+		}
+		if elapsed > 0 && !w.startedAt.IsZero() {
+			stats[i].Throughput = float64(st.processed) / elapsed
+		}
+	}
+	w.mu.Unlock()
+
+	for i := range stats {
+		if depth, err := w.queue.Size(ctx, stats[i].Name); err == nil {
+			stats[i].Depth = depth
+		}
+	}
+	return stats
+}
+
+// loop 调度循环，ctx 结束时停止调度，任务使用 jobCtx 执行
+func (w *Worker) loop(ctx, jobCtx context.Context) {
+	defer w.wg.Done()
+
+	for ctx.Err() == nil {
+		w.mu.Lock()
+		st := w.pickLocked(w.now())
+		if st != nil {
+			st.running++
+			w.running++
+			w.metrics.WorkerConcurrency(st.spec.Name, st.running)
+		}
+		w.mu.Unlock()
+
+		if st == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.release:
+			case <-time.After(w.pollInterval):
+			}
+			continue
+		}
+
+		w.wg.Add(1)
+		go w.run(jobCtx, st)
+	}
+}
+
+// run 在指定队列上处理一个任务
+func (w *Worker) run(ctx context.Context, st *queueState) {
+	defer w.wg.Done()
+
+	processed, err := w.process(ctx, st.spec.Name)
+
+	w.mu.Lock()
+	st.running--
+	w.running--
+	w.metrics.WorkerConcurrency(st.spec.Name, st.running)
+	now := w.now()
+	if processed {
+		st.processed++
+		st.lastServed = now
+		if err != nil {
+			st.failed++
+		}
+	} else {
+		// 队列为空，等待轮询间隔后再尝试
+		st.emptyUntil = now.Add(w.pollInterval)
+	}
+	w.mu.Unlock()
+
+	select {
+	case w.release <- struct{}{}:
+	default:
+	}
+}
+
+// sampleDepth 按采样间隔上报各队列长度
+func (w *Worker) sampleDepth(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, st := range w.states {
+			if depth, err := w.queue.Size(ctx, st.spec.Name); err == nil {
+				w.metrics.QueueDepth(st.spec.Name, depth)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process 处理一个任务，返回是否取到了任务
+// 中间件链通过 context 传给驱动，由驱动调用的 Execute 应用
+func (w *Worker) process(ctx context.Context, name string) (bool, error) {
+	w.mu.Lock()
+	chain := w.chain
+	w.mu.Unlock()
+	if chain != nil {
+		ctx = context.WithValue(ctx, chainKey{}, chain)
+	}
+
+	if p, ok := w.queue.(Processor); ok {
+		return p.TryProcessNext(ctx, name)
+	}
+	// 无法判断是否为空的实现按队列为空计算，由轮询间隔控制调用频率，避免空转
+	return false, w.queue.ProcessNext(ctx, name)
+}
+
+// pickLocked 选择下一个要调度的队列，调用方需持有锁
+// 先检查饥饿保护，再按平滑加权轮询在可调度的队列中选择
+func (w *Worker) pickLocked(now time.Time) *queueState {
+	if w.running >= w.capacity {
+		return nil
+	}
+
+	var candidates []*queueState
+	total := 0
+	for _, st := range w.states {
+		if now.Before(st.emptyUntil) {
+			continue
+		}
+		if !w.stealing && st.running >= st.spec.Weight {
+			continue
+		}
+		candidates = append(candidates, st)
+		total += st.spec.Weight
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if w.aging > 0 {
+		var oldest *queueState
+		for _, st := range candidates {
+			if now.Sub(st.lastServed) >= w.aging && (oldest == nil || st.lastServed.Before(oldest.lastServed)) {
+				oldest = st
+			}
+		}
+		if oldest != nil {
+			// 被提升的队列视为已服务，避免连续抢占
+			oldest.lastServed = now
+			return oldest
+		}
+	}
+
+	var best *queueState
+	for _, st := range candidates {
+		st.current += st.spec.Weight
+		if best == nil || st.current > best.current {
+			best = st
+		}
+	}
+	best.current -= total
+	return best
+}
@@ -0,0 +1,337 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQueue 只实现工作进程用到的方法
+type fakeQueue struct {
+	Queue
+
+	mu      sync.Mutex
+	pending map[string]int
+	running map[string]int
+	peak    map[string]int
+	fail    map[string]bool
+	delay   time.Duration
+}
+
+func newFakeQueue(pending map[string]int) *fakeQueue {
+	return &fakeQueue{
+		pending: pending,
+		running: make(map[string]int),
+		peak:    make(map[string]int),
+		fail:    make(map[string]bool),
+	}
+}
+
+func (f *fakeQueue) Size(ctx context.Context, name string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pending[name], nil
+}
+
+func (f *fakeQueue) TryProcessNext(ctx context.Context, name string) (bool, error) {
+	f.mu.Lock()
+	if f.pending[name] == 0 {
+		f.mu.Unlock()
+		return false, nil
+	}
+	f.pending[name]--
+	f.running[name]++
+	if f.running[name] > f.peak[name] {
+		f.peak[name] = f.running[name]
+	}
+	f.mu.Unlock()
+
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.running[name]--
+	if f.fail[name] {
+		return true, assert.AnError
+	}
+	return true, nil
+}
+
+func TestParseQueueSpec(t *testing.T) {
+	specs, err := ParseQueueSpec("critical:3, default:2,bulk")
+	require.NoError(t, err)
+	assert.Equal(t, []QueueSpec{{"critical", 3}, {"default", 2}, {"bulk", 1}}, specs)
+
+	for _, spec := range []string{"", "a:0", "a:x", ":2", "a,a"} {
+		_, err := ParseQueueSpec(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestWorkerWeightedDistribution(t *testing.T) {
+	specs, _ := ParseQueueSpec("critical:3,default:2,bulk:1")
+	w, err := NewWorker(nil, specs)
+	require.NoError(t, err)
+
+	// 每次选择后立即释放，只观察轮询顺序
+	counts := make(map[string]int)
+	now := time.Now()
+	for i := 0; i < 6000; i++ {
+		st := w.pickLocked(now)
+		require.NotNil(t, st)
+		counts[st.spec.Name]++
+	}
+
+	assert.Equal(t, 3000, counts["critical"])
+	assert.Equal(t, 2000, counts["default"])
+	assert.Equal(t, 1000, counts["bulk"])
+}
+
+func TestWorkerAging(t *testing.T) {
+	specs, _ := ParseQueueSpec("critical:5,bulk:1")
+	w, _ := NewWorker(nil, specs, WithAging(time.Minute))
+
+	now := time.Now()
+	w.states[0].lastServed = now
+	w.states[1].lastServed = now.Add(-2 * time.Minute)
+
+	st := w.pickLocked(now)
+	require.NotNil(t, st)
+	assert.Equal(t, "bulk", st.spec.Name)
+	assert.Equal(t, "critical", w.pickLocked(now).spec.Name)
+}
+
+func TestWorkerConcurrencyCaps(t *testing.T) {
+	q := newFakeQueue(map[string]int{"critical": 0, "bulk": 40})
+	q.delay = 5 * time.Millisecond
+
+	specs, _ := ParseQueueSpec("critical:3,bulk:1")
+	w, err := NewWorker(q, specs, WithPollInterval(time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, w.Start(context.Background()))
+
+	require.Eventually(t, func() bool {
+		size, _ := q.Size(context.Background(), "bulk")
+		return size == 0
+	}, 5*time.Second, time.Millisecond)
+	w.Stop()
+
+	// critical为空时bulk也不能超过自己的并发额度
+	assert.Equal(t, 1, q.peak["bulk"])
+}
+
+func TestWorkerStealing(t *testing.T) {
+	q := newFakeQueue(map[string]int{"critical": 0, "bulk": 40})
+	q.delay = 5 * time.Millisecond
+
+	specs, _ := ParseQueueSpec("critical:3,bulk:1")
+	w, _ := NewWorker(q, specs, WithWorkStealing(true), WithPollInterval(time.Millisecond))
+	require.NoError(t, w.Start(context.Background()))
+
+	require.Eventually(t, func() bool {
+		size, _ := q.Size(context.Background(), "bulk")
+		return size == 0
+	}, 5*time.Second, time.Millisecond)
+	w.Stop()
+
+	assert.Greater(t, q.peak["bulk"], 1)
+	assert.LessOrEqual(t, q.peak["bulk"], 4)
+}
+
+func TestWorkerStats(t *testing.T) {
+	q := newFakeQueue(map[string]int{"default": 10, "bulk": 5})
+	q.fail["bulk"] = true
+
+	specs, _ := ParseQueueSpec("default:2,bulk:1")
+	w, _ := NewWorker(q, specs, WithPollInterval(time.Millisecond))
+
+	require.NoError(t, w.Start(context.Background()))
+	assert.Error(t, w.Start(context.Background()))
+
+	require.Eventually(t, func() bool {
+		for _, s := range w.Stats(context.Background()) {
+			if s.Processed < int64(map[string]int{"default": 10, "bulk": 5}[s.Name]) {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, time.Millisecond)
+	w.Stop()
+
+	stats := w.Stats(context.Background())
+	require.Len(t, stats, 2)
+	assert.Equal(t, QueueStats{Name: "default", Weight: 2, Processed: 10, Throughput: stats[0].Throughput}, stats[0])
+	assert.Equal(t, int64(5), stats[1].Processed)
+	assert.Equal(t, int64(5), stats[1].Failed)
+	assert.Zero(t, stats[1].Depth)
+	assert.Greater(t, stats[0].Throughput, 0.0)
+}
+
+// countingMetrics 记录采样次数
+type countingMetrics struct {
+	noopMetrics
+	mu     sync.Mutex
+	depths map[string]int
+}
+
+func (m *countingMetrics) QueueDepth(name string, depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depths[name]++
+}
+
+func TestWorkerDepthSampling(t *testing.T) {
+	q := newFakeQueue(map[string]int{"default": 0})
+	m := &countingMetrics{depths: make(map[string]int)}
+
+	specs, _ := ParseQueueSpec("default")
+	w, _ := NewWorker(q, specs, WithMetrics(m), WithDepthSampleInterval(time.Hour))
+	require.NoError(t, w.Start(context.Background()))
+	time.Sleep(20 * time.Millisecond)
+	w.Stop()
+
+	// 一个采样间隔内只调用一次Size
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Equal(t, 1, m.depths["default"])
+}
+
+// plainQueue 未实现Processor，无法区分队列为空与已处理
+type plainQueue struct {
+	Queue
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *plainQueue) ProcessNext(ctx context.Context, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return nil
+}
+
+func TestWorkerPlainQueueDoesNotSpin(t *testing.T) {
+	q := &plainQueue{}
+
+	specs, _ := ParseQueueSpec("default")
+	w, _ := NewWorker(q, specs, WithPollInterval(20*time.Millisecond))
+	require.NoError(t, w.Start(context.Background()))
+	time.Sleep(100 * time.Millisecond)
+	w.Stop()
+
+	// 每个轮询间隔最多调用一次
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	assert.LessOrEqual(t, q.calls, 10)
+	assert.GreaterOrEqual(t, q.calls, 1)
+}
+
+// drainQueue 任务一直执行到 finish 关闭或上下文取消，记录结束时上下文的错误
+type drainQueue struct {
+	Queue
+
+	started chan struct{}
+	finish  chan struct{}
+
+	mu      sync.Mutex
+	pending int
+	errs    []error
+}
+
+func (d *drainQueue) TryProcessNext(ctx context.Context, name string) (bool, error) {
+	d.mu.Lock()
+	if d.pending == 0 {
+		d.mu.Unlock()
+		return false, nil
+	}
+	d.pending--
+	d.mu.Unlock()
+
+	d.started <- struct{}{}
+	select {
+	case <-d.finish:
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.errs = append(d.errs, ctx.Err())
+	return true, ctx.Err()
+}
+
+func TestWorkerStopDrainsRunningJobs(t *testing.T) {
+	q := &drainQueue{started: make(chan struct{}, 2), finish: make(chan struct{}), pending: 2}
+	specs, _ := ParseQueueSpec("default")
+	w, _ := NewWorker(q, specs, WithPollInterval(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, w.Start(ctx))
+	<-q.started
+
+	// 取消 Start 的上下文与调用 Stop 都不取消正在执行的任务
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		w.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop 在任务完成前返回")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(q.finish)
+	<-stopped
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	assert.Equal(t, []error{nil}, q.errs, "任务正常完成，且停止后不再取新任务")
+	assert.Equal(t, 1, q.pending)
+}
+
+func TestWorkerStopDrainTimeout(t *testing.T) {
+	q := &drainQueue{started: make(chan struct{}, 1), finish: make(chan struct{}), pending: 1}
+	specs, _ := ParseQueueSpec("default")
+	w, _ := NewWorker(q, specs, WithPollInterval(time.Millisecond), WithDrainTimeout(20*time.Millisecond))
+	require.NoError(t, w.Start(context.Background()))
+	<-q.started
+
+	start := time.Now()
+	w.Stop()
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// 超时后取消任务的上下文
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	assert.Equal(t, []error{context.Canceled}, q.errs)
+}
+
+func TestInspectorStats(t *testing.T) {
+	q := newFakeQueue(map[string]int{"default": 3, "bulk": 2})
+
+	stats, err := NewInspector(nil, nil).Stats(context.Background(), q, []string{"default", "bulk"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []QueueStats{{Name: "default", Depth: 3}, {Name: "bulk", Depth: 2}}, stats)
+
+	// 合并工作进程的处理统计与吞吐量
+	specs, _ := ParseQueueSpec("default")
+	w, _ := NewWorker(q, specs, WithPollInterval(time.Millisecond))
+	require.NoError(t, w.Start(context.Background()))
+	require.Eventually(t, func() bool {
+		s := w.Stats(context.Background())
+		return s[0].Processed == 3
+	}, 5*time.Second, time.Millisecond)
+	w.Stop()
+
+	stats, err = NewInspector(nil, nil).Stats(context.Background(), q, []string{"default", "bulk"}, w)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats[0].Depth)
+	assert.Equal(t, int64(3), stats[0].Processed)
+	assert.Greater(t, stats[0].Throughput, 0.0)
+	assert.Equal(t, QueueStats{Name: "bulk", Depth: 2}, stats[1])
+}
@@ -0,0 +1,192 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReadinessPath 内置的就绪检查端点，启动完成前响应503，之后响应200
+//
+// 应用自己在默认主机上注册了 GET /readyz 时不启用内置端点。通过 WithUnixSocket 监听时，
+// 探针需要经由套接字访问，主机名可以任意填写：
+//
+//	curl --unix-socket /run/app/app.sock http://localhost/readyz
+const ReadinessPath = "/readyz"
+
+// DefaultStartupTimeout 默认的最长启动时间
+const DefaultStartupTimeout = time.Minute
+
+// ErrStartupTimeout 启动任务未在最长启动时间内完成
+var ErrStartupTimeout = errors.New("flow: 启动超时")
+
+// startupRetryAfter 启动期间建议客户端重试的间隔（秒）
+const startupRetryAfter = "1"
+
+// startupGrace 启动超时后等待当前任务响应取消的时间
+const startupGrace = 100 * time.Millisecond
+
+// startupTask 启动任务，全部完成后才开始处理请求
+type startupTask struct {
+	name     string
+	fn       func(ctx context.Context) error
+	priority int // 数值越小越先执行
+}
+
+// readinessGate 就绪门控：Run 先监听端口，启动任务完成前所有请求响应503
+type readinessGate struct {
+	closed  atomic.Bool // 为 true 时拒绝请求
+	readyz  atomic.Bool // 是否启用内置就绪端点
+	current atomic.Value
+	timeout time.Duration
+	tasksMu sync.Mutex
+	tasks   []startupTask
+	results []StartupTaskResult // 已执行的启动任务
+	addrs   []string            // 实际监听的地址
+}
+
+// StartupTaskResult 已执行的启动任务及其耗时，失败的任务 Err 不为空
+type StartupTaskResult struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// WithStartupTimeout 返回一个设置最长启动时间的选项，启动钩子与启动任务超过该时间未完成时 Run 返回 ErrStartupTimeout
+func WithStartupTimeout(d time.Duration) Option {
+	return func(e *Engine) {
+		e.readiness.timeout = d
+	}
+}
+
+// AddStartupTask 注册启动任务，如阻塞式的缓存预热、等待依赖服务就绪，priority 越小越先执行，默认为100
+//
+// Run 监听端口并执行启动钩子后，按优先级依次执行启动任务，期间请求响应 503 与 Retry-After，
+// 任一任务失败或超过最长启动时间时关闭服务器并返回错误
+func (e *Engine) AddStartupTask(name string, fn func(ctx context.Context) error, priority ...int) {
+	p := 100
+	if len(priority) > 0 {
+		p = priority[0]
+	}
+	e.readiness.tasksMu.Lock()
+	defer e.readiness.tasksMu.Unlock()
+	e.readiness.tasks = append(e.readiness.tasks, startupTask{name: name, fn: fn, priority: p})
+}
+
+// StartupTasks 返回 Run 已执行的启动任务，按执行顺序排列
+func (e *Engine) StartupTasks() []StartupTaskResult {
+	e.readiness.tasksMu.Lock()
+	defer e.readiness.tasksMu.Unlock()
+	return append([]StartupTaskResult(nil), e.readiness.results...)
+}
+
+// ListenAddrs 返回 Run 实际监听的地址，例如 "[::]:8080"，未启动时为空
+func (e *Engine) ListenAddrs() []string {
+	e.readiness.tasksMu.Lock()
+	defer e.readiness.tasksMu.Unlock()
+	return append([]string(nil), e.readiness.addrs...)
+}
+
+// Ready 返回服务器是否已开始处理请求，未通过 Run 启动的引擎总是就绪
+func (e *Engine) Ready() bool {
+	return !e.readiness.closed.Load()
+}
+
+// serveGate 在启动完成前拦截请求，处理内置就绪端点，返回请求是否已处理
+func (e *Engine) serveGate(w http.ResponseWriter, r *http.Request) bool {
+	if e.readiness.closed.Load() {
+		w.Header().Set("Retry-After", startupRetryAfter)
+		http.Error(w, "service starting", http.StatusServiceUnavailable)
+		return true
+	}
+	if e.readiness.readyz.Load() && r.URL.Path == ReadinessPath {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+		return true
+	}
+	return false
+}
+
+// startup 执行启动钩子与启动任务，超过最长启动时间时返回 ErrStartupTimeout
+func (e *Engine) startup() error {
+	timeout := e.readiness.timeout
+	if timeout <= 0 {
+		timeout = DefaultStartupTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	e.readiness.current.Store("启动钩子")
+	done := make(chan error, 1)
+	go func() {
+		done <- e.runStartupTasks(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// 响应取消的任务可以给出更具体的原因
+		select {
+		case err := <-done:
+			if err == nil {
+				return nil
+			}
+			return fmt.Errorf("%w: %s 内未完成: %w", ErrStartupTimeout, timeout, err)
+		case <-time.After(startupGrace):
+		}
+		return fmt.Errorf("%w: %s 内未完成，正在执行: %s", ErrStartupTimeout, timeout, e.readiness.current.Load())
+	}
+}
+
+// runStartupTasks 依次执行启动钩子与启动任务，并记录每个任务的耗时
+func (e *Engine) runStartupTasks(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("flow: %s panic: %v", e.readiness.current.Load(), r)
+		}
+	}()
+
+	// 执行启动钩子
+	executeHooks(e.startHooks)
+
+	// 检查路由跳过的中间件名称
+	e.checkRouteSkips()
+
+	// 检查重叠的路由
+	e.checkRouteConflicts()
+
+	e.readiness.tasksMu.Lock()
+	tasks := append([]startupTask(nil), e.readiness.tasks...)
+	e.readiness.tasksMu.Unlock()
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].priority < tasks[j].priority
+	})
+
+	for _, task := range tasks {
+		e.readiness.current.Store(task.name)
+		start := time.Now()
+		err := task.fn(ctx)
+		result := StartupTaskResult{Name: task.name, Duration: time.Since(start), Err: err}
+		e.readiness.tasksMu.Lock()
+		e.readiness.results = append(e.readiness.results, result)
+		e.readiness.tasksMu.Unlock()
+		if err != nil {
+			return fmt.Errorf("flow: 启动任务 %s 失败: %w", task.name, err)
+		}
+		flog.Debugf("启动任务 %s 完成，耗时: %s", task.name, result.Duration)
+	}
+
+	// 应用自己注册了就绪端点时不启用内置端点
+	e.routeTable.mu.RLock()
+	_, custom := e.routeTable.index[routeKey("", http.MethodGet, ReadinessPath)]
+	e.routeTable.mu.RUnlock()
+	e.readiness.readyz.Store(!custom)
+	return nil
+}
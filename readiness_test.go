@@ -0,0 +1,143 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freeAddr 返回一个空闲的本地地址
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+// waitListening 等待端口开始监听
+func waitListening(t *testing.T, addr string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+// getURL 发起请求并返回状态码、Retry-After 与响应体
+func getURL(t *testing.T, url string) (int, string, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header.Get("Retry-After"), string(body)
+}
+
+func TestRun_GatesRequestsUntilStartupCompletes(t *testing.T) {
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	e := newRouteTestEngine()
+	e.GET("/ping", reply("pong"))
+
+	release := make(chan struct{})
+	var order []string
+	e.AddStartupTask("warm-cache", func(ctx context.Context) error {
+		order = append(order, "warm-cache")
+		<-release
+		return nil
+	})
+	e.AddStartupTask("providers", func(ctx context.Context) error {
+		order = append(order, "providers")
+		return nil
+	}, 0)
+	e.OnStart(func() {
+		order = append(order, "hook")
+		// 启动钩子中注册的路由在开放后可用
+		e.GET("/late", reply("late"))
+	})
+
+	addr := freeAddr(t)
+	done := make(chan error, 1)
+	go func() { done <- e.Run(addr) }()
+	base := "http://" + addr
+
+	// 端口已监听，启动完成前请求响应503
+	waitListening(t, addr)
+	code, retry, _ := getURL(t, base+"/ping")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "1", retry)
+	code, _, _ = getURL(t, base+ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, e.Ready())
+
+	close(release)
+	require.Eventually(t, e.Ready, 2*time.Second, 10*time.Millisecond)
+	code, _, body := getURL(t, base+"/ping")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "pong", body)
+	code, _, body = getURL(t, base+ReadinessPath)
+	assert.Equal(t, http.StatusOK, code, "就绪端点与门控一致")
+	assert.Equal(t, "ok", body)
+	_, _, body = getURL(t, base+"/late")
+	assert.Equal(t, "late", body)
+	assert.Equal(t, []string{"hook", "providers", "warm-cache"}, order)
+
+	require.NoError(t, e.Shutdown(context.Background()))
+	assert.ErrorIs(t, <-done, http.ErrServerClosed)
+}
+
+func TestRun_StartupTimeout(t *testing.T) {
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	e := New(WithStartupTimeout(50 * time.Millisecond))
+	e.AddStartupTask("stuck", func(ctx context.Context) error {
+		select {}
+	})
+
+	addr := freeAddr(t)
+	err := e.Run(addr)
+	require.ErrorIs(t, err, ErrStartupTimeout)
+	assert.Contains(t, err.Error(), "stuck")
+
+	// 启动失败后端口已释放
+	l, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	l.Close()
+}
+
+func TestRun_StartupTaskError(t *testing.T) {
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	e := newRouteTestEngine()
+	boom := errors.New("redis unavailable")
+	e.AddStartupTask("warm-cache", func(ctx context.Context) error { return boom })
+	err := e.Run(freeAddr(t))
+	assert.ErrorIs(t, err, boom)
+	assert.Contains(t, err.Error(), "warm-cache")
+}
+
+func TestRun_CustomReadinessRoute(t *testing.T) {
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	e := newRouteTestEngine()
+	e.GET(ReadinessPath, reply("custom"))
+
+	addr := freeAddr(t)
+	done := make(chan error, 1)
+	go func() { done <- e.Run(addr) }()
+	waitListening(t, addr)
+	require.Eventually(t, e.Ready, 2*time.Second, 10*time.Millisecond)
+
+	_, _, body := getURL(t, "http://"+addr+ReadinessPath)
+	assert.Equal(t, "custom", body)
+	require.NoError(t, e.Shutdown(context.Background()))
+	<-done
+}
@@ -0,0 +1,77 @@
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Header 返回脱敏后的请求头副本
+func (p *Policy) Header(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	result := make(http.Header, len(h))
+	for name, values := range h {
+		if p.MatchHeader(name) {
+			result[name] = p.maskAll(values)
+			continue
+		}
+		result[name] = append([]string(nil), values...)
+	}
+	return result
+}
+
+// Values 返回脱敏后的查询参数或表单副本
+func (p *Policy) Values(values url.Values) url.Values {
+	if values == nil {
+		return nil
+	}
+	result := make(url.Values, len(values))
+	for name, vs := range values {
+		if p.MatchParam(name) {
+			result[name] = p.maskAll(vs)
+			continue
+		}
+		result[name] = append([]string(nil), vs...)
+	}
+	return result
+}
+
+// Query 脱敏原始查询字符串，保留参数顺序与未匹配参数的原始编码
+//
+// 掩码不做 URL 编码，结果只用于记录日志
+func (p *Policy) Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.Grow(len(rawQuery))
+	for i, pair := range strings.Split(rawQuery, "&") {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		rawName, rawValue, hasValue := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		if !hasValue || !p.MatchParam(name) {
+			b.WriteString(pair)
+			continue
+		}
+		b.WriteString(rawName)
+		b.WriteByte('=')
+		b.WriteString(p.maskFor(len(rawValue)))
+	}
+	return b.String()
+}
+
+// maskAll 将每个值替换为掩码
+func (p *Policy) maskAll(values []string) []string {
+	masked := make([]string, len(values))
+	for i, v := range values {
+		masked[i] = p.maskFor(len(v))
+	}
+	return masked
+}
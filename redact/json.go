@@ -0,0 +1,416 @@
+package redact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidJSON 输入不是有效的 JSON
+var ErrInvalidJSON = errors.New("redact: 无效的 JSON")
+
+// JSON 脱敏 JSON 文本并返回紧凑格式的结果
+//
+// 逐个扫描标记并复制到输出，不构建中间对象，只有匹配的值与超过最大深度的容器被跳过并替换为掩码。
+// 输入无效或末尾有多余内容时返回 ErrInvalidJSON，调用方不应回退为记录原文
+func (p *Policy) JSON(data []byte) ([]byte, error) {
+	r := &rewriter{
+		policy: p,
+		in:     data,
+		out:    make([]byte, 0, len(data)),
+	}
+	r.skipSpace()
+	if err := r.value(p.rootCursors(), 0); err != nil {
+		return nil, err
+	}
+	r.skipSpace()
+	if r.pos < len(r.in) {
+		return nil, r.errorf("多余的内容")
+	}
+	return r.out, nil
+}
+
+// rewriter JSON 标记重写器
+type rewriter struct {
+	policy *Policy
+	in     []byte
+	pos    int
+	out    []byte
+}
+
+// errorf 返回带偏移量的解析错误
+func (r *rewriter) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s（偏移 %d）", ErrInvalidJSON, fmt.Sprintf(format, args...), r.pos)
+}
+
+// skipSpace 跳过空白
+func (r *rewriter) skipSpace() {
+	for r.pos < len(r.in) {
+		switch r.in[r.pos] {
+		case ' ', '\t', '\n', '\r':
+			r.pos++
+		default:
+			return
+		}
+	}
+}
+
+// value 复制一个值，容器超过最大深度时替换为掩码
+func (r *rewriter) value(cursors []cursor, depth int) error {
+	if r.pos >= len(r.in) {
+		return r.errorf("意外的结尾")
+	}
+	switch c := r.in[r.pos]; c {
+	case '{', '[':
+		if depth >= r.policy.maxDepth {
+			return r.mask()
+		}
+		if c == '{' {
+			return r.object(cursors, depth)
+		}
+		return r.array(cursors, depth)
+	default:
+		start := r.pos
+		if err := r.scalar(); err != nil {
+			return err
+		}
+		r.out = append(r.out, r.in[start:r.pos]...)
+		return nil
+	}
+}
+
+// mask 跳过当前值并输出掩码，null 保持为 null
+func (r *rewriter) mask() error {
+	start := r.pos
+	if err := r.skipValue(); err != nil {
+		return err
+	}
+	token := r.in[start:r.pos]
+	if string(token) == "null" {
+		r.out = append(r.out, token...)
+		return nil
+	}
+	size := len(token)
+	if token[0] == '"' {
+		size -= 2
+	}
+	r.out = r.policy.appendMaskJSON(r.out, size)
+	return nil
+}
+
+// object 复制对象，匹配的字段值替换为掩码
+func (r *rewriter) object(cursors []cursor, depth int) error {
+	r.pos++
+	r.out = append(r.out, '{')
+	r.skipSpace()
+	if r.pos < len(r.in) && r.in[r.pos] == '}' {
+		r.pos++
+		r.out = append(r.out, '}')
+		return nil
+	}
+	for {
+		r.skipSpace()
+		if r.pos >= len(r.in) || r.in[r.pos] != '"' {
+			return r.errorf("缺少字段名")
+		}
+		start := r.pos
+		escaped, err := r.scanString()
+		if err != nil {
+			return err
+		}
+		token := r.in[start:r.pos]
+		r.out = append(r.out, token...)
+
+		key := string(token[1 : len(token)-1])
+		if escaped {
+			if err := json.Unmarshal(token, &key); err != nil {
+				return r.errorf("无效的字段名")
+			}
+		}
+
+		r.skipSpace()
+		if r.pos >= len(r.in) || r.in[r.pos] != ':' {
+			return r.errorf("缺少冒号")
+		}
+		r.pos++
+		r.out = append(r.out, ':')
+		r.skipSpace()
+
+		next, full := r.policy.descend(cursors, key, false)
+		if full || r.policy.fields.match(key) {
+			err = r.mask()
+		} else {
+			err = r.value(next, depth+1)
+		}
+		if err != nil {
+			return err
+		}
+
+		r.skipSpace()
+		if r.pos >= len(r.in) {
+			return r.errorf("对象未结束")
+		}
+		switch r.in[r.pos] {
+		case ',':
+			r.pos++
+			r.out = append(r.out, ',')
+		case '}':
+			r.pos++
+			r.out = append(r.out, '}')
+			return nil
+		default:
+			return r.errorf("对象中意外的字符 %q", r.in[r.pos])
+		}
+	}
+}
+
+// array 复制数组，匹配的元素替换为掩码
+func (r *rewriter) array(cursors []cursor, depth int) error {
+	r.pos++
+	r.out = append(r.out, '[')
+	r.skipSpace()
+	if r.pos < len(r.in) && r.in[r.pos] == ']' {
+		r.pos++
+		r.out = append(r.out, ']')
+		return nil
+	}
+	next, full := r.policy.descend(cursors, "", true)
+	for {
+		r.skipSpace()
+		var err error
+		if full {
+			err = r.mask()
+		} else {
+			err = r.value(next, depth+1)
+		}
+		if err != nil {
+			return err
+		}
+
+		r.skipSpace()
+		if r.pos >= len(r.in) {
+			return r.errorf("数组未结束")
+		}
+		switch r.in[r.pos] {
+		case ',':
+			r.pos++
+			r.out = append(r.out, ',')
+		case ']':
+			r.pos++
+			r.out = append(r.out, ']')
+			return nil
+		default:
+			return r.errorf("数组中意外的字符 %q", r.in[r.pos])
+		}
+	}
+}
+
+// skipValue 跳过一个值，不递归，任意深度的输入都不会耗尽栈
+func (r *rewriter) skipValue() error {
+	var stack []byte
+	for {
+		r.skipSpace()
+		if r.pos >= len(r.in) {
+			return r.errorf("意外的结尾")
+		}
+
+		// 读取一个值或容器的开始
+		switch r.in[r.pos] {
+		case '{':
+			r.pos++
+			r.skipSpace()
+			if r.pos < len(r.in) && r.in[r.pos] == '}' {
+				r.pos++
+				break
+			}
+			stack = append(stack, '{')
+			if err := r.skipKey(); err != nil {
+				return err
+			}
+			continue
+		case '[':
+			r.pos++
+			r.skipSpace()
+			if r.pos < len(r.in) && r.in[r.pos] == ']' {
+				r.pos++
+				break
+			}
+			stack = append(stack, '[')
+			continue
+		default:
+			if err := r.scalar(); err != nil {
+				return err
+			}
+		}
+
+		// 一个值结束后，关闭已完成的容器或进入下一个元素
+		for {
+			if len(stack) == 0 {
+				return nil
+			}
+			r.skipSpace()
+			if r.pos >= len(r.in) {
+				return r.errorf("意外的结尾")
+			}
+			top := stack[len(stack)-1]
+			c := r.in[r.pos]
+			r.pos++
+			if c == ',' {
+				if top == '{' {
+					if err := r.skipKey(); err != nil {
+						return err
+					}
+				}
+				break
+			}
+			if (top == '{' && c == '}') || (top == '[' && c == ']') {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			r.pos--
+			return r.errorf("意外的字符 %q", c)
+		}
+	}
+}
+
+// skipKey 跳过字段名与冒号
+func (r *rewriter) skipKey() error {
+	r.skipSpace()
+	if r.pos >= len(r.in) || r.in[r.pos] != '"' {
+		return r.errorf("缺少字段名")
+	}
+	if _, err := r.scanString(); err != nil {
+		return err
+	}
+	r.skipSpace()
+	if r.pos >= len(r.in) || r.in[r.pos] != ':' {
+		return r.errorf("缺少冒号")
+	}
+	r.pos++
+	return nil
+}
+
+// scalar 跳过字符串、数字或字面量
+func (r *rewriter) scalar() error {
+	if r.pos >= len(r.in) {
+		return r.errorf("意外的结尾")
+	}
+	switch c := r.in[r.pos]; {
+	case c == '"':
+		_, err := r.scanString()
+		return err
+	case c == '-' || (c >= '0' && c <= '9'):
+		return r.scanNumber()
+	case c == 't':
+		return r.literal("true")
+	case c == 'f':
+		return r.literal("false")
+	case c == 'n':
+		return r.literal("null")
+	default:
+		return r.errorf("意外的字符 %q", c)
+	}
+}
+
+// literal 跳过 true、false 或 null
+func (r *rewriter) literal(word string) error {
+	if len(r.in)-r.pos < len(word) || string(r.in[r.pos:r.pos+len(word)]) != word {
+		return r.errorf("无效的字面量")
+	}
+	r.pos += len(word)
+	return nil
+}
+
+// scanString 跳过字符串，返回其中是否包含转义
+func (r *rewriter) scanString() (bool, error) {
+	escaped := false
+	i := r.pos + 1
+	for i < len(r.in) {
+		switch c := r.in[i]; {
+		case c == '"':
+			r.pos = i + 1
+			return escaped, nil
+		case c == '\\':
+			escaped = true
+			if i+1 >= len(r.in) {
+				r.pos = i
+				return false, r.errorf("字符串未结束")
+			}
+			switch r.in[i+1] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				i += 2
+			case 'u':
+				if i+6 > len(r.in) || !isHex(r.in[i+2:i+6]) {
+					r.pos = i
+					return false, r.errorf("无效的转义")
+				}
+				i += 6
+			default:
+				r.pos = i
+				return false, r.errorf("无效的转义")
+			}
+		case c < 0x20:
+			r.pos = i
+			return false, r.errorf("字符串中的控制字符")
+		default:
+			i++
+		}
+	}
+	r.pos = i
+	return false, r.errorf("字符串未结束")
+}
+
+// scanNumber 跳过数字
+func (r *rewriter) scanNumber() error {
+	i := r.pos
+	if r.in[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(r.in) && r.in[i] == '0':
+		i++
+	case i < len(r.in) && r.in[i] >= '1' && r.in[i] <= '9':
+		i = skipDigits(r.in, i)
+	default:
+		return r.errorf("无效的数字")
+	}
+	if i < len(r.in) && r.in[i] == '.' {
+		i++
+		if i >= len(r.in) || !isDigit(r.in[i]) {
+			return r.errorf("无效的数字")
+		}
+		i = skipDigits(r.in, i)
+	}
+	if i < len(r.in) && (r.in[i] == 'e' || r.in[i] == 'E') {
+		i++
+		if i < len(r.in) && (r.in[i] == '+' || r.in[i] == '-') {
+			i++
+		}
+		if i >= len(r.in) || !isDigit(r.in[i]) {
+			return r.errorf("无效的数字")
+		}
+		i = skipDigits(r.in, i)
+	}
+	r.pos = i
+	return nil
+}
+
+func skipDigits(data []byte, i int) int {
+	for i < len(data) && isDigit(data[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(data []byte) bool {
+	for _, c := range data {
+		if !isDigit(c) && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
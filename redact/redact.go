@@ -0,0 +1,314 @@
+// Package redact 提供日志、审计与诊断共用的敏感数据脱敏策略
+//
+// 策略由 Config 编译得到，编译后不可修改，可在多个 goroutine 中共享：
+//
+//	policy := redact.MustCompile(redact.Config{
+//		Fields:  []string{"*password*", "card_number"},
+//		Paths:   []string{"$.user.ssn", "$.items[*].cvv"},
+//		Headers: []string{"Cookie"},
+//		Params:  []string{"sig"},
+//	})
+//	body, err := policy.JSON(raw)
+//
+// 脱敏是失败安全的：超过最大嵌套深度的值整体替换为掩码，无法识别的 JSON 返回错误而不是原样输出
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/zzliekkas/flow/v2/config"
+)
+
+// DefaultMask 默认掩码
+const DefaultMask = "***MASKED***"
+
+// DefaultMaxDepth 默认最大嵌套深度
+const DefaultMaxDepth = 64
+
+// Config 脱敏配置
+type Config struct {
+	// Fields 字段名模式，不区分大小写，* 匹配任意字符，如 "*password*"、"card_number"；
+	// 同时作用于对象字段、请求头与查询参数
+	Fields []string
+	// Paths JSON 路径，如 "$.user.ssn"，"*" 匹配任意字段，"[*]" 匹配任意数组元素
+	Paths []string
+	// Headers 需要脱敏的请求头名称，不区分大小写
+	Headers []string
+	// Params 需要脱敏的查询参数名称，不区分大小写
+	Params []string
+	// Mask 替换敏感值的掩码，为空时使用 DefaultMask
+	Mask string
+	// LengthHint 在掩码后附加原值的大致长度（向上取整到8的倍数），如 "***MASKED***(~16)"
+	LengthHint bool
+	// MaxDepth 最大嵌套深度，超出的值整体替换为掩码，为0时使用 DefaultMaxDepth
+	MaxDepth int
+}
+
+// DefaultConfig 返回默认配置，与请求记录中间件原有的敏感字段规则一致
+func DefaultConfig() Config {
+	return Config{
+		Fields:  []string{"*password*", "*token*", "*secret*", "*key*", "*auth*", "*credit*", "*card*"},
+		Headers: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+	}
+}
+
+// LoadConfig 从配置加载脱敏设置，配置的字段、路径、请求头与参数追加到默认规则之后
+//
+//	redact:
+//	  fields: ["*ssn*", "phone"]
+//	  paths: ["$.user.address"]
+//	  headers: ["X-Session"]
+//	  params: ["sig"]
+//	  mask: "[REDACTED]"
+//	  length_hint: true
+//	  max_depth: 32
+func LoadConfig(configManager *config.ConfigManager) Config {
+	cfg := DefaultConfig()
+	cfg.Fields = append(cfg.Fields, configManager.GetStringSlice("redact.fields")...)
+	cfg.Paths = append(cfg.Paths, configManager.GetStringSlice("redact.paths")...)
+	cfg.Headers = append(cfg.Headers, configManager.GetStringSlice("redact.headers")...)
+	cfg.Params = append(cfg.Params, configManager.GetStringSlice("redact.params")...)
+	cfg.Mask = configManager.GetString("redact.mask")
+	cfg.LengthHint = configManager.GetBool("redact.length_hint")
+	cfg.MaxDepth = configManager.GetInt("redact.max_depth")
+	return cfg
+}
+
+// Policy 编译后的脱敏策略，不可修改，并发安全
+type Policy struct {
+	fields     nameMatcher
+	paths      [][]string
+	headers    map[string]struct{}
+	params     map[string]struct{}
+	mask       string
+	maskJSON   []byte
+	lengthHint bool
+	maxDepth   int
+
+	types sync.Map // reflect.Type -> *typeInfo
+}
+
+// Compile 编译脱敏策略
+func Compile(cfg Config) (*Policy, error) {
+	p := &Policy{
+		fields:     newNameMatcher(cfg.Fields),
+		headers:    lowerSet(cfg.Headers),
+		params:     lowerSet(cfg.Params),
+		mask:       cfg.Mask,
+		lengthHint: cfg.LengthHint,
+		maxDepth:   cfg.MaxDepth,
+	}
+	if p.mask == "" {
+		p.mask = DefaultMask
+	}
+	if p.maxDepth <= 0 {
+		p.maxDepth = DefaultMaxDepth
+	}
+	maskJSON, err := json.Marshal(p.mask)
+	if err != nil {
+		return nil, err
+	}
+	p.maskJSON = maskJSON
+
+	for _, path := range cfg.Paths {
+		segments, err := parsePath(path)
+		if err != nil {
+			return nil, err
+		}
+		p.paths = append(p.paths, segments)
+	}
+	return p, nil
+}
+
+// MustCompile 编译脱敏策略，配置无效时 panic
+func MustCompile(cfg Config) *Policy {
+	p, err := Compile(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+var defaultPolicy = MustCompile(DefaultConfig())
+
+// Default 返回使用默认配置的策略
+func Default() *Policy {
+	return defaultPolicy
+}
+
+// MatchField 判断字段名是否需要脱敏
+func (p *Policy) MatchField(name string) bool {
+	return p.fields.match(name)
+}
+
+// MatchHeader 判断请求头是否需要脱敏
+func (p *Policy) MatchHeader(name string) bool {
+	if _, ok := p.headers[strings.ToLower(name)]; ok {
+		return true
+	}
+	return p.fields.match(name)
+}
+
+// MatchParam 判断查询参数是否需要脱敏
+func (p *Policy) MatchParam(name string) bool {
+	if _, ok := p.params[strings.ToLower(name)]; ok {
+		return true
+	}
+	return p.fields.match(name)
+}
+
+// maskFor 返回替换长度为 size 的值的掩码，size 小于0表示长度未知
+func (p *Policy) maskFor(size int) string {
+	if !p.lengthHint || size < 0 {
+		return p.mask
+	}
+	return fmt.Sprintf("%s(~%d)", p.mask, (size+7)/8*8)
+}
+
+// appendMaskJSON 追加 JSON 编码的掩码
+func (p *Policy) appendMaskJSON(out []byte, size int) []byte {
+	if !p.lengthHint || size < 0 {
+		return append(out, p.maskJSON...)
+	}
+	encoded, _ := json.Marshal(p.maskFor(size))
+	return append(out, encoded...)
+}
+
+// nameMatcher 不区分大小写的字段名匹配
+type nameMatcher struct {
+	exact map[string]struct{}
+	globs [][]string // 按 * 拆分后的模式
+}
+
+// newNameMatcher 编译字段名模式
+func newNameMatcher(patterns []string) nameMatcher {
+	m := nameMatcher{exact: make(map[string]struct{})}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if !strings.Contains(pattern, "*") {
+			m.exact[pattern] = struct{}{}
+			continue
+		}
+		m.globs = append(m.globs, strings.Split(pattern, "*"))
+	}
+	return m
+}
+
+// match 判断名称是否匹配任一模式
+func (m nameMatcher) match(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := m.exact[name]; ok {
+		return true
+	}
+	for _, parts := range m.globs {
+		if globMatch(name, parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch 匹配按 * 拆分的模式：首段为前缀、末段为后缀，中间各段依次出现
+func globMatch(name string, parts []string) bool {
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return strings.HasSuffix(name, last)
+}
+
+// lowerSet 转换为小写集合
+func lowerSet(names []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = struct{}{}
+	}
+	return set
+}
+
+// anyIndex 路径中匹配任意数组元素的段
+const anyIndex = "[*]"
+
+// parsePath 解析 JSON 路径，支持 $.a.b、$.a.*、$.a[*].b
+func parsePath(path string) ([]string, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("redact: JSON 路径必须以 $ 开头: %s", path)
+	}
+	var segments []string
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, anyIndex):
+			segments = append(segments, anyIndex)
+			rest = rest[len(anyIndex):]
+		case rest[0] == '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("redact: JSON 路径包含空字段名: %s", path)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("redact: 不支持的 JSON 路径语法: %s", path)
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("redact: JSON 路径不能只有 $: %s", path)
+	}
+	return segments, nil
+}
+
+// cursor 路径规则的匹配进度
+type cursor struct {
+	rule int
+	pos  int
+}
+
+// rootCursors 返回所有路径规则的初始进度
+func (p *Policy) rootCursors() []cursor {
+	if len(p.paths) == 0 {
+		return nil
+	}
+	cursors := make([]cursor, len(p.paths))
+	for i := range p.paths {
+		cursors[i] = cursor{rule: i}
+	}
+	return cursors
+}
+
+// descend 进入对象字段（index 为false）或数组元素，返回子节点的进度以及子节点是否被某条路径完整匹配
+func (p *Policy) descend(cursors []cursor, key string, index bool) ([]cursor, bool) {
+	var next []cursor
+	for _, c := range cursors {
+		segment := p.paths[c.rule][c.pos]
+		var ok bool
+		if index {
+			ok = segment == anyIndex
+		} else {
+			ok = segment == "*" || segment == key
+		}
+		if !ok {
+			continue
+		}
+		if c.pos+1 == len(p.paths[c.rule]) {
+			return nil, true
+		}
+		next = append(next, cursor{rule: c.rule, pos: c.pos + 1})
+	}
+	return next, false
+}
@@ -0,0 +1,329 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPolicy(t testing.TB) *Policy {
+	p, err := Compile(Config{
+		Fields:  []string{"*password*", "card_number", "*token"},
+		Paths:   []string{"$.user.ssn", "$.items[*].cvv", "$.meta.*.secret_value"},
+		Headers: []string{"X-Session"},
+		Params:  []string{"sig"},
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestCompile_InvalidPath(t *testing.T) {
+	for _, path := range []string{"user.ssn", "$", "$..a", "$.a[0]"} {
+		_, err := Compile(Config{Paths: []string{path}})
+		assert.Error(t, err, path)
+	}
+}
+
+func TestMatchField(t *testing.T) {
+	p := testPolicy(t)
+	assert.True(t, p.MatchField("Password"))
+	assert.True(t, p.MatchField("old_password_hash"))
+	assert.True(t, p.MatchField("CARD_NUMBER"))
+	assert.True(t, p.MatchField("access_token"))
+	assert.False(t, p.MatchField("token_type"), "*token 只匹配后缀")
+	assert.False(t, p.MatchField("card_number_last4"))
+	assert.False(t, p.MatchField("username"))
+}
+
+func TestMap_NestedMatches(t *testing.T) {
+	p := testPolicy(t)
+	input := map[string]interface{}{
+		"username": "alice",
+		"password": "hunter2",
+		"user": map[string]interface{}{
+			"ssn":  "123-45-6789",
+			"name": "Alice",
+			"profile": map[string]interface{}{
+				"ssn": "not on path",
+			},
+		},
+		"items": []interface{}{
+			map[string]interface{}{"sku": "A", "cvv": 123},
+			map[string]interface{}{"sku": "B", "cvv": nil},
+		},
+		"meta": map[string]interface{}{
+			"stripe": map[string]interface{}{"secret_value": "sk_live"},
+		},
+		"credentials": map[string]interface{}{"api_token": "abc"},
+	}
+
+	got := p.Map(input)
+	assert.Equal(t, "alice", got["username"])
+	assert.Equal(t, DefaultMask, got["password"])
+
+	user := got["user"].(map[string]interface{})
+	assert.Equal(t, DefaultMask, user["ssn"])
+	assert.Equal(t, "Alice", user["name"])
+	assert.Equal(t, "not on path", user["profile"].(map[string]interface{})["ssn"])
+
+	items := got["items"].([]interface{})
+	assert.Equal(t, DefaultMask, items[0].(map[string]interface{})["cvv"])
+	assert.Nil(t, items[1].(map[string]interface{})["cvv"], "null 保持为 null")
+	assert.Equal(t, "B", items[1].(map[string]interface{})["sku"])
+
+	assert.Equal(t, DefaultMask, got["meta"].(map[string]interface{})["stripe"].(map[string]interface{})["secret_value"])
+	assert.Equal(t, DefaultMask, got["credentials"].(map[string]interface{})["api_token"])
+
+	// 原 map 不被修改
+	assert.Equal(t, "hunter2", input["password"])
+	assert.Nil(t, p.Map(nil))
+}
+
+type address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip" redact:"true"`
+}
+
+type auditable struct {
+	ID        int
+	createdAt time.Time
+}
+
+type account struct {
+	auditable
+	Name      string            `json:"name"`
+	Password  string            `json:"password"`
+	Email     string            `json:"email,omitempty"`
+	Ignored   string            `json:"-"`
+	Address   *address          `json:"address"`
+	Cards     []map[string]int  `json:"cards"`
+	Labels    map[int]string    `json:"labels"`
+	Raw       []byte            `json:"raw"`
+	Joined    time.Time         `json:"joined"`
+	Extra     map[string]string `json:"extra"`
+	Callback  func()            `json:"-"`
+	Reference *account          `json:"reference,omitempty"`
+}
+
+func TestValue_Struct(t *testing.T) {
+	p := testPolicy(t)
+	joined := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	got := p.Value(&account{
+		auditable: auditable{ID: 7},
+		Name:      "alice",
+		Password:  "hunter2",
+		Ignored:   "x",
+		Address:   &address{City: "Paris", Zip: "75001"},
+		Cards:     []map[string]int{{"card_number": 4111}},
+		Labels:    map[int]string{1: "one"},
+		Raw:       []byte("raw"),
+		Joined:    joined,
+	}).(map[string]interface{})
+
+	assert.Equal(t, 7, got["ID"], "嵌入结构体的字段被提升")
+	assert.Equal(t, "alice", got["name"])
+	assert.Equal(t, DefaultMask, got["password"])
+	assert.NotContains(t, got, "email", "omitempty 的零值被省略")
+	assert.NotContains(t, got, "-")
+	assert.NotContains(t, got, "Ignored")
+	assert.Equal(t, map[string]interface{}{"city": "Paris", "zip": DefaultMask}, got["address"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"card_number": DefaultMask}}, got["cards"])
+	assert.Equal(t, map[string]interface{}{"1": "one"}, got["labels"], "数字键转换为字符串")
+	assert.Equal(t, []byte("raw"), got["raw"])
+	assert.Equal(t, joined, got["joined"], "实现 Marshaler 的值原样返回")
+	assert.Nil(t, got["extra"])
+
+	// 类型信息被缓存，并发使用安全
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v := p.Value(account{Password: "x"}).(map[string]interface{})
+			assert.Equal(t, DefaultMask, v["password"])
+		}()
+	}
+	wg.Wait()
+}
+
+func TestValue_SelfReference(t *testing.T) {
+	p := MustCompile(Config{MaxDepth: 4})
+	a := &account{Name: "loop"}
+	a.Reference = a
+	got := p.Value(a).(map[string]interface{})
+	for depth := 1; depth < 4; depth++ {
+		got = got["reference"].(map[string]interface{})
+	}
+	assert.Equal(t, DefaultMask, got["reference"], "超过深度限制的值被替换")
+
+	var loop interface{}
+	loop = &loop
+	assert.NotPanics(t, func() { p.Value(loop) })
+}
+
+func TestValues(t *testing.T) {
+	p := testPolicy(t)
+	values := url.Values{"sig": {"abc"}, "page": {"2"}, "reset_token": {"t1", "t2"}}
+	got := p.Values(values)
+	assert.Equal(t, []string{DefaultMask}, got["sig"])
+	assert.Equal(t, []string{"2"}, got["page"])
+	assert.Equal(t, []string{DefaultMask, DefaultMask}, got["reset_token"])
+	assert.Equal(t, "abc", values.Get("sig"))
+}
+
+func TestQuery(t *testing.T) {
+	p := testPolicy(t)
+	assert.Equal(t, "b=2&SIG=***MASKED***&a=%20x&flag&pass%77ord=***MASKED***",
+		p.Query("b=2&SIG=deadbeef&a=%20x&flag&pass%77ord=hunter2"))
+	assert.Equal(t, "", p.Query(""))
+}
+
+func TestHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc")
+	h.Set("X-Session", "s1")
+	h.Set("Accept", "application/json")
+
+	got := testPolicy(t).Header(h)
+	assert.Equal(t, DefaultMask, got.Get("X-Session"))
+	assert.Equal(t, "Bearer abc", got.Get("Authorization"), "只有配置的请求头被替换")
+
+	got = Default().Header(h)
+	assert.Equal(t, DefaultMask, got.Get("Authorization"))
+	assert.Equal(t, "application/json", got.Get("Accept"))
+	assert.Equal(t, "Bearer abc", h.Get("Authorization"))
+}
+
+func TestLengthHint(t *testing.T) {
+	p := MustCompile(Config{Fields: []string{"password"}, Mask: "[REDACTED]", LengthHint: true})
+	assert.Equal(t, "[REDACTED](~8)", p.Map(map[string]interface{}{"password": "hunter2"})["password"])
+
+	out, err := p.JSON([]byte(`{"password":"0123456789"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"password":"[REDACTED](~16)"}`, string(out))
+}
+
+func TestJSON_MatchesFullParse(t *testing.T) {
+	p := testPolicy(t)
+	inputs := []string{
+		`{"username":"alice","password":"hunter2","user":{"ssn":"1","name":"A"}}`,
+		`{"items":[{"cvv":123,"sku":"A"},{"cvv":{"nested":[1,2]}},{"cvv":null}]}`,
+		`{"meta":{"a":{"secret_value":true},"b":{"secret_value":[1]}},"user":{"profile":{"ssn":"x"}}}`,
+		`{"passwordHint":{"deep":{"deeper":[1,2,{"x":"y"}]}},"n":-1.5e+10,"z":0}`,
+		"{ \"user\" : { \"ssn\" : \"a\\\"b\" , \"pass\\u0077ord\" : \"x\" } , \"list\" : [ ] , \"obj\" : { } }",
+		`[{"password":"x"},"password",1,true,false,null]`,
+		`{"123":"numeric key","1e3":{"card_number":1}}`,
+		`"just a string"`,
+		`42`,
+		`{"unicode":"中文","access_token":"😀"}`,
+	}
+	for _, input := range inputs {
+		got, err := p.JSON([]byte(input))
+		require.NoError(t, err, input)
+
+		var parsed interface{}
+		require.NoError(t, json.Unmarshal([]byte(input), &parsed))
+		want, err := json.Marshal(p.Value(parsed))
+		require.NoError(t, err)
+		assert.JSONEq(t, string(want), string(got), input)
+		assert.True(t, json.Valid(got), input)
+	}
+}
+
+func TestJSON_Invalid(t *testing.T) {
+	p := testPolicy(t)
+	inputs := []string{
+		``, `{`, `{"a"`, `{"a":}`, `{"a":1,}`, `[1,]`, `{"a":1}x`, `{a:1}`, `"unterminated`,
+		`{"password":{"x":[1,2}}`, `{"password":tru}`, `01`, `-`, `1.`, `1e`, `"\x"`, "\"\x01\"",
+		`{"password":"\u12"}`, `[1 2]`, `{"a":1 "b":2}`,
+	}
+	for _, input := range inputs {
+		assert.NotPanics(t, func() {
+			_, err := p.JSON([]byte(input))
+			assert.ErrorIs(t, err, ErrInvalidJSON, input)
+		}, input)
+	}
+}
+
+func TestJSON_DepthLimit(t *testing.T) {
+	p := MustCompile(Config{MaxDepth: 3})
+	out, err := p.JSON([]byte(`{"a":{"b":{"c":{"d":1}},"e":[1]},"f":[]}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":{"b":{"c":"***MASKED***"},"e":[1]},"f":[]}`, string(out))
+
+	// 与 Value 使用相同的深度语义
+	var parsed interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"a":{"b":{"c":{"d":1}},"e":[1]},"f":[]}`), &parsed))
+	want, _ := json.Marshal(p.Value(parsed))
+	assert.JSONEq(t, string(want), string(out))
+
+	// 极深的输入不会耗尽栈
+	deep := strings.Repeat(`{"a":[`, 200000) + strings.Repeat(`]}`, 200000)
+	out, err = Default().JSON([]byte(deep))
+	require.NoError(t, err)
+	assert.True(t, json.Valid(out))
+	assert.Contains(t, string(out), DefaultMask)
+
+	_, err = Default().JSON([]byte(strings.Repeat(`[`, 200000)))
+	assert.ErrorIs(t, err, ErrInvalidJSON)
+}
+
+func TestDefaultConfigMatchesLegacyRules(t *testing.T) {
+	p := Default()
+	for _, field := range []string{"password", "accessToken", "client_secret", "apiKey", "Authorization", "credit_limit", "card"} {
+		assert.True(t, p.MatchField(field), field)
+	}
+	assert.False(t, p.MatchField("username"))
+	assert.True(t, p.MatchHeader("Cookie"))
+}
+
+// largeBody 生成约100KB的 JSON 请求体
+func largeBody() []byte {
+	var b strings.Builder
+	b.WriteString(`{"users":[`)
+	for i := 0; b.Len() < 100*1024; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"id":%d,"name":"user-%d","email":"user%d@example.com","password":"secret-%d","tags":["a","b","c"],"profile":{"age":%d,"bio":"lorem ipsum dolor sit amet"}}`, i, i, i, i, i%90)
+	}
+	b.WriteString(`]}`)
+	return []byte(b.String())
+}
+
+func BenchmarkJSON_100KB(b *testing.B) {
+	p := Default()
+	body := largeBody()
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.JSON(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFullParse_100KB 完整解码、脱敏、编码，作为对照
+func BenchmarkFullParse_100KB(b *testing.B) {
+	p := Default()
+	body := largeBody()
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var parsed interface{}
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := json.Marshal(p.Value(parsed)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
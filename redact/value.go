@@ -0,0 +1,277 @@
+package redact
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// structInfo 结构体类型的字段信息，按策略缓存
+type structInfo struct {
+	fields []fieldInfo
+}
+
+// fieldInfo 结构体字段信息
+type fieldInfo struct {
+	index     []int
+	name      string // JSON 字段名
+	omitEmpty bool
+	masked    bool // 带有 redact:"true" 标签或字段名匹配
+}
+
+// Map 返回脱敏后的副本，原 map 不会被修改
+func (p *Policy) Map(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	return p.mapValue(m, p.rootCursors(), 0)
+}
+
+// Value 返回脱敏后的值，适合直接写入 JSON 日志
+//
+// 结构体按 JSON 字段名转换为 map[string]interface{}，切片与数组转换为 []interface{}，
+// 带有 redact:"true" 标签的字段总是被替换；实现了 json.Marshaler 或 encoding.TextMarshaler 的值原样返回
+func (p *Policy) Value(v interface{}) interface{} {
+	return p.walk(v, p.rootCursors(), 0)
+}
+
+// walk 脱敏任意值，常见的 JSON 解码类型不经过反射
+func (p *Policy) walk(v interface{}, cursors []cursor, depth int) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		if val == nil {
+			return val
+		}
+		if depth >= p.maxDepth {
+			return p.maskValue(val)
+		}
+		return p.mapValue(val, cursors, depth)
+	case []interface{}:
+		if val == nil {
+			return val
+		}
+		if depth >= p.maxDepth {
+			return p.maskValue(val)
+		}
+		result := make([]interface{}, len(val))
+		for i, item := range val {
+			result[i] = p.element(item, cursors, depth)
+		}
+		return result
+	case string, bool, float64, float32, int, int64, int32, uint, uint64, uint32, json.Number, json.RawMessage:
+		return v
+	}
+	return p.reflectValue(reflect.ValueOf(v), cursors, depth)
+}
+
+// mapValue 脱敏 map 的每个字段
+func (p *Policy) mapValue(m map[string]interface{}, cursors []cursor, depth int) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = p.field(k, v, cursors, depth)
+	}
+	return result
+}
+
+// field 脱敏对象字段的值
+func (p *Policy) field(key string, v interface{}, cursors []cursor, depth int) interface{} {
+	next, full := p.descend(cursors, key, false)
+	if full || p.fields.match(key) {
+		return p.maskValue(v)
+	}
+	return p.walk(v, next, depth+1)
+}
+
+// element 脱敏数组元素
+func (p *Policy) element(v interface{}, cursors []cursor, depth int) interface{} {
+	next, full := p.descend(cursors, "", true)
+	if full {
+		return p.maskValue(v)
+	}
+	return p.walk(v, next, depth+1)
+}
+
+// maskValue 返回替换值的掩码，nil 保持为 nil
+func (p *Policy) maskValue(v interface{}) interface{} {
+	if isNil(v) {
+		return nil
+	}
+	if !p.lengthHint {
+		return p.mask
+	}
+	if s, ok := v.(string); ok {
+		return p.maskFor(len(s))
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return p.mask
+	}
+	return p.maskFor(len(encoded))
+}
+
+// isNil 判断值是否为 nil 或 nil 指针
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// reflectValue 通过反射脱敏任意值
+func (p *Policy) reflectValue(rv reflect.Value, cursors []cursor, depth int) interface{} {
+	// 解引用指针与接口，自引用的指针链按深度限制截断
+	for hops := 0; rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface; hops++ {
+		if rv.IsNil() {
+			return nil
+		}
+		if hops >= p.maxDepth {
+			return p.mask
+		}
+		if rv.Type().Implements(jsonMarshalerType) || rv.Type().Implements(textMarshalerType) {
+			return rv.Interface()
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	if rv.Type().Implements(jsonMarshalerType) || rv.Type().Implements(textMarshalerType) {
+		return rv.Interface()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		if depth >= p.maxDepth {
+			return p.maskValue(rv.Interface())
+		}
+		info := p.structInfo(rv.Type())
+		result := make(map[string]interface{}, len(info.fields))
+		for _, f := range info.fields {
+			fv, err := rv.FieldByIndexErr(f.index)
+			if err != nil || !fv.CanInterface() {
+				continue
+			}
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			next, full := p.descend(cursors, f.name, false)
+			if f.masked || full {
+				result[f.name] = p.maskValue(fv.Interface())
+				continue
+			}
+			result[f.name] = p.reflectValue(fv, next, depth+1)
+		}
+		return result
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		if depth >= p.maxDepth {
+			return p.maskValue(rv.Interface())
+		}
+		result := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := mapKey(iter.Key())
+			next, full := p.descend(cursors, key, false)
+			if full || p.fields.match(key) {
+				result[key] = p.maskValue(iter.Value().Interface())
+				continue
+			}
+			result[key] = p.reflectValue(iter.Value(), next, depth+1)
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Interface()
+		}
+		if depth >= p.maxDepth {
+			return p.maskValue(rv.Interface())
+		}
+		result := make([]interface{}, rv.Len())
+		for i := range result {
+			next, full := p.descend(cursors, "", true)
+			if full {
+				result[i] = p.maskValue(rv.Index(i).Interface())
+				continue
+			}
+			result[i] = p.reflectValue(rv.Index(i), next, depth+1)
+		}
+		return result
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(rv.Interface())
+	}
+	return rv.Interface()
+}
+
+// mapKey 返回 map 键的字符串形式，与 encoding/json 一致
+func mapKey(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	if tm, ok := key.Interface().(encoding.TextMarshaler); ok {
+		if text, err := tm.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(key.Interface())
+}
+
+// structInfo 返回结构体类型的字段信息，首次使用时解析并缓存
+func (p *Policy) structInfo(t reflect.Type) *structInfo {
+	if cached, ok := p.types.Load(t); ok {
+		return cached.(*structInfo)
+	}
+
+	info := &structInfo{}
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			// 嵌入结构体的字段已经由 VisibleFields 提升
+			if ft.Kind() == reflect.Struct {
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		info.fields = append(info.fields, fieldInfo{
+			index:     f.Index,
+			name:      name,
+			omitEmpty: strings.Contains(opts, "omitempty"),
+			masked:    f.Tag.Get("redact") == "true" || p.fields.match(name),
+		})
+	}
+
+	actual, _ := p.types.LoadOrStore(t, info)
+	return actual.(*structInfo)
+}
@@ -0,0 +1,251 @@
+package flow
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 资源路由的动作
+const (
+	ActionIndex   = "index"   // GET    /users
+	ActionCreate  = "create"  // GET    /users/create，表单页面
+	ActionStore   = "store"   // POST   /users
+	ActionShow    = "show"    // GET    /users/:id
+	ActionEdit    = "edit"    // GET    /users/:id/edit，表单页面
+	ActionUpdate  = "update"  // PUT 与 PATCH /users/:id
+	ActionDestroy = "destroy" // DELETE /users/:id
+)
+
+// resourceActions 所有动作，按注册顺序排列
+var resourceActions = []string{ActionIndex, ActionCreate, ActionStore, ActionShow, ActionEdit, ActionUpdate, ActionDestroy}
+
+// ResourceController RESTful 资源控制器
+//
+// Resource 按控制器实现的方法注册路由，只实现其中一部分方法时只注册对应的路由；
+// 另外实现 ResourceFormController 时注册 Create 与 Edit 表单页面
+type ResourceController interface {
+	Index(c *Context)
+	Show(c *Context)
+	Store(c *Context)
+	Update(c *Context)
+	Destroy(c *Context)
+}
+
+// ResourceFormController 提供新建与编辑表单页面的资源控制器，用于服务端渲染的页面
+type ResourceFormController interface {
+	Create(c *Context)
+	Edit(c *Context)
+}
+
+// ResourceRoute 资源路由的属性，附加在每个资源路由上，用于路由列表与文档生成
+//
+//	route, _ := flow.AttrFrom[flow.ResourceRoute](c)
+type ResourceRoute struct {
+	Name     string // 路由名称，如 users.show、users.posts.index
+	Resource string // 资源名称，如 users、users.posts
+	Action   string // 动作，如 show
+	Summary  string // 文档摘要
+}
+
+// resourceConfig 资源路由配置
+type resourceConfig struct {
+	name       string
+	param      string
+	only       []string
+	except     []string
+	middleware map[string][]HandlerFunc
+	model      func(param string) HandlerFunc
+}
+
+// ResourceOption 资源路由选项
+type ResourceOption func(*resourceConfig)
+
+// Only 只注册指定的动作
+func Only(actions ...string) ResourceOption {
+	return func(cfg *resourceConfig) {
+		cfg.only = append(cfg.only, actions...)
+	}
+}
+
+// Except 不注册指定的动作
+func Except(actions ...string) ResourceOption {
+	return func(cfg *resourceConfig) {
+		cfg.except = append(cfg.except, actions...)
+	}
+}
+
+// WithResourceName 设置资源名称，默认由路径中的静态段组成，如 /users/:user_id/posts 为 users.posts
+func WithResourceName(name string) ResourceOption {
+	return func(cfg *resourceConfig) {
+		cfg.name = name
+	}
+}
+
+// WithResourceParam 设置单个资源的路由参数名称，默认为 id
+func WithResourceParam(param string) ResourceOption {
+	return func(cfg *resourceConfig) {
+		cfg.param = param
+	}
+}
+
+// WithActionMiddleware 为指定动作添加中间件，action 为 "*" 时作用于所有动作
+func WithActionMiddleware(action string, handlers ...HandlerFunc) ResourceOption {
+	return func(cfg *resourceConfig) {
+		if cfg.middleware == nil {
+			cfg.middleware = make(map[string][]HandlerFunc)
+		}
+		cfg.middleware[action] = append(cfg.middleware[action], handlers...)
+	}
+}
+
+// WithResourceModel 为 show、edit、update、destroy 按路由参数加载模型（见 BindModel），
+// 处理函数通过 ModelFrom 读取，记录不存在时响应404
+//
+//	e.Resource("/users", users, flow.WithResourceModel[User]())
+//
+//	func (ctrl *UserController) Show(c *flow.Context) {
+//		user, _ := flow.ModelFrom[User](c)
+//		c.JSON(http.StatusOK, user)
+//	}
+func WithResourceModel[T any](opts ...ModelOption) ResourceOption {
+	return func(cfg *resourceConfig) {
+		cfg.model = func(param string) HandlerFunc {
+			return BindModel[T](param, opts...)
+		}
+	}
+}
+
+// resourceRegistrar 注册单个路由的函数，由 Engine 与 RouterGroup 提供
+type resourceRegistrar func(httpMethod, relativePath string, handlers ...HandlerFunc) *Route
+
+// Resource 按 RESTful 约定注册资源控制器的路由，返回注册的路由：
+//
+//	GET    /users            index
+//	GET    /users/create     create（实现 ResourceFormController 时）
+//	POST   /users            store
+//	GET    /users/:id        show
+//	GET    /users/:id/edit   edit（实现 ResourceFormController 时）
+//	PUT    /users/:id        update
+//	PATCH  /users/:id        update
+//	DELETE /users/:id        destroy
+//
+// 控制器未实现的方法不注册。嵌套资源直接写在路径中，父资源参数通过 c.Param 读取：
+//
+//	e.Resource("/users/:user_id/posts", posts, flow.Only(flow.ActionIndex, flow.ActionStore))
+//
+// 每个路由附加 ResourceRoute 属性；Only、Except 与 WithActionMiddleware 中的未知动作会 panic
+func (e *Engine) Resource(path string, controller interface{}, opts ...ResourceOption) []*Route {
+	return registerResource(e.Handle, path, controller, opts)
+}
+
+// Resource 在路由组中注册资源控制器的路由，见 Engine.Resource，资源名称不包含路由组前缀
+func (g *RouterGroup) Resource(path string, controller interface{}, opts ...ResourceOption) []*Route {
+	return registerResource(g.Handle, path, controller, opts)
+}
+
+// registerResource 注册资源路由
+func registerResource(register resourceRegistrar, path string, controller interface{}, opts []ResourceOption) []*Route {
+	cfg := &resourceConfig{param: "id"}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	for _, action := range append(append([]string(nil), cfg.only...), cfg.except...) {
+		checkResourceAction(action)
+	}
+	for action := range cfg.middleware {
+		if action != "*" {
+			checkResourceAction(action)
+		}
+	}
+
+	collection := strings.TrimRight(path, "/")
+	if collection == "" {
+		collection = "/"
+	}
+	member := strings.TrimRight(collection, "/") + "/:" + cfg.param
+	if cfg.name == "" {
+		cfg.name = resourceName(path)
+	}
+
+	type endpoint struct {
+		action  string
+		methods []string
+		path    string
+		handler HandlerFunc
+		summary string
+	}
+	var endpoints []endpoint
+	add := func(action, path, summary string, handler HandlerFunc, methods ...string) {
+		endpoints = append(endpoints, endpoint{action: action, methods: methods, path: path, handler: handler, summary: summary})
+	}
+	if c, ok := controller.(interface{ Index(*Context) }); ok {
+		add(ActionIndex, collection, "列出 "+cfg.name, c.Index, http.MethodGet)
+	}
+	if c, ok := controller.(interface{ Create(*Context) }); ok {
+		add(ActionCreate, strings.TrimRight(collection, "/")+"/create", "新建 "+cfg.name+" 的表单", c.Create, http.MethodGet)
+	}
+	if c, ok := controller.(interface{ Store(*Context) }); ok {
+		add(ActionStore, collection, "创建 "+cfg.name, c.Store, http.MethodPost)
+	}
+	if c, ok := controller.(interface{ Show(*Context) }); ok {
+		add(ActionShow, member, "获取单个 "+cfg.name, c.Show, http.MethodGet)
+	}
+	if c, ok := controller.(interface{ Edit(*Context) }); ok {
+		add(ActionEdit, member+"/edit", "编辑 "+cfg.name+" 的表单", c.Edit, http.MethodGet)
+	}
+	if c, ok := controller.(interface{ Update(*Context) }); ok {
+		add(ActionUpdate, member, "更新 "+cfg.name, c.Update, http.MethodPut, http.MethodPatch)
+	}
+	if c, ok := controller.(interface{ Destroy(*Context) }); ok {
+		add(ActionDestroy, member, "删除 "+cfg.name, c.Destroy, http.MethodDelete)
+	}
+	if len(endpoints) == 0 {
+		panic(fmt.Sprintf("flow: %T 没有实现任何资源动作", controller))
+	}
+
+	var routes []*Route
+	for _, ep := range endpoints {
+		if len(cfg.only) > 0 && !containsString(cfg.only, ep.action) || containsString(cfg.except, ep.action) {
+			continue
+		}
+		handlers := []HandlerFunc{WithAttr(ResourceRoute{
+			Name:     cfg.name + "." + ep.action,
+			Resource: cfg.name,
+			Action:   ep.action,
+			Summary:  ep.summary,
+		})}
+		handlers = append(handlers, cfg.middleware["*"]...)
+		handlers = append(handlers, cfg.middleware[ep.action]...)
+		switch ep.action {
+		case ActionShow, ActionEdit, ActionUpdate, ActionDestroy:
+			if cfg.model != nil {
+				handlers = append(handlers, cfg.model(cfg.param))
+			}
+		}
+		handlers = append(handlers, ep.handler)
+		for _, method := range ep.methods {
+			routes = append(routes, register(method, ep.path, handlers...))
+		}
+	}
+	return routes
+}
+
+// checkResourceAction 检查动作名称
+func checkResourceAction(action string) {
+	if !containsString(resourceActions, action) {
+		panic(fmt.Sprintf("flow: 未知的资源动作 %q", action))
+	}
+}
+
+// resourceName 由路径中的静态段生成资源名称，/api/users/:user_id/posts 为 api.users.posts
+func resourceName(path string) string {
+	var parts []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			continue
+		}
+		parts = append(parts, segment)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,206 @@
+package flow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accountController 实现全部资源动作，响应 动作 与路由参数
+type accountController struct{}
+
+func (accountController) Index(c *Context)   { c.String(http.StatusOK, "index") }
+func (accountController) Create(c *Context)  { c.String(http.StatusOK, "create") }
+func (accountController) Store(c *Context)   { c.String(http.StatusCreated, "store") }
+func (accountController) Show(c *Context)    { c.String(http.StatusOK, "show "+c.Param("id")) }
+func (accountController) Edit(c *Context)    { c.String(http.StatusOK, "edit "+c.Param("id")) }
+func (accountController) Update(c *Context)  { c.String(http.StatusOK, "update "+c.Param("id")) }
+func (accountController) Destroy(c *Context) { c.Status(http.StatusNoContent) }
+
+var _ ResourceController = accountController{}
+var _ ResourceFormController = accountController{}
+
+// orderController 只实现部分动作的嵌套资源控制器
+type orderController struct{}
+
+func (orderController) Index(c *Context) {
+	c.String(http.StatusOK, "orders of "+c.Param("account_id"))
+}
+
+func (orderController) Show(c *Context) {
+	c.String(http.StatusOK, "order "+c.Param("id")+" of "+c.Param("account_id"))
+}
+
+// routeTable 返回 "方法 路径 名称" 列表
+func resourceRouteTable(e *Engine) []string {
+	var table []string
+	for _, route := range e.RouteList() {
+		name := ""
+		if attr, ok := route.Attrs["flow.ResourceRoute"].(ResourceRoute); ok {
+			name = attr.Name
+		}
+		table = append(table, route.Method+" "+route.Path+" "+name)
+	}
+	return table
+}
+
+func serveResource(e *Engine, method, path string) (int, string) {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w.Code, w.Body.String()
+}
+
+func TestResource_RouteTable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := New()
+	routes := e.Resource("/accounts", accountController{})
+	assert.Len(t, routes, 8)
+	assert.Equal(t, []string{
+		"GET /accounts accounts.index",
+		"GET /accounts/create accounts.create",
+		"POST /accounts accounts.store",
+		"GET /accounts/:id accounts.show",
+		"GET /accounts/:id/edit accounts.edit",
+		"PUT /accounts/:id accounts.update",
+		"PATCH /accounts/:id accounts.update",
+		"DELETE /accounts/:id accounts.destroy",
+	}, resourceRouteTable(e))
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/accounts", "index"},
+		{http.MethodGet, "/accounts/create", "create"},
+		{http.MethodGet, "/accounts/7", "show 7"},
+		{http.MethodGet, "/accounts/7/edit", "edit 7"},
+		{http.MethodPatch, "/accounts/7", "update 7"},
+	} {
+		_, body := serveResource(e, tc.method, tc.path)
+		assert.Equal(t, tc.body, body, tc.method+" "+tc.path)
+	}
+
+	// Only 与 Except
+	e = New()
+	e.Resource("/accounts", accountController{}, Only(ActionIndex, ActionShow))
+	assert.Equal(t, []string{"GET /accounts accounts.index", "GET /accounts/:id accounts.show"}, resourceRouteTable(e))
+
+	e = New()
+	e.Group("/api").Resource("/accounts", accountController{}, Except(ActionCreate, ActionEdit, ActionUpdate), WithResourceParam("account_id"))
+	assert.Equal(t, []string{
+		"GET /api/accounts accounts.index",
+		"POST /api/accounts accounts.store",
+		"GET /api/accounts/:account_id accounts.show",
+		"DELETE /api/accounts/:account_id accounts.destroy",
+	}, resourceRouteTable(e))
+
+	// 未实现的方法不注册，未知动作 panic
+	e = New()
+	e.Resource("/accounts/:account_id/orders", orderController{})
+	assert.Equal(t, []string{
+		"GET /accounts/:account_id/orders accounts.orders.index",
+		"GET /accounts/:account_id/orders/:id accounts.orders.show",
+	}, resourceRouteTable(e))
+	assert.Panics(t, func() { New().Resource("/x", accountController{}, Only("list")) })
+	assert.Panics(t, func() { New().Resource("/x", struct{}{}) })
+}
+
+func TestResource_NestedParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := New()
+	e.Resource("/accounts", accountController{}, Only(ActionShow))
+	e.Resource("/accounts/:id/orders", orderController{}, WithResourceParam("order_id"), WithResourceName("orders"))
+
+	_, body := serveResource(e, http.MethodGet, "/accounts/3")
+	assert.Equal(t, "show 3", body)
+
+	e = New()
+	e.Resource("/accounts/:account_id/orders", orderController{})
+	_, body = serveResource(e, http.MethodGet, "/accounts/3/orders")
+	assert.Equal(t, "orders of 3", body)
+	_, body = serveResource(e, http.MethodGet, "/accounts/3/orders/9")
+	assert.Equal(t, "order 9 of 3", body)
+}
+
+func TestResource_ActionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var trace []string
+	guard := func(c *Context) {
+		if c.GetHeader("X-Admin") == "" {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+
+	e := New()
+	e.Resource("/accounts", accountController{},
+		WithActionMiddleware("*", recordMiddleware("all", &trace)),
+		WithActionMiddleware(ActionDestroy, guard),
+	)
+
+	code, _ := serveResource(e, http.MethodGet, "/accounts")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = serveResource(e, http.MethodDelete, "/accounts/1")
+	assert.Equal(t, http.StatusForbidden, code, "只有 destroy 需要授权")
+	assert.Equal(t, []string{"all", "all"}, trace)
+
+	// 动作中间件只出现在对应路由的中间件链中
+	middleware := map[string]int{}
+	for _, route := range e.RouteList() {
+		middleware[route.Method+" "+route.Path] = len(route.Middleware)
+	}
+	assert.Equal(t, middleware["GET /accounts"]+1, middleware["DELETE /accounts/:id"])
+	assert.Panics(t, func() { New().Resource("/x", accountController{}, WithActionMiddleware("remove", guard)) })
+}
+
+func TestResource_DocsMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := New()
+	e.Resource("/accounts", accountController{}, Only(ActionShow, ActionStore))
+	var seen ResourceRoute
+	e.GET("/probe/:id", WithAttr(ResourceRoute{Name: "probe"}), func(c *Context) {
+		seen, _ = AttrFrom[ResourceRoute](c)
+	})
+
+	routes := e.RouteList()
+	require.Len(t, routes, 3)
+	assert.Equal(t, ResourceRoute{Name: "accounts.store", Resource: "accounts", Action: ActionStore, Summary: "创建 accounts"},
+		routes[0].Attrs["flow.ResourceRoute"])
+	assert.Equal(t, ResourceRoute{Name: "accounts.show", Resource: "accounts", Action: ActionShow, Summary: "获取单个 accounts"},
+		routes[1].Attrs["flow.ResourceRoute"])
+
+	serveResource(e, http.MethodGet, "/probe/1")
+	assert.Equal(t, "probe", seen.Name)
+}
+
+func TestResource_Model(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := newModelTestDB(t)
+	e := New()
+	e.Resource("/accounts", modelController{}, WithResourceModel[bindAccount](WithModelDB(conn)))
+
+	code, body := serveResource(e, http.MethodGet, "/accounts/1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Acme", body)
+	code, _ = serveResource(e, http.MethodDelete, "/accounts/99")
+	assert.Equal(t, http.StatusNotFound, code, "记录不存在时不进入处理函数")
+	code, _ = serveResource(e, http.MethodGet, "/accounts")
+	assert.Equal(t, http.StatusOK, code, "列表不加载模型")
+}
+
+// modelController 从上下文读取 WithResourceModel 加载的模型
+type modelController struct{}
+
+func (modelController) Index(c *Context) { c.Status(http.StatusOK) }
+
+func (modelController) Show(c *Context) {
+	account, _ := ModelFrom[bindAccount](c)
+	c.String(http.StatusOK, account.Name)
+}
+
+func (modelController) Destroy(c *Context) {
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,149 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultShutdownTimeout 默认的优雅关闭时间，超过后仍未完成的请求被中断
+const DefaultShutdownTimeout = 5 * time.Second
+
+// 平滑重启相关错误
+var (
+	// ErrRestartUnsupported 当前系统不支持平滑重启（Windows）
+	ErrRestartUnsupported = errors.New("flow: 当前系统不支持平滑重启")
+	// ErrRestartInProgress 已有一次平滑重启正在进行
+	ErrRestartInProgress = errors.New("flow: 平滑重启正在进行")
+)
+
+// 新进程通过环境变量识别父进程传递的监听器
+const (
+	envRestartPID = "FLOW_RESTART_PID" // 父进程ID，新进程只在父进程与之相同时接管
+	envRestartFDs = "FLOW_RESTART_FDS" // 传递的监听器数量，文件描述符从3开始，之后一个是就绪通知管道
+)
+
+// restartState 平滑重启的状态
+type restartState struct {
+	enabled    bool
+	pidFile    string
+	inProgress atomic.Bool    // 同一时间只允许一次重启，交接完成后保持为 true
+	handedOff  atomic.Bool    // 监听器已交给新进程
+	listeners  []net.Listener // Run 正在使用的监听器
+	ready      *os.File       // 新进程：就绪后通知父进程的管道
+
+	doneOnce sync.Once
+	done     chan struct{} // 交接完成、进行中的请求处理完毕后关闭
+}
+
+// WithGracefulRestart 返回一个启用平滑重启的选项（仅 Linux 等 Unix 系统）
+//
+// Run 期间收到 SIGUSR2 时以相同的参数启动当前可执行文件，通过文件描述符把全部监听器传给新进程；
+// 新进程启动任务完成、开始处理请求后通知父进程，父进程随即停止接受新连接，等待进行中的请求处理完
+// （最长为 WithShutdownTimeout 设置的时间）后 Run 返回 http.ErrServerClosed，Restarted 返回的通道关闭。
+// 新进程启动失败或未在最长启动时间内就绪时父进程继续服务。同一时间只进行一次重启，启动完成前收到的信号被忽略。
+//
+// 适用于 TCP 地址、WithUnixSocket 与 WithSystemdSocket；应用自己创建并通过 WithListener 传入的监听器在新进程中
+// 需要重新创建，通常无法绑定同一地址。配合 WithPIDFile 与 flow restart 命令使用：
+//
+//	e := flow.New(flow.WithGracefulRestart(), flow.WithPIDFile("/run/app/app.pid"))
+//
+// Windows 上 Run 记录警告后照常运行，Restart 返回 ErrRestartUnsupported
+func WithGracefulRestart() Option {
+	return func(e *Engine) {
+		e.restart.enabled = true
+	}
+}
+
+// WithPIDFile 返回一个写入PID文件的选项
+//
+// Run 在开始处理请求后写入当前进程ID，退出时删除（文件已被平滑重启的新进程覆盖时不删除）
+func WithPIDFile(path string) Option {
+	return func(e *Engine) {
+		e.restart.pidFile = path
+	}
+}
+
+// WithShutdownTimeout 返回一个设置优雅关闭时间的选项，用于 WaitForTermination 与平滑重启，默认为 DefaultShutdownTimeout
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(e *Engine) {
+		e.shutdownTimeout = d
+	}
+}
+
+// Restarted 返回平滑重启完成后关闭的通道，此时监听器已交给新进程，旧进程中的请求已处理完毕，应用应当退出
+func (e *Engine) Restarted() <-chan struct{} {
+	e.restart.doneOnce.Do(func() {
+		e.restart.done = make(chan struct{})
+	})
+	return e.restart.done
+}
+
+// shutdownTimeoutOrDefault 返回优雅关闭时间
+func (e *Engine) shutdownTimeoutOrDefault() time.Duration {
+	if e.shutdownTimeout > 0 {
+		return e.shutdownTimeout
+	}
+	return DefaultShutdownTimeout
+}
+
+// ReadPIDFile 读取PID文件中的进程ID
+func ReadPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("flow: PID文件 %s 的内容无效", path)
+	}
+	return pid, nil
+}
+
+// writePIDFile 写入当前进程ID，先写临时文件再重命名，读取方不会读到不完整的内容
+func (e *Engine) writePIDFile() {
+	path := e.restart.pidFile
+	if path == "" {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err == nil {
+		_, err = tmp.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		flog.Warnf("写入PID文件 %s 失败: %v", path, err)
+	}
+}
+
+// removePIDFile 删除仍指向当前进程的PID文件
+func (e *Engine) removePIDFile() {
+	path := e.restart.pidFile
+	if path == "" {
+		return
+	}
+	if pid, err := ReadPIDFile(path); err == nil && pid == os.Getpid() {
+		os.Remove(path)
+	}
+}
+
+// finishRestart 交接完成后关闭 Restarted 返回的通道
+func (e *Engine) finishRestart() {
+	e.Restarted()
+	close(e.restart.done)
+}
@@ -0,0 +1,31 @@
+//go:build !unix
+
+package flow
+
+import "net"
+
+// SignalRestart 当前系统不支持平滑重启，返回 ErrRestartUnsupported
+func SignalRestart(pid int) error {
+	return ErrRestartUnsupported
+}
+
+// Restart 当前系统不支持平滑重启，返回 ErrRestartUnsupported
+func (e *Engine) Restart() error {
+	return ErrRestartUnsupported
+}
+
+// inheritedListeners 当前系统不支持平滑重启，总是返回空
+func (e *Engine) inheritedListeners() ([]net.Listener, error) {
+	return nil, nil
+}
+
+// notifyRestartReady 当前系统不支持平滑重启
+func (e *Engine) notifyRestartReady() {}
+
+// watchRestartSignal 启用了平滑重启时记录警告
+func (e *Engine) watchRestartSignal() func() {
+	if e.restart.enabled {
+		flog.Warnf("%v，WithGracefulRestart 不生效", ErrRestartUnsupported)
+	}
+	return func() {}
+}
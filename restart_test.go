@@ -0,0 +1,179 @@
+package flow
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildRestartServer 构建 testdata/restartserver，版本号写入响应内容
+func buildRestartServer(t *testing.T, output, version string) {
+	t.Helper()
+	cmd := exec.Command("go", "build", "-o", output, "-ldflags", "-X main.version="+version, "./testdata/restartserver")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestRestart_ZeroDowntimeHandoff(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不支持平滑重启")
+	}
+	if testing.Short() {
+		t.Skip("需要构建测试服务器")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("找不到 go 命令")
+	}
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "server")
+	pidFile := filepath.Join(dir, "server.pid")
+	addr := freeAddr(t)
+	buildRestartServer(t, binary, "v1")
+
+	server := exec.Command(binary, addr, pidFile)
+	server.Env = append(os.Environ(), "FLOW_HIDE_BANNER=true")
+	server.Stdout = os.Stderr
+	server.Stderr = os.Stderr
+	require.NoError(t, server.Start())
+	oldExited := make(chan error, 1)
+	go func() { oldExited <- server.Wait() }()
+	t.Cleanup(func() {
+		if pid, err := ReadPIDFile(pidFile); err == nil {
+			if process, err := os.FindProcess(pid); err == nil {
+				process.Signal(os.Interrupt)
+			}
+		}
+		server.Process.Kill()
+	})
+
+	require.Eventually(t, func() bool {
+		pid, err := ReadPIDFile(pidFile)
+		return err == nil && pid == server.Process.Pid
+	}, 10*time.Second, 20*time.Millisecond, "旧进程就绪后写入PID文件")
+
+	// 替换可执行文件，新进程运行新版本
+	buildRestartServer(t, binary+".new", "v2")
+	require.NoError(t, os.Rename(binary+".new", binary))
+
+	// 交接期间持续发送请求
+	var failed, total atomic.Int64
+	var mu sync.Mutex
+	versions := make(map[string]int)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	client := &http.Client{Timeout: 5 * time.Second}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				total.Add(1)
+				resp, err := client.Get("http://" + addr + "/")
+				if err != nil {
+					failed.Add(1)
+					t.Logf("请求失败: %v", err)
+					continue
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					failed.Add(1)
+					t.Logf("请求失败: %d %s", resp.StatusCode, body)
+					continue
+				}
+				mu.Lock()
+				versions[string(body)]++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, SignalRestart(server.Process.Pid))
+
+	// 旧进程处理完请求后退出，PID文件指向新进程
+	select {
+	case err := <-oldExited:
+		assert.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("等待旧进程退出超时")
+	}
+	newPID, err := ReadPIDFile(pidFile)
+	require.NoError(t, err)
+	assert.NotEqual(t, server.Process.Pid, newPID)
+
+	time.Sleep(200 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	assert.Zero(t, failed.Load(), "交接期间没有失败的请求")
+	assert.Greater(t, total.Load(), int64(50))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Greater(t, versions["v1"], 0)
+	assert.Greater(t, versions["v2"], 0)
+
+	code, _, body := getURL(t, "http://"+addr+"/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "v2", body)
+}
+
+func TestRestart_OneAtATime(t *testing.T) {
+	e := newRouteTestEngine()
+	if runtime.GOOS == "windows" {
+		assert.ErrorIs(t, e.Restart(), ErrRestartUnsupported)
+		return
+	}
+
+	// 未开始处理请求时不能重启
+	assert.ErrorContains(t, e.Restart(), "尚未开始处理请求")
+	assert.False(t, e.restart.inProgress.Load(), "失败后允许再次重启")
+
+	e.restart.inProgress.Store(true)
+	assert.ErrorIs(t, e.Restart(), ErrRestartInProgress)
+}
+
+func TestPIDFile(t *testing.T) {
+	t.Setenv("FLOW_HIDE_BANNER", "true")
+	path := filepath.Join(t.TempDir(), "app.pid")
+
+	e := New(WithMode("test"), WithPIDFile(path))
+	e.GET("/ping", reply("pong"))
+	done := runUntilReady(t, e, freeAddr(t))
+	pid, err := ReadPIDFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+
+	require.NoError(t, e.Shutdown(context.Background()))
+	<-done
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "退出时删除PID文件")
+
+	// 已被其他进程覆盖的PID文件不删除
+	require.NoError(t, os.WriteFile(path, []byte(strconv.Itoa(os.Getpid()+1)), 0o644))
+	e.removePIDFile()
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("abc"), 0o644))
+	_, err = ReadPIDFile(path)
+	assert.ErrorContains(t, err, "内容无效")
+}
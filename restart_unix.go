@@ -0,0 +1,242 @@
+//go:build unix
+
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// restartReadyGrace 在最长启动时间之外，等待新进程启动与加载的额外时间
+const restartReadyGrace = 10 * time.Second
+
+// SignalRestart 向进程发送平滑重启信号（SIGUSR2）
+func SignalRestart(pid int) error {
+	return syscall.Kill(pid, syscall.SIGUSR2)
+}
+
+// Restart 平滑重启：启动新进程并交出监听器，新进程就绪后停止接受新连接并等待进行中的请求处理完毕
+//
+// 只能在 Run 开始处理请求后调用，返回nil时交接已完成，Run 随后返回 http.ErrServerClosed。
+// 新进程启动失败时返回错误，当前进程继续服务
+func (e *Engine) Restart() error {
+	if !e.restart.inProgress.CompareAndSwap(false, true) {
+		return ErrRestartInProgress
+	}
+	handedOff := false
+	defer func() {
+		if !handedOff {
+			e.restart.inProgress.Store(false)
+		}
+	}()
+	if e.server == nil || !e.Ready() || len(e.restart.listeners) == 0 {
+		return errors.New("flow: 服务器尚未开始处理请求，不能重启")
+	}
+
+	flog.Infof("平滑重启: 启动新进程")
+	child, ready, err := e.startChild()
+	if err != nil {
+		return fmt.Errorf("flow: 启动新进程失败: %w", err)
+	}
+	if err := waitChildReady(child, ready, e.restartReadyTimeout()); err != nil {
+		return fmt.Errorf("flow: 新进程 %d 未就绪: %w", child.Pid, err)
+	}
+	pid := child.Pid
+	_ = child.Release()
+
+	// 监听器已由新进程接管，关闭时不能删除套接字文件
+	handedOff = true
+	e.restart.handedOff.Store(true)
+	for _, listener := range e.restart.listeners {
+		if unix, ok := listener.(*net.UnixListener); ok {
+			unix.SetUnlinkOnClose(false)
+		}
+	}
+
+	timeout := e.shutdownTimeoutOrDefault()
+	flog.Infof("平滑重启: 新进程 %d 已就绪，停止接受新连接，等待进行中的请求完成（最长 %s）", pid, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err = e.Shutdown(ctx)
+	if err != nil {
+		flog.Warnf("平滑重启: 等待请求完成超时: %v", err)
+	}
+	flog.Infof("平滑重启: 交接完成，当前进程 %d 退出", os.Getpid())
+	e.finishRestart()
+	return err
+}
+
+// restartReadyTimeout 等待新进程就绪的最长时间
+func (e *Engine) restartReadyTimeout() time.Duration {
+	timeout := e.readiness.timeout
+	if timeout <= 0 {
+		timeout = DefaultStartupTimeout
+	}
+	return timeout + restartReadyGrace
+}
+
+// startChild 以相同的参数与环境启动当前可执行文件，传递监听器与就绪通知管道
+func (e *Engine) startChild() (*os.Process, *os.File, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := make([]*os.File, 0, len(e.restart.listeners)+1)
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	for _, listener := range e.restart.listeners {
+		filer, ok := listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return nil, nil, fmt.Errorf("监听器 %s（%T）不能传递给新进程", listenerAddr(listener), listener)
+		}
+		file, err := filer.File()
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, file)
+	}
+
+	ready, notify, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	files = append(files, notify)
+
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, envRestartPID+"=") && !strings.HasPrefix(kv, envRestartFDs+"=") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env,
+		envRestartPID+"="+strconv.Itoa(os.Getpid()),
+		envRestartFDs+"="+strconv.Itoa(len(e.restart.listeners)),
+	)
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		ready.Close()
+		return nil, nil, err
+	}
+	flog.Infof("平滑重启: 新进程 %d 已启动，传递 %d 个监听器", cmd.Process.Pid, len(e.restart.listeners))
+	return cmd.Process, ready, nil
+}
+
+// waitChildReady 等待新进程通过管道通知就绪，新进程退出或超时时返回错误
+func waitChildReady(child *os.Process, ready *os.File, timeout time.Duration) error {
+	defer ready.Close()
+	result := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if n, _ := ready.Read(buf); n == 1 {
+			result <- nil
+			return
+		}
+		// 新进程退出后管道关闭
+		state, err := child.Wait()
+		if err == nil {
+			err = fmt.Errorf("进程已退出: %s", state)
+		}
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		_ = child.Kill()
+		<-result
+		return fmt.Errorf("%s 内未就绪，已终止", timeout)
+	}
+}
+
+// inheritedListeners 接管父进程平滑重启时传递的监听器，不是由平滑重启启动时返回空
+func (e *Engine) inheritedListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv(envRestartPID))
+	if err != nil || pid != os.Getppid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv(envRestartFDs))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	os.Unsetenv(envRestartPID)
+	os.Unsetenv(envRestartFDs)
+
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		file := newListenFile(uintptr(listenFDsStart+i), "flow-restart-"+strconv.Itoa(i))
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("flow: 接管父进程的监听器失败: %w", err)
+		}
+		listeners = append(listeners, listener)
+	}
+	e.restart.ready = newListenFile(uintptr(listenFDsStart+count), "flow-restart-ready")
+	flog.Infof("平滑重启: 从父进程 %d 接管 %d 个监听器", pid, count)
+	return listeners, nil
+}
+
+// notifyRestartReady 通知父进程新进程已就绪
+func (e *Engine) notifyRestartReady() {
+	if e.restart.ready == nil {
+		return
+	}
+	if _, err := e.restart.ready.Write([]byte{1}); err != nil {
+		flog.Warnf("平滑重启: 通知父进程失败: %v", err)
+	}
+	e.restart.ready.Close()
+	e.restart.ready = nil
+}
+
+// watchRestartSignal 监听 SIGUSR2，返回停止监听的函数；未启用平滑重启时不监听
+func (e *Engine) watchRestartSignal() func() {
+	if !e.restart.enabled {
+		return func() {}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if !e.Ready() {
+					flog.Warnf("平滑重启: 启动完成前忽略 SIGUSR2")
+					continue
+				}
+				go func() {
+					if err := e.Restart(); err != nil {
+						flog.Errorf("平滑重启失败: %v", err)
+					}
+				}()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(stop)
+	}
+}
@@ -0,0 +1,138 @@
+package flow
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// flowPkgPath 框架根包路径，获取路由注册位置时跳过框架内部的调用
+var flowPkgPath = reflect.TypeOf(Engine{}).PkgPath()
+
+// RouteConflict 两个路由模式可以匹配同一请求，匹配两者的请求只会交给其中一个路由处理
+//
+// 例如 /users/:id 与 /users/new：请求 /users/new 由静态路由处理，/users/:id 的处理函数不会收到 id=new
+type RouteConflict struct {
+	Route RouteInfo // 后注册的路由
+	Other RouteInfo // 先注册的路由
+	// Winner 同时匹配两者的请求实际使用的路由模式，静态段优先于参数段，参数段优先于通配段
+	Winner string
+}
+
+// Error 实现error接口，便于与其他启动检查的结果一起汇总
+func (c RouteConflict) Error() string {
+	host := ""
+	if c.Route.Host != "" {
+		host = " (主机 " + c.Route.Host + ")"
+	}
+	return fmt.Sprintf("路由重叠%s: %s %s [%s] 与 %s %s [%s]，同时匹配的请求由 %s 处理",
+		host, c.Route.Method, c.Route.Path, c.Route.Location, c.Other.Method, c.Other.Path, c.Other.Location, c.Winner)
+}
+
+// RouteConflicts 返回主机与方法相同、模式互相重叠的路由，按后注册路由的注册顺序排列
+func (e *Engine) RouteConflicts() []RouteConflict {
+	e.routeTable.mu.RLock()
+	defer e.routeTable.mu.RUnlock()
+
+	var conflicts []RouteConflict
+	for i, r := range e.routeTable.routes {
+		segments := routeSegments(r.path)
+		for _, other := range e.routeTable.routes[:i] {
+			if other.host != r.host || other.method != r.method || other.path == r.path {
+				continue
+			}
+			winner, ok := overlapWinner(segments, routeSegments(other.path))
+			if !ok {
+				continue
+			}
+			conflict := RouteConflict{Route: r.infoLocked(), Other: other.infoLocked(), Winner: r.path}
+			if winner > 0 {
+				conflict.Winner = other.path
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
+
+// checkRouteConflicts 启动时对重叠的路由给出警告
+func (e *Engine) checkRouteConflicts() {
+	for _, conflict := range e.RouteConflicts() {
+		flog.Warn(conflict.Error())
+	}
+}
+
+// routeSegments 按 / 拆分路由模式
+func routeSegments(pattern string) []string {
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return nil
+	}
+	return strings.Split(pattern, "/")
+}
+
+// segmentRank 路由段的优先级，数值越小越优先
+func segmentRank(segment string) int {
+	switch {
+	case strings.HasPrefix(segment, "*"):
+		return 2
+	case strings.HasPrefix(segment, ":"):
+		return 1
+	}
+	return 0
+}
+
+// overlapWinner 判断两个路由模式是否存在同时匹配的请求路径，
+// 存在时返回优先的一方：负数为 a，正数为 b，由第一个类型不同的路由段决定
+func overlapWinner(a, b []string) (int, bool) {
+	winner := 0
+	for i := 0; ; i++ {
+		if i == len(a) || i == len(b) {
+			// 通配段可以匹配空路径，其余情况要求段数相同
+			rest := a[i:]
+			if i == len(a) {
+				rest = b[i:]
+			}
+			if len(rest) > 0 && !(len(rest) == 1 && segmentRank(rest[0]) == 2) {
+				return 0, false
+			}
+			if winner == 0 {
+				winner = len(a) - len(b)
+			}
+			return winner, true
+		}
+
+		ra, rb := segmentRank(a[i]), segmentRank(b[i])
+		if ra == 0 && rb == 0 && a[i] != b[i] {
+			return 0, false
+		}
+		if winner == 0 {
+			winner = ra - rb
+		}
+		if ra == 2 || rb == 2 {
+			// 通配段匹配剩余的全部路径
+			return winner, true
+		}
+	}
+}
+
+// routeCallSite 返回注册路由的调用位置，跳过框架内部的调用
+func routeCallSite() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !isFlowFrame(frame) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// isFlowFrame 判断调用帧是否位于框架根包（测试文件除外）
+func isFlowFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, flowPkgPath+".") && !strings.HasSuffix(frame.File, "_test.go")
+}
@@ -1,6 +1,7 @@
 package flow
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,6 +11,9 @@ import (
 type RouterGroup struct {
 	RouterGroup gin.RouterGroup
 	engine      *Engine
+	host        string      // 通过 Engine.Host 创建时绑定的主机模式
+	middleware  []string    // 路由组中间件链名称（含创建时的全局中间件）
+	attrs       []routeAttr // 路由组属性，由子路由组和路由继承
 }
 
 // wrapHandlers 将Flow的HandlerFunc切片转换为gin的HandlerFunc切片
@@ -33,92 +37,160 @@ func (e *Engine) NewContext(c *gin.Context) *Context {
 	}
 }
 
+// handle 注册路由并记录中间件链，最后一个处理函数为路由处理器，其余视为路由级中间件
+// WithMiddlewareGroup 引用的中间件组在路由级中间件之前执行；WithAttr 传入的属性从处理函数中分离，与路由组属性合并后记录在路由上
+// gin 拒绝冲突的路由时 panic，panic 信息中追加注册位置
+func (e *Engine) handle(group *gin.RouterGroup, host string, chain []string, groupAttrs []routeAttr, httpMethod, relativePath string, handlers []HandlerFunc) *Route {
+	location := routeCallSite()
+	defer func() {
+		if r := recover(); r != nil {
+			panic(fmt.Sprintf("%v（注册位置: %s）", r, location))
+		}
+	}()
+
+	handlers, attrs := splitAttrs(handlers)
+	handlers, groups := splitGroups(handlers)
+	e.registerInjected(handlers)
+	var named []namedHandler
+	var handlerName string
+	var ginHandlers []gin.HandlerFunc
+	if n := len(handlers); n > 0 {
+		named = e.nameWithGroups(chain, groups, handlers[:n-1])
+		handlerName = shortFuncName(handlers[n-1])
+		ginHandlers = append(wrapNamedHandlers(e, named), markHandlerStart)
+		ginHandlers = append(ginHandlers, wrapHandlers(e, handlers[n-1:])...)
+	}
+
+	// 路由在注册时绑定到处理链最前面，请求时无需再按路径查找
+	var route *Route
+	bound := *group
+	bound.Handlers = append(gin.HandlersChain{bindRoute(&route)}, group.Handlers...)
+	bound.Handle(httpMethod, relativePath, ginHandlers...)
+
+	middleware := append(append([]string(nil), chain...), handlerNames(named)...)
+	route = e.addRoute(host, httpMethod, joinPaths(group.BasePath(), relativePath), middleware, handlerName, mergeAttrs(groupAttrs, attrs), location)
+	return route
+}
+
 // Handle 注册处理函数到给定的HTTP方法和路径
-func (e *Engine) Handle(httpMethod, relativePath string, handlers ...HandlerFunc) {
-	e.Engine.Handle(httpMethod, relativePath, wrapHandlers(e, handlers)...)
+func (e *Engine) Handle(httpMethod, relativePath string, handlers ...HandlerFunc) *Route {
+	return e.handle(&e.Engine.RouterGroup, "", e.middleware, nil, httpMethod, relativePath, handlers)
 }
 
 // GET 是对Handle("GET", path, handlers)的简便方法
-func (e *Engine) GET(relativePath string, handlers ...HandlerFunc) {
-	e.Handle(http.MethodGet, relativePath, handlers...)
+func (e *Engine) GET(relativePath string, handlers ...HandlerFunc) *Route {
+	return e.Handle(http.MethodGet, relativePath, handlers...)
 }
 
 // POST 是对Handle("POST", path, handlers)的简便方法
-func (e *Engine) POST(relativePath string, handlers ...HandlerFunc) {
-	e.Handle(http.MethodPost, relativePath, handlers...)
+func (e *Engine) POST(relativePath string, handlers ...HandlerFunc) *Route {
+	return e.Handle(http.MethodPost, relativePath, handlers...)
 }
 
 // PUT 是对Handle("PUT", path, handlers)的简便方法
-func (e *Engine) PUT(relativePath string, handlers ...HandlerFunc) {
-	e.Handle(http.MethodPut, relativePath, handlers...)
+func (e *Engine) PUT(relativePath string, handlers ...HandlerFunc) *Route {
+	return e.Handle(http.MethodPut, relativePath, handlers...)
 }
 
 // DELETE 是对Handle("DELETE", path, handlers)的简便方法
-func (e *Engine) DELETE(relativePath string, handlers ...HandlerFunc) {
-	e.Handle(http.MethodDelete, relativePath, handlers...)
+func (e *Engine) DELETE(relativePath string, handlers ...HandlerFunc) *Route {
+	return e.Handle(http.MethodDelete, relativePath, handlers...)
 }
 
 // PATCH 是对Handle("PATCH", path, handlers)的简便方法
-func (e *Engine) PATCH(relativePath string, handlers ...HandlerFunc) {
-	e.Handle(http.MethodPatch, relativePath, handlers...)
+func (e *Engine) PATCH(relativePath string, handlers ...HandlerFunc) *Route {
+	return e.Handle(http.MethodPatch, relativePath, handlers...)
 }
 
-// Group 创建一个新的路由组
+// Group 创建一个新的路由组，可通过 WithAttr 设置路由组属性，通过 WithMiddlewareGroup 引用中间件组
 func (e *Engine) Group(relativePath string, handlers ...HandlerFunc) *RouterGroup {
-	ginGroup := e.Engine.Group(relativePath, wrapHandlers(e, handlers)...)
+	handlers, attrs := splitAttrs(handlers)
+	handlers, groups := splitGroups(handlers)
+	named := e.nameWithGroups(e.middleware, groups, handlers)
+	ginGroup := e.Engine.Group(relativePath, wrapNamedHandlers(e, named)...)
 	return &RouterGroup{
 		RouterGroup: *ginGroup,
 		engine:      e,
+		middleware:  append(append([]string(nil), e.middleware...), handlerNames(named)...),
+		attrs:       attrs,
 	}
 }
 
-// Use 添加全局中间件
+// Use 添加全局中间件，中间件名称根据函数名自动推导
 func (e *Engine) Use(middleware ...HandlerFunc) *Engine {
-	e.Engine.Use(wrapHandlers(e, middleware)...)
+	named := nameHandlers(e.middleware, middleware)
+	e.warnLateMiddleware(handlerNames(named))
+	e.middleware = append(e.middleware, handlerNames(named)...)
+	e.Engine.Use(wrapNamedHandlers(e, named)...)
+	return e
+}
+
+// UseNamed 以指定名称添加全局中间件，名称可用于Route.Without跳过
+func (e *Engine) UseNamed(name string, middleware HandlerFunc) *Engine {
+	named := []namedHandler{{name: uniqueMiddlewareName(e.middleware, name), handler: middleware}}
+	e.warnLateMiddleware(handlerNames(named))
+	e.middleware = append(e.middleware, handlerNames(named)...)
+	e.Engine.Use(wrapNamedHandlers(e, named)...)
 	return e
 }
 
 // Handle 在路由组中注册处理函数
-func (g *RouterGroup) Handle(httpMethod, relativePath string, handlers ...HandlerFunc) {
-	g.RouterGroup.Handle(httpMethod, relativePath, wrapHandlers(g.engine, handlers)...)
+func (g *RouterGroup) Handle(httpMethod, relativePath string, handlers ...HandlerFunc) *Route {
+	return g.engine.handle(&g.RouterGroup, g.host, g.middleware, g.attrs, httpMethod, relativePath, handlers)
 }
 
 // GET 是对Handle("GET", path, handlers)的简便方法
-func (g *RouterGroup) GET(relativePath string, handlers ...HandlerFunc) {
-	g.Handle(http.MethodGet, relativePath, handlers...)
+func (g *RouterGroup) GET(relativePath string, handlers ...HandlerFunc) *Route {
+	return g.Handle(http.MethodGet, relativePath, handlers...)
 }
 
 // POST 是对Handle("POST", path, handlers)的简便方法
-func (g *RouterGroup) POST(relativePath string, handlers ...HandlerFunc) {
-	g.Handle(http.MethodPost, relativePath, handlers...)
+func (g *RouterGroup) POST(relativePath string, handlers ...HandlerFunc) *Route {
+	return g.Handle(http.MethodPost, relativePath, handlers...)
 }
 
 // PUT 是对Handle("PUT", path, handlers)的简便方法
-func (g *RouterGroup) PUT(relativePath string, handlers ...HandlerFunc) {
-	g.Handle(http.MethodPut, relativePath, handlers...)
+func (g *RouterGroup) PUT(relativePath string, handlers ...HandlerFunc) *Route {
+	return g.Handle(http.MethodPut, relativePath, handlers...)
 }
 
 // DELETE 是对Handle("DELETE", path, handlers)的简便方法
-func (g *RouterGroup) DELETE(relativePath string, handlers ...HandlerFunc) {
-	g.Handle(http.MethodDelete, relativePath, handlers...)
+func (g *RouterGroup) DELETE(relativePath string, handlers ...HandlerFunc) *Route {
+	return g.Handle(http.MethodDelete, relativePath, handlers...)
 }
 
 // PATCH 是对Handle("PATCH", path, handlers)的简便方法
-func (g *RouterGroup) PATCH(relativePath string, handlers ...HandlerFunc) {
-	g.Handle(http.MethodPatch, relativePath, handlers...)
+func (g *RouterGroup) PATCH(relativePath string, handlers ...HandlerFunc) *Route {
+	return g.Handle(http.MethodPatch, relativePath, handlers...)
 }
 
-// Group 创建一个子路由组
+// Group 创建一个子路由组，继承父路由组的属性
 func (g *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *RouterGroup {
-	ginGroup := g.RouterGroup.Group(relativePath, wrapHandlers(g.engine, handlers)...)
+	handlers, attrs := splitAttrs(handlers)
+	handlers, groups := splitGroups(handlers)
+	named := g.engine.nameWithGroups(g.middleware, groups, handlers)
+	ginGroup := g.RouterGroup.Group(relativePath, wrapNamedHandlers(g.engine, named)...)
 	return &RouterGroup{
 		RouterGroup: *ginGroup,
 		engine:      g.engine,
+		host:        g.host,
+		middleware:  append(append([]string(nil), g.middleware...), handlerNames(named)...),
+		attrs:       mergeAttrs(g.attrs, attrs),
 	}
 }
 
 // Use 添加路由组中间件
 func (g *RouterGroup) Use(middleware ...HandlerFunc) *RouterGroup {
-	g.RouterGroup.Use(wrapHandlers(g.engine, middleware)...)
+	named := nameHandlers(g.middleware, middleware)
+	g.middleware = append(g.middleware, handlerNames(named)...)
+	g.RouterGroup.Use(wrapNamedHandlers(g.engine, named)...)
+	return g
+}
+
+// UseNamed 以指定名称添加路由组中间件
+func (g *RouterGroup) UseNamed(name string, middleware HandlerFunc) *RouterGroup {
+	named := []namedHandler{{name: uniqueMiddlewareName(g.middleware, name), handler: middleware}}
+	g.middleware = append(g.middleware, handlerNames(named)...)
+	g.RouterGroup.Use(wrapNamedHandlers(g.engine, named)...)
 	return g
 }
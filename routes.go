@@ -0,0 +1,408 @@
+package flow
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteInfo 路由信息快照，用于路由列表、文档生成等场景
+type RouteInfo struct {
+	Host       string   // 通过 Engine.Host 绑定的主机模式，为空表示默认主机
+	Method     string   // HTTP方法
+	Path       string   // 完整路由路径（含路由组前缀）
+	Handler    string   // 最终处理函数名称
+	Middleware []string // 按执行顺序排列的中间件名称（包含被跳过的）
+	Skipped    []string // 该路由通过Without跳过的中间件名称
+	Location   string   // 注册路由的源码位置，形如 /path/routes.go:42
+
+	Attrs map[string]interface{} // 通过WithAttr附加的路由属性（已合并路由组属性），键为属性类型名称
+}
+
+// Route 表示一个已注册的路由，可用于按名称跳过中间件
+type Route struct {
+	engine     *Engine
+	host       string
+	method     string
+	path       string
+	handler    string
+	middleware []string
+	skip       atomic.Pointer[map[string]bool] // 跳过的中间件，Without 时整体替换，请求时无锁读取
+	skipOrder  []string
+	attrs      []routeAttr
+	location   string
+}
+
+// routeTable 路由注册表
+type routeTable struct {
+	mu       sync.RWMutex
+	routes   []*Route
+	index    map[string]*Route
+	hasSkips atomic.Bool
+	hasAttrs atomic.Bool
+}
+
+// handlerStartKey 路由处理函数开始执行时间在上下文中的键
+const handlerStartKey = "_flow/handler_start"
+
+// markHandlerStart 在进入路由处理函数前记录时间，用于区分中间件耗时与处理函数耗时
+func markHandlerStart(c *gin.Context) {
+	c.Set(handlerStartKey, time.Now())
+}
+
+// HandlerStartTime 返回路由处理函数开始执行的时间
+func HandlerStartTime(c *Context) (time.Time, bool) {
+	v, ok := c.Get(handlerStartKey)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, ok := v.(time.Time)
+	return t, ok
+}
+
+// routeKey 生成路由索引键，不同主机上的相同路径互不冲突
+func routeKey(host, method, fullPath string) string {
+	return host + " " + method + " " + fullPath
+}
+
+// Without 让该路由跳过指定名称的中间件
+// 名称为Use时分配的稳定名称，如 "logger"、"cors"，重复注册的中间件为 "logger#2"
+func (r *Route) Without(names ...string) *Route {
+	r.engine.routeTable.mu.Lock()
+	defer r.engine.routeTable.mu.Unlock()
+
+	skip := make(map[string]bool, len(r.skipOrder)+len(names))
+	for _, name := range r.skipOrder {
+		skip[name] = true
+	}
+	for _, name := range names {
+		if skip[name] {
+			continue
+		}
+		skip[name] = true
+		r.skipOrder = append(r.skipOrder, name)
+	}
+	r.skip.Store(&skip)
+	if len(skip) > 0 {
+		r.engine.routeTable.hasSkips.Store(true)
+	}
+	return r
+}
+
+// Info 返回路由信息快照
+func (r *Route) Info() RouteInfo {
+	r.engine.routeTable.mu.RLock()
+	defer r.engine.routeTable.mu.RUnlock()
+	return r.infoLocked()
+}
+
+// infoLocked 生成路由信息快照，调用方需持有锁
+func (r *Route) infoLocked() RouteInfo {
+	return RouteInfo{
+		Host:       r.host,
+		Method:     r.method,
+		Path:       r.path,
+		Handler:    r.handler,
+		Middleware: append([]string(nil), r.middleware...),
+		Skipped:    append([]string(nil), r.skipOrder...),
+		Location:   r.location,
+		Attrs:      attrsInfo(r.attrs),
+	}
+}
+
+// RouteList 返回所有已注册路由的信息，按注册顺序排列
+func (e *Engine) RouteList() []RouteInfo {
+	e.routeTable.mu.RLock()
+	defer e.routeTable.mu.RUnlock()
+
+	routes := make([]RouteInfo, 0, len(e.routeTable.routes))
+	for _, r := range e.routeTable.routes {
+		routes = append(routes, r.infoLocked())
+	}
+	return routes
+}
+
+// MiddlewareChain 返回指定路由实际生效的中间件名称（按执行顺序，已排除被跳过的中间件）
+// path 可以是注册时的路由模式（/users/:id），也可以是具体请求路径（/users/42）；只查找默认主机的路由
+func (e *Engine) MiddlewareChain(method, requestPath string) []string {
+	return e.HostMiddlewareChain("", method, requestPath)
+}
+
+// HostMiddlewareChain 返回通过 Engine.Host 绑定到指定主机模式的路由实际生效的中间件名称
+func (e *Engine) HostMiddlewareChain(host, method, requestPath string) []string {
+	e.routeTable.mu.RLock()
+	defer e.routeTable.mu.RUnlock()
+
+	if host != "" {
+		name, port := splitHost(host)
+		host = name
+		if port != "" {
+			host = net.JoinHostPort(name, port)
+		}
+	}
+	method = strings.ToUpper(method)
+	r, ok := e.routeTable.index[routeKey(host, method, requestPath)]
+	if !ok {
+		for _, candidate := range e.routeTable.routes {
+			if candidate.host == host && candidate.method == method && matchRoutePath(candidate.path, requestPath) {
+				r = candidate
+				break
+			}
+		}
+	}
+	if r == nil {
+		return nil
+	}
+
+	chain := make([]string, 0, len(r.middleware))
+	for _, name := range r.middleware {
+		if !r.skips(name) {
+			chain = append(chain, name)
+		}
+	}
+	return chain
+}
+
+// addRoute 记录新注册的路由
+func (e *Engine) addRoute(host, method, fullPath string, middleware []string, handler string, attrs []routeAttr, location string) *Route {
+	r := &Route{
+		engine:     e,
+		host:       host,
+		method:     method,
+		path:       fullPath,
+		handler:    handler,
+		middleware: middleware,
+		attrs:      attrs,
+		location:   location,
+	}
+	if len(attrs) > 0 {
+		e.routeTable.hasAttrs.Store(true)
+	}
+
+	e.routeTable.mu.Lock()
+	defer e.routeTable.mu.Unlock()
+	if e.routeTable.index == nil {
+		e.routeTable.index = make(map[string]*Route)
+	}
+	e.routeTable.routes = append(e.routeTable.routes, r)
+	e.routeTable.index[routeKey(host, method, fullPath)] = r
+	return r
+}
+
+// routeContextKey 当前请求匹配的路由在上下文中的键
+const routeContextKey = "_flow/route"
+
+// bindRoute 返回放在路由处理链最前面的处理函数，把路由记录到上下文中，
+// 中间件判断是否跳过与读取路由属性时不再查找路由表；route 在注册完成后才可用
+func bindRoute(route **Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(routeContextKey, *route)
+	}
+}
+
+// currentRoute 返回当前请求匹配的路由，未匹配路由（如 NoRoute）时返回 nil
+func currentRoute(c *gin.Context) *Route {
+	v, ok := c.Get(routeContextKey)
+	if !ok {
+		return nil
+	}
+	r, _ := v.(*Route)
+	return r
+}
+
+// skips 判断路由是否跳过指定中间件
+func (r *Route) skips(name string) bool {
+	skip := r.skip.Load()
+	return skip != nil && (*skip)[name]
+}
+
+// shouldSkip 判断当前请求的路由是否跳过指定中间件
+func (e *Engine) shouldSkip(c *gin.Context, name string) bool {
+	if !e.routeTable.hasSkips.Load() {
+		return false
+	}
+	r := currentRoute(c)
+	return r != nil && r.skips(name)
+}
+
+// checkRouteSkips 检查路由跳过的中间件名称是否存在，启动时对不存在的名称给出警告
+func (e *Engine) checkRouteSkips() {
+	e.routeTable.mu.RLock()
+	defer e.routeTable.mu.RUnlock()
+
+	for _, r := range e.routeTable.routes {
+		for _, name := range r.skipOrder {
+			if !containsString(r.middleware, name) {
+				flog.Warnf("路由 %s %s 跳过了不存在的中间件: %s (可用: %s)",
+					r.method, r.path, name, strings.Join(r.middleware, ", "))
+			}
+		}
+	}
+}
+
+// namedHandler 带名称的处理函数
+type namedHandler struct {
+	name    string
+	handler HandlerFunc
+}
+
+// nameHandlers 为中间件分配名称，名称在所属中间件链中唯一
+func nameHandlers(chain []string, handlers []HandlerFunc) []namedHandler {
+	named := make([]namedHandler, len(handlers))
+	current := append([]string(nil), chain...)
+	for i, h := range handlers {
+		name := uniqueMiddlewareName(current, handlerName(h))
+		current = append(current, name)
+		named[i] = namedHandler{name: name, handler: h}
+	}
+	return named
+}
+
+// wrapNamedHandlers 包装带名称的中间件，请求时检查路由是否跳过该中间件
+func wrapNamedHandlers(engine *Engine, handlers []namedHandler) []gin.HandlerFunc {
+	ginHandlers := make([]gin.HandlerFunc, len(handlers))
+	for i, nh := range handlers {
+		nh := nh
+		ginHandlers[i] = func(c *gin.Context) {
+			if engine.shouldSkip(c, nh.name) {
+				return
+			}
+			nh.handler(engine.NewContext(c))
+		}
+	}
+	return ginHandlers
+}
+
+// handlerNames 提取名称列表
+func handlerNames(handlers []namedHandler) []string {
+	names := make([]string, len(handlers))
+	for i, h := range handlers {
+		names[i] = h.name
+	}
+	return names
+}
+
+// uniqueMiddlewareName 在中间件链中为名称去重，重复的名称追加序号，如 "logger#2"
+func uniqueMiddlewareName(chain []string, base string) string {
+	count := 0
+	for _, name := range chain {
+		if name == base || strings.HasPrefix(name, base+"#") {
+			count++
+		}
+	}
+	if count == 0 {
+		return base
+	}
+	return fmt.Sprintf("%s#%d", base, count+1)
+}
+
+// handlerName 根据函数名推导中间件名称
+// 例如 middleware.LoggerWithConfig.func1 推导为 "logger"，security.(*Manager).XSSProtectionMiddleware-fm 推导为 "xssprotection"
+func handlerName(h HandlerFunc) string {
+	name := funcName(h)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+
+	var last string
+	for _, part := range strings.Split(name, ".") {
+		if part == "" || strings.HasPrefix(part, "func") || strings.HasPrefix(part, "(") || isDigits(part) {
+			continue
+		}
+		last = part
+	}
+	if last == "" {
+		return "anonymous"
+	}
+
+	last = strings.TrimSuffix(last, "WithConfig")
+	if trimmed := strings.TrimSuffix(last, "Middleware"); trimmed != "" {
+		last = trimmed
+	}
+	return strings.ToLower(last)
+}
+
+// funcName 获取函数的完整名称
+func funcName(h interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer())
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+// shortFuncName 获取去掉包路径的函数名称，用于展示处理函数
+func shortFuncName(h HandlerFunc) string {
+	name := funcName(injectedFunc(h))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// matchRoutePath 判断请求路径是否匹配路由模式，支持 :param 与 *wildcard
+func matchRoutePath(pattern, requestPath string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(requestPath, "/"), "/")
+
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
+
+// joinPaths 拼接路由路径，与gin的路径拼接规则保持一致
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
+
+// containsString 判断字符串切片是否包含指定值
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isDigits 判断字符串是否全为数字
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,182 @@
+package flow
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordMiddleware 返回记录执行顺序的中间件
+func recordMiddleware(name string, trace *[]string) HandlerFunc {
+	return func(c *Context) {
+		*trace = append(*trace, name)
+		c.Next()
+	}
+}
+
+func newRouteTestEngine() *Engine {
+	gin.SetMode(gin.TestMode)
+	return New()
+}
+
+func TestMiddlewareChain_MatchesExecutionOrder(t *testing.T) {
+	e := newRouteTestEngine()
+	var trace []string
+
+	e.UseNamed("logger", recordMiddleware("logger", &trace))
+	e.UseNamed("cors", recordMiddleware("cors", &trace))
+	api := e.Group("/api")
+	api.UseNamed("auth", recordMiddleware("auth", &trace))
+	api.GET("/users/:id", recordMiddleware("throttle", &trace), func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users/42", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 路由级中间件使用推导名称
+	chain := e.MiddlewareChain(http.MethodGet, "/api/users/42")
+	assert.Equal(t, []string{"recovery", "logger", "cors", "auth", "record"}, chain)
+	assert.Equal(t, []string{"logger", "cors", "auth", "throttle"}, trace)
+	assert.Equal(t, chain, e.MiddlewareChain(http.MethodGet, "/api/users/:id"), "路由模式与具体路径结果一致")
+	assert.Nil(t, e.MiddlewareChain(http.MethodPost, "/api/users/42"))
+}
+
+func TestRouteWithout_SkipsMiddleware(t *testing.T) {
+	e := newRouteTestEngine()
+	var trace []string
+
+	e.UseNamed("logger", recordMiddleware("logger", &trace))
+	e.UseNamed("compress", recordMiddleware("compress", &trace))
+	e.GET("/health", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}).Without("logger", "compress")
+	e.GET("/users", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, trace, "跳过的中间件不应执行")
+	assert.Equal(t, []string{"recovery"}, e.MiddlewareChain(http.MethodGet, "/health"))
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(t, []string{"logger", "compress"}, trace, "其他路由不受影响")
+}
+
+func TestRouteWithout_NoRouteTableLookupPerRequest(t *testing.T) {
+	e := newRouteTestEngine()
+	var trace []string
+
+	e.UseNamed("logger", recordMiddleware("logger", &trace))
+	e.GET("/health", func(c *Context) {
+		c.String(http.StatusOK, AttrOr(c, "none"))
+	}, WithAttr("probe")).Without("logger")
+
+	// 请求处理不再读取路由表，持有路由表的写锁时请求仍能完成
+	e.routeTable.mu.Lock()
+	defer e.routeTable.mu.Unlock()
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "probe", w.Body.String())
+	assert.Empty(t, trace)
+}
+
+func TestRouteWithout_GroupComposition(t *testing.T) {
+	e := newRouteTestEngine()
+	var trace []string
+
+	e.UseNamed("logger", recordMiddleware("logger", &trace))
+	hooks := e.Group("/hooks")
+	hooks.UseNamed("auth", recordMiddleware("auth", &trace))
+	hooks.UseNamed("logger", recordMiddleware("logger-2", &trace))
+	hooks.POST("/stripe", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	}).Without("auth", "logger#2")
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/hooks/stripe", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"logger"}, trace)
+
+	routes := e.RouteList()
+	assert.Len(t, routes, 1)
+	assert.Equal(t, "/hooks/stripe", routes[0].Path)
+	assert.Equal(t, []string{"recovery", "logger", "auth", "logger#2"}, routes[0].Middleware)
+	assert.Equal(t, []string{"auth", "logger#2"}, routes[0].Skipped)
+}
+
+func TestHandlerName(t *testing.T) {
+	assert.Equal(t, "record", handlerName(recordMiddleware("x", nil)))
+	assert.Equal(t, "logger#2", uniqueMiddlewareName([]string{"logger"}, "logger"))
+	assert.Equal(t, "logger#3", uniqueMiddlewareName([]string{"logger", "logger#2"}, "logger"))
+	assert.True(t, matchRoutePath("/files/*path", "/files/a/b"))
+	assert.False(t, matchRoutePath("/users/:id", "/users"))
+}
+
+// lineAfter 返回调用位置下 n 行的 file:line
+func lineAfter(n int) string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("%s:%d", file, line+n)
+}
+
+func TestRouteConflicts(t *testing.T) {
+	e := newRouteTestEngine()
+	ok := reply("ok")
+
+	byID := lineAfter(1)
+	e.GET("/users/:id", ok)
+	api := e.Group("/")
+	newUser := lineAfter(1)
+	api.GET("/users/new", ok)
+	e.POST("/users/new", ok)                          // 方法不同
+	e.GET("/users/:id/posts", ok)                     // 段数不同
+	e.Host("admin.example.com").GET("/users/new", ok) // 主机不同
+	e.GET("/files/*path", ok)
+	e.GET("/:dir/readme", ok) // 与通配段重叠
+	e.GET("/orders/:id/items/all", reply("all"))
+	e.GET("/orders/today/items/:n", reply("today")) // 第一个不同的段决定优先级
+
+	conflicts := e.RouteConflicts()
+	require.Len(t, conflicts, 4)
+
+	users := conflicts[0]
+	assert.Equal(t, "/users/new", users.Route.Path)
+	assert.Equal(t, "/users/:id", users.Other.Path)
+	assert.Equal(t, "/users/new", users.Winner, "静态段优先")
+	assert.Equal(t, newUser, users.Route.Location)
+	assert.Equal(t, byID, users.Other.Location)
+	assert.Contains(t, users.Error(), byID)
+
+	assert.Equal(t, "/users/:id", conflicts[1].Winner, "/users/readme 同时匹配 /:dir/readme")
+	assert.Equal(t, "/files/*path", conflicts[2].Winner, "静态段优先于参数段，与后续的通配段无关")
+	assert.Equal(t, "/orders/today/items/:n", conflicts[3].Winner)
+
+	// 同时匹配的请求交给优先的路由
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/today/items/all", nil))
+	assert.Equal(t, "today", w.Body.String())
+}
+
+func TestRoutePanic_IncludesLocation(t *testing.T) {
+	e := newRouteTestEngine()
+	e.GET("/users/:id", reply("ok"))
+
+	location := lineAfter(6)
+	defer func() {
+		r := recover()
+		require.NotNil(t, r, "gin 拒绝冲突的参数名")
+		assert.Contains(t, fmt.Sprint(r), "注册位置: "+location)
+	}()
+	e.GET("/users/:name", reply("ok"))
+}
@@ -0,0 +1,194 @@
+package sanitize
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// droppedElements 连同内容一起移除的元素，任何策略都不能允许
+var droppedElements = map[string]struct{}{
+	"script": {}, "style": {}, "iframe": {}, "frame": {}, "frameset": {}, "object": {}, "embed": {},
+	"applet": {}, "noscript": {}, "noembed": {}, "noframes": {}, "template": {}, "svg": {}, "math": {},
+	"textarea": {}, "select": {}, "title": {}, "xmp": {}, "plaintext": {}, "base": {}, "link": {}, "meta": {},
+}
+
+// voidElements 没有结束标签的元素
+var voidElements = map[string]struct{}{
+	"area": {}, "base": {}, "br": {}, "col": {}, "embed": {}, "hr": {}, "img": {}, "input": {},
+	"link": {}, "meta": {}, "source": {}, "track": {}, "wbr": {},
+}
+
+// urlAttrs 值为地址、需要检查协议的属性；srcset 由 allowSrcset 逐项检查
+var urlAttrs = map[string]struct{}{
+	"href": {}, "src": {}, "cite": {}, "action": {}, "formaction": {}, "poster": {}, "background": {},
+	"xlink:href": {}, "data": {}, "longdesc": {}, "usemap": {}, "ping": {}, "manifest": {}, "codebase": {},
+	"dynsrc": {}, "lowsrc": {}, "icon": {}, "profile": {}, "archive": {}, "classid": {}, "xml:base": {},
+}
+
+// droppedAttrs 任何策略都不能允许的属性：style 可以通过 CSS 加载地址或执行表达式，无法按协议检查
+var droppedAttrs = map[string]struct{}{
+	"style": {},
+}
+
+// noFollowRel 开启 NoFollow 时链接使用的 rel
+const noFollowRel = "nofollow noopener noreferrer"
+
+// Sanitize 按策略清理 HTML，返回可以直接输出到页面的片段
+//
+// 未允许的元素只保留文本内容，未闭合的元素在末尾补齐结束标签
+func (p *Policy) Sanitize(input string) string {
+	z := html.NewTokenizer(strings.NewReader(input))
+	var b strings.Builder
+	var open []string
+
+	// 正在移除的元素及其嵌套层数
+	skipTag, skip := "", 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			return b.String()
+
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(string(z.Text())))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			name := token.Data
+			_, void := voidElements[name]
+			if skip > 0 {
+				if name == skipTag && tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if _, dropped := droppedElements[name]; dropped {
+				if tt == html.StartTagToken && !void {
+					skipTag, skip = name, 1
+				}
+				continue
+			}
+			allowed, ok := p.elements[name]
+			if !ok {
+				continue
+			}
+			p.writeStartTag(&b, name, token.Attr, allowed)
+			switch {
+			case void:
+			case tt == html.SelfClosingTagToken:
+				b.WriteString("</" + name + ">")
+			default:
+				open = append(open, name)
+			}
+
+		case html.EndTagToken:
+			raw, _ := z.TagName()
+			name := string(raw)
+			if skip > 0 {
+				if name == skipTag {
+					skip--
+				}
+				continue
+			}
+			// 只关闭已打开的元素，并补齐其中未闭合的元素
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+		// 注释与文档类型声明直接丢弃
+	}
+}
+
+// writeStartTag 输出开始标签，只保留允许的属性
+func (p *Policy) writeStartTag(b *strings.Builder, name string, attrs []html.Attribute, allowed map[string]struct{}) {
+	b.WriteString("<" + name)
+	seen := make(map[string]struct{}, len(attrs))
+	for _, attr := range attrs {
+		key := attr.Key
+		if _, dropped := droppedAttrs[key]; dropped || attr.Namespace != "" || strings.HasPrefix(key, "on") {
+			continue
+		}
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		if _, ok := allowed[key]; !ok {
+			if _, ok := p.globalAttrs[key]; !ok {
+				continue
+			}
+		}
+		if key == "rel" && name == "a" && p.noFollow {
+			continue
+		}
+		if _, isURL := urlAttrs[key]; isURL && !p.allowURL(attr.Val) {
+			continue
+		}
+		if key == "srcset" && !p.allowSrcset(attr.Val) {
+			continue
+		}
+		seen[key] = struct{}{}
+		b.WriteString(" " + key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if name == "a" && p.noFollow {
+		b.WriteString(` rel="` + noFollowRel + `"`)
+	}
+	b.WriteString(">")
+}
+
+// allowURL 判断地址的协议是否允许，相对地址始终允许
+func (p *Policy) allowURL(raw string) bool {
+	// 浏览器会忽略地址中的空白与控制字符，例如 "java\tscript:"，判断协议前先移除
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+	i := strings.IndexAny(cleaned, ":/?#")
+	if i < 0 || cleaned[i] != ':' {
+		return true
+	}
+	_, ok := p.protocols[strings.ToLower(cleaned[:i])]
+	return ok
+}
+
+// allowSrcset 判断 srcset 中的每个候选地址，任一地址的协议不允许时丢弃整个属性
+//
+// 候选项连同描述符整体检查，"java\tscript:" 这样被空白拆开的协议同样被拒绝
+func (p *Policy) allowSrcset(raw string) bool {
+	for _, candidate := range strings.Split(raw, ",") {
+		if !p.allowURL(candidate) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasMarkup 判断文本是否包含 HTML 标签、注释或文档类型声明，"a < b" 这样的文本不算
+//
+// 验证规则 no_html 使用该函数
+func HasMarkup(input string) bool {
+	z := html.NewTokenizer(strings.NewReader(input))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.TextToken:
+		default:
+			return true
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package sanitize
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xssCorpus 常见的 XSS 载荷
+var xssCorpus = []string{
+	`<script>alert(1)</script>`,
+	`<SCRIPT SRC=//evil.example/x.js></SCRIPT>`,
+	`<img src=x onerror=alert(1)>`,
+	`<img src="javascript:alert(1)">`,
+	`<img src="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">`,
+	`<a href="javascript:alert(1)">x</a>`,
+	`<a href=" JaVaScRiPt:alert(1)">x</a>`,
+	`<a href="jav&#x09;ascript:alert(1)">x</a>`,
+	`<a href="javascript&colon;alert(1)">x</a>`,
+	`<a href="vbscript:msgbox(1)">x</a>`,
+	`<svg onload=alert(1)><script>alert(1)</script></svg>`,
+	`<math><mi xlink:href="javascript:alert(1)">x</mi></math>`,
+	`<iframe src="//evil.example"></iframe>`,
+	`<object data="javascript:alert(1)"></object>`,
+	`<style>@import "//evil.example/x.css";</style>`,
+	`<div style="background:url(javascript:alert(1))">x</div>`,
+	`<<script>script>alert(1)<</script>/script>`,
+	`"><script>alert(1)</script>`,
+	`<!--<script>alert(1)</script>-->`,
+	`<p onclick="alert(1)" onmouseover=alert(1)>x</p>`,
+	`<body onload=alert(1)>`,
+	`<meta http-equiv="refresh" content="0;url=javascript:alert(1)">`,
+	`<form action="javascript:alert(1)"><button>x</button></form>`,
+	`<textarea><script>alert(1)</script></textarea>`,
+	`<template><script>alert(1)</script></template>`,
+	`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`,
+}
+
+// assertSafe 输出中不能出现可执行的标签、事件属性与脚本协议
+func assertSafe(t *testing.T, p *Policy, input string) {
+	t.Helper()
+	out := p.Sanitize(input)
+	lower := strings.ToLower(out)
+	for _, bad := range []string{"<script", "<svg", "<iframe", "<object", "<style", "<meta", "<form", "onerror", "onload", "onclick", "javascript:", "vbscript:", "data:"} {
+		assert.NotContains(t, lower, bad, "%s: %s => %s", p.Name(), input, out)
+	}
+	// 清理结果再次清理应保持不变
+	assert.Equal(t, out, p.Sanitize(out), "%s: %s", p.Name(), input)
+}
+
+func TestPolicies_XSSCorpus(t *testing.T) {
+	for _, p := range []*Policy{StrictText, BasicFormatting, RichArticle} {
+		for _, input := range xssCorpus {
+			assertSafe(t, p, input)
+		}
+	}
+}
+
+func TestStrictText(t *testing.T) {
+	assert.Equal(t, "hi &amp; bye", StrictText.Sanitize("<b>hi</b> &amp; <i>bye</i>"))
+	assert.Equal(t, "a &lt; b", StrictText.Sanitize("a < b"))
+	assert.Equal(t, "x", StrictText.Sanitize("<script>alert(1)</script>x"))
+}
+
+func TestBasicFormatting(t *testing.T) {
+	assert.Equal(t, "<p><strong>hi</strong> there</p>", BasicFormatting.Sanitize(`<p class="x"><strong>hi</strong> <a href="https://example.com">there</a></p>`))
+	// 未闭合的元素补齐结束标签，多余的结束标签被丢弃
+	assert.Equal(t, "<p><em>open</em></p>", BasicFormatting.Sanitize("<p><em>open"))
+	assert.Equal(t, "text", BasicFormatting.Sanitize("text</p></em>"))
+	assert.Equal(t, "a<br>b", BasicFormatting.Sanitize("a<br/>b"))
+}
+
+func TestRichArticle(t *testing.T) {
+	out := RichArticle.Sanitize(`<h2 id="t">Title</h2><a href="https://example.com/a?b=1&c=2" rel="opener" target="_blank">link</a>` +
+		`<img src="/uploads/x.png" alt="x" onerror="alert(1)"><a href="mailto:a@example.com">mail</a>`)
+	assert.Equal(t, `<h2>Title</h2><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer">link</a>`+
+		`<img src="/uploads/x.png" alt="x"><a href="mailto:a@example.com" rel="nofollow noopener noreferrer">mail</a>`, out)
+
+	// 属性值中的引号被转义
+	assert.Equal(t, `<img alt="&#34;&gt;&lt;script&gt;">`, RichArticle.Sanitize(`<img alt='"><script>'>`))
+}
+
+func TestHasMarkup(t *testing.T) {
+	assert.False(t, HasMarkup("plain text"))
+	assert.False(t, HasMarkup("a < b && c > d"))
+	assert.True(t, HasMarkup("<b>bold</b>"))
+	assert.True(t, HasMarkup("hi <!-- comment -->"))
+	assert.True(t, HasMarkup("x</p>"))
+}
+
+func TestJSONInHTML(t *testing.T) {
+	state := map[string]string{"bio": "</script><script>alert(1)</script>", "note": "a & b <!-- \u2028\u2029"}
+	out, err := JSONInHTML(state)
+	require.NoError(t, err)
+	s := string(out)
+	for _, bad := range []string{"</script", "<!--", "&", "\u2028", "\u2029"} {
+		assert.NotContains(t, s, bad)
+	}
+	assert.Contains(t, s, `\u003c/script\u003e`)
+	assert.Contains(t, s, `\u2028\u2029`)
+
+	var decoded map[string]string
+	require.NoError(t, json.Unmarshal([]byte(s), &decoded))
+	assert.Equal(t, state, decoded)
+
+	_, err = JSONInHTML(make(chan int))
+	assert.Error(t, err)
+}
+
+func TestFuncMap_Template(t *testing.T) {
+	tmpl := template.Must(template.New("page").Funcs(FuncMap()).Parse(
+		`<article>{{ sanitize .Body "article" }}</article><script>window.__STATE__ = {{ jsonInHTML .State }};</script>`))
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, map[string]interface{}{
+		"Body":  `<p>hi<script>alert(1)</script></p>`,
+		"State": map[string]string{"name": "</script><b>"},
+	}))
+	assert.Equal(t, `<article><p>hi</p></article><script>window.__STATE__ = {"name":"\u003c/script\u003e\u003cb\u003e"};</script>`, buf.String())
+
+	// 未注册的策略使模板执行失败，而不是输出未清理的内容
+	tmpl = template.Must(template.New("bad").Funcs(FuncMap()).Parse(`{{ sanitize .Body "missing" }}`))
+	err := tmpl.Execute(&buf, map[string]interface{}{"Body": "<script>"})
+	assert.ErrorIs(t, err, ErrUnknownPolicy)
+}
+
+func TestRegister(t *testing.T) {
+	p, err := Compile("test:comment", Config{Elements: map[string][]string{"B": nil, "a": {"HREF"}}, Protocols: []string{"https"}})
+	require.NoError(t, err)
+	require.NoError(t, Register(p))
+	assert.Contains(t, Policies(), "test:comment")
+
+	out, err := Sanitize("test:comment", `<b>x</b><i>y</i><a href="http://example.com">z</a>`)
+	require.NoError(t, err)
+	assert.Equal(t, `<b>x</b>y<a>z</a>`, out)
+
+	// 名称冲突，内置策略同样不能替换
+	assert.ErrorIs(t, Register(p), ErrPolicyExists)
+	assert.ErrorIs(t, Register(MustCompile("article", Config{})), ErrPolicyExists)
+	got, _ := Lookup("article")
+	assert.Same(t, RichArticle, got)
+
+	// 任何策略都不能允许脚本类元素
+	_, err = Compile("test:unsafe", Config{Elements: map[string][]string{"script": nil}})
+	assert.Error(t, err)
+
+	_, err = Sanitize("missing", "x")
+	assert.ErrorIs(t, err, ErrUnknownPolicy)
+}
+
+func TestPolicy_Concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assertSafe(t, RichArticle, xssCorpus[(i+j)%len(xssCorpus)])
+				_ = Register(MustCompile(fmt.Sprintf("test:concurrent-%d-%d", i, j), Config{}))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,50 @@
+package sanitize
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+)
+
+// FuncMap 返回模板函数，flow.New 默认注册到 HTML 模板：
+//
+//   - sanitize：{{ sanitize .Body "article" }}，按名称使用已注册的策略清理 HTML
+//   - jsonInHTML：{{ jsonInHTML .State }}，见 JSONInHTML
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"sanitize":   sanitizeFunc,
+		"jsonInHTML": JSONInHTML,
+	}
+}
+
+// sanitizeFunc 模板函数 sanitize，策略未注册时模板执行失败，而不是输出未清理的内容
+func sanitizeFunc(input interface{}, policy string) (template.HTML, error) {
+	var s string
+	switch v := input.(type) {
+	case nil:
+	case string:
+		s = v
+	case template.HTML:
+		s = string(v)
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	out, err := Sanitize(policy, s)
+	return template.HTML(out), err
+}
+
+// JSONInHTML 将值序列化为可以直接放在 <script> 中的 JSON
+//
+// <、>、& 转义为 \u003c、\u003e、\u0026，因此 "</script" 与 "<!--" 不会提前结束脚本；
+// U+2028 与 U+2029 转义为 \u2028、\u2029，在旧的 JavaScript 引擎中它们是换行符。
+// 返回 template.JS，html/template 在脚本中原样输出，不会再次加引号
+func JSONInHTML(v interface{}) (template.JS, error) {
+	// json.Marshal 总是转义上述字符，包括 json.Marshaler 返回的内容
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("sanitize: 序列化 JSON 失败: %w", err)
+	}
+	return template.JS(data), nil
+}
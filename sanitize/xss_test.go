@@ -0,0 +1,280 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// owaspVectors OWASP XSS Filter Evasion Cheat Sheet 中的向量
+var owaspVectors = []string{
+	`javascript:/*--></title></style></textarea></script></xmp><svg/onload='+/"/+/onmouseover=1/+/[*/[]/+alert(1)//'>`,
+	`<SCRIPT SRC=https://cdn.example/xss.js></SCRIPT>`,
+	`<IMG SRC="javascript:alert('XSS');">`,
+	`<IMG SRC=javascript:alert('XSS')>`,
+	`<IMG SRC=JaVaScRiPt:alert('XSS')>`,
+	`<IMG SRC=javascript:alert(&quot;XSS&quot;)>`,
+	"<IMG SRC=`javascript:alert(\"RSnake says, 'XSS'\")`>",
+	`\<a onmouseover="alert(document.cookie)"\>xxs link\</a\>`,
+	`<a onmouseover=alert(document.cookie)>xxs link</a>`,
+	`<IMG """><SCRIPT>alert("XSS")</SCRIPT>"\>`,
+	`<IMG SRC=javascript:alert(String.fromCharCode(88,83,83))>`,
+	`<IMG SRC=# onmouseover="alert('xxs')">`,
+	`<IMG SRC= onmouseover="alert('xxs')">`,
+	`<IMG onmouseover="alert('xxs')">`,
+	`<IMG SRC=/ onerror="alert(String.fromCharCode(88,83,83))"></img>`,
+	`<img src=x onerror="&#0000106&#0000097&#0000118&#0000097&#0000115&#0000099&#0000114&#0000105&#0000112&#0000116&#0000058&#0000097&#0000108&#0000101&#0000114&#0000116&#0000040&#0000039&#0000088&#0000083&#0000083&#0000039&#0000041">`,
+	`<IMG SRC=&#106;&#97;&#118;&#97;&#115;&#99;&#114;&#105;&#112;&#116;&#58;&#97;&#108;&#101;&#114;&#116;&#40;&#39;&#88;&#83;&#83;&#39;&#41;>`,
+	`<IMG SRC=&#0000106&#0000097&#0000118&#0000097&#0000115&#0000099&#0000114&#0000105&#0000112&#0000116&#0000058&#0000097&#0000108&#0000101&#0000114&#0000116&#0000040&#0000039&#0000088&#0000083&#0000083&#0000039&#0000041>`,
+	`<IMG SRC=&#x6A&#x61&#x76&#x61&#x73&#x63&#x72&#x69&#x70&#x74&#x3A&#x61&#x6C&#x65&#x72&#x74&#x28&#x27&#x58&#x53&#x53&#x27&#x29>`,
+	"<IMG SRC=\"jav\tascript:alert('XSS');\">",
+	`<IMG SRC="jav&#x09;ascript:alert('XSS');">`,
+	`<IMG SRC="jav&#x0A;ascript:alert('XSS');">`,
+	`<IMG SRC="jav&#x0D;ascript:alert('XSS');">`,
+	"<IMG SRC=\"java\x00script:alert('XSS');\">",
+	`<IMG SRC=" &#14;  javascript:alert('XSS');">`,
+	`<SCRIPT/XSS SRC="http://xss.example/xss.js"></SCRIPT>`,
+	"<BODY onload!#$%&()*~+-_.,:;?@[/|\\]^`=alert(\"XSS\")>",
+	`<SCRIPT/SRC="http://xss.example/xss.js"></SCRIPT>`,
+	`<<SCRIPT>alert("XSS");//\<</SCRIPT>`,
+	`<SCRIPT SRC=http://xss.example/xss.js?< B >`,
+	`<SCRIPT SRC=//xss.example/.j>`,
+	"<IMG SRC=\"`<javascript:alert>`('XSS')\"",
+	`<iframe src=http://xss.example/scriptlet.html <`,
+	`</TITLE><SCRIPT>alert("XSS");</SCRIPT>`,
+	`<INPUT TYPE="IMAGE" SRC="javascript:alert('XSS');">`,
+	`<BODY BACKGROUND="javascript:alert('XSS')">`,
+	`<IMG DYNSRC="javascript:alert('XSS')">`,
+	`<IMG LOWSRC="javascript:alert('XSS')">`,
+	`<STYLE>li {list-style-image: url("javascript:alert('XSS')");}</STYLE><UL><LI>XSS</br>`,
+	`<svg/onload=alert('XSS')>`,
+	`<BGSOUND SRC="javascript:alert('XSS');">`,
+	`<BR SIZE="&{alert('XSS')}">`,
+	`<LINK REL="stylesheet" HREF="javascript:alert('XSS');">`,
+	`<STYLE>@import'http://xss.example/xss.css';</STYLE>`,
+	`<META HTTP-EQUIV="Link" Content="<http://xss.example/xss.css>; REL=stylesheet">`,
+	`<STYLE>BODY{-moz-binding:url("http://xss.example/xssmoz.xml#xss")}</STYLE>`,
+	`<STYLE>@im\port'\ja\vasc\ript:alert("XSS")';</STYLE>`,
+	`<IMG STYLE="xss:expr/*XSS*/ession(alert('XSS'))">`,
+	`<STYLE TYPE="text/javascript">alert('XSS');</STYLE>`,
+	`<STYLE>.XSS{background-image:url("javascript:alert('XSS')");}</STYLE><A CLASS=XSS></A>`,
+	`<XSS STYLE="xss:expression(alert('XSS'))">`,
+	`<META HTTP-EQUIV="refresh" CONTENT="0;url=data:text/html base64,PHNjcmlwdD5hbGVydCgnWFNTJyk8L3NjcmlwdD4K">`,
+	`<IFRAME SRC="javascript:alert('XSS');"></IFRAME>`,
+	`<IFRAME SRC=# onmouseover="alert(document.cookie)"></IFRAME>`,
+	`<FRAMESET><FRAME SRC="javascript:alert('XSS');"></FRAMESET>`,
+	`<TABLE BACKGROUND="javascript:alert('XSS')">`,
+	`<TABLE><TD BACKGROUND="javascript:alert('XSS')">`,
+	`<DIV STYLE="background-image: url(javascript:alert('XSS'))">`,
+	`<DIV STYLE="background-image:\0075\0072\006C\0028'\006a\0061\0076\0061\0073\0063\0072\0069\0070\0074\003a\0061\006c\0065\0072\0074\0028.1027\0058.1053\0053\0027\0029'\0029">`,
+	`<DIV STYLE="width: expression(alert('XSS'));">`,
+	`<BASE HREF="javascript:alert('XSS');//">`,
+	`<OBJECT TYPE="text/x-scriptlet" DATA="http://xss.example/scriptlet.html"></OBJECT>`,
+	`<EMBED SRC="data:image/svg+xml;base64,PHN2ZyB4bWxuczpzdmc9Imh0dHA6Ly93d3cudzMub3JnLzIwMDAvc3ZnIj48c2NyaXB0PmFsZXJ0KDEpPC9zY3JpcHQ+PC9zdmc+" type="image/svg+xml" AllowScriptAccess="always"></EMBED>`,
+	`<XML ID="xss"><I><B><IMG SRC="javas<!-- -->cript:alert('XSS')"></B></I></XML>`,
+	`<HTML><BODY><?xml:namespace prefix="t" ns="urn:schemas-microsoft-com:time"><?import namespace="t" implementation="#default#time2"><t:set attributeName="innerHTML" to="XSS<SCRIPT DEFER>alert("XSS")</SCRIPT>"></BODY></HTML>`,
+	`<SCRIPT a=">" SRC="httx://xss.example/xss.js"></SCRIPT>`,
+	`<SCRIPT ="blah" SRC="httx://xss.example/xss.js"></SCRIPT>`,
+	`<SCRIPT a=">'>" SRC="httx://xss.example/xss.js"></SCRIPT>`,
+	`<SCRIPT>document.write("<SCRI");</SCRIPT>PT SRC="httx://xss.example/xss.js"></SCRIPT>`,
+	`<A HREF="javascript:document.location='http://www.example.com/'">XSS</A>`,
+	`<a href="&#1;javascript:alert(1)">x</a>`,
+	`<a href="javas&#99;ript:alert(1)">x</a>`,
+	`<a href="JAVASCRIPT&colon;alert(1)">x</a>`,
+	`<a href="&#106avascript:alert(1)">x</a>`,
+	`<a href="javascript&#58alert(1)">x</a>`,
+	`<a href="java&NewLine;script:alert(1)">x</a>`,
+	`<a href="java&Tab;script:alert(1)">x</a>`,
+	`<a href="  &#x20;javascript:alert(1)">x</a>`,
+	"<a href=\"\x01javascript:alert(1)\">x</a>",
+	`<a href="data:text/html,<script>alert(1)</script>">x</a>`,
+	`<a href="livescript:alert(1)">x</a>`,
+	`<img src="x:gif" onerror="alert(1)">`,
+}
+
+// mutationVectors 清理结果被浏览器重新解析时可能变形的 mutation XSS 向量
+var mutationVectors = []string{
+	`<svg></p><style><a id="</style><img src=1 onerror=alert(1)>">`,
+	`<math><mtext><table><mglyph><style><!--</style><img title="--&gt;&lt;/mglyph&gt;&lt;img&Tab;src=1&Tab;onerror=alert(1)&gt;">`,
+	`<form><math><mtext></form><form><mglyph><style></math><img src onerror=alert(1)>`,
+	`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`,
+	`<xmp><p title="</xmp><img src=x onerror=alert(1)>">`,
+	`<title><p title="</title><img src=x onerror=alert(1)>">`,
+	`<textarea><p title="</textarea><img src=x onerror=alert(1)>">`,
+	`<noembed><p title="</noembed><img src=x onerror=alert(1)>">`,
+	`<noframes><p title="</noframes><img src=x onerror=alert(1)>">`,
+	`<iframe><p title="</iframe><img src=x onerror=alert(1)>">`,
+	`<select><template><style><!--</style><a rel="--></style></template></select><img src=x onerror=alert(1)>">`,
+	`<listing>&lt;img onerror="alert(1)" src=x&gt;</listing>`,
+	`<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>`,
+	`<a title="&lt;/a&gt;&lt;img src=x onerror=alert(1)&gt;">x</a>`,
+	`<p>a<table><tr><td>b</td></tr></table><img src=x onerror=alert(1)>`,
+	`<table><p><img src=x onerror=alert(1)></p></table>`,
+	`<a href="https://ok.example">x<a href="javascript:alert(1)">y</a>`,
+	"<img src=\"x` `<script>alert(1)</script>\"` `>",
+	`<!--><img src=x onerror=alert(1)>-->`,
+	`<!--[if]><script>alert(1)</script -->`,
+	`<!-- --!><img src=x onerror=alert(1)>-->`,
+	`<?xml-stylesheet ?><img src=x onerror=alert(1)>`,
+	`<![CDATA[<img src=x onerror=alert(1)>]]>`,
+	`<div id="1"><![CDATA[</div><img src=x onerror=alert(1)>]]>`,
+	`</p><script>alert(1)</script>`,
+	`<scr<script>ipt>alert(1)</script>`,
+	"<scr\x00ipt>alert(1)</scr\x00ipt>",
+	"<img src=x o\x00nerror=alert(1)>",
+	`<p/title="a"/onclick=alert(1)>x</p>`,
+	`<p title="x"title="y" onclick="alert(1)">x</p>`,
+	`<b><p>x</b>y<img src=x onerror=alert(1)>`,
+	`<plaintext><img src=x onerror=alert(1)>`,
+}
+
+// permissivePolicy 允许各类地址属性的策略，用于检查地址属性的协议过滤
+var permissivePolicy = MustCompile("test:permissive", Config{
+	Elements: map[string][]string{
+		"a":          {"href", "ping", "xlink:href", "title"},
+		"img":        {"src", "srcset", "alt", "longdesc", "usemap", "dynsrc", "lowsrc"},
+		"picture":    nil,
+		"source":     {"src", "srcset"},
+		"video":      {"src", "poster"},
+		"form":       {"action"},
+		"button":     {"formaction"},
+		"input":      {"formaction", "type", "src"},
+		"blockquote": {"cite"},
+		"table":      {"background"},
+		"td":         {"background"},
+		"tr":         nil,
+		"tbody":      nil,
+		"p":          nil,
+		"div":        nil,
+		"b":          nil,
+	},
+	GlobalAttrs: []string{"class", "title"},
+	Protocols:   []string{"http", "https", "mailto"},
+})
+
+// urlAttrVectors 地址属性中的脚本协议
+var urlAttrVectors = []string{
+	`<img srcset="https://a.example/x.png 1x, javascript:alert(1) 2x">`,
+	`<img srcset="javascript:alert(1)">`,
+	`<img srcset=" java&#x09;script:alert(1) 1x">`,
+	`<picture><source srcset="data:image/svg+xml,<svg onload=alert(1)>"><img src="/x.png"></picture>`,
+	`<p style="background:url(javascript:alert(1))">x</p>`,
+	`<img style="xss:expression(alert(1))" src="/x.png">`,
+	`<form action="javascript:alert(1)"><button formaction="javascript:alert(1)">x</button></form>`,
+	`<input type="image" formaction="JaVaScRiPt:alert(1)" src="javascript:alert(1)">`,
+	`<a xlink:href="javascript:alert(1)">x</a>`,
+	`<a href="#" xlink:href="data:text/html,<script>alert(1)</script>">x</a>`,
+	`<a ping="javascript:alert(1)" href="https://example.com">x</a>`,
+	`<img longdesc="javascript:alert(1)" usemap="javascript:alert(1)" src="/x.png">`,
+	`<video poster="javascript:alert(1)" src="vbscript:msgbox(1)"></video>`,
+	`<blockquote cite="javascript:alert(1)">x</blockquote>`,
+	`<table background="javascript:alert(1)"><tr><td background="javascript:alert(1)">x</td></tr></table>`,
+}
+
+// unsafeURL 独立于 allowURL 判断地址是否使用脚本协议，按浏览器的方式先移除空白与控制字符
+func unsafeURL(raw string) bool {
+	cleaned := strings.ToLower(strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw))
+	for _, scheme := range []string{"javascript:", "vbscript:", "livescript:", "data:"} {
+		if strings.HasPrefix(cleaned, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// assertParsedSafe 将清理结果按浏览器的方式重新解析，解析出的元素与属性都必须是策略允许的，
+// 且不包含事件属性、style 属性与脚本协议的地址；结果再次清理应保持不变
+func assertParsedSafe(t *testing.T, p *Policy, input string) {
+	t.Helper()
+	out := p.Sanitize(input)
+	nodes, err := html.ParseFragment(strings.NewReader(out), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	require.NoError(t, err)
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			allowed, ok := p.elements[n.Data]
+			assert.True(t, ok, "%s: 元素 <%s> 未被允许: %s => %s", p.Name(), n.Data, input, out)
+			for _, attr := range n.Attr {
+				key := attr.Key
+				if attr.Namespace != "" {
+					key = attr.Namespace + ":" + key
+				}
+				_, ok := allowed[key]
+				_, global := p.globalAttrs[key]
+				noFollow := key == "rel" && n.Data == "a" && p.noFollow
+				assert.True(t, ok || global || noFollow, "%s: 属性 %s 未被允许: %s => %s", p.Name(), key, input, out)
+				assert.False(t, strings.HasPrefix(key, "on") || key == "style", "%s: %s => %s", p.Name(), input, out)
+				candidates := []string{attr.Val}
+				if key == "srcset" {
+					candidates = strings.Split(attr.Val, ",")
+				}
+				for _, candidate := range candidates {
+					assert.False(t, unsafeURL(candidate), "%s: %s=%q: %s => %s", p.Name(), key, attr.Val, input, out)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	assert.Equal(t, out, p.Sanitize(out), "%s: %s", p.Name(), input)
+}
+
+func TestPolicies_OWASPVectors(t *testing.T) {
+	for _, p := range []*Policy{StrictText, BasicFormatting, RichArticle, permissivePolicy} {
+		for _, input := range owaspVectors {
+			assertParsedSafe(t, p, input)
+		}
+		for _, input := range xssCorpus {
+			assertParsedSafe(t, p, input)
+		}
+	}
+}
+
+func TestPolicies_MutationXSS(t *testing.T) {
+	for _, p := range []*Policy{StrictText, BasicFormatting, RichArticle, permissivePolicy} {
+		for _, input := range mutationVectors {
+			assertParsedSafe(t, p, input)
+		}
+	}
+
+	// 转义的文本保持转义，不会在输出中还原为标签
+	assert.Equal(t, "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>", BasicFormatting.Sanitize(`<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>`))
+	assert.Equal(t, `<a title="&lt;/a&gt;&lt;img src=x onerror=alert(1)&gt;" rel="nofollow noopener noreferrer">x</a>`,
+		RichArticle.Sanitize(`<a title="&lt;/a&gt;&lt;img src=x onerror=alert(1)&gt;">x</a>`))
+	// 与启用脚本的浏览器一致，noscript 按原始文本解析，其后的标签照常清理
+	assert.Equal(t, `<img src="x">&#34;&gt;`, RichArticle.Sanitize(`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`))
+}
+
+func TestPolicy_URLAttributes(t *testing.T) {
+	for _, input := range urlAttrVectors {
+		assertParsedSafe(t, permissivePolicy, input)
+	}
+
+	// 不安全的候选地址使整个 srcset 被丢弃，安全的 srcset 保留
+	assert.Equal(t, `<img>`, permissivePolicy.Sanitize(`<img srcset="https://a.example/x.png 1x, javascript:alert(1) 2x">`))
+	assert.Equal(t, `<img srcset="/x.png 1x, https://a.example/y.png 2x">`, permissivePolicy.Sanitize(`<img srcset="/x.png 1x, https://a.example/y.png 2x">`))
+	assert.Equal(t, `<p>x</p>`, permissivePolicy.Sanitize(`<p style="color:red">x</p>`))
+	assert.Equal(t, `<form><button>x</button></form>`, permissivePolicy.Sanitize(`<form action="javascript:alert(1)"><button formaction="javascript:alert(1)">x</button></form>`))
+	assert.Equal(t, `<a>x</a>`, permissivePolicy.Sanitize(`<a xlink:href="javascript:alert(1)">x</a>`))
+	assert.Equal(t, `<a xlink:href="https://example.com">x</a>`, permissivePolicy.Sanitize(`<a xlink:href="https://example.com">x</a>`))
+
+	// 任何策略都不能允许 style 属性
+	_, err := Compile("test:style", Config{Elements: map[string][]string{"p": {"STYLE"}}})
+	assert.Error(t, err)
+	_, err = Compile("test:style", Config{GlobalAttrs: []string{"style"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 支持的搜索驱动
+const (
+	DriverMeilisearch   = "meilisearch"
+	DriverElasticsearch = "elasticsearch"
+)
+
+// Config 搜索配置，对应配置段 search
+//
+//	search:
+//	  driver: meilisearch
+//	  host: http://127.0.0.1:7700
+//	  api_key: masterKey
+//	  index_prefix: myapp_
+type Config struct {
+	Driver      string        `mapstructure:"driver" json:"driver" validate:"required,oneof=meilisearch elasticsearch"`
+	Host        string        `mapstructure:"host" json:"host" validate:"required,url"`
+	APIKey      string        `mapstructure:"api_key" json:"api_key"`
+	Username    string        `mapstructure:"username" json:"username"`
+	Password    string        `mapstructure:"password" json:"password"`
+	IndexPrefix string        `mapstructure:"index_prefix" json:"index_prefix"`
+	Timeout     time.Duration `mapstructure:"timeout" json:"timeout"`
+}
+
+// NewEngine 根据配置创建搜索引擎驱动
+func NewEngine(cfg Config) (Engine, error) {
+	client := newHTTPClient(cfg)
+	switch cfg.Driver {
+	case DriverMeilisearch:
+		return &MeilisearchEngine{client: client}, nil
+	case DriverElasticsearch:
+		return &ElasticsearchEngine{client: client}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, cfg.Driver)
+}
+
+// httpClient 驱动共用的HTTP客户端
+type httpClient struct {
+	host     string
+	apiKey   string
+	username string
+	password string
+	prefix   string
+	client   *http.Client
+}
+
+// newHTTPClient 创建HTTP客户端，默认超时10秒
+func newHTTPClient(cfg Config) *httpClient {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &httpClient{
+		host:     strings.TrimRight(cfg.Host, "/"),
+		apiKey:   cfg.APIKey,
+		username: cfg.Username,
+		password: cfg.Password,
+		prefix:   cfg.IndexPrefix,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// index 加上前缀的索引名称
+func (c *httpClient) index(name string) string {
+	return c.prefix + name
+}
+
+// do 发送请求，body 为 []byte 时原样发送，否则编码为JSON；out 不为nil时解析JSON响应
+// allowNotFound 为true时404不视为错误
+func (c *httpClient) do(ctx context.Context, method, path, contentType string, body interface{}, out interface{}, allowNotFound bool) error {
+	var reader io.Reader
+	if body != nil {
+		data, ok := body.([]byte)
+		if !ok {
+			var err error
+			if data, err = json.Marshal(body); err != nil {
+				return fmt.Errorf("编码搜索请求失败: %w", err)
+			}
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.host+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	switch {
+	case c.apiKey != "" && c.username == "":
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRequestFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && allowNotFound {
+		return nil
+	}
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%w: %s %s 返回 %d: %s", ErrRequestFailed, method, path, resp.StatusCode, bytes.TrimSpace(message))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析搜索响应失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ElasticsearchEngine Elasticsearch 驱动，通过 REST API 访问
+type ElasticsearchEngine struct {
+	client *httpClient
+}
+
+// NewElasticsearchEngine 创建 Elasticsearch 驱动
+func NewElasticsearchEngine(host, username, password string) *ElasticsearchEngine {
+	return &ElasticsearchEngine{client: newHTTPClient(Config{Host: host, Username: username, Password: password})}
+}
+
+// Index 写入或更新单个文档
+func (e *ElasticsearchEngine) Index(ctx context.Context, index string, doc Document) error {
+	path := fmt.Sprintf("/%s/_doc/%s", url.PathEscape(e.client.index(index)), url.PathEscape(doc.ID))
+	return e.client.do(ctx, http.MethodPut, path, "application/json", doc.Fields, nil, false)
+}
+
+// esBulkResponse 批量写入响应
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID    string `json:"_id"`
+		Error *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// BulkIndex 通过 _bulk 接口批量写入文档，任一文档失败时返回错误
+func (e *ElasticsearchEngine) BulkIndex(ctx context.Context, index string, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]interface{}{"index": map[string]string{"_index": e.client.index(index), "_id": doc.ID}}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+		if err := encoder.Encode(doc.Fields); err != nil {
+			return fmt.Errorf("编码文档 %s 失败: %w", doc.ID, err)
+		}
+	}
+
+	var resp esBulkResponse
+	if err := e.client.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes(), &resp, false); err != nil {
+		return err
+	}
+	if !resp.Errors {
+		return nil
+	}
+	var failures []string
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Error != nil {
+				failures = append(failures, fmt.Sprintf("%s: %s", result.ID, result.Error.Reason))
+			}
+		}
+	}
+	return fmt.Errorf("%w: %d 个文档写入失败: %s", ErrRequestFailed, len(failures), strings.Join(failures, "; "))
+}
+
+// Delete 删除文档
+func (e *ElasticsearchEngine) Delete(ctx context.Context, index string, id string) error {
+	path := fmt.Sprintf("/%s/_doc/%s", url.PathEscape(e.client.index(index)), url.PathEscape(id))
+	return e.client.do(ctx, http.MethodDelete, path, "", nil, nil, true)
+}
+
+// esSearchResponse 搜索响应
+type esSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			ID        string                 `json:"_id"`
+			Score     float64                `json:"_score"`
+			Source    map[string]interface{} `json:"_source"`
+			Highlight map[string][]string    `json:"highlight"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// Search 搜索文档，Text 在所有字段中匹配，Filters 转换为 term/terms 过滤
+func (e *ElasticsearchEngine) Search(ctx context.Context, index string, query Query) (*Results, error) {
+	query = query.normalize()
+
+	must := []interface{}{map[string]interface{}{"match_all": map[string]interface{}{}}}
+	if query.Text != "" {
+		must = []interface{}{map[string]interface{}{"multi_match": map[string]interface{}{"query": query.Text}}}
+	}
+	fields := make([]string, 0, len(query.Filters))
+	for field := range query.Filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	var filter []interface{}
+	for _, field := range fields {
+		values := filterValues(query.Filters[field])
+		if len(values) == 1 {
+			filter = append(filter, map[string]interface{}{"term": map[string]interface{}{field: values[0]}})
+		} else {
+			filter = append(filter, map[string]interface{}{"terms": map[string]interface{}{field: values}})
+		}
+	}
+
+	body := map[string]interface{}{
+		"query": map[string]interface{}{"bool": map[string]interface{}{"must": must, "filter": filter}},
+		"from":  query.offset(),
+		"size":  query.PerPage,
+	}
+	if len(query.Highlight) > 0 {
+		fields := make(map[string]interface{}, len(query.Highlight))
+		for _, field := range query.Highlight {
+			fields[field] = map[string]interface{}{}
+		}
+		body["highlight"] = map[string]interface{}{"fields": fields}
+	}
+	if len(query.Sort) > 0 {
+		sorts := make([]interface{}, len(query.Sort))
+		for i, s := range query.Sort {
+			field, order, _ := strings.Cut(s, ":")
+			if order == "" {
+				order = "asc"
+			}
+			sorts[i] = map[string]interface{}{field: map[string]string{"order": order}}
+		}
+		body["sort"] = sorts
+	}
+
+	var resp esSearchResponse
+	path := fmt.Sprintf("/%s/_search", url.PathEscape(e.client.index(index)))
+	if err := e.client.do(ctx, http.MethodPost, path, "application/json", body, &resp, false); err != nil {
+		return nil, err
+	}
+
+	results := &Results{Total: resp.Hits.Total.Value, Page: query.Page, PerPage: query.PerPage}
+	for _, raw := range resp.Hits.Hits {
+		results.Hits = append(results.Hits, Hit{
+			ID:         raw.ID,
+			Score:      raw.Score,
+			Document:   raw.Source,
+			Highlights: raw.Highlight,
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,183 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedRequest 测试服务器收到的请求
+type recordedRequest struct {
+	Method string
+	Path   string
+	Auth   string
+	Body   string
+}
+
+// newRecordingServer 记录请求并返回固定响应的测试服务器
+func newRecordingServer(t *testing.T, response string) (*httptest.Server, *[]recordedRequest) {
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, recordedRequest{r.Method, r.URL.RequestURI(), r.Header.Get("Authorization"), string(body)})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestMeilisearchEngine_Requests(t *testing.T) {
+	server, requests := newRecordingServer(t, `{
+		"hits": [
+			{"id": "2", "name": "phone", "_rankingScore": 0.9, "_formatted": {"name": "<em>phone</em>"}},
+			{"id": "1", "name": "phone case", "_rankingScore": 0.5}
+		],
+		"estimatedTotalHits": 2
+	}`)
+	engine, err := NewEngine(Config{Driver: DriverMeilisearch, Host: server.URL, APIKey: "secret", IndexPrefix: "app_"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, engine.Index(ctx, "products", Document{ID: "1", Fields: map[string]interface{}{"name": "phone case"}}))
+	require.NoError(t, engine.Delete(ctx, "products", "1"))
+	results, err := engine.Search(ctx, "products", Query{
+		Text:      "phone",
+		Filters:   map[string]interface{}{"brand": []string{"acme", "globex"}, "stock": 1},
+		Page:      2,
+		PerPage:   10,
+		Highlight: []string{"name"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 3)
+	index := (*requests)[0]
+	assert.Equal(t, "POST /indexes/app_products/documents?primaryKey=id", index.Method+" "+index.Path)
+	assert.Equal(t, "Bearer secret", index.Auth)
+	assert.JSONEq(t, `[{"id":"1","name":"phone case"}]`, index.Body)
+	assert.Equal(t, "DELETE /indexes/app_products/documents/1", (*requests)[1].Method+" "+(*requests)[1].Path)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte((*requests)[2].Body), &body))
+	assert.Equal(t, `(brand = "acme" OR brand = "globex") AND stock = 1`, body["filter"])
+	assert.Equal(t, float64(10), body["offset"])
+
+	assert.Equal(t, []string{"2", "1"}, results.IDs())
+	assert.Equal(t, int64(2), results.Total)
+	assert.Equal(t, 0.9, results.Hits[0].Score)
+	assert.Equal(t, []string{"<em>phone</em>"}, results.Hits[0].Highlights["name"])
+	assert.NotContains(t, results.Hits[0].Document, "_formatted")
+}
+
+func TestElasticsearchEngine_Requests(t *testing.T) {
+	server, requests := newRecordingServer(t, `{
+		"errors": false,
+		"hits": {
+			"total": {"value": 1},
+			"hits": [{"_id": "7", "_score": 1.2, "_source": {"name": "phone"}, "highlight": {"name": ["<em>phone</em>"]}}]
+		}
+	}`)
+	engine, err := NewEngine(Config{Driver: DriverElasticsearch, Host: server.URL + "/", Username: "elastic", Password: "pw"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	docs := []Document{
+		{ID: "1", Fields: map[string]interface{}{"name": "a"}},
+		{ID: "2", Fields: map[string]interface{}{"name": "b"}},
+	}
+	require.NoError(t, engine.BulkIndex(ctx, "products", docs))
+	results, err := engine.Search(ctx, "products", Query{Text: "phone", Filters: map[string]interface{}{"brand": "acme"}, Sort: []string{"price:desc"}})
+	require.NoError(t, err)
+
+	bulk := (*requests)[0]
+	assert.Equal(t, "POST /_bulk", bulk.Method+" "+bulk.Path)
+	assert.True(t, strings.HasPrefix(bulk.Auth, "Basic "))
+	lines := strings.Split(strings.TrimSpace(bulk.Body), "\n")
+	require.Len(t, lines, 4)
+	assert.JSONEq(t, `{"index":{"_index":"products","_id":"2"}}`, lines[2])
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte((*requests)[1].Body), &body))
+	assert.Equal(t, []interface{}{map[string]interface{}{"term": map[string]interface{}{"brand": "acme"}}},
+		body["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"price": map[string]interface{}{"order": "desc"}}}, body["sort"])
+
+	require.Len(t, results.Hits, 1)
+	assert.Equal(t, Hit{ID: "7", Score: 1.2, Document: map[string]interface{}{"name": "phone"},
+		Highlights: map[string][]string{"name": {"<em>phone</em>"}}}, results.Hits[0])
+}
+
+func TestEngine_ErrorResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"message":"invalid filter"}`)
+	}))
+	defer server.Close()
+
+	engine := NewMeilisearchEngine(server.URL, "")
+	assert.NoError(t, engine.Delete(context.Background(), "products", "missing"), "删除不存在的文档不报错")
+	_, err := engine.Search(context.Background(), "products", Query{})
+	assert.ErrorIs(t, err, ErrRequestFailed)
+	assert.Contains(t, err.Error(), "invalid filter")
+
+	_, err = NewEngine(Config{Driver: "solr"})
+	assert.ErrorIs(t, err, ErrUnsupportedDriver)
+}
+
+// TestEngine_RoundTrip 需要真实的搜索服务，通过环境变量启用：
+//
+//	FLOW_TEST_MEILISEARCH_URL=http://127.0.0.1:7700 FLOW_TEST_MEILISEARCH_KEY=masterKey
+//	FLOW_TEST_ELASTICSEARCH_URL=http://127.0.0.1:9200
+func TestEngine_RoundTrip(t *testing.T) {
+	engines := map[string]Config{}
+	if host := os.Getenv("FLOW_TEST_MEILISEARCH_URL"); host != "" {
+		engines[DriverMeilisearch] = Config{Driver: DriverMeilisearch, Host: host, APIKey: os.Getenv("FLOW_TEST_MEILISEARCH_KEY")}
+	}
+	if host := os.Getenv("FLOW_TEST_ELASTICSEARCH_URL"); host != "" {
+		engines[DriverElasticsearch] = Config{Driver: DriverElasticsearch, Host: host}
+	}
+	if len(engines) == 0 {
+		t.Skip("未设置 FLOW_TEST_MEILISEARCH_URL 或 FLOW_TEST_ELASTICSEARCH_URL")
+	}
+
+	for driver, cfg := range engines {
+		t.Run(driver, func(t *testing.T) {
+			cfg.IndexPrefix = "flow_test_" + strings.ReplaceAll(t.Name(), "/", "_") + "_"
+			engine, err := NewEngine(cfg)
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			require.NoError(t, engine.BulkIndex(ctx, "products", []Document{
+				{ID: "1", Fields: map[string]interface{}{"name": "red phone"}},
+				{ID: "2", Fields: map[string]interface{}{"name": "blue chair"}},
+			}))
+
+			// 两种引擎的写入都是近实时的，轮询直到可搜索
+			var results *Results
+			require.Eventually(t, func() bool {
+				results, err = engine.Search(ctx, "products", Query{Text: "phone"})
+				return err == nil && len(results.Hits) == 1
+			}, 10*time.Second, 200*time.Millisecond)
+			assert.Equal(t, "1", results.Hits[0].ID)
+
+			require.NoError(t, engine.Delete(ctx, "products", "1"))
+			require.Eventually(t, func() bool {
+				results, err = engine.Search(ctx, "products", Query{Text: "phone"})
+				return err == nil && len(results.Hits) == 0
+			}, 10*time.Second, 200*time.Millisecond)
+		})
+	}
+}
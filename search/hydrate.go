@@ -0,0 +1,60 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Hydrate 按搜索结果的ID从数据库加载模型，返回顺序与搜索结果的相关度排序一致
+// 已从数据库删除但仍在索引中的文档会被跳过；T 或 *T 需实现 Indexable
+//
+//	results, _ := engine.Search(ctx, "products", search.Query{Text: "phone"})
+//	products, err := search.Hydrate[Product](ctx, db, results)
+func Hydrate[T any](ctx context.Context, db *gorm.DB, results *Results) ([]T, error) {
+	ids := results.IDs()
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	values := make([]interface{}, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+
+	var records []T
+	err := db.WithContext(ctx).
+		Where(clause.IN{Column: clause.PrimaryColumn, Values: values}).
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("加载搜索结果失败: %w", err)
+	}
+
+	byID := make(map[string]int, len(records))
+	for i := range records {
+		model, ok := indexable(&records[i])
+		if !ok {
+			return nil, fmt.Errorf("%T 未实现 search.Indexable", records[i])
+		}
+		byID[model.SearchID()] = i
+	}
+
+	ordered := make([]T, 0, len(records))
+	for _, id := range ids {
+		if i, ok := byID[id]; ok {
+			ordered = append(ordered, records[i])
+		}
+	}
+	return ordered, nil
+}
+
+// indexable 将 *T 或 T 转换为 Indexable
+func indexable[T any](ptr *T) (Indexable, bool) {
+	if model, ok := interface{}(ptr).(Indexable); ok {
+		return model, true
+	}
+	model, ok := interface{}(*ptr).(Indexable)
+	return model, ok
+}
@@ -0,0 +1,166 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// meiliPrimaryKey Meilisearch 文档的主键字段
+const meiliPrimaryKey = "id"
+
+// MeilisearchEngine Meilisearch 驱动，通过 REST API 访问
+// Meilisearch 的写入是异步任务，Index 返回时文档可能尚未可搜索
+type MeilisearchEngine struct {
+	client *httpClient
+}
+
+// NewMeilisearchEngine 创建 Meilisearch 驱动
+func NewMeilisearchEngine(host, apiKey string) *MeilisearchEngine {
+	return &MeilisearchEngine{client: newHTTPClient(Config{Host: host, APIKey: apiKey})}
+}
+
+// Index 写入或更新单个文档
+func (e *MeilisearchEngine) Index(ctx context.Context, index string, doc Document) error {
+	return e.BulkIndex(ctx, index, []Document{doc})
+}
+
+// BulkIndex 批量写入或更新文档，文档ID写入 id 字段
+func (e *MeilisearchEngine) BulkIndex(ctx context.Context, index string, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	payload := make([]map[string]interface{}, len(docs))
+	for i, doc := range docs {
+		fields := make(map[string]interface{}, len(doc.Fields)+1)
+		for k, v := range doc.Fields {
+			fields[k] = v
+		}
+		fields[meiliPrimaryKey] = doc.ID
+		payload[i] = fields
+	}
+	path := fmt.Sprintf("/indexes/%s/documents?primaryKey=%s", url.PathEscape(e.client.index(index)), meiliPrimaryKey)
+	return e.client.do(ctx, http.MethodPost, path, "application/json", payload, nil, false)
+}
+
+// Delete 删除文档
+func (e *MeilisearchEngine) Delete(ctx context.Context, index string, id string) error {
+	path := fmt.Sprintf("/indexes/%s/documents/%s", url.PathEscape(e.client.index(index)), url.PathEscape(id))
+	return e.client.do(ctx, http.MethodDelete, path, "", nil, nil, true)
+}
+
+// meiliSearchResponse Meilisearch 搜索响应
+type meiliSearchResponse struct {
+	Hits               []map[string]interface{} `json:"hits"`
+	EstimatedTotalHits int64                    `json:"estimatedTotalHits"`
+}
+
+// Search 搜索文档，过滤字段需在 Meilisearch 中设置为 filterableAttributes
+func (e *MeilisearchEngine) Search(ctx context.Context, index string, query Query) (*Results, error) {
+	query = query.normalize()
+	body := map[string]interface{}{
+		"q":                query.Text,
+		"offset":           query.offset(),
+		"limit":            query.PerPage,
+		"showRankingScore": true,
+	}
+	if filter := meiliFilter(query.Filters); filter != "" {
+		body["filter"] = filter
+	}
+	if len(query.Highlight) > 0 {
+		body["attributesToHighlight"] = query.Highlight
+	}
+	if len(query.Sort) > 0 {
+		body["sort"] = query.Sort
+	}
+
+	var resp meiliSearchResponse
+	path := fmt.Sprintf("/indexes/%s/search", url.PathEscape(e.client.index(index)))
+	if err := e.client.do(ctx, http.MethodPost, path, "application/json", body, &resp, false); err != nil {
+		return nil, err
+	}
+
+	results := &Results{Total: resp.EstimatedTotalHits, Page: query.Page, PerPage: query.PerPage}
+	for _, raw := range resp.Hits {
+		hit := Hit{ID: fmt.Sprint(raw[meiliPrimaryKey]), Document: make(map[string]interface{}, len(raw))}
+		for k, v := range raw {
+			switch k {
+			case "_rankingScore":
+				hit.Score, _ = v.(float64)
+			case "_formatted":
+				hit.Highlights = meiliHighlights(v, query.Highlight)
+			default:
+				hit.Document[k] = v
+			}
+		}
+		results.Hits = append(results.Hits, hit)
+	}
+	return results, nil
+}
+
+// meiliFilter 将过滤条件转换为 Meilisearch 过滤表达式
+func meiliFilter(filters map[string]interface{}) string {
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	clauses := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := filterValues(filters[key])
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = fmt.Sprintf("%s = %s", key, meiliValue(v))
+		}
+		clause := strings.Join(parts, " OR ")
+		if len(parts) > 1 {
+			clause = "(" + clause + ")"
+		}
+		clauses = append(clauses, clause)
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// meiliValue 过滤值的表达式形式，字符串加引号
+func meiliValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return strconv.Quote(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// meiliHighlights 从 _formatted 中取出高亮字段
+func meiliHighlights(formatted interface{}, fields []string) map[string][]string {
+	values, ok := formatted.(map[string]interface{})
+	if !ok || len(fields) == 0 {
+		return nil
+	}
+	highlights := make(map[string][]string, len(fields))
+	for _, field := range fields {
+		if s, ok := values[field].(string); ok {
+			highlights[field] = []string{s}
+		}
+	}
+	return highlights
+}
+
+// filterValues 将过滤值展开为切片
+func filterValues(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{v}
+	}
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values
+}
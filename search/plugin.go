@@ -0,0 +1,188 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/zzliekkas/flow/v2/queue"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SyncJobName 异步同步索引的队列任务名称
+const SyncJobName = "search:sync"
+
+// 同步操作类型
+const (
+	opIndex  = "index"
+	opDelete = "delete"
+)
+
+// Plugin gorm 插件，在模型创建、更新、删除后同步搜索索引
+//
+// 未配置队列时在回调中直接写入搜索引擎；配置队列后只分发包含索引名与ID的任务，
+// 任务执行时重新从数据库加载模型再写入索引，记录不存在时从索引删除，因此任务重试与乱序执行都能得到最终一致的结果。
+// 回调在 gorm 的自动事务提交之后执行；在显式事务中回调先于事务提交，可通过 queue.WithDelay 延迟任务执行
+//
+//	conn.Use(search.NewPlugin(engine, search.WithQueue(queues, queue.WithDelay(time.Second))))
+type Plugin struct {
+	engine       Engine
+	queue        *queue.QueueManager
+	dispatchOpts []queue.DispatchOption
+	onError      func(error)
+	db           *gorm.DB
+}
+
+// PluginOption 插件选项
+type PluginOption func(*Plugin)
+
+// WithQueue 通过队列异步同步索引，并为队列注册 SyncJobName 任务处理器
+func WithQueue(manager *queue.QueueManager, opts ...queue.DispatchOption) PluginOption {
+	return func(p *Plugin) {
+		p.queue = manager
+		p.dispatchOpts = opts
+	}
+}
+
+// WithErrorHandler 设置同步失败时的处理函数，同步失败不会影响数据库操作的结果
+func WithErrorHandler(handler func(error)) PluginOption {
+	return func(p *Plugin) {
+		p.onError = handler
+	}
+}
+
+// NewPlugin 创建索引同步插件
+func NewPlugin(engine Engine, opts ...PluginOption) *Plugin {
+	p := &Plugin{engine: engine, onError: func(error) {}}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Name 插件名称
+func (p *Plugin) Name() string {
+	return "flow:search"
+}
+
+// Initialize 注册 gorm 回调
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	p.db = db
+	if p.queue != nil {
+		p.queue.Register(SyncJobName, p.HandleSync)
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:commit_or_rollback_transaction").
+		Register("flow:search_create", p.callback(opIndex)); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:commit_or_rollback_transaction").
+		Register("flow:search_update", p.callback(opIndex)); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:commit_or_rollback_transaction").
+		Register("flow:search_delete", p.callback(opDelete))
+}
+
+// callback 返回同步指定操作的回调
+func (p *Plugin) callback(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		if tx.Error != nil || tx.Statement.Schema == nil {
+			return
+		}
+		ctx := tx.Statement.Context
+		for _, model := range changedModels(tx) {
+			if err := p.sync(ctx, op, model); err != nil {
+				p.onError(fmt.Errorf("同步搜索索引 %s/%s 失败: %w", model.SearchIndex(), model.SearchID(), err))
+			}
+		}
+	}
+}
+
+// sync 同步单个模型
+func (p *Plugin) sync(ctx context.Context, op string, model Indexable) error {
+	if p.queue != nil {
+		payload := map[string]interface{}{"op": op, "index": model.SearchIndex(), "id": model.SearchID()}
+		_, err := p.queue.Dispatch(ctx, SyncJobName, payload, p.dispatchOpts...)
+		return err
+	}
+	if op == opDelete {
+		return DeleteModel(ctx, p.engine, model)
+	}
+	return IndexModel(ctx, p.engine, model)
+}
+
+// syncPayload 同步任务的负载
+type syncPayload struct {
+	Op    string `json:"op"`
+	Index string `json:"index"`
+	ID    string `json:"id"`
+}
+
+// HandleSync 处理同步任务，模型需通过 RegisterModels 注册
+// 使用 WithQueue 时自动注册；队列在插件初始化之后添加时需手动注册
+func (p *Plugin) HandleSync(ctx context.Context, job *queue.Job) error {
+	var payload syncPayload
+	if err := job.GetPayload(&payload); err != nil {
+		return err
+	}
+	if payload.Op == opDelete {
+		return p.engine.Delete(ctx, payload.Index, payload.ID)
+	}
+
+	model, err := LookupModel(payload.Index)
+	if err != nil {
+		return err
+	}
+	err = p.db.WithContext(ctx).Where(clause.Eq{Column: clause.PrimaryColumn, Value: payload.ID}).Take(model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 记录已删除或事务已回滚
+		return p.engine.Delete(ctx, payload.Index, payload.ID)
+	}
+	if err != nil {
+		return err
+	}
+	return IndexModel(ctx, p.engine, model)
+}
+
+// changedModels 取出语句涉及的、主键非零的可索引模型
+// 按条件批量更新或删除时没有主键，这类变更需通过重建索引同步
+func changedModels(tx *gorm.DB) []Indexable {
+	primary := tx.Statement.Schema.PrioritizedPrimaryField
+	if primary == nil {
+		return nil
+	}
+
+	var result []Indexable
+	collect := func(rv reflect.Value) {
+		for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+			if rv.IsNil() {
+				return
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Struct || !rv.CanAddr() {
+			return
+		}
+		if _, zero := primary.ValueOf(tx.Statement.Context, rv); zero {
+			return
+		}
+		if model, ok := rv.Addr().Interface().(Indexable); ok {
+			result = append(result, model)
+		}
+	}
+
+	rv := tx.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			collect(rv.Index(i))
+		}
+	default:
+		collect(rv)
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package search
+
+import (
+	"time"
+
+	"github.com/zzliekkas/flow/v2/app"
+)
+
+// Provider 搜索服务提供者，读取配置段 search 并注册 Engine
+type Provider struct {
+	*app.BaseProvider
+	config *Config
+}
+
+// NewProvider 创建搜索服务提供者
+func NewProvider() *Provider {
+	return &Provider{
+		BaseProvider: app.NewBaseProvider("search", 60),
+	}
+}
+
+// ConfigSection 配置段名称
+func (p *Provider) ConfigSection() string {
+	return "search"
+}
+
+// ConfigSchema 搜索配置结构
+func (p *Provider) ConfigSchema() interface{} {
+	return &Config{Timeout: 10 * time.Second}
+}
+
+// Configure 接收验证通过的配置
+func (p *Provider) Configure(cfg interface{}) error {
+	p.config = cfg.(*Config)
+	return nil
+}
+
+// Register 创建并注册搜索引擎
+func (p *Provider) Register(application *app.Application) error {
+	cfg := Config{}
+	if p.config != nil {
+		cfg = *p.config
+	}
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		return err
+	}
+	return application.Engine().Provide(func() Engine { return engine })
+}
+
+// Boot 启动搜索服务
+func (p *Provider) Boot(application *app.Application) error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ReindexOptions 重建索引选项
+type ReindexOptions struct {
+	// Chunk 每批读取与写入的记录数，默认500
+	Chunk int
+
+	// After 从主键大于该值的记录开始，用于中断后继续
+	After string
+
+	// Progress 每写入一批后调用
+	Progress func(ReindexProgress)
+}
+
+// ReindexProgress 重建进度
+type ReindexProgress struct {
+	LastID  string // 已写入的最后一条记录的主键
+	Indexed int    // 本次已写入的记录数
+}
+
+// Reindex 按主键顺序分批读取模型并写入索引，不会一次加载全部记录
+// 失败时返回的进度包含最后写入成功的主键，以 After 传入即可从中断处继续
+func Reindex(ctx context.Context, db *gorm.DB, engine Engine, model Indexable, opts ReindexOptions) (ReindexProgress, error) {
+	if opts.Chunk <= 0 {
+		opts.Chunk = 500
+	}
+	progress := ReindexProgress{LastID: opts.After}
+
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return progress, fmt.Errorf("解析模型 %T 失败: %w", model, err)
+	}
+	primary := stmt.Schema.PrioritizedPrimaryField
+	if primary == nil {
+		return progress, fmt.Errorf("模型 %T 没有主键", model)
+	}
+
+	var after interface{}
+	if opts.After != "" {
+		value, err := parsePrimaryKey(primary, opts.After)
+		if err != nil {
+			return progress, err
+		}
+		after = value
+	}
+
+	for {
+		batch := reflect.New(reflect.SliceOf(modelType))
+		query := db.WithContext(ctx).Model(reflect.New(modelType).Interface()).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: primary.DBName}}).
+			Limit(opts.Chunk)
+		if after != nil {
+			query = query.Where(clause.Gt{Column: clause.Column{Name: primary.DBName}, Value: after})
+		}
+		if err := query.Find(batch.Interface()).Error; err != nil {
+			return progress, fmt.Errorf("读取 %s 失败: %w", stmt.Schema.Table, err)
+		}
+
+		records := batch.Elem()
+		if records.Len() == 0 {
+			return progress, nil
+		}
+
+		docs := make([]Document, records.Len())
+		for i := range docs {
+			docs[i] = NewDocument(records.Index(i).Addr().Interface().(Indexable))
+		}
+		if err := engine.BulkIndex(ctx, model.SearchIndex(), docs); err != nil {
+			return progress, err
+		}
+
+		last, _ := primary.ValueOf(ctx, records.Index(records.Len()-1))
+		after = last
+		progress.LastID = fmt.Sprint(last)
+		progress.Indexed += len(docs)
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		if records.Len() < opts.Chunk {
+			return progress, nil
+		}
+	}
+}
+
+// parsePrimaryKey 将字符串形式的主键转换为字段类型
+func parsePrimaryKey(field *schema.Field, value string) (interface{}, error) {
+	switch field.DataType {
+	case schema.Int:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的主键 %q: %w", value, err)
+		}
+		return v, nil
+	case schema.Uint:
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的主键 %q: %w", value, err)
+		}
+		return v, nil
+	}
+	return value, nil
+}
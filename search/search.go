@@ -0,0 +1,162 @@
+// Package search 提供全文搜索集成：可索引模型接口、Meilisearch 与 Elasticsearch 驱动、
+// 自动同步索引的 gorm 插件、按模型ID回填搜索结果以及分批重建索引
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// 搜索错误定义
+var (
+	ErrUnsupportedDriver = errors.New("search: 不支持的搜索驱动")
+	ErrModelNotFound     = errors.New("search: 未注册的搜索模型")
+	ErrRequestFailed     = errors.New("search: 搜索服务请求失败")
+)
+
+// Indexable 可被搜索索引的模型
+type Indexable interface {
+	// SearchIndex 索引名称，例如 "products"
+	SearchIndex() string
+
+	// SearchID 文档ID，通常为模型主键
+	SearchID() string
+
+	// SearchDocument 写入索引的文档内容
+	SearchDocument() map[string]interface{}
+}
+
+// Document 待索引的文档
+type Document struct {
+	ID     string
+	Fields map[string]interface{}
+}
+
+// NewDocument 从模型创建文档
+func NewDocument(model Indexable) Document {
+	return Document{ID: model.SearchID(), Fields: model.SearchDocument()}
+}
+
+// Query 搜索条件
+type Query struct {
+	// Text 搜索文本，为空时匹配所有文档
+	Text string
+
+	// Filters 精确匹配的过滤条件，值为切片时匹配其中任意一个
+	Filters map[string]interface{}
+
+	// Page 页码，从1开始，默认1
+	Page int
+
+	// PerPage 每页数量，默认20
+	PerPage int
+
+	// Highlight 需要高亮的字段
+	Highlight []string
+
+	// Sort 排序字段，格式为 "field:asc" 或 "field:desc"，为空时按相关度排序
+	Sort []string
+}
+
+// normalize 填充分页默认值
+func (q Query) normalize() Query {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PerPage <= 0 {
+		q.PerPage = 20
+	}
+	return q
+}
+
+// offset 分页偏移量
+func (q Query) offset() int {
+	return (q.Page - 1) * q.PerPage
+}
+
+// Hit 单条搜索结果
+type Hit struct {
+	ID         string                 `json:"id"`
+	Score      float64                `json:"score"`
+	Document   map[string]interface{} `json:"document"`
+	Highlights map[string][]string    `json:"highlights,omitempty"`
+}
+
+// Results 搜索结果，Hits 按相关度排列
+type Results struct {
+	Hits    []Hit `json:"hits"`
+	Total   int64 `json:"total"`
+	Page    int   `json:"page"`
+	PerPage int   `json:"per_page"`
+}
+
+// IDs 按排列顺序返回结果的文档ID
+func (r *Results) IDs() []string {
+	ids := make([]string, len(r.Hits))
+	for i, hit := range r.Hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+// Engine 搜索引擎驱动
+type Engine interface {
+	// Index 写入或更新单个文档
+	Index(ctx context.Context, index string, doc Document) error
+
+	// BulkIndex 批量写入或更新文档
+	BulkIndex(ctx context.Context, index string, docs []Document) error
+
+	// Delete 删除文档，文档不存在时不返回错误
+	Delete(ctx context.Context, index string, id string) error
+
+	// Search 搜索文档
+	Search(ctx context.Context, index string, query Query) (*Results, error)
+}
+
+// IndexModel 将模型写入索引
+func IndexModel(ctx context.Context, engine Engine, model Indexable) error {
+	return engine.Index(ctx, model.SearchIndex(), NewDocument(model))
+}
+
+// DeleteModel 从索引中删除模型
+func DeleteModel(ctx context.Context, engine Engine, model Indexable) error {
+	return engine.Delete(ctx, model.SearchIndex(), model.SearchID())
+}
+
+var (
+	modelsMu sync.RWMutex
+	models   = make(map[string]reflect.Type)
+)
+
+// RegisterModels 注册可搜索的模型，注册后可按类型名或索引名查找，用于异步同步索引与 flow search reindex
+//
+//	func init() {
+//		search.RegisterModels(&Product{}, &Article{})
+//	}
+func RegisterModels(values ...Indexable) {
+	modelsMu.Lock()
+	defer modelsMu.Unlock()
+	for _, value := range values {
+		t := reflect.TypeOf(value)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		models[t.Name()] = t
+		models[value.SearchIndex()] = t
+	}
+}
+
+// LookupModel 按类型名或索引名查找已注册的模型，返回该类型的新实例指针
+func LookupModel(name string) (Indexable, error) {
+	modelsMu.RLock()
+	t, ok := models[name]
+	modelsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, name)
+	}
+	return reflect.New(t).Interface().(Indexable), nil
+}
@@ -0,0 +1,194 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2/queue"
+	"github.com/zzliekkas/flow/v2/queue/memory"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type product struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func (p *product) SearchIndex() string { return "products" }
+func (p *product) SearchID() string    { return strconv.FormatUint(uint64(p.ID), 10) }
+func (p *product) SearchDocument() map[string]interface{} {
+	return map[string]interface{}{"name": p.Name}
+}
+
+// fakeEngine 记录文档的内存搜索引擎
+type fakeEngine struct {
+	mu     sync.Mutex
+	docs   map[string]map[string]interface{}
+	bulks  int
+	failAt int // 第几次批量写入失败，0表示不失败
+}
+
+func newFakeEngine() *fakeEngine {
+	return &fakeEngine{docs: make(map[string]map[string]interface{})}
+}
+
+func (e *fakeEngine) Index(ctx context.Context, index string, doc Document) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.docs[index+"/"+doc.ID] = doc.Fields
+	return nil
+}
+
+func (e *fakeEngine) BulkIndex(ctx context.Context, index string, docs []Document) error {
+	e.mu.Lock()
+	e.bulks++
+	failed := e.bulks == e.failAt
+	e.mu.Unlock()
+	if failed {
+		return errors.New("engine unavailable")
+	}
+	for _, doc := range docs {
+		_ = e.Index(ctx, index, doc)
+	}
+	return nil
+}
+
+func (e *fakeEngine) Delete(ctx context.Context, index string, id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.docs, index+"/"+id)
+	return nil
+}
+
+func (e *fakeEngine) Search(ctx context.Context, index string, query Query) (*Results, error) {
+	return &Results{}, nil
+}
+
+func (e *fakeEngine) doc(id string) (map[string]interface{}, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	doc, ok := e.docs["products/"+id]
+	return doc, ok
+}
+
+func (e *fakeEngine) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.docs)
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&product{}))
+	return conn
+}
+
+func TestPlugin_SyncIndexesAfterWrites(t *testing.T) {
+	conn := newTestDB(t)
+	engine := newFakeEngine()
+	require.NoError(t, conn.Use(NewPlugin(engine)))
+
+	p := &product{Name: "phone"}
+	require.NoError(t, conn.Create(p).Error)
+	doc, ok := engine.doc(p.SearchID())
+	require.True(t, ok)
+	assert.Equal(t, "phone", doc["name"])
+
+	require.NoError(t, conn.Model(p).Update("name", "smart phone").Error)
+	doc, _ = engine.doc(p.SearchID())
+	assert.Equal(t, "smart phone", doc["name"])
+
+	require.NoError(t, conn.Delete(p).Error)
+	_, ok = engine.doc(p.SearchID())
+	assert.False(t, ok)
+
+	// 主键冲突的写入失败，不同步索引
+	require.NoError(t, conn.Create(&product{ID: 10, Name: "a"}).Error)
+	require.Error(t, conn.Create(&product{ID: 10, Name: "b"}).Error)
+	doc, _ = engine.doc("10")
+	assert.Equal(t, "a", doc["name"])
+}
+
+func TestPlugin_QueueReloadsFromDatabase(t *testing.T) {
+	RegisterModels(&product{})
+	conn := newTestDB(t)
+	engine := newFakeEngine()
+
+	mq := memory.New(0)
+	queues := queue.NewQueueManager()
+	require.NoError(t, queues.AddQueue("default", mq))
+	require.NoError(t, queues.SetDefaultQueue("default"))
+	require.NoError(t, conn.Use(NewPlugin(engine, WithQueue(queues))))
+
+	ctx := context.Background()
+	p := &product{Name: "phone"}
+	require.NoError(t, conn.Create(p).Error)
+	require.NoError(t, conn.Model(p).Update("name", "tablet").Error)
+	assert.Equal(t, 0, engine.count(), "任务执行前不写入索引")
+
+	// 两个任务都从数据库读取最新状态
+	require.NoError(t, mq.ProcessNext(ctx, "default"))
+	doc, _ := engine.doc(p.SearchID())
+	assert.Equal(t, "tablet", doc["name"])
+	require.NoError(t, mq.ProcessNext(ctx, "default"))
+
+	// 事务回滚后任务执行时记录不存在，从索引删除
+	_ = conn.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, tx.Create(&product{ID: 99, Name: "ghost"}).Error)
+		return errors.New("rollback")
+	})
+	require.NoError(t, mq.ProcessNext(ctx, "default"))
+	_, ok := engine.doc("99")
+	assert.False(t, ok)
+}
+
+func TestHydrate_PreservesRankingOrder(t *testing.T) {
+	conn := newTestDB(t)
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, conn.Create(&product{ID: uint(i), Name: "p" + strconv.Itoa(i)}).Error)
+	}
+
+	// 5 已从数据库删除但仍在索引中
+	results := &Results{Hits: []Hit{{ID: "3"}, {ID: "1"}, {ID: "5"}, {ID: "4"}}}
+	products, err := Hydrate[product](context.Background(), conn, results)
+	require.NoError(t, err)
+
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	assert.Equal(t, []uint{3, 1, 4}, ids)
+}
+
+func TestReindex_ChunkedAndResumable(t *testing.T) {
+	conn := newTestDB(t)
+	for i := 1; i <= 7; i++ {
+		require.NoError(t, conn.Create(&product{ID: uint(i), Name: "p" + strconv.Itoa(i)}).Error)
+	}
+
+	engine := newFakeEngine()
+	engine.failAt = 2
+
+	var batches []ReindexProgress
+	opts := ReindexOptions{Chunk: 3, Progress: func(p ReindexProgress) { batches = append(batches, p) }}
+	progress, err := Reindex(context.Background(), conn, engine, &product{}, opts)
+	require.Error(t, err)
+	assert.Equal(t, ReindexProgress{LastID: "3", Indexed: 3}, progress)
+	assert.Equal(t, 3, engine.count())
+
+	// 从中断处继续
+	opts.After = progress.LastID
+	progress, err = Reindex(context.Background(), conn, engine, &product{}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, ReindexProgress{LastID: "7", Indexed: 4}, progress)
+	assert.Equal(t, 7, engine.count())
+	assert.Len(t, batches, 3)
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"sync"
 	"time"
+
+	"github.com/zzliekkas/flow/v2/flowctx"
 )
 
 // AuditLogger 安全审计日志记录器接口
@@ -141,7 +143,7 @@ func (l *AuditLoggerImpl) LogEvent(eventType string, userID string, action strin
 		Action:    action,
 		Resource:  resource,
 		Success:   success,
-		Details:   details,
+		Details:   l.config.redaction().Map(details),
 	}
 
 	// 记录事件
@@ -195,8 +197,12 @@ func (l *AuditLoggerImpl) LogRequest(r *http.Request) error {
 		return nil
 	}
 
-	// 获取用户ID（实际项目中应从上下文或认证会话中获取）
-	userID := "anonymous"
+	// 用户ID与请求ID由认证与请求ID中间件写入请求的 context
+	values := flowctx.Import(r.Context())
+	userID := values.UserID
+	if userID == "" {
+		userID = "anonymous"
+	}
 
 	// 创建事件详情
 	details := map[string]interface{}{
@@ -205,6 +211,9 @@ func (l *AuditLoggerImpl) LogRequest(r *http.Request) error {
 		"remote_ip":  getClientIP(r),
 		"user_agent": r.UserAgent(),
 	}
+	if values.RequestID != "" {
+		details["request_id"] = values.RequestID
+	}
 
 	// 记录请求事件
 	return l.LogEvent("HTTP_REQUEST", userID, "request", r.URL.Path, true, details)
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/zzliekkas/flow/v2/flowctx"
 )
 
 // BasicAuditLogger 提供 AuditLogger 接口的简单实现
@@ -43,7 +45,7 @@ func (l *BasicAuditLogger) LogEvent(eventType string, userID string, action stri
 		Action:    action,
 		Resource:  resource,
 		Success:   success,
-		Details:   details,
+		Details:   l.config.redaction().Map(details),
 	}
 
 	// 将事件添加到内存日志中
@@ -63,8 +65,12 @@ func (l *BasicAuditLogger) LogRequest(r *http.Request) error {
 		return nil
 	}
 
-	// 获取用户ID（实际项目中应从上下文或认证会话中获取）
-	userID := "anonymous"
+	// 用户ID与请求ID由认证与请求ID中间件写入请求的 context
+	values := flowctx.Import(r.Context())
+	userID := values.UserID
+	if userID == "" {
+		userID = "anonymous"
+	}
 
 	// 创建事件详情
 	details := map[string]interface{}{
@@ -73,6 +79,9 @@ func (l *BasicAuditLogger) LogRequest(r *http.Request) error {
 		"remote_ip":  getClientIP(r),
 		"user_agent": r.UserAgent(),
 	}
+	if values.RequestID != "" {
+		details["request_id"] = values.RequestID
+	}
 
 	// 记录请求事件
 	return l.LogEvent("HTTP_REQUEST", userID, "request", r.URL.Path, true, details)
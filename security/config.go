@@ -1,5 +1,7 @@
 package security
 
+import "github.com/zzliekkas/flow/v2/redact"
+
 // Config 表示安全框架的配置
 type Config struct {
 	// 安全头部配置
@@ -195,6 +197,16 @@ type AuditConfig struct {
 	LogDataAccess bool
 	// 是否记录敏感操作事件
 	LogSensitiveActions bool
+	// 事件详情的脱敏策略，为空时使用 redact.Default
+	Redaction *redact.Policy
+}
+
+// redaction 返回事件详情的脱敏策略
+func (c AuditConfig) redaction() *redact.Policy {
+	if c.Redaction != nil {
+		return c.Redaction
+	}
+	return redact.Default()
 }
 
 // CSRFConfig 定义 CSRF 防护配置
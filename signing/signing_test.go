@@ -0,0 +1,44 @@
+package signing
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_SignsWithoutMutatingRequest(t *testing.T) {
+	secret := []byte("secret")
+	var received *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received, body = r, string(data)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewSigner("orders", secret).Transport(nil)}
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/charge?b=2&a=1", strings.NewReader(`{"amount":1}`))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Empty(t, req.Header.Get(HeaderSignature), "原请求不被修改")
+	assert.Equal(t, `{"amount":1}`, body)
+	assert.Equal(t, "orders", received.Header.Get(HeaderKeyID))
+
+	stringToSign := StringToSign(received.Method, received.URL.EscapedPath(), received.URL.RawQuery,
+		BodyHash([]byte(body)), received.Header.Get(HeaderTimestamp), received.Header.Get(HeaderNonce))
+	assert.True(t, Verify([][]byte{[]byte("old"), secret}, stringToSign, received.Header.Get(HeaderSignature)))
+	assert.False(t, Verify([][]byte{[]byte("old")}, stringToSign, received.Header.Get(HeaderSignature)))
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	assert.Equal(t, "a=1&a=2&b=x+y", CanonicalQuery("b=x+y&a=2&a=1"))
+	assert.Equal(t, "", CanonicalQuery(""))
+}
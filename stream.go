@@ -0,0 +1,225 @@
+package flow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// 流式响应的默认刷新策略
+const (
+	defaultStreamFlushItems = 100
+	defaultStreamFlushBytes = 32 * 1024
+)
+
+// streamConfig 流式响应配置
+type streamConfig struct {
+	flushItems int
+	flushBytes int
+}
+
+// StreamOption 流式响应选项
+type StreamOption func(*streamConfig)
+
+// WithStreamFlushItems 每写入n个元素刷新一次，默认100
+func WithStreamFlushItems(n int) StreamOption {
+	return func(c *streamConfig) {
+		c.flushItems = n
+	}
+}
+
+// WithStreamFlushBytes 缓冲超过n字节时刷新一次，默认32KB
+func WithStreamFlushBytes(n int) StreamOption {
+	return func(c *streamConfig) {
+		c.flushBytes = n
+	}
+}
+
+// StreamEncoder 流式JSON编码器，由 JSONStream 与 NDJSON 创建
+type StreamEncoder struct {
+	ctx     context.Context
+	writer  http.ResponseWriter
+	buf     bytes.Buffer
+	json    JSONOptions
+	config  streamConfig
+	ndjson  bool
+	count   int
+	pending int
+}
+
+// newStreamEncoder 创建流式编码器
+func newStreamEncoder(ctx context.Context, w http.ResponseWriter, ndjson bool, jsonOpts JSONOptions, opts []StreamOption) *StreamEncoder {
+	config := streamConfig{flushItems: defaultStreamFlushItems, flushBytes: defaultStreamFlushBytes}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return &StreamEncoder{ctx: ctx, writer: w, config: config, ndjson: ndjson, json: jsonOpts}
+}
+
+// Encode 写入一个元素，请求被取消后返回上下文错误，调用方应停止生产数据
+func (e *StreamEncoder) Encode(v interface{}) error {
+	if err := e.ctx.Err(); err != nil {
+		return err
+	}
+
+	if !e.ndjson && e.count > 0 {
+		e.buf.WriteByte(',')
+	}
+	if err := e.write(v); err != nil {
+		return err
+	}
+	e.count++
+	e.pending++
+
+	if e.pending >= e.config.flushItems || e.buf.Len() >= e.config.flushBytes {
+		return e.Flush()
+	}
+	return nil
+}
+
+// write 按JSON编码配置写入一个值，每个值后追加换行，数组模式下换行是合法的空白
+func (e *StreamEncoder) write(v interface{}) error {
+	data, err := e.json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.buf.Write(data)
+	e.buf.WriteByte('\n')
+	return nil
+}
+
+// Count 返回已写入的元素数量
+func (e *StreamEncoder) Count() int {
+	return e.count
+}
+
+// Flush 将缓冲写入响应并刷新到客户端
+func (e *StreamEncoder) Flush() error {
+	if e.buf.Len() > 0 {
+		if _, err := e.writer.Write(e.buf.Bytes()); err != nil {
+			e.buf.Reset()
+			return err
+		}
+		e.buf.Reset()
+	}
+	e.pending = 0
+	if flusher, ok := e.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// writeRaw 写入不计入元素的原始内容
+func (e *StreamEncoder) writeRaw(s string) {
+	e.buf.WriteString(s)
+}
+
+// prepareStream 设置流式响应头
+func (c *Context) prepareStream(status int, contentType string) {
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	header.Del("Content-Length")
+	c.Status(status)
+	c.Writer.WriteHeaderNow()
+}
+
+// JSONStream 以分块传输的方式输出JSON数组，适合导出大量数据
+//
+// 生产函数通过 enc.Encode 逐个写入元素，编码器负责逗号分隔与按数量/字节刷新。
+// 客户端断开时 Encode 返回上下文错误，数组会被正常闭合；
+// 生产函数返回其他错误时记录日志并中止响应（不写入结尾的 ]），客户端将得到不完整的JSON
+func (c *Context) JSONStream(status int, produce func(enc *StreamEncoder) error, opts ...StreamOption) error {
+	c.prepareStream(status, "application/json; charset=utf-8")
+
+	enc := newStreamEncoder(c.Request.Context(), c.Writer, false, c.jsonOptions(), opts)
+	enc.writeRaw("[")
+
+	err := produce(enc)
+	if err != nil && !isStreamCanceled(c.Request.Context(), err) {
+		_ = enc.Flush()
+		flog.Errorf("JSON流输出中止 %s %s: 已写入 %d 个元素: %v", c.Request.Method, c.Request.URL.Path, enc.Count(), err)
+		_ = c.Error(err)
+		c.Abort()
+		return err
+	}
+
+	enc.writeRaw("]")
+	if flushErr := enc.Flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// NDJSON 输出换行分隔的JSON（application/x-ndjson），适合数据管道消费
+//
+// 生产函数返回错误时会追加一行 {"error": "..."} 作为结尾，
+// 消费方据此区分正常结束与中途失败；客户端断开时直接结束
+func (c *Context) NDJSON(status int, produce func(enc *StreamEncoder) error, opts ...StreamOption) error {
+	c.prepareStream(status, "application/x-ndjson")
+
+	enc := newStreamEncoder(c.Request.Context(), c.Writer, true, c.jsonOptions(), opts)
+
+	err := produce(enc)
+	if err != nil && !isStreamCanceled(c.Request.Context(), err) {
+		flog.Errorf("NDJSON流输出失败 %s %s: 已写入 %d 个元素: %v", c.Request.Method, c.Request.URL.Path, enc.Count(), err)
+		_ = c.Error(err)
+		_ = enc.write(map[string]string{"error": err.Error()})
+	}
+
+	if flushErr := enc.Flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// isStreamCanceled 判断错误是否由请求取消导致
+func isStreamCanceled(ctx context.Context, err error) bool {
+	return ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+}
+
+// StreamRows 逐行扫描查询结果并写入流，内存中只保留当前行
+//
+//	return c.NDJSON(http.StatusOK, func(enc *flow.StreamEncoder) error {
+//		return flow.StreamRows[User](enc, c.DB().Model(&User{}).Order("id"))
+//	})
+func StreamRows[T any](enc *StreamEncoder, query *gorm.DB) error {
+	rows, err := query.WithContext(enc.ctx).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item T
+		if err := query.ScanRows(rows, &item); err != nil {
+			return err
+		}
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// StreamInBatches 使用 FindInBatches 分批查询并写入流，适合需要钩子或预加载的模型
+func StreamInBatches[T any](enc *StreamEncoder, query *gorm.DB, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var batch []T
+	result := query.WithContext(enc.ctx).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		for _, item := range batch {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result.Error
+}
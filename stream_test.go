@@ -0,0 +1,169 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// discardWriter 丢弃响应体，只统计字节数与刷新次数
+type discardWriter struct {
+	header  http.Header
+	status  int
+	written int
+	flushes int
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) WriteHeader(status int)      { w.status = status }
+func (w *discardWriter) Flush()                      { w.flushes++ }
+func (w *discardWriter) Write(p []byte) (int, error) { w.written += len(p); return len(p), nil }
+
+type streamItem struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func newStreamTestContext(ctx context.Context, w http.ResponseWriter) *Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/export", nil).WithContext(ctx)
+	return &Context{Context: c}
+}
+
+func TestJSONStream_MemoryCeiling(t *testing.T) {
+	w := &discardWriter{header: make(http.Header)}
+	c := newStreamTestContext(context.Background(), w)
+
+	const total = 200000
+	var base, peak uint64
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	base = stats.HeapAlloc
+
+	err := c.JSONStream(http.StatusOK, func(enc *StreamEncoder) error {
+		for i := 0; i < total; i++ {
+			item := streamItem{ID: i, Name: "user", Email: "user@example.com"}
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+			if i%20000 == 0 {
+				runtime.GC()
+				runtime.ReadMemStats(&stats)
+				if stats.HeapAlloc > peak {
+					peak = stats.HeapAlloc
+				}
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// 整体输出约10MB，流式输出时堆增长应远小于输出大小
+	assert.Greater(t, w.written, 10*1024*1024)
+	if peak > base {
+		assert.Less(t, peak-base, uint64(2*1024*1024), "流式输出不应在内存中累积数据")
+	}
+	assert.Greater(t, w.flushes, total/defaultStreamFlushItems-1)
+	assert.Equal(t, "application/json; charset=utf-8", w.header.Get("Content-Type"))
+}
+
+func TestJSONStream_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	c := newStreamTestContext(ctx, w)
+
+	produced := 0
+	err := c.JSONStream(http.StatusOK, func(enc *StreamEncoder) error {
+		for {
+			if produced == 50 {
+				cancel()
+			}
+			if err := enc.Encode(produced); err != nil {
+				return err
+			}
+			produced++
+		}
+	}, WithStreamFlushItems(10))
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 50, produced, "取消后应停止生产")
+
+	// 数组被正常闭合
+	var items []int
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	assert.Len(t, items, 50)
+}
+
+func TestJSONStream_ErrorAbortsArray(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newStreamTestContext(context.Background(), w)
+
+	err := c.JSONStream(http.StatusOK, func(enc *StreamEncoder) error {
+		_ = enc.Encode(1)
+		return errors.New("数据库连接断开")
+	})
+	require.Error(t, err)
+	assert.True(t, c.IsAborted())
+
+	var items []int
+	assert.Error(t, json.Unmarshal(w.Body.Bytes(), &items), "中止的数组不应是合法JSON")
+}
+
+func TestNDJSON_ErrorTrailer(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newStreamTestContext(context.Background(), w)
+
+	err := c.NDJSON(http.StatusOK, func(enc *StreamEncoder) error {
+		_ = enc.Encode(map[string]int{"id": 1})
+		_ = enc.Encode(map[string]int{"id": 2})
+		return errors.New("读取失败")
+	})
+	require.Error(t, err)
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, `{"id":1}`, lines[0])
+	assert.JSONEq(t, `{"error":"读取失败"}`, lines[2])
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+}
+
+func TestStreamRows(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&streamItem{}))
+	for i := 1; i <= 25; i++ {
+		require.NoError(t, conn.Create(&streamItem{ID: i, Name: "user", Email: "u@example.com"}).Error)
+	}
+
+	for name, produce := range map[string]func(enc *StreamEncoder) error{
+		"rows": func(enc *StreamEncoder) error {
+			return StreamRows[streamItem](enc, conn.Model(&streamItem{}).Order("id"))
+		},
+		"batches": func(enc *StreamEncoder) error {
+			return StreamInBatches[streamItem](enc, conn.Model(&streamItem{}).Order("id"), 10)
+		},
+	} {
+		w := httptest.NewRecorder()
+		c := newStreamTestContext(context.Background(), w)
+		require.NoError(t, c.JSONStream(http.StatusOK, produce), name)
+
+		var items []streamItem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items), name)
+		require.Len(t, items, 25, name)
+		assert.Equal(t, 25, items[24].ID, name)
+	}
+}
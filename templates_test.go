@@ -0,0 +1,42 @@
+package flow
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates_DefaultFuncs(t *testing.T) {
+	dir := t.TempDir()
+	page := `<article>{{ sanitize .Body "article" }}</article>` +
+		`<script>window.__STATE__ = {{ jsonInHTML .State }}</script><p>{{ shout .Title }}</p>`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "page.html"), []byte(page), 0644))
+
+	e := New(
+		WithTemplateFuncs(template.FuncMap{"shout": strings.ToUpper}),
+		WithTemplates(filepath.Join(dir, "*.html")),
+	)
+	e.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "page.html", H{
+			"Body":  `<p>hi<img src=x onerror=alert(1)></p>`,
+			"State": H{"bio": "</script>"},
+			"Title": "hello",
+		})
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `<article><p>hi<img src="x"></p></article>`)
+	assert.NotContains(t, body, "onerror")
+	assert.Contains(t, body, `{"bio":"\u003c/script\u003e"}</script>`)
+	assert.Contains(t, body, "<p>HELLO</p>")
+}
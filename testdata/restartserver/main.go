@@ -0,0 +1,34 @@
+// restartserver 平滑重启集成测试使用的服务器，版本号在构建时通过 -ldflags "-X main.version=..." 设置
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+)
+
+var version = "dev"
+
+func main() {
+	if len(os.Args) != 3 {
+		log.Fatal("用法: restartserver <地址> <PID文件>")
+	}
+	e := flow.New(
+		flow.WithMode("release"),
+		flow.WithGracefulRestart(),
+		flow.WithPIDFile(os.Args[2]),
+		flow.WithShutdownTimeout(10*time.Second),
+	)
+	e.GET("/", func(c *flow.Context) {
+		// 模拟耗时的请求，交接时仍有请求在处理
+		time.Sleep(20 * time.Millisecond)
+		c.String(http.StatusOK, version)
+	})
+	if err := e.Run(os.Args[1]); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,216 @@
+// Package timing 按层统计单个请求的耗时（数据库、缓存、业务代码），用于输出 Server-Timing 响应头
+//
+// middleware.ServerTiming 为启用的请求在 context 中放入 Collector，下层通过 context 记录耗时：
+//
+//	defer timing.Measure(ctx, "render")()
+//
+//	span := timing.Start(ctx, "search")
+//	results, err := client.Search(ctx, query)
+//	span.Stop()
+//
+// 数据库查询回调与缓存管理器自动记录 db 与 cache 两层。未启用的请求 context 中没有 Collector，
+// 所有函数只做一次 context 查找，不分配内存
+package timing
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 自动记录的层名称
+const (
+	LayerDB    = "db"
+	LayerCache = "cache"
+	LayerTotal = "total"
+)
+
+// Entry 一个层的累计耗时，同名的多次记录合并为一条
+type Entry struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Count    int           `json:"count"`
+	Desc     string        `json:"desc,omitempty"`
+}
+
+// Collector 单个请求的耗时收集器，可并发使用，nil 收集器的所有方法都是空操作
+type Collector struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New 创建耗时收集器
+func New() *Collector {
+	return &Collector{}
+}
+
+// collectorKey Collector 在 context 中的键
+type collectorKey struct{}
+
+// WithCollector 将收集器保存到 context
+func WithCollector(ctx context.Context, c *Collector) context.Context {
+	return context.WithValue(ctx, collectorKey{}, c)
+}
+
+// FromContext 读取 context 中的收集器，不存在时返回nil
+func FromContext(ctx context.Context) *Collector {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(collectorKey{}).(*Collector)
+	return c
+}
+
+// Add 累加一个层的耗时，desc 不为空时覆盖该层的描述
+func (c *Collector) Add(name string, d time.Duration, desc ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.entries {
+		if c.entries[i].Name == name {
+			c.entries[i].Duration += d
+			c.entries[i].Count++
+			if len(desc) > 0 && desc[0] != "" {
+				c.entries[i].Desc = desc[0]
+			}
+			return
+		}
+	}
+	entry := Entry{Name: name, Duration: d, Count: 1}
+	if len(desc) > 0 {
+		entry.Desc = desc[0]
+	}
+	c.entries = append(c.entries, entry)
+}
+
+// Entries 返回已记录的层，按首次记录的顺序排列
+func (c *Collector) Entries() []Entry {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Entry(nil), c.entries...)
+}
+
+// Span 进行中的计时，nil 的 Span 调用 Stop 是空操作
+type Span struct {
+	collector *Collector
+	name      string
+	start     time.Time
+}
+
+// Start 开始一个层的计时，context 中没有收集器时返回nil
+func Start(ctx context.Context, name string) *Span {
+	c := FromContext(ctx)
+	if c == nil {
+		return nil
+	}
+	return &Span{collector: c, name: name, start: time.Now()}
+}
+
+// Stop 结束计时并累加到收集器，返回本次耗时
+func (s *Span) Stop() time.Duration {
+	if s == nil {
+		return 0
+	}
+	d := time.Since(s.start)
+	s.collector.Add(s.name, d)
+	return d
+}
+
+// noop Measure 在未启用时返回的空函数
+func noop() {}
+
+// Measure 开始一个层的计时，返回结束计时的函数，通常与 defer 一起使用：
+//
+//	defer timing.Measure(ctx, "db")()
+func Measure(ctx context.Context, name string) func() {
+	span := Start(ctx, name)
+	if span == nil {
+		return noop
+	}
+	return func() { span.Stop() }
+}
+
+// Header 将耗时格式化为 W3C Server-Timing 响应头，如 db;dur=12.3, cache;dur=0.8;desc="redis"
+//
+// 超过 max 条时只保留耗时最长的 max 条（仍按记录顺序输出），total 总是保留在最后；max 小于等于0时不限制
+func Header(entries []Entry, total time.Duration, max int) string {
+	if max > 0 && len(entries) > max {
+		kept := append([]Entry(nil), entries...)
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].Duration > kept[j].Duration })
+		keep := make(map[string]bool, max)
+		for _, entry := range kept[:max] {
+			keep[entry.Name] = true
+		}
+		filtered := entries[:0:0]
+		for _, entry := range entries {
+			if keep[entry.Name] {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		if entry.Name == LayerTotal {
+			continue
+		}
+		writeMetric(&b, entry.Name, entry.Duration, entry.Desc)
+	}
+	if total > 0 {
+		writeMetric(&b, LayerTotal, total, "")
+	}
+	return b.String()
+}
+
+// writeMetric 写入一条指标，名称中的非 token 字符替换为下划线
+func writeMetric(b *strings.Builder, name string, d time.Duration, desc string) {
+	if b.Len() > 0 {
+		b.WriteString(", ")
+	}
+	b.WriteString(tokenize(name))
+	b.WriteString(";dur=")
+	b.WriteString(strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64))
+	if desc != "" {
+		b.WriteString(";desc=")
+		writeQuoted(b, desc)
+	}
+}
+
+// tokenize 将名称转换为 RFC 7230 token
+func tokenize(name string) string {
+	if name == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r < 0x80 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", r)) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// writeQuoted 写入 quoted-string，响应头只能包含 ASCII，其他字符替换为问号
+func writeQuoted(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t' || r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte('"')
+}
@@ -0,0 +1,91 @@
+package timing
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// metricPattern W3C Server-Timing 的单条指标：token 名称、dur 参数与可选的 desc
+var metricPattern = regexp.MustCompile(`^[!#$%&'*+\-.^_` + "`" + `|~0-9A-Za-z]+;dur=[0-9]+(\.[0-9]+)?(;desc="([^"\\]|\\.)*")?$`)
+
+func TestHeader_Format(t *testing.T) {
+	c := New()
+	c.Add("db", 12300*time.Microsecond)
+	c.Add("db", 700*time.Microsecond)
+	c.Add("cache", 800*time.Microsecond, `redis "primary"`)
+	c.Add("模板 render", time.Millisecond, "渲染")
+
+	entries := c.Entries()
+	assert.Equal(t, 2, entries[0].Count, "同名记录合并")
+	header := Header(entries, 20*time.Millisecond, 0)
+	assert.Equal(t, `db;dur=13, cache;dur=0.8;desc="redis \"primary\"", ___render;dur=1;desc="??", total;dur=20`, header)
+	for _, metric := range regexp.MustCompile(`, `).Split(header, -1) {
+		assert.Regexp(t, metricPattern, metric)
+	}
+}
+
+func TestHeader_Truncation(t *testing.T) {
+	entries := []Entry{
+		{Name: "a", Duration: 1 * time.Millisecond},
+		{Name: "b", Duration: 5 * time.Millisecond},
+		{Name: "c", Duration: 2 * time.Millisecond},
+		{Name: "d", Duration: 4 * time.Millisecond},
+	}
+	// 保留耗时最长的两条，仍按记录顺序输出，total 不计入条数
+	assert.Equal(t, "b;dur=5, d;dur=4, total;dur=9", Header(entries, 9*time.Millisecond, 2))
+	assert.Len(t, entries, 4, "不修改传入的切片")
+	assert.Empty(t, Header(nil, 0, 2))
+}
+
+func TestMeasure(t *testing.T) {
+	c := New()
+	ctx := WithCollector(context.Background(), c)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer Measure(ctx, "search")()
+		}()
+	}
+	wg.Wait()
+	span := Start(ctx, "render")
+	time.Sleep(time.Millisecond)
+	assert.GreaterOrEqual(t, span.Stop(), time.Millisecond)
+
+	entries := c.Entries()
+	assert.Equal(t, "search", entries[0].Name)
+	assert.Equal(t, 10, entries[0].Count)
+	assert.Equal(t, "render", entries[1].Name)
+
+	// 没有收集器时是空操作，不分配内存
+	ctx = context.Background()
+	assert.Nil(t, FromContext(ctx))
+	assert.Nil(t, Start(ctx, "db"))
+	assert.Zero(t, Start(ctx, "db").Stop())
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		Measure(ctx, "db")()
+	}))
+}
+
+func BenchmarkMeasure_Disabled(b *testing.B) {
+	ctx := context.WithValue(context.Background(), struct{}{}, "request")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Measure(ctx, LayerDB)()
+	}
+}
+
+func BenchmarkMeasure_Enabled(b *testing.B) {
+	ctx := WithCollector(context.Background(), New())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Measure(ctx, LayerDB)()
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	"github.com/zzliekkas/flow/v2/sanitize"
 )
 
 // 预定义正则表达式
@@ -78,6 +79,12 @@ func InitCustomRules() {
 		ErrorMessage: "{0}不能包含特殊字符",
 	})
 
+	// 注册不包含HTML标签验证规则
+	RegisterRule("no_html", Rule{
+		Validation:   validateNoHTML,
+		ErrorMessage: "{0}不能包含HTML标签",
+	})
+
 	// 注册整数范围验证规则 (参数化)
 	RegisterRule("intrange", Rule{
 		Validation: validateIntRange,
@@ -87,6 +94,11 @@ func InitCustomRules() {
 	})
 }
 
+// validateNoHTML 验证字符串不包含HTML标签、注释或文档类型声明，"a < b" 这样的文本允许
+func validateNoHTML(fl validator.FieldLevel) bool {
+	return !sanitize.HasMarkup(fl.Field().String())
+}
+
 // validateMobile 验证中国大陆手机号
 func validateMobile(fl validator.FieldLevel) bool {
 	if fl.Field().String() == "" {
@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/zzliekkas/flow/v2/cache"
+)
+
+// ReplayStore 记录已处理的事件，用于拒绝重复投递
+type ReplayStore interface {
+	// Remember 记录键，首次出现时返回true
+	Remember(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Forget 删除键，处理失败时调用以允许服务商重试
+	Forget(ctx context.Context, key string) error
+}
+
+// cacheReplayStore 基于缓存存储的防重放实现
+type cacheReplayStore struct {
+	store cache.Store
+}
+
+// NewCacheReplayStore 基于缓存存储创建防重放存储
+// 存储实现了 cache.Locker（如Redis）时使用原子的 SetNX，否则退化为计数器
+func NewCacheReplayStore(store cache.Store) ReplayStore {
+	return &cacheReplayStore{store: store}
+}
+
+// Remember 实现 ReplayStore 接口
+func (s *cacheReplayStore) Remember(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if locker, ok := s.store.(cache.Locker); ok {
+		return locker.TryLock(ctx, key, ttl)
+	}
+
+	count, err := s.store.Increment(ctx, key, 1)
+	if err != nil {
+		return false, err
+	}
+	if count != 1 {
+		return false, nil
+	}
+	// 计数器不带过期时间，首次出现时补充过期时间
+	return true, s.store.Set(ctx, key, int64(1), cache.WithExpiration(ttl))
+}
+
+// Forget 实现 ReplayStore 接口
+func (s *cacheReplayStore) Forget(ctx context.Context, key string) error {
+	if locker, ok := s.store.(cache.Locker); ok {
+		return locker.Unlock(ctx, key)
+	}
+	return s.store.Delete(ctx, key)
+}
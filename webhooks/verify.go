@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名校验相关错误
+var (
+	ErrMissingSignature = errors.New("webhooks: 缺少签名")
+	ErrInvalidSignature = errors.New("webhooks: 签名无效")
+	ErrExpired          = errors.New("webhooks: 时间戳超出允许范围")
+	ErrReplay           = errors.New("webhooks: 重复投递")
+)
+
+// Verification 签名校验结果
+type Verification struct {
+	// EventID 事件唯一标识，用于防重放，为空时使用签名值
+	EventID string
+
+	// Timestamp 签名时间，为零值时不检查时间窗口
+	Timestamp time.Time
+
+	// Signature 请求中携带的签名值
+	Signature string
+}
+
+// Verifier 签名校验策略，可为特定服务商实现
+type Verifier interface {
+	// Verify 校验请求签名，body 为原始请求体
+	Verify(r *http.Request, body []byte) (Verification, error)
+}
+
+// VerifierFunc 函数形式的校验策略
+type VerifierFunc func(r *http.Request, body []byte) (Verification, error)
+
+// Verify 实现 Verifier 接口
+func (f VerifierFunc) Verify(r *http.Request, body []byte) (Verification, error) {
+	return f(r, body)
+}
+
+// Encoding 签名编码方式
+type Encoding int
+
+const (
+	EncodingHex    Encoding = iota // 十六进制
+	EncodingBase64                 // 标准base64
+)
+
+// HMACVerifier 对原始请求体计算HMAC-SHA256的通用校验器
+type HMACVerifier struct {
+	secret          []byte
+	header          string
+	prefix          string
+	encoding        Encoding
+	timestampHeader string
+	eventIDHeader   string
+}
+
+// HMACOption HMAC校验器选项
+type HMACOption func(*HMACVerifier)
+
+// WithSignatureHeader 设置签名所在的请求头，默认 X-Signature
+func WithSignatureHeader(header string) HMACOption {
+	return func(v *HMACVerifier) {
+		v.header = header
+	}
+}
+
+// WithSignaturePrefix 设置签名值的前缀，例如 "sha256="
+func WithSignaturePrefix(prefix string) HMACOption {
+	return func(v *HMACVerifier) {
+		v.prefix = prefix
+	}
+}
+
+// WithEncoding 设置签名编码，默认十六进制
+func WithEncoding(encoding Encoding) HMACOption {
+	return func(v *HMACVerifier) {
+		v.encoding = encoding
+	}
+}
+
+// WithTimestampHeader 设置时间戳请求头（Unix秒）
+// 设置后签名内容为 "时间戳.请求体"，并启用时间窗口检查
+func WithTimestampHeader(header string) HMACOption {
+	return func(v *HMACVerifier) {
+		v.timestampHeader = header
+	}
+}
+
+// WithEventIDHeader 设置事件ID请求头，用于防重放
+func WithEventIDHeader(header string) HMACOption {
+	return func(v *HMACVerifier) {
+		v.eventIDHeader = header
+	}
+}
+
+// NewHMACVerifier 创建HMAC-SHA256校验器
+func NewHMACVerifier(secret string, opts ...HMACOption) *HMACVerifier {
+	v := &HMACVerifier{
+		secret: []byte(secret),
+		header: "X-Signature",
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Sign 计算签名值（含前缀），便于内部系统发送与测试
+func (v *HMACVerifier) Sign(body []byte, timestamp time.Time) string {
+	mac := hmac.New(sha256.New, v.secret)
+	if v.timestampHeader != "" {
+		mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	}
+	mac.Write(body)
+	sum := mac.Sum(nil)
+
+	if v.encoding == EncodingBase64 {
+		return v.prefix + base64.StdEncoding.EncodeToString(sum)
+	}
+	return v.prefix + hex.EncodeToString(sum)
+}
+
+// Verify 实现 Verifier 接口
+func (v *HMACVerifier) Verify(r *http.Request, body []byte) (Verification, error) {
+	signature := r.Header.Get(v.header)
+	if signature == "" {
+		return Verification{}, ErrMissingSignature
+	}
+
+	result := Verification{Signature: signature}
+	if v.eventIDHeader != "" {
+		result.EventID = r.Header.Get(v.eventIDHeader)
+	}
+	if v.timestampHeader != "" {
+		ts, err := parseUnix(r.Header.Get(v.timestampHeader))
+		if err != nil {
+			return Verification{}, ErrInvalidSignature
+		}
+		result.Timestamp = ts
+	}
+
+	expected := v.Sign(body, result.Timestamp)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return Verification{}, ErrInvalidSignature
+	}
+	return result, nil
+}
+
+// GitHubVerifier GitHub webhook校验器（X-Hub-Signature-256，以 X-GitHub-Delivery 防重放）
+func GitHubVerifier(secret string) *HMACVerifier {
+	return NewHMACVerifier(secret,
+		WithSignatureHeader("X-Hub-Signature-256"),
+		WithSignaturePrefix("sha256="),
+		WithEventIDHeader("X-GitHub-Delivery"),
+	)
+}
+
+// SendGridVerifier SendGrid事件webhook校验器，使用控制台提供的ECDSA公钥校验签名
+type SendGridVerifier struct {
+	publicKey *ecdsa.PublicKey
+}
+
+// NewSendGridVerifier 创建SendGrid校验器
+func NewSendGridVerifier(publicKey *ecdsa.PublicKey) *SendGridVerifier {
+	return &SendGridVerifier{publicKey: publicKey}
+}
+
+// Verify 实现 Verifier 接口，签名内容为 时间戳+请求体
+func (v *SendGridVerifier) Verify(r *http.Request, body []byte) (Verification, error) {
+	signature := r.Header.Get("X-Twilio-Email-Event-Webhook-Signature")
+	timestamp := r.Header.Get("X-Twilio-Email-Event-Webhook-Timestamp")
+	if signature == "" || timestamp == "" {
+		return Verification{}, ErrMissingSignature
+	}
+
+	ts, err := parseUnix(timestamp)
+	if err != nil {
+		return Verification{}, ErrInvalidSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return Verification{}, ErrInvalidSignature
+	}
+
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(v.publicKey, digest[:], sig) {
+		return Verification{}, ErrInvalidSignature
+	}
+	return Verification{Timestamp: ts, Signature: signature}, nil
+}
+
+// parseUnix 解析Unix秒时间戳
+func parseUnix(value string) (time.Time, error) {
+	sec, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
@@ -0,0 +1,327 @@
+// Package webhooks 提供统一的webhook接收框架：签名校验、时间窗口与防重放、同步处理或投递到队列
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/event"
+	"github.com/zzliekkas/flow/v2/middleware"
+	"github.com/zzliekkas/flow/v2/queue"
+)
+
+// EventReceived 通过校验的webhook在事件总线上的名称
+const EventReceived = "webhooks.received"
+
+// JobName 投递到队列时使用的默认任务名称
+const JobName = "webhooks.process"
+
+// ErrReplayedRequest 请求由 flow replay 重放，见 middleware.ReplayHeader
+var ErrReplayedRequest = errors.New("webhooks: 不接受重放的请求")
+
+// defaultMaxBodySize 默认的最大请求体大小
+const defaultMaxBodySize = 1 << 20
+
+// Event 一次webhook投递
+type Event struct {
+	Receiver  string      `json:"receiver"`
+	ID        string      `json:"id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Header    http.Header `json:"header"`
+	Body      []byte      `json:"body"`
+}
+
+// Bind 将请求体按JSON解析到v
+func (e *Event) Bind(v interface{}) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+// Received 通过校验的webhook事件，发布在事件总线上
+type Received struct {
+	event.BaseEvent
+	Webhook *Event
+}
+
+// Handler webhook处理函数，返回错误时服务商会收到500并重试
+type Handler func(ctx context.Context, evt *Event) error
+
+// Receiver 命名的webhook接收器
+type Receiver struct {
+	name      string
+	path      string
+	verifier  Verifier
+	tolerance time.Duration
+	replay    ReplayStore
+	window    time.Duration
+	handler   Handler
+	queue     queue.Queue
+	queueName string
+	jobName   string
+	publisher *event.Publisher
+	maxBody   int64
+	now       func() time.Time
+	// allowReplayed 接受 flow replay 重放的请求
+	allowReplayed bool
+}
+
+// Option 接收器选项
+type Option func(*Receiver)
+
+// WithPath 设置挂载路径，默认 /webhooks/<name>
+func WithPath(path string) Option {
+	return func(r *Receiver) {
+		r.path = path
+	}
+}
+
+// WithTolerance 设置签名时间戳允许的偏差，默认5分钟
+func WithTolerance(d time.Duration) Option {
+	return func(r *Receiver) {
+		r.tolerance = d
+	}
+}
+
+// WithReplayProtection 启用防重放，事件ID或签名在window内只接受一次
+func WithReplayProtection(store ReplayStore, window time.Duration) Option {
+	return func(r *Receiver) {
+		r.replay = store
+		r.window = window
+	}
+}
+
+// WithHandler 同步处理，处理成功返回200
+func WithHandler(handler Handler) Option {
+	return func(r *Receiver) {
+		r.handler = handler
+	}
+}
+
+// WithQueue 投递到队列异步处理并立即返回202，jobName为空时使用 JobName
+// 工作进程中可通过 EventFromJob 还原事件
+func WithQueue(q queue.Queue, queueName, jobName string) Option {
+	return func(r *Receiver) {
+		r.queue = q
+		r.queueName = queueName
+		r.jobName = jobName
+	}
+}
+
+// WithPublisher 设置事件发布器，通过校验的webhook会以 EventReceived 发布
+func WithPublisher(publisher *event.Publisher) Option {
+	return func(r *Receiver) {
+		r.publisher = publisher
+	}
+}
+
+// WithMaxBodySize 设置最大请求体大小，默认1MB
+func WithMaxBodySize(size int64) Option {
+	return func(r *Receiver) {
+		r.maxBody = size
+	}
+}
+
+// WithReplayedRequests 接受 flow replay 重放的请求，默认以403拒绝，避免本地调试时重复触发处理
+func WithReplayedRequests() Option {
+	return func(r *Receiver) {
+		r.allowReplayed = true
+	}
+}
+
+// WithClock 设置时钟，用于测试
+func WithClock(now func() time.Time) Option {
+	return func(r *Receiver) {
+		r.now = now
+	}
+}
+
+// NewReceiver 创建webhook接收器
+func NewReceiver(name string, verifier Verifier, opts ...Option) *Receiver {
+	r := &Receiver{
+		name:      name,
+		path:      "/webhooks/" + name,
+		verifier:  verifier,
+		tolerance: 5 * time.Minute,
+		window:    24 * time.Hour,
+		maxBody:   defaultMaxBodySize,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.jobName == "" {
+		r.jobName = JobName
+	}
+	return r
+}
+
+// Name 返回接收器名称
+func (r *Receiver) Name() string {
+	return r.name
+}
+
+// Route 在引擎上挂载接收端点
+func (r *Receiver) Route(e *flow.Engine) *flow.Route {
+	return e.POST(r.path, CaptureRawBody(r.maxBody), r.Serve)
+}
+
+// Serve 处理webhook请求，原始请求体通过 c.RawBody() 读取
+//
+// 响应状态码：401 签名缺失、无效或过期，403 重放的请求，409 重复投递，413 请求体过大，
+// 202 已投递到队列，200 同步处理成功，500 处理失败（服务商会重试）
+func (r *Receiver) Serve(c *flow.Context) {
+	if !r.allowReplayed && middleware.IsReplay(c.Request) {
+		r.reject(c, http.StatusForbidden, ErrReplayedRequest)
+		return
+	}
+
+	c.SetRawBodyLimit(r.maxBody)
+	body, err := c.RawBody()
+	if err != nil {
+		r.reject(c, bodyErrorStatus(err), err)
+		return
+	}
+
+	verification, err := r.verifier.Verify(c.Request, body)
+	if err != nil {
+		r.reject(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	if !verification.Timestamp.IsZero() && r.tolerance > 0 {
+		skew := r.now().Sub(verification.Timestamp)
+		if skew > r.tolerance || skew < -r.tolerance {
+			r.reject(c, http.StatusUnauthorized, ErrExpired)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	replayKey := ""
+	if r.replay != nil {
+		id := verification.EventID
+		if id == "" {
+			id = verification.Signature
+		}
+		replayKey = "webhooks:" + r.name + ":" + id
+		first, err := r.replay.Remember(ctx, replayKey, r.window)
+		if err != nil {
+			log.Printf("[webhooks] %s 防重放存储不可用: %v", r.name, err)
+		} else if !first {
+			r.reject(c, http.StatusConflict, ErrReplay)
+			return
+		}
+	}
+
+	evt := &Event{
+		Receiver:  r.name,
+		ID:        verification.EventID,
+		Timestamp: verification.Timestamp,
+		Header:    c.Request.Header.Clone(),
+		Body:      body,
+	}
+
+	status, err := r.process(ctx, evt)
+	if err != nil {
+		// 允许服务商重试同一事件
+		if replayKey != "" {
+			_ = r.replay.Forget(context.Background(), replayKey)
+		}
+		log.Printf("[webhooks] %s 处理失败: %v", r.name, err)
+		c.JSON(http.StatusInternalServerError, flow.H{"error": "处理失败"})
+		return
+	}
+
+	if r.publisher.Enabled(EventReceived) {
+		received := &Received{BaseEvent: *event.NewBaseEvent(EventReceived), Webhook: evt}
+		received.SetPayloadValue("receiver", evt.Receiver)
+		received.SetPayloadValue("id", evt.ID)
+		r.publisher.Publish(received)
+	}
+
+	c.JSON(status, flow.H{"status": "ok"})
+}
+
+// process 同步处理或投递到队列
+func (r *Receiver) process(ctx context.Context, evt *Event) (int, error) {
+	if r.queue != nil {
+		payload := map[string]interface{}{
+			"receiver":  evt.Receiver,
+			"id":        evt.ID,
+			"timestamp": evt.Timestamp.Unix(),
+			"body":      string(evt.Body),
+		}
+		if _, err := r.queue.Push(ctx, r.queueName, r.jobName, payload); err != nil {
+			return 0, fmt.Errorf("投递到队列失败: %w", err)
+		}
+		return http.StatusAccepted, nil
+	}
+
+	if r.handler != nil {
+		if err := r.handler(ctx, evt); err != nil {
+			return 0, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// reject 以服务商能识别的状态码拒绝请求
+func (r *Receiver) reject(c *flow.Context, status int, err error) {
+	c.AbortWithStatusJSON(status, flow.H{"error": err.Error()})
+}
+
+// EventFromJob 从队列任务还原webhook事件
+func EventFromJob(job *queue.Job) (*Event, error) {
+	body, ok := job.Payload["body"].(string)
+	if !ok {
+		return nil, errors.New("webhooks: 任务中缺少请求体")
+	}
+	evt := &Event{Body: []byte(body)}
+	evt.Receiver, _ = job.Payload["receiver"].(string)
+	evt.ID, _ = job.Payload["id"].(string)
+	switch ts := job.Payload["timestamp"].(type) {
+	case int64:
+		evt.Timestamp = time.Unix(ts, 0)
+	case float64:
+		evt.Timestamp = time.Unix(int64(ts), 0)
+	}
+	if evt.Timestamp.Unix() <= 0 {
+		evt.Timestamp = time.Time{}
+	}
+	return evt, nil
+}
+
+// CaptureRawBody 读取并保存原始请求体，随后的JSON绑定仍然可以读取请求体
+// 超过limit（<=0 时为1MB）的请求返回413
+func CaptureRawBody(limit int64) flow.HandlerFunc {
+	if limit <= 0 {
+		limit = defaultMaxBodySize
+	}
+	return func(c *flow.Context) {
+		c.SetRawBodyLimit(limit)
+		if _, err := c.RawBody(); err != nil {
+			c.AbortWithStatusJSON(bodyErrorStatus(err), flow.H{"error": err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RawBody 获取原始请求体，等同于 c.RawBody()
+func RawBody(c *flow.Context) ([]byte, bool) {
+	body, err := c.RawBody()
+	return body, err == nil
+}
+
+// bodyErrorStatus 读取请求体失败时的状态码
+func bodyErrorStatus(err error) int {
+	if errors.Is(err, flow.ErrBodyTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,223 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zzliekkas/flow/v2"
+	"github.com/zzliekkas/flow/v2/cache"
+	"github.com/zzliekkas/flow/v2/middleware"
+	"github.com/zzliekkas/flow/v2/queue"
+	"github.com/zzliekkas/flow/v2/queue/memory"
+)
+
+const testBody = `{"type":"invoice.paid","amount":42}`
+
+// newSignedRequest 创建带签名、时间戳和事件ID的请求
+func newSignedRequest(v *HMACVerifier, body, id string, ts time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/billing", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", v.Sign([]byte(body), ts))
+	req.Header.Set("X-Timestamp", strconv.FormatInt(ts.Unix(), 10))
+	req.Header.Set("X-Event-ID", id)
+	return req
+}
+
+func newTestVerifier() *HMACVerifier {
+	return NewHMACVerifier("secret", WithTimestampHeader("X-Timestamp"), WithEventIDHeader("X-Event-ID"))
+}
+
+func serve(e *flow.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestReceiver_Signatures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier := newTestVerifier()
+	now := time.Unix(1700000000, 0)
+
+	var handled []string
+	e := flow.New()
+	NewReceiver("billing", verifier,
+		WithClock(func() time.Time { return now }),
+		WithHandler(func(ctx context.Context, evt *Event) error {
+			handled = append(handled, evt.ID)
+			return nil
+		}),
+	).Route(e)
+
+	assert.Equal(t, http.StatusOK, serve(e, newSignedRequest(verifier, testBody, "evt_1", now)).Code)
+
+	// 请求体被篡改
+	req := newSignedRequest(verifier, testBody, "evt_2", now)
+	req.Body = http.NoBody
+	assert.Equal(t, http.StatusUnauthorized, serve(e, req).Code)
+
+	// 缺少签名
+	req = newSignedRequest(verifier, testBody, "evt_3", now)
+	req.Header.Del("X-Signature")
+	assert.Equal(t, http.StatusUnauthorized, serve(e, req).Code)
+
+	// 时间戳超出容忍范围
+	assert.Equal(t, http.StatusUnauthorized, serve(e, newSignedRequest(verifier, testBody, "evt_4", now.Add(-10*time.Minute))).Code)
+
+	assert.Equal(t, []string{"evt_1"}, handled)
+}
+
+func TestReceiver_ReplayProtection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier := newTestVerifier()
+	now := time.Now()
+	fail := true
+
+	e := flow.New()
+	NewReceiver("billing", verifier,
+		WithReplayProtection(NewCacheReplayStore(cache.NewMemoryStore()), time.Hour),
+		WithHandler(func(ctx context.Context, evt *Event) error {
+			if fail {
+				fail = false
+				return errors.New("下游不可用")
+			}
+			return nil
+		}),
+	).Route(e)
+
+	// 处理失败后允许服务商重试同一事件
+	assert.Equal(t, http.StatusInternalServerError, serve(e, newSignedRequest(verifier, testBody, "evt_1", now)).Code)
+	assert.Equal(t, http.StatusOK, serve(e, newSignedRequest(verifier, testBody, "evt_1", now)).Code)
+	assert.Equal(t, http.StatusConflict, serve(e, newSignedRequest(verifier, testBody, "evt_1", now)).Code)
+	assert.Equal(t, http.StatusOK, serve(e, newSignedRequest(verifier, testBody, "evt_2", now)).Code)
+}
+
+func TestReceiver_RejectsReplayedRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier := newTestVerifier()
+	now := time.Now()
+	handled := 0
+	handler := WithHandler(func(ctx context.Context, evt *Event) error {
+		handled++
+		return nil
+	})
+
+	e := flow.New()
+	NewReceiver("billing", verifier, handler).Route(e)
+	NewReceiver("billing-debug", verifier, handler, WithPath("/webhooks/billing-debug"), WithReplayedRequests()).Route(e)
+
+	// 签名有效也拒绝重放的请求
+	req := newSignedRequest(verifier, testBody, "evt_1", now)
+	req.Header.Set(middleware.ReplayHeader, "capture-1")
+	w := serve(e, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), ErrReplayedRequest.Error())
+	assert.Zero(t, handled)
+
+	req = newSignedRequest(verifier, testBody, "evt_1", now)
+	req.URL.Path = "/webhooks/billing-debug"
+	req.Header.Set(middleware.ReplayHeader, "capture-1")
+	assert.Equal(t, http.StatusOK, serve(e, req).Code)
+	assert.Equal(t, 1, handled)
+}
+
+func TestCaptureRawBody_PreservesBinding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var raw []byte
+	var payload struct {
+		Type   string `json:"type"`
+		Amount int    `json:"amount"`
+	}
+	e := flow.New()
+	e.POST("/hook", CaptureRawBody(0), func(c *flow.Context) {
+		raw, _ = RawBody(c)
+		require.NoError(t, c.ShouldBindJSON(&payload))
+		c.Status(http.StatusOK)
+	})
+	e.POST("/small", CaptureRawBody(8), func(c *flow.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(testBody))
+	req.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, http.StatusOK, serve(e, req).Code)
+	assert.Equal(t, testBody, string(raw))
+	assert.Equal(t, "invoice.paid", payload.Type)
+	assert.Equal(t, 42, payload.Amount)
+
+	req = httptest.NewRequest(http.MethodPost, "/small", strings.NewReader(testBody))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve(e, req).Code)
+}
+
+func TestReceiver_QueueHandoff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier := newTestVerifier()
+	now := time.Now()
+	q := memory.New(1)
+
+	e := flow.New()
+	NewReceiver("billing", verifier, WithQueue(q, "webhooks", "")).Route(e)
+
+	w := serve(e, newSignedRequest(verifier, testBody, "evt_1", now))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var received *Event
+	q.Register(JobName, func(ctx context.Context, job *queue.Job) error {
+		var err error
+		received, err = EventFromJob(job)
+		return err
+	})
+	require.NoError(t, q.ProcessNext(context.Background(), "webhooks"))
+
+	require.NotNil(t, received)
+	assert.Equal(t, "billing", received.Receiver)
+	assert.Equal(t, "evt_1", received.ID)
+	assert.Equal(t, now.Unix(), received.Timestamp.Unix())
+	var payload map[string]interface{}
+	require.NoError(t, received.Bind(&payload))
+	assert.Equal(t, "invoice.paid", payload["type"])
+}
+
+func TestProviderVerifiers(t *testing.T) {
+	body := []byte(testBody)
+
+	github := GitHubVerifier("secret")
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Hub-Signature-256", github.Sign(body, time.Time{}))
+	req.Header.Set("X-GitHub-Delivery", "delivery-1")
+	result, err := github.Verify(req, body)
+	require.NoError(t, err)
+	assert.Equal(t, "delivery-1", result.EventID)
+	assert.True(t, strings.HasPrefix(result.Signature, "sha256="))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	sendgrid := NewSendGridVerifier(&key.PublicKey)
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Twilio-Email-Event-Webhook-Signature", base64.StdEncoding.EncodeToString(sig))
+	req.Header.Set("X-Twilio-Email-Event-Webhook-Timestamp", timestamp)
+	_, err = sendgrid.Verify(req, body)
+	assert.NoError(t, err)
+
+	_, err = sendgrid.Verify(req, []byte(`{}`))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}